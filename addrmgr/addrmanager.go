@@ -1,5 +1,5 @@
 // Copyright (c) 2013-2014 The btcsuite developers
-// Copyright (c) 2015-2023 The Decred developers
+// Copyright (c) 2015-2024 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
@@ -39,6 +39,11 @@ type AddrManager struct {
 	// is saved to and loaded from.
 	peersFile string
 
+	// anchorsFile is the path of the file that the addresses of long-lived
+	// outbound peers are saved to on shutdown and loaded from on the next
+	// start.
+	anchorsFile string
+
 	// lookupFunc is a function provided to the address manager that is used to
 	// perform DNS lookups for a given hostname.
 	// The provided function MUST be safe for concurrent access.
@@ -53,6 +58,12 @@ type AddrManager struct {
 	// key is a random seed used to map addresses to new and tried buckets.
 	key [32]byte
 
+	// asmap is an optional map from IP addresses to the autonomous system
+	// that announces them.  When set, addresses are grouped by autonomous
+	// system instead of by address prefix.  It is only set prior to starting
+	// the address manager.
+	asmap *ASMap
+
 	// addrIndex maintains an index of all addresses known to the address
 	// manager, including both new and tried addresses.  The key is a
 	// unique string representation of the underlying network address.
@@ -114,9 +125,15 @@ type AddrManager struct {
 // serializedKnownAddress is used to represent the serializable state of a
 // known address.  It excludes convenience fields that can be derived from the
 // address manager's state.
+//
+// The address types are only needed to distinguish CJDNS addresses from IPv6
+// addresses since all other types are derived from the address strings.  They
+// were not added until serialization version 2.
 type serializedKnownAddress struct {
 	Addr        string
+	AddrType    NetAddressType
 	Src         string
+	SrcType     NetAddressType
 	Attempts    int
 	TimeStamp   int64
 	LastAttempt int64
@@ -125,9 +142,14 @@ type serializedKnownAddress struct {
 
 // serializedAddrManager is used to represent the serializable state of an
 // address manager instance.
+//
+// The ASN map hash identifies the map, if any, that was used to group the
+// addresses when placing them into the buckets.  It is empty when no map was
+// used.
 type serializedAddrManager struct {
 	Version      int
 	Key          [32]byte
+	ASMapHash    string
 	Addresses    []*serializedKnownAddress
 	NewBuckets   [newBucketCount][]string
 	TriedBuckets [triedBucketCount][]string
@@ -230,7 +252,11 @@ const (
 	getKnownAddressPercentage = 23
 
 	// serialisationVersion is the current version of the on-disk format.
-	serialisationVersion = 1
+	//
+	// Version 2 adds the types of the addresses so that addresses for
+	// networks that share the same string representation, such as CJDNS and
+	// IPv6, can be distinguished.  Version 1 is still accepted when loading.
+	serialisationVersion = 2
 )
 
 // addOrUpdateAddress is a helper function to either update an address already known
@@ -371,12 +397,12 @@ func (a *AddrManager) getOldestAddressIndex(bucket int) int {
 }
 
 // getNewBucket returns a psuedorandom new bucket index for the provided
-// addresses.
-func getNewBucket(key [32]byte, netAddr, srcAddr *NetAddress) int {
+// address and source address group keys.
+func getNewBucket(key [32]byte, netGroup, srcGroup string) int {
 	data1 := []byte{}
 	data1 = append(data1, key[:]...)
-	data1 = append(data1, []byte(netAddr.GroupKey())...)
-	data1 = append(data1, []byte(srcAddr.GroupKey())...)
+	data1 = append(data1, []byte(netGroup)...)
+	data1 = append(data1, []byte(srcGroup)...)
 	hash1 := chainhash.HashB(data1)
 	hash64 := binary.LittleEndian.Uint64(hash1)
 	hash64 %= newBucketsPerGroup
@@ -384,7 +410,7 @@ func getNewBucket(key [32]byte, netAddr, srcAddr *NetAddress) int {
 	binary.LittleEndian.PutUint64(hashbuf[:], hash64)
 	data2 := []byte{}
 	data2 = append(data2, key[:]...)
-	data2 = append(data2, srcAddr.GroupKey()...)
+	data2 = append(data2, srcGroup...)
 	data2 = append(data2, hashbuf[:]...)
 
 	hash2 := chainhash.HashB(data2)
@@ -392,8 +418,8 @@ func getNewBucket(key [32]byte, netAddr, srcAddr *NetAddress) int {
 }
 
 // getTriedBucket returns a psuedorandom tried bucket index for the provided
-// address.
-func getTriedBucket(key [32]byte, netAddr *NetAddress) int {
+// address and its group key.
+func getTriedBucket(key [32]byte, netAddr *NetAddress, netGroup string) int {
 	data1 := []byte{}
 	data1 = append(data1, key[:]...)
 	data1 = append(data1, []byte(netAddr.Key())...)
@@ -404,7 +430,7 @@ func getTriedBucket(key [32]byte, netAddr *NetAddress) int {
 	binary.LittleEndian.PutUint64(hashbuf[:], hash64)
 	data2 := []byte{}
 	data2 = append(data2, key[:]...)
-	data2 = append(data2, netAddr.GroupKey()...)
+	data2 = append(data2, netGroup...)
 	data2 = append(data2, hashbuf[:]...)
 
 	hash2 := chainhash.HashB(data2)
@@ -446,14 +472,17 @@ func (a *AddrManager) savePeers() {
 	sam := new(serializedAddrManager)
 	sam.Version = serialisationVersion
 	copy(sam.Key[:], a.key[:])
+	sam.ASMapHash = a.asmapHash()
 
 	sam.Addresses = make([]*serializedKnownAddress, len(a.addrIndex))
 	i := 0
 	for k, v := range a.addrIndex {
 		ska := new(serializedKnownAddress)
 		ska.Addr = k
+		ska.AddrType = v.na.Type
 		ska.TimeStamp = v.na.Timestamp.Unix()
 		ska.Src = v.srcAddr.Key()
+		ska.SrcType = v.srcAddr.Type
 		ska.Attempts = v.attempts
 		ska.LastAttempt = v.lastattempt.Unix()
 		ska.LastSuccess = v.lastsuccess.Unix()
@@ -542,19 +571,29 @@ func (a *AddrManager) deserializePeers(filePath string) error {
 		return fmt.Errorf("error reading %s: %w", filePath, err)
 	}
 
-	if sam.Version != serialisationVersion {
+	if sam.Version < 1 || sam.Version > serialisationVersion {
 		return fmt.Errorf("unknown version %v in serialized "+
 			"addrmanager", sam.Version)
 	}
 	copy(a.key[:], sam.Key[:])
 
+	// The buckets addresses are placed in depend on the groups they belong
+	// to, so they must be placed again when the ASN map used to group them
+	// differs from the current one.
+	rebucket := sam.ASMapHash != a.asmapHash()
+	if rebucket {
+		log.Infof("ASN map changed since the addresses were saved -- " +
+			"placing them into new buckets")
+		a.addrChanged = true
+	}
+
 	for _, v := range sam.Addresses {
-		netAddr, err := a.newAddressFromString(v.Addr)
+		netAddr, err := a.newAddressFromStringType(v.Addr, v.AddrType)
 		if err != nil {
 			return fmt.Errorf("failed to deserialize netaddress %s: %w", v.Addr,
 				err)
 		}
-		srcAddr, err := a.newAddressFromString(v.Src)
+		srcAddr, err := a.newAddressFromStringType(v.Src, v.SrcType)
 		if err != nil {
 			return fmt.Errorf("failed to deserialize netaddress %s: %w", v.Src,
 				err)
@@ -578,11 +617,22 @@ func (a *AddrManager) deserializePeers(filePath string) error {
 					"none in address list", val)
 			}
 
+			bucket := i
+			if rebucket {
+				bucket = a.getNewBucket(ka.na, ka.srcAddr)
+				if _, ok := a.addrNew[bucket][val]; ok {
+					continue
+				}
+				if len(a.addrNew[bucket]) >= newBucketSize {
+					continue
+				}
+			}
+
 			if ka.refs == 0 {
 				a.nNew++
 			}
 			ka.refs++
-			a.addrNew[i][val] = ka
+			a.addrNew[bucket][val] = ka
 		}
 	}
 	for i := range sam.TriedBuckets {
@@ -593,9 +643,36 @@ func (a *AddrManager) deserializePeers(filePath string) error {
 					"none in address list", val)
 			}
 
+			bucket := i
+			if rebucket {
+				// Move the address to a new bucket when the tried bucket
+				// it now maps to is full.
+				bucket = a.getTriedBucket(ka.na)
+				if len(a.addrTried[bucket]) >= a.triedBucketSize {
+					newBucket := a.getNewBucket(ka.na, ka.srcAddr)
+					if len(a.addrNew[newBucket]) >= newBucketSize {
+						continue
+					}
+					a.nNew++
+					ka.refs++
+					a.addrNew[newBucket][val] = ka
+					continue
+				}
+			}
+
 			ka.tried = true
 			a.nTried++
-			a.addrTried[i] = append(a.addrTried[i], ka)
+			a.addrTried[bucket] = append(a.addrTried[bucket], ka)
+		}
+	}
+
+	// Forget any addresses that no longer fit in their buckets after placing
+	// them again.
+	if rebucket {
+		for k, v := range a.addrIndex {
+			if v.refs == 0 && !v.tried {
+				delete(a.addrIndex, k)
+			}
 		}
 	}
 
@@ -746,21 +823,140 @@ func (a *AddrManager) reset() {
 	}
 	a.addrChanged = true
 	a.getNewBucket = func(netAddr, srcAddr *NetAddress) int {
-		return getNewBucket(a.key, netAddr, srcAddr)
+		return getNewBucket(a.key, a.groupKey(netAddr), a.groupKey(srcAddr))
 	}
 	a.getTriedBucket = func(netAddr *NetAddress) int {
-		return getTriedBucket(a.key, netAddr)
+		return getTriedBucket(a.key, netAddr, a.groupKey(netAddr))
+	}
+}
+
+// SetASMap sets the map used to group addresses by the autonomous system that
+// announces them for the purposes of bucket placement and outbound diversity.
+// Passing nil groups addresses by their address prefix instead, which is also
+// the default.
+//
+// It must be called prior to Start since the placement of the addresses loaded
+// from the peers file depends on it.
+//
+// This function is safe for concurrent access.
+func (a *AddrManager) SetASMap(m *ASMap) {
+	a.mtx.Lock()
+	a.asmap = m
+	a.mtx.Unlock()
+}
+
+// asmapHash returns the hash of the current ASN map as a string or an empty
+// string when there is no map.
+//
+// This function MUST be called with the address manager lock held (for reads).
+func (a *AddrManager) asmapHash() string {
+	if a.asmap == nil {
+		return ""
 	}
+	return a.asmap.Hash().String()
+}
+
+// asn returns the autonomous system number the current ASN map associates
+// with the provided address or 0 when there is no map or it does not contain
+// the address.  Only routable IPv4 and IPv6 addresses are mapped and the
+// IPv6 ranges that embed IPv4 addresses are mapped via the embedded address.
+//
+// This function MUST be called with the address manager lock held (for reads).
+func (a *AddrManager) asn(na *NetAddress) uint32 {
+	if a.asmap == nil || (na.Type != IPv4Address && na.Type != IPv6Address) {
+		return 0
+	}
+	netIP := net.IP(na.IP)
+	if !IsRoutable(netIP) {
+		return 0
+	}
+	if ip4 := linkedIPv4(netIP); ip4 != nil {
+		netIP = ip4
+	}
+	return a.asmap.ASN(netIP)
+}
+
+// groupKey returns a string representing the network group the address is
+// part of.  This is the string "as:number" where number is the autonomous
+// system number of the address when the current ASN map contains it and the
+// group key of the address itself otherwise.
+//
+// This function MUST be called with the address manager lock held (for reads).
+func (a *AddrManager) groupKey(na *NetAddress) string {
+	if asn := a.asn(na); asn != 0 {
+		return fmt.Sprintf("as:%d", asn)
+	}
+	return na.GroupKey()
+}
+
+// ASN returns the autonomous system number associated with the provided
+// address by the ASN map set via SetASMap.  It returns 0 when there is no map
+// or the map does not contain the address.
+//
+// This function is safe for concurrent access.
+func (a *AddrManager) ASN(na *NetAddress) uint32 {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	return a.asn(na)
+}
+
+// GroupKey returns a string representing the network group the address is part
+// of.  Addresses in the same group are considered to be operated by the same
+// entity, so callers should avoid making multiple outbound connections to the
+// same group.
+//
+// The group is the autonomous system that announces the address when an ASN
+// map was set via SetASMap and it contains the address.  Otherwise, it is the
+// network prefix of the address as described by NetAddress.GroupKey.
+//
+// This function is safe for concurrent access.
+func (a *AddrManager) GroupKey(na *NetAddress) string {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	return a.groupKey(na)
 }
 
 // HostToNetAddress parses and returns a network address given a hostname in a
-// supported format (IPv4, IPv6, TORv2).  If the hostname cannot be immediately
-// converted from a known address format, it will be resolved using the lookup
-// function provided to the address manager. If it cannot be resolved, an error
-// is returned.
+// supported format (IPv4, IPv6, TORv2, TORv3, I2P).  If the hostname cannot be
+// immediately converted from a known address format, it will be resolved using
+// the lookup function provided to the address manager. If it cannot be
+// resolved, an error is returned.
+//
+// Note that CJDNS addresses share the same format as IPv6 addresses and are
+// therefore treated as IPv6 addresses.
 //
 // This function is safe for concurrent access.
 func (a *AddrManager) HostToNetAddress(host string, port uint16, services wire.ServiceFlag) (*NetAddress, error) {
+	// TORv3 address is 56 char base32 + ".onion".
+	const torV3HostLen = 56 + len(onionSuffix)
+	if len(host) == torV3HostLen && strings.HasSuffix(host, onionSuffix) {
+		pubKey, err := decodeTorV3Host(host[:len(host)-len(onionSuffix)])
+		if err != nil {
+			return nil, err
+		}
+		return NewNetAddressFromParams(TORv3Address, pubKey, port, time.Now(),
+			services)
+	}
+
+	// I2P address is 52 char base32 + ".b32.i2p".
+	const i2pHostLen = 52 + len(i2pSuffix)
+	if strings.HasSuffix(host, i2pSuffix) {
+		// Names in the .b32.i2p domain are only resolvable via I2P, so
+		// reject them outright rather than attempting a lookup.
+		if len(host) != i2pHostLen {
+			return nil, fmt.Errorf("invalid i2p address %q", host)
+		}
+		hash, err := lowerBase32.DecodeString(strings.ToLower(
+			host[:len(host)-len(i2pSuffix)]))
+		if err != nil {
+			return nil, err
+		}
+		return NewNetAddressFromParams(I2PAddress, hash, port, time.Now(),
+			services)
+	}
+
 	// Tor address is 16 char base32 + ".onion"
 	var ip net.IP
 	if len(host) == 22 && host[16:] == ".onion" {
@@ -1117,10 +1313,22 @@ const (
 	// Ipv6Strong represents a connection state between two IPV6 addresses.
 	Ipv6Strong
 
-	// Private represents a connection state connect between two Tor addresses.
+	// Private represents a connection state connect between two Tor, I2P, or
+	// CJDNS addresses.
 	Private
 )
 
+// isOverlay returns whether or not the provided address belongs to a network
+// that is overlaid on top of the public internet such as Tor, I2P, and CJDNS
+// and therefore is not reachable from public IP addresses.
+func isOverlay(netAddr *NetAddress) bool {
+	switch netAddr.Type {
+	case TORv3Address, I2PAddress, CJDNSAddress:
+		return true
+	}
+	return isOnionCatTor(netAddr.IP)
+}
+
 // getReachabilityFrom returns the relative reachability of the provided local
 // address to the provided remote address.
 //
@@ -1130,8 +1338,18 @@ func getReachabilityFrom(localAddr, remoteAddr *NetAddress) NetAddressReach {
 		return Unreachable
 	}
 
-	if isOnionCatTor(remoteAddr.IP) {
-		if isOnionCatTor(localAddr.IP) {
+	switch remoteAddr.Type {
+	case I2PAddress, CJDNSAddress:
+		if localAddr.Type == remoteAddr.Type {
+			return Private
+		}
+		return Default
+	}
+
+	isRemoteTor := remoteAddr.Type == TORv3Address ||
+		isOnionCatTor(remoteAddr.IP)
+	if isRemoteTor {
+		if localAddr.Type == TORv3Address || isOnionCatTor(localAddr.IP) {
 			return Private
 		}
 
@@ -1162,10 +1380,21 @@ func getReachabilityFrom(localAddr, remoteAddr *NetAddress) NetAddressReach {
 		if localAddr.IsRoutable() && isIPv4(localAddr.IP) {
 			return Ipv4
 		}
+		// Addresses on overlay networks are not directly reachable from IP
+		// addresses, but they may still be relayed to other peers.
+		if isOverlay(localAddr) {
+			return Default
+		}
 		return Unreachable
 	}
 
 	/* ipv6 */
+	// Addresses on overlay networks are not directly reachable from IP
+	// addresses, but they may still be relayed to other peers.
+	if isOverlay(localAddr) {
+		return Default
+	}
+
 	var tunnelled bool
 	// Is our v6 tunnelled?
 	if isRFC3964(localAddr.IP) || isRFC6052(localAddr.IP) || isRFC6145(localAddr.IP) {
@@ -1220,7 +1449,7 @@ func (a *AddrManager) GetBestLocalAddress(remoteAddr *NetAddress) *NetAddress {
 
 		// Send something unroutable if nothing suitable.
 		var ip net.IP
-		if !isIPv4(remoteAddr.IP) && !isOnionCatTor(remoteAddr.IP) {
+		if !isIPv4(remoteAddr.IP) && !isOverlay(remoteAddr) {
 			ip = net.IPv6zero
 		} else {
 			ip = net.IPv4zero
@@ -1237,7 +1466,7 @@ func (a *AddrManager) GetBestLocalAddress(remoteAddr *NetAddress) *NetAddress {
 //
 // This function is safe for concurrent access.
 func (a *AddrManager) ValidatePeerNa(localAddr, remoteAddr *NetAddress) (bool, NetAddressReach) {
-	net := addressType(localAddr.IP)
+	net := localAddr.Type
 	reach := getReachabilityFrom(localAddr, remoteAddr)
 	valid := (net == IPv4Address && reach == Ipv4) || (net == IPv6Address &&
 		(reach == Ipv6Weak || reach == Ipv6Strong || reach == Teredo))
@@ -1250,6 +1479,7 @@ func (a *AddrManager) ValidatePeerNa(localAddr, remoteAddr *NetAddress) (bool, N
 func New(dataDir string, lookupFunc func(string) ([]net.IP, error)) *AddrManager {
 	am := AddrManager{
 		peersFile:       filepath.Join(dataDir, peersFilename),
+		anchorsFile:     filepath.Join(dataDir, anchorsFilename),
 		lookupFunc:      lookupFunc,
 		rand:            rand.New(rand.NewSource(time.Now().UnixNano())),
 		quit:            make(chan struct{}),
@@ -1,5 +1,5 @@
 // Copyright (c) 2013-2014 The btcsuite developers
-// Copyright (c) 2015-2023 The Decred developers
+// Copyright (c) 2015-2024 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
@@ -85,6 +85,203 @@ func TestStartStop(t *testing.T) {
 	}
 }
 
+// TestStartStopOverlay ensures the types of addresses on overlay networks are
+// preserved when the known addresses are flushed to and loaded from the peers
+// file.
+func TestStartStopOverlay(t *testing.T) {
+	dir := t.TempDir()
+	amgr := New(dir, nil)
+	amgr.Start()
+
+	// Add a Tor v3 and a CJDNS address to the address manager.
+	torV3Addr, err := amgr.HostToNetAddress("duckduckgogg42xjoc72x3sjasowoa"+
+		"rfbgcmvfimaftt6twagswzczad.onion", 9108, wire.SFNodeNetwork)
+	if err != nil {
+		t.Fatalf("unexpected error parsing tor v3 address: %v", err)
+	}
+	cjdnsAddr, err := NewNetAddressFromParams(CJDNSAddress,
+		net.ParseIP("fc32:17ea:e415:c3bf:9808:149d:b5a2:c9aa"), 9108,
+		time.Now(), wire.SFNodeNetwork)
+	if err != nil {
+		t.Fatalf("unexpected error creating cjdns address: %v", err)
+	}
+	amgr.addOrUpdateAddress(torV3Addr, torV3Addr)
+	amgr.addOrUpdateAddress(cjdnsAddr, cjdnsAddr)
+
+	// Stop the address manager to force the known addresses to be flushed
+	// to the peers file and start a new one which loads them.
+	if err := amgr.Stop(); err != nil {
+		t.Fatalf("address manager failed to stop: %v", err)
+	}
+	amgr = New(dir, nil)
+	amgr.Start()
+
+	// Ensure the known addresses retained their types.
+	for _, want := range []*NetAddress{torV3Addr, cjdnsAddr} {
+		ka := amgr.addrIndex[want.Key()]
+		if ka == nil {
+			t.Errorf("address manager does not contain %v", want)
+			continue
+		}
+		if ka.na.Type != want.Type {
+			t.Errorf("unexpected type for %v - got %v, want %v", want,
+				ka.na.Type, want.Type)
+		}
+		if ka.srcAddr.Type != want.Type {
+			t.Errorf("unexpected source type for %v - got %v, want %v",
+				want, ka.srcAddr.Type, want.Type)
+		}
+	}
+
+	if err := amgr.Stop(); err != nil {
+		t.Fatalf("address manager failed to stop: %v", err)
+	}
+}
+
+// TestASMapGrouping ensures addresses are grouped by autonomous system when an
+// ASN map is set and that the known addresses are retained when the map
+// changes between runs.
+func TestASMapGrouping(t *testing.T) {
+	asmap, err := ParseASMap(testASMap())
+	if err != nil {
+		t.Fatalf("unexpected error parsing map: %v", err)
+	}
+
+	newAddr := func(ip string) *NetAddress {
+		return NewNetAddressIPPort(net.ParseIP(ip), 9108, wire.SFNodeNetwork)
+	}
+
+	// Ensure addresses are grouped by prefix when no map is set.
+	dir := t.TempDir()
+	amgr := New(dir, nil)
+	if key := amgr.GroupKey(newAddr("1.2.3.4")); key != "1.2.0.0" {
+		t.Fatalf("unexpected group key without map -- got %q, want %q", key,
+			"1.2.0.0")
+	}
+
+	// Add addresses from multiple prefixes and flush them to the peers file
+	// without a map.
+	amgr.Start()
+	addrs := []string{"1.2.3.4", "1.3.0.1", "1.200.0.1", "8.8.8.8"}
+	for _, addr := range addrs {
+		amgr.addAddressByIP(addr, 9108)
+	}
+	if err := amgr.Stop(); err != nil {
+		t.Fatalf("address manager failed to stop: %v", err)
+	}
+
+	// Ensure addresses are grouped by autonomous system with the map set and
+	// fall back to the prefix for addresses the map does not contain.
+	amgr = New(dir, nil)
+	amgr.SetASMap(asmap)
+	tests := []struct {
+		addr    string
+		wantKey string
+		wantASN uint32
+	}{
+		{addr: "1.2.3.4", wantKey: "as:100", wantASN: 100},
+		{addr: "1.3.0.1", wantKey: "as:100", wantASN: 100},
+		{addr: "1.200.0.1", wantKey: "as:300", wantASN: 300},
+		{addr: "8.8.8.8", wantKey: "as:200", wantASN: 200},
+		{addr: "2002:102:304::1", wantKey: "as:100", wantASN: 100},
+		{addr: "2001:470::1", wantKey: "2001:470::", wantASN: 0},
+		{addr: "10.0.0.1", wantKey: "unroutable", wantASN: 0},
+	}
+	for _, test := range tests {
+		na := newAddr(test.addr)
+		if key := amgr.GroupKey(na); key != test.wantKey {
+			t.Errorf("%s: unexpected group key -- got %q, want %q",
+				test.addr, key, test.wantKey)
+		}
+		if asn := amgr.ASN(na); asn != test.wantASN {
+			t.Errorf("%s: unexpected ASN -- got %d, want %d", test.addr,
+				asn, test.wantASN)
+		}
+	}
+
+	// Ensure the addresses saved without a map are placed into buckets again
+	// and retained when loading them with the map set.
+	amgr.Start()
+	defer amgr.Stop()
+	if got := amgr.numAddresses(); got != len(addrs) {
+		t.Fatalf("unexpected number of addresses -- got %d, want %d", got,
+			len(addrs))
+	}
+	for _, addr := range addrs {
+		na := newAddr(addr)
+		ka := amgr.find(na)
+		if ka == nil {
+			t.Fatalf("address %s not found after changing map", addr)
+		}
+		bucket := amgr.getNewBucket(ka.na, ka.srcAddr)
+		if _, ok := amgr.addrNew[bucket][na.Key()]; !ok {
+			t.Fatalf("address %s not in expected new bucket %d", addr,
+				bucket)
+		}
+	}
+}
+
+// TestAnchors ensures only known addresses that previously connected
+// successfully are saved as anchors and that loading them removes the anchors
+// file.
+func TestAnchors(t *testing.T) {
+	dir := t.TempDir()
+	amgr := New(dir, nil)
+	good := NewNetAddressIPPort(net.ParseIP("1.2.3.4"), 9108, wire.SFNodeNetwork)
+	untried := NewNetAddressIPPort(net.ParseIP("5.6.7.8"), 9108, 0)
+	unknown := NewNetAddressIPPort(net.ParseIP("9.9.9.9"), 9108, 0)
+	amgr.addOrUpdateAddress(good, good)
+	amgr.addOrUpdateAddress(untried, untried)
+	if err := amgr.Good(good); err != nil {
+		t.Fatalf("unexpected error marking address good: %v", err)
+	}
+
+	// Ensure no file is written when none of the addresses qualify.
+	anchorsFile := filepath.Join(dir, anchorsFilename)
+	err := amgr.SaveAnchors([]*NetAddress{untried, unknown})
+	if err != nil {
+		t.Fatalf("unexpected error saving anchors: %v", err)
+	}
+	if _, err := os.Stat(anchorsFile); !os.IsNotExist(err) {
+		t.Fatalf("unexpected anchors file -- stat err: %v", err)
+	}
+
+	// Ensure only the address that was marked good is saved.
+	err = amgr.SaveAnchors([]*NetAddress{good, untried, unknown})
+	if err != nil {
+		t.Fatalf("unexpected error saving anchors: %v", err)
+	}
+	amgr = New(dir, nil)
+	anchors, err := amgr.LoadAnchors()
+	if err != nil {
+		t.Fatalf("unexpected error loading anchors: %v", err)
+	}
+	if len(anchors) != 1 {
+		t.Fatalf("unexpected number of anchors -- got %d, want 1",
+			len(anchors))
+	}
+	if anchors[0].Key() != good.Key() {
+		t.Fatalf("unexpected anchor -- got %s, want %s", anchors[0].Key(),
+			good.Key())
+	}
+	if anchors[0].Services != good.Services {
+		t.Fatalf("unexpected anchor services -- got %v, want %v",
+			anchors[0].Services, good.Services)
+	}
+
+	// Ensure the anchors file is removed once loaded.
+	if _, err := os.Stat(anchorsFile); !os.IsNotExist(err) {
+		t.Fatalf("anchors file not removed -- stat err: %v", err)
+	}
+	anchors, err = amgr.LoadAnchors()
+	if err != nil {
+		t.Fatalf("unexpected error loading anchors: %v", err)
+	}
+	if len(anchors) != 0 {
+		t.Fatalf("unexpected anchors after removal: %v", anchors)
+	}
+}
+
 func TestAddOrUpdateAddress(t *testing.T) {
 	amgr := New("testaddaddressupdate", nil)
 	amgr.Start()
@@ -584,6 +781,49 @@ func TestGetBestLocalAddress(t *testing.T) {
 	*/
 }
 
+// TestOverlayReachability ensures the reachability between addresses on
+// overlay networks and IP addresses is calculated as expected.
+func TestOverlayReachability(t *testing.T) {
+	mustParams := func(addrType NetAddressType, addr []byte) *NetAddress {
+		t.Helper()
+		na, err := NewNetAddressFromParams(addrType, addr, 9108, time.Now(),
+			wire.SFNodeNetwork)
+		if err != nil {
+			t.Fatalf("unexpected error creating address: %v", err)
+		}
+		return na
+	}
+	torV3Addr := mustParams(TORv3Address, make([]byte, 32))
+	i2pAddr := mustParams(I2PAddress, make([]byte, 32))
+	cjdnsAddr := mustParams(CJDNSAddress, net.ParseIP("fc32::1"))
+	ipv4Addr := NewNetAddressIPPort(net.ParseIP("204.124.8.1"), 0,
+		wire.SFNodeNetwork)
+	ipv6Addr := NewNetAddressIPPort(net.ParseIP("2602:100:abcd::102"), 0,
+		wire.SFNodeNetwork)
+
+	tests := []struct {
+		name   string
+		local  *NetAddress
+		remote *NetAddress
+		want   NetAddressReach
+	}{
+		{name: "torv3 to torv3", local: torV3Addr, remote: torV3Addr, want: Private},
+		{name: "i2p to i2p", local: i2pAddr, remote: i2pAddr, want: Private},
+		{name: "cjdns to cjdns", local: cjdnsAddr, remote: cjdnsAddr, want: Private},
+		{name: "torv3 to i2p", local: torV3Addr, remote: i2pAddr, want: Default},
+		{name: "ipv4 to torv3", local: ipv4Addr, remote: torV3Addr, want: Ipv4},
+		{name: "torv3 to ipv4", local: torV3Addr, remote: ipv4Addr, want: Default},
+		{name: "i2p to ipv6", local: i2pAddr, remote: ipv6Addr, want: Default},
+	}
+	for _, test := range tests {
+		got := getReachabilityFrom(test.local, test.remote)
+		if got != test.want {
+			t.Errorf("%q: unexpected reachability - got %v, want %v",
+				test.name, got, test.want)
+		}
+	}
+}
+
 func TestCorruptPeersFile(t *testing.T) {
 	dir := t.TempDir()
 	peersFile := filepath.Join(dir, peersFilename)
@@ -795,6 +1035,22 @@ func TestHostToNetAddress(t *testing.T) {
 		lookupFunc: nil,
 		wantErr:    true,
 		want:       nil,
+	}, {
+		name: "tor v3 onion address with invalid checksum",
+		host: "duckduckgogg42xjoc72x3sjasowoarfbgcmvfimaftt6twagswzczae" +
+			".onion",
+		port:       8333,
+		lookupFunc: nil,
+		wantErr:    true,
+		want:       nil,
+	}, {
+		name: "i2p address with invalid length",
+		host: "ukeu3k5oycgaauneqgtnvselmt4yemvoilkln7jpvamvfx7dnkd" +
+			".b32.i2p",
+		port:       0,
+		lookupFunc: nil,
+		wantErr:    true,
+		want:       nil,
 	}, {
 		name: "unresolvable host name",
 		host: hostnameForLookup,
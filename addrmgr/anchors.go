@@ -0,0 +1,123 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package addrmgr
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/decred/dcrd/wire"
+)
+
+const (
+	// anchorsFilename is the default filename to store serialized anchors.
+	anchorsFilename = "anchors.json"
+
+	// anchorsVersion is the current version of the serialized anchors.
+	anchorsVersion = 1
+)
+
+// serializedAnchor is used to represent the serializable state of an anchor
+// address.
+type serializedAnchor struct {
+	Addr     string
+	AddrType NetAddressType
+	Services wire.ServiceFlag
+}
+
+// serializedAnchors is used to represent the serializable state of the anchor
+// addresses.
+type serializedAnchors struct {
+	Version int
+	Anchors []serializedAnchor
+}
+
+// SaveAnchors saves the provided addresses of long-lived outbound peers to the
+// anchors file so they can be reconnected to first on the next start via
+// LoadAnchors.  This makes it much harder for an attacker to take advantage of
+// a restart to isolate the node since it would otherwise select all of its
+// outbound peers from scratch.
+//
+// Only the addresses that are known to the address manager and have been
+// marked good, meaning they previously completed a successful connection, are
+// saved.  No file is written when none of the addresses qualify.
+//
+// This function is safe for concurrent access.
+func (a *AddrManager) SaveAnchors(addrs []*NetAddress) error {
+	a.mtx.Lock()
+	anchors := make([]serializedAnchor, 0, len(addrs))
+	for _, na := range addrs {
+		ka := a.find(na)
+		if ka == nil || !ka.tried || ka.isBad() {
+			continue
+		}
+		anchors = append(anchors, serializedAnchor{
+			Addr:     ka.na.Key(),
+			AddrType: ka.na.Type,
+			Services: ka.na.Services,
+		})
+	}
+	a.mtx.Unlock()
+	if len(anchors) == 0 {
+		return nil
+	}
+
+	// Write temporary anchors file and then move it into place.
+	sa := serializedAnchors{Version: anchorsVersion, Anchors: anchors}
+	tmpfile := a.anchorsFile + ".new"
+	w, err := os.Create(tmpfile)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(w).Encode(&sa); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to encode file %s: %w", tmpfile, err)
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpfile, a.anchorsFile)
+}
+
+// LoadAnchors returns the addresses saved by a previous call to SaveAnchors and
+// removes the anchors file so the anchors are only ever used for a single
+// start.  This ensures stale anchors are not reused should the node not shut
+// down cleanly.  No addresses are returned when there is no anchors file.
+//
+// This function is safe for concurrent access.
+func (a *AddrManager) LoadAnchors() ([]*NetAddress, error) {
+	data, err := os.ReadFile(a.anchorsFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Remove(a.anchorsFile); err != nil {
+		return nil, err
+	}
+
+	var sa serializedAnchors
+	if err := json.Unmarshal(data, &sa); err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", a.anchorsFile, err)
+	}
+	if sa.Version != anchorsVersion {
+		return nil, fmt.Errorf("unknown version %v in serialized anchors",
+			sa.Version)
+	}
+
+	addrs := make([]*NetAddress, 0, len(sa.Anchors))
+	for _, anchor := range sa.Anchors {
+		na, err := a.newAddressFromStringType(anchor.Addr, anchor.AddrType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to deserialize anchor %s: %w",
+				anchor.Addr, err)
+		}
+		na.Services = anchor.Services
+		addrs = append(addrs, na)
+	}
+	return addrs, nil
+}
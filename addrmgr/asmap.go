@@ -0,0 +1,328 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package addrmgr
+
+import (
+	"fmt"
+	"math/bits"
+	"net"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+// asmapInvalid is the value returned when decoding a field of an ASN map fails
+// due to reaching the end of the map.
+const asmapInvalid = 0xffffffff
+
+// asmapOpcode describes the instructions that make up an encoded ASN map.
+type asmapOpcode uint32
+
+const (
+	// asmapReturn returns the ASN that immediately follows the instruction.
+	asmapReturn asmapOpcode = 0
+
+	// asmapJump consumes a single bit of the IP address and skips the number
+	// of bits in the map that immediately follows the instruction when it is
+	// set.
+	asmapJump asmapOpcode = 1
+
+	// asmapMatch compares the bits that immediately follow the instruction
+	// against the next bits of the IP address and returns the current default
+	// ASN when they differ.
+	asmapMatch asmapOpcode = 2
+
+	// asmapDefault sets the ASN that is returned by a subsequent failed
+	// match to the ASN that immediately follows the instruction.
+	asmapDefault asmapOpcode = 3
+)
+
+var (
+	// asmapTypeBitSizes, asmapASNBitSizes, asmapMatchBitSizes, and
+	// asmapJumpBitSizes are the mantissa sizes of the variable length
+	// encodings of the respective fields of an ASN map.
+	asmapTypeBitSizes  = []uint8{0, 0, 1}
+	asmapASNBitSizes   = []uint8{15, 16, 17, 18, 19, 20, 21, 22, 23, 24}
+	asmapMatchBitSizes = []uint8{1, 2, 3, 4, 5, 6, 7, 8}
+	asmapJumpBitSizes  = []uint8{5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16,
+		17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30}
+)
+
+// ASMap is a compact map from IP addresses to the autonomous system number
+// (ASN) of the network that announces them.
+//
+// The map is a program made up of a series of variable length instructions that
+// walks the bits of an IPv6 address in the form of a binary trie.  IPv4
+// addresses are mapped into the IPv4-mapped IPv6 address space.  This is the
+// same format produced by the asmap tooling in the wider ecosystem so that
+// existing maps can be used as is.
+type ASMap struct {
+	data    []byte
+	numBits int
+	hash    chainhash.Hash
+}
+
+// bit returns whether or not the bit at the provided position of the map is
+// set.  The bits of each byte are ordered from least to most significant.
+func (m *ASMap) bit(pos int) bool {
+	return m.data[pos>>3]>>(pos&7)&1 == 1
+}
+
+// decodeBits decodes a variable length field that starts at the provided
+// position and advances the position past it.  Each field is encoded as a
+// unary exponent that selects one of the provided mantissa sizes followed by the
+// mantissa itself.  It returns asmapInvalid if the field straddles the end of
+// the map.
+func (m *ASMap) decodeBits(pos *int, minVal uint32, bitSizes []uint8) uint32 {
+	val := minVal
+	for i, bitSize := range bitSizes {
+		var bit bool
+		if i+1 != len(bitSizes) {
+			if *pos == m.numBits {
+				break
+			}
+			bit = m.bit(*pos)
+			*pos++
+		}
+		if bit {
+			val += 1 << bitSize
+			continue
+		}
+		for b := uint8(0); b < bitSize; b++ {
+			if *pos == m.numBits {
+				return asmapInvalid
+			}
+			if m.bit(*pos) {
+				val += 1 << (bitSize - 1 - b)
+			}
+			*pos++
+		}
+		return val
+	}
+	return asmapInvalid
+}
+
+// decodeOpcode decodes an instruction type from the map.
+func (m *ASMap) decodeOpcode(pos *int) asmapOpcode {
+	return asmapOpcode(m.decodeBits(pos, 0, asmapTypeBitSizes))
+}
+
+// decodeASN decodes an ASN from the map.
+func (m *ASMap) decodeASN(pos *int) uint32 {
+	return m.decodeBits(pos, 1, asmapASNBitSizes)
+}
+
+// decodeMatch decodes the bits to compare for a match instruction from the
+// map.  The most significant set bit marks the length of the bits to compare.
+func (m *ASMap) decodeMatch(pos *int) uint32 {
+	return m.decodeBits(pos, 2, asmapMatchBitSizes)
+}
+
+// decodeJump decodes the number of bits to skip for a jump instruction from the
+// map.
+func (m *ASMap) decodeJump(pos *int) uint32 {
+	return m.decodeBits(pos, 17, asmapJumpBitSizes)
+}
+
+// ipBit returns whether or not the bit at the provided position of the IPv6
+// address is set.  The bits of each byte are ordered from most to least
+// significant.
+func ipBit(ip net.IP, pos int) bool {
+	return ip[pos>>3]>>(7-pos&7)&1 == 1
+}
+
+// validate ensures the map is well formed such that every possible IP address
+// reaches a return instruction without running past the end of the map and
+// without consuming more bits than an IPv6 address has.
+func (m *ASMap) validate() error {
+	type jumpTarget struct {
+		offset int
+		bits   int
+	}
+
+	pos := 0
+	ipBits := net.IPv6len * 8
+	var jumps []jumpTarget
+	prevOpcode := asmapJump
+	hadIncompleteMatch := false
+	for pos != m.numBits {
+		if len(jumps) > 0 && pos >= jumps[len(jumps)-1].offset {
+			return fmt.Errorf("jump into the middle of the instruction at "+
+				"bit %d", pos)
+		}
+
+		switch opcode := m.decodeOpcode(&pos); opcode {
+		case asmapReturn:
+			if prevOpcode == asmapDefault {
+				return fmt.Errorf("return immediately after default at "+
+					"bit %d", pos)
+			}
+			if m.decodeASN(&pos) == asmapInvalid {
+				return fmt.Errorf("return ASN straddles end of map")
+			}
+			if len(jumps) == 0 {
+				// Nothing else is reachable, so only zero padding to the
+				// next byte boundary may follow.
+				if m.numBits-pos > 7 {
+					return fmt.Errorf("excessive padding at end of map")
+				}
+				for ; pos != m.numBits; pos++ {
+					if m.bit(pos) {
+						return fmt.Errorf("nonzero padding bit at end of map")
+					}
+				}
+				return nil
+			}
+
+			// Continue with the target of the most recent jump.
+			target := jumps[len(jumps)-1]
+			if pos != target.offset {
+				return fmt.Errorf("unreachable instructions at bit %d", pos)
+			}
+			ipBits = target.bits
+			jumps = jumps[:len(jumps)-1]
+			prevOpcode = asmapJump
+
+		case asmapJump:
+			jump := m.decodeJump(&pos)
+			if jump == asmapInvalid {
+				return fmt.Errorf("jump offset straddles end of map")
+			}
+			if int64(jump) > int64(m.numBits-pos) {
+				return fmt.Errorf("jump past end of map at bit %d", pos)
+			}
+			if ipBits == 0 {
+				return fmt.Errorf("jump consumes more bits than an IP " +
+					"address has")
+			}
+			ipBits--
+			offset := pos + int(jump)
+			if len(jumps) > 0 && offset >= jumps[len(jumps)-1].offset {
+				return fmt.Errorf("intersecting jumps at bit %d", pos)
+			}
+			jumps = append(jumps, jumpTarget{offset: offset, bits: ipBits})
+			prevOpcode = asmapJump
+
+		case asmapMatch:
+			match := m.decodeMatch(&pos)
+			if match == asmapInvalid {
+				return fmt.Errorf("match bits straddle end of map")
+			}
+			matchLen := bits.Len32(match) - 1
+			if prevOpcode != asmapMatch {
+				hadIncompleteMatch = false
+			}
+			if matchLen < 8 && hadIncompleteMatch {
+				return fmt.Errorf("multiple incomplete matches in sequence "+
+					"at bit %d", pos)
+			}
+			hadIncompleteMatch = matchLen < 8
+			if ipBits < matchLen {
+				return fmt.Errorf("match consumes more bits than an IP " +
+					"address has")
+			}
+			ipBits -= matchLen
+			prevOpcode = asmapMatch
+
+		case asmapDefault:
+			if prevOpcode == asmapDefault {
+				return fmt.Errorf("successive defaults at bit %d", pos)
+			}
+			if m.decodeASN(&pos) == asmapInvalid {
+				return fmt.Errorf("default ASN straddles end of map")
+			}
+			prevOpcode = asmapDefault
+
+		default:
+			return fmt.Errorf("instruction straddles end of map")
+		}
+	}
+
+	return fmt.Errorf("end of map reached without a return instruction")
+}
+
+// ParseASMap parses the provided serialized ASN map and ensures it is well
+// formed.  An error of kind ErrInvalidASMap is returned when it is not.
+func ParseASMap(data []byte) (*ASMap, error) {
+	m := &ASMap{
+		data:    data,
+		numBits: len(data) * 8,
+		hash:    chainhash.HashH(data),
+	}
+	if err := m.validate(); err != nil {
+		str := fmt.Sprintf("malformed ASN map: %v", err)
+		return nil, makeError(ErrInvalidASMap, str)
+	}
+	return m, nil
+}
+
+// Hash returns the hash of the serialized map.  It is used to detect when the
+// map used to place addresses into buckets changes.
+func (m *ASMap) Hash() chainhash.Hash {
+	return m.hash
+}
+
+// ASN returns the autonomous system number the map associates with the provided
+// IP address.  IPv4 addresses are looked up via their IPv4-mapped IPv6 form.  A
+// value of 0 is returned when the map does not contain the address.
+func (m *ASMap) ASN(netIP net.IP) uint32 {
+	ip := netIP.To16()
+	if ip == nil {
+		return 0
+	}
+
+	pos := 0
+	ipBits := net.IPv6len * 8
+	var defaultASN uint32
+	for pos != m.numBits {
+		switch opcode := m.decodeOpcode(&pos); opcode {
+		case asmapReturn:
+			asn := m.decodeASN(&pos)
+			if asn == asmapInvalid {
+				return 0
+			}
+			return asn
+
+		case asmapJump:
+			jump := m.decodeJump(&pos)
+			if jump == asmapInvalid || ipBits == 0 ||
+				int64(jump) >= int64(m.numBits-pos) {
+
+				return 0
+			}
+			if ipBit(ip, len(ip)*8-ipBits) {
+				pos += int(jump)
+			}
+			ipBits--
+
+		case asmapMatch:
+			match := m.decodeMatch(&pos)
+			if match == asmapInvalid {
+				return 0
+			}
+			matchLen := bits.Len32(match) - 1
+			if ipBits < matchLen {
+				return 0
+			}
+			for i := 0; i < matchLen; i++ {
+				want := match>>(matchLen-1-i)&1 == 1
+				if ipBit(ip, len(ip)*8-ipBits) != want {
+					return defaultASN
+				}
+				ipBits--
+			}
+
+		case asmapDefault:
+			defaultASN = m.decodeASN(&pos)
+			if defaultASN == asmapInvalid {
+				return 0
+			}
+
+		default:
+			return 0
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package addrmgr
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+// asmapBuilder is used to construct ASN maps for the tests by appending
+// encoded instructions.
+type asmapBuilder struct {
+	bits []bool
+}
+
+// encodeBits appends the variable length encoding of the provided value using
+// the provided minimum value and mantissa sizes.
+func (b *asmapBuilder) encodeBits(val, minVal uint32, bitSizes []uint8) {
+	val -= minVal
+	for i, bitSize := range bitSizes {
+		if i+1 != len(bitSizes) {
+			if val >= 1<<bitSize {
+				b.bits = append(b.bits, true)
+				val -= 1 << bitSize
+				continue
+			}
+			b.bits = append(b.bits, false)
+		}
+		for bit := int(bitSize) - 1; bit >= 0; bit-- {
+			b.bits = append(b.bits, val>>bit&1 == 1)
+		}
+		return
+	}
+}
+
+// ret appends a return instruction for the provided ASN.
+func (b *asmapBuilder) ret(asn uint32) *asmapBuilder {
+	b.encodeBits(uint32(asmapReturn), 0, asmapTypeBitSizes)
+	b.encodeBits(asn, 1, asmapASNBitSizes)
+	return b
+}
+
+// def appends a default instruction for the provided ASN.
+func (b *asmapBuilder) def(asn uint32) *asmapBuilder {
+	b.encodeBits(uint32(asmapDefault), 0, asmapTypeBitSizes)
+	b.encodeBits(asn, 1, asmapASNBitSizes)
+	return b
+}
+
+// match appends match instructions for the provided bytes.
+func (b *asmapBuilder) match(data ...byte) *asmapBuilder {
+	for _, v := range data {
+		b.encodeBits(uint32(asmapMatch), 0, asmapTypeBitSizes)
+		b.encodeBits(1<<8|uint32(v), 2, asmapMatchBitSizes)
+	}
+	return b
+}
+
+// jump appends a jump instruction that chooses between the provided programs
+// depending on the next bit of the IP address.
+func (b *asmapBuilder) jump(zero, one *asmapBuilder) *asmapBuilder {
+	b.encodeBits(uint32(asmapJump), 0, asmapTypeBitSizes)
+	b.encodeBits(uint32(len(zero.bits)), 17, asmapJumpBitSizes)
+	b.bits = append(b.bits, zero.bits...)
+	b.bits = append(b.bits, one.bits...)
+	return b
+}
+
+// bytes returns the serialized map.
+func (b *asmapBuilder) bytes() []byte {
+	data := make([]byte, (len(b.bits)+7)/8)
+	for i, bit := range b.bits {
+		if bit {
+			data[i/8] |= 1 << (i % 8)
+		}
+	}
+	return data
+}
+
+// testASMap returns a serialized ASN map that associates 1.0.0.0/9 with ASN
+// 100, 1.128.0.0/9 with ASN 300, the rest of the IPv4 address space with ASN 200
+// and does not contain any other addresses.
+func testASMap() []byte {
+	ipv4MappedPrefix := []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0xff, 0xff}
+	b := new(asmapBuilder).match(ipv4MappedPrefix...).def(200).match(1)
+	b.jump(new(asmapBuilder).ret(100), new(asmapBuilder).ret(300))
+	return b.bytes()
+}
+
+// TestASMap ensures ASN maps are parsed and looked up as expected.
+func TestASMap(t *testing.T) {
+	asmap, err := ParseASMap(testASMap())
+	if err != nil {
+		t.Fatalf("unexpected error parsing map: %v", err)
+	}
+
+	tests := []struct {
+		ip   string
+		want uint32
+	}{
+		{ip: "1.2.3.4", want: 100},
+		{ip: "1.127.255.255", want: 100},
+		{ip: "1.128.0.0", want: 300},
+		{ip: "1.200.0.1", want: 300},
+		{ip: "8.8.8.8", want: 200},
+		{ip: "::ffff:1.2.3.4", want: 100},
+		{ip: "2001:470::1", want: 0},
+	}
+	for _, test := range tests {
+		asn := asmap.ASN(net.ParseIP(test.ip))
+		if asn != test.want {
+			t.Errorf("%s: unexpected ASN -- got %d, want %d", test.ip, asn,
+				test.want)
+		}
+	}
+}
+
+// TestParseASMapErrors ensures malformed ASN maps are rejected.
+func TestParseASMapErrors(t *testing.T) {
+	valid := testASMap()
+
+	// Create a map with a jump past its end.
+	jumpPastEnd := new(asmapBuilder)
+	jumpPastEnd.encodeBits(uint32(asmapJump), 0, asmapTypeBitSizes)
+	jumpPastEnd.encodeBits(1000, 17, asmapJumpBitSizes)
+	jumpPastEnd.ret(1)
+
+	// Create a map with more matched bits than an IP address has.
+	tooManyBits := new(asmapBuilder).match(make([]byte, 17)...).ret(1)
+
+	tests := []struct {
+		name string
+		data []byte
+	}{{
+		name: "empty",
+		data: nil,
+	}, {
+		name: "truncated",
+		data: valid[:len(valid)-1],
+	}, {
+		name: "excessive padding",
+		data: append(append([]byte(nil), valid...), 0),
+	}, {
+		name: "no return",
+		data: new(asmapBuilder).match(1).def(1).bytes(),
+	}, {
+		name: "return after default",
+		data: new(asmapBuilder).def(1).ret(2).bytes(),
+	}, {
+		name: "jump past end",
+		data: jumpPastEnd.bytes(),
+	}, {
+		name: "too many bits",
+		data: tooManyBits.bytes(),
+	}}
+	for _, test := range tests {
+		_, err := ParseASMap(test.data)
+		if !errors.Is(err, ErrInvalidASMap) {
+			t.Errorf("%s: unexpected error -- got %v, want %v", test.name,
+				err, ErrInvalidASMap)
+		}
+	}
+}
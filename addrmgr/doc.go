@@ -1,5 +1,5 @@
 // Copyright (c) 2014 The btcsuite developers
-// Copyright (c) 2015-2022 The Decred developers
+// Copyright (c) 2015-2024 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
@@ -29,6 +29,14 @@ generally helps provide greater peer diversity, and perhaps more importantly,
 drastically reduces the chances an attacker is able to coerce your peer into
 only connecting to nodes they control.
 
+By default, the groups are based on the network prefixes of the addresses.
+Since an attacker might control many prefixes that are all announced by the
+same autonomous system, the caller may optionally provide a map from IP
+addresses to autonomous system numbers via SetASMap in which case addresses are
+grouped by the autonomous system that announces them instead.  The GroupKey
+method exposes the resulting groups so callers can avoid making multiple
+outbound connections to the same group.
+
 The address manager also understands routability and Tor addresses and tries
 hard to only return routable addresses.  In addition, it uses the information
 provided by the caller about connected, known good, and attempted addresses to
@@ -1,4 +1,4 @@
-// Copyright (c) 2021 The Decred developers
+// Copyright (c) 2021-2024 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
@@ -14,6 +14,9 @@ const (
 	// ErrAddressNotFound indicates that an operation in the address manager
 	// failed due to an address lookup failure.
 	ErrAddressNotFound = ErrorKind("ErrAddressNotFound")
+
+	// ErrInvalidASMap indicates that a serialized ASN map is malformed.
+	ErrInvalidASMap = ErrorKind("ErrInvalidASMap")
 )
 
 // Error satisfies the error interface and prints human-readable errors.
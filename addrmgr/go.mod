@@ -6,10 +6,12 @@ require (
 	github.com/decred/dcrd/chaincfg/chainhash v1.0.4
 	github.com/decred/dcrd/wire v1.6.0
 	github.com/decred/slog v1.2.0
+	golang.org/x/crypto v0.6.0
 )
 
 require (
 	github.com/decred/dcrd/crypto/blake256 v1.0.1 // indirect
 	github.com/klauspost/cpuid/v2 v2.0.9 // indirect
+	golang.org/x/sys v0.5.0 // indirect
 	lukechampine.com/blake3 v1.2.1 // indirect
 )
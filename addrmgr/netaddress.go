@@ -1,24 +1,70 @@
-// Copyright (c) 2021 The Decred developers
+// Copyright (c) 2021-2024 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
 package addrmgr
 
 import (
+	"bytes"
 	"encoding/base32"
+	"fmt"
 	"net"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/decred/dcrd/wire"
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	// torV3AddrSize is the size of a Tor v3 onion service address which is
+	// the ed25519 public key of the service.
+	torV3AddrSize = 32
+
+	// torV3Version is the version byte that is encoded in Tor v3 onion
+	// service host names.
+	torV3Version = 0x03
+
+	// torV3ChecksumSize is the size of the checksum that is encoded in Tor v3
+	// onion service host names.
+	torV3ChecksumSize = 2
+
+	// i2pAddrSize is the size of an I2P address which is the SHA256 hash of
+	// the destination.
+	i2pAddrSize = 32
+
+	// cjdnsAddrSize is the size of a CJDNS address.
+	cjdnsAddrSize = 16
+
+	// onionSuffix is the suffix of Tor onion service host names.
+	onionSuffix = ".onion"
+
+	// i2pSuffix is the suffix of I2P host names.
+	i2pSuffix = ".b32.i2p"
+)
+
+var (
+	// torV3ChecksumPrefix is the constant prefix of the data that is hashed
+	// to produce the checksum encoded in Tor v3 onion service host names.
+	torV3ChecksumPrefix = []byte(".onion checksum")
+
+	// lowerBase32 is the unpadded lowercase base32 encoding used by the host
+	// names of Tor v3 onion services and I2P addresses.
+	lowerBase32 = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").
+			WithPadding(base32.NoPadding)
 )
 
 // NetAddress defines information about a peer on the network.
 type NetAddress struct {
+	// Type is the type of the network address.
+	Type NetAddressType
+
 	// IP address of the peer. It is defined as a byte array to support various
 	// address types that are not standard to the net module and therefore not
-	// entirely appropriate to store as a net.IP.
+	// entirely appropriate to store as a net.IP.  For Tor v3 and I2P
+	// addresses, this is the 32-byte public key and destination hash,
+	// respectively.
 	IP []byte
 
 	// Port is the port of the remote peer.
@@ -34,14 +80,76 @@ type NetAddress struct {
 // IsRoutable returns a boolean indicating whether the network address is
 // routable.
 func (netAddr *NetAddress) IsRoutable() bool {
+	switch netAddr.Type {
+	case TORv3Address, I2PAddress, CJDNSAddress:
+		return true
+	}
 	return IsRoutable(netAddr.IP)
 }
 
+// torV3Checksum returns the checksum encoded in the host name of the Tor v3
+// onion service with the provided public key.
+func torV3Checksum(pubKey []byte) [torV3ChecksumSize]byte {
+	h := sha3.New256()
+	h.Write(torV3ChecksumPrefix)
+	h.Write(pubKey)
+	h.Write([]byte{torV3Version})
+	var checksum [torV3ChecksumSize]byte
+	copy(checksum[:], h.Sum(nil))
+	return checksum
+}
+
+// encodeTorV3Host returns the host name of the Tor v3 onion service with the
+// provided public key.
+func encodeTorV3Host(pubKey []byte) string {
+	checksum := torV3Checksum(pubKey)
+	data := make([]byte, 0, torV3AddrSize+torV3ChecksumSize+1)
+	data = append(data, pubKey...)
+	data = append(data, checksum[:]...)
+	data = append(data, torV3Version)
+	return lowerBase32.EncodeToString(data) + onionSuffix
+}
+
+// decodeTorV3Host returns the public key of the Tor v3 onion service with the
+// provided host name without the .onion suffix.  An error is returned when the
+// host name is not a valid Tor v3 onion service host name.
+func decodeTorV3Host(host string) ([]byte, error) {
+	data, err := lowerBase32.DecodeString(strings.ToLower(host))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != torV3AddrSize+torV3ChecksumSize+1 {
+		return nil, fmt.Errorf("tor v3 host %s decodes to %d bytes instead "+
+			"of the required %d bytes", host, len(data),
+			torV3AddrSize+torV3ChecksumSize+1)
+	}
+	pubKey := data[:torV3AddrSize]
+	checksum := data[torV3AddrSize : torV3AddrSize+torV3ChecksumSize]
+	version := data[torV3AddrSize+torV3ChecksumSize]
+	if version != torV3Version {
+		return nil, fmt.Errorf("tor v3 host %s has unsupported version %d",
+			host, version)
+	}
+	wantChecksum := torV3Checksum(pubKey)
+	if !bytes.Equal(checksum, wantChecksum[:]) {
+		return nil, fmt.Errorf("tor v3 host %s has an invalid checksum", host)
+	}
+	return pubKey, nil
+}
+
 // ipString returns a string representation of the network address' IP field.
 // If the ip is in the range used for TORv2 addresses then it will be
-// transformed into the respective .onion address.  It does not include the
-// port.
+// transformed into the respective .onion address.  Similarly, TORv3 and I2P
+// addresses are transformed into their respective .onion and .b32.i2p host
+// names.  It does not include the port.
 func (netAddr *NetAddress) ipString() string {
+	switch netAddr.Type {
+	case TORv3Address:
+		return encodeTorV3Host(netAddr.IP)
+	case I2PAddress:
+		return lowerBase32.EncodeToString(netAddr.IP) + i2pSuffix
+	}
+
 	netIP := netAddr.IP
 	if isOnionCatTor(netIP) {
 		// We know now that na.IP is long enough.
@@ -94,14 +202,80 @@ func (a *AddrManager) newAddressFromString(addr string) (*NetAddress, error) {
 	return a.HostToNetAddress(host, uint16(port), wire.SFNodeNetwork)
 }
 
+// newAddressFromStringType creates a new address manager network address from
+// the provided string the same as newAddressFromString except the address is
+// treated as a CJDNS address when the provided type is CJDNSAddress.  This is
+// necessary since CJDNS addresses share the same string representation as IPv6
+// addresses.
+func (a *AddrManager) newAddressFromStringType(addr string, addrType NetAddressType) (*NetAddress, error) {
+	netAddr, err := a.newAddressFromString(addr)
+	if err != nil {
+		return nil, err
+	}
+	if addrType != CJDNSAddress {
+		return netAddr, nil
+	}
+	return NewNetAddressFromParams(CJDNSAddress, net.IP(netAddr.IP).To16(),
+		netAddr.Port, netAddr.Timestamp, netAddr.Services)
+}
+
 // NewNetAddressIPPort creates a new address manager network address given an ip,
-// port, and the supported service flags for the address.
+// port, and the supported service flags for the address.  The type of the
+// address is derived from the ip.
 func NewNetAddressIPPort(ip net.IP, port uint16, services wire.ServiceFlag) *NetAddress {
 	timestamp := time.Unix(time.Now().Unix(), 0)
 	return &NetAddress{
+		Type:      addressType(ip),
 		IP:        ip,
 		Port:      port,
 		Services:  services,
 		Timestamp: timestamp,
 	}
 }
+
+// NewNetAddressFromParams creates a new address manager network address of the
+// provided type given the raw address bytes, port, timestamp, and the supported
+// service flags for the address.  An error is returned when the size of the
+// address bytes is not valid for the provided type.
+//
+// Local, IPv4, IPv6, and TORv2 addresses are IP addresses, so the type must
+// match the type derived from the address bytes in that case.
+func NewNetAddressFromParams(addrType NetAddressType, addrBytes []byte, port uint16, timestamp time.Time, services wire.ServiceFlag) (*NetAddress, error) {
+	var wantSize int
+	switch addrType {
+	case LocalAddress, IPv4Address, IPv6Address, TORv2Address:
+		if len(addrBytes) != net.IPv4len && len(addrBytes) != net.IPv6len {
+			return nil, fmt.Errorf("%v address is %d bytes instead of an "+
+				"IP address", addrType, len(addrBytes))
+		}
+		if derivedType := addressType(addrBytes); derivedType != addrType {
+			return nil, fmt.Errorf("ip address %v is a %v address instead "+
+				"of a %v address", net.IP(addrBytes), derivedType, addrType)
+		}
+		wantSize = len(addrBytes)
+	case TORv3Address:
+		wantSize = torV3AddrSize
+	case I2PAddress:
+		wantSize = i2pAddrSize
+	case CJDNSAddress:
+		wantSize = cjdnsAddrSize
+	default:
+		return nil, fmt.Errorf("unknown network address type %d", addrType)
+	}
+	if len(addrBytes) != wantSize {
+		return nil, fmt.Errorf("%v address is %d bytes instead of the "+
+			"required %d bytes", addrType, len(addrBytes), wantSize)
+	}
+	if addrType == CJDNSAddress && !isCJDNS(addrBytes) {
+		return nil, fmt.Errorf("cjdns address %v is not in the %v range",
+			net.IP(addrBytes), cjdnsNet)
+	}
+
+	return &NetAddress{
+		Type:      addrType,
+		IP:        addrBytes,
+		Port:      port,
+		Timestamp: time.Unix(timestamp.Unix(), 0),
+		Services:  services,
+	}, nil
+}
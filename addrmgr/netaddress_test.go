@@ -1,13 +1,15 @@
-// Copyright (c) 2021 The Decred developers
+// Copyright (c) 2021-2024 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
 package addrmgr
 
 import (
+	"bytes"
 	"net"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/decred/dcrd/wire"
 )
@@ -121,3 +123,142 @@ func TestAddService(t *testing.T) {
 			netAddr.Services, wire.SFNodeNetwork)
 	}
 }
+
+// TestOverlayKey verifies that Key converts network addresses on overlay
+// networks to the expected string values and that the addresses round trip
+// through HostToNetAddress.
+func TestOverlayKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		addrType NetAddressType
+		host     string
+		port     uint16
+		want     string
+	}{{
+		name:     "torv3",
+		addrType: TORv3Address,
+		host:     "duckduckgogg42xjoc72x3sjasowoarfbgcmvfimaftt6twagswzczad.onion",
+		port:     9108,
+		want:     "duckduckgogg42xjoc72x3sjasowoarfbgcmvfimaftt6twagswzczad.onion:9108",
+	}, {
+		name:     "torv3 uppercase",
+		addrType: TORv3Address,
+		host:     "DUCKDUCKGOGG42XJOC72X3SJASOWOARFBGCMVFIMAFTT6TWAGSWZCZAD.onion",
+		port:     9108,
+		want:     "duckduckgogg42xjoc72x3sjasowoarfbgcmvfimaftt6twagswzczad.onion:9108",
+	}, {
+		name:     "i2p",
+		addrType: I2PAddress,
+		host:     "ukeu3k5oycgaauneqgtnvselmt4yemvoilkln7jpvamvfx7dnkdq.b32.i2p",
+		port:     0,
+		want:     "ukeu3k5oycgaauneqgtnvselmt4yemvoilkln7jpvamvfx7dnkdq.b32.i2p:0",
+	}}
+
+	amgr := New("testoverlaykey", nil)
+	for _, test := range tests {
+		netAddr, err := amgr.HostToNetAddress(test.host, test.port,
+			wire.SFNodeNetwork)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", test.name, err)
+			continue
+		}
+		if netAddr.Type != test.addrType {
+			t.Errorf("%q: unexpected type -- got %v, want %v", test.name,
+				netAddr.Type, test.addrType)
+			continue
+		}
+		if key := netAddr.Key(); key != test.want {
+			t.Errorf("%q: unexpected network address key -- got %s, want %s",
+				test.name, key, test.want)
+			continue
+		}
+		if !netAddr.IsRoutable() {
+			t.Errorf("%q: address is not routable", test.name)
+		}
+	}
+}
+
+// TestNewNetAddressFromParams ensures creating network addresses from their
+// type and raw address bytes works as expected and rejects address bytes that
+// are not valid for the type.
+func TestNewNetAddressFromParams(t *testing.T) {
+	timestamp := time.Unix(0x495fab29, 0)
+	tests := []struct {
+		name      string
+		addrType  NetAddressType
+		addrBytes []byte
+		wantErr   bool
+		wantKey   string
+	}{{
+		name:      "ipv4",
+		addrType:  IPv4Address,
+		addrBytes: net.ParseIP("12.1.2.3"),
+		wantKey:   "12.1.2.3:9108",
+	}, {
+		name:      "ipv6 with ipv4 type",
+		addrType:  IPv4Address,
+		addrBytes: net.ParseIP("2602:100::1"),
+		wantErr:   true,
+	}, {
+		name:      "ipv4 with wrong size",
+		addrType:  IPv4Address,
+		addrBytes: []byte{0x01, 0x02, 0x03},
+		wantErr:   true,
+	}, {
+		name:      "torv3",
+		addrType:  TORv3Address,
+		addrBytes: bytes.Repeat([]byte{0x00}, 32),
+	}, {
+		name:      "torv3 with wrong size",
+		addrType:  TORv3Address,
+		addrBytes: bytes.Repeat([]byte{0x00}, 16),
+		wantErr:   true,
+	}, {
+		name:      "i2p with wrong size",
+		addrType:  I2PAddress,
+		addrBytes: bytes.Repeat([]byte{0x00}, 31),
+		wantErr:   true,
+	}, {
+		name:      "cjdns",
+		addrType:  CJDNSAddress,
+		addrBytes: net.ParseIP("fc32:17ea:e415:c3bf:9808:149d:b5a2:c9aa"),
+		wantKey:   "[fc32:17ea:e415:c3bf:9808:149d:b5a2:c9aa]:9108",
+	}, {
+		name:      "cjdns outside of range",
+		addrType:  CJDNSAddress,
+		addrBytes: net.ParseIP("fd32:17ea:e415:c3bf:9808:149d:b5a2:c9aa"),
+		wantErr:   true,
+	}, {
+		name:      "unknown type",
+		addrType:  CJDNSAddress + 1,
+		addrBytes: bytes.Repeat([]byte{0x00}, 16),
+		wantErr:   true,
+	}}
+
+	for _, test := range tests {
+		netAddr, err := NewNetAddressFromParams(test.addrType,
+			test.addrBytes, 9108, timestamp, wire.SFNodeNetwork)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%q: did not receive expected error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", test.name, err)
+			continue
+		}
+		if netAddr.Type != test.addrType {
+			t.Errorf("%q: unexpected type -- got %v, want %v", test.name,
+				netAddr.Type, test.addrType)
+		}
+		if !netAddr.Timestamp.Equal(timestamp) {
+			t.Errorf("%q: unexpected timestamp -- got %v, want %v",
+				test.name, netAddr.Timestamp, timestamp)
+		}
+		if test.wantKey != "" && netAddr.Key() != test.wantKey {
+			t.Errorf("%q: unexpected key -- got %s, want %s", test.name,
+				netAddr.Key(), test.wantKey)
+		}
+	}
+}
@@ -1,5 +1,5 @@
 // Copyright (c) 2013-2014 The btcsuite developers
-// Copyright (c) 2015-2023 The Decred developers
+// Copyright (c) 2015-2024 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
@@ -83,6 +83,12 @@ var (
 	// { magic 6 bytes, 10 bytes base32 decode of key hash }
 	onionCatNet = ipNet("fd87:d87e:eb43::", 48, 128)
 
+	// cjdnsNet defines the IPv6 address block used by CJDNS (fc00::/8).  Note
+	// that this range is part of the RFC4193 unique local IPv6 range, so
+	// addresses in it are only treated as CJDNS addresses when they are
+	// explicitly identified as such.
+	cjdnsNet = ipNet("fc00::", 8, 128)
+
 	// zero4Net defines the IPv4 address block for address staring with 0
 	// (0.0.0.0/8).
 	zero4Net = ipNet("0.0.0.0", 8, 32)
@@ -116,6 +122,12 @@ func isOnionCatTor(netIP net.IP) bool {
 	return onionCatNet.Contains(netIP)
 }
 
+// isCJDNS returns whether or not the passed address is in the IPv6 range used
+// by CJDNS (fc00::/8).
+func isCJDNS(netIP net.IP) bool {
+	return cjdnsNet.Contains(netIP)
+}
+
 // NetAddressType is used to indicate which network a network address belongs
 // to.
 type NetAddressType uint8
@@ -125,9 +137,34 @@ const (
 	IPv4Address
 	IPv6Address
 	TORv2Address
+	TORv3Address
+	I2PAddress
+	CJDNSAddress
 )
 
+// netAddressTypeStrings is a map of network address types back to their
+// human-readable names.
+var netAddressTypeStrings = map[NetAddressType]string{
+	LocalAddress: "local",
+	IPv4Address:  "ipv4",
+	IPv6Address:  "ipv6",
+	TORv2Address: "torv2",
+	TORv3Address: "torv3",
+	I2PAddress:   "i2p",
+	CJDNSAddress: "cjdns",
+}
+
+// String returns the network address type in human-readable form.
+func (t NetAddressType) String() string {
+	if s, ok := netAddressTypeStrings[t]; ok {
+		return s
+	}
+	return fmt.Sprintf("unknown network address type (%d)", uint8(t))
+}
+
 // addressType returns the network address type of the provided network address.
+// Note that TORv3, I2P, and CJDNS addresses can't be identified from the IP and
+// therefore must be explicitly identified.
 func addressType(netIP net.IP) NetAddressType {
 	switch {
 	case isLocal(netIP):
@@ -256,31 +293,20 @@ func IsRoutable(netIP net.IP) bool {
 		isLocal(netIP) || (isRFC4193(netIP) && !isOnionCatTor(netIP)))
 }
 
-// GroupKey returns a string representing the network group an address is part
-// of.  This is the /16 for IPv4, the /32 (/36 for he.net) for IPv6, the string
-// "local" for a local address, the string "tor:key" where key is the /4 of the
-// onion address for Tor address, and the string "unroutable" for an unroutable
-// address.
-func (na *NetAddress) GroupKey() string {
-	netIP := net.IP(na.IP)
-	if isLocal(netIP) {
-		return "local"
-	}
-	if !IsRoutable(netIP) {
-		return "unroutable"
-	}
+// linkedIPv4 returns the IPv4 address the passed address is linked to.  This is
+// the address itself for IPv4 addresses and the embedded IPv4 address for the
+// IPv6 ranges that tunnel or translate IPv4 addresses.  It returns nil when the
+// address is not linked to an IPv4 address.
+func linkedIPv4(netIP net.IP) net.IP {
 	if isIPv4(netIP) {
-		return netIP.Mask(net.CIDRMask(16, 32)).String()
+		return netIP.To4()
 	}
 	if isRFC6145(netIP) || isRFC6052(netIP) {
 		// last four bytes are the ip address
-		newIP := netIP[12:16]
-		return newIP.Mask(net.CIDRMask(16, 32)).String()
+		return netIP[12:16]
 	}
-
 	if isRFC3964(netIP) {
-		newIP := netIP[2:6]
-		return newIP.Mask(net.CIDRMask(16, 32)).String()
+		return netIP[2:6]
 	}
 	if isRFC4380(netIP) {
 		// teredo tunnels have the last 4 bytes as the v4 address XOR
@@ -289,6 +315,37 @@ func (na *NetAddress) GroupKey() string {
 		for i, byte := range netIP[12:16] {
 			newIP[i] = byte ^ 0xff
 		}
+		return newIP
+	}
+	return nil
+}
+
+// GroupKey returns a string representing the network group an address is part
+// of.  This is the /16 for IPv4, the /32 (/36 for he.net) for IPv6, the string
+// "local" for a local address, the string "tor:key" where key is the /4 of the
+// onion address for Tor address, the strings "torv3:key", "i2p:key", and
+// "cjdns:key" where key is the /4 of the respective address for TORv3, I2P, and
+// CJDNS addresses, and the string "unroutable" for an unroutable address.
+func (na *NetAddress) GroupKey() string {
+	switch na.Type {
+	case TORv3Address:
+		return fmt.Sprintf("torv3:%d", na.IP[0]&((1<<4)-1))
+	case I2PAddress:
+		return fmt.Sprintf("i2p:%d", na.IP[0]&((1<<4)-1))
+	case CJDNSAddress:
+		// The first byte is always the same for CJDNS addresses, so the
+		// group is keyed off the first 4 bits of the second byte.
+		return fmt.Sprintf("cjdns:%d", na.IP[1]&((1<<4)-1))
+	}
+
+	netIP := net.IP(na.IP)
+	if isLocal(netIP) {
+		return "local"
+	}
+	if !IsRoutable(netIP) {
+		return "unroutable"
+	}
+	if newIP := linkedIPv4(netIP); newIP != nil {
 		return newIP.Mask(net.CIDRMask(16, 32)).String()
 	}
 	if isOnionCatTor(netIP) {
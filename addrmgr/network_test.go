@@ -1,5 +1,5 @@
 // Copyright (c) 2013-2014 The btcsuite developers
-// Copyright (c) 2015-2021 The Decred developers
+// Copyright (c) 2015-2024 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
@@ -8,6 +8,7 @@ package addrmgr
 import (
 	"net"
 	"testing"
+	"time"
 
 	"github.com/decred/dcrd/wire"
 )
@@ -197,4 +198,29 @@ func TestGroupKey(t *testing.T) {
 				"- got '%s', want '%s'", i, test.name, key, test.expected)
 		}
 	}
+
+	// Ensure addresses on overlay networks that are not based on IP are
+	// grouped by network.
+	overlayTests := []struct {
+		name     string
+		addrType NetAddressType
+		addr     []byte
+		expected string
+	}{
+		{name: "torv3", addrType: TORv3Address, addr: append([]byte{0x15}, make([]byte, 31)...), expected: "torv3:5"},
+		{name: "i2p", addrType: I2PAddress, addr: append([]byte{0xa2}, make([]byte, 31)...), expected: "i2p:2"},
+		{name: "cjdns", addrType: CJDNSAddress, addr: net.ParseIP("fc32:17ea::1"), expected: "cjdns:2"},
+	}
+	for _, test := range overlayTests {
+		na, err := NewNetAddressFromParams(test.addrType, test.addr, 9108,
+			time.Now(), wire.SFNodeNetwork)
+		if err != nil {
+			t.Errorf("TestGroupKey (%s): unexpected error: %v", test.name, err)
+			continue
+		}
+		if key := na.GroupKey(); key != test.expected {
+			t.Errorf("TestGroupKey (%s): unexpected group key - got '%s', "+
+				"want '%s'", test.name, key, test.expected)
+		}
+	}
 }
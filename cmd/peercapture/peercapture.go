@@ -0,0 +1,117 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// peercapture decodes the capture files dcrd writes when the --capturedir
+// option is set and replays them against a simnet node to reproduce issues.
+//
+// Usage:
+//
+//	peercapture decode [-v] file...
+//	peercapture replay [-c addr] [-d direction] [-r] file...
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/decred/dcrd/peer/v3"
+	flags "github.com/jessevdk/go-flags"
+)
+
+// decodeCmd defines the options for the decode command.
+type decodeCmd struct {
+	Verbose bool `short:"v" long:"verbose" description:"Dump the full contents of each message"`
+}
+
+// config defines the commands of peercapture.
+type config struct {
+	Decode decodeCmd `command:"decode" description:"Print the messages in the provided capture files"`
+	Replay replayCmd `command:"replay" description:"Replay the messages in the provided capture files against a simnet node"`
+}
+
+// forEachRecord invokes the provided function with the header and each record
+// of the provided capture files in order.  Iteration stops when the function
+// returns an error.
+func forEachRecord(paths []string, fn func(*peer.CaptureHeader, *peer.CaptureRecord) error) error {
+	for _, path := range paths {
+		err := func() error {
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			cr, err := peer.NewCaptureReader(f)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			for {
+				rec, err := cr.Next()
+				if errors.Is(err, io.EOF) {
+					return nil
+				}
+				if err != nil {
+					return fmt.Errorf("%s: %w", path, err)
+				}
+				if err := fn(&cr.Header, rec); err != nil {
+					return err
+				}
+			}
+		}()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Execute prints the messages in the capture files provided via args.  It is
+// part of the flags.Commander interface.
+func (cmd *decodeCmd) Execute(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no capture files specified")
+	}
+
+	var lastHeader *peer.CaptureHeader
+	return forEachRecord(args, func(hdr *peer.CaptureHeader, rec *peer.CaptureRecord) error {
+		if lastHeader == nil || *lastHeader != *hdr {
+			direction := "outbound"
+			if hdr.Inbound {
+				direction = "inbound"
+			}
+			fmt.Printf("Capture of %s peer %s on %v\n", direction, hdr.Addr,
+				hdr.Net)
+			lastHeader = hdr
+		}
+
+		fmt.Printf("%s %-8s %-12s %d bytes (pver %d)\n",
+			rec.Timestamp.Format(time.RFC3339Nano), rec.Direction,
+			rec.Command, len(rec.RawMessage), rec.ProtocolVersion)
+		if cmd.Verbose {
+			msg, err := rec.Decode(hdr.Net)
+			if err != nil {
+				fmt.Printf("unable to decode message: %v\n", err)
+				return nil
+			}
+			fmt.Print(spew.Sdump(msg))
+		}
+		return nil
+	})
+}
+
+func main() {
+	var cfg config
+	parser := flags.NewParser(&cfg, flags.Default)
+	if _, err := parser.Parse(); err != nil {
+		var e *flags.Error
+		if errors.As(err, &e) && e.Type == flags.ErrHelp {
+			os.Exit(0)
+		}
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/decred/dcrd/peer/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+// replayCmd defines the options for the replay command.
+type replayCmd struct {
+	Connect   string        `short:"c" long:"connect" description:"Address of the simnet node to replay the messages against" default:"127.0.0.1:18555"`
+	Direction string        `short:"d" long:"direction" description:"Direction of the captured messages to replay {received, sent}" default:"received"`
+	RealTime  bool          `short:"r" long:"realtime" description:"Replay the messages with the same delays between them as when they were captured"`
+	Linger    time.Duration `long:"linger" description:"Duration to wait for responses from the node after replaying all messages" default:"5s"`
+}
+
+// readResponses reads messages from the provided connection and prints them
+// until the connection is closed.
+func readResponses(conn net.Conn, done chan<- struct{}) {
+	defer close(done)
+	for {
+		_, msg, _, err := wire.ReadMessageN(conn, wire.ProtocolVersion,
+			wire.SimNet)
+		if err != nil {
+			var msgErr *wire.MessageError
+			if errors.As(err, &msgErr) {
+				fmt.Printf("<- unable to decode message: %v\n", err)
+				continue
+			}
+			return
+		}
+		fmt.Printf("<- %s\n", msg.Command())
+	}
+}
+
+// Execute replays the messages in the capture files provided via args against
+// the configured simnet node.  It is part of the flags.Commander interface.
+func (cmd *replayCmd) Execute(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no capture files specified")
+	}
+	var direction peer.CaptureDirection
+	switch cmd.Direction {
+	case peer.CaptureReceived.String():
+		direction = peer.CaptureReceived
+	case peer.CaptureSent.String():
+		direction = peer.CaptureSent
+	default:
+		return fmt.Errorf("invalid direction %q", cmd.Direction)
+	}
+
+	conn, err := net.Dial("tcp", cmd.Connect)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	done := make(chan struct{})
+	go readResponses(conn, done)
+
+	var lastTimestamp time.Time
+	err = forEachRecord(args, func(hdr *peer.CaptureHeader, rec *peer.CaptureRecord) error {
+		if rec.Direction != direction {
+			return nil
+		}
+		msg, err := rec.Decode(hdr.Net)
+		if err != nil {
+			return err
+		}
+
+		// The replayed connection is always plaintext, so do not advertise
+		// support for the encrypted transport to avoid the node attempting to
+		// upgrade the connection.
+		if msgVersion, ok := msg.(*wire.MsgVersion); ok {
+			msgVersion.Services &^= wire.SFNodeEncryptedTransport
+		}
+
+		if cmd.RealTime && !lastTimestamp.IsZero() {
+			time.Sleep(rec.Timestamp.Sub(lastTimestamp))
+		}
+		lastTimestamp = rec.Timestamp
+
+		// Messages are always sent on simnet regardless of the network they
+		// were captured on.
+		err = wire.WriteMessage(conn, msg, rec.ProtocolVersion, wire.SimNet)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("-> %s\n", msg.Command())
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-done:
+	case <-time.After(cmd.Linger):
+	}
+	return nil
+}
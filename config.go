@@ -50,6 +50,7 @@ const (
 	defaultUtxoCacheMaxSize = 150
 	minUtxoCacheMaxSize     = 25
 	maxUtxoCacheMaxSize     = 32768 // 32 GiB
+	minPruneTarget          = 1024  // 1 GiB
 
 	// Defaults for RPC server options and policy.
 	defaultTLSCurve             = "P-256"
@@ -60,6 +61,7 @@ const (
 	// Defaults for P2P network options.
 	defaultMaxSameIP       = 5
 	defaultMaxPeers        = 125
+	defaultBlockRelayConns = 2
 	defaultDialTimeout     = time.Second * 30
 	defaultPeerIdleTimeout = time.Second * 120
 
@@ -69,6 +71,7 @@ const (
 
 	// Defaults for relay and mempool policy options.
 	defaultMaxOrphanTransactions = 100
+	defaultMaxMempoolSize        = 300 // 300 MiB
 	defaultAllowOldVotes         = false
 
 	// Defaults for mining options and policy.
@@ -80,6 +83,8 @@ const (
 
 	// Defaults for indexing options.
 	defaultTxIndex           = false
+	defaultAddrIndex         = false
+	defaultSpendIndex        = false
 	defaultNoExistsAddrIndex = false
 
 	// Authorization types.
@@ -122,16 +127,20 @@ type config struct {
 	Profile          string `long:"profile" description:"Enable HTTP profiling on given [addr:]port -- NOTE port must be between 1024 and 65536"`
 	CPUProfile       string `long:"cpuprofile" description:"Write CPU profile to the specified file"`
 	MemProfile       string `long:"memprofile" description:"Write mem profile to the specified file"`
+	CaptureDir       string `long:"capturedir" description:"Write every message sent to and received from each peer to capture files in the specified directory for debugging -- NOTE: The captures are rotated every 10 MiB and the 5 most recent are kept for each peer"`
 	TestNet          bool   `long:"testnet" description:"Use the test network"`
 	SimNet           bool   `long:"simnet" description:"Use the simulation test network"`
 	RegNet           bool   `long:"regnet" description:"Use the regression test network"`
 	DebugLevel       string `short:"d" long:"debuglevel" description:"Logging level for all subsystems {trace, debug, info, warn, error, critical} -- You may also specify <subsystem>=<level>,<subsystem2>=<level>,... to set the log level for individual subsystems -- Use show to list available subsystems"`
 	SigCacheMaxSize  uint   `long:"sigcachemaxsize" description:"The maximum number of entries in the signature verification cache"`
 	UtxoCacheMaxSize uint   `long:"utxocachemaxsize" description:"The maximum size in MiB of the utxo cache; (min: 25, max: 32768)"`
+	Prune            uint   `long:"prune" description:"Prune old block data to keep the total size of stored blocks below the specified target in MiB -- NOTE: Pruned nodes do not serve historical blocks to other peers and are not compatible with --txindex, --addrindex, or --spendindex; 0 to disable (min: 1024)"`
 
 	// RPC server options and policy.
 	DisableRPC           bool     `long:"norpc" description:"Disable built-in RPC server -- NOTE: The RPC server is disabled by default if no rpcuser/rpcpass or rpclimituser/rpclimitpass is specified"`
 	RPCListeners         []string `long:"rpclisten" description:"Add an interface/port to listen for RPC connections (default port: 9109, testnet: 19109)"`
+	RESTListeners        []string `long:"restlisten" description:"Add an interface/port to listen for unauthenticated read-only REST connections -- NOTE: The REST interface is disabled unless at least one interface is specified and requires the RPC server (default port: 9112, testnet: 19112)"`
+	MetricsListeners     []string `long:"metricslisten" description:"Add an interface/port to serve Prometheus-compatible metrics over HTTP at /metrics -- NOTE: The metrics server is disabled unless at least one interface is specified and does not perform any authentication (default port: 9113, testnet: 19113)"`
 	RPCUser              string   `short:"u" long:"rpcuser" description:"Username for RPC connections"`
 	RPCPass              string   `short:"P" long:"rpcpass" default-mask:"-" description:"Password for RPC connections"`
 	RPCAuthType          string   `long:"authtype" description:"Method for RPC client authentication (basic or clientcert)"`
@@ -158,14 +167,19 @@ type config struct {
 	TorIsolation   bool   `long:"torisolation" description:"Enable Tor stream isolation by randomizing user credentials for each connection"`
 
 	// P2P network options.
-	AddPeers        []string      `short:"a" long:"addpeer" description:"Add a peer to connect with at startup"`
-	ConnectPeers    []string      `long:"connect" description:"Connect only to the specified peers at startup"`
-	DisableListen   bool          `long:"nolisten" description:"Disable listening for incoming connections -- NOTE: Listening is automatically disabled if the --connect or --proxy options are used without also specifying listen interfaces via --listen"`
-	Listeners       []string      `long:"listen" description:"Add an interface/port to listen for connections (default all interfaces port: 9108, testnet: 19108)"`
-	MaxSameIP       int           `long:"maxsameip" description:"Max number of connections with the same IP -- 0 to disable"`
-	MaxPeers        int           `long:"maxpeers" description:"Max number of inbound and outbound peers"`
-	DialTimeout     time.Duration `long:"dialtimeout" description:"How long to wait for TCP connection completion.  Valid time units are {s, m, h}.  Minimum 1 second"`
-	PeerIdleTimeout time.Duration `long:"peeridletimeout" description:"The duration of inactivity before a peer is timed out.  Valid time units are {s,m,h}.  Minimum 15 seconds"`
+	AddPeers          []string      `short:"a" long:"addpeer" description:"Add a peer to connect with at startup"`
+	ConnectPeers      []string      `long:"connect" description:"Connect only to the specified peers at startup"`
+	DisableListen     bool          `long:"nolisten" description:"Disable listening for incoming connections -- NOTE: Listening is automatically disabled if the --connect or --proxy options are used without also specifying listen interfaces via --listen"`
+	Listeners         []string      `long:"listen" description:"Add an interface/port to listen for connections (default all interfaces port: 9108, testnet: 19108)"`
+	MaxSameIP         int           `long:"maxsameip" description:"Max number of connections with the same IP -- 0 to disable"`
+	MaxPeers          int           `long:"maxpeers" description:"Max number of inbound and outbound peers"`
+	BlockRelayConns   int           `long:"blockrelayconns" description:"Number of additional outbound peers that only relay blocks and headers -- 0 to disable"`
+	ASMap             string        `long:"asmap" description:"Path to a file that maps IP addresses to autonomous system numbers in order to group peers by autonomous system instead of network prefix"`
+	DialTimeout       time.Duration `long:"dialtimeout" description:"How long to wait for TCP connection completion.  Valid time units are {s, m, h}.  Minimum 1 second"`
+	PeerIdleTimeout   time.Duration `long:"peeridletimeout" description:"The duration of inactivity before a peer is timed out.  Valid time units are {s,m,h}.  Minimum 15 seconds"`
+	NoP2PEncrypt      bool          `long:"nop2pencrypt" description:"Disable upgrading connections with peers that support it to the encrypted transport"`
+	RequireP2PEncrypt bool          `long:"requirep2pencrypt" description:"Disconnect peers that do not support the encrypted transport"`
+	PeerBloomFilters  bool          `long:"peerbloomfilters" description:"Enable serving bloom filtered transactions and blocks to peers that load a bloom filter"`
 
 	// P2P network discovery options.
 	DisableSeeders bool     `long:"noseeders" description:"Disable seeding for peer discovery"`
@@ -181,15 +195,19 @@ type config struct {
 	Whitelists     []string      `long:"whitelist" description:"Add an IP network or IP that will not be banned (eg. 192.168.1.0/24 or ::1)"`
 
 	// Chain related options.
-	AllowOldForks  bool   `long:"allowoldforks" description:"Process forks deep in history.  Don't do this unless you know what you're doing"`
-	DumpBlockchain string `long:"dumpblockchain" description:"Write blockchain as a flat file of blocks for use with addblock, to the specified filename"`
-	AssumeValid    string `long:"assumevalid" description:"Hash of an assumed valid block.  Defaults to the hard-coded assumed valid block that is updated periodically with new releases.  Don't use a different hash unless you understand the implications.  Set to 0 to disable"`
+	AllowOldForks   bool     `long:"allowoldforks" description:"Process forks deep in history.  Don't do this unless you know what you're doing"`
+	DumpBlockchain  string   `long:"dumpblockchain" description:"Write blockchain as a flat file of blocks for use with addblock, to the specified filename"`
+	AssumeValid     string   `long:"assumevalid" description:"Hash of an assumed valid block.  Defaults to the hard-coded assumed valid block that is updated periodically with new releases.  Don't use a different hash unless you understand the implications.  Set to 0 to disable"`
+	LightMode       bool     `long:"lightmode" description:"Only sync and validate block headers and committed filters along with the blocks matched by the filters for the addresses specified via --lightwatchaddr -- NOTE: Only a subset of the RPCs are available, listening is disabled, and not compatible with --generate, --prune, --txindex, --addrindex, or --spendindex"`
+	LightWatchAddrs []string `long:"lightwatchaddr" description:"Add the specified address to the list of addresses to match against the committed filters in light mode"`
 
 	// Relay and mempool policy.
 	MinRelayTxFee    float64 `long:"minrelaytxfee" description:"The minimum transaction fee in DCR/kB to be considered a non-zero fee"`
 	FreeTxRelayLimit float64 `long:"limitfreerelay" description:"DEPRECATED: This behavior is no longer available and this option will be removed in a future version of the software"`
 	NoRelayPriority  bool    `long:"norelaypriority" description:"DEPRECATED: This behavior is no longer available and this option will be removed in a future version of the software"`
 	MaxOrphanTxs     int     `long:"maxorphantx" description:"Max number of orphan transactions to keep in memory"`
+	MaxMempool       uint    `long:"maxmempool" description:"Max total size in MiB of the transactions to keep in memory.  The transactions with the lowest fee rates are evicted when the limit is exceeded.  Set to 0 to disable the limit"`
+	NoPersistMempool bool    `long:"nopersistmempool" description:"Do not save the mempool to the data directory on shutdown and load it again on startup"`
 	BlocksOnly       bool    `long:"blocksonly" description:"Do not accept transactions from remote peers"`
 	AcceptNonStd     bool    `long:"acceptnonstd" description:"Accept and relay non-standard transactions to the network regardless of the default settings for the active network"`
 	RejectNonStd     bool    `long:"rejectnonstd" description:"Reject non-standard transactions regardless of the default settings for the active network"`
@@ -209,6 +227,10 @@ type config struct {
 	// Indexing options.
 	TxIndex             bool `long:"txindex" description:"Maintain a full hash-based transaction index which makes all transactions available via the getrawtransaction RPC"`
 	DropTxIndex         bool `long:"droptxindex" description:"Deletes the hash-based transaction index from the database on start up and then exits"`
+	AddrIndex           bool `long:"addrindex" description:"Maintain a full address-based transaction index which makes the searchrawtransactions and getaddresstxids RPCs available -- NOTE: Requires and automatically enables --txindex"`
+	DropAddrIndex       bool `long:"dropaddrindex" description:"Deletes the address-based transaction index from the database on start up and then exits"`
+	SpendIndex          bool `long:"spendindex" description:"Maintain an index of the transactions that spend each output which makes the gettxspendingprevout RPC available for confirmed transactions"`
+	DropSpendIndex      bool `long:"dropspendindex" description:"Deletes the spend index from the database on start up and then exits"`
 	NoExistsAddrIndex   bool `long:"noexistsaddrindex" description:"Disable the exists address index, which tracks whether or not an address has even been used"`
 	DropExistsAddrIndex bool `long:"dropexistsaddrindex" description:"Deletes the exists address index from the database on start up and then exits"`
 
@@ -224,6 +246,7 @@ type config struct {
 	oniondial     func(context.Context, string, string) (net.Conn, error)
 	dial          func(context.Context, string, string) (net.Conn, error)
 	miningAddrs   []stdaddr.Address
+	watchScripts  [][]byte
 	minRelayTxFee dcrutil.Amount
 	whitelists    []*net.IPNet
 	ipv4NetInfo   types.NetworksResult
@@ -619,6 +642,7 @@ func loadConfig(appName string) (*config, []string, error) {
 		// P2P network options.
 		MaxSameIP:       defaultMaxSameIP,
 		MaxPeers:        defaultMaxPeers,
+		BlockRelayConns: defaultBlockRelayConns,
 		DialTimeout:     defaultDialTimeout,
 		PeerIdleTimeout: defaultPeerIdleTimeout,
 
@@ -629,6 +653,7 @@ func loadConfig(appName string) (*config, []string, error) {
 		// Relay and mempool policy.
 		MinRelayTxFee: mempool.DefaultMinRelayTxFee.ToCoin(),
 		MaxOrphanTxs:  defaultMaxOrphanTransactions,
+		MaxMempool:    defaultMaxMempoolSize,
 		AllowOldVotes: defaultAllowOldVotes,
 
 		// Mining options and policy.
@@ -639,6 +664,8 @@ func loadConfig(appName string) (*config, []string, error) {
 
 		// Indexing options.
 		TxIndex:           defaultTxIndex,
+		AddrIndex:         defaultAddrIndex,
+		SpendIndex:        defaultSpendIndex,
 		NoExistsAddrIndex: defaultNoExistsAddrIndex,
 
 		// Cooked options ready for use.
@@ -865,6 +892,12 @@ func loadConfig(appName string) (*config, []string, error) {
 	oldTestNets = append(oldTestNets, filepath.Join(cfg.DataDir, "testnet"))
 	oldTestNets = append(oldTestNets, filepath.Join(cfg.DataDir, "testnet2"))
 	cfg.DataDir = filepath.Join(cfg.DataDir, cfg.params.Name)
+	if cfg.CaptureDir != "" {
+		cfg.CaptureDir = cleanAndExpandPath(cfg.CaptureDir)
+	}
+	if cfg.ASMap != "" {
+		cfg.ASMap = cleanAndExpandPath(cfg.ASMap)
+	}
 	logRotator = nil
 	if !cfg.NoFileLogging {
 		// Append the network type to the log directory so it is "namespaced"
@@ -980,6 +1013,14 @@ func loadConfig(appName string) (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// Don't allow a negative number of block relay connections.
+	if cfg.BlockRelayConns < 0 {
+		str := "%s: the blockrelayconns option may not be less than 0 " +
+			"-- parsed [%d]"
+		err := fmt.Errorf(str, funcName, cfg.BlockRelayConns)
+		return nil, nil, err
+	}
+
 	// Validate any given whitelisted IP addresses and networks.
 	if len(cfg.Whitelists) > 0 {
 		var ip net.IP
@@ -1018,12 +1059,31 @@ func loadConfig(appName string) (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// --nop2pencrypt and --requirep2pencrypt do not mix.
+	if cfg.NoP2PEncrypt && cfg.RequireP2PEncrypt {
+		str := "%s: the --nop2pencrypt and --requirep2pencrypt options can " +
+			"not be mixed"
+		err := fmt.Errorf(str, funcName)
+		return nil, nil, err
+	}
+
 	// --proxy or --connect without --listen disables listening.
 	if (cfg.Proxy != "" || len(cfg.ConnectPeers) > 0) &&
 		len(cfg.Listeners) == 0 {
 		cfg.DisableListen = true
 	}
 
+	// --lightmode disables listening and serving bloom filters since the full
+	// blocks are not available to serve to other peers.  It also implies
+	// --blocksonly and --nominingstatesync since there is no mempool state to
+	// maintain without the full blocks.
+	if cfg.LightMode {
+		cfg.DisableListen = true
+		cfg.PeerBloomFilters = false
+		cfg.BlocksOnly = true
+		cfg.NoMiningStateSync = true
+	}
+
 	// Connect means no seeding.
 	if len(cfg.ConnectPeers) > 0 {
 		cfg.DisableSeeders = true
@@ -1148,6 +1208,106 @@ func loadConfig(appName string) (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// --addrindex and --dropaddrindex do not mix.
+	if cfg.AddrIndex && cfg.DropAddrIndex {
+		err := fmt.Errorf("%s: the --addrindex and --dropaddrindex "+
+			"options may not be activated at the same time", funcName)
+		return nil, nil, err
+	}
+
+	// --addrindex and --droptxindex do not mix since the address index
+	// requires the transaction index.
+	if cfg.AddrIndex && cfg.DropTxIndex {
+		err := fmt.Errorf("%s: the --addrindex and --droptxindex "+
+			"options may not be activated at the same time because the "+
+			"address index relies on the transaction index", funcName)
+		return nil, nil, err
+	}
+
+	// --spendindex and --dropspendindex do not mix.
+	if cfg.SpendIndex && cfg.DropSpendIndex {
+		err := fmt.Errorf("%s: the --spendindex and --dropspendindex "+
+			"options may not be activated at the same time", funcName)
+		return nil, nil, err
+	}
+
+	// Enforce the minimum prune target when pruning is enabled.
+	if cfg.Prune != 0 && cfg.Prune < minPruneTarget {
+		err := fmt.Errorf("%s: the --prune option must be at least %d MiB "+
+			"when enabled", funcName, minPruneTarget)
+		return nil, nil, err
+	}
+
+	// --prune and --txindex do not mix since the transaction index requires
+	// all historical blocks.
+	if cfg.Prune != 0 && cfg.TxIndex {
+		err := fmt.Errorf("%s: the --prune and --txindex options may not "+
+			"be activated at the same time", funcName)
+		return nil, nil, err
+	}
+
+	// --prune and --addrindex do not mix for the same reason since the
+	// address index relies on the transaction index.
+	if cfg.Prune != 0 && cfg.AddrIndex {
+		err := fmt.Errorf("%s: the --prune and --addrindex options may not "+
+			"be activated at the same time", funcName)
+		return nil, nil, err
+	}
+
+	// --prune and --spendindex do not mix since building the spend index
+	// requires all historical blocks.
+	if cfg.Prune != 0 && cfg.SpendIndex {
+		err := fmt.Errorf("%s: the --prune and --spendindex options may "+
+			"not be activated at the same time", funcName)
+		return nil, nil, err
+	}
+
+	// --lightmode does not mix with options that require the full blocks.
+	if cfg.LightMode {
+		fullBlockOpts := []struct {
+			name  string
+			isSet bool
+		}{
+			{"generate", cfg.Generate},
+			{"prune", cfg.Prune != 0},
+			{"txindex", cfg.TxIndex},
+			{"addrindex", cfg.AddrIndex},
+			{"spendindex", cfg.SpendIndex},
+		}
+		for _, opt := range fullBlockOpts {
+			if opt.isSet {
+				str := "%s: the --lightmode and --%s options can not be mixed"
+				err := fmt.Errorf(str, funcName, opt.name)
+				return nil, nil, err
+			}
+		}
+
+		// The exists address index can't be maintained without the full
+		// blocks either.
+		cfg.NoExistsAddrIndex = true
+	}
+
+	// --lightwatchaddr requires --lightmode.
+	if len(cfg.LightWatchAddrs) > 0 && !cfg.LightMode {
+		str := "%s: the --lightwatchaddr option requires --lightmode"
+		err := fmt.Errorf(str, funcName)
+		return nil, nil, err
+	}
+
+	// Check light mode watch addresses are valid and save the scripts to match
+	// against the committed filters.
+	cfg.watchScripts = make([][]byte, 0, len(cfg.LightWatchAddrs))
+	for _, strAddr := range cfg.LightWatchAddrs {
+		addr, err := stdaddr.DecodeAddress(strAddr, cfg.params.Params)
+		if err != nil {
+			str := "%s: light mode watch address '%s' failed to decode: %w"
+			err := fmt.Errorf(str, funcName, strAddr, err)
+			return nil, nil, err
+		}
+		_, script := addr.PaymentScript()
+		cfg.watchScripts = append(cfg.watchScripts, script)
+	}
+
 	// !--noexistsaddrindex and --dropexistsaddrindex do not mix.
 	if !cfg.NoExistsAddrIndex && cfg.DropExistsAddrIndex {
 		err := fmt.Errorf("dropexistsaddrindex cannot be activated when " +
@@ -1198,6 +1358,25 @@ func loadConfig(appName string) (*config, []string, error) {
 	cfg.RPCListeners = normalizeAddresses(cfg.RPCListeners,
 		cfg.params.rpcPort, normalizeInterfaceAddrs)
 
+	// The REST interface is served by the RPC server, so it may not be
+	// enabled when the RPC server is disabled.
+	if cfg.DisableRPC && len(cfg.RESTListeners) > 0 {
+		str := "%s: the --restlisten option requires the RPC server to " +
+			"be enabled"
+		err := fmt.Errorf(str, funcName)
+		return nil, nil, err
+	}
+
+	// Add default port to all REST listener addresses if needed and remove
+	// duplicate addresses.
+	cfg.RESTListeners = normalizeAddresses(cfg.RESTListeners,
+		cfg.params.restPort, normalizeInterfaceAddrs)
+
+	// Add default port to all metrics listener addresses if needed and remove
+	// duplicate addresses.
+	cfg.MetricsListeners = normalizeAddresses(cfg.MetricsListeners,
+		cfg.params.metricsPort, normalizeInterfaceAddrs)
+
 	// The authtype config must be one of "basic" or "clientcert".
 	switch cfg.RPCAuthType {
 	case authTypeBasic, authTypeClientCert:
@@ -1215,7 +1394,11 @@ func loadConfig(appName string) (*config, []string, error) {
 			"127.0.0.1": {},
 			"::1":       {},
 		}
-		for _, addr := range cfg.RPCListeners {
+		listeners := make([]string, 0, len(cfg.RPCListeners)+
+			len(cfg.RESTListeners))
+		listeners = append(listeners, cfg.RPCListeners...)
+		listeners = append(listeners, cfg.RESTListeners...)
+		for _, addr := range listeners {
 			host, _, err := net.SplitHostPort(addr)
 			if err != nil {
 				str := "%s: RPC listen interface '%s' is " +
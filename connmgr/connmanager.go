@@ -1,5 +1,5 @@
 // Copyright (c) 2016 The btcsuite developers
-// Copyright (c) 2017-2023 The Decred developers
+// Copyright (c) 2017-2024 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
@@ -53,6 +53,35 @@ const (
 	ConnCanceled
 )
 
+// ConnType represents the type of an outbound connection which determines the
+// kind of data relayed over it.
+type ConnType uint8
+
+// ConnType can be either full relay or block relay.  Full relay connections
+// relay all data such as blocks, transactions and addresses.  Block relay
+// connections only relay blocks and headers which makes it harder for
+// attackers to infer the network topology via the relayed transactions and
+// addresses and therefore harder to eclipse the local peer.
+const (
+	ConnTypeFullRelay ConnType = iota
+	ConnTypeBlockRelay
+)
+
+// connTypeStrings is a map of connection types back to their constant names
+// for pretty printing.
+var connTypeStrings = map[ConnType]string{
+	ConnTypeFullRelay:  "outbound-full-relay",
+	ConnTypeBlockRelay: "block-relay-only",
+}
+
+// String returns the ConnType in human-readable form.
+func (t ConnType) String() string {
+	if s, ok := connTypeStrings[t]; ok {
+		return s
+	}
+	return fmt.Sprintf("Unknown ConnType (%d)", uint8(t))
+}
+
 // ConnReq is the connection request to a network address. If permanent, the
 // connection will be retried on disconnection.
 type ConnReq struct {
@@ -83,6 +112,10 @@ type ConnReq struct {
 	// manager will try to always maintain the connection including retries with
 	// increasing backoff timeouts.
 	Permanent bool
+
+	// Type specifies the type of the connection which determines the kind of
+	// data relayed over it.  It defaults to ConnTypeFullRelay.
+	Type ConnType
 }
 
 // updateState updates the state of the connection request.
@@ -133,10 +166,26 @@ type Config struct {
 	// connections in that case.
 	OnAccept func(net.Conn)
 
-	// TargetOutbound is the number of outbound network connections to
-	// maintain. Defaults to 8.
+	// TargetOutbound is the number of full relay outbound network
+	// connections to maintain. Defaults to 8.
 	TargetOutbound uint32
 
+	// TargetBlockRelayOutbound is the number of block relay outbound network
+	// connections to maintain in addition to the full relay ones.  Defaults
+	// to 0.
+	TargetBlockRelayOutbound uint32
+
+	// Anchors are the addresses of block relay outbound peers from a previous
+	// run to reconnect to first in order to make it harder for an attacker
+	// to take advantage of a restart to isolate the node.  They count toward
+	// TargetBlockRelayOutbound and any that exceed it are ignored.  New
+	// addresses are requested as usual when connecting to them fails or they
+	// later disconnect.
+	//
+	// This field will not have any effect if the GetNewAddress field is not
+	// also specified.
+	Anchors []net.Addr
+
 	// RetryDuration is the duration to wait before retrying connection
 	// requests. Defaults to 5s.
 	RetryDuration time.Duration
@@ -241,6 +290,27 @@ type ConnManager struct {
 	requests chan interface{}
 }
 
+// targetOutbound returns the number of outbound network connections of the
+// provided type to maintain.
+func (cm *ConnManager) targetOutbound(connType ConnType) uint32 {
+	if connType == ConnTypeBlockRelay {
+		return cm.cfg.TargetBlockRelayOutbound
+	}
+	return cm.cfg.TargetOutbound
+}
+
+// numConns returns the number of connections of the provided type in the
+// provided connections.
+func numConns(conns map[uint64]*ConnReq, connType ConnType) uint32 {
+	var n uint32
+	for _, connReq := range conns {
+		if connReq.Type == connType {
+			n++
+		}
+	}
+	return n
+}
+
 // handleFailedConn handles a connection failed due to a disconnect or any
 // other failure. If permanent, it retries the connection after the configured
 // retry duration. Otherwise, if required, it makes a new connection request.
@@ -275,12 +345,12 @@ func (cm *ConnManager) handleFailedConn(ctx context.Context, c *ConnReq) {
 			go func() {
 				select {
 				case <-time.After(cm.cfg.RetryDuration):
-					cm.newConnReq(ctx)
+					cm.newConnReq(ctx, c.Type)
 				case <-cm.quit:
 				}
 			}()
 		} else {
-			go cm.newConnReq(ctx)
+			go cm.newConnReq(ctx, c.Type)
 		}
 	}
 }
@@ -380,12 +450,14 @@ out:
 				}
 
 				// Otherwise, we will attempt a reconnection if
-				// we do not have enough peers, or if this is a
-				// persistent peer. The connection request is
-				// re added to the pending map, so that
-				// subsequent processing of connections and
-				// failures do not ignore the request.
-				if uint32(len(conns)) < cm.cfg.TargetOutbound ||
+				// we do not have enough peers of the same type,
+				// or if this is a persistent peer. The
+				// connection request is re added to the pending
+				// map, so that subsequent processing of
+				// connections and failures do not ignore the
+				// request.
+				connType := connReq.Type
+				if numConns(conns, connType) < cm.targetOutbound(connType) ||
 					connReq.Permanent {
 
 					connReq.updateState(ConnPending)
@@ -459,15 +531,18 @@ out:
 	log.Trace("Connection handler done")
 }
 
-// newConnReq creates a new connection request and connects to the
-// corresponding address.
-func (cm *ConnManager) newConnReq(ctx context.Context) {
+// newConnReq creates a new connection request of the provided type and
+// connects to the corresponding address.
+func (cm *ConnManager) newConnReq(ctx context.Context, connType ConnType) {
 	// Ignore during shutdown.
 	if ctx.Err() != nil {
 		return
 	}
 
-	c := &ConnReq{id: atomic.AddUint64(&cm.connReqCount, 1)}
+	c := &ConnReq{
+		id:   atomic.AddUint64(&cm.connReqCount, 1),
+		Type: connType,
+	}
 
 	// Submit a request of a pending connection attempt to the connection
 	// manager. By registering the id before the connection is even
@@ -713,12 +788,22 @@ func (cm *ConnManager) Run(ctx context.Context) {
 		}(listener)
 	}
 
-	// Start enough outbound connections to reach the target number when not
-	// in manual connect mode.
+	// Start enough outbound connections of each type to reach the target
+	// numbers when not in manual connect mode.
 	if cm.cfg.GetNewAddress != nil {
 		curConnReqCount := atomic.LoadUint64(&cm.connReqCount)
 		for i := curConnReqCount; i < uint64(cm.cfg.TargetOutbound); i++ {
-			go cm.newConnReq(ctx)
+			go cm.newConnReq(ctx, ConnTypeFullRelay)
+		}
+		for i := uint32(0); i < cm.cfg.TargetBlockRelayOutbound; i++ {
+			if i < uint32(len(cm.cfg.Anchors)) {
+				go cm.Connect(ctx, &ConnReq{
+					Addr: cm.cfg.Anchors[i],
+					Type: ConnTypeBlockRelay,
+				})
+				continue
+			}
+			go cm.newConnReq(ctx, ConnTypeBlockRelay)
 		}
 	}
 
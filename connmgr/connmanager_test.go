@@ -1,5 +1,5 @@
 // Copyright (c) 2016 The btcsuite developers
-// Copyright (c) 2019-2022 The Decred developers
+// Copyright (c) 2019-2024 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
@@ -237,6 +237,124 @@ func TestTargetOutbound(t *testing.T) {
 	wg.Wait()
 }
 
+// TestTargetBlockRelayOutbound tests the target number of block relay outbound
+// connections configuration option by waiting until all connections are
+// established and ensuring the expected number of each type are made.
+func TestTargetBlockRelayOutbound(t *testing.T) {
+	targetOutbound := uint32(4)
+	targetBlockRelayOutbound := uint32(2)
+	connected := make(chan *ConnReq)
+	cmgr, err := New(&Config{
+		TargetOutbound:           targetOutbound,
+		TargetBlockRelayOutbound: targetBlockRelayOutbound,
+		Dial:                     mockDialer,
+		GetNewAddress: func() (net.Addr, error) {
+			return &net.TCPAddr{
+				IP:   net.ParseIP("127.0.0.1"),
+				Port: 18555,
+			}, nil
+		},
+		OnConnection: func(c *ConnReq, conn net.Conn) {
+			connected <- c
+		},
+	})
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	_, shutdown, wg := runConnMgrAsync(context.Background(), cmgr)
+
+	// Wait for the expected number of target outbound conns to be established
+	// and count them by type.
+	numByType := make(map[ConnType]uint32)
+	for i := uint32(0); i < targetOutbound+targetBlockRelayOutbound; i++ {
+		c := <-connected
+		numByType[c.Type]++
+	}
+	if got := numByType[ConnTypeFullRelay]; got != targetOutbound {
+		t.Fatalf("unexpected number of full relay conns - got %d, want %d",
+			got, targetOutbound)
+	}
+	if got := numByType[ConnTypeBlockRelay]; got != targetBlockRelayOutbound {
+		t.Fatalf("unexpected number of block relay conns - got %d, want %d",
+			got, targetBlockRelayOutbound)
+	}
+
+	// Ensure no additional connections are made.
+	select {
+	case c := <-connected:
+		t.Fatalf("target outbound: got unexpected connection - %v", c.Addr)
+	case <-time.After(time.Millisecond * 5):
+		break
+	}
+
+	// Ensure clean shutdown of connection manager.
+	shutdown()
+	wg.Wait()
+}
+
+// TestAnchors ensures the connection manager connects to the anchor addresses
+// as block relay connections before requesting new addresses for them and
+// ignores any anchors beyond the target number of block relay connections.
+func TestAnchors(t *testing.T) {
+	newAddr := func(ip string) net.Addr {
+		return &net.TCPAddr{IP: net.ParseIP(ip), Port: 18555}
+	}
+	anchors := []net.Addr{
+		newAddr("127.0.0.2"),
+		newAddr("127.0.0.3"),
+		newAddr("127.0.0.4"),
+	}
+	targetBlockRelayOutbound := uint32(2)
+	connected := make(chan *ConnReq)
+	cmgr, err := New(&Config{
+		TargetOutbound:           1,
+		TargetBlockRelayOutbound: targetBlockRelayOutbound,
+		Anchors:                  anchors,
+		Dial:                     mockDialer,
+		GetNewAddress: func() (net.Addr, error) {
+			return newAddr("127.0.0.1"), nil
+		},
+		OnConnection: func(c *ConnReq, conn net.Conn) {
+			connected <- c
+		},
+	})
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	_, shutdown, wg := runConnMgrAsync(context.Background(), cmgr)
+
+	// Wait for the expected number of conns to be established and ensure the
+	// block relay conns are to the anchors within the target.
+	blockRelayAddrs := make(map[string]struct{})
+	for i := uint32(0); i < 1+targetBlockRelayOutbound; i++ {
+		c := <-connected
+		if c.Type == ConnTypeBlockRelay {
+			blockRelayAddrs[c.Addr.String()] = struct{}{}
+		}
+	}
+	if len(blockRelayAddrs) != int(targetBlockRelayOutbound) {
+		t.Fatalf("unexpected number of block relay conns - got %d, want %d",
+			len(blockRelayAddrs), targetBlockRelayOutbound)
+	}
+	for _, anchor := range anchors[:targetBlockRelayOutbound] {
+		if _, ok := blockRelayAddrs[anchor.String()]; !ok {
+			t.Fatalf("no block relay conn to anchor %v", anchor)
+		}
+	}
+
+	// Ensure no additional connections are made.
+	select {
+	case c := <-connected:
+		t.Fatalf("anchors: got unexpected connection - %v", c.Addr)
+	case <-time.After(time.Millisecond * 5):
+		break
+	}
+
+	// Ensure clean shutdown of connection manager.
+	shutdown()
+	wg.Wait()
+}
+
 // TestPassAddrAlongDialAddr tests if when using the DialAddr config option,
 // any address object returned by GetNewAddress will be correctly passed along
 // to DialAddr to be used for connecting to a host.
@@ -16,6 +16,9 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/decred/dcrd/chaincfg/chainhash"
@@ -172,6 +175,26 @@ type blockStore struct {
 	// new blocks are written to.
 	writeCursor *writeCursor
 
+	// The following fields are related to reclaiming the space used by
+	// flat files once all of the blocks they contain have been deleted.
+	//
+	// refsMutex protects concurrent access to the fields below it.  It is
+	// independent from the mutexes described above and MUST NOT be held
+	// while acquiring any of them.
+	//
+	// fileRefs tracks the number of blocks in the block index that are
+	// stored in each flat file.  It is lazily loaded the first time a block
+	// is deleted since the counts are only needed once blocks are deleted
+	// and loading them requires iterating the entire block index.
+	//
+	// reclaimable houses the flat file numbers that no longer contain any
+	// blocks referenced by the block index and are therefore candidates to
+	// be removed from disk once the removal of the associated block index
+	// entries has been persisted.
+	refsMutex   sync.Mutex
+	fileRefs    map[uint32]uint32
+	reclaimable map[uint32]struct{}
+
 	// These functions are set to openFile, openWriteFile, and deleteFile by
 	// default, but are exposed here to allow the whitebox tests to replace
 	// them when working with mock files.
@@ -718,23 +741,174 @@ func (s *blockStore) handleRollback(oldBlockFileNum, oldBlockOffset uint32) {
 	}
 }
 
+// blockFileNums returns the numbers of all flat block files in the provided
+// database directory sorted in ascending order.  Any files that do not match
+// the block file naming scheme are ignored.
+func blockFileNums(dbPath string) []uint32 {
+	entries, err := os.ReadDir(dbPath)
+	if err != nil {
+		return nil
+	}
+
+	const blockFileExt = ".fdb"
+	var fileNums []uint32
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, blockFileExt) {
+			continue
+		}
+		numStr := strings.TrimSuffix(name, blockFileExt)
+		fileNum, err := strconv.ParseUint(numStr, 10, 32)
+		if err != nil || name != fmt.Sprintf(blockFilenameTemplate, fileNum) {
+			continue
+		}
+		fileNums = append(fileNums, uint32(fileNum))
+	}
+	sort.Slice(fileNums, func(i, j int) bool {
+		return fileNums[i] < fileNums[j]
+	})
+	return fileNums
+}
+
+// fileRefsLoaded returns whether or not the per-file block reference counts
+// have been loaded.
+func (s *blockStore) fileRefsLoaded() bool {
+	s.refsMutex.Lock()
+	loaded := s.fileRefs != nil
+	s.refsMutex.Unlock()
+	return loaded
+}
+
+// loadFileRefs sets the per-file block reference counts to the provided
+// values, which must reflect all blocks in the block index, when they have not
+// already been loaded.  It also marks any existing flat files prior to the
+// current write file that are not referenced by any blocks as reclaimable in
+// order to handle unexpected shutdowns after the block index was updated but
+// before the associated files were removed.
+func (s *blockStore) loadFileRefs(refs map[uint32]uint32) {
+	wc := s.writeCursor
+	wc.RLock()
+	curFileNum := wc.curFileNum
+	wc.RUnlock()
+
+	s.refsMutex.Lock()
+	defer s.refsMutex.Unlock()
+	if s.fileRefs != nil {
+		return
+	}
+	s.fileRefs = refs
+	for _, fileNum := range blockFileNums(s.basePath) {
+		if fileNum < curFileNum && refs[fileNum] == 0 {
+			s.reclaimable[fileNum] = struct{}{}
+		}
+	}
+}
+
+// updateFileRefs updates the per-file block reference counts, when they have
+// been loaded, to account for blocks that were added to and removed from the
+// provided flat files.  Files that no longer contain any referenced blocks as a
+// result are marked reclaimable.
+//
+// This MUST only be called once the block index updates associated with the
+// changes have been committed.
+func (s *blockStore) updateFileRefs(added, removed []uint32) {
+	s.refsMutex.Lock()
+	defer s.refsMutex.Unlock()
+	if s.fileRefs == nil {
+		return
+	}
+
+	for _, fileNum := range added {
+		s.fileRefs[fileNum]++
+		delete(s.reclaimable, fileNum)
+	}
+	for _, fileNum := range removed {
+		if s.fileRefs[fileNum] <= 1 {
+			delete(s.fileRefs, fileNum)
+			s.reclaimable[fileNum] = struct{}{}
+			continue
+		}
+		s.fileRefs[fileNum]--
+	}
+}
+
+// reclaimFiles closes and removes all reclaimable flat files prior to the
+// current write file.  Reclaimable files that are at or after the current write
+// file are left in place since new blocks might still be written to them.
+//
+// This MUST only be called once all block index updates that led to the files
+// becoming reclaimable have been persisted.
+func (s *blockStore) reclaimFiles() error {
+	wc := s.writeCursor
+	wc.RLock()
+	curFileNum := wc.curFileNum
+	wc.RUnlock()
+
+	s.refsMutex.Lock()
+	var fileNums []uint32
+	for fileNum := range s.reclaimable {
+		if fileNum < curFileNum {
+			fileNums = append(fileNums, fileNum)
+		}
+	}
+	s.refsMutex.Unlock()
+	sort.Slice(fileNums, func(i, j int) bool {
+		return fileNums[i] < fileNums[j]
+	})
+
+	for _, fileNum := range fileNums {
+		// Close the file when it is open for reads.  This is done under
+		// the write lock for the file in case any readers are currently
+		// reading from it so it's not closed out from under them.
+		s.obfMutex.Lock()
+		if blockFile, ok := s.openBlockFiles[fileNum]; ok {
+			s.lruMutex.Lock()
+			if elem, ok := s.fileNumToLRUElem[fileNum]; ok {
+				s.openBlocksLRU.Remove(elem)
+				delete(s.fileNumToLRUElem, fileNum)
+			}
+			s.lruMutex.Unlock()
+
+			blockFile.Lock()
+			_ = blockFile.file.Close()
+			blockFile.Unlock()
+			delete(s.openBlockFiles, fileNum)
+		}
+		s.obfMutex.Unlock()
+
+		if err := s.deleteFileFunc(fileNum); err != nil {
+			return err
+		}
+		log.Debugf("Reclaimed block file %d", fileNum)
+
+		s.refsMutex.Lock()
+		delete(s.reclaimable, fileNum)
+		s.refsMutex.Unlock()
+	}
+
+	return nil
+}
+
 // scanBlockFiles searches the database directory for all flat block files to
 // find the end of the most recent file.  This position is considered the
 // current write cursor which is also stored in the metadata.  Thus, it is used
 // to detect unexpected shutdowns in the middle of writes so the block files
 // can be reconciled.
 func scanBlockFiles(dbPath string) (int, uint32) {
+	// Note that the block files might not start at zero when older files
+	// have been reclaimed after all of their blocks were deleted, so the
+	// latest file is determined from all files in the directory as opposed
+	// to stopping at the first missing file.
 	lastFile := -1
 	fileLen := uint32(0)
-	for i := 0; ; i++ {
-		filePath := blockFilePath(dbPath, uint32(i))
-		st, err := os.Stat(filePath)
-		if err != nil {
-			break
+	fileNums := blockFileNums(dbPath)
+	if len(fileNums) > 0 {
+		fileNum := fileNums[len(fileNums)-1]
+		st, err := os.Stat(blockFilePath(dbPath, fileNum))
+		if err == nil {
+			lastFile = int(fileNum)
+			fileLen = uint32(st.Size())
 		}
-		lastFile = i
-
-		fileLen = uint32(st.Size())
 	}
 
 	log.Tracef("Scan found latest block file #%d with length %d", lastFile,
@@ -761,6 +935,7 @@ func newBlockStore(basePath string, network wire.CurrencyNet) *blockStore {
 		openBlockFiles:   make(map[uint32]*lockableFile),
 		openBlocksLRU:    list.New(),
 		fileNumToLRUElem: make(map[uint32]*list.Element),
+		reclaimable:      make(map[uint32]struct{}),
 
 		writeCursor: &writeCursor{
 			curFile:    &lockableFile{},
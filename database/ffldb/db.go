@@ -973,6 +973,11 @@ type transaction struct {
 	pendingBlocks    map[chainhash.Hash]int
 	pendingBlockData []pendingBlock
 
+	// Flat file numbers of blocks that are removed from the block index on
+	// commit.  There is an entry for each deleted block, so the same file
+	// number may appear multiple times.
+	pendingDeletes []uint32
+
 	// Keys that need to be stored or deleted on commit.
 	pendingKeys   *treap.Mutable
 	pendingRemove *treap.Mutable
@@ -1196,6 +1201,81 @@ func (tx *transaction) StoreBlock(block database.BlockSerializer) error {
 	return nil
 }
 
+// DeleteBlock removes the block identified by the provided hash from the
+// database.  The block is removed from the block index immediately from the
+// viewpoint of the transaction, while the space used by the flat file that
+// houses it is only reclaimed once all of the blocks in the file have been
+// deleted and the associated block index updates have been flushed to
+// persistent storage.
+//
+// Returns the following errors as required by the interface contract:
+//   - ErrBlockNotFound if the requested block hash does not exist
+//   - ErrTxNotWritable if attempted against a read-only transaction
+//   - ErrTxClosed if the transaction has already been closed
+//
+// This function is part of the database.Tx interface implementation.
+func (tx *transaction) DeleteBlock(hash *chainhash.Hash) error {
+	// Ensure transaction state is valid.
+	if err := tx.checkClosed(); err != nil {
+		return err
+	}
+
+	// Ensure the transaction is writable.
+	if !tx.writable {
+		str := "delete block requires a writable database transaction"
+		return makeDbErr(database.ErrTxNotWritable, str)
+	}
+
+	// Remove the block from the pending blocks to store when it was added
+	// in this transaction since it has not been written yet.
+	if idx, exists := tx.pendingBlocks[*hash]; exists {
+		delete(tx.pendingBlocks, *hash)
+		tx.pendingBlockData = append(tx.pendingBlockData[:idx],
+			tx.pendingBlockData[idx+1:]...)
+		for i := idx; i < len(tx.pendingBlockData); i++ {
+			tx.pendingBlocks[*tx.pendingBlockData[i].hash] = i
+		}
+		log.Tracef("Removed block %s from pending blocks", hash)
+		return nil
+	}
+
+	blockRow, err := tx.fetchBlockRow(hash)
+	if err != nil {
+		return err
+	}
+	location := deserializeBlockLoc(blockRow)
+
+	// Load the number of blocks in each flat file from the block index when
+	// they have not been loaded yet.  This must be done prior to removing
+	// the block from the block index below so the counts reflect the
+	// committed state.
+	store := tx.db.store
+	if !store.fileRefsLoaded() {
+		refs := make(map[uint32]uint32)
+		err := tx.blockIdxBucket.ForEach(func(k, v []byte) error {
+			if len(v) < blockLocSize {
+				str := fmt.Sprintf("block index entry for %x is "+
+					"malformed", k)
+				return makeDbErr(database.ErrCorruption, str)
+			}
+			refs[deserializeBlockLoc(v).blockFileNum]++
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		store.loadFileRefs(refs)
+	}
+
+	if err := tx.blockIdxBucket.Delete(hash[:]); err != nil {
+		return err
+	}
+	tx.pendingDeletes = append(tx.pendingDeletes, location.blockFileNum)
+	log.Tracef("Deleted block %s", hash)
+
+	return nil
+}
+
 // HasBlock returns whether or not a block with the given hash exists in the
 // database.
 //
@@ -1642,6 +1722,7 @@ func (tx *transaction) close() {
 	// Clear pending blocks that would have been written on commit.
 	tx.pendingBlocks = nil
 	tx.pendingBlockData = nil
+	tx.pendingDeletes = nil
 
 	// Clear pending keys that would have been written or deleted on commit.
 	tx.pendingKeys = nil
@@ -1700,6 +1781,7 @@ func (tx *transaction) writePendingAndCommit() error {
 	}
 
 	// Loop through all of the pending blocks to store and write them.
+	addedFileNums := make([]uint32, 0, len(tx.pendingBlockData))
 	for _, blockData := range tx.pendingBlockData {
 		log.Tracef("Storing block %s", blockData.hash)
 		location, err := tx.db.store.writeBlock(blockData.bytes)
@@ -1707,6 +1789,7 @@ func (tx *transaction) writePendingAndCommit() error {
 			rollback()
 			return err
 		}
+		addedFileNums = append(addedFileNums, location.blockFileNum)
 
 		// Add a record in the block index for the block.  The record
 		// includes the location information needed to locate the block
@@ -1730,7 +1813,15 @@ func (tx *transaction) writePendingAndCommit() error {
 
 	// Atomically update the database cache.  The cache automatically
 	// handles flushing to the underlying persistent storage database.
-	return tx.db.cache.commitTx(tx)
+	if err := tx.db.cache.commitTx(tx); err != nil {
+		return err
+	}
+
+	// Update the number of blocks referenced in each flat file now that the
+	// block index changes have been committed so the space used by files
+	// that no longer contain any blocks can be reclaimed.
+	tx.db.store.updateFileRefs(addedFileNums, tx.pendingDeletes)
+	return nil
 }
 
 // Commit commits all changes that have been made to the root metadata bucket
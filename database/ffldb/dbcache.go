@@ -492,9 +492,11 @@ func (c *dbCache) flush() error {
 	cachedRemove := c.cachedRemove
 	c.cacheLock.RUnlock()
 
-	// Nothing to do if there is no data to flush.
+	// Nothing to flush if there is no cached data.  However, any flat files
+	// that have become reclaimable are still removed since that implies
+	// the associated block index updates were already persisted.
 	if cachedKeys.Len() == 0 && cachedRemove.Len() == 0 {
-		return nil
+		return c.store.reclaimFiles()
 	}
 
 	// Perform all leveldb updates using an atomic transaction.
@@ -508,7 +510,9 @@ func (c *dbCache) flush() error {
 	c.cachedRemove = treap.NewImmutable()
 	c.cacheLock.Unlock()
 
-	return nil
+	// Remove any flat files that no longer contain any blocks now that the
+	// block index updates that removed them have been persisted.
+	return c.store.reclaimFiles()
 }
 
 // Flush flushes the database cache to persistent storage.  This involves
@@ -676,3 +676,150 @@ func TestFailureScenarios(t *testing.T) {
 	// Test various corruption scenarios.
 	testCorruption(tc)
 }
+
+// TestDeleteBlocks ensures deleting blocks removes them from the database and
+// reclaims the flat files that no longer contain any blocks once the deletions
+// have been flushed, including across database restarts.
+func TestDeleteBlocks(t *testing.T) {
+	t.Parallel()
+
+	// Create a new database to run tests against.
+	dbPath := t.TempDir()
+	idb, err := openDB(dbPath, blockDataNet, true)
+	if err != nil {
+		t.Fatalf("openDB: unexpected error: %v", err)
+	}
+	t.Cleanup(func() {
+		idb.Close()
+	})
+
+	// Change the maximum file size to a small value to force multiple flat
+	// files with the test data set.
+	store := idb.(*db).store
+	store.maxBlockFileSize = 8192 // 8KiB
+
+	blocks, err := loadBlocks(t, blockDataFile, blockDataNet)
+	if err != nil {
+		t.Fatalf("loadBlocks: unexpected error: %v", err)
+	}
+	lastBlock := blocks[len(blocks)-1]
+
+	// Ensure deleting a block requires a writable transaction.
+	err = idb.View(func(tx database.Tx) error {
+		return tx.DeleteBlock(blocks[0].Hash())
+	})
+	if !checkDbError(t, "DeleteBlock read-only", err, database.ErrTxNotWritable) {
+		return
+	}
+
+	// Ensure deleting a block that does not exist returns the expected
+	// error.
+	err = idb.Update(func(tx database.Tx) error {
+		return tx.DeleteBlock(blocks[0].Hash())
+	})
+	if !checkDbError(t, "DeleteBlock missing", err, database.ErrBlockNotFound) {
+		return
+	}
+
+	// Store all of the blocks while deleting the first one again prior to
+	// committing and ensure it was never stored.
+	err = idb.Update(func(tx database.Tx) error {
+		for _, block := range blocks {
+			if err := tx.StoreBlock(block); err != nil {
+				return err
+			}
+		}
+		return tx.DeleteBlock(blocks[0].Hash())
+	})
+	if err != nil {
+		t.Fatalf("StoreBlock: unexpected error: %v", err)
+	}
+	err = idb.View(func(tx database.Tx) error {
+		for i, block := range blocks {
+			hasBlock, err := tx.HasBlock(block.Hash())
+			if err != nil {
+				return err
+			}
+			if hasBlock != (i != 0) {
+				return fmt.Errorf("HasBlock #%d: got %v, want %v", i,
+					hasBlock, i != 0)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lastFileNum := store.writeCursor.curFileNum
+	if lastFileNum < 2 {
+		t.Fatalf("test data only spans %d files", lastFileNum+1)
+	}
+
+	// Delete all blocks except the final one and ensure none of the flat
+	// files are removed prior to flushing.
+	err = idb.Update(func(tx database.Tx) error {
+		for _, block := range blocks[1 : len(blocks)-1] {
+			if err := tx.DeleteBlock(block.Hash()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("DeleteBlock: unexpected error: %v", err)
+	}
+	if _, err := os.Stat(blockFilePath(dbPath, 0)); err != nil {
+		t.Fatalf("block file removed prior to flush: %v", err)
+	}
+
+	// Ensure all flat files prior to the one that houses the final block
+	// are removed once flushed.
+	if err := idb.Flush(); err != nil {
+		t.Fatalf("Flush: unexpected error: %v", err)
+	}
+	for fileNum := uint32(0); fileNum <= lastFileNum; fileNum++ {
+		_, err := os.Stat(blockFilePath(dbPath, fileNum))
+		wantExists := fileNum == lastFileNum
+		if exists := err == nil; exists != wantExists {
+			t.Fatalf("block file %d: exists %v, want %v", fileNum,
+				exists, wantExists)
+		}
+	}
+
+	// Reopen the database and ensure the remaining block is still available,
+	// the deleted blocks are not, and new blocks are stored after the final
+	// block.
+	if err := idb.Close(); err != nil {
+		t.Fatalf("Close: unexpected error: %v", err)
+	}
+	idb, err = openDB(dbPath, blockDataNet, false)
+	if err != nil {
+		t.Fatalf("openDB: unexpected error: %v", err)
+	}
+	store = idb.(*db).store
+	if store.writeCursor.curFileNum != lastFileNum {
+		t.Fatalf("write cursor file: got %d, want %d",
+			store.writeCursor.curFileNum, lastFileNum)
+	}
+	err = idb.Update(func(tx database.Tx) error {
+		if _, err := tx.FetchBlock(lastBlock.Hash()); err != nil {
+			return err
+		}
+		_, err := tx.FetchBlock(blocks[1].Hash())
+		if !errors.Is(err, database.ErrBlockNotFound) {
+			return fmt.Errorf("FetchBlock deleted block: got %v, want %v",
+				err, database.ErrBlockNotFound)
+		}
+		return tx.StoreBlock(blocks[1])
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = idb.View(func(tx database.Tx) error {
+		_, err := tx.FetchBlock(blocks[1].Hash())
+		return err
+	})
+	if err != nil {
+		t.Fatalf("FetchBlock: unexpected error: %v", err)
+	}
+}
@@ -241,6 +241,26 @@ type Tx interface {
 	// Other errors are possible depending on the implementation.
 	StoreBlock(block BlockSerializer) error
 
+	// DeleteBlock removes the block identified by the provided hash from
+	// the database.  There are no checks to ensure the block is not
+	// referenced by other data such as indexes.  It simply removes the
+	// block from the database.
+	//
+	// The storage consumed by deleted blocks is not necessarily reclaimed
+	// immediately.  Instead, implementations may defer reclaiming it until
+	// doing so is safe with respect to crash recovery, such as once all
+	// blocks sharing the same underlying storage have been deleted and the
+	// removal has been persisted.
+	//
+	// The interface contract guarantees at least the following errors will
+	// be returned (other implementation-specific errors are possible):
+	//   - ErrBlockNotFound if the requested block hash does not exist
+	//   - ErrTxNotWritable if attempted against a read-only transaction
+	//   - ErrTxClosed if the transaction has already been closed
+	//
+	// Other errors are possible depending on the implementation.
+	DeleteBlock(hash *chainhash.Hash) error
+
 	// HasBlock returns whether or not a block with the given hash exists
 	// in the database.
 	//
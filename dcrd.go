@@ -209,10 +209,18 @@ func dcrdMain() error {
 	//
 	// NOTE: The order is important here because dropping the tx index also
 	// drops the address index since it relies on it.
-	if err := indexers.DropAddrIndex(ctx, db); err != nil {
+	if err := indexers.DropLegacyAddrIndex(ctx, db); err != nil {
 		dcrdLog.Errorf("%v", err)
 		return err
 	}
+	if cfg.DropAddrIndex {
+		if err := indexers.DropAddrIndex(ctx, db); err != nil {
+			dcrdLog.Errorf("%v", err)
+			return err
+		}
+
+		return nil
+	}
 	if cfg.DropTxIndex {
 		if err := indexers.DropTxIndex(ctx, db); err != nil {
 			dcrdLog.Errorf("%v", err)
@@ -221,6 +229,14 @@ func dcrdMain() error {
 
 		return nil
 	}
+	if cfg.DropSpendIndex {
+		if err := indexers.DropSpendIndex(ctx, db); err != nil {
+			dcrdLog.Errorf("%v", err)
+			return err
+		}
+
+		return nil
+	}
 	if cfg.DropExistsAddrIndex {
 		if err := indexers.DropExistsAddrIndex(ctx, db); err != nil {
 			dcrdLog.Errorf("%v", err)
@@ -0,0 +1,353 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package schnorr
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+
+	"github.com/decred/dcrd/crypto/blake256"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+// minBatchVerifySize is the minimum number of signatures in a batch for which
+// they are verified together.  Smaller batches are verified individually since
+// the fixed cost of the multi-scalar multiplication outweighs the savings.
+const minBatchVerifySize = 16
+
+// batchEntry houses the values of a single signature that has been added to a
+// batch and are needed to verify it as part of the batch.
+type batchEntry struct {
+	// s is the s value of the signature.
+	s secp256k1.ModNScalar
+
+	// e is the challenge calculated from the r value of the signature and
+	// the message.
+	e secp256k1.ModNScalar
+
+	// q is the public key in affine coordinates.
+	q secp256k1.JacobianPoint
+
+	// r is the point R with an even y coordinate that corresponds to the r
+	// value of the signature in affine coordinates.
+	r secp256k1.JacobianPoint
+
+	// pubKey is the serialized compressed public key and m is the message.
+	// They are committed to by the seed the batch coefficients are derived
+	// from.
+	pubKey [secp256k1.PubKeyBytesLenCompressed]byte
+	m      [scalarSize]byte
+}
+
+// verify returns whether or not the signature the entry was created from is
+// valid by verifying it individually.
+func (entry *batchEntry) verify() bool {
+	// s*G + e*Q == R
+	var sG, eQ, sum secp256k1.JacobianPoint
+	secp256k1.ScalarBaseMultNonConst(&entry.s, &sG)
+	secp256k1.ScalarMultNonConst(&entry.e, &entry.q, &eQ)
+	secp256k1.AddNonConst(&sG, &eQ, &sum)
+	if (sum.X.IsZero() && sum.Y.IsZero()) || sum.Z.IsZero() {
+		return false
+	}
+	sum.ToAffine()
+	return sum.X.Equals(&entry.r.X) && sum.Y.Equals(&entry.r.Y)
+}
+
+// BatchVerifier verifies multiple EC-Schnorr-DCRv0 signatures at once
+// significantly faster than verifying each of them individually.
+//
+// Signatures are added to the batch with Add and then all of them are verified
+// together with Verify.  Verify only reports whether or not all of the
+// signatures in the batch are valid, so callers that need to identify which
+// signature is invalid must fall back to verifying each of them individually
+// when it fails.
+//
+// Signatures that were added to the batch may be removed again with Truncate,
+// which allows callers to roll the batch back to an earlier state, and the
+// signatures of multiple batches may be combined into a single larger batch
+// with Merge.
+//
+// The zero value is an empty batch that is ready to use.  A BatchVerifier is
+// not safe for concurrent access.
+type BatchVerifier struct {
+	entries []batchEntry
+}
+
+// NewBatchVerifier returns a new empty batch with space preallocated for the
+// provided number of signatures.
+func NewBatchVerifier(sizeHint int) *BatchVerifier {
+	return &BatchVerifier{entries: make([]batchEntry, 0, sizeHint)}
+}
+
+// Len returns the number of signatures in the batch.
+func (b *BatchVerifier) Len() int {
+	return len(b.entries)
+}
+
+// Truncate removes all signatures from the batch except the first n that were
+// added to it.  It has no effect when the batch has n or fewer signatures.
+//
+// This is typically used along with Len to remove the signatures that were
+// added to the batch after a given point.
+func (b *BatchVerifier) Truncate(n int) {
+	if n < 0 {
+		n = 0
+	}
+	if n < len(b.entries) {
+		b.entries = b.entries[:n]
+	}
+}
+
+// Merge adds all of the signatures in the passed batch to the batch.  The
+// passed batch is not modified.
+func (b *BatchVerifier) Merge(other *BatchVerifier) {
+	b.entries = append(b.entries, other.entries...)
+}
+
+// Add adds the signature for the provided hash and secp256k1 public key to the
+// batch.
+//
+// An error is returned without adding the signature to the batch when it is
+// possible to determine the signature is invalid without performing the
+// expensive verification, such as when the hash is not 32 bytes or the r value
+// of the signature is not the x coordinate of a point on the curve.  The error
+// kinds are the same as those that would cause individual verification of the
+// signature to fail.
+func (b *BatchVerifier) Add(sig *Signature, hash []byte, pubKey *secp256k1.PublicKey) error {
+	// The checks performed here mirror the individual verification algorithm
+	// described in README.md except that, instead of calculating R = s*G + e*Q
+	// and checking R.x == r, the point R with an even y coordinate is
+	// recovered from r so that s*G + e*Q == R may be verified for all
+	// signatures in the batch at once.
+	if len(hash) != scalarSize {
+		str := fmt.Sprintf("wrong size for message (got %v, want %v)",
+			len(hash), scalarSize)
+		return signatureError(ErrInvalidHashLen, str)
+	}
+	if !pubKey.IsOnCurve() {
+		str := "pubkey point is not on curve"
+		return signatureError(ErrPubKeyNotOnCurve, str)
+	}
+
+	// e = BLAKE-256(r || m) (Ensure r is padded to 32 bytes)
+	var commitmentInput [scalarSize * 2]byte
+	sig.r.PutBytesUnchecked(commitmentInput[0:scalarSize])
+	copy(commitmentInput[scalarSize:], hash)
+	commitment := blake256.Sum256(commitmentInput[:])
+
+	// Fail if e >= n
+	var entry batchEntry
+	if overflow := entry.e.SetBytes(&commitment); overflow != 0 {
+		str := "hash of (R || m) too big"
+		return signatureError(ErrSchnorrHashValue, str)
+	}
+
+	// Recover R with an even y coordinate from r.  Fail if r is not the x
+	// coordinate of a point on the curve since the calculated R.x could never
+	// be equal to it.
+	entry.r.X.Set(&sig.r)
+	if !secp256k1.DecompressY(&entry.r.X, false, &entry.r.Y) {
+		str := "signature r value is not the x coordinate of a point on " +
+			"the curve"
+		return signatureError(ErrSigRNotOnCurve, str)
+	}
+	entry.r.Y.Normalize()
+	entry.r.Z.SetInt(1)
+
+	entry.s.Set(&sig.s)
+	pubKey.AsJacobian(&entry.q)
+	copy(entry.pubKey[:], pubKey.SerializeCompressed())
+	copy(entry.m[:], hash)
+	b.entries = append(b.entries, entry)
+
+	return nil
+}
+
+// calcSeed returns a hash that commits to every signature, public key, and
+// message in the batch which is used to derive the coefficients the batch
+// equation is randomized with.
+func (b *BatchVerifier) calcSeed() [32]byte {
+	var seed [32]byte
+	var sigBytes [scalarSize * 2]byte
+	hasher := blake256.New()
+	for i := range b.entries {
+		entry := &b.entries[i]
+		entry.r.X.PutBytesUnchecked(sigBytes[0:scalarSize])
+		entry.s.PutBytesUnchecked(sigBytes[scalarSize:])
+		hasher.Write(sigBytes[:])
+		hasher.Write(entry.pubKey[:])
+		hasher.Write(entry.m[:])
+	}
+	hasher.Sum(seed[:0])
+	return seed
+}
+
+// Verify returns whether or not all of the signatures in the batch are valid.
+// An empty batch is valid.
+func (b *BatchVerifier) Verify() bool {
+	// The batch is verified by checking the following equation that is a
+	// randomized linear combination of the individual verification equations
+	// s_i*G + e_i*Q_i == R_i:
+	//
+	// (a_1*s_1 + ... + a_n*s_n)*G + a_1*e_1*Q_1 + ... + a_n*e_n*Q_n -
+	//   a_1*R_1 - ... - a_n*R_n == ∞
+	//
+	// The coefficients a_i are 128-bit values derived from a hash of all of
+	// the signatures, public keys, and messages in the batch (except a_1
+	// which is 1), which makes it infeasible to craft invalid signatures that
+	// cancel each other out.
+	//
+	// The terms involving the public keys and R values are calculated with a
+	// single multi-scalar multiplication.
+	numEntries := len(b.entries)
+	if numEntries == 0 {
+		return true
+	}
+	if numEntries < minBatchVerifySize {
+		for i := range b.entries {
+			if !b.entries[i].verify() {
+				return false
+			}
+		}
+		return true
+	}
+
+	seed := b.calcSeed()
+
+	var sumS secp256k1.ModNScalar
+	scalars := make([]secp256k1.ModNScalar, 0, numEntries*2)
+	points := make([]secp256k1.JacobianPoint, 0, numEntries*2)
+	var coeffInput [36]byte
+	copy(coeffInput[:], seed[:])
+	for i := range b.entries {
+		entry := &b.entries[i]
+
+		var a secp256k1.ModNScalar
+		if i == 0 {
+			a.SetInt(1)
+		} else {
+			binary.LittleEndian.PutUint32(coeffInput[32:], uint32(i))
+			coeff := blake256.Sum256(coeffInput[:])
+			a.SetByteSlice(coeff[:16])
+		}
+
+		// a_i*s_i
+		var as secp256k1.ModNScalar
+		as.Mul2(&a, &entry.s)
+		sumS.Add(&as)
+
+		// a_i*e_i*Q_i
+		var ae secp256k1.ModNScalar
+		ae.Mul2(&a, &entry.e)
+		scalars = append(scalars, ae)
+		points = append(points, entry.q)
+
+		// a_i*(-R_i)
+		negR := entry.r
+		negR.Y.Negate(1).Normalize()
+		scalars = append(scalars, a)
+		points = append(points, negR)
+	}
+
+	var sG, sum, result secp256k1.JacobianPoint
+	secp256k1.ScalarBaseMultNonConst(&sumS, &sG)
+	multiScalarMultNonConst(scalars, points, &sum)
+	secp256k1.AddNonConst(&sG, &sum, &result)
+	return (result.X.IsZero() && result.Y.IsZero()) || result.Z.IsZero()
+}
+
+// scalarWindow returns the value of the window of the passed number of bits
+// that starts at the provided bit offset of the scalar, which is given as
+// little-endian 64-bit words.
+func scalarWindow(words *[4]uint64, offset, windowBits uint) uint64 {
+	wordIdx, bitIdx := offset/64, offset%64
+	window := words[wordIdx] >> bitIdx
+	if bitIdx+windowBits > 64 && wordIdx < 3 {
+		window |= words[wordIdx+1] << (64 - bitIdx)
+	}
+	return window & (1<<windowBits - 1)
+}
+
+// multiScalarMultNonConst calculates k_1*P_1 + ... + k_n*P_n for the passed
+// scalars and points and stores the result in the provided result param in
+// *non-constant* time.
+//
+// It uses the bucket method (Pippenger's algorithm) which is significantly
+// faster than calculating each of the scalar multiplications individually
+// since the doublings are shared by all of the points and each point is only
+// added once per window.
+//
+// NOTE: The points must be normalized and are ideally in affine coordinates
+// for the fastest result.  The resulting point will be normalized.
+func multiScalarMultNonConst(scalars []secp256k1.ModNScalar, points []secp256k1.JacobianPoint, result *secp256k1.JacobianPoint) {
+	// Choose the window size based on the number of points since each window
+	// requires adding up all of its buckets.
+	windowBits := uint(bits.Len(uint(len(points))))
+	switch {
+	case windowBits < 4:
+		windowBits = 2
+	case windowBits > 18:
+		windowBits = 16
+	default:
+		windowBits -= 2
+	}
+
+	// Convert the scalars to little-endian 64-bit words for efficient window
+	// extraction.
+	words := make([][4]uint64, len(scalars))
+	for i := range scalars {
+		var b [32]byte
+		scalars[i].PutBytes(&b)
+		for j := 0; j < 4; j++ {
+			words[i][j] = binary.BigEndian.Uint64(b[24-j*8:])
+		}
+	}
+
+	// The buckets hold the sums of all points with the same window value.
+	// Note that there is no bucket for a window value of zero.
+	var acc, tmp secp256k1.JacobianPoint
+	buckets := make([]secp256k1.JacobianPoint, 1<<windowBits-1)
+	numWindows := (256 + windowBits - 1) / windowBits
+	for w := int(numWindows) - 1; w >= 0; w-- {
+		// Shift the accumulated result by the window size.
+		if w != int(numWindows)-1 {
+			for i := uint(0); i < windowBits; i++ {
+				secp256k1.DoubleNonConst(&acc, &tmp)
+				acc.Set(&tmp)
+			}
+		}
+
+		// Add each point to the bucket for its window value.
+		for i := range buckets {
+			buckets[i] = secp256k1.JacobianPoint{}
+		}
+		offset := uint(w) * windowBits
+		for i := range points {
+			window := scalarWindow(&words[i], offset, windowBits)
+			if window == 0 {
+				continue
+			}
+			bucket := &buckets[window-1]
+			secp256k1.AddNonConst(bucket, &points[i], &tmp)
+			bucket.Set(&tmp)
+		}
+
+		// Calculate sum(j * buckets[j-1]) by summing running sums from the
+		// highest bucket down.
+		var runningSum, windowSum secp256k1.JacobianPoint
+		for i := len(buckets) - 1; i >= 0; i-- {
+			secp256k1.AddNonConst(&runningSum, &buckets[i], &tmp)
+			runningSum.Set(&tmp)
+			secp256k1.AddNonConst(&windowSum, &runningSum, &tmp)
+			windowSum.Set(&tmp)
+		}
+		secp256k1.AddNonConst(&acc, &windowSum, &tmp)
+		acc.Set(&tmp)
+	}
+
+	result.Set(&acc)
+}
@@ -0,0 +1,233 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package schnorr
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+// batchTestSig houses a signature along with the hash and public key it is
+// for.
+type batchTestSig struct {
+	sig    *Signature
+	hash   []byte
+	pubKey *secp256k1.PublicKey
+}
+
+// randBatchTestSigs returns the passed number of valid signatures for random
+// private keys and messages.
+func randBatchTestSigs(t testing.TB, rng *rand.Rand, num int) []batchTestSig {
+	t.Helper()
+
+	sigs := make([]batchTestSig, 0, num)
+	for i := 0; i < num; i++ {
+		var buf [32]byte
+		if _, err := rng.Read(buf[:]); err != nil {
+			t.Fatalf("failed to read random private key: %v", err)
+		}
+		var privKeyScalar secp256k1.ModNScalar
+		privKeyScalar.SetBytes(&buf)
+		privKey := secp256k1.NewPrivateKey(&privKeyScalar)
+
+		hash := make([]byte, 32)
+		if _, err := rng.Read(hash); err != nil {
+			t.Fatalf("failed to read random hash: %v", err)
+		}
+		sig, err := Sign(privKey, hash)
+		if err != nil {
+			t.Fatalf("failed to sign: %v", err)
+		}
+		sigs = append(sigs, batchTestSig{sig, hash, privKey.PubKey()})
+	}
+	return sigs
+}
+
+// TestBatchVerify ensures batch verification succeeds for batches of valid
+// signatures and fails for batches that contain an invalid signature.
+func TestBatchVerify(t *testing.T) {
+	// Use a unique random seed each test instance and log it if the tests fail.
+	seed := time.Now().Unix()
+	rng := rand.New(rand.NewSource(seed))
+	defer func(t *testing.T, seed int64) {
+		if t.Failed() {
+			t.Logf("random seed: %d", seed)
+		}
+	}(t, seed)
+
+	// Ensure an empty batch is valid.
+	if !new(BatchVerifier).Verify() {
+		t.Fatal("empty batch did not verify")
+	}
+
+	for _, numSigs := range []int{1, 2, 3, 16, 100} {
+		sigs := randBatchTestSigs(t, rng, numSigs)
+		batch := NewBatchVerifier(numSigs)
+		for _, s := range sigs {
+			if err := batch.Add(s.sig, s.hash, s.pubKey); err != nil {
+				t.Fatalf("%d sigs: unexpected error adding sig: %v", numSigs,
+					err)
+			}
+		}
+		if batch.Len() != numSigs {
+			t.Fatalf("%d sigs: unexpected batch len %d", numSigs, batch.Len())
+		}
+		if !batch.Verify() {
+			t.Fatalf("%d sigs: valid batch did not verify", numSigs)
+		}
+
+		// Change the message of a random signature and ensure the batch
+		// fails to verify.
+		badIdx := rng.Intn(numSigs)
+		batch = NewBatchVerifier(numSigs)
+		for i, s := range sigs {
+			hash := s.hash
+			if i == badIdx {
+				hash = make([]byte, len(s.hash))
+				copy(hash, s.hash)
+				hash[rng.Intn(len(hash))] ^= 1 << rng.Intn(7)
+			}
+			if err := batch.Add(s.sig, hash, s.pubKey); err != nil {
+				// The bad message may result in a challenge that is too
+				// big which is also a failure.
+				continue
+			}
+		}
+		if batch.Verify() {
+			t.Fatalf("%d sigs: batch with bad message %d verified", numSigs,
+				badIdx)
+		}
+
+		// Use the public key of a different private key for a random signature
+		// and ensure the batch fails to verify.
+		otherPubKey := randBatchTestSigs(t, rng, 1)[0].pubKey
+		batch = NewBatchVerifier(numSigs)
+		for i, s := range sigs {
+			pubKey := s.pubKey
+			if i == badIdx {
+				pubKey = otherPubKey
+			}
+			if err := batch.Add(s.sig, s.hash, pubKey); err != nil {
+				t.Fatalf("%d sigs: unexpected error adding sig: %v", numSigs,
+					err)
+			}
+		}
+		if batch.Verify() {
+			t.Fatalf("%d sigs: batch with bad public key %d verified",
+				numSigs, badIdx)
+		}
+	}
+}
+
+// TestBatchAddErrors ensures adding signatures that are known to be invalid
+// without verifying them to a batch returns the expected errors.
+func TestBatchAddErrors(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	s := randBatchTestSigs(t, rng, 1)[0]
+
+	// Ensure a hash with the wrong length is rejected.
+	var batch BatchVerifier
+	err := batch.Add(s.sig, s.hash[:31], s.pubKey)
+	if !errors.Is(err, ErrInvalidHashLen) {
+		t.Fatalf("mismatched err -- got %v, want %v", err, ErrInvalidHashLen)
+	}
+
+	// Ensure an r value that is not the x coordinate of a point on the curve
+	// is rejected.
+	var r, y secp256k1.FieldVal
+	for x := uint16(1); ; x++ {
+		r.SetInt(x)
+		if !secp256k1.DecompressY(&r, false, &y) {
+			break
+		}
+	}
+	err = batch.Add(NewSignature(&r, &s.sig.s), s.hash, s.pubKey)
+	if !errors.Is(err, ErrSigRNotOnCurve) {
+		t.Fatalf("mismatched err -- got %v, want %v", err, ErrSigRNotOnCurve)
+	}
+
+	// Ensure signatures that fail to be added are not part of the batch.
+	if batch.Len() != 0 {
+		t.Fatalf("unexpected batch len %d", batch.Len())
+	}
+}
+
+// TestBatchTruncateAndMerge ensures removing signatures from a batch with
+// Truncate and combining batches with Merge produce batches that only verify
+// when all of the remaining signatures are valid.
+func TestBatchTruncateAndMerge(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	sigs := randBatchTestSigs(t, rng, 64)
+
+	// Create a batch with valid signatures followed by an invalid one.
+	var batch BatchVerifier
+	for _, s := range sigs[:32] {
+		if err := batch.Add(s.sig, s.hash, s.pubKey); err != nil {
+			t.Fatalf("unexpected error adding sig: %v", err)
+		}
+	}
+	numValid := batch.Len()
+	badSig := sigs[32]
+	if err := batch.Add(badSig.sig, sigs[33].hash, badSig.pubKey); err != nil {
+		t.Fatalf("unexpected error adding sig: %v", err)
+	}
+	if batch.Verify() {
+		t.Fatal("batch with invalid signature verified")
+	}
+
+	// Ensure truncating the batch to the valid signatures removes the
+	// invalid one and that truncating to a larger size has no effect.
+	batch.Truncate(numValid)
+	if batch.Len() != numValid {
+		t.Fatalf("unexpected batch len %d after truncate", batch.Len())
+	}
+	batch.Truncate(numValid + 1)
+	if batch.Len() != numValid {
+		t.Fatalf("unexpected batch len %d after truncate", batch.Len())
+	}
+	if !batch.Verify() {
+		t.Fatal("truncated batch did not verify")
+	}
+
+	// Ensure merging another batch of valid signatures results in a valid
+	// batch with all of the signatures and does not modify the other batch.
+	other := NewBatchVerifier(len(sigs) - 33)
+	for _, s := range sigs[33:] {
+		if err := other.Add(s.sig, s.hash, s.pubKey); err != nil {
+			t.Fatalf("unexpected error adding sig: %v", err)
+		}
+	}
+	batch.Merge(other)
+	if batch.Len() != numValid+other.Len() {
+		t.Fatalf("unexpected batch len %d after merge", batch.Len())
+	}
+	if other.Len() != len(sigs)-33 {
+		t.Fatalf("unexpected other batch len %d after merge", other.Len())
+	}
+	if !batch.Verify() {
+		t.Fatal("merged batch did not verify")
+	}
+
+	// Ensure merging a batch with an invalid signature results in a batch
+	// that fails to verify.
+	var bad BatchVerifier
+	if err := bad.Add(badSig.sig, sigs[33].hash, badSig.pubKey); err != nil {
+		t.Fatalf("unexpected error adding sig: %v", err)
+	}
+	batch.Merge(&bad)
+	if batch.Verify() {
+		t.Fatal("merged batch with invalid signature verified")
+	}
+
+	// Ensure truncating to zero results in an empty valid batch.
+	batch.Truncate(0)
+	if batch.Len() != 0 || !batch.Verify() {
+		t.Fatal("batch truncated to zero is not an empty valid batch")
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package musig2 provides MuSig2 multi-signatures for EC-Schnorr-DCRv0.
+
+This package implements the MuSig2 multi-signature scheme adapted to the
+EC-Schnorr-DCRv0 signature scheme provided by the parent schnorr package.  It
+allows a group of signers to jointly produce a single 64-byte signature for an
+aggregate public key that is indistinguishable from, and verifies exactly the
+same as, a signature produced by a single signer.  Consequently, the resulting
+signatures are usable anywhere a standard EC-Schnorr-DCRv0 signature is, such as
+pay-to-pubkey scripts, without any changes to the consensus rules.
+
+A comprehensive suite of tests is provided to ensure proper functionality.
+
+# Overview
+
+Producing a multi-signature involves the following steps:
+
+  - Key aggregation: the public keys of all signers are combined into an
+    aggregate public key via AggregateKeys.  Each key is weighted by a
+    coefficient that commits to the full list of keys which prevents rogue key
+    attacks
+  - Nonce generation: each signer generates a secret nonce and an associated
+    public nonce via GenerateNonces and shares the public nonce with the others
+  - Nonce aggregation: the public nonces of all signers are combined into an
+    aggregate nonce via AggregateNonces
+  - Partial signing: each signer produces a partial signature with Sign and
+    shares it with the others
+  - Signature combination: the partial signatures are combined into the final
+    signature via CombinePartialSignatures
+
+Each public nonce consists of two points which is what allows MuSig2 to complete
+in two rounds of communication while remaining secure under concurrent signing
+sessions.  The nonce generation round does not depend on the message, so it may
+be performed in advance.
+
+# Differences From BIP-0327
+
+The scheme closely follows BIP-0327 with the following differences that are
+required to produce EC-Schnorr-DCRv0 signatures:
+
+  - Public keys are serialized in their 33-byte compressed form and the
+    aggregate public key is not forced to have an even y coordinate
+  - All hashes use BLAKE-256 with 14 rounds and are domain separated with tags
+    specific to this package
+  - The challenge is calculated as e = BLAKE-256(R.x || m) which is the same as
+    EC-Schnorr-DCRv0, so the challenge does NOT commit to the aggregate public
+    key as it does in BIP-0327.  See the Challenge Does Not Commit To The
+    Aggregate Key section for the implications
+  - Signing computes s = k - e*a*d in line with EC-Schnorr-DCRv0 signing
+
+Tweaking of the aggregate key is not supported.
+
+# Challenge Does Not Commit To The Aggregate Key
+
+The security proof of MuSig2 assumes a challenge that commits to the aggregate
+public key Q.  That is not possible here without breaking compatibility with
+EC-Schnorr-DCRv0 because its challenge only commits to the nonce point and the
+message.  While the nonce coefficient still commits to Q, this deviation means
+the resulting signatures are malleable across related keys.  Specifically,
+given a signature (R, s) for a message m that is valid for Q, anyone who knows
+the discrete log t of the difference between Q and a related key Q' = Q + t*G
+is able to produce a signature (R, s - e*t) for the same message that is valid
+for Q'.
+
+Callers MUST take the following into account:
+
+  - Never sign the same message with aggregate keys that are related by a known
+    offset, such as keys derived from one another via non-hardened derivation.
+    Ideally, the message commits to the aggregate key, for example by including
+    the serialized aggregate key in the data that is hashed to produce it
+  - Never tweak an aggregate key, for example to commit to a script or other
+    data, since a signature for the tweaked key can be converted to one for the
+    untweaked key and vice versa
+
+# Nonce Reuse
+
+Reusing a secret nonce to produce more than one partial signature leaks the
+private key.  In order to help prevent this, SecretNonce intentionally can not
+be serialized and is cleared by Sign once it is used.  Callers must never
+attempt to persist or copy secret nonces.
+
+# Errors
+
+Errors returned by this package are of type musig2.Error and fully support the
+standard library errors.Is and errors.As functions.
+*/
+package musig2
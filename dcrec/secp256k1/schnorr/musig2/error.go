@@ -0,0 +1,99 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package musig2
+
+// ErrorKind identifies a kind of error.  It has full support for errors.Is
+// and errors.As, so the caller can directly check against an error kind
+// when determining the reason for an error.
+type ErrorKind string
+
+// These constants are used to identify a specific Error.
+const (
+	// ErrNoPubKeys indicates an attempt was made to aggregate an empty set of
+	// public keys.
+	ErrNoPubKeys = ErrorKind("ErrNoPubKeys")
+
+	// ErrAggregateKeyIsInfinity indicates the aggregation of a set of public
+	// keys resulted in the point at infinity.
+	ErrAggregateKeyIsInfinity = ErrorKind("ErrAggregateKeyIsInfinity")
+
+	// ErrPubKeyNotInAggregate indicates an attempt was made to sign or verify
+	// a partial signature with a public key that is not part of the aggregate
+	// key.
+	ErrPubKeyNotInAggregate = ErrorKind("ErrPubKeyNotInAggregate")
+
+	// ErrInvalidHashLen indicates that the input hash to sign or verify is not
+	// the required length.
+	ErrInvalidHashLen = ErrorKind("ErrInvalidHashLen")
+
+	// ErrPrivateKeyIsZero indicates an attempt was made to sign a message with
+	// a private key that is equal to zero.
+	ErrPrivateKeyIsZero = ErrorKind("ErrPrivateKeyIsZero")
+
+	// ErrNoNonces indicates an attempt was made to aggregate an empty set of
+	// public nonces.
+	ErrNoNonces = ErrorKind("ErrNoNonces")
+
+	// ErrInvalidNonce indicates a public nonce or aggregate nonce is not
+	// properly encoded.
+	ErrInvalidNonce = ErrorKind("ErrInvalidNonce")
+
+	// ErrSecretNonceUsed indicates an attempt was made to sign with a secret
+	// nonce that was already used to sign.  Reusing a secret nonce would leak
+	// the private key.
+	ErrSecretNonceUsed = ErrorKind("ErrSecretNonceUsed")
+
+	// ErrSecretNonceKeyMismatch indicates an attempt was made to sign with a
+	// secret nonce that was generated for a different private key.
+	ErrSecretNonceKeyMismatch = ErrorKind("ErrSecretNonceKeyMismatch")
+
+	// ErrSchnorrHashValue indicates that the hash of (R || m) was too large and
+	// so the signing session must be restarted with new nonces.
+	ErrSchnorrHashValue = ErrorKind("ErrSchnorrHashValue")
+
+	// ErrPartialSigTooBig indicates a serialized partial signature is not in
+	// the valid range for secp256k1 scalars.
+	ErrPartialSigTooBig = ErrorKind("ErrPartialSigTooBig")
+
+	// ErrInvalidPartialSigLen indicates a serialized partial signature is not
+	// the required length.
+	ErrInvalidPartialSigLen = ErrorKind("ErrInvalidPartialSigLen")
+
+	// ErrPartialSigInvalid indicates a partial signature is not valid for the
+	// public key, public nonce, and signing session it was verified against.
+	ErrPartialSigInvalid = ErrorKind("ErrPartialSigInvalid")
+
+	// ErrNoPartialSigs indicates an attempt was made to combine an empty set
+	// of partial signatures.
+	ErrNoPartialSigs = ErrorKind("ErrNoPartialSigs")
+)
+
+// Error satisfies the error interface and prints human-readable errors.
+func (e ErrorKind) Error() string {
+	return string(e)
+}
+
+// Error identifies an error related to MuSig2 multi-signatures.  It has full
+// support for errors.Is and errors.As, so the caller can ascertain the
+// specific reason for the error by checking the underlying error.
+type Error struct {
+	Err         error
+	Description string
+}
+
+// Error satisfies the error interface and prints human-readable errors.
+func (e Error) Error() string {
+	return e.Description
+}
+
+// Unwrap returns the underlying wrapped error.
+func (e Error) Unwrap() error {
+	return e.Err
+}
+
+// makeError creates an Error given a set of arguments.
+func makeError(kind ErrorKind, desc string) Error {
+	return Error{Err: kind, Description: desc}
+}
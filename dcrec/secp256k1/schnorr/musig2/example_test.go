@@ -0,0 +1,88 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package musig2_test
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/decred/dcrd/crypto/blake256"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/schnorr/musig2"
+)
+
+// This example demonstrates two signers jointly producing an EC-Schnorr-DCRv0
+// signature for their aggregate public key.  In practice, each signer runs on
+// a separate device and only exchanges public keys, public nonces, and partial
+// signatures with the others.
+func Example() {
+	// Decode the hex-encoded private keys of the signers.
+	var privKeys []*secp256k1.PrivateKey
+	for _, privKeyHex := range []string{
+		"22a47fa09a223f2aa079edf85a7c2d4f8720ee63e502ee2869afab7de234b80c",
+		"eaf02ca348c524e6392655ba4d29603cd1a7347d9d65cfe93ce1ebffdca22694",
+	} {
+		pkBytes, err := hex.DecodeString(privKeyHex)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		privKeys = append(privKeys, secp256k1.PrivKeyFromBytes(pkBytes))
+	}
+
+	// Aggregate the public keys of all signers in a canonical order.
+	pubKeys := musig2.SortKeys([]*secp256k1.PublicKey{privKeys[0].PubKey(),
+		privKeys[1].PubKey()})
+	aggKey, err := musig2.AggregateKeys(pubKeys)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// Each signer generates nonces for the message and shares the public
+	// nonce with the others.
+	message := "test message"
+	messageHash := blake256.Sum256([]byte(message))
+	secNonces := make([]*musig2.SecretNonce, len(privKeys))
+	pubNonces := make([][musig2.PubNonceSize]byte, len(privKeys))
+	for i, privKey := range privKeys {
+		secNonces[i], pubNonces[i], err = musig2.GenerateNonces(privKey,
+			aggKey, messageHash[:])
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+	aggNonce, err := musig2.AggregateNonces(pubNonces)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// Each signer produces a partial signature and shares it.
+	partialSigs := make([]*musig2.PartialSignature, len(privKeys))
+	for i, privKey := range privKeys {
+		partialSigs[i], err = musig2.Sign(secNonces[i], privKey, &aggNonce,
+			aggKey, messageHash[:])
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+
+	// Combine the partial signatures into the final signature and verify it
+	// as a standard signature for the aggregate public key.
+	signature, err := musig2.CombinePartialSignatures(&aggNonce, aggKey,
+		messageHash[:], partialSigs)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	verified := signature.Verify(messageHash[:], aggKey.PubKey())
+	fmt.Printf("Signature Verified? %v\n", verified)
+
+	// Output:
+	// Signature Verified? true
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package musig2
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/decred/dcrd/crypto/blake256"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+const (
+	// pubKeySize is the size of a serialized compressed public key.
+	pubKeySize = 33
+
+	// scalarSize is the size of an encoded big endian scalar.
+	scalarSize = 32
+)
+
+var (
+	// These are the tags used to domain separate the hashes used throughout
+	// the scheme so they are not able to collide with each other or with
+	// hashes used for other purposes.
+	tagKeyAggList  = []byte("DCR-MuSig2/keyagg list")
+	tagKeyAggCoeff = []byte("DCR-MuSig2/keyagg coef")
+	tagNonceCoeff  = []byte("DCR-MuSig2/noncecoef")
+	tagNonce       = []byte("DCR-MuSig2/nonce")
+)
+
+// taggedHash implements a tagged hash with BLAKE-256 as the hash function.  It
+// is defined as:
+//
+//	BLAKE-256(BLAKE-256(tag) || BLAKE-256(tag) || data[0] || ... || data[n-1])
+func taggedHash(tag []byte, data ...[]byte) [32]byte {
+	tagHash := blake256.Sum256(tag)
+	h := blake256.New()
+	h.Write(tagHash[:])
+	h.Write(tagHash[:])
+	for _, d := range data {
+		h.Write(d)
+	}
+	var hash [32]byte
+	h.Sum(hash[:0])
+	return hash
+}
+
+// AggregateKey houses an aggregate public key along with the public keys it
+// aggregates and the information needed to determine the coefficient each of
+// them contributes to it.
+//
+// The aggregate public key is a normal secp256k1 public key, so signatures
+// produced by the signers for it verify with schnorr.Signature.Verify and
+// may be used anywhere a single-signer Schnorr public key is expected, such as
+// pay-to-pubkey scripts.
+type AggregateKey struct {
+	// key is the aggregate public key.
+	key *secp256k1.PublicKey
+
+	// pubKeys are the serialized public keys that were aggregated in the
+	// order they were provided.
+	pubKeys [][]byte
+
+	// listHash is the hash of the list of all aggregated public keys.
+	listHash [32]byte
+
+	// secondKey is the first serialized public key in the list that differs
+	// from the first key in the list or nil when all keys are the same.
+	secondKey []byte
+}
+
+// SortKeys returns a copy of the passed public keys sorted lexicographically by
+// their compressed serialization.  It may be used prior to AggregateKeys to
+// obtain an aggregate key that does not depend on the order of the keys.
+func SortKeys(pubKeys []*secp256k1.PublicKey) []*secp256k1.PublicKey {
+	sorted := make([]*secp256k1.PublicKey, len(pubKeys))
+	copy(sorted, pubKeys)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].SerializeCompressed(),
+			sorted[j].SerializeCompressed()) < 0
+	})
+	return sorted
+}
+
+// AggregateKeys aggregates the passed public keys into a single public key
+// that the owners of the associated private keys may jointly produce
+// signatures for.
+//
+// The aggregate key depends on the order of the public keys, so all signers
+// must provide the keys in the same order.  See SortKeys to obtain a canonical
+// order.
+//
+// The algorithm for aggregating the keys is:
+//
+//	P_i = public key i
+//	L = H_list(P_1 || ... || P_n)
+//	a_i = 1 if P_i is the second distinct key in the list, otherwise
+//	a_i = H_coef(L || P_i) mod n
+//	Q = a_1*P_1 + ... + a_n*P_n
+func AggregateKeys(pubKeys []*secp256k1.PublicKey) (*AggregateKey, error) {
+	if len(pubKeys) == 0 {
+		str := "no public keys to aggregate"
+		return nil, makeError(ErrNoPubKeys, str)
+	}
+
+	// Serialize the keys and calculate the hash of the key list.
+	aggKey := AggregateKey{pubKeys: make([][]byte, 0, len(pubKeys))}
+	for _, pubKey := range pubKeys {
+		serialized := pubKey.SerializeCompressed()
+		aggKey.pubKeys = append(aggKey.pubKeys, serialized)
+		if aggKey.secondKey == nil &&
+			!bytes.Equal(serialized, aggKey.pubKeys[0]) {
+
+			aggKey.secondKey = serialized
+		}
+	}
+	aggKey.listHash = taggedHash(tagKeyAggList, aggKey.pubKeys...)
+
+	// Q = a_1*P_1 + ... + a_n*P_n
+	var q secp256k1.JacobianPoint
+	for i, pubKey := range pubKeys {
+		var p, aP, sum secp256k1.JacobianPoint
+		pubKey.AsJacobian(&p)
+		a := aggKey.coefficient(aggKey.pubKeys[i])
+		secp256k1.ScalarMultNonConst(&a, &p, &aP)
+		secp256k1.AddNonConst(&q, &aP, &sum)
+		q.Set(&sum)
+	}
+	if (q.X.IsZero() && q.Y.IsZero()) || q.Z.IsZero() {
+		str := "aggregate public key is the point at infinity"
+		return nil, makeError(ErrAggregateKeyIsInfinity, str)
+	}
+	q.ToAffine()
+	aggKey.key = secp256k1.NewPublicKey(&q.X, &q.Y)
+
+	return &aggKey, nil
+}
+
+// PubKey returns the aggregate public key.
+func (k *AggregateKey) PubKey() *secp256k1.PublicKey {
+	return k.key
+}
+
+// hasKey returns whether or not the passed serialized public key is one of the
+// keys that were aggregated.
+func (k *AggregateKey) hasKey(serializedPubKey []byte) bool {
+	for _, pubKey := range k.pubKeys {
+		if bytes.Equal(pubKey, serializedPubKey) {
+			return true
+		}
+	}
+	return false
+}
+
+// coefficient returns the coefficient the passed serialized public key
+// contributes to the aggregate key with.
+//
+// The coefficient of the second distinct key in the list is 1, which saves a
+// scalar multiplication for one of the keys without affecting the security of
+// the scheme.
+func (k *AggregateKey) coefficient(serializedPubKey []byte) secp256k1.ModNScalar {
+	var a secp256k1.ModNScalar
+	if k.secondKey != nil && bytes.Equal(serializedPubKey, k.secondKey) {
+		a.SetInt(1)
+		return a
+	}
+
+	hash := taggedHash(tagKeyAggCoeff, k.listHash[:], serializedPubKey)
+	a.SetBytes(&hash)
+	return a
+}
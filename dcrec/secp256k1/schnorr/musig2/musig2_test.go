@@ -0,0 +1,392 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package musig2
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/decred/dcrd/crypto/blake256"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+// hexToBytes converts the passed hex string into bytes and will panic if there
+// is an error.  This is only provided for the hard-coded constants so errors in
+// the source code can be detected.  It will only (and must only) be called with
+// hard-coded values.
+func hexToBytes(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic("invalid hex in source file: " + s)
+	}
+	return b
+}
+
+// testPrivKeys returns deterministic private keys for use in the tests.
+func testPrivKeys(t *testing.T, num int) []*secp256k1.PrivateKey {
+	t.Helper()
+
+	privKeys := make([]*secp256k1.PrivateKey, 0, num)
+	for i := 0; i < num; i++ {
+		seed := blake256.Sum256([]byte{byte(i)})
+		privKeys = append(privKeys, secp256k1.PrivKeyFromBytes(seed[:]))
+	}
+	return privKeys
+}
+
+// testPubKeys returns the public keys for the passed private keys.
+func testPubKeys(privKeys []*secp256k1.PrivateKey) []*secp256k1.PublicKey {
+	pubKeys := make([]*secp256k1.PublicKey, 0, len(privKeys))
+	for _, privKey := range privKeys {
+		pubKeys = append(pubKeys, privKey.PubKey())
+	}
+	return pubKeys
+}
+
+// testSession houses the state of a signing session run by the tests.
+type testSession struct {
+	privKeys  []*secp256k1.PrivateKey
+	aggKey    *AggregateKey
+	secNonces []*SecretNonce
+	pubNonces [][PubNonceSize]byte
+	aggNonce  [PubNonceSize]byte
+	hash      []byte
+}
+
+// newTestSession creates a new signing session for the passed private keys and
+// message hash through the nonce aggregation round.
+func newTestSession(t *testing.T, privKeys []*secp256k1.PrivateKey, hash []byte) *testSession {
+	t.Helper()
+
+	aggKey, err := AggregateKeys(testPubKeys(privKeys))
+	if err != nil {
+		t.Fatalf("unexpected error aggregating keys: %v", err)
+	}
+	s := testSession{privKeys: privKeys, aggKey: aggKey, hash: hash}
+	for _, privKey := range privKeys {
+		secNonce, pubNonce, err := GenerateNonces(privKey, aggKey, hash)
+		if err != nil {
+			t.Fatalf("unexpected error generating nonces: %v", err)
+		}
+		s.secNonces = append(s.secNonces, secNonce)
+		s.pubNonces = append(s.pubNonces, pubNonce)
+	}
+	s.aggNonce, err = AggregateNonces(s.pubNonces)
+	if err != nil {
+		t.Fatalf("unexpected error aggregating nonces: %v", err)
+	}
+	return &s
+}
+
+// TestMuSig2 ensures that signatures jointly produced by multiple signers
+// verify as EC-Schnorr-DCRv0 signatures for the aggregate public key and that
+// the partial signatures verify for each signer.
+func TestMuSig2(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string // test description
+		numKeys   int    // number of signers
+		duplicate bool   // whether or not to duplicate the first key
+		sortKeys  bool   // whether or not to sort the keys
+	}{{
+		name:    "single signer",
+		numKeys: 1,
+	}, {
+		name:    "two signers",
+		numKeys: 2,
+	}, {
+		name:    "three signers",
+		numKeys: 3,
+	}, {
+		name:     "five signers with sorted keys",
+		numKeys:  5,
+		sortKeys: true,
+	}, {
+		name:      "three signers with duplicate key",
+		numKeys:   3,
+		duplicate: true,
+	}}
+
+	for _, test := range tests {
+		privKeys := testPrivKeys(t, test.numKeys)
+		if test.duplicate {
+			privKeys = append(privKeys, privKeys[0])
+		}
+		if test.sortKeys {
+			pubKeys := SortKeys(testPubKeys(privKeys))
+			for i := 1; i < len(pubKeys); i++ {
+				if bytes.Compare(pubKeys[i-1].SerializeCompressed(),
+					pubKeys[i].SerializeCompressed()) > 0 {
+
+					t.Fatalf("%q: keys are not sorted", test.name)
+				}
+			}
+		}
+
+		// Run several sessions for different messages to exercise both
+		// parities of the final nonce point.
+		for i := 0; i < 8; i++ {
+			hash := blake256.Sum256([]byte{byte(i)})
+			s := newTestSession(t, privKeys, hash[:])
+
+			var partialSigs []*PartialSignature
+			for j, privKey := range privKeys {
+				partialSig, err := Sign(s.secNonces[j], privKey, &s.aggNonce,
+					s.aggKey, hash[:])
+				if err != nil {
+					t.Fatalf("%q: unexpected error signing: %v", test.name,
+						err)
+				}
+
+				// Ensure the partial signature round trips through its
+				// serialization and verifies.
+				partialSig, err = ParsePartialSignature(partialSig.Serialize())
+				if err != nil {
+					t.Fatalf("%q: unexpected error parsing partial sig: %v",
+						test.name, err)
+				}
+				err = verifyPartial(partialSig, &s.pubNonces[j],
+					privKey.PubKey(), &s.aggNonce, s.aggKey, hash[:])
+				if err != nil {
+					t.Fatalf("%q: partial signature %d did not verify: %v",
+						test.name, j, err)
+				}
+				partialSigs = append(partialSigs, partialSig)
+			}
+
+			sig, err := CombinePartialSignatures(&s.aggNonce, s.aggKey,
+				hash[:], partialSigs)
+			if err != nil {
+				t.Fatalf("%q: unexpected error combining: %v", test.name, err)
+			}
+			if !sig.Verify(hash[:], s.aggKey.PubKey()) {
+				t.Fatalf("%q: aggregate signature did not verify", test.name)
+			}
+
+			// Ensure the signature does not verify when a partial signature
+			// is missing.
+			if len(partialSigs) > 1 {
+				sig, err := CombinePartialSignatures(&s.aggNonce, s.aggKey,
+					hash[:], partialSigs[1:])
+				if err != nil {
+					t.Fatalf("%q: unexpected error combining: %v", test.name,
+						err)
+				}
+				if sig.Verify(hash[:], s.aggKey.PubKey()) {
+					t.Fatalf("%q: signature missing a partial signature "+
+						"verified", test.name)
+				}
+			}
+		}
+	}
+}
+
+// TestAggregateKeys ensures key aggregation depends on the order of the keys and
+// rejects invalid sets of keys.
+func TestAggregateKeys(t *testing.T) {
+	t.Parallel()
+
+	pubKeys := testPubKeys(testPrivKeys(t, 3))
+	aggKey, err := AggregateKeys(pubKeys)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	reversed := []*secp256k1.PublicKey{pubKeys[2], pubKeys[1], pubKeys[0]}
+	reversedAggKey, err := AggregateKeys(reversed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if aggKey.PubKey().IsEqual(reversedAggKey.PubKey()) {
+		t.Fatal("aggregate key does not depend on the order of the keys")
+	}
+
+	// Ensure sorting the keys produces the same aggregate key regardless of
+	// the original order.
+	sortedAggKey, err := AggregateKeys(SortKeys(pubKeys))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sortedReversedAggKey, err := AggregateKeys(SortKeys(reversed))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !sortedAggKey.PubKey().IsEqual(sortedReversedAggKey.PubKey()) {
+		t.Fatal("aggregate keys of sorted keys differ")
+	}
+
+	// Ensure aggregating no keys is rejected.
+	_, err = AggregateKeys(nil)
+	if !errors.Is(err, ErrNoPubKeys) {
+		t.Fatalf("mismatched err -- got %v, want %v", err, ErrNoPubKeys)
+	}
+}
+
+// TestSignErrors ensures that Sign returns the expected errors for invalid
+// inputs and when a secret nonce is reused.
+func TestSignErrors(t *testing.T) {
+	t.Parallel()
+
+	privKeys := testPrivKeys(t, 3)
+	hash := blake256.Sum256([]byte("test message"))
+	s := newTestSession(t, privKeys[:2], hash[:])
+
+	// Ensure signing with a secret nonce generated for a different key fails.
+	_, err := Sign(s.secNonces[1], privKeys[0], &s.aggNonce, s.aggKey, hash[:])
+	if !errors.Is(err, ErrSecretNonceKeyMismatch) {
+		t.Fatalf("mismatched err -- got %v, want %v", err,
+			ErrSecretNonceKeyMismatch)
+	}
+
+	// Ensure signing with a key that is not part of the aggregate key fails.
+	secNonce, _, err := GenerateNonces(privKeys[2], s.aggKey, hash[:])
+	if err != nil {
+		t.Fatalf("unexpected error generating nonces: %v", err)
+	}
+	_, err = Sign(secNonce, privKeys[2], &s.aggNonce, s.aggKey, hash[:])
+	if !errors.Is(err, ErrPubKeyNotInAggregate) {
+		t.Fatalf("mismatched err -- got %v, want %v", err,
+			ErrPubKeyNotInAggregate)
+	}
+
+	// Ensure signing a hash with the wrong length fails.
+	_, err = Sign(s.secNonces[0], privKeys[0], &s.aggNonce, s.aggKey,
+		hash[:31])
+	if !errors.Is(err, ErrInvalidHashLen) {
+		t.Fatalf("mismatched err -- got %v, want %v", err, ErrInvalidHashLen)
+	}
+
+	// Ensure a secret nonce can only be used once.
+	_, err = Sign(s.secNonces[0], privKeys[0], &s.aggNonce, s.aggKey, hash[:])
+	if err != nil {
+		t.Fatalf("unexpected error signing: %v", err)
+	}
+	_, err = Sign(s.secNonces[0], privKeys[0], &s.aggNonce, s.aggKey, hash[:])
+	if !errors.Is(err, ErrSecretNonceUsed) {
+		t.Fatalf("mismatched err -- got %v, want %v", err, ErrSecretNonceUsed)
+	}
+
+	// Ensure an invalid aggregate nonce is rejected.
+	var badAggNonce [PubNonceSize]byte
+	badAggNonce[0] = 0x04
+	_, err = Sign(s.secNonces[1], privKeys[1], &badAggNonce, s.aggKey, hash[:])
+	if !errors.Is(err, ErrInvalidNonce) {
+		t.Fatalf("mismatched err -- got %v, want %v", err, ErrInvalidNonce)
+	}
+
+	// Ensure combining no partial signatures fails.
+	_, err = CombinePartialSignatures(&s.aggNonce, s.aggKey, hash[:], nil)
+	if !errors.Is(err, ErrNoPartialSigs) {
+		t.Fatalf("mismatched err -- got %v, want %v", err, ErrNoPartialSigs)
+	}
+
+	// Ensure aggregating no nonces fails.
+	_, err = AggregateNonces(nil)
+	if !errors.Is(err, ErrNoNonces) {
+		t.Fatalf("mismatched err -- got %v, want %v", err, ErrNoNonces)
+	}
+}
+
+// TestVerifyPartialErrors ensures that partial signature verification detects
+// partial signatures that are invalid for the signer.
+func TestVerifyPartialErrors(t *testing.T) {
+	t.Parallel()
+
+	privKeys := testPrivKeys(t, 3)
+	hash := blake256.Sum256([]byte("test message"))
+	s := newTestSession(t, privKeys[:2], hash[:])
+
+	partialSig, err := Sign(s.secNonces[0], privKeys[0], &s.aggNonce,
+		s.aggKey, hash[:])
+	if err != nil {
+		t.Fatalf("unexpected error signing: %v", err)
+	}
+	pubKey := privKeys[0].PubKey()
+	if !partialSig.Verify(&s.pubNonces[0], pubKey, &s.aggNonce, s.aggKey,
+		hash[:]) {
+
+		t.Fatal("valid partial signature did not verify")
+	}
+
+	// Ensure verifying with the public nonce of another signer fails.
+	err = verifyPartial(partialSig, &s.pubNonces[1], pubKey, &s.aggNonce,
+		s.aggKey, hash[:])
+	if !errors.Is(err, ErrPartialSigInvalid) {
+		t.Fatalf("mismatched err -- got %v, want %v", err,
+			ErrPartialSigInvalid)
+	}
+
+	// Ensure verifying with the public key of another signer fails.
+	err = verifyPartial(partialSig, &s.pubNonces[0], privKeys[1].PubKey(),
+		&s.aggNonce, s.aggKey, hash[:])
+	if !errors.Is(err, ErrPartialSigInvalid) {
+		t.Fatalf("mismatched err -- got %v, want %v", err,
+			ErrPartialSigInvalid)
+	}
+
+	// Ensure verifying with a public key that is not part of the aggregate
+	// key fails.
+	err = verifyPartial(partialSig, &s.pubNonces[0], privKeys[2].PubKey(),
+		&s.aggNonce, s.aggKey, hash[:])
+	if !errors.Is(err, ErrPubKeyNotInAggregate) {
+		t.Fatalf("mismatched err -- got %v, want %v", err,
+			ErrPubKeyNotInAggregate)
+	}
+
+	// Ensure verifying for a different message fails.
+	otherHash := blake256.Sum256([]byte("other message"))
+	err = verifyPartial(partialSig, &s.pubNonces[0], pubKey, &s.aggNonce,
+		s.aggKey, otherHash[:])
+	if !errors.Is(err, ErrPartialSigInvalid) {
+		t.Fatalf("mismatched err -- got %v, want %v", err,
+			ErrPartialSigInvalid)
+	}
+}
+
+// TestParsePartialSignature ensures parsing partial signatures rejects
+// malformed encodings.
+func TestParsePartialSignature(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string // test description
+		sig  []byte // serialized partial signature
+		err  error  // expected error
+	}{{
+		name: "valid",
+		sig:  hexToBytes("0000000000000000000000000000000000000000000000000000000000000001"),
+		err:  nil,
+	}, {
+		name: "too short",
+		sig:  hexToBytes("00000000000000000000000000000000000000000000000000000000000001"),
+		err:  ErrInvalidPartialSigLen,
+	}, {
+		name: "too long",
+		sig:  hexToBytes("000000000000000000000000000000000000000000000000000000000000000001"),
+		err:  ErrInvalidPartialSigLen,
+	}, {
+		name: "s == group order",
+		sig:  hexToBytes("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141"),
+		err:  ErrPartialSigTooBig,
+	}}
+
+	for _, test := range tests {
+		partialSig, err := ParsePartialSignature(test.sig)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%q: mismatched err -- got %v, want %v", test.name, err,
+				test.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if !bytes.Equal(partialSig.Serialize(), test.sig) {
+			t.Errorf("%q: mismatched serialization -- got %x, want %x",
+				test.name, partialSig.Serialize(), test.sig)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package musig2
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+// PubNonceSize is the size of a serialized public nonce and aggregate nonce.
+// They consist of two serialized compressed points.
+const PubNonceSize = 2 * pubKeySize
+
+// SecretNonce houses the secret nonce values of a signer for a single signing
+// session.
+//
+// The secret nonce MUST only be used to produce a single partial signature
+// since using it more than once would leak the private key.  Sign clears it
+// after use to help enforce this.  Consequently, it is intentionally not
+// possible to serialize it.
+type SecretNonce struct {
+	k1, k2 secp256k1.ModNScalar
+
+	// pubKey is the serialized public key of the signer the nonce is bound
+	// to.
+	pubKey [pubKeySize]byte
+}
+
+// Zero clears the secret nonce values so they can no longer be used.
+func (n *SecretNonce) Zero() {
+	n.k1.Zero()
+	n.k2.Zero()
+}
+
+// isZero returns whether or not the secret nonce has been cleared.
+func (n *SecretNonce) isZero() bool {
+	return n.k1.IsZero() || n.k2.IsZero()
+}
+
+// generateNonces generates a new pair of secret and public nonces for the
+// passed private key using the provided source of randomness.  See
+// GenerateNonces for details.
+func generateNonces(rand io.Reader, privKey *secp256k1.PrivateKey, aggKey *AggregateKey, hash []byte) (*SecretNonce, [PubNonceSize]byte, error) {
+	var pubNonce [PubNonceSize]byte
+	if privKey.Key.IsZero() {
+		str := "private key is zero"
+		return nil, pubNonce, makeError(ErrPrivateKeyIsZero, str)
+	}
+
+	var randBytes [scalarSize]byte
+	if _, err := io.ReadFull(rand, randBytes[:]); err != nil {
+		return nil, pubNonce, err
+	}
+
+	// Mix the private key, public key, aggregate key, and message into the
+	// nonces along with the random bytes so that a faulty source of
+	// randomness does not result in the same nonce being used for different
+	// sessions.
+	var privKeyBytes [scalarSize]byte
+	privKey.Key.PutBytes(&privKeyBytes)
+	defer zeroArray(&privKeyBytes)
+	var secNonce SecretNonce
+	copy(secNonce.pubKey[:], privKey.PubKey().SerializeCompressed())
+	var aggKeyBytes []byte
+	if aggKey != nil {
+		aggKeyBytes = aggKey.key.SerializeCompressed()
+	}
+	ks := [2]*secp256k1.ModNScalar{&secNonce.k1, &secNonce.k2}
+	for i, k := range ks {
+		kBytes := taggedHash(tagNonce, randBytes[:], privKeyBytes[:],
+			secNonce.pubKey[:], []byte{byte(len(aggKeyBytes))}, aggKeyBytes,
+			[]byte{byte(len(hash))}, hash, []byte{byte(i)})
+		k.SetBytes(&kBytes)
+		zeroArray(&kBytes)
+		if k.IsZero() {
+			secNonce.Zero()
+			str := "generated nonce is zero"
+			return nil, pubNonce, makeError(ErrInvalidNonce, str)
+		}
+
+		// R_i = k_i*G
+		var r secp256k1.JacobianPoint
+		secp256k1.ScalarBaseMultNonConst(k, &r)
+		r.ToAffine()
+		rBytes := secp256k1.NewPublicKey(&r.X, &r.Y).SerializeCompressed()
+		copy(pubNonce[i*pubKeySize:], rBytes)
+	}
+
+	return &secNonce, pubNonce, nil
+}
+
+// GenerateNonces generates a new pair of secret and public nonces for the
+// passed private key to use in a single signing session.  The public nonce
+// must be shared with the other signers while the secret nonce must be kept
+// private and only used for a single call to Sign.
+//
+// The nonces are generated with a cryptographically secure source of
+// randomness.  The aggregate key and message hash the nonces will be used to
+// sign are optional and may be nil, such as when the nonces are generated in
+// advance, but providing them adds additional protection against a faulty
+// source of randomness.
+func GenerateNonces(privKey *secp256k1.PrivateKey, aggKey *AggregateKey, hash []byte) (*SecretNonce, [PubNonceSize]byte, error) {
+	return generateNonces(rand.Reader, privKey, aggKey, hash)
+}
+
+// parseNoncePoint parses a point of a serialized public nonce or aggregate
+// nonce into the passed Jacobian point.  The point at infinity, which is
+// encoded as all zero bytes, is only allowed when allowInfinity is true.
+func parseNoncePoint(serialized []byte, allowInfinity bool, point *secp256k1.JacobianPoint) error {
+	if allowInfinity {
+		var zero [pubKeySize]byte
+		if string(serialized) == string(zero[:]) {
+			*point = secp256k1.JacobianPoint{}
+			return nil
+		}
+	}
+	pubKey, err := secp256k1.ParsePubKey(serialized)
+	if err != nil {
+		str := fmt.Sprintf("invalid nonce point %x: %v", serialized, err)
+		return makeError(ErrInvalidNonce, str)
+	}
+	pubKey.AsJacobian(point)
+	return nil
+}
+
+// parseNonce parses the two points of a serialized public nonce or aggregate
+// nonce.  See parseNoncePoint for details on allowInfinity.
+func parseNonce(nonce *[PubNonceSize]byte, allowInfinity bool) ([2]secp256k1.JacobianPoint, error) {
+	var points [2]secp256k1.JacobianPoint
+	for i := range points {
+		serialized := nonce[i*pubKeySize : (i+1)*pubKeySize]
+		if err := parseNoncePoint(serialized, allowInfinity, &points[i]); err != nil {
+			return points, err
+		}
+	}
+	return points, nil
+}
+
+// AggregateNonces aggregates the passed public nonces of all signers into an
+// aggregate nonce that is used by all signers to produce their partial
+// signatures.
+//
+// The aggregate nonce consists of the sums of the first and second points of
+// the public nonces.  A sum that is the point at infinity is encoded as all
+// zero bytes.
+func AggregateNonces(pubNonces [][PubNonceSize]byte) ([PubNonceSize]byte, error) {
+	var aggNonce [PubNonceSize]byte
+	if len(pubNonces) == 0 {
+		str := "no public nonces to aggregate"
+		return aggNonce, makeError(ErrNoNonces, str)
+	}
+
+	var sums [2]secp256k1.JacobianPoint
+	for i := range pubNonces {
+		points, err := parseNonce(&pubNonces[i], false)
+		if err != nil {
+			return aggNonce, err
+		}
+		for j := range sums {
+			var sum secp256k1.JacobianPoint
+			secp256k1.AddNonConst(&sums[j], &points[j], &sum)
+			sums[j].Set(&sum)
+		}
+	}
+	for i := range sums {
+		// Leave the point at infinity encoded as zero bytes.
+		sum := &sums[i]
+		if (sum.X.IsZero() && sum.Y.IsZero()) || sum.Z.IsZero() {
+			continue
+		}
+		sum.ToAffine()
+		serialized := secp256k1.NewPublicKey(&sum.X, &sum.Y).SerializeCompressed()
+		copy(aggNonce[i*pubKeySize:], serialized)
+	}
+
+	return aggNonce, nil
+}
+
+// zeroArray zeroes the memory of a scalar array.
+func zeroArray(a *[scalarSize]byte) {
+	for i := 0; i < scalarSize; i++ {
+		a[i] = 0x00
+	}
+}
@@ -0,0 +1,300 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package musig2
+
+import (
+	"fmt"
+
+	"github.com/decred/dcrd/crypto/blake256"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/schnorr"
+)
+
+// PartialSigSize is the size of a serialized partial signature.
+const PartialSigSize = scalarSize
+
+// PartialSignature is a type representing the partial signature a single signer
+// contributes to an aggregate signature.
+type PartialSignature struct {
+	s secp256k1.ModNScalar
+}
+
+// Serialize returns the partial signature encoded as a 32-byte big-endian
+// scalar.
+func (sig *PartialSignature) Serialize() []byte {
+	var b [PartialSigSize]byte
+	sig.s.PutBytes(&b)
+	return b[:]
+}
+
+// ParsePartialSignature parses a partial signature that is encoded as a
+// 32-byte big-endian scalar.
+func ParsePartialSignature(sig []byte) (*PartialSignature, error) {
+	if len(sig) != PartialSigSize {
+		str := fmt.Sprintf("malformed partial signature: wrong size (got %d, "+
+			"want %d)", len(sig), PartialSigSize)
+		return nil, makeError(ErrInvalidPartialSigLen, str)
+	}
+	var partialSig PartialSignature
+	if overflow := partialSig.s.SetByteSlice(sig); overflow {
+		str := "invalid partial signature: s >= group order"
+		return nil, makeError(ErrPartialSigTooBig, str)
+	}
+	return &partialSig, nil
+}
+
+// session houses the values that are shared by all signers of a signing
+// session and are derived from the aggregate nonce, the aggregate key, and the
+// message.
+type session struct {
+	// b is the nonce coefficient.
+	b secp256k1.ModNScalar
+
+	// r is the final nonce point in affine coordinates.
+	r secp256k1.JacobianPoint
+
+	// negateNonce indicates the nonces must be negated because the final
+	// nonce point has an odd y coordinate.
+	negateNonce bool
+
+	// e is the signature challenge.
+	e secp256k1.ModNScalar
+}
+
+// newSession calculates the values that are shared by all signers of the
+// signing session for the passed aggregate nonce, aggregate key, and message
+// hash.
+//
+// The algorithm for calculating the session values is:
+//
+//	(R_1, R_2) = aggregate nonce
+//	Q = aggregate key
+//	m = message
+//	b = H_noncecoef(R_1 || R_2 || Q || m) mod n
+//	R = R_1 + b*R_2, or G if that is the point at infinity
+//	Negate the nonces if R.y is odd
+//	e = BLAKE-256(R.x || m)
+//	Fail if e >= n
+//
+// Note that the challenge is the same as the one used by EC-Schnorr-DCRv0 so
+// the resulting signature verifies as a standard signature for Q.  This means
+// that, unlike BIP-0327, the challenge does NOT commit to Q, so a signature
+// for Q is easily converted to a signature for the same message that is valid
+// for any key related to Q by a known offset.  Callers must not sign the same
+// message with related aggregate keys and must not tweak aggregate keys.  See
+// the package documentation for details.
+func newSession(aggNonce *[PubNonceSize]byte, aggKey *AggregateKey, hash []byte) (*session, error) {
+	if len(hash) != scalarSize {
+		str := fmt.Sprintf("wrong size for message hash (got %v, want %v)",
+			len(hash), scalarSize)
+		return nil, makeError(ErrInvalidHashLen, str)
+	}
+	points, err := parseNonce(aggNonce, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// b = H_noncecoef(R_1 || R_2 || Q || m) mod n
+	var s session
+	bHash := taggedHash(tagNonceCoeff, aggNonce[:],
+		aggKey.key.SerializeCompressed(), hash)
+	s.b.SetBytes(&bHash)
+
+	// R = R_1 + b*R_2
+	//
+	// Use the generator when R is the point at infinity.  This can only
+	// happen when a signer is malicious and ensures the session is still able
+	// to proceed so the malicious signer can be identified.
+	var bR2 secp256k1.JacobianPoint
+	secp256k1.ScalarMultNonConst(&s.b, &points[1], &bR2)
+	secp256k1.AddNonConst(&points[0], &bR2, &s.r)
+	if (s.r.X.IsZero() && s.r.Y.IsZero()) || s.r.Z.IsZero() {
+		var one secp256k1.ModNScalar
+		one.SetInt(1)
+		secp256k1.ScalarBaseMultNonConst(&one, &s.r)
+	}
+	s.r.ToAffine()
+	s.negateNonce = s.r.Y.IsOdd()
+
+	// e = BLAKE-256(R.x || m)
+	var commitmentInput [scalarSize * 2]byte
+	s.r.X.PutBytesUnchecked(commitmentInput[0:scalarSize])
+	copy(commitmentInput[scalarSize:], hash)
+	commitment := blake256.Sum256(commitmentInput[:])
+	if overflow := s.e.SetBytes(&commitment); overflow != 0 {
+		str := "hash of (R || m) too big"
+		return nil, makeError(ErrSchnorrHashValue, str)
+	}
+
+	return &s, nil
+}
+
+// Sign produces a partial signature for the passed message hash with the
+// provided secret nonce and private key for a signing session with the passed
+// aggregate nonce and aggregate key.
+//
+// The secret nonce is cleared once it is used so that it is not possible to
+// accidentally use it more than once.  An Error with kind ErrSecretNonceUsed is
+// returned when it has already been used.
+//
+// WARNING: The signature challenge does not commit to the aggregate key, so
+// callers must never sign the same message hash with aggregate keys that are
+// related by a known offset and must never tweak aggregate keys.  See the
+// package documentation for details.
+//
+// The algorithm for producing a partial signature is:
+//
+//	(k_1, k_2) = secret nonce
+//	d = private key
+//	a = the coefficient of the public key for d in the aggregate key
+//	k = k_1 + b*k_2, negated if R.y is odd
+//	s = k - e*a*d mod n
+//
+// See newSession for the definitions of b, R, and e.
+func Sign(secNonce *SecretNonce, privKey *secp256k1.PrivateKey, aggNonce *[PubNonceSize]byte, aggKey *AggregateKey, hash []byte) (*PartialSignature, error) {
+	if secNonce.isZero() {
+		str := "secret nonce has already been used"
+		return nil, makeError(ErrSecretNonceUsed, str)
+	}
+	if privKey.Key.IsZero() {
+		str := "private key is zero"
+		return nil, makeError(ErrPrivateKeyIsZero, str)
+	}
+	pubKey := privKey.PubKey().SerializeCompressed()
+	if string(pubKey) != string(secNonce.pubKey[:]) {
+		str := "secret nonce was generated for a different private key"
+		return nil, makeError(ErrSecretNonceKeyMismatch, str)
+	}
+	if !aggKey.hasKey(pubKey) {
+		str := fmt.Sprintf("public key %x is not part of the aggregate key",
+			pubKey)
+		return nil, makeError(ErrPubKeyNotInAggregate, str)
+	}
+
+	s, err := newSession(aggNonce, aggKey, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	// k = k_1 + b*k_2, negated if R.y is odd
+	var k secp256k1.ModNScalar
+	k.Mul2(&s.b, &secNonce.k2).Add(&secNonce.k1)
+	if s.negateNonce {
+		k.Negate()
+	}
+	secNonce.Zero()
+
+	// s = k - e*a*d mod n
+	a := aggKey.coefficient(pubKey)
+	var partialSig PartialSignature
+	partialSig.s.Mul2(&s.e, &a).Mul(&privKey.Key).Negate().Add(&k)
+	k.Zero()
+
+	return &partialSig, nil
+}
+
+// verifyPartial attempts to verify the partial signature and either returns nil
+// if successful or a specific error indicating why it failed if not
+// successful.
+//
+// This differs from the exported Verify method in that it returns a specific
+// error to support better testing while the exported method simply returns a
+// bool indicating success or failure.
+func verifyPartial(sig *PartialSignature, pubNonce *[PubNonceSize]byte, pubKey *secp256k1.PublicKey, aggNonce *[PubNonceSize]byte, aggKey *AggregateKey, hash []byte) error {
+	// The algorithm for verifying a partial signature is:
+	//
+	// (R_1', R_2') = public nonce of the signer
+	// P = public key of the signer
+	// a = the coefficient of P in the aggregate key
+	// R' = R_1' + b*R_2', negated if R.y is odd
+	// Verified if s*G + e*a*P == R'
+	serializedPubKey := pubKey.SerializeCompressed()
+	if !aggKey.hasKey(serializedPubKey) {
+		str := fmt.Sprintf("public key %x is not part of the aggregate key",
+			serializedPubKey)
+		return makeError(ErrPubKeyNotInAggregate, str)
+	}
+	points, err := parseNonce(pubNonce, false)
+	if err != nil {
+		return err
+	}
+	s, err := newSession(aggNonce, aggKey, hash)
+	if err != nil {
+		return err
+	}
+
+	// R' = R_1' + b*R_2', negated if R.y is odd
+	var bR2, wantR secp256k1.JacobianPoint
+	secp256k1.ScalarMultNonConst(&s.b, &points[1], &bR2)
+	secp256k1.AddNonConst(&points[0], &bR2, &wantR)
+	if (wantR.X.IsZero() && wantR.Y.IsZero()) || wantR.Z.IsZero() {
+		str := "public nonce of the signer sums to the point at infinity"
+		return makeError(ErrPartialSigInvalid, str)
+	}
+	wantR.ToAffine()
+	if s.negateNonce {
+		wantR.Y.Negate(1).Normalize()
+	}
+
+	// s*G + e*a*P
+	var p, sG, eaP, gotR secp256k1.JacobianPoint
+	pubKey.AsJacobian(&p)
+	a := aggKey.coefficient(serializedPubKey)
+	var ea secp256k1.ModNScalar
+	ea.Mul2(&s.e, &a)
+	secp256k1.ScalarBaseMultNonConst(&sig.s, &sG)
+	secp256k1.ScalarMultNonConst(&ea, &p, &eaP)
+	secp256k1.AddNonConst(&sG, &eaP, &gotR)
+	if (gotR.X.IsZero() && gotR.Y.IsZero()) || gotR.Z.IsZero() {
+		str := "partial signature does not match the public nonce and key"
+		return makeError(ErrPartialSigInvalid, str)
+	}
+	gotR.ToAffine()
+	if !gotR.X.Equals(&wantR.X) || !gotR.Y.Equals(&wantR.Y) {
+		str := "partial signature does not match the public nonce and key"
+		return makeError(ErrPartialSigInvalid, str)
+	}
+	return nil
+}
+
+// Verify returns whether or not the partial signature is valid for the passed
+// public nonce and public key of the signer that produced it for the signing
+// session with the provided aggregate nonce, aggregate key, and message hash.
+//
+// Verifying partial signatures is not necessary to produce a valid aggregate
+// signature, but it allows identifying a signer that provided an invalid
+// partial signature when the aggregate signature is invalid.
+func (sig *PartialSignature) Verify(pubNonce *[PubNonceSize]byte, pubKey *secp256k1.PublicKey, aggNonce *[PubNonceSize]byte, aggKey *AggregateKey, hash []byte) bool {
+	return verifyPartial(sig, pubNonce, pubKey, aggNonce, aggKey, hash) == nil
+}
+
+// CombinePartialSignatures combines the partial signatures of all signers for
+// the signing session with the passed aggregate nonce, aggregate key, and
+// message hash into a final EC-Schnorr-DCRv0 signature.  The resulting
+// signature verifies for the aggregate public key with schnorr.Signature.Verify
+// when all partial signatures are valid.
+//
+// The algorithm for combining the partial signatures is:
+//
+//	r = R.x
+//	s = s_1 + ... + s_n mod n
+//
+// See newSession for the definition of R.
+func CombinePartialSignatures(aggNonce *[PubNonceSize]byte, aggKey *AggregateKey, hash []byte, partialSigs []*PartialSignature) (*schnorr.Signature, error) {
+	if len(partialSigs) == 0 {
+		str := "no partial signatures to combine"
+		return nil, makeError(ErrNoPartialSigs, str)
+	}
+	s, err := newSession(aggNonce, aggKey, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	var sum secp256k1.ModNScalar
+	for _, partialSig := range partialSigs {
+		sum.Add(&partialSig.s)
+	}
+	return schnorr.NewSignature(&s.r.X, &sum), nil
+}
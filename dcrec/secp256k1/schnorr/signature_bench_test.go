@@ -1,4 +1,4 @@
-// Copyright (c) 2015-2022 The Decred developers
+// Copyright (c) 2015-2024 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
@@ -6,6 +6,8 @@ package schnorr
 
 import (
 	"encoding/hex"
+	"fmt"
+	"math/rand"
 	"testing"
 
 	"github.com/decred/dcrd/dcrec/secp256k1/v4"
@@ -94,6 +96,40 @@ func BenchmarkSigVerify(b *testing.B) {
 	}
 }
 
+// BenchmarkSigVerifyBatch benchmarks how long it takes to verify batches of
+// Schnorr signatures of various sizes both individually and with a batch
+// verifier.
+func BenchmarkSigVerifyBatch(b *testing.B) {
+	rng := rand.New(rand.NewSource(0))
+	for _, numSigs := range []int{4, 8, 16, 64, 256, 1024} {
+		sigs := randBatchTestSigs(b, rng, numSigs)
+
+		benchName := fmt.Sprintf("individual/%d", numSigs)
+		b.Run(benchName, func(b *testing.B) {
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for _, s := range sigs {
+					s.sig.Verify(s.hash, s.pubKey)
+				}
+			}
+		})
+
+		benchName = fmt.Sprintf("batch/%d", numSigs)
+		b.Run(benchName, func(b *testing.B) {
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				batch := NewBatchVerifier(len(sigs))
+				for _, s := range sigs {
+					batch.Add(s.sig, s.hash, s.pubKey)
+				}
+				batch.Verify()
+			}
+		})
+	}
+}
+
 // BenchmarkSigSerialize benchmarks how long it takes to serialize Schnorr
 // signatures.
 func BenchmarkSigSerialize(b *testing.B) {
@@ -38,6 +38,11 @@ Application Options:
 	                             NOTE: port must be between 1024 and 65536
 	    --cpuprofile=            Write CPU profile to the specified file
 	    --memprofile=            Write mem profile to the specified file
+	    --capturedir=            Write every message sent to and received from
+	                             each peer to capture files in the specified
+	                             directory for debugging -- NOTE: The captures
+	                             are rotated every 10 MiB and the 5 most recent
+	                             are kept for each peer
 	    --testnet                Use the test network
 	    --simnet                 Use the simulation test network
 	    --regnet                 Use the regression test network
@@ -51,12 +56,30 @@ Application Options:
 	                             verification cache (default: 100000)
 	    --utxocachemaxsize=      The maximum size in MiB of the utxo cache
 	                             (default: 150, minimum: 25, maximum: 32768)
+	    --prune=                 Prune old block data to keep the total size of
+	                             stored blocks below the specified target in MiB
+	                             -- NOTE: Pruned nodes do not serve historical
+	                             blocks to other peers and are not compatible
+	                             with --txindex, --addrindex, or --spendindex;
+	                             0 to disable (minimum: 1024)
 	    --norpc                  Disable built-in RPC server -- NOTE: The RPC
 	                             server is disabled by default if no
 	                             rpcuser/rpcpass or rpclimituser/rpclimitpass is
 	                             specified
 	    --rpclisten=             Add an interface/port to listen for RPC
 	                             connections (default port: 9109, testnet: 19109)
+	    --restlisten=            Add an interface/port to listen for
+	                             unauthenticated read-only REST connections --
+	                             NOTE: The REST interface is disabled unless at
+	                             least one interface is specified and requires
+	                             the RPC server (default port: 9112, testnet:
+	                             19112)
+	    --metricslisten=         Add an interface/port to serve
+	                             Prometheus-compatible metrics over HTTP at
+	                             /metrics -- NOTE: The metrics server is disabled
+	                             unless at least one interface is specified and
+	                             does not perform any authentication (default
+	                             port: 9113, testnet: 19113)
 	-u, --rpcuser=               Username for RPC connections
 	-P, --rpcpass=               Password for RPC connections
 	    --authtype=              Method for RPC client authentication
@@ -106,12 +129,25 @@ Application Options:
 	                             to disable (default: 5)
 	    --maxpeers=              Max number of inbound and outbound peers
 	                             (default: 125)
+	    --blockrelayconns=       Number of additional outbound peers that only
+	                             relay blocks and headers -- 0 to disable
+	                             (default: 2)
+	    --asmap=                 Path to a file that maps IP addresses to
+	                             autonomous system numbers in order to group
+	                             peers by autonomous system instead of network
+	                             prefix
 	    --dialtimeout=           How long to wait for TCP connection completion
 	                             Valid time units are {s, m, h}  Minimum 1
 	                             second (default: 30s)
 	    --peeridletimeout        The duration of inactivity before a peer is
 	                             timed out.  Valid time units are {s,m,h}.
 	                             Minimum 15 seconds (default: 2m0s)
+	    --nop2pencrypt           Disable upgrading connections with peers that
+	                             support it to the encrypted transport
+	    --requirep2pencrypt      Disconnect peers that do not support the
+	                             encrypted transport
+	    --peerbloomfilters       Enable serving bloom filtered transactions and
+	                             blocks to peers that load a bloom filter
 	    --noseeders              Disable seeding for peer discovery
 	    --nodnsseed              DEPRECATED: use --noseeders
 	    --externalip=            Add a public-facing IP to the list of local
@@ -137,6 +173,16 @@ Application Options:
 	                             periodically with new releases. Don't use a
 	                             different hash unless you understand the
 	                             implications. Set to 0 to disable
+	    --lightmode              Only sync and validate block headers and committed
+	                             filters along with the blocks matched by the
+	                             filters for the addresses specified via
+	                             --lightwatchaddr -- NOTE: Only a subset of the
+	                             RPCs are available, listening is disabled, and
+	                             not compatible with --generate, --prune,
+	                             --txindex, --addrindex, or --spendindex
+	    --lightwatchaddr=        Add the specified address to the list of
+	                             addresses to match against the committed filters
+	                             in light mode
 	    --minrelaytxfee=         The minimum transaction fee in DCR/kB to be
 	                             considered a non-zero fee (default: 0.0001)
 	    --limitfreerelay=        DEPRECATED: This behavior is no longer available
@@ -147,6 +193,13 @@ Application Options:
 	                             version of the software
 	    --maxorphantx=           Max number of orphan transactions to keep in
 	                             memory (default: 100)
+	    --maxmempool=            Max total size in MiB of the transactions to
+	                             keep in memory.  The transactions with the
+	                             lowest fee rates are evicted when the limit is
+	                             exceeded.  Set to 0 to disable the limit
+	                             (default: 300)
+	    --nopersistmempool       Do not save the mempool to the data directory on
+	                             shutdown and load it again on startup
 	    --blocksonly             Do not accept transactions from remote peers
 	    --acceptnonstd           Accept and relay non-standard transactions to
 	                             the network regardless of the default settings
@@ -185,6 +238,18 @@ Application Options:
 	                             getrawtransaction RPC
 	    --droptxindex            Deletes the hash-based transaction index from
 	                             the database on start up and then exits
+	    --addrindex              Maintain a full address-based transaction index
+	                             which makes the searchrawtransactions and
+	                             getaddresstxids RPCs available -- NOTE:
+	                             Requires and automatically enables --txindex
+	    --dropaddrindex          Deletes the address-based transaction index
+	                             from the database on start up and then exits
+	    --spendindex             Maintain an index of the transactions that
+	                             spend each output which makes the
+	                             gettxspendingprevout RPC available for
+	                             confirmed transactions
+	    --dropspendindex         Deletes the spend index from the database on
+	                             start up and then exits
 	    --noexistsaddrindex      Disable the exists address index, which tracks
 	                             whether or not an address has even been used
 	    --dropexistsaddrindex    Deletes the exists address index from the
@@ -23,13 +23,14 @@ require (
 	github.com/decred/dcrd/dcrjson/v4 v4.0.1
 	github.com/decred/dcrd/dcrutil/v4 v4.0.1
 	github.com/decred/dcrd/gcs/v4 v4.0.0
+	github.com/decred/dcrd/hdkeychain/v3 v3.1.1
 	github.com/decred/dcrd/lru v1.1.2
 	github.com/decred/dcrd/math/uint256 v1.0.1
 	github.com/decred/dcrd/peer/v3 v3.0.2
-	github.com/decred/dcrd/rpc/jsonrpc/types/v4 v4.0.0
+	github.com/decred/dcrd/rpc/jsonrpc/types/v4 v4.1.0
 	github.com/decred/dcrd/rpcclient/v8 v8.0.0
-	github.com/decred/dcrd/txscript/v4 v4.1.0
-	github.com/decred/dcrd/wire v1.6.0
+	github.com/decred/dcrd/txscript/v4 v4.2.0
+	github.com/decred/dcrd/wire v1.7.0
 	github.com/decred/dcrtest/dcrdtest v1.0.0
 	github.com/decred/go-socks v1.1.0
 	github.com/decred/slog v1.2.0
@@ -47,9 +48,9 @@ require (
 	github.com/agl/ed25519 v0.0.0-20170116200512-5312a6153412 // indirect
 	github.com/dchest/siphash v1.2.3 // indirect
 	github.com/decred/dcrd/dcrec/edwards/v2 v2.0.3 // indirect
-	github.com/decred/dcrd/hdkeychain/v3 v3.1.1 // indirect
 	github.com/golang/snappy v0.0.4 // indirect
 	github.com/klauspost/cpuid/v2 v2.0.9 // indirect
+	golang.org/x/crypto v0.6.0 // indirect
 )
 
 replace (
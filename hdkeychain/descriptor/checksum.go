@@ -0,0 +1,119 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package descriptor
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// inputCharset is the set of characters that are allowed in descriptors.
+	// The position of each character determines the symbols it is expanded
+	// to when calculating checksums.  It is ordered such that the characters
+	// that are most commonly used together are in the same group of 32.
+	inputCharset = "0123456789()[],'/*abcdefgh@:$%{}" +
+		"IJKLMNOPQRSTUVWXYZ&+-.;<=>?!^_|~" +
+		"ijklmnopqrstuvwxyzABCDEFGH`#\"\\ "
+
+	// checksumCharset is the set of characters used to encode checksums.
+	checksumCharset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+	// checksumLen is the number of characters in a checksum.
+	checksumLen = 8
+)
+
+// checksumGenerators are the generator coefficients of the BCH code the
+// checksum is based on.
+var checksumGenerators = [5]uint64{
+	0xf5dee51989, 0xa9fdca3312, 0x1bab10e32d, 0x3706b1677a, 0x644d626ffd,
+}
+
+// polymod updates the passed checksum state with the passed 5-bit symbol.
+func polymod(chk uint64, symbol uint64) uint64 {
+	top := chk >> 35
+	chk = (chk&0x7ffffffff)<<5 ^ symbol
+	for i, gen := range checksumGenerators {
+		if (top>>uint(i))&1 == 1 {
+			chk ^= gen
+		}
+	}
+	return chk
+}
+
+// Checksum returns the checksum for the passed descriptor which must not
+// include a checksum itself.
+//
+// The checksum is the same 8 character checksum that is defined for Bitcoin
+// output descriptors by BIP380 and detects up to 4 errors in descriptors of up
+// to 501 characters.
+//
+// An Error with kind ErrInvalidCharacter will be returned if the descriptor
+// contains characters that are not allowed in descriptors.
+func Checksum(desc string) (string, error) {
+	chk := uint64(1)
+	var groups [3]uint64
+	var numGroups int
+	for i := 0; i < len(desc); i++ {
+		pos := strings.IndexByte(inputCharset, desc[i])
+		if pos == -1 {
+			str := fmt.Sprintf("invalid character %q at position %d",
+				desc[i], i)
+			return "", makeError(ErrInvalidCharacter, str)
+		}
+
+		// Emit a symbol for the position within each group of 32 characters
+		// and an additional symbol that encodes the groups of every 3
+		// characters.
+		chk = polymod(chk, uint64(pos&31))
+		groups[numGroups] = uint64(pos >> 5)
+		numGroups++
+		if numGroups == 3 {
+			chk = polymod(chk, groups[0]*9+groups[1]*3+groups[2])
+			numGroups = 0
+		}
+	}
+	switch numGroups {
+	case 1:
+		chk = polymod(chk, groups[0])
+	case 2:
+		chk = polymod(chk, groups[0]*3+groups[1])
+	}
+	for i := 0; i < checksumLen; i++ {
+		chk = polymod(chk, 0)
+	}
+	chk ^= 1
+
+	var checksum [checksumLen]byte
+	for i := range checksum {
+		checksum[i] = checksumCharset[(chk>>(5*uint(7-i)))&31]
+	}
+	return string(checksum[:]), nil
+}
+
+// splitChecksum splits the passed descriptor into the descriptor and its
+// checksum, if any, and ensures the checksum is valid when one is present.
+func splitChecksum(desc string) (string, string, error) {
+	pos := strings.LastIndexByte(desc, '#')
+	if pos == -1 {
+		return desc, "", nil
+	}
+	desc, checksum := desc[:pos], desc[pos+1:]
+	if len(checksum) != checksumLen {
+		str := fmt.Sprintf("checksum %q is not %d characters", checksum,
+			checksumLen)
+		return "", "", makeError(ErrInvalidChecksum, str)
+	}
+	want, err := Checksum(desc)
+	if err != nil {
+		return "", "", err
+	}
+	if checksum != want {
+		str := fmt.Sprintf("checksum %q does not match the expected "+
+			"checksum %q", checksum, want)
+		return "", "", makeError(ErrInvalidChecksum, str)
+	}
+	return desc, checksum, nil
+}
@@ -0,0 +1,754 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package descriptor
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/decred/dcrd/hdkeychain/v3"
+	"github.com/decred/dcrd/txscript/v4"
+	"github.com/decred/dcrd/txscript/v4/stdaddr"
+	"github.com/decred/dcrd/txscript/v4/stdscript"
+)
+
+// NetworkParams defines an interface that is used to provide the parameters
+// required when parsing descriptors.  These values are typically well-defined
+// and unique per network.
+type NetworkParams interface {
+	hdkeychain.NetworkParams
+	stdaddr.AddressParams
+}
+
+// These constants define the names of the script expressions that are
+// supported by the descriptor language.
+const (
+	fnPKH             = "pkh"
+	fnPKHSchnorr      = "pkhschnorr"
+	fnPKHEd25519      = "pkhed25519"
+	fnSH              = "sh"
+	fnMulti           = "multi"
+	fnSortedMulti     = "sortedmulti"
+	fnAddr            = "addr"
+	fnRaw             = "raw"
+	fnStakeSubmission = "stakesubmission"
+	fnStakeGen        = "stakegen"
+	fnStakeRevoke     = "stakerevoke"
+	fnStakeChange     = "stakechange"
+	fnTreasuryGen     = "treasurygen"
+)
+
+// isStakeFunc returns whether or not the passed script expression name is one
+// of the stake-tagged wrappers.
+func isStakeFunc(fn string) bool {
+	switch fn {
+	case fnStakeSubmission, fnStakeGen, fnStakeRevoke, fnStakeChange,
+		fnTreasuryGen:
+		return true
+	}
+	return false
+}
+
+// rangeType identifies whether and how the final step of the derivation path
+// of a key expression is ranged.
+type rangeType uint8
+
+const (
+	// rangeNone indicates the key expression is not ranged.
+	rangeNone rangeType = iota
+
+	// rangeNormal indicates the key expression is ranged over normal child
+	// indices.
+	rangeNormal
+
+	// rangeHardened indicates the key expression is ranged over hardened
+	// child indices.
+	rangeHardened
+)
+
+// keyOrigin houses the optional origin information of a key expression which
+// consists of the fingerprint of the master key and the derivation path from it
+// to the key.
+type keyOrigin struct {
+	fingerprint [4]byte
+	path        []uint32
+}
+
+// keyExpr houses a parsed key expression.  It is either a serialized public
+// key or an extended key along with a derivation path and range.
+type keyExpr struct {
+	origin *keyOrigin
+
+	// pubKey is the serialized public key when the key expression is not an
+	// extended key.
+	pubKey []byte
+
+	// extKey is the extended key exactly as it appears in the descriptor and
+	// derived is the result of deriving the path from it.
+	extKey    *hdkeychain.ExtendedKey
+	path      []uint32
+	rangeType rangeType
+	derived   *hdkeychain.ExtendedKey
+}
+
+// scriptExpr houses a parsed script expression.
+type scriptExpr struct {
+	fn string
+
+	// keys and threshold are set for the key-based expressions.
+	keys      []*keyExpr
+	threshold int
+
+	// inner is the wrapped expression for sh and the stake-tagged wrappers.
+	inner *scriptExpr
+
+	// addr is set for addr expressions and raw for raw expressions.
+	addr string
+	raw  []byte
+}
+
+// Descriptor is a parsed output descriptor.  It describes a set of output
+// scripts along with the information needed to derive them and may be expanded
+// into the scripts and addresses it describes via Expand.
+type Descriptor struct {
+	root *scriptExpr
+}
+
+// Output describes an output script that is the result of expanding a
+// descriptor.
+type Output struct {
+	// Version is the script version of the script.
+	Version uint16
+
+	// Script is the output script.
+	Script []byte
+
+	// Address is the address the output script pays to.  It is nil for
+	// scripts that do not have an address such as those described by raw
+	// expressions.
+	Address stdaddr.Address
+}
+
+// parser houses the state for parsing a descriptor.
+type parser struct {
+	net NetworkParams
+}
+
+// splitArgs splits the passed arguments of a script expression on the commas
+// that are not nested within another expression or a key origin.
+func splitArgs(args string) []string {
+	var parts []string
+	var depth, start int
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, args[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, args[start:])
+}
+
+// splitFunc splits the passed script expression into its name and arguments.
+func splitFunc(expr string) (string, string, error) {
+	open := strings.IndexByte(expr, '(')
+	if open <= 0 || !strings.HasSuffix(expr, ")") {
+		str := fmt.Sprintf("%q is not a script expression", expr)
+		return "", "", makeError(ErrMalformedDescriptor, str)
+	}
+
+	// Ensure the closing parenthesis matches the opening one.
+	depth := 0
+	for i := open; i < len(expr); i++ {
+		switch expr[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 && i != len(expr)-1 {
+				str := fmt.Sprintf("unexpected characters %q after "+
+					"script expression", expr[i+1:])
+				return "", "", makeError(ErrMalformedDescriptor, str)
+			}
+		}
+	}
+	if depth != 0 {
+		str := fmt.Sprintf("unbalanced parentheses in %q", expr)
+		return "", "", makeError(ErrMalformedDescriptor, str)
+	}
+
+	return expr[:open], expr[open+1 : len(expr)-1], nil
+}
+
+// parsePathStep parses a single step of a derivation path, which is a decimal
+// child index optionally followed by ' or h to indicate hardened derivation.
+func parsePathStep(step string) (uint32, error) {
+	hardened := strings.HasSuffix(step, "'") || strings.HasSuffix(step, "h")
+	if hardened {
+		step = step[:len(step)-1]
+	}
+	if step == "" || step[0] == '+' || step[0] == '-' {
+		str := fmt.Sprintf("invalid key path step %q", step)
+		return 0, makeError(ErrInvalidKeyPath, str)
+	}
+	index, err := strconv.ParseUint(step, 10, 32)
+	if err != nil || index >= hdkeychain.HardenedKeyStart {
+		str := fmt.Sprintf("key path step %q is out of range", step)
+		return 0, makeError(ErrInvalidKeyPath, str)
+	}
+	if hardened {
+		index += hdkeychain.HardenedKeyStart
+	}
+	return uint32(index), nil
+}
+
+// parsePath parses a derivation path that consists of steps separated by
+// slashes.
+func parsePath(steps []string) ([]uint32, error) {
+	path := make([]uint32, 0, len(steps))
+	for _, step := range steps {
+		index, err := parsePathStep(step)
+		if err != nil {
+			return nil, err
+		}
+		path = append(path, index)
+	}
+	return path, nil
+}
+
+// parseOrigin parses the contents of a key origin, which is the hex-encoded
+// fingerprint of a master key optionally followed by a derivation path.
+func parseOrigin(origin string) (*keyOrigin, error) {
+	steps := strings.Split(origin, "/")
+	fingerprint, err := hex.DecodeString(steps[0])
+	if err != nil || len(fingerprint) != 4 {
+		str := fmt.Sprintf("key origin fingerprint %q is not 8 hex "+
+			"characters", steps[0])
+		return nil, makeError(ErrInvalidKeyPath, str)
+	}
+	path, err := parsePath(steps[1:])
+	if err != nil {
+		return nil, err
+	}
+	ko := &keyOrigin{path: path}
+	copy(ko.fingerprint[:], fingerprint)
+	return ko, nil
+}
+
+// parseKey parses a key expression.  Extended keys are only allowed when
+// allowExtended is true.
+func (p *parser) parseKey(expr string, allowExtended bool) (*keyExpr, error) {
+	var key keyExpr
+	if strings.HasPrefix(expr, "[") {
+		end := strings.IndexByte(expr, ']')
+		if end == -1 {
+			str := fmt.Sprintf("unterminated key origin in %q", expr)
+			return nil, makeError(ErrInvalidKeyPath, str)
+		}
+		origin, err := parseOrigin(expr[1:end])
+		if err != nil {
+			return nil, err
+		}
+		key.origin = origin
+		expr = expr[end+1:]
+	}
+
+	parts := strings.Split(expr, "/")
+	if len(parts) == 1 {
+		pubKey, err := hex.DecodeString(parts[0])
+		if err == nil {
+			key.pubKey = pubKey
+			return &key, nil
+		}
+	}
+	if !allowExtended {
+		str := fmt.Sprintf("%q is not a hex-encoded public key", expr)
+		return nil, makeError(ErrInvalidKey, str)
+	}
+
+	extKey, err := hdkeychain.NewKeyFromString(parts[0], p.net)
+	if err != nil {
+		str := fmt.Sprintf("%q is neither a hex-encoded public key nor an "+
+			"extended key: %v", parts[0], err)
+		return nil, makeError(ErrInvalidKey, str)
+	}
+	key.extKey = extKey
+
+	// Parse the range, if any, which is only allowed as the final step.
+	steps := parts[1:]
+	if len(steps) > 0 {
+		switch steps[len(steps)-1] {
+		case "*":
+			key.rangeType = rangeNormal
+		case "*'", "*h":
+			key.rangeType = rangeHardened
+		}
+		if key.rangeType != rangeNone {
+			steps = steps[:len(steps)-1]
+		}
+	}
+	key.path, err = parsePath(steps)
+	if err != nil {
+		return nil, err
+	}
+
+	// Derive the path now so that it only needs to be done once and ensure
+	// hardened derivation is only requested from private keys.
+	derived := extKey
+	for _, index := range key.path {
+		derived, err = derived.ChildBIP32Std(index)
+		if err != nil {
+			str := fmt.Sprintf("unable to derive key path of %q: %v", expr,
+				err)
+			if index >= hdkeychain.HardenedKeyStart && !extKey.IsPrivate() {
+				return nil, makeError(ErrInvalidKeyPath, str)
+			}
+			return nil, makeError(ErrDeriveKey, str)
+		}
+	}
+	if key.rangeType == rangeHardened && !extKey.IsPrivate() {
+		str := fmt.Sprintf("hardened range of %q requires an extended "+
+			"private key", expr)
+		return nil, makeError(ErrInvalidKeyPath, str)
+	}
+	key.derived = derived
+
+	return &key, nil
+}
+
+// parseSecp256k1Key parses a key expression that must result in a secp256k1
+// public key serialized in the compressed format.
+func (p *parser) parseSecp256k1Key(expr string) (*keyExpr, error) {
+	key, err := p.parseKey(expr, true)
+	if err != nil {
+		return nil, err
+	}
+	if key.pubKey != nil && !txscript.IsStrictCompressedPubKeyEncoding(
+		key.pubKey) {
+
+		str := fmt.Sprintf("%q is not a compressed secp256k1 public key",
+			expr)
+		return nil, makeError(ErrInvalidKey, str)
+	}
+	return key, nil
+}
+
+// parseScript parses a script expression that appears within the script
+// expression with the passed name, which is empty at the top level.
+func (p *parser) parseScript(expr string, parentFn string) (*scriptExpr, error) {
+	fn, args, err := splitFunc(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure the expression is allowed in the context it appears in.
+	var allowed bool
+	switch {
+	case parentFn == "":
+		allowed = fn != fnMulti && fn != fnSortedMulti
+	case parentFn == fnSH:
+		allowed = fn == fnMulti || fn == fnSortedMulti
+	case isStakeFunc(parentFn):
+		allowed = fn == fnPKH || fn == fnSH
+	}
+	if !allowed {
+		str := fmt.Sprintf("%s expression is not allowed", fn)
+		if parentFn != "" {
+			str = fmt.Sprintf("%s expression is not allowed within %s", fn,
+				parentFn)
+		}
+		return nil, makeError(ErrUnsupportedScript, str)
+	}
+
+	script := &scriptExpr{fn: fn}
+	switch fn {
+	case fnPKH, fnPKHSchnorr:
+		key, err := p.parseSecp256k1Key(args)
+		if err != nil {
+			return nil, err
+		}
+		script.keys = []*keyExpr{key}
+
+	case fnPKHEd25519:
+		key, err := p.parseKey(args, false)
+		if err != nil {
+			return nil, err
+		}
+		_, err = stdaddr.NewAddressPubKeyEd25519V0Raw(key.pubKey, p.net)
+		if err != nil {
+			str := fmt.Sprintf("%q is not an ed25519 public key: %v", args,
+				err)
+			return nil, makeError(ErrInvalidKey, str)
+		}
+		script.keys = []*keyExpr{key}
+
+	case fnSH, fnStakeSubmission, fnStakeGen, fnStakeRevoke, fnStakeChange,
+		fnTreasuryGen:
+
+		inner, err := p.parseScript(args, fn)
+		if err != nil {
+			return nil, err
+		}
+		script.inner = inner
+
+	case fnMulti, fnSortedMulti:
+		parts := splitArgs(args)
+		threshold, err := strconv.Atoi(parts[0])
+		if err != nil {
+			str := fmt.Sprintf("%s threshold %q is not a number", fn,
+				parts[0])
+			return nil, makeError(ErrMalformedDescriptor, str)
+		}
+		numKeys := len(parts) - 1
+		if numKeys < 1 || numKeys > txscript.MaxPubKeysPerMultiSig {
+			str := fmt.Sprintf("%s expression has %d keys which is not "+
+				"within the range [1, %d]", fn, numKeys,
+				txscript.MaxPubKeysPerMultiSig)
+			return nil, makeError(ErrInvalidThreshold, str)
+		}
+		if threshold < 1 || threshold > numKeys {
+			str := fmt.Sprintf("%s threshold %d is not within the range "+
+				"[1, %d]", fn, threshold, numKeys)
+			return nil, makeError(ErrInvalidThreshold, str)
+		}
+		for _, part := range parts[1:] {
+			key, err := p.parseSecp256k1Key(part)
+			if err != nil {
+				return nil, err
+			}
+			script.keys = append(script.keys, key)
+		}
+		script.threshold = threshold
+
+	case fnAddr:
+		if _, err := stdaddr.DecodeAddress(args, p.net); err != nil {
+			str := fmt.Sprintf("%q is not a valid address: %v", args, err)
+			return nil, makeError(ErrInvalidAddress, str)
+		}
+		script.addr = args
+
+	case fnRaw:
+		raw, err := hex.DecodeString(args)
+		if err != nil {
+			str := fmt.Sprintf("raw script %q is not hex-encoded", args)
+			return nil, makeError(ErrMalformedDescriptor, str)
+		}
+		script.raw = raw
+
+	default:
+		str := fmt.Sprintf("unknown script expression %q", fn)
+		return nil, makeError(ErrUnsupportedScript, str)
+	}
+
+	return script, nil
+}
+
+// Parse parses the passed descriptor for the provided network.  The descriptor
+// may optionally end with a checksum, in which case it must match the
+// descriptor.
+//
+// The supported script expressions are:
+//
+//   - pkh(KEY): pay-to-pubkey-hash with a secp256k1 key and ECDSA signature
+//   - pkhschnorr(KEY): pay-to-pubkey-hash with a secp256k1 key and Schnorr
+//     signature
+//   - pkhed25519(KEY): pay-to-pubkey-hash with an Ed25519 key and signature
+//   - sh(multi(k,KEY,...)): pay-to-script-hash of a multisig script
+//   - sh(sortedmulti(k,KEY,...)): same as multi with the keys sorted
+//   - addr(ADDR): the script the address pays to
+//   - raw(HEX): the hex-encoded script
+//   - stakesubmission(SCRIPT), stakegen(SCRIPT), stakerevoke(SCRIPT),
+//     stakechange(SCRIPT), and treasurygen(SCRIPT): the stake-tagged variant
+//     of a pkh or sh expression
+func Parse(desc string, net NetworkParams) (*Descriptor, error) {
+	desc, _, err := splitChecksum(desc)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := Checksum(desc); err != nil {
+		return nil, err
+	}
+
+	p := parser{net: net}
+	root, err := p.parseScript(desc, "")
+	if err != nil {
+		return nil, err
+	}
+	return &Descriptor{root: root}, nil
+}
+
+// writePath writes the passed derivation path to the builder with a leading
+// slash for each step.
+func writePath(b *strings.Builder, path []uint32) {
+	for _, index := range path {
+		b.WriteByte('/')
+		if index >= hdkeychain.HardenedKeyStart {
+			b.WriteString(strconv.FormatUint(uint64(index-
+				hdkeychain.HardenedKeyStart), 10))
+			b.WriteByte('\'')
+			continue
+		}
+		b.WriteString(strconv.FormatUint(uint64(index), 10))
+	}
+}
+
+// write writes the key expression to the builder.  Extended private keys are
+// written as the associated extended public keys unless private is true.
+func (k *keyExpr) write(b *strings.Builder, private bool) {
+	if k.origin != nil {
+		b.WriteByte('[')
+		b.WriteString(hex.EncodeToString(k.origin.fingerprint[:]))
+		writePath(b, k.origin.path)
+		b.WriteByte(']')
+	}
+	if k.extKey == nil {
+		b.WriteString(hex.EncodeToString(k.pubKey))
+		return
+	}
+
+	extKey := k.extKey
+	if !private {
+		extKey = extKey.Neuter()
+	}
+	b.WriteString(extKey.String())
+	writePath(b, k.path)
+	switch k.rangeType {
+	case rangeNormal:
+		b.WriteString("/*")
+	case rangeHardened:
+		b.WriteString("/*'")
+	}
+}
+
+// write writes the script expression to the builder.  Extended private keys
+// are written as the associated extended public keys unless private is true.
+func (s *scriptExpr) write(b *strings.Builder, private bool) {
+	b.WriteString(s.fn)
+	b.WriteByte('(')
+	switch {
+	case s.inner != nil:
+		s.inner.write(b, private)
+	case s.fn == fnMulti || s.fn == fnSortedMulti:
+		b.WriteString(strconv.Itoa(s.threshold))
+		for _, key := range s.keys {
+			b.WriteByte(',')
+			key.write(b, private)
+		}
+	case len(s.keys) > 0:
+		s.keys[0].write(b, private)
+	case s.fn == fnAddr:
+		b.WriteString(s.addr)
+	case s.fn == fnRaw:
+		b.WriteString(hex.EncodeToString(s.raw))
+	}
+	b.WriteByte(')')
+}
+
+// serialize returns the descriptor along with its checksum.
+func (d *Descriptor) serialize(private bool) string {
+	var b strings.Builder
+	d.root.write(&b, private)
+	desc := b.String()
+
+	// The checksum can't fail since all characters of serialized descriptors
+	// are in the input character set.
+	checksum, _ := Checksum(desc)
+	return desc + "#" + checksum
+}
+
+// String returns the canonical form of the descriptor along with its checksum.
+// Extended private keys are replaced with the associated extended public keys,
+// so the result is safe to share.
+//
+// This is part of the fmt.Stringer interface.
+func (d *Descriptor) String() string {
+	return d.serialize(false)
+}
+
+// PrivateString returns the canonical form of the descriptor along with its
+// checksum while retaining any extended private keys.
+func (d *Descriptor) PrivateString() string {
+	return d.serialize(true)
+}
+
+// forEachKey invokes the passed function for every key expression in the
+// script expression.
+func (s *scriptExpr) forEachKey(f func(*keyExpr)) {
+	for _, key := range s.keys {
+		f(key)
+	}
+	if s.inner != nil {
+		s.inner.forEachKey(f)
+	}
+}
+
+// IsRange returns whether or not the descriptor contains ranged key
+// expressions and therefore describes a different script for each index it is
+// expanded with.
+func (d *Descriptor) IsRange() bool {
+	var isRange bool
+	d.root.forEachKey(func(k *keyExpr) {
+		isRange = isRange || k.rangeType != rangeNone
+	})
+	return isRange
+}
+
+// HasPrivateKeys returns whether or not the descriptor contains extended
+// private keys.
+func (d *Descriptor) HasPrivateKeys() bool {
+	var hasPrivKeys bool
+	d.root.forEachKey(func(k *keyExpr) {
+		hasPrivKeys = hasPrivKeys || (k.extKey != nil && k.extKey.IsPrivate())
+	})
+	return hasPrivKeys
+}
+
+// pubKeyAt returns the serialized public key of the key expression for the
+// passed index.
+func (k *keyExpr) pubKeyAt(index uint32) ([]byte, error) {
+	if k.extKey == nil {
+		return k.pubKey, nil
+	}
+
+	switch k.rangeType {
+	case rangeNone:
+		return k.derived.SerializedPubKey(), nil
+	case rangeHardened:
+		index += hdkeychain.HardenedKeyStart
+	}
+	child, err := k.derived.ChildBIP32Std(index)
+	if err != nil {
+		str := fmt.Sprintf("unable to derive child %d: %v", index, err)
+		return nil, makeError(ErrDeriveKey, str)
+	}
+	return child.SerializedPubKey(), nil
+}
+
+// address returns the address the script expression pays to for the passed
+// index.  It returns nil for raw expressions.
+func (s *scriptExpr) address(index uint32, params stdaddr.AddressParams) (stdaddr.Address, error) {
+	switch s.fn {
+	case fnPKH, fnPKHSchnorr, fnPKHEd25519:
+		pubKey, err := s.keys[0].pubKeyAt(index)
+		if err != nil {
+			return nil, err
+		}
+		var addr stdaddr.AddressPubKeyHasher
+		switch s.fn {
+		case fnPKH:
+			addr, err = stdaddr.NewAddressPubKeyEcdsaSecp256k1V0Raw(pubKey,
+				params)
+		case fnPKHSchnorr:
+			addr, err = stdaddr.NewAddressPubKeySchnorrSecp256k1V0Raw(pubKey,
+				params)
+		case fnPKHEd25519:
+			addr, err = stdaddr.NewAddressPubKeyEd25519V0Raw(pubKey, params)
+		}
+		if err != nil {
+			str := fmt.Sprintf("invalid public key %x: %v", pubKey, err)
+			return nil, makeError(ErrInvalidKey, str)
+		}
+		return addr.AddressPubKeyHash(), nil
+
+	case fnSH:
+		redeemScript, err := s.inner.script(index)
+		if err != nil {
+			return nil, err
+		}
+		addr, err := stdaddr.NewAddressScriptHashV0(redeemScript, params)
+		if err != nil {
+			str := fmt.Sprintf("unable to create script hash address: %v", err)
+			return nil, makeError(ErrUnsupportedScript, str)
+		}
+		return addr, nil
+
+	case fnAddr:
+		addr, err := stdaddr.DecodeAddress(s.addr, params)
+		if err != nil {
+			str := fmt.Sprintf("%q is not a valid address: %v", s.addr, err)
+			return nil, makeError(ErrInvalidAddress, str)
+		}
+		return addr, nil
+	}
+
+	// Stake-tagged wrappers pay to the address of the wrapped expression.
+	if s.inner != nil {
+		return s.inner.address(index, params)
+	}
+	return nil, nil
+}
+
+// script returns the script described by a script expression that is not
+// directly associated with an address, such as multisig redeem scripts.
+func (s *scriptExpr) script(index uint32) ([]byte, error) {
+	pubKeys := make([][]byte, 0, len(s.keys))
+	for _, key := range s.keys {
+		pubKey, err := key.pubKeyAt(index)
+		if err != nil {
+			return nil, err
+		}
+		pubKeys = append(pubKeys, pubKey)
+	}
+	if s.fn == fnSortedMulti {
+		sort.Slice(pubKeys, func(i, j int) bool {
+			return bytes.Compare(pubKeys[i], pubKeys[j]) < 0
+		})
+	}
+	script, err := stdscript.MultiSigScriptV0(s.threshold, pubKeys...)
+	if err != nil {
+		str := fmt.Sprintf("unable to create multisig script: %v", err)
+		return nil, makeError(ErrInvalidKey, str)
+	}
+	return script, nil
+}
+
+// Expand returns the output script and address described by the descriptor for
+// the passed child index and address parameters.  The index is ignored when
+// the descriptor is not ranged.
+//
+// An Error with kind ErrInvalidIndex will be returned for ranged descriptors
+// when the index is not less than hdkeychain.HardenedKeyStart.
+func (d *Descriptor) Expand(index uint32, params stdaddr.AddressParams) (*Output, error) {
+	if d.IsRange() && index >= hdkeychain.HardenedKeyStart {
+		str := fmt.Sprintf("child index %d is out of range", index)
+		return nil, makeError(ErrInvalidIndex, str)
+	}
+
+	if d.root.fn == fnRaw {
+		return &Output{Script: d.root.raw}, nil
+	}
+
+	addr, err := d.root.address(index, params)
+	if err != nil {
+		return nil, err
+	}
+	var version uint16
+	var script []byte
+	switch d.root.fn {
+	case fnStakeSubmission:
+		version, script = addr.(stdaddr.StakeAddress).VotingRightsScript()
+	case fnStakeGen:
+		version, script = addr.(stdaddr.StakeAddress).PayVoteCommitmentScript()
+	case fnStakeRevoke:
+		version, script = addr.(stdaddr.StakeAddress).PayRevokeCommitmentScript()
+	case fnStakeChange:
+		version, script = addr.(stdaddr.StakeAddress).StakeChangeScript()
+	case fnTreasuryGen:
+		version, script = addr.(stdaddr.StakeAddress).PayFromTreasuryScript()
+	default:
+		version, script = addr.PaymentScript()
+	}
+	return &Output{Version: version, Script: script, Address: addr}, nil
+}
@@ -0,0 +1,544 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package descriptor
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/hdkeychain/v3"
+	"github.com/decred/dcrd/txscript/v4/stdaddr"
+	"github.com/decred/dcrd/txscript/v4/stdscript"
+)
+
+// hexToBytes converts the passed hex string into bytes and will panic if there
+// is an error.  This is only provided for the hard-coded constants so errors in
+// the source code can be detected.  It will only (and must only) be called with
+// hard-coded values.
+func hexToBytes(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic("invalid hex in source file: " + s)
+	}
+	return b
+}
+
+// withChecksum returns the passed descriptor followed by its checksum and will
+// panic if there is an error.
+func withChecksum(desc string) string {
+	checksum, err := Checksum(desc)
+	if err != nil {
+		panic(err)
+	}
+	return desc + "#" + checksum
+}
+
+// testKeys houses the keys used throughout the tests.
+type testKeys struct {
+	master    *hdkeychain.ExtendedKey
+	dprv      string
+	dpub      string
+	pubKey1   string
+	pubKey2   string
+	edPubKey  string
+	netParams *chaincfg.Params
+}
+
+// makeTestKeys returns the keys used throughout the tests.
+func makeTestKeys(t *testing.T) *testKeys {
+	t.Helper()
+
+	net := chaincfg.MainNetParams()
+	seed := bytes.Repeat([]byte{0x01}, hdkeychain.RecommendedSeedLen)
+	master, err := hdkeychain.NewMaster(seed, net)
+	if err != nil {
+		t.Fatalf("unexpected error creating master key: %v", err)
+	}
+	return &testKeys{
+		master:    master,
+		dprv:      master.String(),
+		dpub:      master.Neuter().String(),
+		pubKey1:   "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798",
+		pubKey2:   "02c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5",
+		edPubKey:  "d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a",
+		netParams: net,
+	}
+}
+
+// deriveTestPubKey derives the serialized public key at the passed path from
+// the passed extended key and will fail the test if there is an error.
+func deriveTestPubKey(t *testing.T, key *hdkeychain.ExtendedKey, path ...uint32) []byte {
+	t.Helper()
+
+	var err error
+	for _, index := range path {
+		key, err = key.ChildBIP32Std(index)
+		if err != nil {
+			t.Fatalf("unexpected error deriving child %d: %v", index, err)
+		}
+	}
+	return key.SerializedPubKey()
+}
+
+// TestChecksum ensures descriptor checksums are calculated according to the
+// BIP380 test vectors.
+func TestChecksum(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		desc    string
+		want    string
+		wantErr error
+	}{{
+		name: "addr",
+		desc: "addr(mkmZxiEcEd8ZqjQWVZuC6so5dFMKEFpN2j)",
+		want: "02wpgw69",
+	}, {
+		name: "raw",
+		desc: "raw(deadbeef)",
+		want: "89f8spxm",
+	}, {
+		name:    "invalid character",
+		desc:    "raw(deadbeef)é",
+		wantErr: ErrInvalidCharacter,
+	}}
+
+	for _, test := range tests {
+		checksum, err := Checksum(test.desc)
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("%q: mismatched err -- got %v, want %v", test.name, err,
+				test.wantErr)
+			continue
+		}
+		if checksum != test.want {
+			t.Errorf("%q: mismatched checksum -- got %s, want %s", test.name,
+				checksum, test.want)
+			continue
+		}
+
+		// Ensure the checksum is verified by splitChecksum.
+		if err != nil {
+			continue
+		}
+		desc, _, err := splitChecksum(test.desc + "#" + checksum)
+		if err != nil || desc != test.desc {
+			t.Errorf("%q: unexpected split result -- got %q, %v", test.name,
+				desc, err)
+		}
+	}
+}
+
+// TestParse ensures parsing descriptors works as expected for both valid and
+// invalid descriptors and that valid descriptors serialize to their canonical
+// form.
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	keys := makeTestKeys(t)
+	tests := []struct {
+		name        string
+		desc        string
+		want        string // canonical form without checksum
+		wantPrivate string // private canonical form without checksum
+		isRange     bool
+		hasPrivKeys bool
+		wantErr     error
+	}{{
+		name: "pkh with public key",
+		desc: "pkh(" + keys.pubKey1 + ")",
+		want: "pkh(" + keys.pubKey1 + ")",
+	}, {
+		name: "pkh with valid checksum",
+		desc: withChecksum("pkh(" + keys.pubKey1 + ")"),
+		want: "pkh(" + keys.pubKey1 + ")",
+	}, {
+		name:    "pkh with invalid checksum",
+		desc:    "pkh(" + keys.pubKey1 + ")#qqqqqqqq",
+		wantErr: ErrInvalidChecksum,
+	}, {
+		name:    "pkh with short checksum",
+		desc:    "pkh(" + keys.pubKey1 + ")#qqqq",
+		wantErr: ErrInvalidChecksum,
+	}, {
+		name: "pkhschnorr with uppercase public key",
+		desc: "pkhschnorr(" + strings.ToUpper(keys.pubKey1) + ")",
+		want: "pkhschnorr(" + keys.pubKey1 + ")",
+	}, {
+		name: "pkhed25519 with origin",
+		desc: "pkhed25519([DEADBEEF/1h/2]" + keys.edPubKey + ")",
+		want: "pkhed25519([deadbeef/1'/2]" + keys.edPubKey + ")",
+	}, {
+		name:    "pkh with ranged extended public key",
+		desc:    "pkh([d34db33f/44'/42'/0']" + keys.dpub + "/0/*)",
+		want:    "pkh([d34db33f/44'/42'/0']" + keys.dpub + "/0/*)",
+		isRange: true,
+	}, {
+		name:        "pkh with hardened ranged extended private key",
+		desc:        "pkh(" + keys.dprv + "/1h/*h)",
+		want:        "pkh(" + keys.dpub + "/1'/*')",
+		wantPrivate: "pkh(" + keys.dprv + "/1'/*')",
+		isRange:     true,
+		hasPrivKeys: true,
+	}, {
+		name: "sh multi",
+		desc: "sh(multi(1," + keys.pubKey2 + "," + keys.dpub + "/7))",
+		want: "sh(multi(1," + keys.pubKey2 + "," + keys.dpub + "/7))",
+	}, {
+		name:    "sh sortedmulti ranged",
+		desc:    "sh(sortedmulti(2," + keys.pubKey1 + "," + keys.dpub + "/*))",
+		want:    "sh(sortedmulti(2," + keys.pubKey1 + "," + keys.dpub + "/*))",
+		isRange: true,
+	}, {
+		name: "stakegen pkh",
+		desc: "stakegen(pkh(" + keys.pubKey1 + "))",
+		want: "stakegen(pkh(" + keys.pubKey1 + "))",
+	}, {
+		name: "treasurygen sh multi",
+		desc: "treasurygen(sh(multi(1," + keys.pubKey1 + ")))",
+		want: "treasurygen(sh(multi(1," + keys.pubKey1 + ")))",
+	}, {
+		name: "addr",
+		desc: "addr(DsUZxxoHJSty8DCfwfartwTYbuhmVct7tJu)",
+		want: "addr(DsUZxxoHJSty8DCfwfartwTYbuhmVct7tJu)",
+	}, {
+		name: "raw",
+		desc: "raw(DEADBEEF)",
+		want: "raw(deadbeef)",
+	}, {
+		name:    "invalid character",
+		desc:    "raw(deadbeef)é",
+		wantErr: ErrInvalidCharacter,
+	}, {
+		name:    "unknown script expression",
+		desc:    "wpkh(" + keys.pubKey1 + ")",
+		wantErr: ErrUnsupportedScript,
+	}, {
+		name:    "multi at top level",
+		desc:    "multi(1," + keys.pubKey1 + ")",
+		wantErr: ErrUnsupportedScript,
+	}, {
+		name:    "pkh within sh",
+		desc:    "sh(pkh(" + keys.pubKey1 + "))",
+		wantErr: ErrUnsupportedScript,
+	}, {
+		name:    "pkhschnorr within stake wrapper",
+		desc:    "stakegen(pkhschnorr(" + keys.pubKey1 + "))",
+		wantErr: ErrUnsupportedScript,
+	}, {
+		name:    "nested stake wrappers",
+		desc:    "stakegen(stakerevoke(pkh(" + keys.pubKey1 + ")))",
+		wantErr: ErrUnsupportedScript,
+	}, {
+		name:    "missing closing parenthesis",
+		desc:    "pkh(" + keys.pubKey1,
+		wantErr: ErrMalformedDescriptor,
+	}, {
+		name:    "trailing characters",
+		desc:    "pkh(" + keys.pubKey1 + ")x",
+		wantErr: ErrMalformedDescriptor,
+	}, {
+		name:    "unbalanced parentheses",
+		desc:    "sh(multi(1," + keys.pubKey1 + ")",
+		wantErr: ErrMalformedDescriptor,
+	}, {
+		name:    "uncompressed public key",
+		desc:    "pkh(04" + keys.pubKey1[2:] + keys.pubKey1[2:] + ")",
+		wantErr: ErrInvalidKey,
+	}, {
+		name:    "secp256k1 key for ed25519",
+		desc:    "pkhed25519(" + keys.pubKey1 + ")",
+		wantErr: ErrInvalidKey,
+	}, {
+		name:    "extended key for ed25519",
+		desc:    "pkhed25519(" + keys.dpub + ")",
+		wantErr: ErrInvalidKey,
+	}, {
+		name:    "extended key for wrong network",
+		desc:    "pkh(tpubVhnMyQmZAhoosedBTX7oacwyCNc5qtdEMoNHudUCW1R6WZTvqCZQoNJHSn4H11puwdk4qyDv2ET637EDap4r8HH3odjBC5nEjmnPcsDfLwm)",
+		wantErr: ErrInvalidKey,
+	}, {
+		name:    "hardened derivation from extended public key",
+		desc:    "pkh(" + keys.dpub + "/1'/0)",
+		wantErr: ErrInvalidKeyPath,
+	}, {
+		name:    "hardened range from extended public key",
+		desc:    "pkh(" + keys.dpub + "/*')",
+		wantErr: ErrInvalidKeyPath,
+	}, {
+		name:    "range not in final step",
+		desc:    "pkh(" + keys.dpub + "/*/0)",
+		wantErr: ErrInvalidKeyPath,
+	}, {
+		name:    "key path step out of range",
+		desc:    "pkh(" + keys.dpub + "/2147483648)",
+		wantErr: ErrInvalidKeyPath,
+	}, {
+		name:    "short origin fingerprint",
+		desc:    "pkh([d34db3/0]" + keys.pubKey1 + ")",
+		wantErr: ErrInvalidKeyPath,
+	}, {
+		name:    "unterminated origin",
+		desc:    "pkh([d34db33f/0" + keys.pubKey1 + ")",
+		wantErr: ErrInvalidKeyPath,
+	}, {
+		name:    "zero threshold",
+		desc:    "sh(multi(0," + keys.pubKey1 + "))",
+		wantErr: ErrInvalidThreshold,
+	}, {
+		name:    "threshold exceeds keys",
+		desc:    "sh(multi(2," + keys.pubKey1 + "))",
+		wantErr: ErrInvalidThreshold,
+	}, {
+		name:    "non-numeric threshold",
+		desc:    "sh(multi(x," + keys.pubKey1 + "))",
+		wantErr: ErrMalformedDescriptor,
+	}, {
+		name:    "address for wrong network",
+		desc:    "addr(TsmWaPM77WSyA3aiQ2Q1KnwGDVWvEkhip23)",
+		wantErr: ErrInvalidAddress,
+	}, {
+		name:    "non-hex raw script",
+		desc:    "raw(xyz)",
+		wantErr: ErrMalformedDescriptor,
+	}}
+
+	for _, test := range tests {
+		d, err := Parse(test.desc, keys.netParams)
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("%q: mismatched err -- got %v, want %v", test.name, err,
+				test.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		if got, want := d.String(), withChecksum(test.want); got != want {
+			t.Errorf("%q: mismatched descriptor -- got %s, want %s",
+				test.name, got, want)
+			continue
+		}
+		wantPrivate := test.want
+		if test.wantPrivate != "" {
+			wantPrivate = test.wantPrivate
+		}
+		if got, want := d.PrivateString(), withChecksum(wantPrivate); got != want {
+			t.Errorf("%q: mismatched private descriptor -- got %s, want %s",
+				test.name, got, want)
+			continue
+		}
+		if d.IsRange() != test.isRange {
+			t.Errorf("%q: mismatched range -- got %v, want %v", test.name,
+				d.IsRange(), test.isRange)
+			continue
+		}
+		if d.HasPrivateKeys() != test.hasPrivKeys {
+			t.Errorf("%q: mismatched private keys -- got %v, want %v",
+				test.name, d.HasPrivateKeys(), test.hasPrivKeys)
+			continue
+		}
+
+		// Ensure the canonical form parses to the same descriptor.
+		d2, err := Parse(d.PrivateString(), keys.netParams)
+		if err != nil {
+			t.Errorf("%q: unexpected error parsing canonical form: %v",
+				test.name, err)
+			continue
+		}
+		if d2.PrivateString() != d.PrivateString() {
+			t.Errorf("%q: canonical form does not round trip -- got %s, "+
+				"want %s", test.name, d2.PrivateString(), d.PrivateString())
+			continue
+		}
+	}
+}
+
+// TestExpand ensures expanding descriptors results in the expected scripts and
+// addresses.
+func TestExpand(t *testing.T) {
+	t.Parallel()
+
+	keys := makeTestKeys(t)
+	net := keys.netParams
+	const hardened = hdkeychain.HardenedKeyStart
+
+	// mustAddr returns the result of the passed address creation function
+	// and will fail the test if there is an error.
+	mustAddr := func(addr stdaddr.Address, err error) stdaddr.Address {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("unexpected error creating address: %v", err)
+		}
+		return addr
+	}
+	ecdsaPKH := func(pubKey []byte) stdaddr.Address {
+		addr := mustAddr(stdaddr.NewAddressPubKeyEcdsaSecp256k1V0Raw(pubKey,
+			net))
+		return addr.(stdaddr.AddressPubKeyHasher).AddressPubKeyHash()
+	}
+	multiSigSH := func(threshold int, pubKeys ...[]byte) stdaddr.Address {
+		script, err := stdscript.MultiSigScriptV0(threshold, pubKeys...)
+		if err != nil {
+			t.Fatalf("unexpected error creating multisig script: %v", err)
+		}
+		return mustAddr(stdaddr.NewAddressScriptHashV0(script, net))
+	}
+
+	pubKey1 := hexToBytes(keys.pubKey1)
+	pubKey2 := hexToBytes(keys.pubKey2)
+	edPubKey := hexToBytes(keys.edPubKey)
+	schnorrAddr := mustAddr(stdaddr.NewAddressPubKeySchnorrSecp256k1V0Raw(
+		pubKey1, net))
+	edAddr := mustAddr(stdaddr.NewAddressPubKeyEd25519V0Raw(edPubKey, net))
+	rawAddr := mustAddr(stdaddr.DecodeAddress(
+		"DsUZxxoHJSty8DCfwfartwTYbuhmVct7tJu", net))
+
+	tests := []struct {
+		name    string
+		desc    string
+		index   uint32
+		want    stdaddr.Address
+		script  func(stdaddr.StakeAddress) (uint16, []byte)
+		wantErr error
+	}{{
+		name: "pkh with public key",
+		desc: "pkh(" + keys.pubKey1 + ")",
+		want: ecdsaPKH(pubKey1),
+	}, {
+		name:  "pkh with public key ignores index",
+		desc:  "pkh(" + keys.pubKey1 + ")",
+		index: 5,
+		want:  ecdsaPKH(pubKey1),
+	}, {
+		name: "pkhschnorr",
+		desc: "pkhschnorr(" + keys.pubKey1 + ")",
+		want: schnorrAddr.(stdaddr.AddressPubKeyHasher).AddressPubKeyHash(),
+	}, {
+		name: "pkhed25519",
+		desc: "pkhed25519(" + keys.edPubKey + ")",
+		want: edAddr.(stdaddr.AddressPubKeyHasher).AddressPubKeyHash(),
+	}, {
+		name: "pkh with extended public key path",
+		desc: "pkh(" + keys.dpub + "/0/1)",
+		want: ecdsaPKH(deriveTestPubKey(t, keys.master, 0, 1)),
+	}, {
+		name:  "pkh with ranged extended public key",
+		desc:  "pkh(" + keys.dpub + "/0/*)",
+		index: 7,
+		want:  ecdsaPKH(deriveTestPubKey(t, keys.master, 0, 7)),
+	}, {
+		name:  "pkh with hardened ranged extended private key",
+		desc:  "pkh(" + keys.dprv + "/44'/*')",
+		index: 3,
+		want: ecdsaPKH(deriveTestPubKey(t, keys.master, 44+hardened,
+			3+hardened)),
+	}, {
+		name:    "ranged index out of range",
+		desc:    "pkh(" + keys.dpub + "/*)",
+		index:   hardened,
+		wantErr: ErrInvalidIndex,
+	}, {
+		name: "sh multi keeps key order",
+		desc: "sh(multi(1," + keys.pubKey2 + "," + keys.pubKey1 + "))",
+		want: multiSigSH(1, pubKey2, pubKey1),
+	}, {
+		name: "sh sortedmulti sorts keys",
+		desc: "sh(sortedmulti(1," + keys.pubKey2 + "," + keys.pubKey1 + "))",
+		want: multiSigSH(1, pubKey1, pubKey2),
+	}, {
+		name:  "sh multi ranged",
+		desc:  "sh(multi(2," + keys.pubKey1 + "," + keys.dpub + "/*))",
+		index: 2,
+		want: multiSigSH(2, pubKey1, deriveTestPubKey(t, keys.master,
+			2)),
+	}, {
+		name:   "stakesubmission pkh",
+		desc:   "stakesubmission(pkh(" + keys.pubKey1 + "))",
+		want:   ecdsaPKH(pubKey1),
+		script: stdaddr.StakeAddress.VotingRightsScript,
+	}, {
+		name:   "stakegen pkh",
+		desc:   "stakegen(pkh(" + keys.pubKey1 + "))",
+		want:   ecdsaPKH(pubKey1),
+		script: stdaddr.StakeAddress.PayVoteCommitmentScript,
+	}, {
+		name:   "stakerevoke sh multi",
+		desc:   "stakerevoke(sh(multi(1," + keys.pubKey1 + ")))",
+		want:   multiSigSH(1, pubKey1),
+		script: stdaddr.StakeAddress.PayRevokeCommitmentScript,
+	}, {
+		name:   "stakechange ranged pkh",
+		desc:   "stakechange(pkh(" + keys.dpub + "/*))",
+		index:  1,
+		want:   ecdsaPKH(deriveTestPubKey(t, keys.master, 1)),
+		script: stdaddr.StakeAddress.StakeChangeScript,
+	}, {
+		name:   "treasurygen pkh",
+		desc:   "treasurygen(pkh(" + keys.pubKey1 + "))",
+		want:   ecdsaPKH(pubKey1),
+		script: stdaddr.StakeAddress.PayFromTreasuryScript,
+	}, {
+		name: "addr",
+		desc: "addr(DsUZxxoHJSty8DCfwfartwTYbuhmVct7tJu)",
+		want: rawAddr,
+	}}
+
+	for _, test := range tests {
+		d, err := Parse(test.desc, net)
+		if err != nil {
+			t.Errorf("%q: unexpected parse error: %v", test.name, err)
+			continue
+		}
+		output, err := d.Expand(test.index, net)
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("%q: mismatched err -- got %v, want %v", test.name, err,
+				test.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		if output.Address.String() != test.want.String() {
+			t.Errorf("%q: mismatched address -- got %s, want %s", test.name,
+				output.Address, test.want)
+			continue
+		}
+		wantVersion, wantScript := test.want.PaymentScript()
+		if test.script != nil {
+			wantVersion, wantScript = test.script(
+				test.want.(stdaddr.StakeAddress))
+		}
+		if output.Version != wantVersion ||
+			!bytes.Equal(output.Script, wantScript) {
+
+			t.Errorf("%q: mismatched script -- got %d:%x, want %d:%x",
+				test.name, output.Version, output.Script, wantVersion,
+				wantScript)
+			continue
+		}
+	}
+
+	// Ensure raw descriptors expand to their script without an address.
+	d, err := Parse("raw(deadbeef)", net)
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	output, err := d.Expand(0, net)
+	if err != nil {
+		t.Fatalf("unexpected expand error: %v", err)
+	}
+	if output.Address != nil || !bytes.Equal(output.Script,
+		hexToBytes("deadbeef")) {
+
+		t.Fatalf("unexpected raw output: %x, %v", output.Script,
+			output.Address)
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package descriptor implements a language for describing output scripts and the
+keys needed to derive them.
+
+An output descriptor is a human-readable string that describes a single output
+script or a range of output scripts along with everything that is needed to
+derive them, such as the public keys or extended keys and the derivation paths
+involved.  This allows wallets to import and export the set of scripts they
+watch for without the need to exchange the individual scripts and addresses.
+
+The language is modeled after the output script descriptors used by Bitcoin
+(BIP380 and related) and adapted to Decred scripts.
+
+# Script Expressions
+
+The following script expressions are supported:
+
+  - pkh(KEY): pay-to-pubkey-hash with a secp256k1 key and ECDSA signature
+  - pkhschnorr(KEY): pay-to-pubkey-hash with a secp256k1 key and Schnorr
+    signature
+  - pkhed25519(KEY): pay-to-pubkey-hash with an Ed25519 key and signature
+  - sh(multi(k,KEY,...)): pay-to-script-hash of a k-of-n multisig script with
+    the keys in the given order
+  - sh(sortedmulti(k,KEY,...)): same as multi, except the keys are sorted
+    lexicographically by their serialized public keys
+  - addr(ADDR): the script that pays to the given address
+  - raw(HEX): the given hex-encoded script
+
+The pkh and sh expressions may additionally be wrapped by one of the following
+stake-tagged expressions to describe the script for the associated stake
+output instead:
+
+  - stakesubmission(SCRIPT): ticket voting rights
+  - stakegen(SCRIPT): vote payouts
+  - stakerevoke(SCRIPT): revocation payouts
+  - stakechange(SCRIPT): ticket and treasury add change
+  - treasurygen(SCRIPT): treasury spend payouts
+
+For example, stakegen(pkh(KEY)) describes the script votes use to pay the
+original funds locked to purchase a ticket plus the reward to KEY.
+
+# Key Expressions
+
+A key expression is either a hex-encoded public key or an extended key as
+provided by the hdkeychain package followed by an optional derivation path of
+child indices separated by slashes.  Hardened child indices are denoted by a
+trailing ' or h and require an extended private key.  Secp256k1 public keys
+must be serialized in the compressed format.  Extended keys are not supported
+for Ed25519 keys.
+
+An extended key whose path ends with /* or /*' is ranged and describes a
+different key for each child index.  A descriptor that contains ranged keys
+describes a different script for each index it is expanded with.
+
+Key expressions may be prefixed with the origin of the key in square brackets,
+which consists of the 8 hex character fingerprint of the master key followed by
+the derivation path from it to the key.  The origin is informational only and
+does not affect the described scripts.  For example:
+
+	[d34db33f/44'/42'/0']dpub.../0/*
+
+# Checksums
+
+Descriptors may be followed by a # and an 8 character checksum which protects
+them against typos.  Parse verifies the checksum when it is present and String
+always includes it.  The checksum is calculated with the same algorithm as the
+checksum for Bitcoin descriptors.
+
+# Errors
+
+Errors returned by this package are of type descriptor.Error and fully support
+the standard library errors.Is and errors.As functions.  This allows the caller
+to programmatically determine the specific error by examining the ErrorKind
+field of the type asserted descriptor.Error while still providing rich error
+messages with contextual information.
+*/
+package descriptor
@@ -0,0 +1,81 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package descriptor
+
+// ErrorKind identifies a kind of error.
+type ErrorKind string
+
+// These constants are used to identify a specific ErrorKind.
+const (
+	// ErrInvalidCharacter indicates a descriptor contains a character that
+	// is not allowed.
+	ErrInvalidCharacter = ErrorKind("ErrInvalidCharacter")
+
+	// ErrInvalidChecksum indicates a descriptor checksum is either malformed
+	// or does not match the descriptor.
+	ErrInvalidChecksum = ErrorKind("ErrInvalidChecksum")
+
+	// ErrMalformedDescriptor indicates a descriptor could not be parsed
+	// because it does not adhere to the descriptor language.
+	ErrMalformedDescriptor = ErrorKind("ErrMalformedDescriptor")
+
+	// ErrUnsupportedScript indicates a descriptor contains a script
+	// expression that is either unknown or not allowed in the context it
+	// appears in.
+	ErrUnsupportedScript = ErrorKind("ErrUnsupportedScript")
+
+	// ErrInvalidKey indicates a key expression does not contain a valid
+	// public key or extended key.
+	ErrInvalidKey = ErrorKind("ErrInvalidKey")
+
+	// ErrInvalidKeyPath indicates the origin or derivation path of a key
+	// expression is malformed or requires hardened derivation from an
+	// extended public key.
+	ErrInvalidKeyPath = ErrorKind("ErrInvalidKeyPath")
+
+	// ErrInvalidThreshold indicates the number of required signatures or the
+	// number of keys of a multisig expression is out of range.
+	ErrInvalidThreshold = ErrorKind("ErrInvalidThreshold")
+
+	// ErrInvalidAddress indicates an address expression does not contain a
+	// valid address for the network.
+	ErrInvalidAddress = ErrorKind("ErrInvalidAddress")
+
+	// ErrInvalidIndex indicates a ranged descriptor was expanded with an
+	// index that is out of range.
+	ErrInvalidIndex = ErrorKind("ErrInvalidIndex")
+
+	// ErrDeriveKey indicates a child key could not be derived.
+	ErrDeriveKey = ErrorKind("ErrDeriveKey")
+)
+
+// Error satisfies the error interface and prints human-readable errors.
+func (e ErrorKind) Error() string {
+	return string(e)
+}
+
+// Error identifies an output descriptor related error.
+//
+// It has full support for errors.Is and errors.As, so the caller can ascertain
+// the specific reason for the error by checking the underlying error.
+type Error struct {
+	Err         error
+	Description string
+}
+
+// Error satisfies the error interface and prints human-readable errors.
+func (e Error) Error() string {
+	return e.Description
+}
+
+// Unwrap returns the underlying wrapped error.
+func (e Error) Unwrap() error {
+	return e.Err
+}
+
+// makeError creates an Error given a set of arguments.
+func makeError(kind ErrorKind, desc string) Error {
+	return Error{Err: kind, Description: desc}
+}
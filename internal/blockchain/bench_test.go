@@ -1,10 +1,12 @@
-// Copyright (c) 2019 The Decred developers
+// Copyright (c) 2019-2024 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
 package blockchain
 
 import (
+	"fmt"
+	"runtime"
 	"testing"
 )
 
@@ -30,3 +32,96 @@ func BenchmarkAncestor(b *testing.B) {
 		branchTip(branch2Nodes).Ancestor(0)
 	}
 }
+
+// BenchmarkValidateSchnorrScripts benchmarks validating the scripts of blocks
+// that consist of various numbers of inputs spent by secp256k1 Schnorr
+// signatures both with the signatures verified individually and in batches as
+// is done during block validation.
+func BenchmarkValidateSchnorrScripts(b *testing.B) {
+	for _, numInputs := range []int{100, 1000, 5000} {
+		inputs := make([]schnorrTestInput, numInputs)
+		items, prevScripts := makeSchnorrTestItems(b, inputs)
+		for _, batchSchnorr := range []bool{false, true} {
+			mode := "individual"
+			if batchSchnorr {
+				mode = "batch"
+			}
+			benchName := fmt.Sprintf("%s/%d", mode, numInputs)
+			b.Run(benchName, func(b *testing.B) {
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					v := newTxValidator(prevScripts, 0, nil, batchSchnorr)
+					if err := v.Validate(items); err != nil {
+						b.Fatalf("unexpected error: %v", err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkCheckBlockScripts benchmarks validating the scripts of blocks that
+// consist of transactions with a few inputs spent by secp256k1 Schnorr
+// signatures each, as is typical during initial sync, with varying numbers of
+// processor cores both with the signatures verified individually and with them
+// deferred and verified in batches as is done during block validation.
+func BenchmarkCheckBlockScripts(b *testing.B) {
+	// Benchmark with a single core, a few cores, and all cores.
+	procs := []int{1}
+	for _, n := range []int{4, runtime.NumCPU()} {
+		if n > procs[len(procs)-1] {
+			procs = append(procs, n)
+		}
+	}
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(0))
+
+	for _, numTxns := range []int{100, 1000} {
+		// Create a block with transactions that have between one and three
+		// inputs each.
+		txns := make([][]schnorrTestInput, numTxns)
+		for i := range txns {
+			txns[i] = make([]schnorrTestInput, i%3+1)
+		}
+		block, view := makeSchnorrTestBlock(b, txns)
+		var items []*txValidateItem
+		for _, tx := range block.Transactions()[1:] {
+			for txInIdx, txIn := range tx.MsgTx().TxIn {
+				items = append(items, &txValidateItem{
+					txInIndex: txInIdx,
+					txIn:      txIn,
+					tx:        tx,
+				})
+			}
+		}
+
+		for _, numProcs := range procs {
+			runtime.GOMAXPROCS(numProcs)
+			benchName := fmt.Sprintf("individual/txns=%d/procs=%d", numTxns,
+				numProcs)
+			b.Run(benchName, func(b *testing.B) {
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					v := newTxValidator(view, 0, nil, false)
+					if err := v.Validate(items); err != nil {
+						b.Fatalf("unexpected error: %v", err)
+					}
+				}
+			})
+
+			benchName = fmt.Sprintf("batch/txns=%d/procs=%d", numTxns,
+				numProcs)
+			b.Run(benchName, func(b *testing.B) {
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					err := checkBlockScripts(block, view, true, 0, nil, true)
+					if err != nil {
+						b.Fatalf("unexpected error: %v", err)
+					}
+				}
+			})
+		}
+	}
+}
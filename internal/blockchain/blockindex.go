@@ -44,6 +44,14 @@ const (
 	// statusInvalidAncestor indicates that one of the ancestors of the block
 	// has failed validation, thus the block is also invalid.
 	statusInvalidAncestor blockStatus = 1 << 3
+
+	// statusDataPruned indicates that the block's payload was previously
+	// stored on disk, but has since been removed due to pruning.
+	//
+	// NOTE: The data stored flag remains set for pruned blocks since the
+	// block was fully available at one point and therefore has been through
+	// all of the same processing as any other block with data.
+	statusDataPruned blockStatus = 1 << 4
 )
 
 const (
@@ -61,10 +69,20 @@ const (
 // HaveData returns whether the full block data is stored in the database.  This
 // will return false for a block node where only the header is downloaded or
 // stored.
+//
+// NOTE: This also returns true for blocks whose data has since been pruned
+// since they were fully processed when the data was available.  Use IsPruned
+// to determine if the data is still available.
 func (status blockStatus) HaveData() bool {
 	return status&statusDataStored != 0
 }
 
+// IsPruned returns whether the full block data was previously stored in the
+// database, but has since been removed due to pruning.
+func (status blockStatus) IsPruned() bool {
+	return status&statusDataPruned != 0
+}
+
 // HasValidated returns whether the block is known to have been successfully
 // validated.  A return value of false in no way implies the block is invalid.
 // Thus, this will return false for a valid block that has not been fully
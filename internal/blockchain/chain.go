@@ -174,6 +174,7 @@ type BlockChain struct {
 	chainParams              *chaincfg.Params
 	timeSource               MedianTimeSource
 	notifications            NotificationCallback
+	onBlockConnectTimings    func(validation, connection time.Duration)
 	sigCache                 *txscript.SigCache
 	indexSubscriber          *indexers.IndexSubscriber
 	interrupt                <-chan struct{}
@@ -262,6 +263,23 @@ type BlockChain struct {
 	// it is unlikely to be referenced in the future.
 	pruner *chainPruner
 
+	// These fields are related to pruning block data from the database once
+	// the stored data exceeds a target size.  pruneTarget is set when the
+	// instance is created and can't be changed afterwards.  The remaining
+	// fields are protected by the chain lock.
+	//
+	// pruneTarget is the target maximum number of bytes of block data to keep
+	// in the database.  It is zero when pruning is disabled.
+	//
+	// prunedHeight is the height of the most recent block in the main chain
+	// with pruned data.  It is zero when no data has been pruned.
+	//
+	// storedBlockBytes is the total size of all blocks with data that is
+	// stored in the database and has not been pruned.
+	pruneTarget      uint64
+	prunedHeight     int64
+	storedBlockBytes uint64
+
 	// The following maps are various caches for the stake version/voting
 	// system.  The goal of these is to reduce disk access to load blocks
 	// from disk.  Measurements indicate that it is slightly more expensive
@@ -493,6 +511,11 @@ func (b *BlockChain) fetchMainChainBlockByNode(node *blockNode) (*dcrutil.Block,
 		return block, nil
 	}
 
+	// The block data is no longer available when it has been pruned.
+	if b.index.NodeStatus(node).IsPruned() {
+		return nil, prunedBlockError(&node.hash)
+	}
+
 	// Load the block from the database.
 	err := b.db.View(func(dbTx database.Tx) error {
 		var err error
@@ -514,6 +537,11 @@ func (b *BlockChain) fetchBlockByNode(node *blockNode) (*dcrutil.Block, error) {
 		return block, nil
 	}
 
+	// The block data is no longer available when it has been pruned.
+	if b.index.NodeStatus(node).IsPruned() {
+		return nil, prunedBlockError(&node.hash)
+	}
+
 	// Load the block from the database.
 	err := b.db.View(func(dbTx database.Tx) error {
 		var err error
@@ -751,6 +779,13 @@ func (b *BlockChain) connectBlock(node *blockNode, block, parent *dcrutil.Block,
 	b.stateSnapshot = state
 	b.stateLock.Unlock()
 
+	// Prune old block data as needed when pruning is enabled.  Failure to
+	// prune only results in more data than the target being stored until the
+	// next attempt, so only warn about it.
+	if err := b.maybePruneBlockData(); err != nil {
+		log.Warnf("Unable to prune block data: %v", err)
+	}
+
 	// Conditionally log target difficulty changes at retarget intervals.  Only
 	// log when the chain believes it is current since it is very noisy during
 	// syncing otherwise.
@@ -1192,6 +1227,7 @@ func (b *BlockChain) reorganizeChainInternal(target *blockNode) error {
 		numSpentOutputs := countSpentOutputs(block)
 		stxos := make([]spentTxOut, 0, numSpentOutputs)
 		var hdrCommitments headerCommitmentData
+		var validationTime time.Duration
 		if b.index.NodeStatus(n).HasValidated() {
 			// Update the view to mark all utxos referenced by the block as
 			// spent and add all transactions being created by this block to it.
@@ -1213,6 +1249,7 @@ func (b *BlockChain) reorganizeChainInternal(target *blockNode) error {
 		} else {
 			// The block must pass all of the validation rules which depend on
 			// having the full block data for all of its ancestors available.
+			validationStart := time.Now()
 			if err := b.checkBlockContext(block, n.parent, BFNone); err != nil {
 				var rerr RuleError
 				if errors.As(err, &rerr) {
@@ -1238,13 +1275,18 @@ func (b *BlockChain) reorganizeChainInternal(target *blockNode) error {
 				return err
 			}
 			b.index.SetStatusFlags(n, statusValidated)
+			validationTime = time.Since(validationStart)
 		}
 
 		// Update the database and chain state.
+		connectStart := time.Now()
 		err = b.connectBlock(n, block, parent, view, stxos, &hdrCommitments)
 		if err != nil {
 			return err
 		}
+		if b.onBlockConnectTimings != nil {
+			b.onBlockConnectTimings(validationTime, time.Since(connectStart))
+		}
 
 		log.Tracef("Connected block %s (height %d) to main chain", n.hash,
 			n.height)
@@ -1486,7 +1528,11 @@ func (b *BlockChain) flushBlockIndex() error {
 	b.index.RLock()
 	maybePruned := make([]*blockNode, 0, len(b.index.modified))
 	for node := range b.index.modified {
-		if !b.index.canValidate(node) {
+		// Note that blocks with pruned data are skipped since their data
+		// is no longer available to reload the information from and the
+		// vote information that is stored in the block index is always
+		// kept in memory.
+		if !b.index.canValidate(node) || node.status.IsPruned() {
 			continue
 		}
 		maybePruned = append(maybePruned, node)
@@ -1705,6 +1751,86 @@ func (b *BlockChain) BlockByHash(hash *chainhash.Hash) (*dcrutil.Block, error) {
 	return b.fetchBlockByNode(node)
 }
 
+// SpentTxOut houses details about a transaction output that was spent by a
+// block in the main chain as recorded in the spend journal.
+type SpentTxOut struct {
+	// Amount is the amount of the output.
+	Amount int64
+
+	// ScriptVersion and PkScript are the version and public key script of
+	// the output.
+	ScriptVersion uint16
+	PkScript      []byte
+
+	// BlockHeight and BlockIndex are the height of the block that contains
+	// the transaction that created the output and the index of that
+	// transaction within the block.
+	BlockHeight uint32
+	BlockIndex  uint32
+
+	// TxType is the type of the transaction that created the output.
+	TxType stake.TxType
+}
+
+// FetchSpentTxOuts returns all of the transaction outputs spent by the main
+// chain block with the given hash as recorded in the spend journal.
+//
+// The outputs are in the order of the inputs that spend them within the block
+// which is the stake tree followed by the regular tree.  Inputs that do not
+// spend a previous output, namely the stakebase inputs of votes, the inputs of
+// treasury spends, and the coinbase and treasurybase inputs, are skipped.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) FetchSpentTxOuts(hash *chainhash.Hash) ([]SpentTxOut, error) {
+	// The chain lock is held for writes since determining the treasury agenda
+	// state requires it and holding it also ensures the block can't be
+	// disconnected, which removes its spend journal entry, in the mean time.
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	node := b.index.LookupNode(hash)
+	if node == nil {
+		return nil, unknownBlockError(hash)
+	}
+	block, err := b.fetchMainChainBlockByNode(node)
+	if err != nil {
+		return nil, err
+	}
+
+	// Determine if treasury agenda is active as of the block.
+	var isTreasuryEnabled bool
+	if node.parent != nil {
+		isTreasuryEnabled, err = b.isTreasuryAgendaActive(node.parent)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Load all of the spent txos for the block from the spend journal.
+	var stxos []spentTxOut
+	err = b.db.View(func(dbTx database.Tx) error {
+		stxos, err = dbFetchSpendJournalEntry(dbTx, block, isTreasuryEnabled)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	spent := make([]SpentTxOut, 0, len(stxos))
+	for i := range stxos {
+		stxo := &stxos[i]
+		spent = append(spent, SpentTxOut{
+			Amount:        stxo.amount,
+			ScriptVersion: stxo.scriptVersion,
+			PkScript:      stxo.pkScript,
+			BlockHeight:   stxo.blockHeight,
+			BlockIndex:    stxo.blockIndex,
+			TxType:        stxo.TransactionType(),
+		})
+	}
+	return spent, nil
+}
+
 // BlockByHeight returns the block at the given height in the main chain.
 //
 // This function is safe for concurrent access.
@@ -2330,6 +2456,15 @@ type Config struct {
 	// notifications.
 	Notifications NotificationCallback
 
+	// OnBlockConnectTimings defines an optional callback that is invoked with
+	// the time spent validating and connecting each block that is connected to
+	// the main chain.  The validation time only includes the checks which
+	// depend on having the full block data for all ancestors available and is
+	// zero for blocks that were already validated.
+	//
+	// This field can be nil if the caller is not interested in the timings.
+	OnBlockConnectTimings func(validation, connection time.Duration)
+
 	// SigCache defines a signature cache to use when validating signatures.
 	// This is typically most useful when individual transactions are
 	// already being validated prior to their inclusion in a block such as
@@ -2356,6 +2491,15 @@ type Config struct {
 	//
 	// This field is required.
 	UtxoCache UtxoCacher
+
+	// PruneTarget is the target maximum number of bytes of block data to keep
+	// in the database.  The data for the oldest blocks is removed once the
+	// total size of the stored block data exceeds the target, subject to
+	// always keeping enough recent blocks to handle reorganizations and
+	// validation.  Note that the block headers are always kept.
+	//
+	// This field can be zero to disable pruning.
+	PruneTarget uint64
 }
 
 // New returns a BlockChain instance using the provided configuration details.
@@ -2432,6 +2576,7 @@ func New(ctx context.Context, config *Config) (*BlockChain, error) {
 		chainParams:                   params,
 		timeSource:                    config.TimeSource,
 		notifications:                 config.Notifications,
+		onBlockConnectTimings:         config.OnBlockConnectTimings,
 		sigCache:                      config.SigCache,
 		interrupt:                     ctx.Done(),
 		indexSubscriber:               config.IndexSubscriber,
@@ -2446,6 +2591,7 @@ func New(ctx context.Context, config *Config) (*BlockChain, error) {
 		calcVoterVersionIntervalCache: make(map[[chainhash.HashSize]byte]uint32),
 		calcStakeVersionCache:         make(map[[chainhash.HashSize]byte]uint32),
 		utxoCache:                     config.UtxoCache,
+		pruneTarget:                   config.PruneTarget,
 	}
 	b.pruner = newChainPruner(&b)
 
@@ -2462,6 +2608,17 @@ func New(ctx context.Context, config *Config) (*BlockChain, error) {
 		return nil, err
 	}
 
+	// Determine the amount of stored block data and how much of the main
+	// chain has been pruned.  Pruning may not be disabled once block data has
+	// been pruned since the data is no longer available.
+	b.initPruneState()
+	if b.pruneTarget == 0 && b.prunedHeight != 0 {
+		str := fmt.Sprintf("the database contains block data pruned "+
+			"through height %d, so pruning may not be disabled without "+
+			"removing the database", b.prunedHeight)
+		return nil, contextError(ErrBlockPruned, str)
+	}
+
 	log.Infof("Blockchain database version info: chain: %d, compression: "+
 		"%d, block index: %d, spend journal: %d", b.dbInfo.version,
 		b.dbInfo.compVer, b.dbInfo.bidxVer, b.dbInfo.stxoVer)
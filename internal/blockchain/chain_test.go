@@ -1,5 +1,5 @@
 // Copyright (c) 2013-2016 The btcsuite developers
-// Copyright (c) 2015-2023 The Decred developers
+// Copyright (c) 2015-2024 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
@@ -18,6 +18,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/decred/dcrd/blockchain/stake/v5"
 	"github.com/decred/dcrd/blockchain/v5/chaingen"
 	"github.com/decred/dcrd/chaincfg/chainhash"
 	"github.com/decred/dcrd/chaincfg/v3"
@@ -692,6 +693,87 @@ func nodeHeaders(nodes []*blockNode, indexes ...int) []wire.BlockHeader {
 	return headers
 }
 
+// TestFetchSpentTxOuts ensures the outputs spent by main chain blocks are
+// loaded from the spend journal as expected.
+func TestFetchSpentTxOuts(t *testing.T) {
+	// Create a test harness initialized with the genesis block as the tip.
+	params := chaincfg.RegNetParams()
+	g := newChaingenHarness(t, params)
+
+	// ---------------------------------------------------------------------
+	// Generate and accept enough blocks to reach stake validation height
+	// and then a block that spends a mature coinbase output in the regular
+	// tree in addition to the votes and ticket purchases in the stake tree.
+	//
+	//   ... -> bsv# -> bbm0 -> ... -> bbm# -> b1
+	// ---------------------------------------------------------------------
+
+	g.AdvanceToStakeValidationHeight()
+	coinbaseMaturity := params.CoinbaseMaturity
+	for i := uint16(0); i < coinbaseMaturity; i++ {
+		outs := g.OldestCoinbaseOuts()
+		blockName := fmt.Sprintf("bbm%d", i)
+		g.NextBlock(blockName, nil, outs[1:])
+		g.SaveTipCoinbaseOuts()
+		g.AcceptTipBlock()
+	}
+	outs := g.OldestCoinbaseOuts()
+	b1 := g.NextBlock("b1", &outs[0], outs[1:])
+	g.AcceptTipBlock()
+
+	// Ensure the spent outputs match the inputs of the block that spend
+	// previous outputs in order.
+	var wantAmounts []int64
+	for _, tx := range b1.STransactions {
+		isVote := stake.IsSSGen(tx)
+		if stake.IsTreasuryBase(tx) || stake.IsTSpend(tx) {
+			continue
+		}
+		for txInIdx, txIn := range tx.TxIn {
+			if txInIdx == 0 && isVote {
+				continue
+			}
+			wantAmounts = append(wantAmounts, txIn.ValueIn)
+		}
+	}
+	for _, tx := range b1.Transactions[1:] {
+		for _, txIn := range tx.TxIn {
+			wantAmounts = append(wantAmounts, txIn.ValueIn)
+		}
+	}
+	b1Hash := b1.BlockHash()
+	spent, err := g.chain.FetchSpentTxOuts(&b1Hash)
+	if err != nil {
+		t.Fatalf("unexpected error fetching spent outputs: %v", err)
+	}
+	if len(spent) != len(wantAmounts) {
+		t.Fatalf("unexpected number of spent outputs -- got %d, want %d",
+			len(spent), len(wantAmounts))
+	}
+	for i := range spent {
+		if spent[i].Amount != wantAmounts[i] {
+			t.Fatalf("unexpected amount for spent output %d -- got %d, "+
+				"want %d", i, spent[i].Amount, wantAmounts[i])
+		}
+	}
+
+	// Ensure the last spent output is the coinbase output spent by the
+	// regular transaction.
+	last := spent[len(spent)-1]
+	if last.TxType != stake.TxTypeRegular || last.BlockIndex != 0 {
+		t.Fatalf("unexpected spent coinbase output: %+v", last)
+	}
+
+	// Ensure attempting to fetch the spent outputs of an unknown block
+	// returns the expected error.
+	var unknownHash chainhash.Hash
+	_, err = g.chain.FetchSpentTxOuts(&unknownHash)
+	if !errors.Is(err, ErrUnknownBlock) {
+		t.Fatalf("unexpected error for unknown block -- got %v, want %v",
+			err, ErrUnknownBlock)
+	}
+}
+
 // TestLocateInventory ensures that locating inventory via the LocateHeaders and
 // LocateBlocks functions behaves as expected.
 func TestLocateInventory(t *testing.T) {
@@ -1031,3 +1113,87 @@ func TestLocateInventory(t *testing.T) {
 		}
 	}
 }
+
+// TestHeaderHashesAfter ensures that retrieving the hashes of the headers in
+// the branch leading up to the best known header via HeaderHashesAfter behaves
+// as expected.
+func TestHeaderHashesAfter(t *testing.T) {
+	// Construct a synthetic block chain with a block index consisting of
+	// the following structure.
+	// 	genesis -> 1 -> 2 -> ... -> 15 -> 16  -> 17  -> 18
+	// 	                              \-> 16a -> 17a
+	tip := branchTip
+	chain := newFakeChain(chaincfg.MainNetParams())
+	genesis := chain.bestChain.Genesis()
+	branch0Nodes := chainedFakeNodes(genesis, 18)
+	branch1Nodes := chainedFakeNodes(branch0Nodes[14], 2)
+	for _, node := range branch0Nodes {
+		chain.index.AddNode(node)
+	}
+	for _, node := range branch1Nodes {
+		chain.index.AddNode(node)
+	}
+
+	// The fake nodes do not have any work, so explicitly set the best known
+	// header to the tip of the main branch.
+	chain.index.bestHeader = tip(branch0Nodes)
+
+	tests := []struct {
+		name      string         // test description
+		hash      chainhash.Hash // hash to retrieve the headers after
+		maxHashes int            // max number of hashes to retrieve
+		fork      chainhash.Hash // expected fork hash
+		hashes    []chainhash.Hash
+	}{{
+		name:      "genesis limited by max",
+		hash:      genesis.hash,
+		maxHashes: 3,
+		fork:      genesis.hash,
+		hashes:    nodeHashes(branch0Nodes, 0, 1, 2),
+	}, {
+		name:      "ancestor of best header",
+		hash:      branch0Nodes[14].hash,
+		maxHashes: 10,
+		fork:      branch0Nodes[14].hash,
+		hashes:    nodeHashes(branch0Nodes, 15, 16, 17),
+	}, {
+		name:      "best header",
+		hash:      tip(branch0Nodes).hash,
+		maxHashes: 10,
+		fork:      tip(branch0Nodes).hash,
+		hashes:    nodeHashes(branch0Nodes),
+	}, {
+		name:      "side chain",
+		hash:      tip(branch1Nodes).hash,
+		maxHashes: 10,
+		fork:      branch0Nodes[14].hash,
+		hashes:    nodeHashes(branch0Nodes, 15, 16, 17),
+	}}
+
+	for _, test := range tests {
+		fork, hashes, err := chain.HeaderHashesAfter(&test.hash,
+			test.maxHashes)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if fork != test.fork {
+			t.Errorf("%s: unexpected fork -- got %v, want %v", test.name,
+				fork, test.fork)
+			continue
+		}
+		if !reflect.DeepEqual(hashes, test.hashes) {
+			t.Errorf("%s: unexpected hashes -- got %v, want %v", test.name,
+				hashes, test.hashes)
+			continue
+		}
+	}
+
+	// Ensure an unknown hash is rejected.
+	unknownHash := chainhash.Hash{0x01}
+	_, _, err := chain.HeaderHashesAfter(&unknownHash, 10)
+	if !errors.Is(err, ErrUnknownBlock) {
+		t.Fatalf("unexpected error for unknown block -- got %v, want %v",
+			err, ErrUnknownBlock)
+	}
+}
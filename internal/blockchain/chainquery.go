@@ -1,4 +1,4 @@
-// Copyright (c) 2018-2021 The Decred developers
+// Copyright (c) 2018-2024 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
@@ -237,6 +237,57 @@ func (b *BlockChain) PutNextNeededBlocks(out []chainhash.Hash) []chainhash.Hash
 	return out[:outputIdx]
 }
 
+// HeaderHashesAfter returns the hashes of up to the provided maximum number of
+// block headers that follow the provided block header in the branch leading up
+// to the current best known header along with the hash of the most recent
+// block header the provided one has in common with that branch.
+//
+// The returned fork hash is the provided hash itself when the provided block
+// header is an ancestor of the current best known header.  Otherwise, the
+// returned hashes start after the fork point.  This allows callers that track
+// their progress through the headers, such as when syncing the committed
+// filters without the full blocks, to detect and handle reorganizations of the
+// best known header.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) HeaderHashesAfter(hash *chainhash.Hash, maxHashes int) (chainhash.Hash, []chainhash.Hash, error) {
+	b.index.RLock()
+	defer b.index.RUnlock()
+
+	node := b.index.lookupNode(hash)
+	if node == nil {
+		return chainhash.Hash{}, nil, unknownBlockError(hash)
+	}
+
+	// Find the most recent ancestor of the provided header that is also in the
+	// branch leading up to the best known header.
+	bestHeader := b.index.bestHeader
+	fork := node
+	if fork.height > bestHeader.height {
+		fork = fork.Ancestor(bestHeader.height)
+	}
+	for fork != nil && bestHeader.Ancestor(fork.height) != fork {
+		fork = fork.parent
+	}
+	if fork == nil {
+		return chainhash.Hash{}, nil, unknownBlockError(hash)
+	}
+
+	// Populate the hashes from back to front by walking backwards from the
+	// final header to include.
+	numHashes := bestHeader.height - fork.height
+	if numHashes > int64(maxHashes) {
+		numHashes = int64(maxHashes)
+	}
+	hashes := make([]chainhash.Hash, numHashes)
+	node = bestHeader.Ancestor(fork.height + numHashes)
+	for i := numHashes - 1; i >= 0; i-- {
+		hashes[i] = node.hash
+		node = node.parent
+	}
+	return fork.hash, hashes, nil
+}
+
 // VerifyProgress returns a percentage that is a guess of the progress of the
 // chain verification process.
 //
@@ -1,5 +1,5 @@
 // Copyright (c) 2013-2016 The btcsuite developers
-// Copyright (c) 2015-2023 The Decred developers
+// Copyright (c) 2015-2024 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
@@ -899,6 +899,29 @@ func (b *BlockChain) CalcNextRequiredStakeDifficulty(hash *chainhash.Hash) (int6
 	return nextDiff, nil
 }
 
+// CalcNextRequiredStakeDifficultyV2 calculates the required stake difficulty
+// for the block after the given block using the stake difficulty algorithm
+// defined by DCP0001 regardless of whether or not the associated agenda is
+// active.
+//
+// Unlike CalcNextRequiredStakeDifficulty, the calculation only depends on
+// information available in the block headers, so it may be used for blocks
+// whose data is not available, such as when only syncing the headers.  It is
+// up to the caller to ensure the algorithm applies to the given block.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) CalcNextRequiredStakeDifficultyV2(hash *chainhash.Hash) (int64, error) {
+	node := b.index.LookupNode(hash)
+	if node == nil {
+		return 0, unknownBlockError(hash)
+	}
+
+	b.chainLock.Lock()
+	nextDiff := b.calcNextRequiredStakeDifficultyV2(node)
+	b.chainLock.Unlock()
+	return nextDiff, nil
+}
+
 // estimateNextStakeDifficultyV1 estimates the next stake difficulty by
 // pretending the provided number of tickets will be purchased in the remainder
 // of the interval unless the flag to use max tickets is set in which case it
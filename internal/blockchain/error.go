@@ -575,6 +575,10 @@ const (
 	// ErrUnknownBlock indicates a requested block does not exist.
 	ErrUnknownBlock = ErrorKind("ErrUnknownBlock")
 
+	// ErrBlockPruned indicates a requested block is known, but its data is no
+	// longer available because it has been pruned.
+	ErrBlockPruned = ErrorKind("ErrBlockPruned")
+
 	// ErrNoFilter indicates a filter for a given block hash does not exist.
 	ErrNoFilter = ErrorKind("ErrNoFilter")
 
@@ -735,6 +739,13 @@ func unknownBlockError(hash *chainhash.Hash) ContextError {
 	return contextError(ErrUnknownBlock, str)
 }
 
+// prunedBlockError create a ContextError with the kind of error set to
+// ErrBlockPruned and a description that includes the provided hash.
+func prunedBlockError(hash *chainhash.Hash) ContextError {
+	str := fmt.Sprintf("block %s data has been pruned", hash)
+	return contextError(ErrBlockPruned, str)
+}
+
 // RuleError identifies a rule violation.  It is used to indicate that
 // processing of a block or transaction failed due to one of the many validation
 // rules.  It has full support for errors.Is and errors.As, so the caller can
@@ -146,6 +146,7 @@ func TestErrorKindStringer(t *testing.T) {
 		{ErrTicketExhaustion, "ErrTicketExhaustion"},
 		{ErrDBTooOldToUpgrade, "ErrDBTooOldToUpgrade"},
 		{ErrUnknownBlock, "ErrUnknownBlock"},
+		{ErrBlockPruned, "ErrBlockPruned"},
 		{ErrNoFilter, "ErrNoFilter"},
 		{ErrNoTreasuryBalance, "ErrNoTreasuryBalance"},
 		{ErrInvalidateGenesisBlock, "ErrInvalidateGenesisBlock"},
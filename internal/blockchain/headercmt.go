@@ -9,6 +9,7 @@ import (
 
 	"github.com/decred/dcrd/blockchain/standalone/v2"
 	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/chaincfg/v3"
 	"github.com/decred/dcrd/database/v3"
 	"github.com/decred/dcrd/dcrutil/v4"
 	"github.com/decred/dcrd/gcs/v4"
@@ -191,3 +192,42 @@ func (b *BlockChain) FilterByBlockHash(hash *chainhash.Hash) (*gcs.FilterV2, *He
 	}
 	return filter, headerProof, nil
 }
+
+// HeaderCmtActivationHeight returns the height of the first block for which the
+// header commitments agenda, as defined in DCP0005, is active on the network
+// defined by the provided parameters along with whether or not it is known.
+// False is also returned when the agenda is forced to fail.
+//
+// Determining the point at which the agenda activated generally requires the
+// votes contained in the full blocks, so it is only known for networks where
+// the agenda is forced active via a forced choice in the deployment parameters
+// and networks where it has already activated, namely the main and test
+// networks.  Callers that only have access to the block headers must not assume
+// the header commitments are present on other networks.
+func HeaderCmtActivationHeight(params *chaincfg.Params) (int64, bool) {
+	// The agenda is active for all blocks after the genesis block when it is
+	// forced active.
+	for _, deployments := range params.Deployments {
+		for i := range deployments {
+			deployment := &deployments[i]
+			if deployment.Vote.Id != chaincfg.VoteIDHeaderCommitments {
+				continue
+			}
+			forcedState, err := determineForcedThresholdState(deployment)
+			if err != nil || forcedState == nil {
+				break
+			}
+			return 1, forcedState.State == ThresholdActive
+		}
+	}
+
+	// The agenda activated at the following heights on networks where the
+	// outcome of the vote is already known.
+	switch params.Net {
+	case wire.MainNet:
+		return 431488, true
+	case wire.TestNet3:
+		return 323328, true
+	}
+	return 0, false
+}
@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/chaincfg/v3"
 )
 
 // TestCalcCommitmentRootV1 ensures the expected version 1 commitment root is
@@ -54,3 +55,46 @@ func TestCalcCommitmentRootV1(t *testing.T) {
 		}
 	}
 }
+
+// TestHeaderCmtActivationHeight ensures the header commitments activation
+// height is only known for the expected networks and is the expected value.
+func TestHeaderCmtActivationHeight(t *testing.T) {
+	tests := []struct {
+		name       string           // test description
+		params     *chaincfg.Params // network params
+		wantHeight int64            // expected activation height
+		wantKnown  bool             // expected known flag
+	}{{
+		name:       "mainnet",
+		params:     chaincfg.MainNetParams(),
+		wantHeight: 431488,
+		wantKnown:  true,
+	}, {
+		name:       "testnet",
+		params:     chaincfg.TestNet3Params(),
+		wantHeight: 323328,
+		wantKnown:  true,
+	}, {
+		name:       "simnet forced active",
+		params:     chaincfg.SimNetParams(),
+		wantHeight: 1,
+		wantKnown:  true,
+	}, {
+		name:      "regnet voted",
+		params:    chaincfg.RegNetParams(),
+		wantKnown: false,
+	}}
+
+	for _, test := range tests {
+		height, known := HeaderCmtActivationHeight(test.params)
+		if known != test.wantKnown {
+			t.Errorf("%q: mismatched known -- got %v, want %v", test.name,
+				known, test.wantKnown)
+			continue
+		}
+		if known && height != test.wantHeight {
+			t.Errorf("%q: mismatched height -- got %d, want %d", test.name,
+				height, test.wantHeight)
+		}
+	}
+}
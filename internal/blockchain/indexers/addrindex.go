@@ -0,0 +1,851 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/decred/dcrd/blockchain/stake/v5"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/database/v3"
+	"github.com/decred/dcrd/dcrutil/v4"
+	"github.com/decred/dcrd/txscript/v4/stdaddr"
+	"github.com/decred/dcrd/txscript/v4/stdscript"
+	"github.com/decred/dcrd/wire"
+)
+
+const (
+	// addrIndexName is the human-readable name for the index.
+	addrIndexName = "address index"
+
+	// addrIndexVersion is the current version of the address index.
+	addrIndexVersion = 1
+
+	// addrIndexEntryKeySize is the size of an address index entry key.  It
+	// consists of the address key + 4 bytes block id + 1 byte tree + 4 bytes
+	// block index.
+	addrIndexEntryKeySize = addrKeySize + 4 + 1 + 4
+
+	// addrIndexEntryValueSize is the size of an address index entry value.
+	// It consists of 4 bytes start offset + 4 bytes tx length.
+	addrIndexEntryValueSize = 4 + 4
+)
+
+var (
+	// addrIndexKey is the key of the address index and the db bucket used to
+	// house it.
+	addrIndexKey = []byte("addrtxidx")
+
+	// addrIndexBlocksBucketName is the name of the db bucket used to house
+	// the block hash -> involved addresses mapping.
+	addrIndexBlocksBucketName = []byte("addrtxidxblocks")
+)
+
+// -----------------------------------------------------------------------------
+// The address index maps every address involved in a transaction in the main
+// chain to the location of that transaction.  An address is involved in a
+// transaction when the transaction either pays to it, including stake and
+// treasury outputs as well as the commitments in ticket purchases, or spends
+// an output that pays to it.
+//
+// It relies on the transaction index for the internal block IDs that identify
+// the blocks and for the previous outputs spent by the transactions and is
+// therefore a dependent of it.
+//
+// There are two buckets used in total.  The first bucket houses an entry for
+// every address and transaction pair.  Since the keys are stored in
+// lexicographical order and the internal block IDs are assigned sequentially
+// as blocks are connected, iterating the entries that share the prefix of a
+// given address yields all transactions involving the address in the order
+// they appear in the main chain.  The second bucket maps the hash of each
+// block to the addresses involved in it along with its internal block ID so
+// that the entries are able to be removed when the block is disconnected
+// without requiring access to the outputs the block spends.
+//
+// The serialized format for the keys and values in the address index bucket
+// is:
+//
+//   <addr key><block id><tree><block index> = <start offset><tx length>
+//
+//   Field           Type              Size
+//   addr key        [21]byte          21 bytes
+//   block id        uint32            4 bytes (big endian)
+//   tree            int8              1 byte
+//   block index     uint32            4 bytes (big endian)
+//   start offset    uint32            4 bytes
+//   tx length       uint32            4 bytes
+//   -----
+//   Total: 38 bytes
+//
+// The serialized format for the keys and values in the block hash to involved
+// addresses bucket is:
+//
+//   <hash> = <block id>[<addr key>...]
+//
+//   Field           Type              Size
+//   hash            chainhash.Hash    32 bytes
+//   block id        uint32            4 bytes
+//   addr key        [21]byte          21 bytes
+//   -----
+//   Total: 36 bytes + 21 bytes per involved address
+// -----------------------------------------------------------------------------
+
+// PrevScripter defines an interface that provides access to scripts and their
+// associated version keyed by an outpoint.  The boolean indicates whether or
+// not the script and version for the provided outpoint was found.
+type PrevScripter interface {
+	PrevScript(*wire.OutPoint) (uint16, []byte, bool)
+}
+
+// prevScriptEntry houses a script and its associated version.
+type prevScriptEntry struct {
+	version uint16
+	script  []byte
+}
+
+// prevScripts provides a source of the scripts and associated script versions
+// of the outputs spent by the transactions in a block and implements the
+// PrevScripter interface.
+type prevScripts map[wire.OutPoint]prevScriptEntry
+
+// PrevScript returns the script and script version associated with the provided
+// previous outpoint along with a bool that indicates whether or not the
+// requested entry exists.
+func (s prevScripts) PrevScript(prevOut *wire.OutPoint) (uint16, []byte, bool) {
+	entry, ok := s[*prevOut]
+	if !ok {
+		return 0, nil, false
+	}
+	return entry.version, entry.script, true
+}
+
+// isNullOutpoint returns whether or not the provided outpoint is the null
+// outpoint referenced by coinbase, stakebase, treasurybase, and treasury spend
+// inputs which do not spend a previous output.
+func isNullOutpoint(prevOut *wire.OutPoint) bool {
+	return prevOut.Hash == chainhash.Hash{}
+}
+
+// dbFetchPrevScripts uses an existing database transaction to load the scripts
+// of all outputs spent by the transactions in the provided block.  Outputs
+// created earlier in the same block are taken directly from the block while
+// all others are loaded from the transaction index.  Outputs of transactions
+// that are not in the transaction index are ignored.
+func dbFetchPrevScripts(dbTx database.Tx, block *dcrutil.Block) (prevScripts, error) {
+	blockTxns := make(map[chainhash.Hash]*wire.MsgTx,
+		len(block.Transactions())+len(block.STransactions()))
+	for _, tx := range block.Transactions() {
+		blockTxns[*tx.Hash()] = tx.MsgTx()
+	}
+	for _, tx := range block.STransactions() {
+		blockTxns[*tx.Hash()] = tx.MsgTx()
+	}
+
+	scripts := make(prevScripts)
+	loadTxInScripts := func(txns []*dcrutil.Tx) error {
+		for _, tx := range txns {
+			for _, txIn := range tx.MsgTx().TxIn {
+				prevOut := &txIn.PreviousOutPoint
+				if isNullOutpoint(prevOut) {
+					continue
+				}
+
+				prevTx, ok := blockTxns[prevOut.Hash]
+				if !ok {
+					entry, err := dbFetchTxIndexEntry(dbTx, &prevOut.Hash)
+					if err != nil {
+						return err
+					}
+					if entry == nil {
+						continue
+					}
+					txBytes, err := dbTx.FetchBlockRegion(&entry.BlockRegion)
+					if err != nil {
+						return err
+					}
+					var msgTx wire.MsgTx
+					err = msgTx.Deserialize(bytes.NewReader(txBytes))
+					if err != nil {
+						return err
+					}
+					prevTx = &msgTx
+					blockTxns[prevOut.Hash] = prevTx
+				}
+				if prevOut.Index >= uint32(len(prevTx.TxOut)) {
+					continue
+				}
+				txOut := prevTx.TxOut[prevOut.Index]
+				scripts[*prevOut] = prevScriptEntry{
+					version: txOut.Version,
+					script:  txOut.PkScript,
+				}
+			}
+		}
+		return nil
+	}
+	if err := loadTxInScripts(block.Transactions()); err != nil {
+		return nil, err
+	}
+	if err := loadTxInScripts(block.STransactions()); err != nil {
+		return nil, err
+	}
+	return scripts, nil
+}
+
+// txAddrKeys returns the keys of all supported addresses involved in the
+// provided transaction.  That is to say the addresses of all outputs and
+// ticket commitments along with the addresses of the outputs spent by the
+// inputs as provided by the given previous script source.
+func txAddrKeys(tx *wire.MsgTx, scripts PrevScripter, params *chaincfg.Params) map[[addrKeySize]byte]struct{} {
+	addrKeys := make(map[[addrKeySize]byte]struct{})
+	addAddrs := func(addrs []stdaddr.Address) {
+		for _, addr := range addrs {
+			k, err := addrToKey(addr)
+			if err != nil {
+				// Ignore unsupported address types.
+				continue
+			}
+			addrKeys[k] = struct{}{}
+		}
+	}
+
+	for _, txIn := range tx.TxIn {
+		prevOut := &txIn.PreviousOutPoint
+		if isNullOutpoint(prevOut) {
+			continue
+		}
+		version, script, ok := scripts.PrevScript(prevOut)
+		if !ok {
+			continue
+		}
+		_, addrs := stdscript.ExtractAddrs(version, script, params)
+		addAddrs(addrs)
+	}
+
+	isSStx := stake.IsSStx(tx)
+	for _, txOut := range tx.TxOut {
+		scriptType, addrs := stdscript.ExtractAddrs(txOut.Version,
+			txOut.PkScript, params)
+		if isSStx && scriptType == stdscript.STNullData {
+			addr, err := stake.AddrFromSStxPkScrCommitment(txOut.PkScript,
+				params)
+			if err != nil {
+				// Ignore unsupported address types.
+				continue
+			}
+			addrs = append(addrs, addr)
+		}
+		addAddrs(addrs)
+	}
+
+	return addrKeys
+}
+
+// putAddrIndexEntryKey serializes the provided values according to the format
+// described above for an address index entry key.  The target byte slice must
+// be at least large enough to handle the number of bytes defined by the
+// addrIndexEntryKeySize constant or it will panic.
+func putAddrIndexEntryKey(target []byte, addrKey [addrKeySize]byte, blockID uint32, tree int8, blockIndex uint32) {
+	copy(target, addrKey[:])
+	binary.BigEndian.PutUint32(target[addrKeySize:], blockID)
+	target[addrKeySize+4] = byte(tree)
+	binary.BigEndian.PutUint32(target[addrKeySize+5:], blockIndex)
+}
+
+// dbFetchBlockIDByHash uses an existing database transaction to retrieve the
+// internal block id for the provided block hash from the index.
+func dbFetchBlockIDByHash(dbTx database.Tx, hash *chainhash.Hash) (uint32, error) {
+	hashIndex := dbTx.Metadata().Bucket(idByHashIndexBucketName)
+	serializedID := hashIndex.Get(hash[:])
+	if serializedID == nil {
+		return 0, errNoBlockIDEntry
+	}
+	return byteOrder.Uint32(serializedID), nil
+}
+
+// AddrIndex implements a transaction by address index.  That is to say, it
+// supports querying all transactions that involve a given address, either by
+// paying to it or by spending an output that pays to it.
+//
+// In addition, support is provided for a memory-only index of unconfirmed
+// transactions such as those which are kept in the memory pool before
+// inclusion in a block.
+type AddrIndex struct {
+	// The following fields are set when the instance is created and can't
+	// be changed afterwards, so there is no need to protect them with a
+	// separate mutex.
+	db    database.DB
+	chain ChainQueryer
+	sub   *IndexSubscription
+
+	// The following fields are used to quickly link transactions and
+	// addresses that have not been included into a block yet.  They are
+	// protected by the unconfirmedLock field.
+	//
+	// The txnsByAddr field is used to keep an index of all transactions
+	// which either create an output to a given address or spend from a
+	// previous output to it keyed by the address.
+	//
+	// The addrsByTx field is essentially the reverse and is used to keep an
+	// index of all addresses which a given transaction involves.  This allows
+	// fairly efficient updates when transactions are removed once they are
+	// included into a block.
+	//
+	// The unconfirmedSeq field is used to order the unconfirmed transactions
+	// by the time they were added.
+	unconfirmedLock sync.RWMutex
+	txnsByAddr      map[[addrKeySize]byte]map[chainhash.Hash]unconfirmedAddrTx
+	addrsByTx       map[chainhash.Hash]map[[addrKeySize]byte]struct{}
+	unconfirmedSeq  uint64
+
+	subscribers map[chan bool]struct{}
+	mtx         sync.Mutex
+	cancel      context.CancelFunc
+}
+
+// unconfirmedAddrTx houses an unconfirmed transaction tracked by the address
+// index along with the sequence number that identifies the order it was added.
+type unconfirmedAddrTx struct {
+	tx  *dcrutil.Tx
+	seq uint64
+}
+
+// Ensure the AddrIndex type implements the Indexer interface.
+var _ Indexer = (*AddrIndex)(nil)
+
+// NewAddrIndex returns a new instance of an indexer that is used to create a
+// mapping of all addresses in the blockchain to the respective transactions
+// that involve them.
+//
+// The address index depends on the transaction index, so it must be created
+// before the address index.
+func NewAddrIndex(subscriber *IndexSubscriber, db database.DB, chain ChainQueryer) (*AddrIndex, error) {
+	idx := &AddrIndex{
+		db:          db,
+		chain:       chain,
+		txnsByAddr:  make(map[[addrKeySize]byte]map[chainhash.Hash]unconfirmedAddrTx),
+		addrsByTx:   make(map[chainhash.Hash]map[[addrKeySize]byte]struct{}),
+		subscribers: make(map[chan bool]struct{}),
+		cancel:      subscriber.cancel,
+	}
+
+	// The address index is an optional index.  It depends on the
+	// transaction index for the internal block IDs and the spent outputs and
+	// is updated asynchronously after it.
+	sub, err := subscriber.Subscribe(idx, txIndexName)
+	if err != nil {
+		return nil, err
+	}
+
+	idx.sub = sub
+
+	err = idx.Init(subscriber.ctx, chain.ChainParams())
+	if err != nil {
+		return nil, err
+	}
+
+	return idx, nil
+}
+
+// Init initializes the address index.
+//
+// This is part of the Indexer interface.
+func (idx *AddrIndex) Init(ctx context.Context, chainParams *chaincfg.Params) error {
+	if interruptRequested(ctx) {
+		return indexerError(ErrInterruptRequested, interruptMsg)
+	}
+
+	// Finish any drops that were previously interrupted.
+	if err := finishDrop(ctx, idx); err != nil {
+		return err
+	}
+
+	// Create the initial state for the index as needed.
+	if err := createIndex(idx, &chainParams.GenesisHash); err != nil {
+		return err
+	}
+
+	// Upgrade the index as needed.
+	if err := upgradeIndex(ctx, idx, &chainParams.GenesisHash); err != nil {
+		return err
+	}
+
+	// Recover the address index to the main chain if needed.
+	return recoverIndex(ctx, idx)
+}
+
+// Key returns the database key to use for the index as a byte slice.
+//
+// This is part of the Indexer interface.
+func (idx *AddrIndex) Key() []byte {
+	return addrIndexKey
+}
+
+// Name returns the human-readable name of the index.
+//
+// This is part of the Indexer interface.
+func (idx *AddrIndex) Name() string {
+	return addrIndexName
+}
+
+// Version returns the current version of the index.
+//
+// This is part of the Indexer interface.
+func (idx *AddrIndex) Version() uint32 {
+	return addrIndexVersion
+}
+
+// DB returns the database of the index.
+//
+// This is part of the Indexer interface.
+func (idx *AddrIndex) DB() database.DB {
+	return idx.db
+}
+
+// Queryer returns the chain queryer.
+//
+// This is part of the Indexer interface.
+func (idx *AddrIndex) Queryer() ChainQueryer {
+	return idx.chain
+}
+
+// Tip returns the current tip of the index.
+//
+// This is part of the Indexer interface.
+func (idx *AddrIndex) Tip() (int64, *chainhash.Hash, error) {
+	return tip(idx.db, idx.Key())
+}
+
+// IndexSubscription returns the subscription for index updates.
+//
+// This is part of the Indexer interface.
+func (idx *AddrIndex) IndexSubscription() *IndexSubscription {
+	return idx.sub
+}
+
+// NotifySyncSubscribers signals subscribers of an index sync update.
+//
+// This is part of the Indexer interface.
+func (idx *AddrIndex) NotifySyncSubscribers() {
+	idx.mtx.Lock()
+	notifySyncSubscribers(idx.subscribers)
+	idx.mtx.Unlock()
+}
+
+// WaitForSync subscribes clients for the next index sync update.
+//
+// This is part of the Indexer interface.
+func (idx *AddrIndex) WaitForSync() chan bool {
+	c := make(chan bool)
+
+	idx.mtx.Lock()
+	idx.subscribers[c] = struct{}{}
+	idx.mtx.Unlock()
+
+	return c
+}
+
+// Create is invoked when the index is created for the first time.  It creates
+// the buckets for the address index and the block hash to involved addresses
+// mapping.
+//
+// This is part of the Indexer interface.
+func (idx *AddrIndex) Create(dbTx database.Tx) error {
+	meta := dbTx.Metadata()
+	if _, err := meta.CreateBucket(addrIndexBlocksBucketName); err != nil {
+		return err
+	}
+	_, err := meta.CreateBucket(addrIndexKey)
+	return err
+}
+
+// connectBlock adds an entry for every address involved in every transaction
+// in the passed block.
+func (idx *AddrIndex) connectBlock(dbTx database.Tx, block *dcrutil.Block) error {
+	// NOTE: The fact that the block can disapprove the regular tree of the
+	// previous block is ignored for this index for the same reasons it is
+	// ignored by the transaction index it depends on.
+
+	// The block ID is assigned by the transaction index which processes the
+	// block before this index.
+	blockID, err := dbFetchBlockIDByHash(dbTx, block.Hash())
+	if err != nil {
+		return err
+	}
+
+	// The offset and length of the transactions within the serialized block.
+	txLocs, stakeTxLocs, err := block.TxLoc()
+	if err != nil {
+		return err
+	}
+
+	scripts, err := dbFetchPrevScripts(dbTx, block)
+	if err != nil {
+		return err
+	}
+
+	params := idx.chain.ChainParams()
+	addrIdxBucket := dbTx.Metadata().Bucket(addrIndexKey)
+	blockAddrs := make(map[[addrKeySize]byte]struct{})
+	addEntries := func(txns []*dcrutil.Tx, txLocs []wire.TxLoc, tree int8) error {
+		for i, tx := range txns {
+			var value [addrIndexEntryValueSize]byte
+			byteOrder.PutUint32(value[:], uint32(txLocs[i].TxStart))
+			byteOrder.PutUint32(value[4:], uint32(txLocs[i].TxLen))
+			for addrKey := range txAddrKeys(tx.MsgTx(), scripts, params) {
+				var key [addrIndexEntryKeySize]byte
+				putAddrIndexEntryKey(key[:], addrKey, blockID, tree, uint32(i))
+				if err := addrIdxBucket.Put(key[:], value[:]); err != nil {
+					return err
+				}
+				blockAddrs[addrKey] = struct{}{}
+			}
+		}
+		return nil
+	}
+	err = addEntries(block.Transactions(), txLocs, wire.TxTreeRegular)
+	if err != nil {
+		return err
+	}
+	err = addEntries(block.STransactions(), stakeTxLocs, wire.TxTreeStake)
+	if err != nil {
+		return err
+	}
+
+	// Store the addresses involved in the block along with its block ID so
+	// the entries can be removed when the block is disconnected.
+	serialized := make([]byte, 4, 4+len(blockAddrs)*addrKeySize)
+	byteOrder.PutUint32(serialized, blockID)
+	for addrKey := range blockAddrs {
+		serialized = append(serialized, addrKey[:]...)
+	}
+	blocksBucket := dbTx.Metadata().Bucket(addrIndexBlocksBucketName)
+	if err := blocksBucket.Put(block.Hash()[:], serialized); err != nil {
+		return err
+	}
+
+	// Update the current index tip.
+	return dbPutIndexerTip(dbTx, idx.Key(), block.Hash(), int32(block.Height()))
+}
+
+// disconnectBlock removes all entries for the addresses involved in the
+// transactions in the passed block.
+func (idx *AddrIndex) disconnectBlock(dbTx database.Tx, block *dcrutil.Block) error {
+	// Load the addresses involved in the block along with its block ID.
+	blocksBucket := dbTx.Metadata().Bucket(addrIndexBlocksBucketName)
+	serialized := blocksBucket.Get(block.Hash()[:])
+	if len(serialized) < 4 || (len(serialized)-4)%addrKeySize != 0 {
+		str := fmt.Sprintf("corrupt address index block entry for %s",
+			block.Hash())
+		return makeDbErr(database.ErrCorruption, str)
+	}
+	blockID := byteOrder.Uint32(serialized)
+
+	// Remove all entries for each of the addresses in the block.  They all
+	// share a prefix consisting of the address key and the block ID.
+	addrIdxBucket := dbTx.Metadata().Bucket(addrIndexKey)
+	var keys [][]byte
+	for offset := 4; offset < len(serialized); offset += addrKeySize {
+		var prefix [addrKeySize + 4]byte
+		copy(prefix[:], serialized[offset:offset+addrKeySize])
+		binary.BigEndian.PutUint32(prefix[addrKeySize:], blockID)
+
+		cursor := addrIdxBucket.Cursor()
+		for ok := cursor.Seek(prefix[:]); ok; ok = cursor.Next() {
+			key := cursor.Key()
+			if !bytes.HasPrefix(key, prefix[:]) {
+				break
+			}
+			keys = append(keys, append([]byte(nil), key...))
+		}
+	}
+	for _, key := range keys {
+		if err := addrIdxBucket.Delete(key); err != nil {
+			return err
+		}
+	}
+	if err := blocksBucket.Delete(block.Hash()[:]); err != nil {
+		return err
+	}
+
+	// Update the current index tip.
+	return dbPutIndexerTip(dbTx, idx.Key(), &block.MsgBlock().Header.PrevBlock,
+		int32(block.Height()-1))
+}
+
+// EntriesForAddress returns the transaction index entries for the transactions
+// in the main chain that involve the provided address.  The block regions
+// contained in the results can in turn be used to load the raw transaction
+// bytes.
+//
+// The entries are ordered by their position in the main chain, from oldest to
+// newest, unless the reverse flag is set in which case they are ordered from
+// newest to oldest.  The provided number of entries are skipped first and then
+// up to the requested number of entries are returned along with the number of
+// entries that were actually skipped, which might be less than requested when
+// there are not enough entries.
+//
+// This function is safe for concurrent access.
+func (idx *AddrIndex) EntriesForAddress(addr stdaddr.Address, numToSkip, numRequested uint32, reverse bool) ([]TxIndexEntry, uint32, error) {
+	addrKey, err := addrToKey(addr)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var entries []TxIndexEntry
+	var numSkipped uint32
+	err = idx.db.View(func(dbTx database.Tx) error {
+		addrIdxBucket := dbTx.Metadata().Bucket(addrIndexKey)
+		cursor := addrIdxBucket.Cursor()
+
+		// Position the cursor at the first entry for the address in the
+		// requested order.  In the case of the reverse order, this is achieved
+		// by seeking to the first key after all keys for the address and
+		// moving back from there.
+		var ok bool
+		if !reverse {
+			ok = cursor.Seek(addrKey[:])
+		} else {
+			var upperBound [addrIndexEntryKeySize + 1]byte
+			copy(upperBound[:], addrKey[:])
+			for i := addrKeySize; i < len(upperBound); i++ {
+				upperBound[i] = 0xff
+			}
+			if cursor.Seek(upperBound[:]) {
+				ok = cursor.Prev()
+			} else {
+				ok = cursor.Last()
+			}
+		}
+
+		for ; ok && uint32(len(entries)) < numRequested; ok = advance(cursor, reverse) {
+			key := cursor.Key()
+			if !bytes.HasPrefix(key, addrKey[:]) {
+				break
+			}
+			if numSkipped < numToSkip {
+				numSkipped++
+				continue
+			}
+			if len(key) != addrIndexEntryKeySize ||
+				len(cursor.Value()) != addrIndexEntryValueSize {
+
+				str := fmt.Sprintf("corrupt address index entry for %s", addr)
+				return makeDbErr(database.ErrCorruption, str)
+			}
+
+			// Load the block hash associated with the block ID.
+			serializedID := key[addrKeySize : addrKeySize+4]
+			var id [4]byte
+			byteOrder.PutUint32(id[:], binary.BigEndian.Uint32(serializedID))
+			hash, err := dbFetchBlockHashBySerializedID(dbTx, id[:])
+			if err != nil {
+				str := fmt.Sprintf("corrupt address index entry for %s: %v",
+					addr, err)
+				return makeDbErr(database.ErrCorruption, str)
+			}
+
+			value := cursor.Value()
+			entries = append(entries, TxIndexEntry{
+				BlockRegion: database.BlockRegion{
+					Hash:   hash,
+					Offset: byteOrder.Uint32(value[0:4]),
+					Len:    byteOrder.Uint32(value[4:8]),
+				},
+				BlockIndex: binary.BigEndian.Uint32(key[addrKeySize+5:]),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return entries, numSkipped, nil
+}
+
+// advance moves the provided cursor one key/value pair forward, or backward
+// when the reverse flag is set, and returns whether or not the pair exists.
+func advance(cursor database.Cursor, reverse bool) bool {
+	if reverse {
+		return cursor.Prev()
+	}
+	return cursor.Next()
+}
+
+// AddUnconfirmedTx adds all addresses related to the transaction to the
+// unconfirmed (memory-only) address index.  The provided previous script
+// source must provide the scripts of the outputs the transaction spends.
+//
+// This function is safe for concurrent access.
+func (idx *AddrIndex) AddUnconfirmedTx(tx *dcrutil.Tx, scripts PrevScripter) {
+	addrKeys := txAddrKeys(tx.MsgTx(), scripts, idx.chain.ChainParams())
+	if len(addrKeys) == 0 {
+		return
+	}
+
+	txHash := *tx.Hash()
+	idx.unconfirmedLock.Lock()
+	if _, ok := idx.addrsByTx[txHash]; ok {
+		idx.unconfirmedLock.Unlock()
+		return
+	}
+	idx.unconfirmedSeq++
+	entry := unconfirmedAddrTx{tx: tx, seq: idx.unconfirmedSeq}
+	for addrKey := range addrKeys {
+		txns, ok := idx.txnsByAddr[addrKey]
+		if !ok {
+			txns = make(map[chainhash.Hash]unconfirmedAddrTx)
+			idx.txnsByAddr[addrKey] = txns
+		}
+		txns[txHash] = entry
+	}
+	idx.addrsByTx[txHash] = addrKeys
+	idx.unconfirmedLock.Unlock()
+}
+
+// RemoveUnconfirmedTx removes the passed transaction from the unconfirmed
+// (memory-only) address index.
+//
+// This function is safe for concurrent access.
+func (idx *AddrIndex) RemoveUnconfirmedTx(hash *chainhash.Hash) {
+	idx.unconfirmedLock.Lock()
+	for addrKey := range idx.addrsByTx[*hash] {
+		delete(idx.txnsByAddr[addrKey], *hash)
+		if len(idx.txnsByAddr[addrKey]) == 0 {
+			delete(idx.txnsByAddr, addrKey)
+		}
+	}
+	delete(idx.addrsByTx, *hash)
+	idx.unconfirmedLock.Unlock()
+}
+
+// UnconfirmedTxnsForAddress returns all transactions currently in the
+// unconfirmed (memory-only) address index that involve the passed address
+// ordered by the time they were added.  Unsupported address types are ignored
+// and will result in no results.
+//
+// This function is safe for concurrent access.
+func (idx *AddrIndex) UnconfirmedTxnsForAddress(addr stdaddr.Address) []*dcrutil.Tx {
+	addrKey, err := addrToKey(addr)
+	if err != nil {
+		return nil
+	}
+
+	idx.unconfirmedLock.RLock()
+	entries := make([]unconfirmedAddrTx, 0, len(idx.txnsByAddr[addrKey]))
+	for _, entry := range idx.txnsByAddr[addrKey] {
+		entries = append(entries, entry)
+	}
+	idx.unconfirmedLock.RUnlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].seq < entries[j].seq
+	})
+	txns := make([]*dcrutil.Tx, 0, len(entries))
+	for _, entry := range entries {
+		txns = append(txns, entry.tx)
+	}
+	return txns
+}
+
+// DropAddrIndex drops the address index from the provided database if it
+// exists.
+func DropAddrIndex(ctx context.Context, db database.DB) error {
+	// Nothing to do if the index doesn't already exist.
+	exists, err := existsIndex(db, addrIndexKey)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		log.Infof("Not dropping %s because it does not exist", addrIndexName)
+		return nil
+	}
+
+	// Mark that the index is in the process of being dropped so that it can
+	// be resumed on the next start if interrupted before the process is
+	// complete.
+	err = markIndexDeletion(db, addrIndexKey)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Dropping all %s entries.  This might take a while...",
+		addrIndexName)
+
+	// Since the indexes can be so large, attempting to simply delete the
+	// bucket in a single database transaction would result in massive memory
+	// usage and likely crash many systems due to ulimits.  In order to avoid
+	// this, use a cursor to delete a maximum number of entries out of the
+	// bucket at a time.
+	err = incrementalFlatDrop(ctx, db, addrIndexKey, addrIndexName)
+	if err != nil {
+		return err
+	}
+
+	// Remove the block hash to involved addresses mapping.
+	err = db.Update(func(dbTx database.Tx) error {
+		meta := dbTx.Metadata()
+		if meta.Bucket(addrIndexBlocksBucketName) == nil {
+			return nil
+		}
+		return meta.DeleteBucket(addrIndexBlocksBucketName)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Remove the index tip, version, bucket, and in-progress drop flag now
+	// that all index entries have been removed.
+	err = dropIndexMetadata(db, addrIndexKey)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Dropped %s", addrIndexName)
+	return nil
+}
+
+// DropIndex drops the address index from the provided database if it exists.
+func (*AddrIndex) DropIndex(ctx context.Context, db database.DB) error {
+	return DropAddrIndex(ctx, db)
+}
+
+// ProcessNotification indexes the provided notification based on its
+// notification type.
+//
+// This is part of the Indexer interface.
+func (idx *AddrIndex) ProcessNotification(dbTx database.Tx, ntfn *IndexNtfn) error {
+	switch ntfn.NtfnType {
+	case ConnectNtfn:
+		err := idx.connectBlock(dbTx, ntfn.Block)
+		if err != nil {
+			msg := fmt.Sprintf("%s: unable to connect block: %v",
+				idx.Name(), err)
+			return indexerError(ErrConnectBlock, msg)
+		}
+
+	case DisconnectNtfn:
+		err := idx.disconnectBlock(dbTx, ntfn.Block)
+		if err != nil {
+			msg := fmt.Sprintf("%s: unable to disconnect block: %v",
+				idx.Name(), err)
+			return indexerError(ErrDisconnectBlock, msg)
+		}
+
+	default:
+		msg := fmt.Sprintf("%s: unknown notification type received: %d",
+			idx.Name(), ntfn.NtfnType)
+		return indexerError(ErrInvalidNotificationType, msg)
+	}
+
+	return nil
+}
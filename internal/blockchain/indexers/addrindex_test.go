@@ -0,0 +1,306 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/decred/dcrd/blockchain/v5/chaingen"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/database/v3"
+	"github.com/decred/dcrd/dcrutil/v4"
+	"github.com/decred/dcrd/txscript/v4/stdaddr"
+	"github.com/decred/dcrd/wire"
+)
+
+// fetchAddrTxHashes returns the hashes of the transactions referenced by the
+// provided address index entries.
+func fetchAddrTxHashes(t *testing.T, db database.DB, entries []TxIndexEntry) []chainhash.Hash {
+	t.Helper()
+
+	hashes := make([]chainhash.Hash, 0, len(entries))
+	err := db.View(func(dbTx database.Tx) error {
+		for i := range entries {
+			txBytes, err := dbTx.FetchBlockRegion(&entries[i].BlockRegion)
+			if err != nil {
+				return err
+			}
+			var msgTx wire.MsgTx
+			if err := msgTx.Deserialize(bytes.NewReader(txBytes)); err != nil {
+				return err
+			}
+			hashes = append(hashes, msgTx.TxHash())
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hashes
+}
+
+// TestAddrIndexAsync ensures the address index behaves as expected when
+// receiving updates asynchronously including paging, reverse ordering,
+// disconnecting blocks, and tracking unconfirmed transactions.
+func TestAddrIndexAsync(t *testing.T) {
+	db := setupDB(t)
+
+	chain, err := newTestChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := chaingen.MakeGenerator(chaincfg.SimNetParams())
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// Add three blocks to the chain and store them in the database since the
+	// address index loads the outputs spent by transactions from it.
+	var blocks []*dcrutil.Block
+	blocks = append(blocks, addBlock(t, chain, &g, "bk1"))
+	blocks = append(blocks, addBlock(t, chain, &g, "bk2"))
+	blocks = append(blocks, addBlock(t, chain, &g, "bk3"))
+	bk3 := blocks[2]
+	storeBlocks := func(blocks ...*dcrutil.Block) {
+		t.Helper()
+		err := db.Update(func(dbTx database.Tx) error {
+			for _, block := range blocks {
+				if err := dbTx.StoreBlock(block); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	storeBlocks(blocks...)
+
+	// Initialize the address index along with the transaction index it
+	// depends on.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	subber := NewIndexSubscriber(ctx)
+	go subber.Run(ctx)
+
+	_, err = NewTxIndex(subber, db, chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, err := NewAddrIndex(subber, db, chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = subber.CatchUp(ctx, db, chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Ensure the index got synced to bk3 on initialization.
+	tipHeight, tipHash, err := idx.Tip()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tipHeight != bk3.Height() {
+		t.Fatalf("expected tip height to be %d, got %d",
+			bk3.Height(), tipHeight)
+	}
+
+	if *tipHash != *bk3.Hash() {
+		t.Fatalf("expected tip hash to be %s, got %s", bk3.Hash(), tipHash)
+	}
+
+	// Ensure all entries for the address the generator pays to are returned
+	// in the order they appear in the chain and that the coinbases of the
+	// blocks after the first one, which only pays the premine, are among
+	// them.
+	p2shAddr := g.P2shOpTrueAddr()
+	entries, skipped, err := idx.EntriesForAddress(p2shAddr, 0, 1000, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skipped != 0 {
+		t.Fatalf("unexpected number of skipped entries: got %d, want 0",
+			skipped)
+	}
+	allHashes := fetchAddrTxHashes(t, db, entries)
+	wantCoinbases := make(map[chainhash.Hash]struct{})
+	for _, block := range blocks[1:] {
+		wantCoinbases[block.MsgBlock().Transactions[0].TxHash()] = struct{}{}
+	}
+	prevHeight := int64(-1)
+	for i, entry := range entries {
+		height, err := chain.BlockHeightByHash(entry.BlockRegion.Hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if height < prevHeight {
+			t.Fatalf("entry %d is out of order: height %d after %d", i,
+				height, prevHeight)
+		}
+		prevHeight = height
+		delete(wantCoinbases, allHashes[i])
+	}
+	if len(wantCoinbases) != 0 {
+		t.Fatalf("missing %d coinbase entries", len(wantCoinbases))
+	}
+
+	// Ensure paging and reverse ordering behave as expected.
+	numEntries := uint32(len(entries))
+	entries, skipped, err = idx.EntriesForAddress(p2shAddr, 1, 1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashes := fetchAddrTxHashes(t, db, entries)
+	if skipped != 1 || len(hashes) != 1 || hashes[0] != allHashes[1] {
+		t.Fatalf("unexpected paged entries: skipped %d, got %v", skipped,
+			hashes)
+	}
+	entries, skipped, err = idx.EntriesForAddress(p2shAddr, 1, 1000, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashes = fetchAddrTxHashes(t, db, entries)
+	if skipped != 1 || uint32(len(hashes)) != numEntries-1 {
+		t.Fatalf("unexpected reverse entries: skipped %d, got %d entries",
+			skipped, len(hashes))
+	}
+	for i := range hashes {
+		if hashes[i] != allHashes[len(allHashes)-2-i] {
+			t.Fatalf("unexpected reverse entry %d: got %v, want %v", i,
+				hashes[i], allHashes[len(allHashes)-2-i])
+		}
+	}
+	entries, skipped, err = idx.EntriesForAddress(p2shAddr, numEntries+5, 10,
+		false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skipped != numEntries || len(entries) != 0 {
+		t.Fatalf("unexpected entries when skipping all: skipped %d, got %d "+
+			"entries", skipped, len(entries))
+	}
+
+	// Connect a block with a transaction that spends a coinbase output paying
+	// to the generator address and pays to a different address.
+	pkHash := bytes.Repeat([]byte{0x01}, 20)
+	p2pkhAddr, err := stdaddr.NewAddressPubKeyHashEcdsaSecp256k1V0(pkHash,
+		chain.ChainParams())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, p2pkhScript := p2pkhAddr.PaymentScript()
+	outs := g.OldestCoinbaseOuts()
+	msgBlk := g.NextBlock("bk4", &outs[0], nil, func(b *wire.MsgBlock) {
+		b.Transactions[1].TxOut[0].PkScript = p2pkhScript
+	})
+	bk4 := dcrutil.NewBlock(msgBlk)
+	if err := chain.AddBlock(bk4); err != nil {
+		t.Fatal(err)
+	}
+	storeBlocks(bk4)
+	notifyAndWait(t, subber, &IndexNtfn{
+		NtfnType: ConnectNtfn,
+		Block:    bk4,
+		Parent:   bk3,
+	})
+
+	// Ensure the spending transaction is indexed for both the address it pays
+	// to and the address of the output it spends.
+	spendHash := msgBlk.Transactions[1].TxHash()
+	entries, _, err = idx.EntriesForAddress(p2pkhAddr, 0, 1000, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashes = fetchAddrTxHashes(t, db, entries)
+	if len(hashes) != 1 || hashes[0] != spendHash {
+		t.Fatalf("unexpected entries for paid address: %v", hashes)
+	}
+	if entries[0].BlockIndex != 1 || *entries[0].BlockRegion.Hash != *bk4.Hash() {
+		t.Fatalf("unexpected entry for paid address: %+v", entries[0])
+	}
+	entries, _, err = idx.EntriesForAddress(p2shAddr, 0, 2, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashes = fetchAddrTxHashes(t, db, entries)
+	if len(hashes) != 2 || hashes[0] != spendHash ||
+		hashes[1] != msgBlk.Transactions[0].TxHash() {
+
+		t.Fatalf("unexpected latest entries for spent address: %v", hashes)
+	}
+
+	// Ensure disconnecting the block removes its entries.
+	if err := chain.RemoveBlock(bk4); err != nil {
+		t.Fatal(err)
+	}
+	g.SetTip("bk3")
+	notifyAndWait(t, subber, &IndexNtfn{
+		NtfnType: DisconnectNtfn,
+		Block:    bk4,
+		Parent:   bk3,
+	})
+	entries, _, err = idx.EntriesForAddress(p2pkhAddr, 0, 1000, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("unexpected entries for paid address after disconnect: %d",
+			len(entries))
+	}
+	entries, _, err = idx.EntriesForAddress(p2shAddr, 0, 1000, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uint32(len(entries)) != numEntries {
+		t.Fatalf("unexpected number of entries after disconnect: got %d, "+
+			"want %d", len(entries), numEntries)
+	}
+
+	// Ensure unconfirmed transactions are tracked for the addresses of both
+	// their outputs and the outputs they spend and are removed as expected.
+	spendTx := dcrutil.NewTx(msgBlk.Transactions[1])
+	p2shVersion, p2shScript := p2shAddr.PaymentScript()
+	scripts := prevScripts{
+		spendTx.MsgTx().TxIn[0].PreviousOutPoint: prevScriptEntry{
+			version: p2shVersion,
+			script:  p2shScript,
+		},
+	}
+	idx.AddUnconfirmedTx(spendTx, scripts)
+	for _, addr := range []stdaddr.Address{p2pkhAddr, p2shAddr} {
+		txns := idx.UnconfirmedTxnsForAddress(addr)
+		if len(txns) != 1 || *txns[0].Hash() != spendHash {
+			t.Fatalf("unexpected unconfirmed txns for %s: %v", addr, txns)
+		}
+	}
+	idx.RemoveUnconfirmedTx(&spendHash)
+	for _, addr := range []stdaddr.Address{p2pkhAddr, p2shAddr} {
+		if txns := idx.UnconfirmedTxnsForAddress(addr); len(txns) != 0 {
+			t.Fatalf("unexpected unconfirmed txns for %s after removal: %v",
+				addr, txns)
+		}
+	}
+
+	// Ensure dropping the transaction index also drops the address index.
+	if err := DropTxIndex(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	exists, err := existsIndex(db, addrIndexKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists {
+		t.Fatal("address index still exists after dropping the tx index")
+	}
+}
@@ -12,21 +12,22 @@ import (
 )
 
 const (
-	// addrIndexName is the human-readable name for the index.
-	addrIndexName = "address index"
+	// legacyAddrIndexName is the human-readable name for the legacy address
+	// index.
+	legacyAddrIndexName = "legacy address index"
 )
 
 var (
-	// addrIndexKey is the key of the address index and the db bucket used
-	// to house it.
-	addrIndexKey = []byte("txbyaddridx")
+	// legacyAddrIndexKey is the key of the legacy address index and the db
+	// bucket used to house it.
+	legacyAddrIndexKey = []byte("txbyaddridx")
 )
 
-// DropAddrIndex drops the address index from the provided database if it
-// exists.
-func DropAddrIndex(ctx context.Context, db database.DB) error {
+// DropLegacyAddrIndex drops the legacy address index from the provided database
+// if it exists.
+func DropLegacyAddrIndex(ctx context.Context, db database.DB) error {
 	// Nothing to do if the index doesn't already exist.
-	exists, err := existsIndex(db, addrIndexKey)
+	exists, err := existsIndex(db, legacyAddrIndexKey)
 	if err != nil {
 		return err
 	}
@@ -34,25 +35,25 @@ func DropAddrIndex(ctx context.Context, db database.DB) error {
 		return nil
 	}
 
-	log.Infof("Dropping all legacy %s entries.  This might take a while...",
-		addrIndexName)
+	log.Infof("Dropping all %s entries.  This might take a while...",
+		legacyAddrIndexName)
 
 	// Since the indexes can be so large, attempting to simply delete the bucket
 	// in a single database transaction would result in massive memory usage and
 	// likely crash many systems due to ulimits.  In order to avoid this, use a
 	// cursor to delete a maximum number of entries out of the bucket at a time.
-	err = incrementalFlatDrop(ctx, db, addrIndexKey, addrIndexName)
+	err = incrementalFlatDrop(ctx, db, legacyAddrIndexKey, legacyAddrIndexName)
 	if err != nil {
 		return err
 	}
 
 	// Remove the index tip, version, bucket, and in-progress drop flag now that
 	// all index entries have been removed.
-	err = dropIndexMetadata(db, addrIndexKey)
+	err = dropIndexMetadata(db, legacyAddrIndexKey)
 	if err != nil {
 		return err
 	}
 
-	log.Infof("Dropped %s", addrIndexName)
+	log.Infof("Dropped %s", legacyAddrIndexName)
 	return nil
 }
@@ -119,6 +119,15 @@ func (s *IndexSubscription) stop() error {
 	return nil
 }
 
+// dependentSubscription returns the subscription that depends on the
+// subscription or nil when there is none.
+func (s *IndexSubscription) dependentSubscription() *IndexSubscription {
+	s.mtx.Lock()
+	dependent := s.dependent
+	s.mtx.Unlock()
+	return dependent
+}
+
 // IndexSubscriber subscribes clients for index updates.
 type IndexSubscriber struct {
 	subscribers atomic.Uint32
@@ -241,6 +250,33 @@ func (s *IndexSubscriber) findLowestIndexTipHeight(queryer ChainQueryer) (int64,
 	return lowestHeight, bestHeight, nil
 }
 
+// LowestTipHeight returns the lowest tip height among the subscribed indexes
+// and their dependents along with whether or not there are any subscribed
+// indexes.
+//
+// This function is safe for concurrent access.
+func (s *IndexSubscriber) LowestTipHeight() (int64, bool, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	var lowestHeight int64
+	var haveIndexes bool
+	for _, sub := range s.subscriptions {
+		for ; sub != nil; sub = sub.dependentSubscription() {
+			tipHeight, _, err := sub.idx.Tip()
+			if err != nil {
+				return 0, false, err
+			}
+			if !haveIndexes || tipHeight < lowestHeight {
+				lowestHeight = tipHeight
+				haveIndexes = true
+			}
+		}
+	}
+
+	return lowestHeight, haveIndexes, nil
+}
+
 // CatchUp syncs all subscribed indexes to the main chain by connecting blocks
 // from after the lowest index tip to the current main chain tip.
 //
@@ -349,10 +385,19 @@ func (s *IndexSubscriber) handleSyncSubscribers(ctx context.Context) {
 		case <-ticker.C:
 			s.mtx.Lock()
 			for _, sub := range s.subscriptions {
-				err := maybeNotifySubscribers(ctx, sub.idx)
-				if err != nil {
-					log.Errorf("unable to notify sync subscribers: %v", err)
-					s.cancel()
+				// Notify the sync subscribers of the index along with those
+				// of its dependents.
+				for dep := sub; dep != nil; {
+					err := maybeNotifySubscribers(ctx, dep.idx)
+					if err != nil {
+						log.Errorf("unable to notify sync subscribers: %v", err)
+						s.cancel()
+					}
+
+					dep.mtx.Lock()
+					next := dep.dependent
+					dep.mtx.Unlock()
+					dep = next
 				}
 			}
 			s.mtx.Unlock()
@@ -144,6 +144,17 @@ func TestIndexSubscriberAsync(t *testing.T) {
 			existsAddrIdxTipHash)
 	}
 
+	// Ensure the lowest tip height among the subscribed indexes is the newly
+	// added block (bk4).
+	lowestHeight, ok, err := subber.LowestTipHeight()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok || lowestHeight != bk4.Height() {
+		t.Fatalf("expected lowest tip height to be %d, got %d (ok %v)",
+			bk4.Height(), lowestHeight, ok)
+	}
+
 	// Ensure stopping a prequisite subscription stops its dependency as well.
 	subber.mtx.Lock()
 	err = txIdx.sub.stop()
@@ -190,4 +201,15 @@ func TestIndexSubscriberAsync(t *testing.T) {
 		t.Fatalf("expected tip hash to be %s, got %s", bk5.Hash(),
 			existsAddrIdxTipHash)
 	}
+
+	// Ensure the lowest tip height only accounts for the indexes that are
+	// still subscribed.
+	lowestHeight, ok, err = subber.LowestTipHeight()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok || lowestHeight != bk5.Height() {
+		t.Fatalf("expected lowest tip height to be %d, got %d (ok %v)",
+			bk5.Height(), lowestHeight, ok)
+	}
 }
@@ -0,0 +1,438 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/database/v3"
+	"github.com/decred/dcrd/dcrutil/v4"
+	"github.com/decred/dcrd/wire"
+)
+
+const (
+	// spendIndexName is the human-readable name for the index.
+	spendIndexName = "spend index"
+
+	// spendIndexVersion is the current version of the spend index.
+	spendIndexVersion = 1
+
+	// spendKeySize is the size of a serialized outpoint key in the spend
+	// index.  It consists of 32 bytes hash + 4 bytes output index + 1 byte
+	// tree.
+	spendKeySize = chainhash.HashSize + 4 + 1
+
+	// spendEntrySize is the size of a spend index entry.  It consists of 32
+	// bytes block hash + 32 bytes spending transaction hash + 4 bytes input
+	// index.
+	spendEntrySize = chainhash.HashSize + chainhash.HashSize + 4
+)
+
+var (
+	// spendIndexKey is the key of the spend index and the db bucket used to
+	// house it.
+	spendIndexKey = []byte("spendidx")
+)
+
+// -----------------------------------------------------------------------------
+// The spend index consists of an entry for every output spent by a
+// transaction in the main chain which maps the outpoint to the transaction
+// that spends it.  The spent outputs of a block are precisely the ones
+// recorded in the spend journal of the chain, so they are loaded directly from
+// the inputs of the transactions in the block in the same order the spend
+// journal is produced.
+//
+// The serialized format for the keys and values in the spend index bucket is:
+//
+//   <hash><output index><tree> = <block hash><tx hash><input index>
+//
+//   Field           Type              Size
+//   hash            chainhash.Hash    32 bytes
+//   output index    uint32            4 bytes
+//   tree            int8              1 byte
+//   block hash      chainhash.Hash    32 bytes
+//   tx hash         chainhash.Hash    32 bytes
+//   input index     uint32            4 bytes
+//   -----
+//   Total: 105 bytes
+// -----------------------------------------------------------------------------
+
+// SpendIndexEntry houses information about the transaction that spends an
+// output as recorded in the spend index.
+type SpendIndexEntry struct {
+	// BlockHash is the hash of the block that contains the spending
+	// transaction.
+	BlockHash chainhash.Hash
+
+	// TxHash is the hash of the spending transaction.
+	TxHash chainhash.Hash
+
+	// InputIndex is the index of the input of the spending transaction that
+	// spends the output.
+	InputIndex uint32
+}
+
+// serializeSpendKey returns the spend index key for the provided outpoint.
+func serializeSpendKey(outpoint *wire.OutPoint) [spendKeySize]byte {
+	var key [spendKeySize]byte
+	copy(key[:], outpoint.Hash[:])
+	byteOrder.PutUint32(key[chainhash.HashSize:], outpoint.Index)
+	key[spendKeySize-1] = byte(outpoint.Tree)
+	return key
+}
+
+// serializeSpendEntry returns the serialized spend index entry for the
+// provided block hash, spending transaction hash, and input index.
+func serializeSpendEntry(blockHash, txHash *chainhash.Hash, inputIndex uint32) []byte {
+	serialized := make([]byte, spendEntrySize)
+	copy(serialized, blockHash[:])
+	copy(serialized[chainhash.HashSize:], txHash[:])
+	byteOrder.PutUint32(serialized[2*chainhash.HashSize:], inputIndex)
+	return serialized
+}
+
+// deserializeSpendEntry decodes the provided serialized spend index entry.
+func deserializeSpendEntry(serialized []byte) (*SpendIndexEntry, error) {
+	if len(serialized) != spendEntrySize {
+		return nil, fmt.Errorf("corrupt spend index entry: unexpected "+
+			"length %d", len(serialized))
+	}
+
+	var entry SpendIndexEntry
+	copy(entry.BlockHash[:], serialized[:chainhash.HashSize])
+	copy(entry.TxHash[:], serialized[chainhash.HashSize:])
+	entry.InputIndex = byteOrder.Uint32(serialized[2*chainhash.HashSize:])
+	return &entry, nil
+}
+
+// forEachSpend invokes the provided function with every output spent by the
+// transactions in the provided slice along with the spending transaction and
+// the index of the input that spends it.  Inputs that do not spend an output,
+// such as those of coinbases, stakebases, treasurybases, and treasury spends,
+// are skipped.
+func forEachSpend(txns []*dcrutil.Tx, f func(outpoint *wire.OutPoint, tx *dcrutil.Tx, inputIndex uint32) error) error {
+	for _, tx := range txns {
+		for txInIdx, txIn := range tx.MsgTx().TxIn {
+			prevOut := &txIn.PreviousOutPoint
+			if isNullOutpoint(prevOut) {
+				continue
+			}
+			if err := f(prevOut, tx, uint32(txInIdx)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// dbAddSpendIndexEntries uses an existing database transaction to add spend
+// index entries for every output spent by the provided transactions which are
+// contained in the block with the provided hash.
+func dbAddSpendIndexEntries(dbTx database.Tx, blockHash *chainhash.Hash, txns []*dcrutil.Tx) error {
+	bucket := dbTx.Metadata().Bucket(spendIndexKey)
+	return forEachSpend(txns, func(outpoint *wire.OutPoint, tx *dcrutil.Tx, inputIndex uint32) error {
+		key := serializeSpendKey(outpoint)
+		entry := serializeSpendEntry(blockHash, tx.Hash(), inputIndex)
+		return bucket.Put(key[:], entry)
+	})
+}
+
+// dbRemoveSpendIndexEntries uses an existing database transaction to remove
+// the spend index entries for every output spent by the provided transactions.
+func dbRemoveSpendIndexEntries(dbTx database.Tx, txns []*dcrutil.Tx) error {
+	bucket := dbTx.Metadata().Bucket(spendIndexKey)
+	return forEachSpend(txns, func(outpoint *wire.OutPoint, _ *dcrutil.Tx, _ uint32) error {
+		key := serializeSpendKey(outpoint)
+		return bucket.Delete(key[:])
+	})
+}
+
+// approvesParent returns whether or not the provided block approves the
+// regular transaction tree of its parent.
+func approvesParent(block *dcrutil.Block) bool {
+	return dcrutil.IsFlagSet16(block.MsgBlock().Header.VoteBits,
+		dcrutil.BlockValid)
+}
+
+// SpendIndex implements a spent output index.  It maps every output spent by
+// a transaction in the main chain to the transaction that spends it along with
+// the block that contains it.
+type SpendIndex struct {
+	// The following fields are set when the instance is created and can't
+	// be changed afterwards, so there is no need to protect them with a
+	// separate mutex.
+	db    database.DB
+	chain ChainQueryer
+	sub   *IndexSubscription
+
+	subscribers map[chan bool]struct{}
+	mtx         sync.Mutex
+	cancel      context.CancelFunc
+}
+
+// Ensure the SpendIndex type implements the Indexer interface.
+var _ Indexer = (*SpendIndex)(nil)
+
+// NewSpendIndex returns a new instance of an indexer that is used to create a
+// mapping of all outputs spent in the main chain to the transactions that
+// spend them.
+func NewSpendIndex(subscriber *IndexSubscriber, db database.DB, chain ChainQueryer) (*SpendIndex, error) {
+	idx := &SpendIndex{
+		db:          db,
+		chain:       chain,
+		subscribers: make(map[chan bool]struct{}),
+		cancel:      subscriber.cancel,
+	}
+
+	// The spend index is an optional index.  It has no prerequisite and is
+	// updated asynchronously.
+	sub, err := subscriber.Subscribe(idx, noPrereqs)
+	if err != nil {
+		return nil, err
+	}
+
+	idx.sub = sub
+
+	err = idx.Init(subscriber.ctx, chain.ChainParams())
+	if err != nil {
+		return nil, err
+	}
+
+	return idx, nil
+}
+
+// Init initializes the spend index.
+//
+// This is part of the Indexer interface.
+func (idx *SpendIndex) Init(ctx context.Context, chainParams *chaincfg.Params) error {
+	if interruptRequested(ctx) {
+		return indexerError(ErrInterruptRequested, interruptMsg)
+	}
+
+	// Finish any drops that were previously interrupted.
+	if err := finishDrop(ctx, idx); err != nil {
+		return err
+	}
+
+	// Create the initial state for the index as needed.
+	if err := createIndex(idx, &chainParams.GenesisHash); err != nil {
+		return err
+	}
+
+	// Upgrade the index as needed.
+	if err := upgradeIndex(ctx, idx, &chainParams.GenesisHash); err != nil {
+		return err
+	}
+
+	// Recover the spend index and its dependents to the main chain if needed.
+	return recoverIndex(ctx, idx)
+}
+
+// Key returns the database key to use for the index as a byte slice.
+//
+// This is part of the Indexer interface.
+func (idx *SpendIndex) Key() []byte {
+	return spendIndexKey
+}
+
+// Name returns the human-readable name of the index.
+//
+// This is part of the Indexer interface.
+func (idx *SpendIndex) Name() string {
+	return spendIndexName
+}
+
+// Version returns the current version of the index.
+//
+// This is part of the Indexer interface.
+func (idx *SpendIndex) Version() uint32 {
+	return spendIndexVersion
+}
+
+// DB returns the database of the index.
+//
+// This is part of the Indexer interface.
+func (idx *SpendIndex) DB() database.DB {
+	return idx.db
+}
+
+// Queryer returns the chain queryer.
+//
+// This is part of the Indexer interface.
+func (idx *SpendIndex) Queryer() ChainQueryer {
+	return idx.chain
+}
+
+// Tip returns the current tip of the index.
+//
+// This is part of the Indexer interface.
+func (idx *SpendIndex) Tip() (int64, *chainhash.Hash, error) {
+	return tip(idx.db, idx.Key())
+}
+
+// IndexSubscription returns the subscription for index updates.
+//
+// This is part of the Indexer interface.
+func (idx *SpendIndex) IndexSubscription() *IndexSubscription {
+	return idx.sub
+}
+
+// NotifySyncSubscribers signals subscribers of an index sync update.
+//
+// This is part of the Indexer interface.
+func (idx *SpendIndex) NotifySyncSubscribers() {
+	idx.mtx.Lock()
+	notifySyncSubscribers(idx.subscribers)
+	idx.mtx.Unlock()
+}
+
+// WaitForSync subscribes clients for the next index sync update.
+//
+// This is part of the Indexer interface.
+func (idx *SpendIndex) WaitForSync() chan bool {
+	c := make(chan bool)
+
+	idx.mtx.Lock()
+	idx.subscribers[c] = struct{}{}
+	idx.mtx.Unlock()
+
+	return c
+}
+
+// Create is invoked when the index is created for the first time.  It creates
+// the bucket for the spend index.
+//
+// This is part of the Indexer interface.
+func (idx *SpendIndex) Create(dbTx database.Tx) error {
+	_, err := dbTx.Metadata().CreateBucket(spendIndexKey)
+	return err
+}
+
+// connectBlock adds an entry for every output spent by the transactions in the
+// passed block and removes the entries for the outputs spent by the regular
+// transaction tree of the parent block when the passed block disapproves it.
+func (idx *SpendIndex) connectBlock(dbTx database.Tx, block, parent *dcrutil.Block) error {
+	// The outputs spent by the regular transaction tree of the parent block
+	// are no longer spent when the block disapproves it.  Notice that this is
+	// done before adding the entries for the block since the disapproved
+	// transactions are typically mined again in it.
+	if !approvesParent(block) {
+		err := dbRemoveSpendIndexEntries(dbTx, parent.Transactions())
+		if err != nil {
+			return err
+		}
+	}
+
+	// Add the entries for the outputs spent by both the stake and regular
+	// transaction trees in the same order the spend journal is produced.
+	err := dbAddSpendIndexEntries(dbTx, block.Hash(), block.STransactions())
+	if err != nil {
+		return err
+	}
+	err = dbAddSpendIndexEntries(dbTx, block.Hash(), block.Transactions())
+	if err != nil {
+		return err
+	}
+
+	// Update the current index tip.
+	return dbPutIndexerTip(dbTx, idx.Key(), block.Hash(), int32(block.Height()))
+}
+
+// disconnectBlock removes the entries for every output spent by the
+// transactions in the passed block and restores the entries for the outputs
+// spent by the regular transaction tree of the parent block when the passed
+// block disapproves it.
+func (idx *SpendIndex) disconnectBlock(dbTx database.Tx, block, parent *dcrutil.Block) error {
+	// Remove the entries for the outputs spent by the block in the reverse
+	// order they were added.
+	if err := dbRemoveSpendIndexEntries(dbTx, block.Transactions()); err != nil {
+		return err
+	}
+	if err := dbRemoveSpendIndexEntries(dbTx, block.STransactions()); err != nil {
+		return err
+	}
+
+	// Restore the entries for the outputs spent by the regular transaction
+	// tree of the parent block when the block disapproves it since they are
+	// spent again once the block is disconnected.
+	if !approvesParent(block) {
+		err := dbAddSpendIndexEntries(dbTx, parent.Hash(),
+			parent.Transactions())
+		if err != nil {
+			return err
+		}
+	}
+
+	// Update the current index tip.
+	return dbPutIndexerTip(dbTx, idx.Key(), &block.MsgBlock().Header.PrevBlock,
+		int32(block.Height()-1))
+}
+
+// Entry returns details about the transaction in the main chain that spends
+// the provided outpoint.  When the outpoint is not spent by a transaction in
+// the main chain, nil is returned for both the entry and the error.
+//
+// This function is safe for concurrent access.
+func (idx *SpendIndex) Entry(outpoint *wire.OutPoint) (*SpendIndexEntry, error) {
+	var entry *SpendIndexEntry
+	key := serializeSpendKey(outpoint)
+	err := idx.db.View(func(dbTx database.Tx) error {
+		serialized := dbTx.Metadata().Bucket(spendIndexKey).Get(key[:])
+		if serialized == nil {
+			return nil
+		}
+
+		var err error
+		entry, err = deserializeSpendEntry(serialized)
+		return err
+	})
+	return entry, err
+}
+
+// DropSpendIndex drops the spend index from the provided database if it
+// exists.
+func DropSpendIndex(ctx context.Context, db database.DB) error {
+	return dropFlatIndex(ctx, db, spendIndexKey, spendIndexName)
+}
+
+// DropIndex drops the spend index from the provided database if it exists.
+func (*SpendIndex) DropIndex(ctx context.Context, db database.DB) error {
+	return DropSpendIndex(ctx, db)
+}
+
+// ProcessNotification indexes the provided notification based on its
+// notification type.
+//
+// This is part of the Indexer interface.
+func (idx *SpendIndex) ProcessNotification(dbTx database.Tx, ntfn *IndexNtfn) error {
+	switch ntfn.NtfnType {
+	case ConnectNtfn:
+		err := idx.connectBlock(dbTx, ntfn.Block, ntfn.Parent)
+		if err != nil {
+			msg := fmt.Sprintf("%s: unable to connect block: %v",
+				idx.Name(), err)
+			return indexerError(ErrConnectBlock, msg)
+		}
+
+	case DisconnectNtfn:
+		err := idx.disconnectBlock(dbTx, ntfn.Block, ntfn.Parent)
+		if err != nil {
+			msg := fmt.Sprintf("%s: unable to disconnect block: %v",
+				idx.Name(), err)
+			return indexerError(ErrDisconnectBlock, msg)
+		}
+
+	default:
+		msg := fmt.Sprintf("%s: unknown notification type received: %d",
+			idx.Name(), ntfn.NtfnType)
+		return indexerError(ErrInvalidNotificationType, msg)
+	}
+
+	return nil
+}
@@ -0,0 +1,184 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/decred/dcrd/blockchain/v5/chaingen"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/dcrutil/v4"
+	"github.com/decred/dcrd/wire"
+)
+
+// TestSpendIndexAsync ensures the spend index behaves as expected when
+// receiving updates asynchronously including blocks that disapprove the
+// regular transaction tree of their parent and disconnecting blocks.
+func TestSpendIndexAsync(t *testing.T) {
+	db := setupDB(t)
+
+	chain, err := newTestChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := chaingen.MakeGenerator(chaincfg.SimNetParams())
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// Add three blocks to the chain.
+	addBlock(t, chain, &g, "bk1")
+	addBlock(t, chain, &g, "bk2")
+	bk3 := addBlock(t, chain, &g, "bk3")
+
+	// Initialize the spend index.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	subber := NewIndexSubscriber(ctx)
+	go subber.Run(ctx)
+
+	idx, err := NewSpendIndex(subber, db, chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = subber.CatchUp(ctx, db, chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Ensure the index got synced to bk3 on initialization.
+	tipHeight, tipHash, err := idx.Tip()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tipHeight != bk3.Height() {
+		t.Fatalf("expected tip height to be %d, got %d",
+			bk3.Height(), tipHeight)
+	}
+
+	if *tipHash != *bk3.Hash() {
+		t.Fatalf("expected tip hash to be %s, got %s", bk3.Hash(), tipHash)
+	}
+
+	// checkEntry ensures the entry for the provided outpoint matches the
+	// provided block hash and spending transaction hash.  Nil block and
+	// transaction hashes indicate the outpoint is expected to be unspent.
+	checkEntry := func(outpoint *wire.OutPoint, blockHash, txHash *chainhash.Hash) {
+		t.Helper()
+
+		entry, err := idx.Entry(outpoint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if blockHash == nil {
+			if entry != nil {
+				t.Fatalf("unexpected entry for %v: %+v", outpoint, entry)
+			}
+			return
+		}
+		if entry == nil {
+			t.Fatalf("missing entry for %v", outpoint)
+		}
+		if entry.BlockHash != *blockHash || entry.TxHash != *txHash ||
+			entry.InputIndex != 0 {
+
+			t.Fatalf("unexpected entry for %v: got %+v, want block %v, "+
+				"tx %v, input 0", outpoint, entry, blockHash, txHash)
+		}
+	}
+
+	// Ensure an output that is not spent does not have an entry.
+	outs := g.OldestCoinbaseOuts()
+	spentOutpoint := outs[0].PrevOut()
+	checkEntry(&spentOutpoint, nil, nil)
+
+	// Connect a block with a transaction that spends the output and ensure
+	// the index maps the output to it.
+	bk4 := dcrutil.NewBlock(g.NextBlock("bk4", &outs[0], nil))
+	if err := chain.AddBlock(bk4); err != nil {
+		t.Fatal(err)
+	}
+	spendHash := bk4.MsgBlock().Transactions[1].TxHash()
+	notifyAndWait(t, subber, &IndexNtfn{
+		NtfnType: ConnectNtfn,
+		Block:    bk4,
+		Parent:   bk3,
+	})
+	checkEntry(&spentOutpoint, bk4.Hash(), &spendHash)
+
+	// Ensure the coinbase of the block, which does not spend any outputs, is
+	// not indexed.
+	coinbase := bk4.MsgBlock().Transactions[0]
+	nullOutpoint := coinbase.TxIn[0].PreviousOutPoint
+	checkEntry(&nullOutpoint, nil, nil)
+
+	// Connect a block that disapproves the regular transaction tree of bk4
+	// and ensure the output is no longer spent.
+	msgBlk := g.NextBlock("bk5", nil, nil, func(b *wire.MsgBlock) {
+		b.Header.VoteBits &^= dcrutil.BlockValid
+	})
+	bk5 := dcrutil.NewBlock(msgBlk)
+	if err := chain.AddBlock(bk5); err != nil {
+		t.Fatal(err)
+	}
+	notifyAndWait(t, subber, &IndexNtfn{
+		NtfnType: ConnectNtfn,
+		Block:    bk5,
+		Parent:   bk4,
+	})
+	checkEntry(&spentOutpoint, nil, nil)
+
+	// Disconnect the disapproving block and ensure the output is spent by
+	// bk4 again.
+	if err := chain.RemoveBlock(bk5); err != nil {
+		t.Fatal(err)
+	}
+	g.SetTip("bk4")
+	notifyAndWait(t, subber, &IndexNtfn{
+		NtfnType: DisconnectNtfn,
+		Block:    bk5,
+		Parent:   bk4,
+	})
+	checkEntry(&spentOutpoint, bk4.Hash(), &spendHash)
+
+	// Disconnect bk4 and ensure the output is no longer spent.
+	if err := chain.RemoveBlock(bk4); err != nil {
+		t.Fatal(err)
+	}
+	g.SetTip("bk3")
+	notifyAndWait(t, subber, &IndexNtfn{
+		NtfnType: DisconnectNtfn,
+		Block:    bk4,
+		Parent:   bk3,
+	})
+	checkEntry(&spentOutpoint, nil, nil)
+
+	tipHeight, tipHash, err = idx.Tip()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tipHeight != bk3.Height() || *tipHash != *bk3.Hash() {
+		t.Fatalf("unexpected tip after disconnect: %d (%s)", tipHeight,
+			tipHash)
+	}
+
+	// Ensure dropping the index removes it.
+	if err := DropSpendIndex(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	exists, err := existsIndex(db, spendIndexKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists {
+		t.Fatal("spend index still exists after being dropped")
+	}
+}
@@ -640,6 +640,18 @@ func DropTxIndex(ctx context.Context, db database.DB) error {
 		return nil
 	}
 
+	// Drop the address index first when it exists since it relies on the
+	// transaction index.
+	exists, err = existsIndex(db, addrIndexKey)
+	if err != nil {
+		return err
+	}
+	if exists {
+		if err := DropAddrIndex(ctx, db); err != nil {
+			return err
+		}
+	}
+
 	// Mark that the index is in the process of being dropped so that it
 	// can be resumed on the next start if interrupted before the process is
 	// complete.
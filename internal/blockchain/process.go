@@ -335,6 +335,7 @@ func (b *BlockChain) maybeAcceptBlockData(node *blockNode, block *dcrutil.Block,
 		return nil, err
 	}
 	b.index.SetStatusFlags(node, statusDataStored)
+	b.storedBlockBytes += uint64(node.blockSize)
 
 	// Update the block index state to account for the full data for the block
 	// now being available.  This might result in the block, and any others that
@@ -5,8 +5,27 @@
 package blockchain
 
 import (
+	"errors"
 	"math"
 	"time"
+
+	"github.com/decred/dcrd/database/v3"
+)
+
+const (
+	// minPruneKeepDepth is the minimum number of blocks prior to the current
+	// best chain tip, in addition to those required by consensus, that will
+	// always have their data kept when pruning block data.  This is
+	// approximately one day's worth of blocks on the main network and is
+	// intended to ensure reorganizations and any subsystems that lag slightly
+	// behind the tip are able to access the data they need.
+	minPruneKeepDepth = 288
+
+	// pruneHysteresisDivisor is the divisor applied to the prune target to
+	// determine the additional amount of data to prune beyond the target each
+	// time pruning takes place.  This prevents pruning a tiny amount of data
+	// after every single connected block once the target has been reached.
+	pruneHysteresisDivisor = 10
 )
 
 // poissonConfidenceSecs returns the number of seconds it will take to produce
@@ -88,3 +107,207 @@ func (c *chainPruner) pruneChainIfNeeded() {
 	c.lastPruneTime = now
 	c.chain.pruneStakeNodes()
 }
+
+// pruneKeepDepth returns the number of blocks prior to the current best chain
+// tip that must always have their data kept when pruning block data.  This
+// accounts for the consensus rules that require access to the data of
+// ancestors, such as counting treasury spend votes over the voting window and
+// determining newly-matured tickets.
+func (b *BlockChain) pruneKeepDepth() int64 {
+	params := b.chainParams
+	keepDepth := int64(params.TreasuryVoteInterval *
+		params.TreasuryVoteIntervalMultiplier)
+	if ticketMaturity := int64(params.TicketMaturity); ticketMaturity > keepDepth {
+		keepDepth = ticketMaturity
+	}
+	return keepDepth + minPruneKeepDepth
+}
+
+// initPruneState determines the total size of the block data stored in the
+// database and the height of the most recent block in the main chain with
+// pruned data from the block index.
+//
+// This function MUST be called with the chain lock held (for writes) or during
+// chain initialization.
+func (b *BlockChain) initPruneState() {
+	b.storedBlockBytes = 0
+	b.prunedHeight = 0
+	countNode := func(node *blockNode) {
+		if !node.status.HaveData() {
+			return
+		}
+		if node.status.IsPruned() {
+			if node.height > b.prunedHeight && b.bestChain.Contains(node) {
+				b.prunedHeight = node.height
+			}
+			return
+		}
+		b.storedBlockBytes += uint64(node.blockSize)
+	}
+
+	b.index.RLock()
+	for _, node := range b.index.index {
+		countNode(node)
+	}
+	for _, node := range b.index.collisions {
+		countNode(node)
+	}
+	b.index.RUnlock()
+
+	if b.pruneTarget != 0 {
+		log.Infof("Block pruning enabled with a target of %d MiB (stored: %d "+
+			"MiB, pruned height: %d)", b.pruneTarget/(1024*1024),
+			b.storedBlockBytes/(1024*1024), b.prunedHeight)
+	}
+}
+
+// maybePruneBlockData removes the data for the oldest blocks from the database
+// when pruning is enabled and the total size of the stored block data exceeds
+// the prune target.
+//
+// Blocks in the main chain are pruned in order of increasing height while
+// keeping the data for the most recent blocks as determined by pruneKeepDepth
+// as well as any blocks that have not yet been flushed to the UTXO backend or
+// processed by the subscribed indexes since they are required to recover the
+// UTXO state and to catch up the indexes, respectively.  Blocks in side chains
+// are pruned once the main chain has been pruned beyond their heights.
+//
+// Note that the headers for all blocks are always kept and the block index
+// entries are marked accordingly so the remaining parts of the chain continue
+// to function as normal.
+//
+// This function MUST be called with the chain lock held (for writes).
+func (b *BlockChain) maybePruneBlockData() error {
+	// Nothing to do when pruning is disabled or the stored data does not
+	// exceed the target.
+	if b.pruneTarget == 0 || b.storedBlockBytes <= b.pruneTarget {
+		return nil
+	}
+
+	// Determine the maximum height that may be pruned.  The block after the
+	// most recently flushed block in the UTXO backend must be kept since it is
+	// needed to catch up the UTXO state on startup.
+	tip := b.bestChain.Tip()
+	maxPruneHeight := tip.height - b.pruneKeepDepth()
+	state, err := b.utxoCache.FetchBackendState()
+	if err != nil {
+		return err
+	}
+	if maxHeight := int64(state.lastFlushHeight) - 1; maxHeight < maxPruneHeight {
+		maxPruneHeight = maxHeight
+	}
+
+	// Similarly, the blocks after the lowest tip of the subscribed indexes,
+	// along with the tip itself in case it is disconnected, must be kept since
+	// the indexes require them to catch up, such as when they are lagging
+	// behind the chain or after a restart.
+	if b.indexSubscriber != nil {
+		tipHeight, ok, err := b.indexSubscriber.LowestTipHeight()
+		if err != nil {
+			return err
+		}
+		if maxHeight := tipHeight - 1; ok && maxHeight < maxPruneHeight {
+			maxPruneHeight = maxHeight
+		}
+	}
+
+	// Determine the blocks in the main chain to prune.  Note that the genesis
+	// block is never pruned.
+	target := b.pruneTarget - b.pruneTarget/pruneHysteresisDivisor
+	storedBytes := b.storedBlockBytes
+	prunedHeight := b.prunedHeight
+	var pruneNodes []*blockNode
+	b.index.RLock()
+	for prunedHeight < maxPruneHeight && storedBytes > target {
+		prunedHeight++
+		node := b.bestChain.NodeByHeight(prunedHeight)
+		if !node.status.HaveData() || node.status.IsPruned() {
+			continue
+		}
+		pruneNodes = append(pruneNodes, node)
+		storedBytes -= uint64(node.blockSize)
+	}
+	if prunedHeight == b.prunedHeight {
+		b.index.RUnlock()
+		return nil
+	}
+
+	// Determine the blocks in side chains at or below the new pruned height.
+	seen := make(map[*blockNode]struct{})
+	b.index.forEachChainTip(func(tip *blockNode) error {
+		for n := tip; n != nil && !b.bestChain.Contains(n); n = n.parent {
+			if _, ok := seen[n]; ok {
+				break
+			}
+			seen[n] = struct{}{}
+			if n.height > prunedHeight || !n.status.HaveData() ||
+				n.status.IsPruned() {
+
+				continue
+			}
+			pruneNodes = append(pruneNodes, n)
+			storedBytes -= uint64(n.blockSize)
+		}
+		return nil
+	})
+	b.index.RUnlock()
+
+	// Mark the blocks as pruned and atomically update their block index
+	// entries while removing their data from the database.
+	b.index.Lock()
+	for _, node := range pruneNodes {
+		b.index.setStatusFlags(node, statusDataPruned)
+	}
+	err = b.db.Update(func(dbTx database.Tx) error {
+		for _, node := range pruneNodes {
+			if err := dbPutBlockNode(dbTx, node); err != nil {
+				return err
+			}
+			err := dbTx.DeleteBlock(&node.hash)
+			if err != nil && !errors.Is(err, database.ErrBlockNotFound) {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		for _, node := range pruneNodes {
+			b.index.unsetStatusFlags(node, statusDataPruned)
+		}
+		b.index.Unlock()
+		return err
+	}
+	b.index.Unlock()
+
+	log.Debugf("Pruned data for %d blocks through height %d", len(pruneNodes),
+		prunedHeight)
+	b.prunedHeight = prunedHeight
+	b.storedBlockBytes = storedBytes
+	return nil
+}
+
+// IsPruned returns whether or not the data for any blocks has been pruned or
+// pruning is enabled.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) IsPruned() bool {
+	b.chainLock.RLock()
+	isPruned := b.pruneTarget != 0 || b.prunedHeight != 0
+	b.chainLock.RUnlock()
+	return isPruned
+}
+
+// PruneHeight returns the height of the oldest block in the main chain, other
+// than the genesis block, that still has its data available.  It returns zero
+// when no block data has been pruned.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) PruneHeight() int64 {
+	b.chainLock.RLock()
+	var pruneHeight int64
+	if b.prunedHeight != 0 {
+		pruneHeight = b.prunedHeight + 1
+	}
+	b.chainLock.RUnlock()
+	return pruneHeight
+}
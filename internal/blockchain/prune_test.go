@@ -0,0 +1,156 @@
+// Copyright (c) 2023 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/internal/blockchain/indexers"
+)
+
+// TestPruneBlockData ensures pruning block data removes the data for the
+// oldest blocks in the main chain while keeping the most recent blocks and the
+// block headers and reports the expected prune state.
+func TestPruneBlockData(t *testing.T) {
+	t.Parallel()
+
+	// Create a test harness initialized with the genesis block as the tip.
+	params := chaincfg.RegNetParams()
+	g := newChaingenHarness(t, params)
+
+	// ---------------------------------------------------------------------
+	// Generate and accept enough blocks to exceed the depth of blocks that
+	// must always have their data kept.
+	// ---------------------------------------------------------------------
+
+	g.AdvanceToStakeValidationHeight()
+	const numBlocks = 200
+	for i := 0; i < numBlocks; i++ {
+		outs := g.OldestCoinbaseOuts()
+		blockName := fmt.Sprintf("bp%d", i)
+		g.NextBlock(blockName, nil, outs[1:])
+		g.SaveTipCoinbaseOuts()
+		g.AcceptTipBlock()
+	}
+	tipHeight := params.StakeValidationHeight + numBlocks
+	g.AssertTipHeight(uint32(tipHeight))
+
+	// Ensure nothing is pruned when pruning is disabled.
+	chain := g.chain
+	if chain.IsPruned() || chain.PruneHeight() != 0 {
+		t.Fatalf("unexpected prune state: is pruned %v, prune height %d",
+			chain.IsPruned(), chain.PruneHeight())
+	}
+
+	// Flush the utxo cache so the blocks are no longer needed to recover the
+	// UTXO state and enable pruning with a target that forces pruning as much
+	// data as possible.
+	chain.chainLock.Lock()
+	tip := chain.bestChain.Tip()
+	err := chain.utxoCache.MaybeFlush(&tip.hash, uint32(tip.height), true,
+		false)
+	if err != nil {
+		chain.chainLock.Unlock()
+		t.Fatalf("unexpected error flushing utxo cache: %v", err)
+	}
+	origStoredBytes := chain.storedBlockBytes
+	chain.pruneTarget = 1
+	err = chain.maybePruneBlockData()
+	chain.chainLock.Unlock()
+	if err != nil {
+		t.Fatalf("unexpected error pruning block data: %v", err)
+	}
+
+	// Ensure the expected blocks were pruned.
+	wantPrunedHeight := tipHeight - chain.pruneKeepDepth()
+	if !chain.IsPruned() {
+		t.Fatal("chain does not report being pruned")
+	}
+	if got := chain.PruneHeight(); got != wantPrunedHeight+1 {
+		t.Fatalf("unexpected prune height: got %d, want %d", got,
+			wantPrunedHeight+1)
+	}
+	if chain.storedBlockBytes >= origStoredBytes {
+		t.Fatalf("stored block bytes did not decrease: got %d, orig %d",
+			chain.storedBlockBytes, origStoredBytes)
+	}
+	for _, height := range []int64{1, wantPrunedHeight} {
+		_, err := chain.BlockByHeight(height)
+		if !errors.Is(err, ErrBlockPruned) {
+			t.Fatalf("block at height %d: unexpected error: got %v, want %v",
+				height, err, ErrBlockPruned)
+		}
+		hash, err := chain.BlockHashByHeight(height)
+		if err != nil {
+			t.Fatalf("unexpected error fetching block hash: %v", err)
+		}
+		if !chain.HaveBlock(hash) {
+			t.Fatalf("block at height %d is no longer known", height)
+		}
+		if _, err := chain.HeaderByHash(hash); err != nil {
+			t.Fatalf("unexpected error fetching header: %v", err)
+		}
+	}
+	for _, height := range []int64{0, wantPrunedHeight + 1, tipHeight} {
+		if _, err := chain.BlockByHeight(height); err != nil {
+			t.Fatalf("block at height %d: unexpected error: %v", height,
+				err)
+		}
+	}
+
+	// Ensure the prune state is properly determined from the block index.
+	chain.chainLock.Lock()
+	storedBytes := chain.storedBlockBytes
+	chain.initPruneState()
+	gotPrunedHeight := chain.prunedHeight
+	gotStoredBytes := chain.storedBlockBytes
+	chain.chainLock.Unlock()
+	if gotPrunedHeight != wantPrunedHeight {
+		t.Fatalf("unexpected pruned height: got %d, want %d",
+			gotPrunedHeight, wantPrunedHeight)
+	}
+	if gotStoredBytes != storedBytes {
+		t.Fatalf("unexpected stored block bytes: got %d, want %d",
+			gotStoredBytes, storedBytes)
+	}
+
+	// Ensure connecting a new block automatically prunes the data for the
+	// block that is no longer required.
+	outs := g.OldestCoinbaseOuts()
+	g.NextBlock("bpnext", nil, outs[1:])
+	g.SaveTipCoinbaseOuts()
+	g.AcceptTipBlock()
+	if got := chain.PruneHeight(); got != wantPrunedHeight+2 {
+		t.Fatalf("unexpected prune height: got %d, want %d", got,
+			wantPrunedHeight+2)
+	}
+
+	// Ensure blocks that have not been processed by the subscribed indexes
+	// are not pruned.  The newly created index starts at the genesis block
+	// and is not caught up, so no further blocks are pruned.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	subber := indexers.NewIndexSubscriber(ctx)
+	_, err = indexers.NewExistsAddrIndex(subber, chain.db,
+		&ChainQueryerAdapter{BlockChain: chain})
+	if err != nil {
+		t.Fatalf("unexpected error creating index: %v", err)
+	}
+	chain.chainLock.Lock()
+	chain.indexSubscriber = subber
+	chain.chainLock.Unlock()
+	outs = g.OldestCoinbaseOuts()
+	g.NextBlock("bpnext2", nil, outs[1:])
+	g.SaveTipCoinbaseOuts()
+	g.AcceptTipBlock()
+	if got := chain.PruneHeight(); got != wantPrunedHeight+2 {
+		t.Fatalf("unexpected prune height with lagging index: got %d, "+
+			"want %d", got, wantPrunedHeight+2)
+	}
+}
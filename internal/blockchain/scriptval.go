@@ -1,5 +1,5 @@
 // Copyright (c) 2013-2016 The btcsuite developers
-// Copyright (c) 2015-2022 The Decred developers
+// Copyright (c) 2015-2024 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
@@ -12,6 +12,7 @@ import (
 	"runtime"
 
 	"github.com/decred/dcrd/blockchain/stake/v5"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/schnorr"
 	"github.com/decred/dcrd/dcrutil/v4"
 	"github.com/decred/dcrd/txscript/v4"
 	"github.com/decred/dcrd/wire"
@@ -31,15 +32,32 @@ type txValidateItem struct {
 	tx        *dcrutil.Tx
 }
 
+// minParallelBatchVerifySize is the minimum number of deferred secp256k1
+// Schnorr signatures per batch when splitting them into multiple batches that
+// are verified concurrently.  Batch verification becomes more efficient per
+// signature as the batch grows, so the signatures are only split across
+// multiple processor cores when each batch is still large enough to benefit
+// from it.
+const minParallelBatchVerifySize = 256
+
+// schnorrBatch houses a batch of deferred secp256k1 Schnorr signature checks
+// along with the items the signatures were deferred from.
+type schnorrBatch struct {
+	batch *schnorr.BatchVerifier
+	items []*txValidateItem
+}
+
 // txValidator provides a type which asynchronously validates transaction
 // inputs.  It provides several channels for communication and a processing
 // function that is intended to be in run multiple goroutines.
 type txValidator struct {
 	validateChan chan *txValidateItem
 	resultChan   chan error
+	batchChan    chan *schnorrBatch
 	prevScripts  PrevScripter
 	flags        txscript.ScriptFlags
 	sigCache     *txscript.SigCache
+	batchSchnorr bool
 }
 
 // sendResult sends the result of a script pair validation on the internal
@@ -53,56 +71,186 @@ func (v *txValidator) sendResult(ctx context.Context, result error) {
 	}
 }
 
+// validateItem executes and validates the script pair for the passed
+// transaction input.  secp256k1 Schnorr signature checks are deferred to the
+// provided batch when it is not nil.
+func (v *txValidator) validateItem(txVI *txValidateItem, batch *schnorr.BatchVerifier) error {
+	// Ensure the referenced input utxo is available.
+	txIn := txVI.txIn
+	prevOut := &txIn.PreviousOutPoint
+	scriptVersion, pkScript, ok := v.prevScripts.PrevScript(prevOut)
+	if !ok {
+		str := fmt.Sprintf("unable to find unspent output %v referenced from "+
+			"transaction %s:%d", *prevOut, txVI.tx.Hash(), txVI.txInIndex)
+		return ruleError(ErrMissingTxOut, str)
+	}
+
+	// Create a new script engine for the script pair.
+	sigScript := txIn.SignatureScript
+	vm, err := txscript.NewEngine(pkScript, txVI.tx.MsgTx(), txVI.txInIndex,
+		v.flags, scriptVersion, v.sigCache)
+	if err != nil {
+		str := fmt.Sprintf("failed to parse input %s:%d which references "+
+			"output %v - %v (input script bytes %x, prev output script "+
+			"bytes %x)", txVI.tx.Hash(), txVI.txInIndex, *prevOut, err,
+			sigScript, pkScript)
+		return ruleError(ErrScriptMalformed, str)
+	}
+	if batch != nil {
+		vm.SetSchnorrBatch(batch)
+	}
+
+	// Execute the script pair.
+	if err := vm.Execute(); err != nil {
+		str := fmt.Sprintf("failed to validate input %s:%d which references "+
+			"output %v - %v (input script bytes %x, prev output script "+
+			"bytes %x)", txVI.tx.Hash(), txVI.txInIndex, *prevOut, err,
+			sigScript, pkScript)
+		return ruleError(ErrScriptValidation, str)
+	}
+
+	return nil
+}
+
+// validateBatched executes and validates the script pair for the passed
+// transaction input with the secp256k1 Schnorr signature checks deferred to the
+// provided batch.
+//
+// The deferred signatures are assumed to be valid which might alter the
+// execution path, so the input is validated again without deferring them when
+// it fails.  In that case, the signatures it deferred are removed from the
+// batch since scripts may legitimately require invalid signatures and keeping
+// them would cause the entire batch to fail.  The input is only recorded as
+// part of the batch when its deferred signatures remain in it.
+func (v *txValidator) validateBatched(txVI *txValidateItem, b *schnorrBatch) error {
+	numBatched := b.batch.Len()
+	err := v.validateItem(txVI, b.batch)
+	if b.batch.Len() == numBatched {
+		return err
+	}
+	if err != nil {
+		b.batch.Truncate(numBatched)
+		return v.validateItem(txVI, nil)
+	}
+	b.items = append(b.items, txVI)
+	return nil
+}
+
+// verifyBatch verifies the secp256k1 Schnorr signatures that were deferred to
+// the passed batch.  When the batch fails to verify, the items the signatures
+// were deferred from are validated again without deferring any signature
+// checks in order to determine the correct result since the deferred
+// signatures were assumed to be valid.
+func (v *txValidator) verifyBatch(b *schnorrBatch) error {
+	if b.batch.Verify() {
+		return nil
+	}
+	for _, txVI := range b.items {
+		if err := v.validateItem(txVI, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyBatches verifies the secp256k1 Schnorr signatures that were deferred
+// to the passed batches.
+//
+// Batch verification is more efficient per signature for larger batches, so
+// the passed batches are combined into as few batches as possible while still
+// making use of multiple processor cores when there are enough signatures, and
+// the combined batches are verified concurrently.
+func (v *txValidator) verifyBatches(batches []*schnorrBatch) error {
+	var numSigs int
+	for _, b := range batches {
+		numSigs += b.batch.Len()
+	}
+	if numSigs == 0 {
+		return nil
+	}
+
+	// Determine how many batches to verify concurrently based on the number
+	// of signatures and processor cores.
+	numCombined := numSigs / minParallelBatchVerifySize
+	if maxProcs := runtime.GOMAXPROCS(0); numCombined > maxProcs {
+		numCombined = maxProcs
+	}
+	if numCombined < 1 {
+		numCombined = 1
+	}
+
+	// Combine the batches.  The passed batches are all roughly the same size
+	// since the items are distributed evenly among the validation handlers,
+	// so assigning them in turn results in similarly sized combined batches.
+	combined := make([]*schnorrBatch, numCombined)
+	for i := range combined {
+		combined[i] = &schnorrBatch{
+			batch: schnorr.NewBatchVerifier(numSigs/numCombined + 1),
+		}
+	}
+	for i, b := range batches {
+		c := combined[i%numCombined]
+		c.batch.Merge(b.batch)
+		c.items = append(c.items, b.items...)
+	}
+	if numCombined == 1 {
+		return v.verifyBatch(combined[0])
+	}
+
+	// Verify the combined batches concurrently.
+	results := make(chan error, numCombined)
+	for _, c := range combined {
+		go func(c *schnorrBatch) {
+			results <- v.verifyBatch(c)
+		}(c)
+	}
+	for i := 0; i < numCombined; i++ {
+		if err := <-results; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // validateHandler consumes items to validate from the internal validate channel
 // and returns the result of the validation on the internal result channel. It
 // must be run as a goroutine.
+//
+// When batching is enabled, secp256k1 Schnorr signature checks are deferred to
+// a batch that is local to the handler and the batch is sent on the internal
+// batch channel once the validate channel is closed so that the signatures of
+// all handlers can be verified together.
 func (v *txValidator) validateHandler(ctx context.Context) {
+	var batch *schnorrBatch
+	if v.batchSchnorr {
+		batch = &schnorrBatch{batch: schnorr.NewBatchVerifier(0)}
+	}
+
 out:
 	for {
 		select {
 		case <-ctx.Done():
 			break out
 
-		case txVI := <-v.validateChan:
-			// Ensure the referenced input utxo is available.
-			txIn := txVI.txIn
-			prevOut := &txIn.PreviousOutPoint
-			scriptVersion, pkScript, ok := v.prevScripts.PrevScript(prevOut)
+		case txVI, ok := <-v.validateChan:
 			if !ok {
-				str := fmt.Sprintf("unable to find unspent output %v "+
-					"referenced from transaction %s:%d", *prevOut,
-					txVI.tx.Hash(), txVI.txInIndex)
-				err := ruleError(ErrMissingTxOut, str)
-				v.sendResult(ctx, err)
-				break out
-			}
-			// Create a new script engine for the script pair.
-			sigScript := txIn.SignatureScript
-			vm, err := txscript.NewEngine(pkScript, txVI.tx.MsgTx(),
-				txVI.txInIndex, v.flags, scriptVersion, v.sigCache)
-			if err != nil {
-				str := fmt.Sprintf("failed to parse input %s:%d which "+
-					"references output %v - %v (input script bytes %x, prev "+
-					"output script bytes %x)", txVI.tx.Hash(), txVI.txInIndex,
-					*prevOut, err, sigScript, pkScript)
-				err := ruleError(ErrScriptMalformed, str)
-				v.sendResult(ctx, err)
+				select {
+				case v.batchChan <- batch:
+				case <-ctx.Done():
+				}
 				break out
 			}
 
-			// Execute the script pair.
-			if err := vm.Execute(); err != nil {
-				str := fmt.Sprintf("failed to validate input %s:%d which "+
-					"references output %v - %v (input script bytes %x, prev "+
-					"output script bytes %x)", txVI.tx.Hash(), txVI.txInIndex,
-					*prevOut, err, sigScript, pkScript)
-				err := ruleError(ErrScriptValidation, str)
-				v.sendResult(ctx, err)
+			var err error
+			if batch != nil {
+				err = v.validateBatched(txVI, batch)
+			} else {
+				err = v.validateItem(txVI, nil)
+			}
+			v.sendResult(ctx, err)
+			if err != nil {
 				break out
 			}
-
-			// Validation succeeded.
-			v.sendResult(ctx, nil)
 		}
 	}
 }
@@ -128,6 +276,7 @@ func (v *txValidator) Validate(items []*txValidateItem) error {
 	// Start up validation handlers that are used to asynchronously
 	// validate each transaction input.
 	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 	for i := 0; i < maxGoRoutines; i++ {
 		go v.validateHandler(ctx)
 	}
@@ -156,25 +305,41 @@ func (v *txValidator) Validate(items []*txValidateItem) error {
 		case err := <-v.resultChan:
 			processedItems++
 			if err != nil {
-				cancel()
 				return err
 			}
 		}
 	}
 
-	cancel()
+	// Signal the validation handlers that all items have been processed and
+	// verify the signatures they deferred to their batches together when
+	// batching is enabled.
+	if v.batchSchnorr {
+		close(v.validateChan)
+		batches := make([]*schnorrBatch, 0, maxGoRoutines)
+		for i := 0; i < maxGoRoutines; i++ {
+			batches = append(batches, <-v.batchChan)
+		}
+		return v.verifyBatches(batches)
+	}
+
 	return nil
 }
 
 // newTxValidator returns a new instance of txValidator to be used for
 // validating transaction scripts asynchronously.
-func newTxValidator(prevScripts PrevScripter, flags txscript.ScriptFlags, sigCache *txscript.SigCache) *txValidator {
+//
+// The batchSchnorr flag enables deferring secp256k1 Schnorr signature checks so
+// they are verified in batches which is significantly faster than verifying
+// them individually when there are many of them.
+func newTxValidator(prevScripts PrevScripter, flags txscript.ScriptFlags, sigCache *txscript.SigCache, batchSchnorr bool) *txValidator {
 	return &txValidator{
 		validateChan: make(chan *txValidateItem),
 		resultChan:   make(chan error),
+		batchChan:    make(chan *schnorrBatch),
 		prevScripts:  prevScripts,
 		sigCache:     sigCache,
 		flags:        flags,
+		batchSchnorr: batchSchnorr,
 	}
 }
 
@@ -215,7 +380,7 @@ func ValidateTransactionScripts(tx *dcrutil.Tx, prevScripts PrevScripter,
 	}
 
 	// Validate all of the inputs.
-	return newTxValidator(prevScripts, flags, sigCache).Validate(txValItems)
+	return newTxValidator(prevScripts, flags, sigCache, false).Validate(txValItems)
 }
 
 // checkBlockScripts executes and validates the scripts for all transactions in
@@ -269,6 +434,10 @@ func checkBlockScripts(block *dcrutil.Block, utxoView *UtxoViewpoint, txTree boo
 		}
 	}
 
-	// Validate all of the inputs.
-	return newTxValidator(utxoView, scriptFlags, sigCache).Validate(txValItems)
+	// Validate all of the inputs.  The secp256k1 Schnorr signature checks are
+	// verified in batches since blocks may contain many of them.  Note that
+	// ECDSA signatures do not support batch verification, so they are still
+	// verified individually.
+	v := newTxValidator(utxoView, scriptFlags, sigCache, true)
+	return v.Validate(txValItems)
 }
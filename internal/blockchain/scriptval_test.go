@@ -0,0 +1,281 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrec"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/schnorr"
+	"github.com/decred/dcrd/dcrutil/v4"
+	"github.com/decred/dcrd/txscript/v4"
+	"github.com/decred/dcrd/wire"
+)
+
+// testPrevScripts is a PrevScripter backed by a map for use in the tests.
+type testPrevScripts map[wire.OutPoint][]byte
+
+// PrevScript returns the version 0 script for the provided outpoint.
+//
+// This is part of the PrevScripter interface.
+func (s testPrevScripts) PrevScript(prevOut *wire.OutPoint) (uint16, []byte, bool) {
+	script, ok := s[*prevOut]
+	return 0, script, ok
+}
+
+// schnorrTestInput describes an input spent by a secp256k1 Schnorr signature
+// for use in the script validation tests.
+type schnorrTestInput struct {
+	badSig bool // whether or not to provide an invalid signature
+	negate bool // whether or not the script requires the check to fail
+}
+
+// schnorrTestScripts returns a pay-to-alt-pubkey script for a secp256k1
+// Schnorr public key derived from the passed index along with a function that
+// signs the provided input of a transaction that spends it according to the
+// passed input description.
+func schnorrTestScripts(t testing.TB, idx int, input schnorrTestInput) ([]byte, func(tx *wire.MsgTx, txInIdx int)) {
+	t.Helper()
+
+	seed := chainhash.HashB([]byte{byte(idx), byte(idx >> 8), byte(idx >> 16)})
+	privKey := secp256k1.PrivKeyFromBytes(seed)
+	builder := txscript.NewScriptBuilder().
+		AddData(privKey.PubKey().SerializeCompressed()).
+		AddInt64(int64(dcrec.STSchnorrSecp256k1)).
+		AddOp(txscript.OP_CHECKSIGALT)
+	if input.negate {
+		builder.AddOp(txscript.OP_NOT)
+	}
+	pkScript, err := builder.Script()
+	if err != nil {
+		t.Fatalf("unexpected error building script: %v", err)
+	}
+
+	sign := func(tx *wire.MsgTx, txInIdx int) {
+		t.Helper()
+
+		hash, err := txscript.CalcSignatureHash(pkScript, txscript.SigHashAll,
+			tx, txInIdx, nil)
+		if err != nil {
+			t.Fatalf("unexpected error calculating signature hash: %v", err)
+		}
+		if input.badSig {
+			hash[0] ^= 0x01
+		}
+		sig, err := schnorr.Sign(privKey, hash)
+		if err != nil {
+			t.Fatalf("unexpected error signing: %v", err)
+		}
+		sigBytes := append(sig.Serialize(), byte(txscript.SigHashAll))
+		sigScript, err := txscript.NewScriptBuilder().AddData(sigBytes).Script()
+		if err != nil {
+			t.Fatalf("unexpected error building script: %v", err)
+		}
+		tx.TxIn[txInIdx].SignatureScript = sigScript
+	}
+	return pkScript, sign
+}
+
+// makeSchnorrTestItems returns items to validate that spend pay-to-alt-pubkey
+// scripts with secp256k1 Schnorr signatures according to the passed input
+// descriptions along with the scripts they spend.
+func makeSchnorrTestItems(t testing.TB, inputs []schnorrTestInput) ([]*txValidateItem, testPrevScripts) {
+	t.Helper()
+
+	prevScripts := make(testPrevScripts, len(inputs))
+	items := make([]*txValidateItem, 0, len(inputs))
+	for i, input := range inputs {
+		pkScript, sign := schnorrTestScripts(t, i, input)
+		prevOut := wire.OutPoint{Hash: chainhash.HashH(pkScript)}
+		prevScripts[prevOut] = pkScript
+		tx := wire.NewMsgTx()
+		tx.AddTxIn(wire.NewTxIn(&prevOut, 0, nil))
+		tx.AddTxOut(wire.NewTxOut(0, []byte{txscript.OP_TRUE}))
+		sign(tx, 0)
+
+		items = append(items, &txValidateItem{
+			txInIndex: 0,
+			txIn:      tx.TxIn[0],
+			tx:        dcrutil.NewTx(tx),
+		})
+	}
+	return items, prevScripts
+}
+
+// makeSchnorrTestBlock returns a block with a coinbase followed by regular
+// transactions that spend pay-to-alt-pubkey scripts with secp256k1 Schnorr
+// signatures according to the passed input descriptions for each transaction
+// along with a view that contains the outputs they spend.
+func makeSchnorrTestBlock(t testing.TB, txns [][]schnorrTestInput) (*dcrutil.Block, *UtxoViewpoint) {
+	t.Helper()
+
+	var block wire.MsgBlock
+	coinbase := wire.NewMsgTx()
+	coinbase.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{},
+		wire.MaxPrevOutIndex, wire.TxTreeRegular), 0, nil))
+	coinbase.AddTxOut(wire.NewTxOut(0, []byte{txscript.OP_TRUE}))
+	block.AddTransaction(coinbase)
+
+	view := NewUtxoViewpoint(nil)
+	var scriptIdx int
+	for _, inputs := range txns {
+		// Create a transaction with an output for each input of the spending
+		// transaction and add it to the view.
+		signFns := make([]func(tx *wire.MsgTx, txInIdx int), 0, len(inputs))
+		fundingTx := wire.NewMsgTx()
+		fundingTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{},
+			uint32(scriptIdx), wire.TxTreeRegular), 0, nil))
+		for _, input := range inputs {
+			pkScript, sign := schnorrTestScripts(t, scriptIdx, input)
+			fundingTx.AddTxOut(wire.NewTxOut(1, pkScript))
+			signFns = append(signFns, sign)
+			scriptIdx++
+		}
+		view.AddTxOuts(dcrutil.NewTx(fundingTx), 1, 0, true)
+
+		// Create the spending transaction and sign its inputs.
+		fundingHash := fundingTx.TxHash()
+		tx := wire.NewMsgTx()
+		for i := range inputs {
+			prevOut := wire.NewOutPoint(&fundingHash, uint32(i),
+				wire.TxTreeRegular)
+			tx.AddTxIn(wire.NewTxIn(prevOut, 1, nil))
+		}
+		tx.AddTxOut(wire.NewTxOut(int64(len(inputs)),
+			[]byte{txscript.OP_TRUE}))
+		for i, sign := range signFns {
+			sign(tx, i)
+		}
+		block.AddTransaction(tx)
+	}
+	return dcrutil.NewBlock(&block), view
+}
+
+// TestValidateSchnorrBatch ensures that validating transaction scripts with the
+// secp256k1 Schnorr signature checks deferred to batches produces the same
+// results as verifying them individually, including when the batches fail to
+// verify.
+func TestValidateSchnorrBatch(t *testing.T) {
+	t.Parallel()
+
+	// makeInputs returns the passed number of input descriptions with valid
+	// signatures with the provided input descriptions appended.
+	makeInputs := func(numValid int, extra ...schnorrTestInput) []schnorrTestInput {
+		inputs := make([]schnorrTestInput, numValid, numValid+len(extra))
+		return append(inputs, extra...)
+	}
+
+	tests := []struct {
+		name   string             // test description
+		inputs []schnorrTestInput // inputs to validate
+		err    error              // expected error
+	}{{
+		name:   "single valid signature",
+		inputs: makeInputs(1),
+		err:    nil,
+	}, {
+		name:   "many valid signatures",
+		inputs: makeInputs(200),
+		err:    nil,
+	}, {
+		name:   "single invalid signature",
+		inputs: makeInputs(0, schnorrTestInput{badSig: true}),
+		err:    ErrScriptValidation,
+	}, {
+		name:   "invalid signature among many valid signatures",
+		inputs: makeInputs(200, schnorrTestInput{badSig: true}),
+		err:    ErrScriptValidation,
+	}, {
+		name: "invalid signature required by script",
+		inputs: makeInputs(0, schnorrTestInput{
+			badSig: true,
+			negate: true,
+		}),
+		err: nil,
+	}, {
+		name: "invalid signature required by script among many valid " +
+			"signatures",
+		inputs: makeInputs(200, schnorrTestInput{badSig: true, negate: true}),
+		err:    nil,
+	}, {
+		name: "valid signature with script that requires an invalid " +
+			"signature",
+		inputs: makeInputs(200, schnorrTestInput{negate: true}),
+		err:    ErrScriptValidation,
+	}}
+
+	for _, test := range tests {
+		items, prevScripts := makeSchnorrTestItems(t, test.inputs)
+		for _, batchSchnorr := range []bool{false, true} {
+			v := newTxValidator(prevScripts, 0, nil, batchSchnorr)
+			err := v.Validate(items)
+			if !errors.Is(err, test.err) {
+				t.Errorf("%q (batch %v): mismatched err -- got %v, want %v",
+					test.name, batchSchnorr, err, test.err)
+			}
+		}
+	}
+}
+
+// TestValidateSchnorrBatchRollback ensures that the secp256k1 Schnorr
+// signatures deferred by inputs that fail with batching and are validated again
+// without it are removed from the batch so that a script that legitimately
+// requires an invalid signature does not cause the batch to fail.
+func TestValidateSchnorrBatchRollback(t *testing.T) {
+	t.Parallel()
+
+	// Create a block with many transactions that spend inputs with valid
+	// signatures along with one that also has an input that requires an
+	// invalid signature.
+	const numTxns, numInputsPerTx = 50, 4
+	txns := make([][]schnorrTestInput, numTxns)
+	for i := range txns {
+		txns[i] = make([]schnorrTestInput, numInputsPerTx)
+	}
+	txns[numTxns/2][1] = schnorrTestInput{badSig: true, negate: true}
+	block, view := makeSchnorrTestBlock(t, txns)
+
+	// Ensure only the signatures of the inputs that pass with batching remain
+	// in the batch and that only those inputs are recorded as part of it.
+	v := newTxValidator(view, 0, nil, true)
+	b := &schnorrBatch{batch: schnorr.NewBatchVerifier(0)}
+	for _, tx := range block.Transactions()[1:] {
+		for txInIdx, txIn := range tx.MsgTx().TxIn {
+			txVI := &txValidateItem{txInIndex: txInIdx, txIn: txIn, tx: tx}
+			if err := v.validateBatched(txVI, b); err != nil {
+				t.Fatalf("unexpected error validating %s:%d: %v", tx.Hash(),
+					txInIdx, err)
+			}
+		}
+	}
+	const wantBatched = numTxns*numInputsPerTx - 1
+	if b.batch.Len() != wantBatched {
+		t.Fatalf("unexpected number of batched signatures -- got %d, want %d",
+			b.batch.Len(), wantBatched)
+	}
+	if len(b.items) != wantBatched {
+		t.Fatalf("unexpected number of batched items -- got %d, want %d",
+			len(b.items), wantBatched)
+	}
+	for _, txVI := range b.items {
+		if txVI.tx.Hash() == block.Transactions()[1+numTxns/2].Hash() &&
+			txVI.txInIndex == 1 {
+
+			t.Fatal("input that requires an invalid signature was batched")
+		}
+	}
+	if !b.batch.Verify() {
+		t.Fatal("batch did not verify")
+	}
+
+	// Ensure the block scripts are valid.
+	err := checkBlockScripts(block, view, true, 0, nil, true)
+	if err != nil {
+		t.Fatalf("unexpected error checking block scripts: %v", err)
+	}
+}
@@ -145,6 +145,11 @@ type UtxoCache struct {
 	// set when the instance is created and is not changed afterward.
 	maxSize uint64
 
+	// onFlush defines an optional callback that is invoked with the time spent
+	// on each flush of the cache to the backend.  It is set when the instance
+	// is created and is not changed afterward.
+	onFlush func(time.Duration)
+
 	// cacheLock protects access to the fields in the struct below this point.
 	// A standard mutex is used rather than a read-write mutex since the cache
 	// will often write when reads result in a cache miss, so it is generally
@@ -217,6 +222,12 @@ type UtxoCacheConfig struct {
 	//
 	// This field is required.
 	MaxSize uint64
+
+	// OnFlush defines an optional callback that is invoked with the time spent
+	// on each flush of the cache to the backend.
+	//
+	// This field can be nil if the caller is not interested in the timings.
+	OnFlush func(time.Duration)
 }
 
 // NewUtxoCache returns a UtxoCache instance using the provided configuration
@@ -232,6 +243,7 @@ func NewUtxoCache(config *UtxoCacheConfig) *UtxoCache {
 		backend:       config.Backend,
 		flushBlockDB:  config.FlushBlockDB,
 		maxSize:       config.MaxSize,
+		onFlush:       config.OnFlush,
 		entries:       make(map[wire.OutPoint]*UtxoEntry, uint64(maxEntries)),
 		lastFlushTime: time.Now(),
 		timeNow:       time.Now,
@@ -265,6 +277,39 @@ func (c *UtxoCache) hitRatio() float64 {
 	return float64(c.hits) / float64(totalLookups) * 100
 }
 
+// UtxoCacheStats houses statistics about the utxo cache.
+type UtxoCacheStats struct {
+	// Entries is the number of entries in the cache.
+	Entries uint64
+
+	// Size is the total size of the cache, in bytes.
+	Size uint64
+
+	// MaxSize is the maximum allowed size of the cache, in bytes.
+	MaxSize uint64
+
+	// Hits and Misses are the total number of cache lookups that resulted in
+	// a cache hit and a cache miss, respectively.
+	Hits   uint64
+	Misses uint64
+}
+
+// Stats returns statistics about the cache.
+//
+// This function is safe for concurrent access.
+func (c *UtxoCache) Stats() UtxoCacheStats {
+	c.cacheLock.Lock()
+	stats := UtxoCacheStats{
+		Entries: uint64(len(c.entries)),
+		Size:    c.totalSize(),
+		MaxSize: c.maxSize,
+		Hits:    c.hits,
+		Misses:  c.misses,
+	}
+	c.cacheLock.Unlock()
+	return stats
+}
+
 // addEntry adds the specified output to the cache.  The entry being added MUST
 // NOT be mutated by the caller after being passed to this function.
 //
@@ -679,6 +724,8 @@ func (c *UtxoCache) shouldFlush(bestHash *chainhash.Hash) bool {
 //
 // This function MUST be called with the cache lock held.
 func (c *UtxoCache) flush(bestHash *chainhash.Hash, bestHeight uint32, logFlush bool) error {
+	flushStart := time.Now()
+
 	// If the maximum allowed size of the cache has been reached, determine the
 	// eviction height.
 	var evictionHeight uint32
@@ -765,6 +812,11 @@ func (c *UtxoCache) flush(bestHash *chainhash.Hash, bestHeight uint32, logFlush
 		c.lastEvictionHeight = evictionHeight
 	}
 
+	// Notify the caller of the time spent on the flush when requested.
+	if c.onFlush != nil {
+		c.onFlush(time.Since(flushStart))
+	}
+
 	// Log that the flush has been completed and indicate the updated memory
 	// usage as it will be reduced due to evicting entries above.
 	if logFlush {
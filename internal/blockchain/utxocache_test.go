@@ -353,6 +353,31 @@ func TestHitRatio(t *testing.T) {
 	}
 }
 
+// TestUtxoCacheStats validates that the expected statistics are returned for
+// the cache.
+func TestUtxoCacheStats(t *testing.T) {
+	t.Parallel()
+
+	outpoint, entry := outpoint299(), entry299()
+	utxoCache := createTestUtxoCache(t, map[wire.OutPoint]*UtxoEntry{
+		outpoint: entry,
+	})
+	utxoCache.maxSize = 1000
+	utxoCache.hits = 197
+	utxoCache.misses = 3
+
+	want := UtxoCacheStats{
+		Entries: 1,
+		Size:    utxoCache.totalSize(),
+		MaxSize: 1000,
+		Hits:    197,
+		Misses:  3,
+	}
+	if got := utxoCache.Stats(); got != want {
+		t.Fatalf("unexpected stats -- got %+v, want %+v", got, want)
+	}
+}
+
 // TestAddEntry validates that entries are added to the cache properly under a
 // variety of conditions.
 func TestAddEntry(t *testing.T) {
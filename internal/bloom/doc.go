@@ -0,0 +1,24 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package bloom provides the bloom filters and merkle blocks that are served to
+peers which load a filter via the filterload message.
+
+Tests are included to ensure proper functionality.
+
+# Feature Overview
+
+The following are the primary features provided:
+
+  - Bloom filters that are compatible with the filterload, filteradd, and
+    filterclear messages
+  - Matching transactions against a filter based on their hash, the data
+    pushes in their output and signature scripts, and the outpoints they spend
+  - Automatically updating a filter with the outpoints of matched outputs per
+    the update flags of the filter so transactions which spend them also match
+  - Construction of merkleblock messages that contain merkle tree inclusion
+    proofs for the transactions in a block that match a filter
+*/
+package bloom
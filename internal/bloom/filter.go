@@ -0,0 +1,361 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package bloom
+
+import (
+	"encoding/binary"
+	"math"
+	"sync"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrutil/v4"
+	"github.com/decred/dcrd/txscript/v4"
+	"github.com/decred/dcrd/txscript/v4/stdscript"
+	"github.com/decred/dcrd/wire"
+)
+
+const (
+	// ln2Squared is simply the square of the natural log of 2.
+	ln2Squared = math.Ln2 * math.Ln2
+
+	// hashSeedMultiplier is multiplied by the index of each hash function
+	// and added to the tweak of the filter to produce the seed for each hash
+	// function.
+	hashSeedMultiplier = 0xfba4c795
+
+	// outPointSize is the size of a serialized outpoint which consists of the
+	// hash of the transaction it refers to and the output index.
+	outPointSize = chainhash.HashSize + 4
+)
+
+// Filter defines a bloom filter that provides easy manipulation of raw filter
+// data.  A filter that is not loaded does not match anything.
+//
+// It is safe for concurrent access.
+type Filter struct {
+	mtx           sync.Mutex
+	msgFilterLoad *wire.MsgFilterLoad
+}
+
+// NewFilter creates a new bloom filter instance that is suitable for holding
+// the provided number of elements with the provided false positive rate.  The
+// tweak is added to the seed of each hash function to allow the filters used
+// by a given client to differ.  The flags specify how the filter is updated
+// when a transaction output matches it.
+//
+// The false positive rate is clamped to the range (0, 1] and the size of the
+// filter and number of hash functions are limited to the maximums allowed by
+// the filterload message.
+func NewFilter(elements, tweak uint32, fprate float64, flags wire.BloomUpdateType) *Filter {
+	// Massage the false positive rate to sane values.
+	if fprate > 1.0 {
+		fprate = 1.0
+	}
+	if fprate < 1e-9 {
+		fprate = 1e-9
+	}
+	if elements == 0 {
+		elements = 1
+	}
+
+	// Calculate the size of the filter in bytes for the given number of
+	// elements and false positive rate.
+	//
+	// Equivalent to m = -(n*ln(p) / ln(2)^2), where m is in bits.
+	dataLen := uint32(-1 * float64(elements) * math.Log(fprate) / ln2Squared)
+	if dataLen > wire.MaxFilterLoadFilterSize*8 {
+		dataLen = wire.MaxFilterLoadFilterSize * 8
+	}
+	dataLen /= 8
+	if dataLen == 0 {
+		dataLen = 1
+	}
+
+	// Calculate the number of hash functions based on the size of the filter
+	// calculated above and the number of elements.
+	//
+	// Equivalent to k = (m/n) * ln(2).
+	hashFuncs := uint32(float64(dataLen*8) / float64(elements) * math.Ln2)
+	if hashFuncs > wire.MaxFilterLoadHashFuncs {
+		hashFuncs = wire.MaxFilterLoadHashFuncs
+	}
+	if hashFuncs == 0 {
+		hashFuncs = 1
+	}
+
+	data := make([]byte, dataLen)
+	msg := wire.NewMsgFilterLoad(data, hashFuncs, tweak, flags)
+	return &Filter{msgFilterLoad: msg}
+}
+
+// LoadFilter creates a new Filter instance with the given underlying
+// wire.MsgFilterLoad.  A nil message results in a filter that is not loaded.
+func LoadFilter(filter *wire.MsgFilterLoad) *Filter {
+	return &Filter{msgFilterLoad: filter}
+}
+
+// IsLoaded returns true if a filter is loaded, otherwise false.
+//
+// This function is safe for concurrent access.
+func (bf *Filter) IsLoaded() bool {
+	bf.mtx.Lock()
+	loaded := bf.msgFilterLoad != nil
+	bf.mtx.Unlock()
+	return loaded
+}
+
+// Reload loads a new filter replacing any existing filter.
+//
+// This function is safe for concurrent access.
+func (bf *Filter) Reload(filter *wire.MsgFilterLoad) {
+	bf.mtx.Lock()
+	bf.msgFilterLoad = filter
+	bf.mtx.Unlock()
+}
+
+// Unload unloads the bloom filter.
+//
+// This function is safe for concurrent access.
+func (bf *Filter) Unload() {
+	bf.mtx.Lock()
+	bf.msgFilterLoad = nil
+	bf.mtx.Unlock()
+}
+
+// hash returns the bit offset in the bloom filter which corresponds to the
+// passed data for the given independent hash function number.
+func (bf *Filter) hash(hashNum uint32, data []byte) uint32 {
+	// bitmask of len(filter) * 8 bits.
+	seed := hashNum*hashSeedMultiplier + bf.msgFilterLoad.Tweak
+	mm := murmurHash3(seed, data)
+	return mm % (uint32(len(bf.msgFilterLoad.Filter)) * 8)
+}
+
+// matches returns true if the bloom filter might contain the passed data and
+// false if it definitely does not.
+//
+// This function MUST be called with the filter lock held.
+func (bf *Filter) matches(data []byte) bool {
+	if bf.msgFilterLoad == nil || len(bf.msgFilterLoad.Filter) == 0 {
+		return false
+	}
+
+	// The bloom filter does not contain the data if any of the bit offsets
+	// which result from hashing the data using each independent hash function
+	// are not set.  The shifts and masks below are a faster equivalent of:
+	//   arrayIndex := idx / 8     (idx >> 3)
+	//   bitOffset := idx % 8      (idx & 7)
+	//   if filter[arrayIndex] & 1<<bitOffset == 0 { ... }
+	for i := uint32(0); i < bf.msgFilterLoad.HashFuncs; i++ {
+		idx := bf.hash(i, data)
+		if bf.msgFilterLoad.Filter[idx>>3]&(1<<(idx&7)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Matches returns true if the bloom filter might contain the passed data and
+// false if it definitely does not.
+//
+// This function is safe for concurrent access.
+func (bf *Filter) Matches(data []byte) bool {
+	bf.mtx.Lock()
+	match := bf.matches(data)
+	bf.mtx.Unlock()
+	return match
+}
+
+// serializeOutPoint returns the serialization of the provided outpoint that
+// is used when adding it to and matching it against the filter.
+func serializeOutPoint(outpoint *wire.OutPoint) []byte {
+	var buf [outPointSize]byte
+	copy(buf[:], outpoint.Hash[:])
+	binary.LittleEndian.PutUint32(buf[chainhash.HashSize:], outpoint.Index)
+	return buf[:]
+}
+
+// matchesOutPoint returns true if the bloom filter might contain the passed
+// outpoint and false if it definitely does not.
+//
+// This function MUST be called with the filter lock held.
+func (bf *Filter) matchesOutPoint(outpoint *wire.OutPoint) bool {
+	return bf.matches(serializeOutPoint(outpoint))
+}
+
+// MatchesOutPoint returns true if the bloom filter might contain the passed
+// outpoint and false if it definitely does not.
+//
+// This function is safe for concurrent access.
+func (bf *Filter) MatchesOutPoint(outpoint *wire.OutPoint) bool {
+	bf.mtx.Lock()
+	match := bf.matchesOutPoint(outpoint)
+	bf.mtx.Unlock()
+	return match
+}
+
+// add adds the passed byte slice to the bloom filter.
+//
+// This function MUST be called with the filter lock held.
+func (bf *Filter) add(data []byte) {
+	if bf.msgFilterLoad == nil || len(bf.msgFilterLoad.Filter) == 0 {
+		return
+	}
+
+	// Adding data to a bloom filter consists of setting all of the bit
+	// offsets which result from hashing the data using each independent hash
+	// function.  The shifts and masks below are a faster equivalent of:
+	//   arrayIndex := idx / 8    (idx >> 3)
+	//   bitOffset := idx % 8     (idx & 7)
+	//   filter[arrayIndex] |= 1<<bitOffset
+	for i := uint32(0); i < bf.msgFilterLoad.HashFuncs; i++ {
+		idx := bf.hash(i, data)
+		bf.msgFilterLoad.Filter[idx>>3] |= (1 << (7 & idx))
+	}
+}
+
+// Add adds the passed byte slice to the bloom filter.
+//
+// This function is safe for concurrent access.
+func (bf *Filter) Add(data []byte) {
+	bf.mtx.Lock()
+	bf.add(data)
+	bf.mtx.Unlock()
+}
+
+// AddHash adds the passed chainhash.Hash to the Filter.
+//
+// This function is safe for concurrent access.
+func (bf *Filter) AddHash(hash *chainhash.Hash) {
+	bf.mtx.Lock()
+	bf.add(hash[:])
+	bf.mtx.Unlock()
+}
+
+// addOutPoint adds the passed transaction outpoint to the bloom filter.
+//
+// This function MUST be called with the filter lock held.
+func (bf *Filter) addOutPoint(outpoint *wire.OutPoint) {
+	bf.add(serializeOutPoint(outpoint))
+}
+
+// AddOutPoint adds the passed transaction outpoint to the bloom filter.
+//
+// This function is safe for concurrent access.
+func (bf *Filter) AddOutPoint(outpoint *wire.OutPoint) {
+	bf.mtx.Lock()
+	bf.addOutPoint(outpoint)
+	bf.mtx.Unlock()
+}
+
+// maybeAddOutpoint potentially adds the passed outpoint to the bloom filter
+// depending on the bloom update flags and the type of the passed public key
+// script.
+//
+// This function MUST be called with the filter lock held.
+func (bf *Filter) maybeAddOutpoint(scriptVersion uint16, pkScript []byte, outHash *chainhash.Hash, outIdx uint32, outTree int8) {
+	switch bf.msgFilterLoad.Flags {
+	case wire.BloomUpdateAll:
+		outpoint := wire.NewOutPoint(outHash, outIdx, outTree)
+		bf.addOutPoint(outpoint)
+
+	case wire.BloomUpdateP2PubkeyOnly:
+		switch stdscript.DetermineScriptType(scriptVersion, pkScript) {
+		case stdscript.STPubKeyEcdsaSecp256k1, stdscript.STPubKeyEd25519,
+			stdscript.STPubKeySchnorrSecp256k1, stdscript.STMultiSig:
+
+			outpoint := wire.NewOutPoint(outHash, outIdx, outTree)
+			bf.addOutPoint(outpoint)
+		}
+	}
+}
+
+// matchScriptPushes returns true if the bloom filter might contain any of the
+// data pushed by the passed script.
+//
+// This function MUST be called with the filter lock held.
+func (bf *Filter) matchScriptPushes(scriptVersion uint16, script []byte) bool {
+	tokenizer := txscript.MakeScriptTokenizer(scriptVersion, script)
+	for tokenizer.Next() {
+		data := tokenizer.Data()
+		if len(data) > 0 && bf.matches(data) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchTxAndUpdate returns true if the bloom filter matches data within the
+// passed transaction, otherwise false is returned.  If the filter does match
+// the passed transaction, it will also update the filter depending on the
+// bloom update flags set via the loaded filter if needed.
+//
+// This function MUST be called with the filter lock held.
+func (bf *Filter) matchTxAndUpdate(tx *dcrutil.Tx) bool {
+	// Check if the filter matches the hash of the transaction.  This is
+	// useful for finding transactions when they appear in a block.
+	txHash := tx.Hash()
+	matched := bf.matches(txHash[:])
+
+	// Check if the filter matches any data elements in the public key
+	// scripts of any of the outputs.  When it does, add the outpoint that
+	// represents the output to the filter depending on the bloom update flags
+	// so spends of the output are found.
+	msgTx := tx.MsgTx()
+	for i, txOut := range msgTx.TxOut {
+		if !bf.matchScriptPushes(txOut.Version, txOut.PkScript) {
+			continue
+		}
+
+		matched = true
+		bf.maybeAddOutpoint(txOut.Version, txOut.PkScript, txHash, uint32(i),
+			tx.Tree())
+	}
+
+	// Nothing more to do if a match has already been made.
+	if matched {
+		return true
+	}
+
+	// At this point, the transaction and none of the data elements in the
+	// public key scripts of its outputs matched.
+	//
+	// Check if the filter matches any outpoints this transaction spends or
+	// any data elements in the signature scripts of any of the inputs.
+	for _, txIn := range msgTx.TxIn {
+		if bf.matchesOutPoint(&txIn.PreviousOutPoint) {
+			return true
+		}
+		if bf.matchScriptPushes(0, txIn.SignatureScript) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MatchTxAndUpdate returns true if the bloom filter matches data within the
+// passed transaction, otherwise false is returned.  If the filter does match
+// the passed transaction, it will also update the filter depending on the
+// bloom update flags set via the loaded filter if needed.
+//
+// This function is safe for concurrent access.
+func (bf *Filter) MatchTxAndUpdate(tx *dcrutil.Tx) bool {
+	bf.mtx.Lock()
+	match := bf.matchTxAndUpdate(tx)
+	bf.mtx.Unlock()
+	return match
+}
+
+// MsgFilterLoad returns the underlying wire.MsgFilterLoad for the bloom
+// filter.
+//
+// This function is safe for concurrent access.
+func (bf *Filter) MsgFilterLoad() *wire.MsgFilterLoad {
+	bf.mtx.Lock()
+	msg := bf.msgFilterLoad
+	bf.mtx.Unlock()
+	return msg
+}
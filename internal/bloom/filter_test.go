@@ -0,0 +1,256 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package bloom
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrutil/v4"
+	"github.com/decred/dcrd/wire"
+)
+
+// hexToBytes converts the passed hex string into bytes and will panic if there
+// is an error.  This is only provided for the hard-coded constants so errors in
+// the source code can be detected. It will only (and must only) be called with
+// hard-coded values.
+func hexToBytes(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic("invalid hex in source file: " + s)
+	}
+	return b
+}
+
+// TestFilterInsert ensures inserting data into a filter causes that data to be
+// matched and the resulting serialized filter matches the expected value for
+// the test vectors from BIP0037.
+func TestFilterInsert(t *testing.T) {
+	tests := []struct {
+		name  string
+		tweak uint32
+		want  string
+	}{{
+		name:  "no tweak",
+		tweak: 0,
+		want:  "03614e9b050000000000000001",
+	}, {
+		name:  "with tweak",
+		tweak: 2147483649,
+		want:  "03ce4299050000000100008001",
+	}}
+
+	for _, test := range tests {
+		f := NewFilter(3, test.tweak, 0.01, wire.BloomUpdateAll)
+
+		data := hexToBytes("99108ad8ed9bb6274d3980bab5a85c048f0950c8")
+		f.Add(data)
+		if !f.Matches(data) {
+			t.Fatalf("%s: filter does not match inserted data", test.name)
+		}
+		data = hexToBytes("19108ad8ed9bb6274d3980bab5a85c048f0950c8")
+		if f.Matches(data) {
+			t.Fatalf("%s: filter matches data that was not inserted",
+				test.name)
+		}
+		f.Add(hexToBytes("b5a2c786d9ef4658287ced5914b37a1b4aa32eee"))
+		f.Add(hexToBytes("b9300670b4c5366e95b2699e8b18bc75e5f729c5"))
+
+		var buf bytes.Buffer
+		err := f.MsgFilterLoad().BtcEncode(&buf, wire.ProtocolVersion)
+		if err != nil {
+			t.Fatalf("%s: unable to encode filter: %v", test.name, err)
+		}
+		want := hexToBytes(test.want)
+		if !bytes.Equal(buf.Bytes(), want) {
+			t.Fatalf("%s: unexpected serialized filter - got %x, want %x",
+				test.name, buf.Bytes(), want)
+		}
+	}
+}
+
+// TestFilterLoad ensures loading, reloading, and unloading filters works as
+// expected.
+func TestFilterLoad(t *testing.T) {
+	f := LoadFilter(nil)
+	if f.IsLoaded() {
+		t.Fatal("filter created from nil message is loaded")
+	}
+	data := []byte{0x01, 0x02, 0x03}
+	f.Add(data)
+	if f.Matches(data) {
+		t.Fatal("filter that is not loaded matches data")
+	}
+
+	msg := NewFilter(10, 0, 0.0001, wire.BloomUpdateNone).MsgFilterLoad()
+	f.Reload(msg)
+	if !f.IsLoaded() {
+		t.Fatal("reloaded filter is not loaded")
+	}
+	f.Add(data)
+	if !f.Matches(data) {
+		t.Fatal("reloaded filter does not match inserted data")
+	}
+
+	f.Unload()
+	if f.IsLoaded() {
+		t.Fatal("unloaded filter is loaded")
+	}
+	if f.Matches(data) {
+		t.Fatal("unloaded filter matches data")
+	}
+}
+
+// TestNewFilterLimits ensures filters are limited to the maximum size and
+// number of hash functions allowed by the filterload message.
+func TestNewFilterLimits(t *testing.T) {
+	// Huge number of elements with a tiny false positive rate.
+	msg := NewFilter(1<<31, 0, 0, wire.BloomUpdateNone).MsgFilterLoad()
+	if len(msg.Filter) != wire.MaxFilterLoadFilterSize {
+		t.Fatalf("unexpected filter size - got %d, want %d", len(msg.Filter),
+			wire.MaxFilterLoadFilterSize)
+	}
+
+	// Single element with a tiny false positive rate.
+	msg = NewFilter(1, 0, 0, wire.BloomUpdateNone).MsgFilterLoad()
+	if msg.HashFuncs > wire.MaxFilterLoadHashFuncs {
+		t.Fatalf("unexpected number of hash funcs - got %d, max %d",
+			msg.HashFuncs, wire.MaxFilterLoadHashFuncs)
+	}
+
+	// No elements with a false positive rate greater than one.
+	msg = NewFilter(0, 0, 2, wire.BloomUpdateNone).MsgFilterLoad()
+	if len(msg.Filter) == 0 || msg.HashFuncs == 0 {
+		t.Fatalf("unexpected empty filter - size %d, hash funcs %d",
+			len(msg.Filter), msg.HashFuncs)
+	}
+}
+
+// p2pkhScript returns a version 0 pay-to-pubkey-hash script for the provided
+// hash.
+func p2pkhScript(hash160 []byte) []byte {
+	script := []byte{0x76, 0xa9, 0x14} // OP_DUP OP_HASH160 OP_DATA_20
+	script = append(script, hash160...)
+	return append(script, 0x88, 0xac) // OP_EQUALVERIFY OP_CHECKSIG
+}
+
+// p2pkScript returns a version 0 pay-to-pubkey script for the provided
+// compressed public key.
+func p2pkScript(pubKey []byte) []byte {
+	script := []byte{0x21} // OP_DATA_33
+	script = append(script, pubKey...)
+	return append(script, 0xac) // OP_CHECKSIG
+}
+
+// newTestTx returns a transaction that spends the provided outpoint with the
+// provided signature script and pays to the provided public key script.
+func newTestTx(prevOut *wire.OutPoint, sigScript, pkScript []byte) *dcrutil.Tx {
+	msgTx := wire.NewMsgTx()
+	msgTx.AddTxIn(wire.NewTxIn(prevOut, 1e8, sigScript))
+	msgTx.AddTxOut(wire.NewTxOut(1e8, pkScript))
+	return dcrutil.NewTx(msgTx)
+}
+
+// TestMatchTxAndUpdate ensures transactions are matched against filters as
+// expected and that filters are updated with the outpoints of matched outputs
+// according to their update flags.
+func TestMatchTxAndUpdate(t *testing.T) {
+	hash160 := hexToBytes("b5a2c786d9ef4658287ced5914b37a1b4aa32eee")
+	pubKey := hexToBytes("02f9308a019258c31049344f85f89d5229b531c845836f99b0" +
+		"8601f113bce036f9")
+	sigData := hexToBytes("3045022100a1b2c3d4e5f60718293a4b5c6d7e8f90")
+	prevOut := wire.NewOutPoint(&chainhash.Hash{0x01}, 0, wire.TxTreeRegular)
+	otherOut := wire.NewOutPoint(&chainhash.Hash{0x02}, 1, wire.TxTreeRegular)
+	p2pkhTx := newTestTx(otherOut, nil, p2pkhScript(hash160))
+	p2pkTx := newTestTx(otherOut, nil, p2pkScript(pubKey))
+
+	// spendOf returns a transaction which spends the first output of the
+	// provided transaction.
+	spendOf := func(tx *dcrutil.Tx) *dcrutil.Tx {
+		prevOut := wire.NewOutPoint(tx.Hash(), 0, wire.TxTreeRegular)
+		return newTestTx(prevOut, nil, []byte{0x6a})
+	}
+
+	tests := []struct {
+		name       string
+		flags      wire.BloomUpdateType
+		add        []byte
+		tx         *dcrutil.Tx
+		wantMatch  bool
+		spendMatch bool
+	}{{
+		name:       "tx hash",
+		flags:      wire.BloomUpdateAll,
+		add:        p2pkhTx.Hash()[:],
+		tx:         p2pkhTx,
+		wantMatch:  true,
+		spendMatch: false,
+	}, {
+		name:       "output push with update all",
+		flags:      wire.BloomUpdateAll,
+		add:        hash160,
+		tx:         p2pkhTx,
+		wantMatch:  true,
+		spendMatch: true,
+	}, {
+		name:       "output push with update none",
+		flags:      wire.BloomUpdateNone,
+		add:        hash160,
+		tx:         p2pkhTx,
+		wantMatch:  true,
+		spendMatch: false,
+	}, {
+		name:       "p2pkh output push with update p2pubkey only",
+		flags:      wire.BloomUpdateP2PubkeyOnly,
+		add:        hash160,
+		tx:         p2pkhTx,
+		wantMatch:  true,
+		spendMatch: false,
+	}, {
+		name:       "p2pk output push with update p2pubkey only",
+		flags:      wire.BloomUpdateP2PubkeyOnly,
+		add:        pubKey,
+		tx:         p2pkTx,
+		wantMatch:  true,
+		spendMatch: true,
+	}, {
+		name:      "spent outpoint",
+		flags:     wire.BloomUpdateNone,
+		add:       serializeOutPoint(prevOut),
+		tx:        newTestTx(prevOut, nil, []byte{0x6a}),
+		wantMatch: true,
+	}, {
+		name:  "signature script push",
+		flags: wire.BloomUpdateNone,
+		add:   sigData,
+		tx: newTestTx(otherOut, append([]byte{byte(len(sigData))},
+			sigData...), []byte{0x6a}),
+		wantMatch: true,
+	}, {
+		name:      "no match",
+		flags:     wire.BloomUpdateAll,
+		add:       []byte{0x01, 0x02, 0x03},
+		tx:        p2pkhTx,
+		wantMatch: false,
+	}}
+
+	for _, test := range tests {
+		f := NewFilter(10, 0, 0.000001, test.flags)
+		f.Add(test.add)
+		if got := f.MatchTxAndUpdate(test.tx); got != test.wantMatch {
+			t.Errorf("%s: unexpected match result - got %v, want %v",
+				test.name, got, test.wantMatch)
+			continue
+		}
+		spend := spendOf(test.tx)
+		if got := f.MatchTxAndUpdate(spend); got != test.spendMatch {
+			t.Errorf("%s: unexpected spend match result - got %v, want %v",
+				test.name, got, test.spendMatch)
+			continue
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package bloom
+
+import (
+	"github.com/decred/dcrd/blockchain/standalone/v2"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrutil/v4"
+	"github.com/decred/dcrd/wire"
+)
+
+// txTreeLeaves returns the leaves of the merkle tree for the provided
+// transactions which are the full hashes of the transactions (including
+// witness data).
+func txTreeLeaves(txns []*dcrutil.Tx) []chainhash.Hash {
+	leaves := make([]chainhash.Hash, 0, len(txns))
+	for _, tx := range txns {
+		leaves = append(leaves, tx.MsgTx().TxHashFull())
+	}
+	return leaves
+}
+
+// addMatchedProofs adds inclusion proofs for the transactions in the provided
+// tree that match the filter to the merkle block and returns the matched
+// transactions appended to the provided slice.
+func addMatchedProofs(msg *wire.MsgMerkleBlock, filter *Filter, tree int8, txns []*dcrutil.Tx, matched []*dcrutil.Tx) []*dcrutil.Tx {
+	var leaves []chainhash.Hash
+	for i, tx := range txns {
+		if !filter.MatchTxAndUpdate(tx) {
+			continue
+		}
+
+		// Only calculate the leaves once there is a match since most
+		// transactions are not expected to match.
+		if leaves == nil {
+			leaves = txTreeLeaves(txns)
+		}
+		msg.AddProof(wire.TxInclusionProof{
+			Tree:        tree,
+			Index:       uint32(i),
+			Hash:        leaves[i],
+			ProofHashes: standalone.GenerateInclusionProof(leaves, uint32(i)),
+		})
+		matched = append(matched, tx)
+	}
+	return matched
+}
+
+// NewMerkleBlock returns a merkleblock message for the provided block that
+// contains merkle tree inclusion proofs for all transactions in both trees of
+// the block that match the provided filter along with the matched
+// transactions in the same order as the proofs.
+//
+// Note that the filter is updated per its bloom update flags as transactions
+// are matched.
+func NewMerkleBlock(block *dcrutil.Block, filter *Filter) (*wire.MsgMerkleBlock, []*dcrutil.Tx) {
+	msgBlock := block.MsgBlock()
+	msg := wire.NewMsgMerkleBlock(&msgBlock.Header,
+		uint32(len(msgBlock.Transactions)),
+		uint32(len(msgBlock.STransactions)))
+
+	var matched []*dcrutil.Tx
+	matched = addMatchedProofs(msg, filter, wire.TxTreeRegular,
+		block.Transactions(), matched)
+	matched = addMatchedProofs(msg, filter, wire.TxTreeStake,
+		block.STransactions(), matched)
+	return msg, matched
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package bloom
+
+import (
+	"testing"
+
+	"github.com/decred/dcrd/blockchain/standalone/v2"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrutil/v4"
+	"github.com/decred/dcrd/wire"
+)
+
+// TestNewMerkleBlock ensures merkle blocks contain valid inclusion proofs for
+// exactly the transactions that match the filter in both transaction trees.
+func TestNewMerkleBlock(t *testing.T) {
+	// Create a block with several transactions in each tree that each pay to
+	// a unique hash.
+	var msgBlock wire.MsgBlock
+	payTo := func(i byte) []byte {
+		var hash160 [20]byte
+		hash160[0] = i
+		return hash160[:]
+	}
+	prevOut := wire.NewOutPoint(&chainhash.Hash{}, 0, wire.TxTreeRegular)
+	for i := byte(0); i < 5; i++ {
+		tx := newTestTx(prevOut, nil, p2pkhScript(payTo(i)))
+		msgBlock.AddTransaction(tx.MsgTx())
+	}
+	for i := byte(5); i < 8; i++ {
+		tx := newTestTx(prevOut, nil, p2pkhScript(payTo(i)))
+		msgBlock.AddSTransaction(tx.MsgTx())
+	}
+	msgBlock.Header.MerkleRoot = standalone.CalcCombinedTxTreeMerkleRoot(
+		msgBlock.Transactions, msgBlock.STransactions)
+	msgBlock.Header.StakeRoot = standalone.CalcTxTreeMerkleRoot(
+		msgBlock.STransactions)
+	block := dcrutil.NewBlock(&msgBlock)
+
+	// Match the second and final regular transactions and the first stake
+	// transaction.
+	f := NewFilter(10, 0, 0.000001, wire.BloomUpdateNone)
+	f.Add(payTo(1))
+	f.Add(payTo(4))
+	f.Add(payTo(5))
+	merkleBlock, matched := NewMerkleBlock(block, f)
+
+	if merkleBlock.Header.BlockHash() != msgBlock.BlockHash() {
+		t.Fatalf("unexpected header hash %v", merkleBlock.Header.BlockHash())
+	}
+	if merkleBlock.Transactions != 5 || merkleBlock.STransactions != 3 {
+		t.Fatalf("unexpected tx counts - got %d, %d, want 5, 3",
+			merkleBlock.Transactions, merkleBlock.STransactions)
+	}
+	wantProofs := []struct {
+		tree  int8
+		index uint32
+		tx    *wire.MsgTx
+	}{
+		{wire.TxTreeRegular, 1, msgBlock.Transactions[1]},
+		{wire.TxTreeRegular, 4, msgBlock.Transactions[4]},
+		{wire.TxTreeStake, 0, msgBlock.STransactions[0]},
+	}
+	if len(merkleBlock.Proofs) != len(wantProofs) {
+		t.Fatalf("unexpected number of proofs - got %d, want %d",
+			len(merkleBlock.Proofs), len(wantProofs))
+	}
+	if len(matched) != len(wantProofs) {
+		t.Fatalf("unexpected number of matched txns - got %d, want %d",
+			len(matched), len(wantProofs))
+	}
+
+	regularRoot := standalone.CalcTxTreeMerkleRoot(msgBlock.Transactions)
+	for i, want := range wantProofs {
+		proof := &merkleBlock.Proofs[i]
+		if proof.Tree != want.tree || proof.Index != want.index {
+			t.Fatalf("#%d: unexpected proof tree %d index %d, want tree %d "+
+				"index %d", i, proof.Tree, proof.Index, want.tree,
+				want.index)
+		}
+		if proof.Hash != want.tx.TxHashFull() {
+			t.Fatalf("#%d: unexpected proof hash %v", i, proof.Hash)
+		}
+		if matched[i].MsgTx() != want.tx {
+			t.Fatalf("#%d: unexpected matched tx %v", i, matched[i].Hash())
+		}
+
+		// Ensure the proof is valid for the respective tree root committed
+		// to by the header.
+		root := &regularRoot
+		if proof.Tree == wire.TxTreeStake {
+			root = &msgBlock.Header.StakeRoot
+		}
+		if !standalone.VerifyInclusionProof(root, &proof.Hash, proof.Index,
+			proof.ProofHashes) {
+
+			t.Fatalf("#%d: invalid inclusion proof", i)
+		}
+	}
+
+	// Ensure the regular tree root combined with the stake root from the
+	// header is the merkle root committed to by the header.
+	combined := standalone.CalcMerkleRoot([]chainhash.Hash{regularRoot,
+		msgBlock.Header.StakeRoot})
+	if combined != msgBlock.Header.MerkleRoot {
+		t.Fatalf("unexpected combined merkle root %v", combined)
+	}
+
+	// Ensure a filter that does not match anything results in no proofs.
+	f = NewFilter(10, 0, 0.000001, wire.BloomUpdateNone)
+	merkleBlock, matched = NewMerkleBlock(block, f)
+	if len(merkleBlock.Proofs) != 0 || len(matched) != 0 {
+		t.Fatalf("unexpected proofs for filter without matches - got %d "+
+			"proofs, %d matched", len(merkleBlock.Proofs), len(matched))
+	}
+}
@@ -273,6 +273,45 @@ func (stats *Estimator) DumpBuckets() string {
 	return res
 }
 
+// BucketStats houses the statistics tracked by the estimator for a single fee
+// rate bucket.
+type BucketStats struct {
+	// FeeRate is the upper bound of the fee rate of the bucket in atoms/kB.
+	// The final bucket has an upper bound of +Inf.
+	FeeRate float64
+
+	// ConfirmedTxs is the exponentially decayed number of mined transactions
+	// in the bucket.
+	ConfirmedTxs float64
+
+	// MemPoolTxs is the number of transactions in the bucket that are
+	// currently tracked as being in the mempool.
+	MemPoolTxs float64
+}
+
+// BucketStats returns the statistics tracked by the estimator for each of its
+// fee rate buckets in increasing order of fee rate.
+//
+// This function is safe for concurrent access.
+func (stats *Estimator) BucketStats() []BucketStats {
+	stats.lock.RLock()
+	defer stats.lock.RUnlock()
+
+	res := make([]BucketStats, len(stats.bucketFeeBounds))
+	for i, feeBound := range stats.bucketFeeBounds {
+		var memPoolTxs float64
+		for _, conf := range stats.memPool[i].confirmed {
+			memPoolTxs += conf.txCount
+		}
+		res[i] = BucketStats{
+			FeeRate:      float64(feeBound),
+			ConfirmedTxs: stats.buckets[i].confirmCount,
+			MemPoolTxs:   memPoolTxs,
+		}
+	}
+	return res
+}
+
 // loadFromDatabase loads the estimator data from the currently opened database
 // and performs any db upgrades if required. After loading, it updates the db
 // with the current estimator configuration.
@@ -91,6 +91,11 @@ const (
 
 	// ErrTSpendInvalidExpiry indicates a treasury spend expiry is invalid.
 	ErrTSpendInvalidExpiry = ErrorKind("ErrTSpendInvalidExpiry")
+
+	// ErrMempoolFull indicates a transaction was evicted immediately after
+	// being accepted because it had the lowest fee rate in a mempool that
+	// exceeded its maximum size.
+	ErrMempoolFull = ErrorKind("ErrMempoolFull")
 )
 
 // Error satisfies the error interface and prints human-readable errors.
@@ -0,0 +1,174 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"container/heap"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+// calcEvictionFeeRate returns the fee rate in atoms/kB that is used to rank
+// the provided transaction for eviction given the total fees and size of the
+// transaction along with all of its descendants in the pool.  It is the greater
+// of the fee rate of the transaction itself and the aggregate fee rate of the
+// package, since evicting a transaction also evicts all of its descendants.
+func calcEvictionFeeRate(txDesc *TxDesc, pkgFees, pkgSize int64) float64 {
+	ownRate := float64(txDesc.Fee) * 1000 / float64(txDesc.TxSize)
+	pkgRate := float64(pkgFees) * 1000 / float64(pkgSize)
+	if ownRate > pkgRate {
+		return ownRate
+	}
+	return pkgRate
+}
+
+// evictionEntry houses a transaction in the main pool that is a candidate for
+// eviction along with the aggregate statistics of its package that determine
+// its rank.
+type evictionEntry struct {
+	txDesc *TxDesc
+
+	// pkgFees and pkgSize are the total fees and serialized size of the
+	// transaction along with all of its descendants in the pool.
+	pkgFees int64
+	pkgSize int64
+
+	// feeRate is the eviction fee rate calculated from the above.
+	feeRate float64
+
+	// index is the index of the entry in the eviction heap.
+	index int
+}
+
+// updateFeeRate updates the eviction fee rate of the entry from its current
+// package statistics.
+func (e *evictionEntry) updateFeeRate() {
+	e.feeRate = calcEvictionFeeRate(e.txDesc, e.pkgFees, e.pkgSize)
+}
+
+// evictionHeap implements a min-heap of eviction entries ordered by their
+// eviction fee rates so that the entry with the lowest fee rate is always the
+// first one.
+type evictionHeap []*evictionEntry
+
+// Len returns the number of entries in the heap.  It is part of the
+// heap.Interface implementation.
+func (h evictionHeap) Len() int {
+	return len(h)
+}
+
+// Less returns whether the entry in the heap with index i should sort before
+// the entry with index j.  It is part of the heap.Interface implementation.
+func (h evictionHeap) Less(i, j int) bool {
+	return h[i].feeRate < h[j].feeRate
+}
+
+// Swap swaps the entries at the passed indices in the heap.  It is part of the
+// heap.Interface implementation.
+func (h evictionHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+// Push pushes the passed entry onto the heap.  It is part of the heap.Interface
+// implementation.
+func (h *evictionHeap) Push(x interface{}) {
+	entry := x.(*evictionEntry)
+	entry.index = len(*h)
+	*h = append(*h, entry)
+}
+
+// Pop removes the entry with the lowest fee rate from the heap and returns it.
+// It is part of the heap.Interface implementation.
+func (h *evictionHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	entry := old[n-1]
+	old[n-1] = nil
+	entry.index = -1
+	*h = old[0 : n-1]
+	return entry
+}
+
+// evictionIndex tracks the transactions in the main pool that are candidates
+// for eviction ordered by the fee rates of their packages so the lowest ranked
+// ones can be found efficiently when the pool exceeds its maximum size.
+type evictionIndex struct {
+	entries map[chainhash.Hash]*evictionEntry
+	heap    evictionHeap
+}
+
+// newEvictionIndex returns a new empty eviction index.
+func newEvictionIndex() *evictionIndex {
+	return &evictionIndex{
+		entries: make(map[chainhash.Hash]*evictionEntry),
+	}
+}
+
+// lookup returns the entry for the provided transaction hash or nil when there
+// is none.
+func (idx *evictionIndex) lookup(txHash *chainhash.Hash) *evictionEntry {
+	return idx.entries[*txHash]
+}
+
+// add adds an entry for the provided transaction with the provided package
+// statistics to the index.
+func (idx *evictionIndex) add(txDesc *TxDesc, pkgFees, pkgSize int64) {
+	entry := &evictionEntry{
+		txDesc:  txDesc,
+		pkgFees: pkgFees,
+		pkgSize: pkgSize,
+	}
+	entry.updateFeeRate()
+	idx.entries[*txDesc.Tx.Hash()] = entry
+	heap.Push(&idx.heap, entry)
+}
+
+// update sets the package statistics of the provided entry and restores its
+// position in the index accordingly.
+func (idx *evictionIndex) update(entry *evictionEntry, pkgFees, pkgSize int64) {
+	entry.pkgFees = pkgFees
+	entry.pkgSize = pkgSize
+	entry.updateFeeRate()
+	heap.Fix(&idx.heap, entry.index)
+}
+
+// remove removes the entry for the provided transaction hash from the index
+// if there is one.
+func (idx *evictionIndex) remove(txHash *chainhash.Hash) {
+	entry, ok := idx.entries[*txHash]
+	if !ok {
+		return
+	}
+	heap.Remove(&idx.heap, entry.index)
+	delete(idx.entries, *txHash)
+}
+
+// lowest returns the entry with the lowest eviction fee rate or nil when the
+// index is empty.
+func (idx *evictionIndex) lowest() *evictionEntry {
+	if len(idx.heap) == 0 {
+		return nil
+	}
+	return idx.heap[0]
+}
+
+// popLowest removes the entry with the lowest eviction fee rate from the heap
+// and returns it without removing it from the index.  The entry MUST be added
+// back with restore.  This allows the entries to be visited in order of their
+// fee rates.
+func (idx *evictionIndex) popLowest() *evictionEntry {
+	if len(idx.heap) == 0 {
+		return nil
+	}
+	return heap.Pop(&idx.heap).(*evictionEntry)
+}
+
+// restore adds an entry that was previously removed from the heap with
+// popLowest back to it.
+func (idx *evictionIndex) restore(entry *evictionEntry) {
+	heap.Push(&idx.heap, entry)
+}
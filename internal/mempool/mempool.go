@@ -8,6 +8,7 @@ package mempool
 import (
 	"errors"
 	"fmt"
+	"math"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -60,6 +61,11 @@ const (
 	// are allowed in the mempool. The number 7 is also the amount of
 	// physical space available for TSpend votes and thus is a hard limit.
 	MempoolMaxConcurrentTSpends = 7
+
+	// rollingMinFeeHalfLife is the amount of time it takes the rolling
+	// minimum relay fee that is raised when transactions are evicted due to
+	// the pool size limit to decay to half of its value.
+	rollingMinFeeHalfLife = time.Hour * 12
 )
 
 // Tag represents an identifier to use for tagging orphan transactions.  The
@@ -125,6 +131,11 @@ type Config struct {
 	// This can be nil if the address index is not enabled.
 	ExistsAddrIndex *indexers.ExistsAddrIndex
 
+	// AddrIndex defines the optional address index instance to use for
+	// indexing the unconfirmed transactions in the memory pool.  This can be
+	// nil if the address index is not enabled.
+	AddrIndex *indexers.AddrIndex
+
 	// AddTxToFeeEstimation defines an optional function to be called whenever a
 	// new transaction is added to the mempool, which can be used to track fees
 	// for the purposes of smart fee estimation.
@@ -190,6 +201,13 @@ type Policy struct {
 	// considered a non-zero fee.
 	MinRelayTxFee dcrutil.Amount
 
+	// MaxPoolSize is the maximum total serialized size in bytes of all
+	// transactions in the main pool.  When the limit is exceeded, the
+	// transaction packages with the lowest fee rates are evicted and the
+	// minimum relay fee is temporarily raised.  A value of zero disables the
+	// limit.
+	MaxPoolSize int64
+
 	// AllowOldVotes defines whether or not votes on old blocks will be
 	// admitted and relayed.
 	AllowOldVotes bool
@@ -216,6 +234,10 @@ type Policy struct {
 // additional metadata.
 type TxDesc struct {
 	mining.TxDesc
+
+	// Tag is the identifier of the source the transaction was received from,
+	// such as a peer ID, when it is known.
+	Tag Tag
 }
 
 // VerboseTxDesc is a descriptor containing a transaction in the mempool along
@@ -266,11 +288,38 @@ type TxPool struct {
 	// TSpends. Access MUST be protected by the mempool mutex.
 	tspends map[chainhash.Hash]*dcrutil.Tx
 
+	// The following fields are used to enforce the maximum pool size.  They
+	// MUST be protected by the mempool mutex.
+	//
+	// totalSize is the total serialized size of all transactions in the main
+	// pool.
+	//
+	// rollingMinFee is the minimum relay fee in atoms/kB that is raised when
+	// transactions are evicted and decays over time thereafter.  It is only
+	// in effect while it is higher than the policy minimum relay fee.
+	//
+	// lastRollingFeeUpdate is the last time the rolling minimum fee was
+	// updated.
+	//
+	// numEvicted is the total number of transactions that have been evicted
+	// due to the size limit.
+	//
+	// evictionIndex tracks the eviction candidates in the main pool ordered
+	// by the fee rates of their packages.
+	totalSize            int64
+	rollingMinFee        float64
+	lastRollingFeeUpdate time.Time
+	numEvicted           uint64
+	evictionIndex        *evictionIndex
+
 	// nextExpireScan is the time after which the orphan pool will be
 	// scanned in order to evict orphans.  This is NOT a hard deadline as
 	// the scan will only run when an orphan is added to the pool as opposed
 	// to on an unconditional timer.
 	nextExpireScan time.Time
+
+	// dumpMtx serializes writing the contents of the pool to files.
+	dumpMtx sync.Mutex
 }
 
 // insertVote inserts a vote into the map of block votes.
@@ -823,6 +872,14 @@ func (mp *TxPool) removeTransaction(tx *dcrutil.Tx, removeRedeemers bool) {
 		mp.miningView.RemoveTransaction(tx.Hash(), updateDescendantStats)
 
 		delete(mp.pool, *txHash)
+		mp.totalSize -= txDesc.TxSize
+		mp.removeFromEvictionIndex(txDesc)
+
+		// Remove unconfirmed address index entries associated with the
+		// transaction if enabled.
+		if mp.cfg.AddrIndex != nil {
+			mp.cfg.AddrIndex.RemoveUnconfirmedTx(txHash)
+		}
 
 		mp.lastUpdated.Store(time.Now().Unix())
 
@@ -877,6 +934,344 @@ func (mp *TxPool) RemoveDoubleSpends(tx *dcrutil.Tx) {
 	mp.mtx.Unlock()
 }
 
+// minRelayFee returns the minimum relay fee per kB that regular transactions
+// and ticket purchases must pay to be accepted into the pool as of the
+// provided time.  This is the greater of the policy minimum relay fee and the
+// rolling minimum fee that is raised when transactions are evicted due to the
+// pool size limit and decays thereafter.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) minRelayFee(now time.Time) dcrutil.Amount {
+	policyFee := mp.cfg.Policy.MinRelayTxFee
+	if mp.rollingMinFee == 0 {
+		return policyFee
+	}
+
+	// Decay the rolling minimum fee exponentially based on the time since
+	// it was last updated and stop enforcing it once it drops below half of
+	// the policy minimum.
+	elapsed := now.Sub(mp.lastRollingFeeUpdate)
+	if elapsed > 0 {
+		halfLives := elapsed.Seconds() / rollingMinFeeHalfLife.Seconds()
+		mp.rollingMinFee /= math.Pow(2, halfLives)
+		mp.lastRollingFeeUpdate = now
+		if mp.rollingMinFee < float64(policyFee)/2 {
+			mp.rollingMinFee = 0
+			return policyFee
+		}
+	}
+
+	rollingFee := dcrutil.Amount(math.Ceil(mp.rollingMinFee))
+	if rollingFee > policyFee {
+		return rollingFee
+	}
+	return policyFee
+}
+
+// MinRelayFee returns the minimum relay fee per kB that regular transactions
+// and ticket purchases currently must pay to be accepted into the pool.  It is
+// higher than the configured policy minimum relay fee while the pool is full.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) MinRelayFee() dcrutil.Amount {
+	mp.mtx.Lock()
+	fee := mp.minRelayFee(time.Now())
+	mp.mtx.Unlock()
+	return fee
+}
+
+// forEachPoolAncestor invokes the provided function for each transaction in
+// the main pool that the provided transaction depends on either directly or
+// indirectly.  Each ancestor is only visited once.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) forEachPoolAncestor(tx *dcrutil.Tx, f func(ancestor *TxDesc)) {
+	seen := make(map[chainhash.Hash]struct{})
+	queue := []*dcrutil.Tx{tx}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		for _, txIn := range next.MsgTx().TxIn {
+			parentHash := txIn.PreviousOutPoint.Hash
+			if _, ok := seen[parentHash]; ok {
+				continue
+			}
+			parent, ok := mp.pool[parentHash]
+			if !ok {
+				continue
+			}
+			seen[parentHash] = struct{}{}
+			f(parent)
+			queue = append(queue, parent.Tx)
+		}
+	}
+}
+
+// calcPackageStats returns the total fees and serialized size of the provided
+// transaction along with all of its descendants in the main pool.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) calcPackageStats(txDesc *TxDesc) (int64, int64) {
+	fees, size := txDesc.Fee, txDesc.TxSize
+	seen := map[chainhash.Hash]struct{}{*txDesc.Tx.Hash(): {}}
+	queue := []*TxDesc{txDesc}
+	for len(queue) > 0 {
+		desc := queue[0]
+		queue = queue[1:]
+		mp.forEachRedeemer(desc.Tx, func(redeemer *TxDesc) {
+			redeemerHash := redeemer.Tx.Hash()
+			if _, ok := seen[*redeemerHash]; ok {
+				return
+			}
+			seen[*redeemerHash] = struct{}{}
+			fees += redeemer.Fee
+			size += redeemer.TxSize
+			queue = append(queue, redeemer)
+		})
+	}
+	return fees, size
+}
+
+// hasPoolRedeemers returns whether or not any transactions in the main pool
+// spend outputs of the provided transaction.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) hasPoolRedeemers(tx *dcrutil.Tx) bool {
+	var hasRedeemers bool
+	mp.forEachRedeemer(tx, func(*TxDesc) { hasRedeemers = true })
+	return hasRedeemers
+}
+
+// isEvictionCandidate returns whether or not transactions of the provided type
+// are considered for eviction when the pool exceeds its maximum size.  Only
+// regular transactions and ticket purchases are considered since the remaining
+// stake transactions are an integral part of the block production process.
+func isEvictionCandidate(txType stake.TxType) bool {
+	return txType == stake.TxTypeRegular || txType == stake.TxTypeSStx
+}
+
+// addToEvictionIndex adds the provided transaction, which MUST have already
+// been added to the main pool, to the eviction index and updates the package
+// statistics of its ancestors in the pool accordingly.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) addToEvictionIndex(txDesc *TxDesc) {
+	// Transactions typically do not have any descendants in the pool when they
+	// are added, in which case the package statistics of the transaction
+	// itself are just its own and its ancestors only gain the transaction.
+	// Otherwise, such as when transactions from disconnected blocks are added
+	// back to the pool, the package statistics of the transaction and its
+	// ancestors are calculated from scratch since some of the descendants
+	// might already be accounted for by the ancestors through other paths.
+	tx := txDesc.Tx
+	recalc := mp.hasPoolRedeemers(tx)
+	if isEvictionCandidate(txDesc.Type) {
+		pkgFees, pkgSize := txDesc.Fee, txDesc.TxSize
+		if recalc {
+			pkgFees, pkgSize = mp.calcPackageStats(txDesc)
+		}
+		mp.evictionIndex.add(txDesc, pkgFees, pkgSize)
+	}
+	mp.forEachPoolAncestor(tx, func(ancestor *TxDesc) {
+		entry := mp.evictionIndex.lookup(ancestor.Tx.Hash())
+		if entry == nil {
+			return
+		}
+		if recalc {
+			pkgFees, pkgSize := mp.calcPackageStats(ancestor)
+			mp.evictionIndex.update(entry, pkgFees, pkgSize)
+			return
+		}
+		mp.evictionIndex.update(entry, entry.pkgFees+txDesc.Fee,
+			entry.pkgSize+txDesc.TxSize)
+	})
+}
+
+// removeFromEvictionIndex removes the provided transaction, which MUST have
+// already been removed from the main pool, from the eviction index and updates
+// the package statistics of its ancestors in the pool accordingly.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) removeFromEvictionIndex(txDesc *TxDesc) {
+	// The ancestors only lose the transaction itself when it does not have any
+	// descendants left in the pool, which is always the case when its
+	// redeemers are removed along with it.  Otherwise, the package statistics
+	// of the ancestors are calculated from scratch since they may also lose
+	// descendants that were only reachable through the transaction.
+	tx := txDesc.Tx
+	mp.evictionIndex.remove(tx.Hash())
+	recalc := mp.hasPoolRedeemers(tx)
+	mp.forEachPoolAncestor(tx, func(ancestor *TxDesc) {
+		entry := mp.evictionIndex.lookup(ancestor.Tx.Hash())
+		if entry == nil {
+			return
+		}
+		if recalc {
+			pkgFees, pkgSize := mp.calcPackageStats(ancestor)
+			mp.evictionIndex.update(entry, pkgFees, pkgSize)
+			return
+		}
+		mp.evictionIndex.update(entry, entry.pkgFees-txDesc.Fee,
+			entry.pkgSize-txDesc.TxSize)
+	})
+}
+
+// wouldBeEvicted returns whether or not adding the provided transaction to the
+// main pool would cause the pool to exceed its maximum size such that the
+// transaction itself would be evicted again immediately.
+//
+// This is determined without modifying the pool by visiting the eviction
+// candidates in order of their fee rates until enough space would be freed and
+// checking whether the transaction or one of its ancestors would be reached
+// first.  Note that the fee rates of the ancestors of the transaction are
+// increased by adding it to the pool, so they are ranked accordingly.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) wouldBeEvicted(txDesc *TxDesc) bool {
+	maxSize := mp.cfg.Policy.MaxPoolSize
+	needed := mp.totalSize + txDesc.TxSize - maxSize
+	if maxSize <= 0 || needed <= 0 || !isEvictionCandidate(txDesc.Type) {
+		return false
+	}
+
+	// The transaction would be evicted as soon as the eviction reaches its own
+	// fee rate or the lowest fee rate of its ancestors once it is added.
+	threshold := calcEvictionFeeRate(txDesc, txDesc.Fee, txDesc.TxSize)
+	ancestors := make(map[chainhash.Hash]struct{})
+	mp.forEachPoolAncestor(txDesc.Tx, func(ancestor *TxDesc) {
+		ancestorHash := ancestor.Tx.Hash()
+		ancestors[*ancestorHash] = struct{}{}
+		entry := mp.evictionIndex.lookup(ancestorHash)
+		if entry == nil {
+			return
+		}
+		feeRate := calcEvictionFeeRate(ancestor, entry.pkgFees+txDesc.Fee,
+			entry.pkgSize+txDesc.TxSize)
+		if feeRate < threshold {
+			threshold = feeRate
+		}
+	})
+
+	// Visit the eviction candidates in order of their fee rates and add the
+	// size of their packages to the freed space until there is enough room
+	// for the transaction or the threshold is reached.  The ancestors are
+	// skipped since they are accounted for by the threshold.  The visited
+	// entries are restored once done.
+	var visited []*evictionEntry
+	defer func() {
+		for _, entry := range visited {
+			mp.evictionIndex.restore(entry)
+		}
+	}()
+	freed := make(map[chainhash.Hash]struct{})
+	var freedSize int64
+	for freedSize < needed {
+		entry := mp.evictionIndex.popLowest()
+		if entry == nil {
+			return true
+		}
+		visited = append(visited, entry)
+		if entry.feeRate >= threshold {
+			return true
+		}
+		entryHash := entry.txDesc.Tx.Hash()
+		if _, ok := ancestors[*entryHash]; ok {
+			continue
+		}
+		if _, ok := freed[*entryHash]; ok {
+			continue
+		}
+
+		// Account for the entire package since evicting a transaction also
+		// evicts all of its descendants.
+		freed[*entryHash] = struct{}{}
+		freedSize += entry.txDesc.TxSize
+		queue := []*TxDesc{entry.txDesc}
+		for len(queue) > 0 {
+			desc := queue[0]
+			queue = queue[1:]
+			mp.forEachRedeemer(desc.Tx, func(redeemer *TxDesc) {
+				redeemerHash := redeemer.Tx.Hash()
+				if _, ok := freed[*redeemerHash]; ok {
+					return
+				}
+				freed[*redeemerHash] = struct{}{}
+				freedSize += redeemer.TxSize
+				queue = append(queue, redeemer)
+			})
+		}
+	}
+	return false
+}
+
+// limitPoolSize evicts the transaction packages with the lowest fee rates until
+// the total size of the main pool no longer exceeds the configured maximum and
+// raises the rolling minimum relay fee accordingly.  Only regular transactions
+// and ticket purchases are considered for eviction since the remaining stake
+// transactions are an integral part of the block production process.  It
+// returns whether or not any transactions were evicted.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) limitPoolSize(now time.Time) bool {
+	maxSize := mp.cfg.Policy.MaxPoolSize
+	if maxSize <= 0 {
+		return false
+	}
+
+	// Evict the packages in order of their fee rates.  Note that the eviction
+	// index is updated as transactions are removed, so evicting a package
+	// lowers the fee rates of its ancestors accordingly.
+	var evicted bool
+	for mp.totalSize > maxSize {
+		entry := mp.evictionIndex.lowest()
+		if entry == nil {
+			break
+		}
+
+		// Raise the rolling minimum fee so that transactions must pay more
+		// than the evicted package, including the incremental relay fee, in
+		// order to be accepted.
+		feeRate := entry.feeRate
+		newMinFee := feeRate + float64(mp.cfg.Policy.MinRelayTxFee)
+		if newMinFee > float64(mp.minRelayFee(now)) {
+			mp.rollingMinFee = newMinFee
+			mp.lastRollingFeeUpdate = now
+		}
+
+		tx := entry.txDesc.Tx
+		numBefore := len(mp.pool)
+		mp.removeTransaction(tx, true)
+		numRemoved := numBefore - len(mp.pool)
+		mp.numEvicted += uint64(numRemoved)
+		evicted = true
+
+		log.Debugf("Evicted transaction %v and %d descendant(s) with fee "+
+			"rate %.0f atoms/kB (pool size: %d bytes)", tx.Hash(),
+			numRemoved-1, feeRate, mp.totalSize)
+	}
+
+	return evicted
+}
+
+// MaxSize returns the configured maximum total serialized size in bytes of all
+// transactions in the main pool.  A value of zero indicates there is no limit.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) MaxSize() int64 {
+	return mp.cfg.Policy.MaxPoolSize
+}
+
+// NumEvicted returns the total number of transactions that have been evicted
+// from the main pool due to the maximum pool size.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) NumEvicted() uint64 {
+	mp.mtx.RLock()
+	numEvicted := mp.numEvicted
+	mp.mtx.RUnlock()
+	return numEvicted
+}
+
 // findTx returns a transaction from the mempool by hash.  If it does not exist
 // in the mempool, a nil pointer is returned.
 func (mp *TxPool) findTx(txHash *chainhash.Hash) *mining.TxDesc {
@@ -906,12 +1301,14 @@ func (mp *TxPool) addTransaction(utxoView *blockchain.UtxoViewpoint, txDesc *TxD
 	// Add the transaction to the pool and mark the referenced outpoints
 	// as spent by the pool.
 	mp.pool[*txHash] = txDesc
+	mp.totalSize += txDesc.TxSize
 	mp.miningView.AddTransaction(&txDesc.TxDesc, mp.findTx)
 
 	msgTx := tx.MsgTx()
 	for _, txIn := range msgTx.TxIn {
 		mp.outpoints[txIn.PreviousOutPoint] = txDesc
 	}
+	mp.addToEvictionIndex(txDesc)
 	mp.lastUpdated.Store(time.Now().Unix())
 
 	// Add unconfirmed exists address index entries associated with the
@@ -920,6 +1317,12 @@ func (mp *TxPool) addTransaction(utxoView *blockchain.UtxoViewpoint, txDesc *TxD
 		mp.cfg.ExistsAddrIndex.AddUnconfirmedTx(msgTx)
 	}
 
+	// Add unconfirmed address index entries associated with the transaction
+	// if enabled.
+	if mp.cfg.AddrIndex != nil {
+		mp.cfg.AddrIndex.AddUnconfirmedTx(tx, utxoView)
+	}
+
 	// Inform the associated fee estimator that a new transaction has been added
 	// to the mempool.
 	if mp.cfg.AddTxToFeeEstimation != nil {
@@ -1106,6 +1509,28 @@ func (mp *TxPool) FetchTransaction(txHash *chainhash.Hash) (*dcrutil.Tx, error)
 	return nil, fmt.Errorf("transaction is not in the pool")
 }
 
+// FetchSpendingTx returns the transaction in the main or stage transaction
+// pools that spends the provided outpoint.  Nil is returned when no
+// transaction in those pools spends it.  Orphans are not included.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) FetchSpendingTx(outpoint *wire.OutPoint) *dcrutil.Tx {
+	// Protect concurrent access.
+	mp.mtx.RLock()
+	txDesc, exists := mp.outpoints[*outpoint]
+	if !exists {
+		// Attempt to fetch the transaction from the stage pool.
+		txDesc, exists = mp.stagedOutpoints[*outpoint]
+	}
+	mp.mtx.RUnlock()
+
+	if exists {
+		return txDesc.Tx
+	}
+
+	return nil
+}
+
 // newTxDesc returns a new TxDesc instance that captures mempool state
 // relevant to the provided transaction at the current time.
 func (mp *TxPool) newTxDesc(utxoView *blockchain.UtxoViewpoint, tx *dcrutil.Tx,
@@ -1124,6 +1549,18 @@ func (mp *TxPool) newTxDesc(utxoView *blockchain.UtxoViewpoint, tx *dcrutil.Tx,
 	}
 }
 
+// setTxTag sets the tag of the transaction with the provided hash in either
+// the main pool or the stage pool when it exists.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) setTxTag(txHash *chainhash.Hash, tag Tag) {
+	if txDesc, ok := mp.pool[*txHash]; ok {
+		txDesc.Tag = tag
+	} else if txDesc, ok := mp.staged[*txHash]; ok {
+		txDesc.Tag = tag
+	}
+}
+
 // maybeUnstageTransaction attempts to bring the staged transaction into the
 // main pool. Note that this does not perform all preliminary checks on the
 // transaction re-entering the main pool since the transaction must have already
@@ -1595,10 +2032,17 @@ func (mp *TxPool) maybeAcceptTransaction(tx *dcrutil.Tx, isNew, allowHighFees,
 	// - Treasurybases (rejected from the mempool anyway)
 	// - Revocations (automatic revocations never in the mempool anyway)
 	// - Votes
+	//
+	// Regular transactions and ticket purchases are additionally subject to
+	// the rolling minimum relay fee that is raised when transactions are
+	// evicted due to the pool size limit.
 	isTreasuryAdd := isTreasuryEnabled && txType == stake.TxTypeTAdd
 	serializedSize := int64(msgTx.SerializeSize())
-	minFee := calcMinRequiredTxRelayFee(serializedSize,
-		mp.cfg.Policy.MinRelayTxFee)
+	minRelayFee := mp.cfg.Policy.MinRelayTxFee
+	if txType == stake.TxTypeRegular || isTicket {
+		minRelayFee = mp.minRelayFee(time.Now())
+	}
+	minFee := calcMinRequiredTxRelayFee(serializedSize, minRelayFee)
 	if txFee < minFee && (txType == stake.TxTypeRegular || isTicket ||
 		isTreasuryAdd || isTSpend) {
 
@@ -1742,6 +2186,15 @@ func (mp *TxPool) maybeAcceptTransaction(tx *dcrutil.Tx, isNew, allowHighFees,
 		return nil, nil
 	}
 
+	// Reject the transaction without adding it to the pool when the pool is
+	// full and the transaction would be evicted again immediately due to its
+	// fee rate.
+	if mp.wouldBeEvicted(txDesc) {
+		str := fmt.Sprintf("transaction %v was not accepted because its fee "+
+			"rate is too low to fit in the full mempool", txHash)
+		return nil, txRuleError(ErrMempoolFull, str)
+	}
+
 	// Add to transaction pool.
 	mp.addTransaction(utxoView, txDesc)
 
@@ -1771,6 +2224,17 @@ func (mp *TxPool) maybeAcceptTransaction(tx *dcrutil.Tx, isNew, allowHighFees,
 		mp.tspends[*txHash] = tx
 	}
 
+	// Evict the lowest fee rate transaction packages when the pool exceeds
+	// the maximum size.  The transaction was already determined to not be
+	// evicted as a result above, however, evicting packages lowers the fee
+	// rates of their ancestors which might change the order in rare cases, so
+	// reject the transaction when it was evicted regardless.
+	if mp.limitPoolSize(time.Now()) && !mp.isTransactionInPool(txHash) {
+		str := fmt.Sprintf("transaction %v was not accepted because its fee "+
+			"rate is too low to fit in the full mempool", txHash)
+		return nil, txRuleError(ErrMempoolFull, str)
+	}
+
 	log.Debugf("Accepted transaction %v (pool size: %v)", txHash,
 		len(mp.pool))
 
@@ -1976,6 +2440,9 @@ func (mp *TxPool) processOrphans(acceptedTx *dcrutil.Tx, checkTxFlags blockchain
 				// transactions to process so any orphans that
 				// depend on it are handled too.
 				acceptedTxns = append(acceptedTxns, tx)
+				if otx, ok := mp.orphans[*tx.Hash()]; ok {
+					mp.setTxTag(tx.Hash(), otx.tag)
+				}
 				mp.removeOrphan(tx, false)
 				processList = append(processList, tx)
 
@@ -2170,6 +2637,8 @@ func (mp *TxPool) ProcessTransaction(tx *dcrutil.Tx, allowOrphan, allowHighFees
 
 	// If len(missingParents) == 0 then we know the tx is NOT an orphan.
 	if len(missingParents) == 0 {
+		mp.setTxTag(tx.Hash(), tag)
+
 		// Accept any orphan transactions that depend on this
 		// transaction (they may no longer be orphans if all inputs
 		// are now available) and repeat for those accepted
@@ -2336,6 +2805,7 @@ func New(cfg *Config) *TxPool {
 		staged:          make(map[chainhash.Hash]*TxDesc),
 		stagedOutpoints: make(map[wire.OutPoint]*TxDesc),
 		transient:       make(map[chainhash.Hash]*dcrutil.Tx),
+		evictionIndex:   newEvictionIndex(),
 	}
 
 	// for a given transaction, scan the mempool to find which transactions
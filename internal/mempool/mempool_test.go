@@ -2216,6 +2216,47 @@ func TestMempoolDoubleSpend(t *testing.T) {
 	testPoolMembership(tc, doubleSpendTx, false, false)
 }
 
+// TestFetchSpendingTx ensures that the transaction in the pool that spends an
+// outpoint is returned by FetchSpendingTx and that nil is returned once it is
+// removed.
+func TestFetchSpendingTx(t *testing.T) {
+	t.Parallel()
+
+	harness, spendableOuts, err := newPoolHarness(chaincfg.MainNetParams())
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+
+	// Ensure no transaction is returned for an outpoint that is not spent by
+	// the pool.
+	outpoint := &spendableOuts[0].outPoint
+	if spendTx := harness.txPool.FetchSpendingTx(outpoint); spendTx != nil {
+		t.Fatalf("FetchSpendingTx: unexpected tx %v", spendTx.Hash())
+	}
+
+	// Add a transaction that spends the outpoint and ensure it is returned.
+	tx, err := harness.CreateTx(spendableOuts[0])
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	_, err = harness.txPool.ProcessTransaction(tx, false, true, 0)
+	if err != nil {
+		t.Fatalf("ProcessTransaction: failed to accept tx: %v", err)
+	}
+	spendTx := harness.txPool.FetchSpendingTx(outpoint)
+	if spendTx == nil || *spendTx.Hash() != *tx.Hash() {
+		t.Fatalf("FetchSpendingTx: unexpected tx: got %v, want %v", spendTx,
+			tx.Hash())
+	}
+
+	// Remove the transaction and ensure it is no longer returned.
+	harness.txPool.RemoveTransaction(tx, true)
+	if spendTx := harness.txPool.FetchSpendingTx(outpoint); spendTx != nil {
+		t.Fatalf("FetchSpendingTx: unexpected tx %v after removal",
+			spendTx.Hash())
+	}
+}
+
 // TestFetchTransaction ensures that a ticket which spends an output in the
 // mempool is returned by FetchTransaction.
 func TestFetchTransaction(t *testing.T) {
@@ -3501,3 +3542,197 @@ func TestMaybeAcceptTransactions(t *testing.T) {
 
 	testExpectedAncestorFee(txC, txAFee+txBFee)
 }
+
+// testEvictionIndex ensures the eviction index of the provided pool contains
+// exactly the eviction candidates in the main pool along with the current
+// statistics of their packages.
+func testEvictionIndex(t *testing.T, txPool *TxPool) {
+	t.Helper()
+
+	txPool.mtx.RLock()
+	defer txPool.mtx.RUnlock()
+
+	var numCandidates int
+	for txHash, txDesc := range txPool.pool {
+		entry := txPool.evictionIndex.lookup(&txHash)
+		if !isEvictionCandidate(txDesc.Type) {
+			if entry != nil {
+				t.Fatalf("tx %v of type %v is in the eviction index", txHash,
+					txDesc.Type)
+			}
+			continue
+		}
+		numCandidates++
+		if entry == nil {
+			t.Fatalf("tx %v is not in the eviction index", txHash)
+		}
+		pkgFees, pkgSize := txPool.calcPackageStats(txDesc)
+		if entry.pkgFees != pkgFees || entry.pkgSize != pkgSize {
+			t.Fatalf("mismatched package stats for tx %v -- got fees %d, "+
+				"size %d, want fees %d, size %d", txHash, entry.pkgFees,
+				entry.pkgSize, pkgFees, pkgSize)
+		}
+	}
+	if len(txPool.evictionIndex.entries) != numCandidates ||
+		len(txPool.evictionIndex.heap) != numCandidates {
+
+		t.Fatalf("mismatched number of eviction index entries -- got %d "+
+			"(heap %d), want %d", len(txPool.evictionIndex.entries),
+			len(txPool.evictionIndex.heap), numCandidates)
+	}
+}
+
+// TestPoolSizeLimit ensures that the pool evicts the transaction packages with
+// the lowest fee rates when it exceeds the maximum size, that the minimum relay
+// fee is raised as a result and decays over time, and that transactions which
+// would immediately be evicted are rejected.
+func TestPoolSizeLimit(t *testing.T) {
+	t.Parallel()
+
+	harness, spendableOuts, err := newPoolHarness(chaincfg.MainNetParams())
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	tc := &testContext{t, harness}
+	txPool := harness.txPool
+
+	// Split the spendable output provided by the harness into several mined
+	// outputs to spend from.
+	splitTx, err := harness.CreateSignedTx(spendableOuts, 5)
+	if err != nil {
+		t.Fatalf("unable to create split transaction: %v", err)
+	}
+	harness.AddFakeUTXO(splitTx, harness.chain.BestHeight(), 0)
+	var outs []spendableOutput
+	for i := uint32(0); i < 5; i++ {
+		outs = append(outs, txOutToSpendableOut(splitTx, i, wire.TxTreeRegular))
+	}
+
+	// createTx creates a transaction that spends the provided output and pays
+	// the provided fee in addition to the minimum required fee.
+	createTx := func(out spendableOutput, extraFee int64) *dcrutil.Tx {
+		t.Helper()
+
+		tx, err := harness.CreateSignedTx([]spendableOutput{out}, 1,
+			func(tx *wire.MsgTx) {
+				tx.TxOut[0].Value -= extraFee
+			})
+		if err != nil {
+			t.Fatalf("unable to create transaction: %v", err)
+		}
+		return tx
+	}
+	mustAccept := func(tx *dcrutil.Tx) {
+		t.Helper()
+
+		_, err := txPool.ProcessTransaction(tx, false, true, 0)
+		if err != nil {
+			t.Fatalf("ProcessTransaction: failed to accept tx: %v", err)
+		}
+	}
+
+	// Add a transaction paying the minimum fee, a transaction paying the
+	// minimum fee with a child that pays a high fee, and a transaction paying
+	// a moderate fee to the pool without any size limit.
+	txLow := createTx(outs[0], 0)
+	parent := createTx(outs[1], 0)
+	child := createTx(txOutToSpendableOut(parent, 0, wire.TxTreeRegular), 30000)
+	txMid := createTx(outs[2], 10000)
+	for _, tx := range []*dcrutil.Tx{txLow, parent, child, txMid} {
+		mustAccept(tx)
+	}
+	testEvictionIndex(t, txPool)
+	if txPool.MinRelayFee() != txPool.cfg.Policy.MinRelayTxFee {
+		t.Fatalf("unexpected min relay fee %v before evictions",
+			txPool.MinRelayFee())
+	}
+
+	// Limit the pool to its current size with a little bit of slack to
+	// account for slight variations in the signature sizes and ensure that
+	// adding a transaction with a higher fee rate evicts the transaction
+	// with the lowest fee rate, while the parent paying the minimum fee is
+	// kept due to its child.
+	policyFee := txPool.cfg.Policy.MinRelayTxFee
+	txPool.cfg.Policy.MaxPoolSize = txPool.totalSize + 10
+	txHigh := createTx(outs[3], 20000)
+	mustAccept(txHigh)
+	testPoolMembership(tc, txLow, false, false)
+	for _, tx := range []*dcrutil.Tx{parent, child, txMid, txHigh} {
+		testPoolMembership(tc, tx, false, true)
+	}
+	if txPool.NumEvicted() != 1 {
+		t.Fatalf("unexpected number of evicted txns: got %d, want 1",
+			txPool.NumEvicted())
+	}
+	if txPool.totalSize > txPool.MaxSize() {
+		t.Fatalf("pool size %d exceeds max size %d", txPool.totalSize,
+			txPool.MaxSize())
+	}
+	testEvictionIndex(t, txPool)
+
+	// Ensure the minimum relay fee was raised to at least the fee rate of the
+	// evicted transaction, which pays the policy minimum, plus the policy
+	// minimum as the incremental fee and that a transaction paying the policy
+	// minimum fee is now rejected.
+	if minRelayFee := txPool.MinRelayFee(); minRelayFee < policyFee*2 {
+		t.Fatalf("min relay fee %v was not raised to at least %v",
+			minRelayFee, policyFee*2)
+	}
+	txBase := createTx(outs[4], 0)
+	_, err = txPool.ProcessTransaction(txBase, false, true, 0)
+	if !errors.Is(err, ErrInsufficientFee) {
+		t.Fatalf("unexpected error for min fee tx in full pool: %v", err)
+	}
+	testPoolMembership(tc, txBase, false, false)
+
+	// Ensure a transaction paying more than the raised minimum relay fee, but
+	// that has the lowest fee rate in the full pool, is rejected without being
+	// added to the pool first, so it is neither counted as evicted nor passed
+	// to the fee estimator.
+	var feeEstimationTxns []chainhash.Hash
+	txPool.cfg.AddTxToFeeEstimation = func(txHash *chainhash.Hash, fee,
+		size int64, txType stake.TxType) {
+
+		feeEstimationTxns = append(feeEstimationTxns, *txHash)
+	}
+	txLowish := createTx(outs[4], 1000)
+	_, err = txPool.ProcessTransaction(txLowish, false, true, 0)
+	if !errors.Is(err, ErrMempoolFull) {
+		t.Fatalf("unexpected error for lowest fee rate tx in full pool: %v",
+			err)
+	}
+	testPoolMembership(tc, txLowish, false, false)
+	if txPool.NumEvicted() != 1 {
+		t.Fatalf("unexpected number of evicted txns: got %d, want 1",
+			txPool.NumEvicted())
+	}
+	if len(feeEstimationTxns) != 0 {
+		t.Fatalf("rejected tx was added to fee estimation: %v",
+			feeEstimationTxns)
+	}
+	testEvictionIndex(t, txPool)
+
+	// Ensure the eviction index is updated when a transaction is removed
+	// without its redeemers, such as when it is mined, and when it is removed
+	// along with them.
+	txPool.RemoveTransaction(parent, false)
+	testPoolMembership(tc, child, false, true)
+	testEvictionIndex(t, txPool)
+	txPool.RemoveTransaction(txMid, true)
+	testEvictionIndex(t, txPool)
+
+	// Ensure the raised minimum relay fee decays back to the policy minimum.
+	minRelayFee := txPool.MinRelayFee()
+	txPool.mtx.Lock()
+	halfLifeFee := txPool.minRelayFee(time.Now().Add(rollingMinFeeHalfLife))
+	decayedFee := txPool.minRelayFee(time.Now().Add(rollingMinFeeHalfLife * 20))
+	txPool.mtx.Unlock()
+	if halfLifeFee >= minRelayFee {
+		t.Fatalf("min relay fee %v did not decay from %v", halfLifeFee,
+			minRelayFee)
+	}
+	if decayedFee != policyFee {
+		t.Fatalf("min relay fee %v did not decay to policy fee %v",
+			decayedFee, policyFee)
+	}
+}
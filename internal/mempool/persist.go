@@ -0,0 +1,290 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrutil/v4"
+	"github.com/decred/dcrd/wire"
+)
+
+const (
+	// persistVersion is the current version of the serialization format used
+	// to persist the contents of the pool.
+	persistVersion = 1
+
+	// maxPersistedTxns is the maximum number of transactions that will be
+	// loaded from persisted pool contents.  It protects against excessive
+	// memory usage due to corrupt or malicious files.
+	maxPersistedTxns = 500000
+)
+
+// persistedTx houses a transaction along with its associated metadata as read
+// from persisted pool contents.
+type persistedTx struct {
+	tx    *dcrutil.Tx
+	added time.Time
+	tag   Tag
+}
+
+// Dump writes the transactions in the main and stage pools along with the tags
+// and times they were added to the provided writer in a versioned format
+// suitable for reading with Load.  Transactions are written in the order they
+// were added to the pool so that transactions that depend on other
+// transactions in the pool are written after their dependencies.  It returns
+// the number of transactions that were written.
+//
+// The serialized format is:
+//
+//	<version><num txns><added><tag><tx>...
+//
+//	Field       Type      Size
+//	version     uint32    4 bytes
+//	num txns    uint64    8 bytes
+//	added       int64     8 bytes (unix nanoseconds)
+//	tag         uint64    8 bytes
+//	tx          MsgTx     variable
+//
+// The added, tag, and tx fields are repeated for each transaction.  All
+// integers are encoded in little endian.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) Dump(w io.Writer) (int, error) {
+	mp.mtx.RLock()
+	descs := make([]*TxDesc, 0, len(mp.pool)+len(mp.staged))
+	for _, desc := range mp.pool {
+		descs = append(descs, desc)
+	}
+	for _, desc := range mp.staged {
+		descs = append(descs, desc)
+	}
+	mp.mtx.RUnlock()
+
+	sort.Slice(descs, func(i, j int) bool {
+		return descs[i].Added.Before(descs[j].Added)
+	})
+
+	bw := bufio.NewWriter(w)
+	var buf [8]byte
+	binary.LittleEndian.PutUint32(buf[:4], persistVersion)
+	if _, err := bw.Write(buf[:4]); err != nil {
+		return 0, err
+	}
+	binary.LittleEndian.PutUint64(buf[:], uint64(len(descs)))
+	if _, err := bw.Write(buf[:]); err != nil {
+		return 0, err
+	}
+	for _, desc := range descs {
+		binary.LittleEndian.PutUint64(buf[:], uint64(desc.Added.UnixNano()))
+		if _, err := bw.Write(buf[:]); err != nil {
+			return 0, err
+		}
+		binary.LittleEndian.PutUint64(buf[:], uint64(desc.Tag))
+		if _, err := bw.Write(buf[:]); err != nil {
+			return 0, err
+		}
+		if err := desc.Tx.MsgTx().Serialize(bw); err != nil {
+			return 0, err
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return 0, err
+	}
+
+	return len(descs), nil
+}
+
+// readPersistedTxns reads transactions along with their associated metadata
+// from the provided reader which must be in the format written by Dump.
+func readPersistedTxns(r io.Reader) ([]persistedTx, error) {
+	br := bufio.NewReader(r)
+	var buf [8]byte
+	if _, err := io.ReadFull(br, buf[:4]); err != nil {
+		return nil, fmt.Errorf("unable to read version: %w", err)
+	}
+	version := binary.LittleEndian.Uint32(buf[:4])
+	if version != persistVersion {
+		return nil, fmt.Errorf("unsupported mempool serialization version %d",
+			version)
+	}
+	if _, err := io.ReadFull(br, buf[:]); err != nil {
+		return nil, fmt.Errorf("unable to read number of transactions: %w",
+			err)
+	}
+	numTxns := binary.LittleEndian.Uint64(buf[:])
+	if numTxns > maxPersistedTxns {
+		return nil, fmt.Errorf("number of transactions %d exceeds the max "+
+			"allowed %d", numTxns, maxPersistedTxns)
+	}
+
+	txns := make([]persistedTx, 0, numTxns)
+	for i := uint64(0); i < numTxns; i++ {
+		if _, err := io.ReadFull(br, buf[:]); err != nil {
+			return nil, fmt.Errorf("unable to read time added: %w", err)
+		}
+		added := int64(binary.LittleEndian.Uint64(buf[:]))
+		if _, err := io.ReadFull(br, buf[:]); err != nil {
+			return nil, fmt.Errorf("unable to read tag: %w", err)
+		}
+		tag := Tag(binary.LittleEndian.Uint64(buf[:]))
+		var msgTx wire.MsgTx
+		if err := msgTx.Deserialize(br); err != nil {
+			return nil, fmt.Errorf("unable to read transaction: %w", err)
+		}
+		txns = append(txns, persistedTx{
+			tx:    dcrutil.NewTx(&msgTx),
+			added: time.Unix(0, added),
+			tag:   tag,
+		})
+	}
+
+	return txns, nil
+}
+
+// restoreTxMetadata restores the time added and tag of a transaction that was
+// loaded from persisted pool contents.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) restoreTxMetadata(txHash *chainhash.Hash, added time.Time, tag Tag) {
+	mp.mtx.Lock()
+	txDesc, ok := mp.pool[*txHash]
+	if !ok {
+		txDesc, ok = mp.staged[*txHash]
+	}
+	if ok {
+		txDesc.Added = added
+		txDesc.Tag = tag
+	}
+	mp.mtx.Unlock()
+}
+
+// Load reads transactions in the format written by Dump from the provided
+// reader and attempts to add them to the pool via MaybeAcceptTransaction so
+// they are fully revalidated against the current state of the chain.  The
+// tags and times the transactions were originally added to the pool are
+// restored for the ones that are accepted.
+//
+// It returns the number of transactions that were accepted and the number that
+// were rejected, for example because they were mined or otherwise became
+// invalid in the meantime.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) Load(r io.Reader) (int, int, error) {
+	txns, err := readPersistedTxns(r)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// Attempt to accept the transactions in the order they were originally
+	// added and retry the ones that are still missing inputs as long as any
+	// progress is made since transactions that depend on others might
+	// otherwise be processed before their dependencies.
+	var accepted, failed int
+	for len(txns) > 0 {
+		var retry []persistedTx
+		for _, ptx := range txns {
+			missing, err := mp.MaybeAcceptTransaction(ptx.tx, true)
+			if err != nil {
+				log.Debugf("Unable to load transaction %v: %v", ptx.tx.Hash(),
+					err)
+				failed++
+				continue
+			}
+			if len(missing) > 0 {
+				retry = append(retry, ptx)
+				continue
+			}
+			mp.restoreTxMetadata(ptx.tx.Hash(), ptx.added, ptx.tag)
+			accepted++
+		}
+		if len(retry) == len(txns) {
+			break
+		}
+		txns = retry
+	}
+	for _, ptx := range txns {
+		log.Debugf("Unable to load transaction %v: missing inputs",
+			ptx.tx.Hash())
+		failed++
+	}
+
+	return accepted, failed, nil
+}
+
+// DumpFile writes the contents of the pool to the file at the provided path as
+// described by Dump.  The file is written atomically by first writing to a
+// uniquely-named temporary file in the same directory and then moving it into
+// place.  Concurrent calls are serialized so the file always contains the
+// contents of the pool as of the most recent call.  It returns the number of
+// transactions that were written.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) DumpFile(path string) (int, error) {
+	mp.dumpMtx.Lock()
+	defer mp.dumpMtx.Unlock()
+
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.new")
+	if err != nil {
+		return 0, err
+	}
+	tmpPath := f.Name()
+	numTxns, err := mp.Dump(f)
+	if err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return 0, err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return 0, err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmpPath)
+		return 0, err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return 0, err
+	}
+
+	log.Infof("Saved %d transactions to %s", numTxns, path)
+	return numTxns, nil
+}
+
+// LoadFile loads the contents of the pool from the file at the provided path
+// as described by Load.  A missing file is not treated as an error and results
+// in no transactions being loaded.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) LoadFile(path string) (int, int, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	accepted, failed, err := mp.Load(f)
+	if err != nil {
+		return 0, 0, fmt.Errorf("unable to load %s: %w", path, err)
+	}
+
+	log.Infof("Loaded %d transactions from %s (%d rejected)", accepted, path,
+		failed)
+	return accepted, failed, nil
+}
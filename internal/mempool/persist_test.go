@@ -0,0 +1,197 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg/v3"
+)
+
+// TestDumpLoad ensures that the contents of the pool can be dumped and loaded
+// again along with their tags and the times they were added, that transactions
+// which are no longer valid are rejected when loading, and that invalid
+// serialized data is detected.
+func TestDumpLoad(t *testing.T) {
+	t.Parallel()
+
+	harness, spendableOuts, err := newPoolHarness(chaincfg.MainNetParams())
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	tc := &testContext{t, harness}
+	txPool := harness.txPool
+
+	// Create a chain of transactions rooted with the first spendable output
+	// provided by the harness and add them to the pool with unique tags.
+	chainedTxns, err := harness.CreateTxChain(spendableOuts[0], 3)
+	if err != nil {
+		t.Fatalf("unable to create transaction chain: %v", err)
+	}
+	for i, tx := range chainedTxns {
+		_, err := txPool.ProcessTransaction(tx, false, true, Tag(i+1))
+		if err != nil {
+			t.Fatalf("ProcessTransaction: failed to accept tx: %v", err)
+		}
+	}
+	wantAdded := make(map[Tag]time.Time)
+	for _, desc := range txPool.TxDescs() {
+		wantAdded[desc.Tag] = desc.Added
+	}
+
+	// Dump the pool contents and ensure the expected number of transactions
+	// is reported.
+	var buf bytes.Buffer
+	numTxns, err := txPool.Dump(&buf)
+	if err != nil {
+		t.Fatalf("Dump: unexpected error: %v", err)
+	}
+	if numTxns != len(chainedTxns) {
+		t.Fatalf("Dump: unexpected number of txns: got %d, want %d", numTxns,
+			len(chainedTxns))
+	}
+	serialized := buf.Bytes()
+
+	// Remove the transactions from the pool, load them again, and ensure they
+	// are all accepted with their original tags and times added.
+	txPool.RemoveTransaction(chainedTxns[0], true)
+	for _, tx := range chainedTxns {
+		testPoolMembership(tc, tx, false, false)
+	}
+	accepted, rejected, err := txPool.Load(bytes.NewReader(serialized))
+	if err != nil {
+		t.Fatalf("Load: unexpected error: %v", err)
+	}
+	if accepted != len(chainedTxns) || rejected != 0 {
+		t.Fatalf("Load: unexpected result: got %d accepted and %d rejected, "+
+			"want %d accepted and 0 rejected", accepted, rejected,
+			len(chainedTxns))
+	}
+	for _, tx := range chainedTxns {
+		testPoolMembership(tc, tx, false, true)
+	}
+	for _, desc := range txPool.TxDescs() {
+		added, ok := wantAdded[desc.Tag]
+		if !ok {
+			t.Fatalf("unexpected tag %d for tx %v", desc.Tag, desc.Tx.Hash())
+		}
+		if !desc.Added.Equal(added) {
+			t.Fatalf("unexpected time added for tx %v: got %v, want %v",
+				desc.Tx.Hash(), desc.Added, added)
+		}
+	}
+
+	// Replace the transactions in the pool with a double spend of the first
+	// one and ensure loading them again rejects all of them since the first
+	// one is now a double spend and the others depend on it.
+	txPool.RemoveTransaction(chainedTxns[0], true)
+	doubleSpend, err := harness.CreateSignedTx(spendableOuts, 2)
+	if err != nil {
+		t.Fatalf("unable to create double spend tx: %v", err)
+	}
+	_, err = txPool.ProcessTransaction(doubleSpend, false, true, 0)
+	if err != nil {
+		t.Fatalf("ProcessTransaction: failed to accept tx: %v", err)
+	}
+	accepted, rejected, err = txPool.Load(bytes.NewReader(serialized))
+	if err != nil {
+		t.Fatalf("Load: unexpected error: %v", err)
+	}
+	if accepted != 0 || rejected != len(chainedTxns) {
+		t.Fatalf("Load: unexpected result: got %d accepted and %d rejected, "+
+			"want 0 accepted and %d rejected", accepted, rejected,
+			len(chainedTxns))
+	}
+	testPoolMembership(tc, doubleSpend, false, true)
+
+	// Ensure an unsupported version and truncated data are rejected.
+	badVersion := append([]byte{0xff}, serialized[1:]...)
+	if _, _, err := txPool.Load(bytes.NewReader(badVersion)); err == nil {
+		t.Fatal("Load: did not error on unsupported version")
+	}
+	truncated := serialized[:len(serialized)-1]
+	if _, _, err := txPool.Load(bytes.NewReader(truncated)); err == nil {
+		t.Fatal("Load: did not error on truncated data")
+	}
+}
+
+// TestDumpLoadFile ensures that the contents of the pool can be saved to and
+// loaded from a file and that a missing file is not treated as an error.
+func TestDumpLoadFile(t *testing.T) {
+	t.Parallel()
+
+	harness, spendableOuts, err := newPoolHarness(chaincfg.MainNetParams())
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	tc := &testContext{t, harness}
+	txPool := harness.txPool
+	path := filepath.Join(t.TempDir(), "mempool.dat")
+
+	// Ensure loading a missing file does not load anything.
+	accepted, rejected, err := txPool.LoadFile(path)
+	if err != nil || accepted != 0 || rejected != 0 {
+		t.Fatalf("LoadFile: unexpected result for missing file: %d accepted, "+
+			"%d rejected, err %v", accepted, rejected, err)
+	}
+
+	// Save a transaction to the file, remove it from the pool, and ensure it
+	// is accepted again when loading the file.
+	tx, err := harness.CreateTx(spendableOuts[0])
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	_, err = txPool.ProcessTransaction(tx, false, true, 0)
+	if err != nil {
+		t.Fatalf("ProcessTransaction: failed to accept tx: %v", err)
+	}
+	numTxns, err := txPool.DumpFile(path)
+	if err != nil || numTxns != 1 {
+		t.Fatalf("DumpFile: unexpected result: %d txns, err %v", numTxns, err)
+	}
+	txPool.RemoveTransaction(tx, true)
+	accepted, rejected, err = txPool.LoadFile(path)
+	if err != nil || accepted != 1 || rejected != 0 {
+		t.Fatalf("LoadFile: unexpected result: %d accepted, %d rejected, "+
+			"err %v", accepted, rejected, err)
+	}
+	testPoolMembership(tc, tx, false, true)
+	if _, err := txPool.FetchTransaction(tx.Hash()); err != nil {
+		t.Fatalf("FetchTransaction: unexpected error: %v", err)
+	}
+
+	// Ensure concurrently saving the pool to the same file succeeds, does not
+	// leave any temporary files behind, and results in a valid file.
+	const numDumps = 8
+	errs := make(chan error, numDumps)
+	for i := 0; i < numDumps; i++ {
+		go func() {
+			_, err := txPool.DumpFile(path)
+			errs <- err
+		}()
+	}
+	for i := 0; i < numDumps; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("DumpFile: unexpected concurrent error: %v", err)
+		}
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("unable to read dir: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name() != filepath.Base(path) {
+		t.Fatalf("unexpected files after concurrent dumps: %v", entries)
+	}
+	txPool.RemoveTransaction(tx, true)
+	accepted, rejected, err = txPool.LoadFile(path)
+	if err != nil || accepted != 1 || rejected != 0 {
+		t.Fatalf("LoadFile: unexpected result after concurrent dumps: %d "+
+			"accepted, %d rejected, err %v", accepted, rejected, err)
+	}
+}
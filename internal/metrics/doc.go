@@ -0,0 +1,23 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package metrics provides a minimal registry of metrics that is able to expose
+them in the Prometheus text-based exposition format.
+
+Tests are included to ensure proper functionality.
+
+# Feature Overview
+
+The following are the primary features provided:
+
+  - Counters and gauges with values that are either set directly or obtained
+    from a function when the metrics are written
+  - Gauges with multiple labeled values obtained from a function
+  - Histograms with configurable buckets, including collections of histograms
+    partitioned by a label and histograms obtained from a function
+  - Writing all registered metrics in the text-based exposition format
+  - Serving all registered metrics over HTTP
+*/
+package metrics
@@ -0,0 +1,538 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ContentType is the HTTP content type of the text-based exposition format
+// written by the registry.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// metricNameRegex is the regular expression that all metric and label names
+// must match.
+var metricNameRegex = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// metricType identifies the type of a metric in the exposition format.
+type metricType string
+
+// These constants define the supported metric types.
+const (
+	typeCounter   metricType = "counter"
+	typeGauge     metricType = "gauge"
+	typeHistogram metricType = "histogram"
+)
+
+// writer wraps a buffered writer to write metric samples in the text-based
+// exposition format while tracking the first error that occurs.
+type writer struct {
+	w   *bufio.Writer
+	err error
+}
+
+// formatFloat returns the provided value formatted per the text-based
+// exposition format.
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// labelValueEscaper escapes label values per the text-based exposition format.
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+// helpEscaper escapes help text per the text-based exposition format.
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+// header writes the help and type lines of a metric.
+func (w *writer) header(name, help string, typ metricType) {
+	if w.err != nil {
+		return
+	}
+	_, w.err = fmt.Fprintf(w.w, "# HELP %s %s\n# TYPE %s %s\n", name,
+		helpEscaper.Replace(help), name, typ)
+}
+
+// sample writes a single sample with the provided name, label pairs, and
+// value.  The label pairs are alternating label names and values.
+func (w *writer) sample(name string, value float64, labelPairs ...string) {
+	if w.err != nil {
+		return
+	}
+	w.w.WriteString(name)
+	if len(labelPairs) > 0 {
+		w.w.WriteByte('{')
+		for i := 0; i+1 < len(labelPairs); i += 2 {
+			if i > 0 {
+				w.w.WriteByte(',')
+			}
+			w.w.WriteString(labelPairs[i])
+			w.w.WriteString(`="`)
+			w.w.WriteString(labelValueEscaper.Replace(labelPairs[i+1]))
+			w.w.WriteByte('"')
+		}
+		w.w.WriteByte('}')
+	}
+	w.w.WriteByte(' ')
+	w.w.WriteString(formatFloat(value))
+	_, w.err = w.w.WriteString("\n")
+}
+
+// metric is the interface implemented by all metrics which are able to write
+// their samples to a writer.
+type metric interface {
+	write(w *writer, name string)
+}
+
+// family describes a registered metric along with its metadata.
+type family struct {
+	name   string
+	help   string
+	typ    metricType
+	metric metric
+}
+
+// Registry houses a collection of metrics and provides the ability to write
+// them in the text-based exposition format.  It implements http.Handler so
+// that it may be served directly.
+//
+// The metrics are written in the order they are registered.
+type Registry struct {
+	mtx      sync.Mutex
+	families []*family
+	names    map[string]struct{}
+}
+
+// NewRegistry returns a new empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		names: make(map[string]struct{}),
+	}
+}
+
+// register adds the provided metric to the registry.  It panics when the name
+// is invalid or already registered since that is a programming error.
+func (r *Registry) register(name, help string, typ metricType, m metric) {
+	if !metricNameRegex.MatchString(name) {
+		panic(fmt.Sprintf("invalid metric name %q", name))
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if _, ok := r.names[name]; ok {
+		panic(fmt.Sprintf("metric %q is already registered", name))
+	}
+	r.names[name] = struct{}{}
+	r.families = append(r.families, &family{
+		name:   name,
+		help:   help,
+		typ:    typ,
+		metric: m,
+	})
+}
+
+// Write writes all registered metrics to the provided writer in the
+// text-based exposition format.
+//
+// This function is safe for concurrent access.
+func (r *Registry) Write(w io.Writer) error {
+	r.mtx.Lock()
+	families := make([]*family, len(r.families))
+	copy(families, r.families)
+	r.mtx.Unlock()
+
+	mw := &writer{w: bufio.NewWriter(w)}
+	for _, f := range families {
+		mw.header(f.name, f.help, f.typ)
+		f.metric.write(mw, f.name)
+	}
+	if mw.err != nil {
+		return mw.err
+	}
+	return mw.w.Flush()
+}
+
+// ServeHTTP writes all registered metrics to the HTTP client in the text-based
+// exposition format.
+//
+// This is part of the http.Handler interface.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", ContentType)
+	r.Write(w)
+}
+
+// Counter is a metric with a value that only ever increases.
+//
+// All methods are safe for concurrent access.
+type Counter struct {
+	bits atomic.Uint64
+}
+
+// NewCounter registers and returns a new counter with the provided name and
+// help text.
+func (r *Registry) NewCounter(name, help string) *Counter {
+	c := new(Counter)
+	r.register(name, help, typeCounter, c)
+	return c
+}
+
+// Add increases the counter by the provided value which must not be negative.
+func (c *Counter) Add(v float64) {
+	if v < 0 {
+		return
+	}
+	for {
+		oldBits := c.bits.Load()
+		newBits := math.Float64bits(math.Float64frombits(oldBits) + v)
+		if c.bits.CompareAndSwap(oldBits, newBits) {
+			return
+		}
+	}
+}
+
+// Inc increases the counter by one.
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Value returns the current value of the counter.
+func (c *Counter) Value() float64 {
+	return math.Float64frombits(c.bits.Load())
+}
+
+// write writes the counter sample to the provided writer.
+//
+// This is part of the metric interface.
+func (c *Counter) write(w *writer, name string) {
+	w.sample(name, c.Value())
+}
+
+// Gauge is a metric with a value that may arbitrarily increase and decrease.
+//
+// All methods are safe for concurrent access.
+type Gauge struct {
+	bits atomic.Uint64
+}
+
+// NewGauge registers and returns a new gauge with the provided name and help
+// text.
+func (r *Registry) NewGauge(name, help string) *Gauge {
+	g := new(Gauge)
+	r.register(name, help, typeGauge, g)
+	return g
+}
+
+// Set sets the gauge to the provided value.
+func (g *Gauge) Set(v float64) {
+	g.bits.Store(math.Float64bits(v))
+}
+
+// Value returns the current value of the gauge.
+func (g *Gauge) Value() float64 {
+	return math.Float64frombits(g.bits.Load())
+}
+
+// write writes the gauge sample to the provided writer.
+//
+// This is part of the metric interface.
+func (g *Gauge) write(w *writer, name string) {
+	w.sample(name, g.Value())
+}
+
+// valueFunc is a metric with a value that is obtained from a function each time
+// the metric is written.
+type valueFunc func() float64
+
+// write writes the sample returned by the function to the provided writer.
+//
+// This is part of the metric interface.
+func (f valueFunc) write(w *writer, name string) {
+	w.sample(name, f())
+}
+
+// NewCounterFunc registers a counter with the provided name and help text
+// whose value is obtained by invoking the provided function each time the
+// metrics are written.  The function must return values that only ever
+// increase and must be safe for concurrent access.
+func (r *Registry) NewCounterFunc(name, help string, fn func() float64) {
+	r.register(name, help, typeCounter, valueFunc(fn))
+}
+
+// NewGaugeFunc registers a gauge with the provided name and help text whose
+// value is obtained by invoking the provided function each time the metrics are
+// written.  The function must be safe for concurrent access.
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.register(name, help, typeGauge, valueFunc(fn))
+}
+
+// LabeledValue is a value along with the values of the labels that identify
+// it.
+type LabeledValue struct {
+	LabelValues []string
+	Value       float64
+}
+
+// labeledValuesFunc is a metric with multiple labeled values that are obtained
+// from a function each time the metric is written.
+type labeledValuesFunc struct {
+	labels []string
+	fn     func() []LabeledValue
+}
+
+// write writes the labeled samples returned by the function to the provided
+// writer.
+//
+// This is part of the metric interface.
+func (f *labeledValuesFunc) write(w *writer, name string) {
+	for _, v := range f.fn() {
+		labelPairs := make([]string, 0, len(f.labels)*2)
+		for i, label := range f.labels {
+			var value string
+			if i < len(v.LabelValues) {
+				value = v.LabelValues[i]
+			}
+			labelPairs = append(labelPairs, label, value)
+		}
+		w.sample(name, v.Value, labelPairs...)
+	}
+}
+
+// NewGaugeVecFunc registers a gauge with the provided name, help text, and
+// label names whose labeled values are obtained by invoking the provided
+// function each time the metrics are written.  The label values of each
+// returned value must be in the same order as the label names.  The function
+// must be safe for concurrent access.
+func (r *Registry) NewGaugeVecFunc(name, help string, labels []string, fn func() []LabeledValue) {
+	for _, label := range labels {
+		if !metricNameRegex.MatchString(label) || label == "le" {
+			panic(fmt.Sprintf("invalid label name %q", label))
+		}
+	}
+	r.register(name, help, typeGauge, &labeledValuesFunc{labels, fn})
+}
+
+// HistogramSnapshot houses the state of a histogram at a given point in time.
+type HistogramSnapshot struct {
+	// UpperBounds are the inclusive upper bounds of the buckets in increasing
+	// order.  There is an additional implicit bucket with an upper bound of
+	// +Inf.
+	UpperBounds []float64
+
+	// Counts are the number of observations in each bucket.  It has one more
+	// entry than the upper bounds for the implicit +Inf bucket.  Note that the
+	// counts are not cumulative.
+	Counts []uint64
+
+	// Sum is the sum of all observed values.
+	Sum float64
+}
+
+// NewHistogramSnapshot returns a histogram snapshot with the provided upper
+// bounds and no observations.  It panics when the upper bounds are not in
+// strictly increasing order since that is a programming error.
+func NewHistogramSnapshot(upperBounds []float64) *HistogramSnapshot {
+	for i := 1; i < len(upperBounds); i++ {
+		if upperBounds[i] <= upperBounds[i-1] {
+			panic("histogram upper bounds must be in increasing order")
+		}
+	}
+	if n := len(upperBounds); n > 0 && math.IsInf(upperBounds[n-1], 1) {
+		upperBounds = upperBounds[:n-1]
+	}
+	return &HistogramSnapshot{
+		UpperBounds: upperBounds,
+		Counts:      make([]uint64, len(upperBounds)+1),
+	}
+}
+
+// Observe adds the provided value to the snapshot.
+func (h *HistogramSnapshot) Observe(v float64) {
+	idx := sort.SearchFloat64s(h.UpperBounds, v)
+	h.Counts[idx]++
+	h.Sum += v
+}
+
+// write writes the histogram samples to the provided writer.  The provided
+// label pairs are included in every sample.
+func (h *HistogramSnapshot) write(w *writer, name string, labelPairs ...string) {
+	bucketName := name + "_bucket"
+	bucketLabels := make([]string, len(labelPairs), len(labelPairs)+2)
+	copy(bucketLabels, labelPairs)
+	bucketLabels = append(bucketLabels, "le", "")
+	var cumulative uint64
+	for i, count := range h.Counts {
+		cumulative += count
+		upperBound := math.Inf(1)
+		if i < len(h.UpperBounds) {
+			upperBound = h.UpperBounds[i]
+		}
+		bucketLabels[len(bucketLabels)-1] = formatFloat(upperBound)
+		w.sample(bucketName, float64(cumulative), bucketLabels...)
+	}
+	w.sample(name+"_sum", h.Sum, labelPairs...)
+	w.sample(name+"_count", float64(cumulative), labelPairs...)
+}
+
+// Histogram is a metric that counts observed values in configurable buckets
+// along with the sum and total number of observations.
+//
+// All methods are safe for concurrent access.
+type Histogram struct {
+	mtx  sync.Mutex
+	snap *HistogramSnapshot
+}
+
+// newHistogram returns a new histogram with the provided bucket upper bounds.
+func newHistogram(upperBounds []float64) *Histogram {
+	return &Histogram{snap: NewHistogramSnapshot(upperBounds)}
+}
+
+// NewHistogram registers and returns a new histogram with the provided name,
+// help text, and bucket upper bounds.  The upper bounds must be in strictly
+// increasing order.
+func (r *Registry) NewHistogram(name, help string, upperBounds []float64) *Histogram {
+	h := newHistogram(upperBounds)
+	r.register(name, help, typeHistogram, h)
+	return h
+}
+
+// Observe adds the provided value to the histogram.
+func (h *Histogram) Observe(v float64) {
+	h.mtx.Lock()
+	h.snap.Observe(v)
+	h.mtx.Unlock()
+}
+
+// ObserveDuration adds the provided duration to the histogram in seconds.
+func (h *Histogram) ObserveDuration(d time.Duration) {
+	h.Observe(d.Seconds())
+}
+
+// Snapshot returns a copy of the current state of the histogram.
+func (h *Histogram) Snapshot() *HistogramSnapshot {
+	h.mtx.Lock()
+	snap := &HistogramSnapshot{
+		UpperBounds: h.snap.UpperBounds,
+		Counts:      make([]uint64, len(h.snap.Counts)),
+		Sum:         h.snap.Sum,
+	}
+	copy(snap.Counts, h.snap.Counts)
+	h.mtx.Unlock()
+	return snap
+}
+
+// write writes the histogram samples to the provided writer.
+//
+// This is part of the metric interface.
+func (h *Histogram) write(w *writer, name string) {
+	h.Snapshot().write(w, name)
+}
+
+// histogramFunc is a histogram metric with a state that is obtained from a
+// function each time the metric is written.
+type histogramFunc func() *HistogramSnapshot
+
+// write writes the samples of the histogram returned by the function to the
+// provided writer.
+//
+// This is part of the metric interface.
+func (f histogramFunc) write(w *writer, name string) {
+	f().write(w, name)
+}
+
+// NewHistogramFunc registers a histogram with the provided name and help text
+// whose state is obtained by invoking the provided function each time the
+// metrics are written.  This is useful for histograms that describe the
+// current state of a collection rather than individual events.  The function
+// must be safe for concurrent access.
+func (r *Registry) NewHistogramFunc(name, help string, fn func() *HistogramSnapshot) {
+	r.register(name, help, typeHistogram, histogramFunc(fn))
+}
+
+// HistogramVec is a collection of histograms that share the same bucket upper
+// bounds and are partitioned by the value of a label.
+//
+// All methods are safe for concurrent access.
+type HistogramVec struct {
+	label       string
+	upperBounds []float64
+
+	mtx        sync.Mutex
+	histograms map[string]*Histogram
+}
+
+// NewHistogramVec registers and returns a new collection of histograms with
+// the provided name, help text, label name, and bucket upper bounds.  The upper
+// bounds must be in strictly increasing order.
+func (r *Registry) NewHistogramVec(name, help, label string, upperBounds []float64) *HistogramVec {
+	if !metricNameRegex.MatchString(label) || label == "le" {
+		panic(fmt.Sprintf("invalid label name %q", label))
+	}
+	v := &HistogramVec{
+		label:       label,
+		upperBounds: NewHistogramSnapshot(upperBounds).UpperBounds,
+		histograms:  make(map[string]*Histogram),
+	}
+	r.register(name, help, typeHistogram, v)
+	return v
+}
+
+// With returns the histogram for the provided label value creating it when it
+// does not already exist.
+func (v *HistogramVec) With(labelValue string) *Histogram {
+	v.mtx.Lock()
+	h, ok := v.histograms[labelValue]
+	if !ok {
+		h = newHistogram(v.upperBounds)
+		v.histograms[labelValue] = h
+	}
+	v.mtx.Unlock()
+	return h
+}
+
+// write writes the samples of all histograms in the collection to the provided
+// writer ordered by their label values.
+//
+// This is part of the metric interface.
+func (v *HistogramVec) write(w *writer, name string) {
+	v.mtx.Lock()
+	labelValues := make([]string, 0, len(v.histograms))
+	for labelValue := range v.histograms {
+		labelValues = append(labelValues, labelValue)
+	}
+	histograms := make(map[string]*Histogram, len(v.histograms))
+	for labelValue, h := range v.histograms {
+		histograms[labelValue] = h
+	}
+	v.mtx.Unlock()
+
+	sort.Strings(labelValues)
+	for _, labelValue := range labelValues {
+		histograms[labelValue].Snapshot().write(w, name, v.label, labelValue)
+	}
+}
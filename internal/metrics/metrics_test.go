@@ -0,0 +1,173 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package metrics
+
+import (
+	"bytes"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestRegistryWrite ensures the registry writes all supported metric types in
+// the expected text-based exposition format.
+func TestRegistryWrite(t *testing.T) {
+	r := NewRegistry()
+	counter := r.NewCounter("test_counter_total", "A test counter.")
+	counter.Inc()
+	counter.Add(2.5)
+	counter.Add(-1) // Negative values are ignored.
+
+	gauge := r.NewGauge("test_gauge", "A test gauge\nwith a newline.")
+	gauge.Set(-3)
+
+	r.NewCounterFunc("test_counter_func_total", "A test counter func.",
+		func() float64 { return 10 })
+	r.NewGaugeFunc("test_gauge_func", "A test gauge func.",
+		func() float64 { return math.Inf(1) })
+	r.NewGaugeVecFunc("test_gauge_vec", "A test gauge vec.",
+		[]string{"dir", "kind"}, func() []LabeledValue {
+			return []LabeledValue{
+				{LabelValues: []string{"in", `a"b`}, Value: 1},
+				{LabelValues: []string{"out", `c\d`}, Value: 2},
+			}
+		})
+
+	hist := r.NewHistogram("test_histogram_seconds", "A test histogram.",
+		[]float64{0.1, 1, math.Inf(1)})
+	hist.Observe(0.1)
+	hist.ObserveDuration(500 * time.Millisecond)
+	hist.Observe(5)
+
+	r.NewHistogramFunc("test_histogram_func", "A test histogram func.",
+		func() *HistogramSnapshot {
+			snap := NewHistogramSnapshot([]float64{10})
+			snap.Observe(20)
+			return snap
+		})
+
+	vec := r.NewHistogramVec("test_histogram_vec_seconds",
+		"A test histogram vec.", "method", []float64{1})
+	vec.With("b").Observe(2)
+	vec.With("a").Observe(0.5)
+
+	want := `# HELP test_counter_total A test counter.
+# TYPE test_counter_total counter
+test_counter_total 3.5
+# HELP test_gauge A test gauge\nwith a newline.
+# TYPE test_gauge gauge
+test_gauge -3
+# HELP test_counter_func_total A test counter func.
+# TYPE test_counter_func_total counter
+test_counter_func_total 10
+# HELP test_gauge_func A test gauge func.
+# TYPE test_gauge_func gauge
+test_gauge_func +Inf
+# HELP test_gauge_vec A test gauge vec.
+# TYPE test_gauge_vec gauge
+test_gauge_vec{dir="in",kind="a\"b"} 1
+test_gauge_vec{dir="out",kind="c\\d"} 2
+# HELP test_histogram_seconds A test histogram.
+# TYPE test_histogram_seconds histogram
+test_histogram_seconds_bucket{le="0.1"} 1
+test_histogram_seconds_bucket{le="1"} 2
+test_histogram_seconds_bucket{le="+Inf"} 3
+test_histogram_seconds_sum 5.6
+test_histogram_seconds_count 3
+# HELP test_histogram_func A test histogram func.
+# TYPE test_histogram_func histogram
+test_histogram_func_bucket{le="10"} 0
+test_histogram_func_bucket{le="+Inf"} 1
+test_histogram_func_sum 20
+test_histogram_func_count 1
+# HELP test_histogram_vec_seconds A test histogram vec.
+# TYPE test_histogram_vec_seconds histogram
+test_histogram_vec_seconds_bucket{method="a",le="1"} 1
+test_histogram_vec_seconds_bucket{method="a",le="+Inf"} 1
+test_histogram_vec_seconds_sum{method="a"} 0.5
+test_histogram_vec_seconds_count{method="a"} 1
+test_histogram_vec_seconds_bucket{method="b",le="1"} 0
+test_histogram_vec_seconds_bucket{method="b",le="+Inf"} 1
+test_histogram_vec_seconds_sum{method="b"} 2
+test_histogram_vec_seconds_count{method="b"} 1
+`
+	var buf bytes.Buffer
+	if err := r.Write(&buf); err != nil {
+		t.Fatalf("unexpected error writing metrics: %v", err)
+	}
+	if got := buf.String(); got != want {
+		t.Fatalf("unexpected metrics output:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Ensure serving the metrics over HTTP returns the same output with the
+	// expected content type.
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status code -- got %d, want %d", rec.Code,
+			http.StatusOK)
+	}
+	if got := rec.Header().Get("Content-Type"); got != ContentType {
+		t.Fatalf("unexpected content type -- got %q, want %q", got,
+			ContentType)
+	}
+	if got := rec.Body.String(); got != want {
+		t.Fatalf("unexpected served metrics:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Ensure methods other than GET and HEAD are rejected.
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/metrics", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("unexpected status code -- got %d, want %d", rec.Code,
+			http.StatusMethodNotAllowed)
+	}
+}
+
+// TestRegistryPanics ensures registering metrics with invalid or duplicate
+// names and histograms with invalid bucket upper bounds panics.
+func TestRegistryPanics(t *testing.T) {
+	tests := []struct {
+		name string          // test description
+		fn   func(*Registry) // function expected to panic
+	}{{
+		name: "invalid metric name",
+		fn:   func(r *Registry) { r.NewCounter("0invalid", "") },
+	}, {
+		name: "duplicate metric name",
+		fn: func(r *Registry) {
+			r.NewCounter("dup", "")
+			r.NewGauge("dup", "")
+		},
+	}, {
+		name: "reserved label name",
+		fn: func(r *Registry) {
+			r.NewHistogramVec("vec", "", "le", []float64{1})
+		},
+	}, {
+		name: "invalid label name",
+		fn: func(r *Registry) {
+			r.NewGaugeVecFunc("vec", "", []string{"a-b"}, nil)
+		},
+	}, {
+		name: "unordered upper bounds",
+		fn: func(r *Registry) {
+			r.NewHistogram("hist", "", []float64{2, 1})
+		},
+	}}
+
+	for _, test := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%q: did not panic", test.name)
+				}
+			}()
+			test.fn(NewRegistry())
+		}()
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package netsync
+
+import (
+	"fmt"
+
+	"github.com/decred/dcrd/blockchain/standalone/v2"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/internal/mempool"
+	"github.com/decred/dcrd/wire"
+)
+
+// partialBlock houses a block that is being reconstructed from a compact block
+// along with the indexes of the transactions in each tree that are still
+// missing.
+type partialBlock struct {
+	hash         chainhash.Hash
+	header       wire.BlockHeader
+	txns         []*wire.MsgTx
+	stxns        []*wire.MsgTx
+	missing      []uint32
+	missingStake []uint32
+	numFromPool  int
+	numPrefilled int
+	numRequested int
+	numTotalTxns int
+}
+
+// fillTxTree returns the transactions of a transaction tree with the provided
+// number of transactions that is represented by the provided prefilled
+// transactions and short IDs along with the indexes of the transactions that
+// could not be found in the provided candidates keyed by their short IDs.
+//
+// The prefilled transactions must be ordered by their index and the total
+// number of prefilled transactions and short IDs must be the provided number
+// of transactions which is guaranteed for decoded messages.
+func fillTxTree(numTxns int, prefilled []wire.PrefilledTx, shortIDs []uint64, candidates map[uint64]*wire.MsgTx) ([]*wire.MsgTx, []uint32) {
+	txns := make([]*wire.MsgTx, numTxns)
+	var missing []uint32
+	var prefilledIdx, shortIDIdx int
+	for i := range txns {
+		if prefilledIdx < len(prefilled) &&
+			int(prefilled[prefilledIdx].Index) == i {
+
+			txns[i] = prefilled[prefilledIdx].Tx
+			prefilledIdx++
+			continue
+		}
+
+		tx := candidates[shortIDs[shortIDIdx]]
+		shortIDIdx++
+		if tx == nil {
+			missing = append(missing, uint32(i))
+			continue
+		}
+		txns[i] = tx
+	}
+	return txns, missing
+}
+
+// newPartialBlock attempts to reconstruct the block represented by the provided
+// compact block using the provided mempool transactions and returns the result
+// as a partial block that tracks any transactions that are still missing.
+//
+// Any mempool transactions that share a short ID are treated as missing since
+// it is not possible to determine which one is the correct one.
+func newPartialBlock(msg *wire.MsgCmpctBlock, txDescs []*mempool.TxDesc) *partialBlock {
+	k0, k1 := msg.ShortTxIDKeys()
+	candidates := make(map[uint64]*wire.MsgTx, len(txDescs))
+	for _, txDesc := range txDescs {
+		shortID := wire.ShortTxID(k0, k1, txDesc.Tx.Hash())
+		if _, ok := candidates[shortID]; ok {
+			candidates[shortID] = nil
+			continue
+		}
+		candidates[shortID] = txDesc.Tx.MsgTx()
+	}
+
+	// Note that the votes, tickets, and revocations in the stake tree are
+	// reconstructed from the mempool the same as the regular transactions
+	// since they are relayed independently ahead of the block.
+	txns, missing := fillTxTree(msg.TxCount(), msg.PrefilledTxns,
+		msg.ShortIDs, candidates)
+	stxns, missingStake := fillTxTree(msg.STxCount(), msg.StakePrefilledTxns,
+		msg.StakeShortIDs, candidates)
+	numPrefilled := len(msg.PrefilledTxns) + len(msg.StakePrefilledTxns)
+	numTotalTxns := len(txns) + len(stxns)
+	numMissing := len(missing) + len(missingStake)
+	return &partialBlock{
+		hash:         msg.Header.BlockHash(),
+		header:       msg.Header,
+		txns:         txns,
+		stxns:        stxns,
+		missing:      missing,
+		missingStake: missingStake,
+		numFromPool:  numTotalTxns - numPrefilled - numMissing,
+		numPrefilled: numPrefilled,
+		numTotalTxns: numTotalTxns,
+	}
+}
+
+// isComplete returns whether or not all of the transactions of the block are
+// available.
+func (pb *partialBlock) isComplete() bool {
+	return len(pb.missing) == 0 && len(pb.missingStake) == 0
+}
+
+// fillMissing fills the missing transactions of the block with the provided
+// transactions in the order they were requested.  An error is returned when
+// the number of provided transactions does not match the number of missing
+// transactions.
+func (pb *partialBlock) fillMissing(txns, stxns []*wire.MsgTx) error {
+	if len(txns) != len(pb.missing) || len(stxns) != len(pb.missingStake) {
+		return fmt.Errorf("got %d regular and %d stake transactions when "+
+			"%d regular and %d stake transactions were requested", len(txns),
+			len(stxns), len(pb.missing), len(pb.missingStake))
+	}
+
+	for i, index := range pb.missing {
+		pb.txns[index] = txns[i]
+	}
+	for i, index := range pb.missingStake {
+		pb.stxns[index] = stxns[i]
+	}
+	pb.numRequested = len(txns) + len(stxns)
+	pb.missing = nil
+	pb.missingStake = nil
+	return nil
+}
+
+// msgBlock returns the reconstructed block.  It must only be called once the
+// partial block is complete.
+func (pb *partialBlock) msgBlock() *wire.MsgBlock {
+	return &wire.MsgBlock{
+		Header:        pb.header,
+		Transactions:  pb.txns,
+		STransactions: pb.stxns,
+	}
+}
+
+// merkleRootsMatch returns whether or not the transactions of the provided
+// block commit to the merkle root(s) in its header either with or without the
+// header commitments agenda active.
+//
+// It is used to detect blocks that were reconstructed incorrectly due to short
+// ID collisions prior to processing them so that the valid block is not marked
+// as having failed validation.
+func merkleRootsMatch(block *wire.MsgBlock) bool {
+	header := &block.Header
+	combinedRoot := standalone.CalcCombinedTxTreeMerkleRoot(
+		block.Transactions, block.STransactions)
+	if header.MerkleRoot == combinedRoot {
+		return true
+	}
+
+	return header.MerkleRoot == standalone.CalcTxTreeMerkleRoot(
+		block.Transactions) && header.StakeRoot ==
+		standalone.CalcTxTreeMerkleRoot(block.STransactions)
+}
@@ -7,8 +7,12 @@ Package netsync implements a concurrency safe block syncing protocol.
 
 The provided implementation of SyncManager communicates with connected peers to
 perform an initial block download, keep the chain in sync, and announce new
-blocks connected to the chain.  Currently the sync manager selects a single sync
-peer that it downloads all blocks from until it is up to date with the longest
-chain the sync peer is aware of.
+blocks connected to the chain.  The sync manager selects a single sync peer
+that it downloads the headers of the longest chain it is aware of from and then
+downloads the associated blocks from multiple peers in parallel.  Blocks are
+requested in order of their height within a sliding window past the current
+tip, giving preference to the peers with the highest measured throughput, and
+peers that stall the download are disconnected with their outstanding requests
+reassigned to other peers.
 */
 package netsync
@@ -0,0 +1,462 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package netsync
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/decred/dcrd/blockchain/standalone/v2"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/gcs/v4"
+	"github.com/decred/dcrd/gcs/v4/blockcf2"
+	"github.com/decred/dcrd/internal/blockchain"
+	"github.com/decred/dcrd/wire"
+)
+
+const (
+	// maxInFlightFilters is the maximum number of committed filters to allow
+	// in flight from the peer the filters are being synced from when running
+	// in light mode.
+	maxInFlightFilters = 500
+
+	// maxCachedFilters is the maximum number of the most recently synced
+	// committed filters to keep in memory when running in light mode.  The
+	// cached filters are served via FilterByBlockHash.
+	maxCachedFilters = 2048
+)
+
+// cfilterV2Msg packages a decred cfilterv2 message and the peer it came from
+// together so the sync manager has access to that information.
+type cfilterV2Msg struct {
+	filter *wire.MsgCFilterV2
+	peer   *Peer
+}
+
+// lightFilter houses a committed filter that was synced in light mode along
+// with its header commitment inclusion proof.
+type lightFilter struct {
+	filter *gcs.FilterV2
+	proof  blockchain.HeaderProof
+}
+
+// lightSyncState houses the state used to sync the committed filters for the
+// best known headers, and the blocks they match, when running in light mode.
+type lightSyncState struct {
+	// watchScripts houses the scripts to match against the committed filters
+	// in order to determine which blocks to download.
+	watchScripts [][]byte
+
+	// verifyProofs indicates whether or not the header commitment inclusion
+	// proofs of the filters are verified for blocks at or after
+	// cmtActivationHeight.
+	verifyProofs        bool
+	cmtActivationHeight int64
+
+	// filterTip and filterTipHeight track the most recent header in the
+	// branch of the best known header the committed filter has been synced
+	// for.
+	filterTip       chainhash.Hash
+	filterTipHeight int64
+
+	// filtersSynced tracks whether or not the committed filters have been
+	// synced to the best known header at least once.
+	filtersSynced bool
+
+	// filterPeer is the peer the filters are being synced from and
+	// inFlightFilters houses the blocks the filters have been requested for in
+	// the order they were requested.
+	filterPeer         *Peer
+	inFlightFilters    []chainhash.Hash
+	lastFilterProgress time.Time
+
+	// pendingBlocks houses the blocks matched by the filters that have not yet
+	// been received along with the peer they were requested from.  A nil peer
+	// indicates the block still needs to be requested.
+	pendingBlocks map[chainhash.Hash]*Peer
+
+	// The following fields house the most recently synced filters.  They are
+	// protected by the mutex since they are queried from outside the event
+	// handler goroutine.
+	filtersMtx  sync.RWMutex
+	filters     map[chainhash.Hash]*lightFilter
+	filterOrder []chainhash.Hash
+}
+
+// makeLightSyncState returns a light sync state initialized to sync the
+// committed filters from the genesis block of the network defined by the
+// provided parameters and to match them against the provided scripts.
+//
+// The header commitment inclusion proofs of the filters are only verified once
+// the header commitments agenda is active and the point at which it activated
+// is only known from the parameters on some networks.  See
+// blockchain.HeaderCmtActivationHeight for details.
+func makeLightSyncState(params *chaincfg.Params, watchScripts [][]byte) lightSyncState {
+	cmtActivationHeight, verifyProofs := blockchain.HeaderCmtActivationHeight(params)
+	return lightSyncState{
+		watchScripts:        watchScripts,
+		verifyProofs:        verifyProofs,
+		cmtActivationHeight: cmtActivationHeight,
+		filterTip:           params.GenesisHash,
+		pendingBlocks:       make(map[chainhash.Hash]*Peer),
+		filters:             make(map[chainhash.Hash]*lightFilter),
+	}
+}
+
+// logFilterVerification logs the extent to which the committed filters are
+// verified against the block headers.  Filters for blocks prior to the
+// activation of the header commitments agenda can't be verified since the
+// headers do not commit to them, so they are trusted as received from the peer
+// they are synced from, which is able to omit matches for the watched scripts.
+func (state *lightSyncState) logFilterVerification() {
+	if !state.verifyProofs {
+		log.Warn("The activation point of header commitments is unknown on " +
+			"this network, so committed filters are NOT verified and are " +
+			"trusted as received from the peer they are synced from")
+		return
+	}
+	if state.cmtActivationHeight > 1 {
+		log.Infof("Committed filters for blocks prior to the activation of "+
+			"header commitments at height %d are NOT verified and are trusted "+
+			"as received from the peer they are synced from",
+			state.cmtActivationHeight)
+	}
+}
+
+// verifyFilterProof returns whether or not the provided committed filter is
+// committed to by the provided block header via the provided header commitment
+// inclusion proof.  Filters for blocks prior to the activation of header
+// commitments, or for all blocks when the activation point is not known, are
+// not able to be verified and are therefore always considered valid.
+func (state *lightSyncState) verifyFilterProof(header *wire.BlockHeader, filter *gcs.FilterV2, proofIndex uint32, proofHashes []chainhash.Hash) bool {
+	if !state.verifyProofs || int64(header.Height) < state.cmtActivationHeight {
+		return true
+	}
+
+	filterHash := filter.Hash()
+	return proofIndex == blockchain.HeaderCmtFilterIndex &&
+		standalone.VerifyInclusionProof(&header.StakeRoot, &filterHash,
+			proofIndex, proofHashes)
+}
+
+// addFilter adds the provided filter and proof to the cache of recently synced
+// filters while evicting the oldest one when the cache is full.
+//
+// This function is safe for concurrent access.
+func (state *lightSyncState) addFilter(hash *chainhash.Hash, filter *lightFilter) {
+	state.filtersMtx.Lock()
+	if _, ok := state.filters[*hash]; !ok {
+		if len(state.filterOrder) >= maxCachedFilters {
+			delete(state.filters, state.filterOrder[0])
+			state.filterOrder = state.filterOrder[1:]
+		}
+		state.filterOrder = append(state.filterOrder, *hash)
+	}
+	state.filters[*hash] = filter
+	state.filtersMtx.Unlock()
+}
+
+// releaseLightRequests releases the filters and blocks that were in flight from
+// the provided peer so they are requested from another peer.
+//
+// This function is NOT safe for concurrent access.  It must be called from the
+// event handler goroutine.
+func (m *SyncManager) releaseLightRequests(peer *Peer) {
+	state := &m.lightState
+	if state.filterPeer == peer {
+		state.filterPeer = nil
+		state.inFlightFilters = nil
+	}
+	for hash, p := range state.pendingBlocks {
+		if p == peer {
+			state.pendingBlocks[hash] = nil
+			delete(m.requestedBlocks, hash)
+			delete(peer.requestedBlocks, hash)
+		}
+	}
+}
+
+// maybeUpdateFiltersSynced updates the manager to signal it believes it is
+// synced once the committed filters are synced to the best known header after
+// the initial headers sync is done.
+//
+// This function is NOT safe for concurrent access.  It must be called from the
+// event handler goroutine.
+func (m *SyncManager) maybeUpdateFiltersSynced() {
+	state := &m.lightState
+	if !m.hdrSyncState.headersSynced || len(state.inFlightFilters) != 0 {
+		return
+	}
+	bestHeaderHash, _ := m.cfg.Chain.BestHeader()
+	if state.filterTip != bestHeaderHash {
+		return
+	}
+
+	if !state.filtersSynced {
+		state.filtersSynced = true
+		log.Infof("Committed filters synced (hash %s, height %d)",
+			state.filterTip, state.filterTipHeight)
+	}
+	m.isCurrentMtx.Lock()
+	m.isCurrent = true
+	m.isCurrentMtx.Unlock()
+}
+
+// fetchNextFilters creates and sends requests for the next committed filters
+// to be synced based on the current headers along with any blocks matched by
+// the filters that still need to be requested.  It is used in place of
+// fetchNextBlocks when running in light mode.
+//
+// The filters are requested from the sync peer in order of their height.  When
+// the headers the filters were synced for are no longer part of the branch of
+// the best known header, the filter tip is rewound to the fork point once the
+// filters in flight have been received.
+//
+// This function is NOT safe for concurrent access.  It must be called from the
+// event handler goroutine.
+func (m *SyncManager) fetchNextFilters() {
+	peer := m.syncPeer
+	if peer == nil {
+		return
+	}
+	state := &m.lightState
+	if state.filterPeer != peer {
+		state.filterPeer = peer
+		state.inFlightFilters = nil
+	}
+
+	// Request any matched blocks that were released from other peers.
+	var gdmsg *wire.MsgGetData
+	for hash, p := range state.pendingBlocks {
+		if p != nil {
+			continue
+		}
+		if gdmsg == nil {
+			gdmsg = wire.NewMsgGetData()
+		}
+		hash := hash
+		gdmsg.AddInvVect(wire.NewInvVect(wire.InvTypeBlock, &hash))
+		state.pendingBlocks[hash] = peer
+		m.requestedBlocks[hash] = struct{}{}
+		peer.requestedBlocks[hash] = struct{}{}
+	}
+	if gdmsg != nil {
+		peer.QueueMessage(gdmsg, nil)
+	}
+
+	// Determine the next headers to request filters for starting after the
+	// final filter in flight or the filter tip when there are none.
+	numNeeded := maxInFlightFilters - len(state.inFlightFilters)
+	if numNeeded <= 0 {
+		return
+	}
+	from := state.filterTip
+	if numInFlight := len(state.inFlightFilters); numInFlight > 0 {
+		from = state.inFlightFilters[numInFlight-1]
+	}
+	chain := m.cfg.Chain
+	fork, hashes, err := chain.HeaderHashesAfter(&from, numNeeded)
+	if err != nil {
+		log.Errorf("Unable to determine next committed filters: %v", err)
+		return
+	}
+	if fork != from {
+		// Wait for the filters in flight to be received before rewinding.
+		if len(state.inFlightFilters) > 0 {
+			return
+		}
+
+		header, err := chain.HeaderByHash(&fork)
+		if err != nil {
+			log.Errorf("Unable to rewind committed filters: %v", err)
+			return
+		}
+		log.Debugf("Rewinding committed filter tip from %s (height %d) to "+
+			"%s (height %d)", state.filterTip, state.filterTipHeight, fork,
+			header.Height)
+		state.filterTip = fork
+		state.filterTipHeight = int64(header.Height)
+	}
+	if len(hashes) == 0 {
+		m.maybeUpdateFiltersSynced()
+		return
+	}
+
+	if len(state.inFlightFilters) == 0 {
+		state.lastFilterProgress = time.Now()
+	}
+	for i := range hashes {
+		peer.QueueMessage(wire.NewMsgGetCFilterV2(&hashes[i]), nil)
+		state.inFlightFilters = append(state.inFlightFilters, hashes[i])
+	}
+}
+
+// handleFilterStallCheck disconnects the peer the committed filters are being
+// synced from when it fails to deliver any of the filters it has in flight for
+// blockStallTimeout.  It is used in place of handleBlockStallCheck when running
+// in light mode.
+//
+// This function is NOT safe for concurrent access.  It must be called from the
+// event handler goroutine.
+func (m *SyncManager) handleFilterStallCheck() {
+	state := &m.lightState
+	peer := state.filterPeer
+	if peer == nil || len(state.inFlightFilters) == 0 {
+		return
+	}
+	if time.Since(state.lastFilterProgress) <= blockStallTimeout {
+		return
+	}
+
+	log.Debugf("Committed filter sync from peer %s stalled -- disconnecting",
+		peer)
+	peer.syncCandidate = false
+	m.releaseLightRequests(peer)
+	peer.Disconnect()
+}
+
+// handleCFilterV2Msg handles cfilterv2 messages from all peers when running in
+// light mode.  The filters must be delivered in the order they were requested.
+// The header commitment inclusion proof of each filter is verified against the
+// associated block header once header commitments are active and the blocks
+// the filters match are requested.
+func (m *SyncManager) handleCFilterV2Msg(cmsg *cfilterV2Msg) {
+	peer := cmsg.peer
+	msg := cmsg.filter
+
+	// The remote peer is misbehaving when the filter was not requested.
+	state := &m.lightState
+	if peer != state.filterPeer || len(state.inFlightFilters) == 0 ||
+		state.inFlightFilters[0] != msg.BlockHash {
+
+		log.Warnf("Got unrequested committed filter for block %v from %s -- "+
+			"disconnecting", msg.BlockHash, peer)
+		peer.Disconnect()
+		return
+	}
+	state.inFlightFilters = state.inFlightFilters[1:]
+	state.lastFilterProgress = time.Now()
+
+	chain := m.cfg.Chain
+	header, err := chain.HeaderByHash(&msg.BlockHash)
+	if err != nil {
+		log.Errorf("Unable to fetch header for committed filter: %v", err)
+		return
+	}
+	filter, err := gcs.FromBytesV2(blockcf2.B, blockcf2.M, msg.Data)
+	if err != nil {
+		log.Warnf("Got invalid committed filter for block %v from %s: %v -- "+
+			"disconnecting", msg.BlockHash, peer, err)
+		m.releaseLightRequests(peer)
+		peer.Disconnect()
+		return
+	}
+
+	// Ensure the filter is committed to by the block header once header
+	// commitments are active.
+	height := int64(header.Height)
+	if !state.verifyFilterProof(&header, filter, msg.ProofIndex, msg.ProofHashes) {
+		log.Warnf("Got committed filter for block %v from %s with an invalid "+
+			"inclusion proof -- disconnecting", msg.BlockHash, peer)
+		m.releaseLightRequests(peer)
+		peer.Disconnect()
+		return
+	}
+
+	state.addFilter(&msg.BlockHash, &lightFilter{
+		filter: filter,
+		proof: blockchain.HeaderProof{
+			ProofIndex:  msg.ProofIndex,
+			ProofHashes: msg.ProofHashes,
+		},
+	})
+	state.filterTip = msg.BlockHash
+	state.filterTipHeight = height
+
+	// Request the block when the filter matches any of the watched scripts.
+	if len(state.watchScripts) > 0 {
+		key := blockcf2.Key(&header.MerkleRoot)
+		if filter.MatchAny(key, state.watchScripts) {
+			log.Debugf("Committed filter for block %v (height %d) matches "+
+				"watched scripts", msg.BlockHash, height)
+			state.pendingBlocks[msg.BlockHash] = nil
+		}
+	}
+
+	m.fetchNextFilters()
+}
+
+// handleLightBlockMsg handles blocks that were requested due to matching the
+// committed filters when running in light mode.  The blocks are only checked to
+// be committed to by their headers since the full chain state required to
+// validate them is not available.
+//
+// This function is NOT safe for concurrent access.  It must be called from the
+// event handler goroutine.
+func (m *SyncManager) handleLightBlockMsg(bmsg *blockMsg) {
+	peer := bmsg.peer
+	block := bmsg.block
+	blockHash := block.Hash()
+	delete(peer.requestedBlocks, *blockHash)
+	delete(m.requestedBlocks, *blockHash)
+	delete(m.lightState.pendingBlocks, *blockHash)
+
+	// Ensure the transactions are committed to by the merkle roots in the
+	// header.  Note that the hash of the block commits to the header, so the
+	// header is the one that was synced.
+	msgBlock := block.MsgBlock()
+	header := &msgBlock.Header
+	txns, stxns := msgBlock.Transactions, msgBlock.STransactions
+	combinedRoot := standalone.CalcCombinedTxTreeMerkleRoot(txns, stxns)
+	if header.MerkleRoot != combinedRoot &&
+		(header.MerkleRoot != standalone.CalcTxTreeMerkleRoot(txns) ||
+			header.StakeRoot != standalone.CalcTxTreeMerkleRoot(stxns)) {
+
+		log.Warnf("Got block %v from %s with transactions that are not "+
+			"committed to by its header -- disconnecting", blockHash, peer)
+		m.lightState.pendingBlocks[*blockHash] = nil
+		m.releaseLightRequests(peer)
+		peer.Disconnect()
+		return
+	}
+
+	numTxns := uint64(len(txns) + len(stxns))
+	log.Infof("Received block %s matching watched scripts (%d %s, height %d)",
+		blockHash, numTxns, pickNoun(numTxns, "transaction", "transactions"),
+		header.Height)
+}
+
+// QueueCFilterV2 adds the passed cfilterv2 message and peer to the event
+// handling queue.
+func (m *SyncManager) QueueCFilterV2(filter *wire.MsgCFilterV2, peer *Peer) {
+	select {
+	case m.msgChan <- &cfilterV2Msg{filter: filter, peer: peer}:
+	case <-m.quit:
+	}
+}
+
+// FilterByBlockHash returns the version 2 GCS filter for the given block hash
+// along with its header commitment inclusion proof when it is one of the most
+// recently synced committed filters in light mode.
+//
+// An error that wraps blockchain.ErrNoFilter will be returned when the filter
+// for the given block hash is not available.
+//
+// This function is safe for concurrent access.
+func (m *SyncManager) FilterByBlockHash(hash *chainhash.Hash) (*gcs.FilterV2, *blockchain.HeaderProof, error) {
+	state := &m.lightState
+	state.filtersMtx.RLock()
+	filter, ok := state.filters[*hash]
+	state.filtersMtx.RUnlock()
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: no filter available for block %s",
+			blockchain.ErrNoFilter, hash)
+	}
+
+	proof := filter.proof
+	return filter.filter, &proof, nil
+}
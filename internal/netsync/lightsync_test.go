@@ -0,0 +1,127 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package netsync
+
+import (
+	"testing"
+
+	"github.com/decred/dcrd/blockchain/standalone/v2"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/gcs/v4"
+	"github.com/decred/dcrd/gcs/v4/blockcf2"
+	"github.com/decred/dcrd/internal/blockchain"
+	"github.com/decred/dcrd/wire"
+)
+
+// TestVerifyFilterProof ensures the header commitment inclusion proofs of
+// committed filters synced in light mode are verified against the block headers
+// once header commitments are active and that filters with invalid proofs are
+// rejected.
+func TestVerifyFilterProof(t *testing.T) {
+	// Create a couple of filters and a header that commits to the first one.
+	var merkleRoot chainhash.Hash
+	merkleRoot[0] = 0x01
+	key := blockcf2.Key(&merkleRoot)
+	filter, err := gcs.NewFilterV2(blockcf2.B, blockcf2.M, key,
+		[][]byte{{0x51}, {0x52}})
+	if err != nil {
+		t.Fatalf("unexpected error creating filter: %v", err)
+	}
+	otherFilter, err := gcs.NewFilterV2(blockcf2.B, blockcf2.M, key,
+		[][]byte{{0x53}})
+	if err != nil {
+		t.Fatalf("unexpected error creating filter: %v", err)
+	}
+	filterHash := filter.Hash()
+	leaves := []chainhash.Hash{filterHash}
+	const proofIndex = blockchain.HeaderCmtFilterIndex
+	proof := standalone.GenerateInclusionProof(leaves, proofIndex)
+	header := wire.BlockHeader{
+		MerkleRoot: merkleRoot,
+		StakeRoot:  blockchain.CalcCommitmentRootV1(filterHash),
+	}
+
+	mainNetParams := chaincfg.MainNetParams()
+	mainNetActivation, _ := blockchain.HeaderCmtActivationHeight(mainNetParams)
+
+	tests := []struct {
+		name        string           // test description
+		params      *chaincfg.Params // network params
+		height      uint32           // height of the header
+		filter      *gcs.FilterV2    // filter to verify
+		proofIndex  uint32           // proof index to verify
+		proofHashes []chainhash.Hash // proof hashes to verify
+		want        bool             // expected result
+	}{{
+		name:        "valid proof at activation",
+		params:      mainNetParams,
+		height:      uint32(mainNetActivation),
+		filter:      filter,
+		proofIndex:  proofIndex,
+		proofHashes: proof,
+		want:        true,
+	}, {
+		name:        "filter not committed to by header",
+		params:      mainNetParams,
+		height:      uint32(mainNetActivation),
+		filter:      otherFilter,
+		proofIndex:  proofIndex,
+		proofHashes: proof,
+		want:        false,
+	}, {
+		name:        "wrong proof index",
+		params:      mainNetParams,
+		height:      uint32(mainNetActivation),
+		filter:      filter,
+		proofIndex:  proofIndex + 1,
+		proofHashes: proof,
+		want:        false,
+	}, {
+		name:        "extra proof hash",
+		params:      mainNetParams,
+		height:      uint32(mainNetActivation) + 1,
+		filter:      filter,
+		proofIndex:  proofIndex,
+		proofHashes: append(proof, chainhash.Hash{0x01}),
+		want:        false,
+	}, {
+		name:        "unverifiable prior to activation",
+		params:      mainNetParams,
+		height:      uint32(mainNetActivation) - 1,
+		filter:      otherFilter,
+		proofIndex:  proofIndex,
+		proofHashes: proof,
+		want:        true,
+	}, {
+		name:        "filter not committed to by header on simnet",
+		params:      chaincfg.SimNetParams(),
+		height:      1,
+		filter:      otherFilter,
+		proofIndex:  proofIndex,
+		proofHashes: proof,
+		want:        false,
+	}, {
+		name:        "unverifiable with unknown activation on regnet",
+		params:      chaincfg.RegNetParams(),
+		height:      uint32(mainNetActivation),
+		filter:      otherFilter,
+		proofIndex:  proofIndex,
+		proofHashes: proof,
+		want:        true,
+	}}
+
+	for _, test := range tests {
+		state := makeLightSyncState(test.params, nil)
+		header := header
+		header.Height = test.height
+		got := state.verifyFilterProof(&header, test.filter, test.proofIndex,
+			test.proofHashes)
+		if got != test.want {
+			t.Errorf("%q: mismatched result -- got %v, want %v", test.name,
+				got, test.want)
+		}
+	}
+}
@@ -1,5 +1,5 @@
 // Copyright (c) 2013-2016 The btcsuite developers
-// Copyright (c) 2015-2023 The Decred developers
+// Copyright (c) 2015-2024 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
@@ -10,6 +10,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"sort"
 	"sync"
 	"time"
 
@@ -32,10 +33,37 @@ const (
 	// in the request queue before requesting more.
 	minInFlightBlocks = 10
 
-	// maxInFlightBlocks is the maximum number of blocks to allow in the sync
-	// peer request queue.
+	// maxInFlightBlocks is the maximum number of blocks to allow in the
+	// request queue of each peer blocks are being downloaded from.
 	maxInFlightBlocks = 16
 
+	// blockDownloadWindow is the maximum number of blocks past the current
+	// best chain tip that will be requested when downloading blocks from
+	// multiple peers in parallel.  It bounds the number of blocks that are
+	// downloaded but can not yet be connected because an earlier block is
+	// still in flight.
+	blockDownloadWindow = 1024
+
+	// blockStallTimeout is the maximum amount of time a peer with blocks in
+	// flight may go without delivering any of them before it is considered
+	// stalled and disconnected.
+	blockStallTimeout = 30 * time.Second
+
+	// blockWindowStallTimeout is the maximum amount of time a peer may go
+	// without delivering the next block needed by the chain while the block
+	// download window is exhausted before it is considered to be stalling the
+	// download and disconnected.
+	blockWindowStallTimeout = 10 * time.Second
+
+	// blockStallCheckInterval is the interval at which peers are checked for
+	// stalled block downloads.
+	blockStallCheckInterval = 2 * time.Second
+
+	// blockThroughputWeight is the weight given to the most recent sample when
+	// updating the exponentially weighted moving average of the block download
+	// throughput of a peer.
+	blockThroughputWeight = 0.2
+
 	// maxRejectedTxns specifies the maximum number of recently rejected
 	// transactions to track.  This is primarily used to avoid wasting a bunch
 	// of bandwidth from requesting transactions that are already known to be
@@ -99,9 +127,28 @@ type peerConnectedMsg struct {
 // blockMsg packages a Decred block message and the peer it came from together
 // so the event handler has access to that information.
 type blockMsg struct {
-	block *dcrutil.Block
-	peer  *Peer
-	reply chan struct{}
+	block    *dcrutil.Block
+	peer     *Peer
+	received time.Time
+	reply    chan struct{}
+}
+
+// cmpctBlockMsg packages a Decred cmpctblock message and the peer it came from
+// together so the event handler has access to that information.
+type cmpctBlockMsg struct {
+	cmpctBlock *wire.MsgCmpctBlock
+	peer       *Peer
+	received   time.Time
+	reply      chan struct{}
+}
+
+// blockTxnMsg packages a Decred blocktxn message and the peer it came from
+// together so the event handler has access to that information.
+type blockTxnMsg struct {
+	blockTxn *wire.MsgBlockTxn
+	peer     *Peer
+	received time.Time
+	reply    chan struct{}
 }
 
 // invMsg packages a Decred inv message and the peer it came from together
@@ -200,6 +247,35 @@ type Peer struct {
 	numConsecutiveOrphanHeaders int32
 
 	lastAnnouncedBlock *chainhash.Hash
+
+	// inFlightBlocks tracks the blocks requested from the peer as part of
+	// downloading the blocks needed to catch the chain up to the best known
+	// header along with their heights and the times they were requested.  It
+	// is a subset of requestedBlocks.
+	inFlightBlocks map[chainhash.Hash]inFlightBlock
+
+	// lastBlockRecvTime is the time the most recent block in inFlightBlocks
+	// was received from the peer.
+	//
+	// blockThroughput is an exponentially weighted moving average of the
+	// rate in bytes per second the peer delivers requested blocks.  It is
+	// zero until the first block has been received.
+	lastBlockRecvTime time.Time
+	blockThroughput   float64
+
+	// pendingCmpctBlock is the block most recently announced by the peer via
+	// a compact block that is waiting on the missing transactions requested
+	// from the peer via a getblocktxn message.  It is nil when there is no
+	// such outstanding request.
+	pendingCmpctBlock *partialBlock
+}
+
+// inFlightBlock houses information about a block requested from a peer as part
+// of downloading the blocks needed to catch the chain up to the best known
+// header.
+type inFlightBlock struct {
+	height    int64
+	requested time.Time
 }
 
 // NewPeer returns a new instance of a peer that wraps the provided underlying
@@ -211,7 +287,49 @@ func NewPeer(peer *peerpkg.Peer) *Peer {
 		syncCandidate:   isSyncCandidate,
 		requestedTxns:   make(map[chainhash.Hash]struct{}),
 		requestedBlocks: make(map[chainhash.Hash]struct{}),
+		inFlightBlocks:  make(map[chainhash.Hash]inFlightBlock),
+	}
+}
+
+// progressStart returns the time from which the progress of the peer in
+// delivering the blocks it has in flight is measured.  It is the later of the
+// time the most recent in flight block was received and the time the oldest
+// block that is still in flight was requested.
+func (peer *Peer) progressStart() time.Time {
+	var start time.Time
+	for _, block := range peer.inFlightBlocks {
+		if start.IsZero() || block.requested.Before(start) {
+			start = block.requested
+		}
+	}
+	if peer.lastBlockRecvTime.After(start) {
+		start = peer.lastBlockRecvTime
 	}
+	return start
+}
+
+// updateBlockThroughput updates the measured block download throughput of the
+// peer to account for the provided block that was received at the given time.
+func (peer *Peer) updateBlockThroughput(block *dcrutil.Block, requested, received time.Time) {
+	// Measure from the later of the time the block was requested and the time
+	// the previous block was received since blocks are delivered one after
+	// the other.
+	start := requested
+	if peer.lastBlockRecvTime.After(start) {
+		start = peer.lastBlockRecvTime
+	}
+	elapsed := received.Sub(start).Seconds()
+	if elapsed < 0.001 {
+		elapsed = 0.001
+	}
+	rate := float64(block.MsgBlock().SerializeSize()) / elapsed
+	if peer.blockThroughput == 0 {
+		peer.blockThroughput = rate
+	} else {
+		peer.blockThroughput += blockThroughputWeight *
+			(rate - peer.blockThroughput)
+	}
+	peer.lastBlockRecvTime = received
 }
 
 // headerSyncState houses the state used to track the header sync progress and
@@ -333,6 +451,16 @@ type SyncManager struct {
 	nextBlocksHeader chainhash.Hash
 	nextBlocksBuf    [512]chainhash.Hash
 	nextNeededBlocks []chainhash.Hash
+
+	// downloadWindowFull tracks whether or not requesting more blocks was
+	// most recently prevented by the block download window being exhausted
+	// as opposed to the peers having no more capacity.  It is used to detect
+	// peers that are stalling the download.
+	downloadWindowFull bool
+
+	// lightState houses the state used to sync the committed filters and the
+	// blocks they match when running in light mode.
+	lightState lightSyncState
 }
 
 // SyncHeight returns latest known block being synced to.
@@ -378,54 +506,212 @@ func (m *SyncManager) maybeUpdateNextNeededBlocks() {
 	}
 }
 
-// fetchNextBlocks creates and sends a request to the provided peer for the next
-// blocks to be downloaded based on the current headers.
-func (m *SyncManager) fetchNextBlocks(peer *Peer) {
-	// Nothing to do if the target maximum number of blocks to request from the
-	// peer at the same time are already in flight.
-	numInFlight := len(peer.requestedBlocks)
-	if numInFlight >= maxInFlightBlocks {
+// releaseInFlightBlocks removes all of the blocks that are in flight from the
+// provided peer as part of downloading the blocks needed to catch the chain up
+// to the best known header from the request maps so they are requested from
+// other peers.
+//
+// This function is NOT safe for concurrent access.  It must be called from the
+// event handler goroutine.
+func (m *SyncManager) releaseInFlightBlocks(peer *Peer) {
+	if len(peer.inFlightBlocks) == 0 {
 		return
 	}
+	for hash := range peer.inFlightBlocks {
+		delete(peer.requestedBlocks, hash)
+		delete(m.requestedBlocks, hash)
+	}
+	peer.inFlightBlocks = make(map[chainhash.Hash]inFlightBlock)
 
-	// Potentially update the list of the next blocks to download in the branch
-	// leading up to the best known header.
-	m.maybeUpdateNextNeededBlocks()
+	// Force the list of the next needed blocks to be updated since the
+	// released blocks were already removed from it.
+	m.nextBlocksHeader = zeroHash
+}
 
-	// Build and send a getdata request for the needed blocks.
-	numNeeded := len(m.nextNeededBlocks)
-	if numNeeded == 0 {
+// blockDownloadPeers returns the peers that are currently suitable for
+// downloading blocks from and have room for more blocks in their request queues
+// ordered by their measured block download throughput from fastest to slowest.
+// Peers that have not delivered any blocks yet are ordered last.
+//
+// This function is NOT safe for concurrent access.  It must be called from the
+// event handler goroutine.
+func (m *SyncManager) blockDownloadPeers() []*Peer {
+	peers := make([]*Peer, 0, len(m.peers))
+	for peer := range m.peers {
+		if !peer.syncCandidate || !peer.Connected() {
+			continue
+		}
+		if len(peer.inFlightBlocks) >= minInFlightBlocks {
+			continue
+		}
+		peers = append(peers, peer)
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].blockThroughput > peers[j].blockThroughput
+	})
+	return peers
+}
+
+// fetchNextBlocks creates and sends requests for the next blocks to be
+// downloaded based on the current headers.  The blocks are requested in order
+// of their height from multiple peers in parallel, giving preference to the
+// peers with the highest measured throughput, and only up to the download
+// window past the current best chain tip.
+//
+// This function is NOT safe for concurrent access.  It must be called from the
+// event handler goroutine.
+func (m *SyncManager) fetchNextBlocks() {
+	// Sync the committed filters instead of the blocks in light mode.
+	if m.cfg.LightMode {
+		m.fetchNextFilters()
 		return
 	}
-	maxNeeded := maxInFlightBlocks - numInFlight
-	if numNeeded > maxNeeded {
-		numNeeded = maxNeeded
+
+	// Nothing to do when there are no peers with room for more blocks in their
+	// request queues.
+	peers := m.blockDownloadPeers()
+	if len(peers) == 0 {
+		return
 	}
-	gdmsg := wire.NewMsgGetDataSizeHint(uint(numNeeded))
-	for i := 0; i < numNeeded && len(gdmsg.InvList) < wire.MaxInvPerMsg; i++ {
-		// The block is either going to be skipped because it has already been
-		// requested or it will be requested, but in either case, the block is
-		// no longer needed for future iterations.
-		hash := &m.nextNeededBlocks[0]
-		m.nextNeededBlocks = m.nextNeededBlocks[1:]
 
+	// Potentially update the list of the next blocks to download in the branch
+	// leading up to the best known header.
+	m.maybeUpdateNextNeededBlocks()
+
+	// Assign the needed blocks to the peers in order of their height until the
+	// download window is exhausted or none of the peers have room for more.
+	chain := m.cfg.Chain
+	maxHeight := chain.BestSnapshot().Height + blockDownloadWindow
+	requests := make(map[*Peer]*wire.MsgGetData, len(peers))
+	now := time.Now()
+	m.downloadWindowFull = false
+	for len(m.nextNeededBlocks) > 0 {
 		// Skip blocks that have already been requested.  The needed blocks
 		// might have been updated above thereby potentially repopulating some
 		// blocks that are still in flight.
-		if _, ok := m.requestedBlocks[*hash]; ok {
+		hash := m.nextNeededBlocks[0]
+		if _, ok := m.requestedBlocks[hash]; ok {
+			m.nextNeededBlocks = m.nextNeededBlocks[1:]
+			continue
+		}
+		header, err := chain.HeaderByHash(&hash)
+		if err != nil {
+			m.nextNeededBlocks = m.nextNeededBlocks[1:]
 			continue
 		}
 
-		iv := wire.NewInvVect(wire.InvTypeBlock, hash)
-		m.requestedBlocks[*hash] = struct{}{}
-		peer.requestedBlocks[*hash] = struct{}{}
-		gdmsg.AddInvVect(iv)
+		// Stop once the download window is exhausted.  The remaining blocks
+		// will be requested as the chain catches up.
+		height := int64(header.Height)
+		if height > maxHeight {
+			m.downloadWindowFull = true
+			break
+		}
+
+		// Choose the fastest peer that has room for the block in its request
+		// queue and is known to have it.
+		var peer *Peer
+		for _, p := range peers {
+			if len(p.inFlightBlocks) < maxInFlightBlocks &&
+				p.LastBlock() >= height {
+
+				peer = p
+				break
+			}
+		}
+		if peer == nil {
+			break
+		}
+
+		// The block will be requested, so it is no longer needed for future
+		// iterations.
+		m.nextNeededBlocks = m.nextNeededBlocks[1:]
+
+		gdmsg, ok := requests[peer]
+		if !ok {
+			gdmsg = wire.NewMsgGetDataSizeHint(maxInFlightBlocks)
+			requests[peer] = gdmsg
+		}
+		gdmsg.AddInvVect(wire.NewInvVect(wire.InvTypeBlock, &hash))
+		m.requestedBlocks[hash] = struct{}{}
+		peer.requestedBlocks[hash] = struct{}{}
+		peer.inFlightBlocks[hash] = inFlightBlock{
+			height:    height,
+			requested: now,
+		}
 	}
-	if len(gdmsg.InvList) > 0 {
+	for peer, gdmsg := range requests {
 		peer.QueueMessage(gdmsg, nil)
 	}
 }
 
+// handleBlockStallCheck checks for peers that are stalling the download of the
+// blocks needed to catch the chain up to the best known header, disconnects
+// them, and requests the blocks they had in flight from other peers.
+//
+// A peer is considered to be stalling when it fails to deliver any of the
+// blocks it has in flight for blockStallTimeout or when it fails to deliver the
+// next block needed by the chain for blockWindowStallTimeout while the download
+// window is exhausted and there are other peers to download from.
+//
+// This function is NOT safe for concurrent access.  It must be called from the
+// event handler goroutine.
+func (m *SyncManager) handleBlockStallCheck() {
+	if m.cfg.LightMode {
+		m.handleFilterStallCheck()
+		return
+	}
+
+	now := time.Now()
+	var numDownloadPeers int
+	var windowPeer *Peer
+	var windowHeight int64
+	var stalled []*Peer
+	for peer := range m.peers {
+		if len(peer.inFlightBlocks) == 0 {
+			continue
+		}
+		numDownloadPeers++
+		if now.Sub(peer.progressStart()) > blockStallTimeout {
+			log.Debugf("Block download from peer %s stalled -- "+
+				"disconnecting", peer)
+			stalled = append(stalled, peer)
+			continue
+		}
+
+		// Track the peer with the lowest block in flight since it is the one
+		// holding up the chain when the download window is exhausted.
+		for _, block := range peer.inFlightBlocks {
+			if windowPeer == nil || block.height < windowHeight {
+				windowPeer = peer
+				windowHeight = block.height
+			}
+		}
+	}
+	if m.downloadWindowFull && windowPeer != nil && numDownloadPeers > 1 &&
+		now.Sub(windowPeer.progressStart()) > blockWindowStallTimeout {
+
+		log.Debugf("Peer %s is stalling the block download window at height "+
+			"%d -- disconnecting", windowPeer, windowHeight)
+		stalled = append(stalled, windowPeer)
+	}
+	if len(stalled) == 0 {
+		return
+	}
+
+	// Disconnect the stalled peers and request the blocks they had in flight
+	// from other peers.  The peers are no longer considered candidates to
+	// download from in the mean time until they are removed.
+	for _, peer := range stalled {
+		peer.syncCandidate = false
+		m.releaseInFlightBlocks(peer)
+		peer.Disconnect()
+	}
+	if m.hdrSyncState.headersSynced {
+		m.fetchNextBlocks()
+	}
+}
+
 // startSync will choose the best peer among the available candidate peers to
 // download/sync the blockchain from.  When syncing is already running, it
 // simply returns.  It also examines the candidates for any which are no longer
@@ -478,13 +764,9 @@ func (m *SyncManager) startSync() {
 		return
 	}
 
-	// Start syncing from the best peer.
-
-	// Clear the requestedBlocks if the sync peer changes, otherwise
-	// we may ignore blocks we need that the last sync peer failed
-	// to send.
-	m.requestedBlocks = make(map[chainhash.Hash]struct{})
-
+	// Start syncing from the best peer.  Note that there is no need to clear
+	// the requested blocks since any blocks that were in flight from a
+	// previous sync peer are released when it is removed.
 	syncHeight := bestPeer.LastBlock()
 
 	headersSynced := m.hdrSyncState.headersSynced
@@ -538,7 +820,7 @@ func (m *SyncManager) startSync() {
 	// for the round trip when there are still blocks that are needed
 	// regardless of the headers response.
 	if headersSynced {
-		m.fetchNextBlocks(m.syncPeer)
+		m.fetchNextBlocks()
 	}
 }
 
@@ -610,6 +892,10 @@ func (m *SyncManager) handlePeerConnectedMsg(ctx context.Context, peer *Peer) {
 	// Start syncing by choosing the best candidate if needed.
 	if peer.syncCandidate && m.syncPeer == nil {
 		m.startSync()
+	} else if peer.syncCandidate && m.hdrSyncState.headersSynced {
+		// Download blocks from the new peer as well when blocks are still
+		// needed to catch the chain up to the best known header.
+		m.fetchNextBlocks()
 	}
 
 	// Request the initial state from this peer now when enabled and the manager
@@ -628,6 +914,15 @@ func (m *SyncManager) handlePeerDisconnectedMsg(peer *Peer) {
 	// Remove the peer from the list of candidate peers.
 	delete(m.peers, peer)
 
+	// Release any blocks that were in flight from the peer as part of
+	// downloading the blocks needed to catch the chain up so they are
+	// requested from other peers below.
+	hadInFlightBlocks := len(peer.inFlightBlocks) > 0
+	m.releaseInFlightBlocks(peer)
+	if m.cfg.LightMode {
+		m.releaseLightRequests(peer)
+	}
+
 	// Re-request in-flight blocks and transactions that were not received
 	// by the disconnected peer if the data was announced by another peer.
 	// Remove the data from the manager's requested data maps if no other
@@ -691,10 +986,13 @@ BlockHashes:
 	}
 
 	// Attempt to find a new peer to sync from and reset the final requested
-	// block when the quitting peer is the sync peer.
+	// block when the quitting peer is the sync peer.  Otherwise, request the
+	// blocks that were in flight from the quitting peer from other peers.
 	if m.syncPeer == peer {
 		m.syncPeer = nil
 		m.startSync()
+	} else if hadInFlightBlocks && m.hdrSyncState.headersSynced {
+		m.fetchNextBlocks()
 	}
 }
 
@@ -759,8 +1057,10 @@ func (m *SyncManager) handleTxMsg(tmsg *txMsg) {
 //
 // This function MUST be called with the is current mutex held (for writes).
 func (m *SyncManager) maybeUpdateIsCurrent() {
-	// Nothing to do when already considered synced.
-	if m.isCurrent {
+	// Nothing to do when already considered synced or when running in light
+	// mode since the chain never becomes current in that case.  The state is
+	// instead updated as the committed filters are synced.
+	if m.isCurrent || m.cfg.LightMode {
 		return
 	}
 
@@ -819,6 +1119,23 @@ func (m *SyncManager) handleBlockMsg(bmsg *blockMsg) {
 		return
 	}
 
+	// Blocks are only requested due to matching the committed filters when
+	// running in light mode and are handled separately since they are not
+	// processed by the chain.
+	if m.cfg.LightMode {
+		m.handleLightBlockMsg(bmsg)
+		return
+	}
+
+	// Update the measured block download throughput of the peer when the
+	// block was requested as part of downloading the blocks needed to catch
+	// the chain up.
+	if inFlight, ok := peer.inFlightBlocks[*blockHash]; ok {
+		peer.updateBlockThroughput(bmsg.block, inFlight.requested,
+			bmsg.received)
+		delete(peer.inFlightBlocks, *blockHash)
+	}
+
 	// Save whether or not the chain believes it is current prior to processing
 	// the block for use below in determining logging behavior.
 	chain := m.cfg.Chain
@@ -929,13 +1246,147 @@ func (m *SyncManager) handleBlockMsg(bmsg *blockMsg) {
 		}
 	}
 
-	// Request more blocks using the headers when the request queue is getting
-	// short.
-	if peer == m.syncPeer && len(peer.requestedBlocks) < minInFlightBlocks {
-		m.fetchNextBlocks(peer)
+	// Request more blocks using the headers when the request queue of the
+	// peer is getting short.
+	if m.hdrSyncState.headersSynced &&
+		len(peer.inFlightBlocks) < minInFlightBlocks {
+
+		m.fetchNextBlocks()
 	}
 }
 
+// requestFullBlock requests the full block with the provided hash from the
+// peer.  It is used when a block announced via a compact block can't be
+// reconstructed.  The block must already be marked as requested.
+//
+// This function is NOT safe for concurrent access.  It must be called from the
+// event handler goroutine.
+func (m *SyncManager) requestFullBlock(peer *Peer, hash *chainhash.Hash) {
+	gdmsg := wire.NewMsgGetDataSizeHint(1)
+	gdmsg.AddInvVect(wire.NewInvVect(wire.InvTypeBlock, hash))
+	peer.QueueMessage(gdmsg, nil)
+}
+
+// processPartialBlock processes the block reconstructed from a compact block
+// sent by the provided peer the same as a full block once all of its
+// transactions are available.  The full block is requested instead when the
+// reconstructed transactions do not match the merkle roots committed to by the
+// header which can happen in the rare case of short ID collisions.
+//
+// This function is NOT safe for concurrent access.  It must be called from the
+// event handler goroutine.
+func (m *SyncManager) processPartialBlock(peer *Peer, pb *partialBlock, received time.Time) {
+	msgBlock := pb.msgBlock()
+	if !merkleRootsMatch(msgBlock) {
+		log.Debugf("Block %s reconstructed from compact block sent by %s "+
+			"does not match the merkle root -- requesting full block",
+			pb.hash, peer)
+		m.requestFullBlock(peer, &pb.hash)
+		return
+	}
+
+	log.Debugf("Reconstructed block %s from compact block sent by %s (%d "+
+		"txns: %d prefilled, %d from mempool, %d requested)", pb.hash, peer,
+		pb.numTotalTxns, pb.numPrefilled, pb.numFromPool, pb.numRequested)
+	m.handleBlockMsg(&blockMsg{
+		block:    dcrutil.NewBlock(msgBlock),
+		peer:     peer,
+		received: received,
+	})
+}
+
+// handleCmpctBlockMsg handles cmpctblock messages from all peers.
+//
+// Compact blocks are only reconstructed once the chain is current and the
+// block connects to a known header.  Otherwise, the compact block is treated
+// the same as a header announcement which results in the full block being
+// requested as needed.
+func (m *SyncManager) handleCmpctBlockMsg(cmsg *cmpctBlockMsg) {
+	peer := cmsg.peer
+	msg := cmsg.cmpctBlock
+	header := &msg.Header
+	blockHash := header.BlockHash()
+
+	// Ignore blocks that are already known or have already been requested.
+	chain := m.cfg.Chain
+	_, isRequestedBlock := m.requestedBlocks[blockHash]
+	if isRequestedBlock || chain.HaveBlock(&blockHash) {
+		return
+	}
+
+	if !chain.IsCurrent() || !chain.HaveHeader(&header.PrevBlock) {
+		m.handleHeadersMsg(&headersMsg{
+			headers: &wire.MsgHeaders{Headers: []*wire.BlockHeader{header}},
+			peer:    peer,
+		})
+		return
+	}
+
+	// Ensure the header is valid prior to performing the comparatively
+	// expensive block reconstruction.
+	if err := chain.ProcessBlockHeader(header); err != nil {
+		log.Debugf("Failed to process block header %s from peer %s: %v -- "+
+			"disconnecting", blockHash, peer, err)
+		peer.Disconnect()
+		return
+	}
+	peer.numConsecutiveOrphanHeaders = 0
+	peer.lastAnnouncedBlock = &blockHash
+	peer.UpdateLastBlockHeight(int64(header.Height))
+
+	// Only a single compact block is reconstructed per peer at a time, so
+	// forget about any previous one that is still waiting on its missing
+	// transactions and request it in full instead.
+	if prev := peer.pendingCmpctBlock; prev != nil {
+		peer.pendingCmpctBlock = nil
+		m.requestFullBlock(peer, &prev.hash)
+	}
+
+	// Mark the block as requested so it is not requested from other peers and
+	// is accepted once it is reconstructed.
+	limitAdd(m.requestedBlocks, blockHash, maxRequestedBlocks)
+	limitAdd(peer.requestedBlocks, blockHash, maxRequestedBlocks)
+
+	// Attempt to reconstruct the block from the transactions in the mempool
+	// and request any that are missing from the peer.
+	pb := newPartialBlock(msg, m.cfg.TxMemPool.TxDescs())
+	if !pb.isComplete() {
+		log.Debugf("Requesting %d missing transactions for compact block %s "+
+			"from %s", len(pb.missing)+len(pb.missingStake), blockHash, peer)
+		peer.pendingCmpctBlock = pb
+		peer.QueueMessage(wire.NewMsgGetBlockTxn(&blockHash, pb.missing,
+			pb.missingStake), nil)
+		return
+	}
+
+	m.processPartialBlock(peer, pb, cmsg.received)
+}
+
+// handleBlockTxnMsg handles blocktxn messages from all peers.
+func (m *SyncManager) handleBlockTxnMsg(bmsg *blockTxnMsg) {
+	peer := bmsg.peer
+	msg := bmsg.blockTxn
+
+	// The remote peer is misbehaving when the transactions were not requested.
+	pb := peer.pendingCmpctBlock
+	if pb == nil || pb.hash != msg.BlockHash {
+		log.Warnf("Got unrequested transactions for block %v from %s -- "+
+			"disconnecting", msg.BlockHash, peer)
+		peer.Disconnect()
+		return
+	}
+	peer.pendingCmpctBlock = nil
+
+	if err := pb.fillMissing(msg.Transactions, msg.STransactions); err != nil {
+		log.Warnf("Got invalid transactions for block %v from %s: %v -- "+
+			"disconnecting", msg.BlockHash, peer, err)
+		peer.Disconnect()
+		return
+	}
+
+	m.processPartialBlock(peer, pb, bmsg.received)
+}
+
 // guessHeaderSyncProgress returns a percentage that is a guess of the progress
 // of the header sync progress for the given currently best known header based
 // on an algorithm that considers the total number of expected headers based on
@@ -1226,8 +1677,8 @@ func (m *SyncManager) handleHeadersMsg(hmsg *headersMsg) {
 
 	// Download any blocks needed to catch the local chain up to the best known
 	// header (if any) once the initial headers sync is done.
-	if headersSynced && m.syncPeer != nil {
-		m.fetchNextBlocks(m.syncPeer)
+	if headersSynced {
+		m.fetchNextBlocks()
 	}
 }
 
@@ -1235,14 +1686,24 @@ func (m *SyncManager) handleHeadersMsg(hmsg *headersMsg) {
 func (m *SyncManager) handleNotFoundMsg(nfmsg *notFoundMsg) {
 	peer := nfmsg.peer
 
+	var missingBlocks, missingInFlightBlocks bool
 	for _, inv := range nfmsg.notFound.InvList {
 		// verify the hash was actually announced by the peer
 		// before deleting from the global requested maps.
 		switch inv.Type {
 		case wire.InvTypeBlock:
+			pendingCmpctBlock := peer.pendingCmpctBlock
+			if pendingCmpctBlock != nil && pendingCmpctBlock.hash == inv.Hash {
+				peer.pendingCmpctBlock = nil
+			}
 			if _, exists := peer.requestedBlocks[inv.Hash]; exists {
 				delete(peer.requestedBlocks, inv.Hash)
 				delete(m.requestedBlocks, inv.Hash)
+				missingBlocks = true
+			}
+			if _, exists := peer.inFlightBlocks[inv.Hash]; exists {
+				delete(peer.inFlightBlocks, inv.Hash)
+				missingInFlightBlocks = true
 			}
 		case wire.InvTypeTx:
 			if _, exists := peer.requestedTxns[inv.Hash]; exists {
@@ -1251,6 +1712,32 @@ func (m *SyncManager) handleNotFoundMsg(nfmsg *notFoundMsg) {
 			}
 		}
 	}
+
+	// The sync peer is no longer a suitable candidate when it does not have
+	// the requested blocks, such as when it has pruned its block data, so
+	// attempt to find a new peer to sync from.
+	if missingBlocks && m.syncPeer == peer {
+		log.Infof("Sync peer %v does not have the requested blocks -- "+
+			"choosing a new sync peer", peer)
+		peer.syncCandidate = false
+		m.releaseInFlightBlocks(peer)
+		m.syncPeer = nil
+		m.startSync()
+		return
+	}
+
+	// Similarly, any other peer that does not have the blocks requested from
+	// it while catching the chain up is no longer suitable for downloading
+	// blocks from, so request its remaining blocks from other peers.
+	if missingInFlightBlocks {
+		log.Debugf("Peer %v does not have the requested blocks -- no longer "+
+			"downloading blocks from it", peer)
+		peer.syncCandidate = false
+		m.releaseInFlightBlocks(peer)
+		if m.hdrSyncState.headersSynced {
+			m.fetchNextBlocks()
+		}
+	}
 }
 
 // needTx returns whether or not the transaction needs to be downloaded.  For
@@ -1322,7 +1809,9 @@ func (m *SyncManager) handleInvMsg(imsg *invMsg) {
 			// Transaction announcements are based on the state of the fully
 			// synced ledger, so they are likely to be invalid before the chain
 			// is current.
-			if !isCurrent || !m.needTx(&iv.Hash) {
+			//
+			// Transactions are never needed when running in light mode.
+			if m.cfg.LightMode || !isCurrent || !m.needTx(&iv.Hash) {
 				continue
 			}
 
@@ -1398,6 +1887,9 @@ func limitAdd(m map[chainhash.Hash]struct{}, hash chainhash.Hash, limit int) {
 // because the sync manager controls which blocks are needed and how the
 // fetching should proceed.
 func (m *SyncManager) eventHandler(ctx context.Context) {
+	stallTicker := time.NewTicker(blockStallCheckInterval)
+	defer stallTicker.Stop()
+
 out:
 	for {
 		select {
@@ -1420,6 +1912,20 @@ out:
 				case <-ctx.Done():
 				}
 
+			case *cmpctBlockMsg:
+				m.handleCmpctBlockMsg(msg)
+				select {
+				case msg.reply <- struct{}{}:
+				case <-ctx.Done():
+				}
+
+			case *blockTxnMsg:
+				m.handleBlockTxnMsg(msg)
+				select {
+				case msg.reply <- struct{}{}:
+				case <-ctx.Done():
+				}
+
 			case *invMsg:
 				m.handleInvMsg(msg)
 
@@ -1429,6 +1935,9 @@ out:
 			case *notFoundMsg:
 				m.handleNotFoundMsg(msg)
 
+			case *cfilterV2Msg:
+				m.handleCFilterV2Msg(msg)
+
 			case *peerDisconnectedMsg:
 				m.handlePeerDisconnectedMsg(msg.peer)
 
@@ -1485,6 +1994,9 @@ out:
 				m.syncPeer.Disconnect()
 			}
 
+		case <-stallTicker.C:
+			m.handleBlockStallCheck()
+
 		case <-ctx.Done():
 			break out
 		}
@@ -1515,7 +2027,30 @@ func (m *SyncManager) QueueTx(tx *dcrutil.Tx, peer *Peer, done chan struct{}) {
 // queue.
 func (m *SyncManager) QueueBlock(block *dcrutil.Block, peer *Peer, done chan struct{}) {
 	select {
-	case m.msgChan <- &blockMsg{block: block, peer: peer, reply: done}:
+	case m.msgChan <- &blockMsg{block: block, peer: peer, received: time.Now(),
+		reply: done}:
+	case <-m.quit:
+		done <- struct{}{}
+	}
+}
+
+// QueueCmpctBlock adds the passed cmpctblock message and peer to the event
+// handling queue.
+func (m *SyncManager) QueueCmpctBlock(cmpctBlock *wire.MsgCmpctBlock, peer *Peer, done chan struct{}) {
+	select {
+	case m.msgChan <- &cmpctBlockMsg{cmpctBlock: cmpctBlock, peer: peer,
+		received: time.Now(), reply: done}:
+	case <-m.quit:
+		done <- struct{}{}
+	}
+}
+
+// QueueBlockTxn adds the passed blocktxn message and peer to the event
+// handling queue.
+func (m *SyncManager) QueueBlockTxn(blockTxn *wire.MsgBlockTxn, peer *Peer, done chan struct{}) {
+	select {
+	case m.msgChan <- &blockTxnMsg{blockTxn: blockTxn, peer: peer,
+		received: time.Now(), reply: done}:
 	case <-m.quit:
 		done <- struct{}{}
 	}
@@ -1747,6 +2282,9 @@ func (m *SyncManager) IsCurrent() bool {
 // function properly and blocks until the provided context is cancelled.
 func (m *SyncManager) Run(ctx context.Context) {
 	log.Trace("Starting sync manager")
+	if m.cfg.LightMode {
+		m.lightState.logFilterVerification()
+	}
 
 	// Start the event handler goroutine.
 	var wg sync.WaitGroup
@@ -1803,6 +2341,16 @@ type Config struct {
 	// and querying the most recently confirmed transactions.  It is useful for
 	// preventing duplicate requests.
 	RecentlyConfirmedTxns *apbf.Filter
+
+	// LightMode indicates whether or not the manager should only sync the
+	// headers and the committed filters along with the blocks matched by the
+	// filters for WatchScripts instead of all blocks.
+	LightMode bool
+
+	// WatchScripts specifies the scripts to match against the committed
+	// filters in order to determine which blocks to download when running in
+	// light mode.
+	WatchScripts [][]byte
 }
 
 // New returns a new network chain synchronization manager.  Use Run to begin
@@ -1831,5 +2379,6 @@ func New(config *Config) *SyncManager {
 		quit:            make(chan struct{}),
 		syncHeight:      config.Chain.BestSnapshot().Height,
 		isCurrent:       config.Chain.IsCurrent(),
+		lightState:      makeLightSyncState(config.ChainParams, config.WatchScripts),
 	}
 }
@@ -1,4 +1,4 @@
-// Copyright (c) 2019-2023 The Decred developers
+// Copyright (c) 2019-2024 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
@@ -18,6 +18,7 @@ import (
 	"github.com/decred/dcrd/internal/blockchain/indexers"
 	"github.com/decred/dcrd/internal/mempool"
 	"github.com/decred/dcrd/internal/mining"
+	"github.com/decred/dcrd/internal/staging/banmanager"
 	"github.com/decred/dcrd/math/uint256"
 	"github.com/decred/dcrd/peer/v3"
 	"github.com/decred/dcrd/rpc/jsonrpc/types/v4"
@@ -42,6 +43,11 @@ type Peer interface {
 	// Inbound returns whether the peer is inbound.
 	Inbound() bool
 
+	// ConnType returns a description of the type of connection with the peer
+	// such as whether it is inbound, manual, or an outbound connection that
+	// relays everything or only blocks.
+	ConnType() string
+
 	// StatsSnapshot returns a snapshot of the current peer flags and statistics.
 	StatsSnapshot() *peer.StatsSnap
 
@@ -69,6 +75,17 @@ type AddrManager interface {
 	// LocalAddresses returns a summary of local addresses information for
 	// the getnetworkinfo rpc.
 	LocalAddresses() []addrmgr.LocalAddr
+
+	// AddressCache returns a randomized subset of all known good addresses.
+	AddressCache() []*addrmgr.NetAddress
+
+	// GroupKey returns a string representing the network group the address
+	// is part of for the purposes of peer diversity.
+	GroupKey(na *addrmgr.NetAddress) string
+
+	// ASN returns the autonomous system number associated with the address
+	// or 0 when it is not known.
+	ASN(na *addrmgr.NetAddress) uint32
 }
 
 // ConnManager represents a connection manager for use with the RPC server.
@@ -135,6 +152,22 @@ type ConnManager interface {
 
 	// Lookup defines the DNS lookup function to be used.
 	Lookup(host string) ([]net.IP, error)
+
+	// BanSubnet adds the provided subnet to the ban list until the provided
+	// expiration time and disconnects all connected peers with an address
+	// in the subnet.
+	BanSubnet(subnet *net.IPNet, created, expires time.Time) error
+
+	// UnbanSubnet removes the provided subnet from the ban list.  An error
+	// is returned when the subnet is not banned.
+	UnbanSubnet(subnet *net.IPNet) error
+
+	// BannedSubnets returns the entries in the ban list with bans that have
+	// not expired.
+	BannedSubnets() []banmanager.BanEntry
+
+	// ClearBanned removes all entries from the ban list.
+	ClearBanned() error
 }
 
 // SyncManager represents a sync manager for use with the RPC server.
@@ -236,6 +269,11 @@ type Chain interface {
 	// main chain.
 	BlockHeightByHash(hash *chainhash.Hash) (int64, error)
 
+	// CalcNextRequiredStakeDifficultyV2 calculates the required stake
+	// difficulty for the block after the given block using the stake
+	// difficulty algorithm defined by DCP0001 based only on the block headers.
+	CalcNextRequiredStakeDifficultyV2(hash *chainhash.Hash) (int64, error)
+
 	// CalcWantHeight calculates the height of the final block of the previous
 	// interval given a block height.
 	CalcWantHeight(interval, height int64) int64
@@ -267,6 +305,12 @@ type Chain interface {
 	// the interval.
 	EstimateNextStakeDifficulty(hash *chainhash.Hash, newTickets int64, useMaxTickets bool) (int64, error)
 
+	// FetchSpentTxOuts returns all of the transaction outputs spent by the main
+	// chain block with the given hash as recorded in the spend journal.  The
+	// outputs are in the order of the inputs that spend them within the block
+	// which is the stake tree followed by the regular tree.
+	FetchSpentTxOuts(hash *chainhash.Hash) ([]blockchain.SpentTxOut, error)
+
 	// FetchUtxoEntry loads and returns the requested unspent transaction output
 	// from the point of view of the main chain tip.
 	//
@@ -319,6 +363,15 @@ type Chain interface {
 	//  - Latest block has a timestamp newer than 24 hours ago
 	IsCurrent() bool
 
+	// IsPruned returns whether or not the data for any blocks has been pruned
+	// or pruning is enabled.
+	IsPruned() bool
+
+	// PruneHeight returns the height of the oldest block in the main chain,
+	// other than the genesis block, that still has its data available.  It
+	// returns zero when no block data has been pruned.
+	PruneHeight() int64
+
 	// LiveTickets returns all currently live tickets.
 	LiveTickets() ([]chainhash.Hash, error)
 
@@ -612,9 +665,44 @@ type TxMempooler interface {
 	// pools and does not include orphans.
 	FetchTransaction(txHash *chainhash.Hash) (*dcrutil.Tx, error)
 
+	// FetchSpendingTx returns the transaction in the main or stage
+	// transaction pools that spends the provided outpoint.  Nil is returned
+	// when no transaction in those pools spends it.
+	FetchSpendingTx(outpoint *wire.OutPoint) *dcrutil.Tx
+
 	// TSpendHashes returns the hashes of the treasury spend transactions
 	// currently in the mempool.
 	TSpendHashes() []chainhash.Hash
+
+	// MinRelayFee returns the minimum relay fee per kB that transactions
+	// currently must pay to be accepted into the pool.  It is raised above
+	// the configured minimum while the pool is full.
+	MinRelayFee() dcrutil.Amount
+
+	// MaxSize returns the maximum total size in bytes of the transactions in
+	// the main pool.  A value of zero indicates there is no limit.
+	MaxSize() int64
+
+	// NumEvicted returns the total number of transactions that have been
+	// evicted from the main pool due to the maximum size.
+	NumEvicted() uint64
+}
+
+// MempoolPersister provides an interface for saving the contents of the
+// transaction memory pool to and loading them from the data directory.
+//
+// The interface contract requires that all of these methods are safe for
+// concurrent access.
+type MempoolPersister interface {
+	// SaveMempool writes the contents of the mempool to the mempool file in
+	// the data directory and returns the path of the file along with the
+	// number of transactions that were written.
+	SaveMempool() (string, int, error)
+
+	// LoadMempool loads the transactions in the mempool file in the data
+	// directory into the mempool and returns the path of the file along with
+	// the number of transactions that were accepted and rejected.
+	LoadMempool() (string, int, int, error)
 }
 
 // TxIndexer provides an interface for retrieving details for a given
@@ -639,6 +727,58 @@ type TxIndexer interface {
 	Entry(hash *chainhash.Hash) (*indexers.TxIndexEntry, error)
 }
 
+// AddrIndexer provides an interface for retrieving the transactions that
+// involve a given address.
+//
+// The interface contract requires that all of these methods are safe for
+// concurrent access.
+type AddrIndexer interface {
+	// Name returns the human-readable name of the index.
+	Name() string
+
+	// Tip returns the current index tip.
+	Tip() (int64, *chainhash.Hash, error)
+
+	// WaitForSync subscribes clients for the next index sync update.
+	WaitForSync() chan bool
+
+	// EntriesForAddress returns the index entries for the transactions that
+	// involve the provided address in the order they appear in the main chain,
+	// or the reverse of that order when the reverse flag is set, after
+	// skipping the provided number of entries.  The number of entries that
+	// were actually skipped is also returned.  The block regions contained in
+	// the results can in turn be used to load the raw transaction bytes.
+	EntriesForAddress(addr stdaddr.Address, numToSkip, numRequested uint32,
+		reverse bool) ([]indexers.TxIndexEntry, uint32, error)
+
+	// UnconfirmedTxnsForAddress returns the unconfirmed transactions in the
+	// memory pool that involve the provided address in the order they were
+	// added.
+	UnconfirmedTxnsForAddress(addr stdaddr.Address) []*dcrutil.Tx
+}
+
+// SpendIndexer provides an interface for retrieving the transaction in the
+// main chain that spends a given output.
+//
+// The interface contract requires that all of these methods are safe for
+// concurrent access.
+type SpendIndexer interface {
+	// Name returns the human-readable name of the index.
+	Name() string
+
+	// Tip returns the current index tip.
+	Tip() (int64, *chainhash.Hash, error)
+
+	// WaitForSync subscribes clients for the next index sync update.
+	WaitForSync() chan bool
+
+	// Entry returns details about the transaction in the main chain that
+	// spends the provided outpoint.  When the outpoint is not spent by a
+	// transaction in the main chain, nil must be returned for both the entry
+	// and the error.
+	Entry(outpoint *wire.OutPoint) (*indexers.SpendIndexEntry, error)
+}
+
 // NtfnManager provides an interface for processing and sending chain
 // notifications.
 //
@@ -751,3 +891,14 @@ type RPCHelpCacher interface {
 	// RPCUsage returns one-line usage for all supported RPC commands.
 	RPCUsage(includeWebsockets bool) (string, error)
 }
+
+// RequestObserver represents an observer of the requests handled by the RPC
+// server such as a collector of request latency metrics.
+//
+// The interface contract requires that all of these methods are safe for
+// concurrent access.
+type RequestObserver interface {
+	// ObserveRequest is invoked with the method and the time spent handling
+	// each request for a standard RPC command once it has been handled.
+	ObserveRequest(method types.Method, duration time.Duration)
+}
@@ -0,0 +1,589 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpcserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	stdlog "log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrjson/v4"
+	"github.com/decred/dcrd/dcrutil/v4"
+	"github.com/decred/dcrd/rpc/jsonrpc/types/v4"
+	"github.com/decred/dcrd/wire"
+)
+
+const (
+	// restPathPrefix is the path prefix of all REST endpoints.
+	restPathPrefix = "/rest/"
+
+	// maxRESTHeaders is the maximum number of block headers that may be
+	// requested via the REST headers endpoint at once.
+	maxRESTHeaders = 2000
+
+	// maxRESTOutpoints is the maximum number of outpoints that may be
+	// requested via the REST getutxos endpoint at once.
+	maxRESTOutpoints = 15
+
+	// restMempoolHeight is the height reported in binary REST responses for
+	// unspent transaction outputs that are only in the mempool.
+	restMempoolHeight = 0x7fffffff
+
+	// restImmutableCacheControl is the cache control header value that is
+	// set for REST responses that will never change since they only depend
+	// on data identified by its hash.
+	restImmutableCacheControl = "public, max-age=31536000, immutable"
+)
+
+// restFormat identifies the encoding of a REST response.
+type restFormat int
+
+const (
+	// restFormatBinary indicates the response is raw binary data.
+	restFormatBinary restFormat = iota
+
+	// restFormatHex indicates the response is hex-encoded binary data.
+	restFormatHex
+
+	// restFormatJSON indicates the response is a JSON object.
+	restFormatJSON
+)
+
+// restFormats maps the file extensions of REST requests to the format of the
+// response.
+var restFormats = map[string]restFormat{
+	"bin":  restFormatBinary,
+	"hex":  restFormatHex,
+	"json": restFormatJSON,
+}
+
+// restError is an error that is returned to REST clients along with an
+// associated HTTP status code.
+type restError struct {
+	code int
+	msg  string
+}
+
+// Error satisfies the error interface and prints human-readable errors.
+func (e *restError) Error() string {
+	return e.msg
+}
+
+// restBadRequest returns a REST error with a bad request status code and the
+// provided formatted message.
+func restBadRequest(fmtStr string, args ...interface{}) *restError {
+	return &restError{
+		code: http.StatusBadRequest,
+		msg:  fmt.Sprintf(fmtStr, args...),
+	}
+}
+
+// restHandler defines the signature of the functions that handle the REST
+// endpoints.  The param is the portion of the path after the endpoint with
+// the format extension removed.
+type restHandler func(ctx context.Context, s *Server, w http.ResponseWriter, param string, format restFormat) error
+
+// restHandlers maps the REST endpoints to their handlers.
+var restHandlers = map[string]restHandler{
+	"block":     handleRESTBlock,
+	"cfilterv2": handleRESTCFilterV2,
+	"chaininfo": handleRESTChainInfo,
+	"getutxos":  handleRESTGetUtxos,
+	"headers":   handleRESTHeaders,
+	"tx":        handleRESTTx,
+}
+
+// parseRESTPath parses the provided REST request path, which must already have
+// the REST path prefix removed, into the requested endpoint, the endpoint
+// specific parameter, and the requested response format.
+func parseRESTPath(path string) (string, string, restFormat, error) {
+	extIdx := strings.LastIndexByte(path, '.')
+	if extIdx == -1 {
+		str := "output format not specified (available: bin, hex, json)"
+		return "", "", 0, restBadRequest(str)
+	}
+	format, ok := restFormats[path[extIdx+1:]]
+	if !ok {
+		str := "output format not found (available: bin, hex, json)"
+		return "", "", 0, restBadRequest(str)
+	}
+	endpoint, param, _ := strings.Cut(path[:extIdx], "/")
+	return endpoint, param, format, nil
+}
+
+// writeRESTError writes the provided error to the REST client along with an
+// HTTP status code that depends on the type of error.
+func writeRESTError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	msg := err.Error()
+	var restErr *restError
+	var rpcErr *dcrjson.RPCError
+	switch {
+	case errors.As(err, &restErr):
+		code = restErr.code
+	case errors.As(err, &rpcErr):
+		msg = rpcErr.Message
+		// Note that missing transactions are also covered by the block not
+		// found case since they share the same error code.
+		switch rpcErr.Code {
+		case dcrjson.ErrRPCBlockNotFound:
+			code = http.StatusNotFound
+		case dcrjson.ErrRPCDecodeHexString, dcrjson.ErrRPCInvalidParameter,
+			dcrjson.ErrRPCOutOfRange:
+			code = http.StatusBadRequest
+		}
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(code)
+	fmt.Fprintln(w, msg)
+}
+
+// writeRESTBytes writes the provided binary data to the REST client in the
+// requested binary or hex format.  The data is marked as immutable for caches
+// when requested.
+//
+// Errors writing to the client are only logged since the response headers have
+// already been sent at that point.
+func writeRESTBytes(w http.ResponseWriter, format restFormat, data []byte, immutable bool) error {
+	var err error
+	switch format {
+	case restFormatBinary:
+		if immutable {
+			w.Header().Set("Cache-Control", restImmutableCacheControl)
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, err = w.Write(data)
+
+	case restFormatHex:
+		if immutable {
+			w.Header().Set("Cache-Control", restImmutableCacheControl)
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, err = fmt.Fprintln(w, hex.EncodeToString(data))
+
+	default:
+		return restBadRequest("output format not supported")
+	}
+	if err != nil {
+		log.Debugf("Failed to write REST response: %v", err)
+	}
+	return nil
+}
+
+// writeRESTJSON writes the provided value to the REST client as JSON.
+//
+// Errors writing to the client are only logged since the response headers have
+// already been sent at that point.
+func writeRESTJSON(w http.ResponseWriter, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	data = append(data, '\n')
+	if _, err := w.Write(data); err != nil {
+		log.Debugf("Failed to write REST response: %v", err)
+	}
+	return nil
+}
+
+// handleRESTBlock handles the REST block endpoint which returns the block with
+// the requested hash.
+//
+// The binary and hex formats return the serialized block while the JSON format
+// returns the same result as the getblock RPC with verbose transactions.
+func handleRESTBlock(ctx context.Context, s *Server, w http.ResponseWriter, param string, format restFormat) error {
+	verbose := format == restFormatJSON
+	result, err := handleGetBlock(ctx, s, &types.GetBlockCmd{
+		Hash:      param,
+		Verbose:   &verbose,
+		VerboseTx: &verbose,
+	})
+	if err != nil {
+		return err
+	}
+	if verbose {
+		return writeRESTJSON(w, result)
+	}
+
+	blkBytes, err := hex.DecodeString(result.(string))
+	if err != nil {
+		return err
+	}
+	return writeRESTBytes(w, format, blkBytes, true)
+}
+
+// handleRESTTx handles the REST tx endpoint which returns the transaction with
+// the requested hash from either the mempool or, when the transaction index is
+// enabled, the main chain.
+//
+// The binary and hex formats return the serialized transaction while the JSON
+// format returns the same result as the verbose getrawtransaction RPC.
+func handleRESTTx(ctx context.Context, s *Server, w http.ResponseWriter, param string, format restFormat) error {
+	verbose := 0
+	if format == restFormatJSON {
+		verbose = 1
+	}
+	result, err := handleGetRawTransaction(ctx, s, &types.GetRawTransactionCmd{
+		Txid:    param,
+		Verbose: &verbose,
+	})
+	if err != nil {
+		return err
+	}
+	if format == restFormatJSON {
+		return writeRESTJSON(w, result)
+	}
+
+	txBytes, err := hex.DecodeString(result.(string))
+	if err != nil {
+		return err
+	}
+	return writeRESTBytes(w, format, txBytes, true)
+}
+
+// handleRESTHeaders handles the REST headers endpoint which returns up to the
+// requested number of main chain block headers starting with the block with
+// the requested hash.  The param is of the form <count>/<hash>.
+//
+// The binary and hex formats return the concatenated serialized headers while
+// the JSON format returns an array of the same results as the verbose
+// getblockheader RPC.
+func handleRESTHeaders(ctx context.Context, s *Server, w http.ResponseWriter, param string, format restFormat) error {
+	countStr, hashStr, ok := strings.Cut(param, "/")
+	if !ok {
+		return restBadRequest("invalid URI format, expected " +
+			"/rest/headers/<count>/<hash>.<ext>")
+	}
+	count, err := strconv.ParseUint(countStr, 10, 32)
+	if err != nil || count < 1 || count > maxRESTHeaders {
+		return restBadRequest("header count is out of acceptable range "+
+			"(1-%d): %s", maxRESTHeaders, countStr)
+	}
+	hash, err := chainhash.NewHashFromStr(hashStr)
+	if err != nil {
+		return rpcDecodeHexError(hashStr)
+	}
+
+	// Load the requested headers from the main chain.
+	chain := s.cfg.Chain
+	height, err := chain.BlockHeightByHash(hash)
+	if err != nil {
+		return &restError{
+			code: http.StatusNotFound,
+			msg:  fmt.Sprintf("Block not found in main chain: %v", hash),
+		}
+	}
+	bestHeight := chain.BestSnapshot().Height
+	headers := make([]wire.BlockHeader, 0, count)
+	for h := height; h <= bestHeight && uint64(len(headers)) < count; h++ {
+		header, err := chain.HeaderByHeight(h)
+		if err != nil {
+			context := fmt.Sprintf("Failed to retrieve header for height %d",
+				h)
+			return rpcInternalErr(err, context)
+		}
+		headers = append(headers, header)
+	}
+
+	if format == restFormatJSON {
+		verbose := true
+		results := make([]interface{}, 0, len(headers))
+		for i := range headers {
+			result, err := handleGetBlockHeader(ctx, s, &types.GetBlockHeaderCmd{
+				Hash:    headers[i].BlockHash().String(),
+				Verbose: &verbose,
+			})
+			if err != nil {
+				return err
+			}
+			results = append(results, result)
+		}
+		return writeRESTJSON(w, results)
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(len(headers) * wire.MaxBlockHeaderPayload)
+	for i := range headers {
+		if err := headers[i].Serialize(&buf); err != nil {
+			return err
+		}
+	}
+
+	// The response only depends on data identified by hash when the
+	// requested number of headers is available.
+	immutable := uint64(len(headers)) == count
+	return writeRESTBytes(w, format, buf.Bytes(), immutable)
+}
+
+// handleRESTCFilterV2 handles the REST cfilterv2 endpoint which returns the
+// version 2 committed filter for the block with the requested hash.
+//
+// The binary and hex formats return the serialized filter while the JSON
+// format returns the same result as the getcfilterv2 RPC which also includes
+// the inclusion proof of the filter.
+func handleRESTCFilterV2(ctx context.Context, s *Server, w http.ResponseWriter, param string, format restFormat) error {
+	result, err := handleGetCFilterV2(ctx, s, &types.GetCFilterV2Cmd{
+		BlockHash: param,
+	})
+	if err != nil {
+		return err
+	}
+	if format == restFormatJSON {
+		return writeRESTJSON(w, result)
+	}
+
+	filterBytes, err := hex.DecodeString(result.(*types.GetCFilterV2Result).Data)
+	if err != nil {
+		return err
+	}
+	return writeRESTBytes(w, format, filterBytes, true)
+}
+
+// handleRESTChainInfo handles the REST chaininfo endpoint which returns the
+// same result as the getblockchaininfo RPC including the current chain tip.
+// Only the JSON format is supported.
+func handleRESTChainInfo(ctx context.Context, s *Server, w http.ResponseWriter, param string, format restFormat) error {
+	if format != restFormatJSON {
+		return restBadRequest("output format not supported (available: json)")
+	}
+	if param != "" {
+		return restBadRequest("invalid URI format, expected " +
+			"/rest/chaininfo.json")
+	}
+
+	result, err := handleGetBlockchainInfo(ctx, s, nil)
+	if err != nil {
+		return err
+	}
+	return writeRESTJSON(w, result)
+}
+
+// restGetUtxosResult models the data returned by the REST getutxos endpoint
+// when the JSON format is requested.
+type restGetUtxosResult struct {
+	ChainHeight  int64                   `json:"chainheight"`
+	ChainTipHash string                  `json:"chaintiphash"`
+	Bitmap       string                  `json:"bitmap"`
+	Utxos        []*types.GetTxOutResult `json:"utxos"`
+}
+
+// handleRESTGetUtxos handles the REST getutxos endpoint which returns the
+// unspent transaction outputs for up to maxRESTOutpoints requested outpoints.
+// The param is of the form [checkmempool/]<txid>-<vout>-<tree>[/...].  The
+// mempool is only taken into account when requested in which case outputs
+// spent by mempool transactions are treated as spent and outputs created by
+// them are treated as unspent.
+//
+// All formats include the current chain height and tip hash along with a
+// bitmap that indicates which of the requested outpoints are unspent.  The
+// binary and hex formats encode them as the little-endian uint32 height, the
+// tip hash, and the variable length bitmap with the first outpoint in the least
+// significant bit followed by the number of unspent outputs and, for each one,
+// the little-endian uint32 height of the block that contains it and its
+// serialized transaction output.  The JSON format returns the unspent outputs
+// as the same results as the gettxout RPC.
+func handleRESTGetUtxos(ctx context.Context, s *Server, w http.ResponseWriter, param string, format restFormat) error {
+	parts := strings.Split(param, "/")
+	checkMempool := parts[0] == "checkmempool"
+	if checkMempool {
+		parts = parts[1:]
+	}
+	if len(parts) == 0 || parts[0] == "" {
+		return restBadRequest("no outpoints specified")
+	}
+	if len(parts) > maxRESTOutpoints {
+		return restBadRequest("too many outpoints requested (max %d)",
+			maxRESTOutpoints)
+	}
+
+	// Parse the requested outpoints.
+	outpoints := make([]wire.OutPoint, 0, len(parts))
+	for _, part := range parts {
+		fields := strings.Split(part, "-")
+		if len(fields) != 3 {
+			return restBadRequest("invalid outpoint %q, expected "+
+				"<txid>-<vout>-<tree>", part)
+		}
+		txHash, err := chainhash.NewHashFromStr(fields[0])
+		if err != nil {
+			return rpcDecodeHexError(fields[0])
+		}
+		vout, err := strconv.ParseUint(fields[1], 10, 32)
+		if err != nil {
+			return restBadRequest("invalid output index %q", fields[1])
+		}
+		tree, err := strconv.ParseInt(fields[2], 10, 8)
+		if err != nil || !(int8(tree) == wire.TxTreeRegular ||
+			int8(tree) == wire.TxTreeStake) {
+
+			return restBadRequest("Tx tree must be regular or stake")
+		}
+		outpoints = append(outpoints, wire.OutPoint{
+			Hash:  *txHash,
+			Index: uint32(vout),
+			Tree:  int8(tree),
+		})
+	}
+
+	// Look up the outputs.  Outputs that are spent by a mempool transaction
+	// are treated as spent when the mempool is requested to be checked.
+	best := s.cfg.Chain.BestSnapshot()
+	bitmap := make([]byte, (len(outpoints)+7)/8)
+	utxos := make([]*types.GetTxOutResult, 0, len(outpoints))
+	for i := range outpoints {
+		outpoint := &outpoints[i]
+		if checkMempool && s.cfg.TxMempooler.FetchSpendingTx(outpoint) != nil {
+			continue
+		}
+		result, err := handleGetTxOut(ctx, s, &types.GetTxOutCmd{
+			Txid:           outpoint.Hash.String(),
+			Vout:           outpoint.Index,
+			Tree:           outpoint.Tree,
+			IncludeMempool: &checkMempool,
+		})
+		if err != nil {
+			var rpcErr *dcrjson.RPCError
+			if errors.As(err, &rpcErr) && rpcErr.Code == dcrjson.ErrRPCInvalidTxVout {
+				continue
+			}
+			return err
+		}
+		txOut, ok := result.(*types.GetTxOutResult)
+		if !ok || txOut == nil {
+			continue
+		}
+		bitmap[i/8] |= 1 << (i % 8)
+		utxos = append(utxos, txOut)
+	}
+
+	if format == restFormatJSON {
+		var bitmapStr strings.Builder
+		for i := range outpoints {
+			if bitmap[i/8]&(1<<(i%8)) != 0 {
+				bitmapStr.WriteByte('1')
+			} else {
+				bitmapStr.WriteByte('0')
+			}
+		}
+		return writeRESTJSON(w, &restGetUtxosResult{
+			ChainHeight:  best.Height,
+			ChainTipHash: best.Hash.String(),
+			Bitmap:       bitmapStr.String(),
+			Utxos:        utxos,
+		})
+	}
+
+	var buf bytes.Buffer
+	var scratch [4]byte
+	binary.LittleEndian.PutUint32(scratch[:], uint32(best.Height))
+	buf.Write(scratch[:])
+	buf.Write(best.Hash[:])
+	if err := wire.WriteVarBytes(&buf, 0, bitmap); err != nil {
+		return err
+	}
+	if err := wire.WriteVarInt(&buf, 0, uint64(len(utxos))); err != nil {
+		return err
+	}
+	for _, utxo := range utxos {
+		height := uint32(restMempoolHeight)
+		if utxo.Confirmations > 0 {
+			height = uint32(best.Height - utxo.Confirmations + 1)
+		}
+		amount, err := dcrutil.NewAmount(utxo.Value)
+		if err != nil {
+			return err
+		}
+		pkScript, err := hex.DecodeString(utxo.ScriptPubKey.Hex)
+		if err != nil {
+			return err
+		}
+		binary.LittleEndian.PutUint32(scratch[:], height)
+		buf.Write(scratch[:])
+		var valueAndVersion [10]byte
+		binary.LittleEndian.PutUint64(valueAndVersion[:8], uint64(amount))
+		binary.LittleEndian.PutUint16(valueAndVersion[8:],
+			utxo.ScriptPubKey.Version)
+		buf.Write(valueAndVersion[:])
+		if err := wire.WriteVarBytes(&buf, 0, pkScript); err != nil {
+			return err
+		}
+	}
+	return writeRESTBytes(w, format, buf.Bytes(), false)
+}
+
+// restRoute returns the HTTP server that serves the REST interface.
+func (s *Server) restRoute(ctx context.Context) *http.Server {
+	restServeMux := http.NewServeMux()
+	httpServer := &http.Server{
+		Handler: restServeMux,
+
+		// Use the provided context as the parent context for all requests to
+		// ensure handlers are able to react to both client disconnects as well
+		// as shutdown via the provided context.
+		BaseContext: func(l net.Listener) context.Context {
+			return ctx
+		},
+
+		// Timeout connections which don't send the request within the
+		// allowed timeframe.
+		ReadTimeout: time.Second * rpcAuthTimeoutSeconds,
+
+		// Reroute http server error logging through the rpcserver
+		// logger.
+		ErrorLog: stdlog.New(logForwarder{}, "", 0),
+	}
+	restServeMux.HandleFunc(restPathPrefix, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			writeRESTError(w, &restError{
+				code: http.StatusMethodNotAllowed,
+				msg:  "Method not allowed",
+			})
+			return
+		}
+
+		// Limit the number of connections to max allowed.
+		if s.limitConnections(w, r.RemoteAddr) {
+			return
+		}
+
+		// Keep track of the number of connected clients.
+		s.incrementClients()
+		defer s.decrementClients()
+
+		path := strings.TrimPrefix(r.URL.Path, restPathPrefix)
+		endpoint, param, format, err := parseRESTPath(path)
+		if err != nil {
+			writeRESTError(w, err)
+			return
+		}
+		handler, ok := restHandlers[endpoint]
+		if !ok {
+			writeRESTError(w, &restError{
+				code: http.StatusNotFound,
+				msg:  fmt.Sprintf("Unknown REST endpoint %q", endpoint),
+			})
+			return
+		}
+		log.Debugf("Received REST request for %s from %s", r.URL.Path,
+			r.RemoteAddr)
+		if err := handler(r.Context(), s, w, param, format); err != nil {
+			writeRESTError(w, err)
+		}
+	})
+	return httpServer
+}
@@ -0,0 +1,260 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpcserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/decred/dcrd/wire"
+)
+
+// TestRESTRoute ensures the REST interface returns the expected status codes,
+// headers, and responses for various requests.
+func TestRESTRoute(t *testing.T) {
+	t.Parallel()
+
+	var blkBuf bytes.Buffer
+	if err := block432100.Serialize(&blkBuf); err != nil {
+		t.Fatalf("unexpected error serializing block: %v", err)
+	}
+	blkBytes := blkBuf.Bytes()
+	blkHash := block432100.BlockHash().String()
+
+	var hdrBuf bytes.Buffer
+	if err := block432100.Header.Serialize(&hdrBuf); err != nil {
+		t.Fatalf("unexpected error serializing header: %v", err)
+	}
+	hdrBytes := hdrBuf.Bytes()
+
+	notFoundChain := defaultMockRPCChain()
+	notFoundChain.blockByHashErr = errors.New("block not found")
+	notFoundChain.blockHeightByHashErr = errors.New("block not found")
+
+	tests := []struct {
+		name      string        // test description
+		method    string        // HTTP method of the request
+		path      string        // request path
+		mockChain *testRPCChain // mock chain to use instead of the default
+		wantCode  int           // expected HTTP status code
+		wantType  string        // expected content type
+		wantCache string        // expected cache control header
+		wantBody  []byte        // expected body or nil to skip check
+	}{{
+		name:      "block binary",
+		method:    http.MethodGet,
+		path:      "/rest/block/" + blkHash + ".bin",
+		wantCode:  http.StatusOK,
+		wantType:  "application/octet-stream",
+		wantCache: restImmutableCacheControl,
+		wantBody:  blkBytes,
+	}, {
+		name:      "block hex",
+		method:    http.MethodGet,
+		path:      "/rest/block/" + blkHash + ".hex",
+		wantCode:  http.StatusOK,
+		wantType:  "text/plain; charset=utf-8",
+		wantCache: restImmutableCacheControl,
+		wantBody:  []byte(hex.EncodeToString(blkBytes) + "\n"),
+	}, {
+		name:     "block json",
+		method:   http.MethodGet,
+		path:     "/rest/block/" + blkHash + ".json",
+		wantCode: http.StatusOK,
+		wantType: "application/json",
+	}, {
+		name:     "block head request",
+		method:   http.MethodHead,
+		path:     "/rest/block/" + blkHash + ".json",
+		wantCode: http.StatusOK,
+		wantType: "application/json",
+	}, {
+		name:      "block not found",
+		method:    http.MethodGet,
+		path:      "/rest/block/" + blkHash + ".bin",
+		mockChain: notFoundChain,
+		wantCode:  http.StatusNotFound,
+		wantType:  "text/plain; charset=utf-8",
+	}, {
+		name:     "block invalid hash",
+		method:   http.MethodGet,
+		path:     "/rest/block/zz.bin",
+		wantCode: http.StatusBadRequest,
+		wantType: "text/plain; charset=utf-8",
+	}, {
+		name:      "headers binary",
+		method:    http.MethodGet,
+		path:      "/rest/headers/1/" + blkHash + ".bin",
+		wantCode:  http.StatusOK,
+		wantType:  "application/octet-stream",
+		wantCache: restImmutableCacheControl,
+		wantBody:  hdrBytes,
+	}, {
+		name:     "headers more than available",
+		method:   http.MethodGet,
+		path:     "/rest/headers/5/" + blkHash + ".bin",
+		wantCode: http.StatusOK,
+		wantType: "application/octet-stream",
+		wantBody: hdrBytes,
+	}, {
+		name:     "headers json",
+		method:   http.MethodGet,
+		path:     "/rest/headers/1/" + blkHash + ".json",
+		wantCode: http.StatusOK,
+		wantType: "application/json",
+	}, {
+		name:     "headers count out of range",
+		method:   http.MethodGet,
+		path:     "/rest/headers/2001/" + blkHash + ".bin",
+		wantCode: http.StatusBadRequest,
+		wantType: "text/plain; charset=utf-8",
+	}, {
+		name:     "headers missing count",
+		method:   http.MethodGet,
+		path:     "/rest/headers/" + blkHash + ".bin",
+		wantCode: http.StatusBadRequest,
+		wantType: "text/plain; charset=utf-8",
+	}, {
+		name:      "headers not in main chain",
+		method:    http.MethodGet,
+		path:      "/rest/headers/1/" + blkHash + ".bin",
+		mockChain: notFoundChain,
+		wantCode:  http.StatusNotFound,
+		wantType:  "text/plain; charset=utf-8",
+	}, {
+		name:     "chaininfo json",
+		method:   http.MethodGet,
+		path:     "/rest/chaininfo.json",
+		wantCode: http.StatusOK,
+		wantType: "application/json",
+	}, {
+		name:     "chaininfo binary not supported",
+		method:   http.MethodGet,
+		path:     "/rest/chaininfo.bin",
+		wantCode: http.StatusBadRequest,
+		wantType: "text/plain; charset=utf-8",
+	}, {
+		name:     "getutxos no outpoints",
+		method:   http.MethodGet,
+		path:     "/rest/getutxos/checkmempool.bin",
+		wantCode: http.StatusBadRequest,
+		wantType: "text/plain; charset=utf-8",
+	}, {
+		name:     "getutxos invalid outpoint",
+		method:   http.MethodGet,
+		path:     "/rest/getutxos/" + blkHash + "-0.bin",
+		wantCode: http.StatusBadRequest,
+		wantType: "text/plain; charset=utf-8",
+	}, {
+		name:     "getutxos invalid tree",
+		method:   http.MethodGet,
+		path:     "/rest/getutxos/" + blkHash + "-0-2.bin",
+		wantCode: http.StatusBadRequest,
+		wantType: "text/plain; charset=utf-8",
+	}, {
+		name:     "missing format",
+		method:   http.MethodGet,
+		path:     "/rest/block/" + blkHash,
+		wantCode: http.StatusBadRequest,
+		wantType: "text/plain; charset=utf-8",
+	}, {
+		name:     "unknown format",
+		method:   http.MethodGet,
+		path:     "/rest/block/" + blkHash + ".xml",
+		wantCode: http.StatusBadRequest,
+		wantType: "text/plain; charset=utf-8",
+	}, {
+		name:     "unknown endpoint",
+		method:   http.MethodGet,
+		path:     "/rest/blocks/" + blkHash + ".bin",
+		wantCode: http.StatusNotFound,
+		wantType: "text/plain; charset=utf-8",
+	}, {
+		name:     "method not allowed",
+		method:   http.MethodPost,
+		path:     "/rest/block/" + blkHash + ".bin",
+		wantCode: http.StatusMethodNotAllowed,
+		wantType: "text/plain; charset=utf-8",
+	}}
+
+	for _, test := range tests {
+		cfg := defaultMockConfig(defaultChainParams)
+		cfg.RPCMaxClients = 1
+		if test.mockChain != nil {
+			cfg.Chain = test.mockChain
+		}
+		s := &Server{
+			cfg:       *cfg,
+			ntfnMgr:   new(testNtfnManager),
+			workState: newWorkState(),
+		}
+		handler := s.restRoute(context.Background()).Handler
+
+		req := httptest.NewRequest(test.method, test.path, nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		resp := rec.Result()
+		if resp.StatusCode != test.wantCode {
+			t.Errorf("%q: unexpected status code -- got %d, want %d (body %q)",
+				test.name, resp.StatusCode, test.wantCode, rec.Body.String())
+			continue
+		}
+		if gotType := resp.Header.Get("Content-Type"); gotType != test.wantType {
+			t.Errorf("%q: unexpected content type -- got %q, want %q",
+				test.name, gotType, test.wantType)
+			continue
+		}
+		if gotCache := resp.Header.Get("Cache-Control"); gotCache != test.wantCache {
+			t.Errorf("%q: unexpected cache control -- got %q, want %q",
+				test.name, gotCache, test.wantCache)
+			continue
+		}
+		if test.wantBody != nil && !bytes.Equal(rec.Body.Bytes(), test.wantBody) {
+			t.Errorf("%q: unexpected body -- got %x, want %x", test.name,
+				rec.Body.Bytes(), test.wantBody)
+			continue
+		}
+	}
+}
+
+// TestRESTGetUtxosBinary ensures the binary encoding of the REST getutxos
+// endpoint is as expected when none of the requested outputs are unspent.
+func TestRESTGetUtxosBinary(t *testing.T) {
+	t.Parallel()
+
+	chain := defaultMockRPCChain()
+	chain.fetchUtxoEntry = nil
+	cfg := defaultMockConfig(defaultChainParams)
+	cfg.RPCMaxClients = 1
+	cfg.Chain = chain
+	s := &Server{cfg: *cfg}
+	handler := s.restRoute(context.Background()).Handler
+
+	txHash := block432100.Transactions[0].TxHash().String()
+	path := "/rest/getutxos/" + txHash + "-0-0/" + txHash + "-1-0.bin"
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status code -- got %d, want %d (body %q)",
+			rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	best := chain.bestSnapshot
+	var want bytes.Buffer
+	want.Write([]byte{0xe4, 0x97, 0x06, 0x00}) // height 432100
+	want.Write(best.Hash[:])
+	wire.WriteVarBytes(&want, 0, []byte{0x00})
+	wire.WriteVarInt(&want, 0, 0)
+	if !bytes.Equal(rec.Body.Bytes(), want.Bytes()) {
+		t.Fatalf("unexpected body -- got %x, want %x", rec.Body.Bytes(),
+			want.Bytes())
+	}
+}
@@ -1,5 +1,5 @@
 // Copyright (c) 2013-2016 The btcsuite developers
-// Copyright (c) 2015-2023 The Decred developers
+// Copyright (c) 2015-2024 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
@@ -47,12 +47,17 @@ import (
 	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
 	"github.com/decred/dcrd/dcrjson/v4"
 	"github.com/decred/dcrd/dcrutil/v4"
+	"github.com/decred/dcrd/hdkeychain/v3"
+	"github.com/decred/dcrd/hdkeychain/v3/descriptor"
 	"github.com/decred/dcrd/internal/blockchain"
+	"github.com/decred/dcrd/internal/blockchain/indexers"
 	"github.com/decred/dcrd/internal/mempool"
 	"github.com/decred/dcrd/internal/mining"
+	"github.com/decred/dcrd/internal/staging/banmanager"
 	"github.com/decred/dcrd/internal/version"
 	"github.com/decred/dcrd/rpc/jsonrpc/types/v4"
 	"github.com/decred/dcrd/txscript/v4"
+	"github.com/decred/dcrd/txscript/v4/psdt"
 	"github.com/decred/dcrd/txscript/v4/stdaddr"
 	"github.com/decred/dcrd/txscript/v4/stdscript"
 	"github.com/decred/dcrd/wire"
@@ -120,6 +125,26 @@ const (
 	// syncWait is the maximum time in seconds to wait for an index
 	// to sync with the main chain.
 	syncWait = time.Second * 3
+
+	// maxAddrTxnsPerRequest is the maximum number of transactions that may be
+	// requested in a single searchrawtransactions or getaddresstxids request.
+	maxAddrTxnsPerRequest = 10000
+
+	// maxDeriveAddresses is the maximum number of addresses that may be
+	// derived in a single deriveaddresses request.
+	maxDeriveAddresses = 10000
+
+	// maxTraceTxInputSize is the maximum approximate serialized size of the
+	// steps included in a tracetxinput result.  Each step includes copies of
+	// the full stacks before and after the opcode, so scripts that manipulate
+	// large or many stack items would otherwise result in huge responses.
+	maxTraceTxInputSize = 1 << 24 // 16 MiB
+
+	// traceStepOverhead is the approximate serialized size of a tracetxinput
+	// step excluding the stack items and traceItemOverhead is the approximate
+	// serialized size of a stack item excluding its hex-encoded data.
+	traceStepOverhead = 256
+	traceItemOverhead = 3
 )
 
 var (
@@ -162,6 +187,13 @@ var (
 		Code:    dcrjson.ErrRPCNoWallet,
 		Message: "This implementation does not implement wallet commands",
 	}
+
+	// ErrRPCLightMode is an error returned to RPC clients when the provided
+	// command is not available when running in light mode.
+	ErrRPCLightMode = &dcrjson.RPCError{
+		Code:    dcrjson.ErrRPCMisc,
+		Message: "Command unavailable in light mode",
+	}
 )
 
 type commandHandler func(context.Context, *Server, interface{}) (interface{}, error)
@@ -173,11 +205,15 @@ var rpcHandlers map[types.Method]commandHandler
 var rpcHandlersBeforeInit = map[types.Method]commandHandler{
 	"addnode":               handleAddNode,
 	"createrawsstx":         handleCreateRawSStx,
+	"clearbanned":           handleClearBanned,
 	"createrawssrtx":        handleCreateRawSSRtx,
+	"combinepsdt":           handleCombinePSDT,
 	"createrawtransaction":  handleCreateRawTransaction,
 	"debuglevel":            handleDebugLevel,
+	"decodepsdt":            handleDecodePSDT,
 	"decoderawtransaction":  handleDecodeRawTransaction,
 	"decodescript":          handleDecodeScript,
+	"deriveaddresses":       handleDeriveAddresses,
 	"estimatefee":           handleEstimateFee,
 	"estimatesmartfee":      handleEstimateSmartFee,
 	"estimatestakediff":     handleEstimateStakeDiff,
@@ -186,8 +222,10 @@ var rpcHandlersBeforeInit = map[types.Method]commandHandler{
 	"existsliveticket":      handleExistsLiveTicket,
 	"existslivetickets":     handleExistsLiveTickets,
 	"existsmempooltxs":      handleExistsMempoolTxs,
+	"finalizepsdt":          handleFinalizePSDT,
 	"generate":              handleGenerate,
 	"getaddednodeinfo":      handleGetAddedNodeInfo,
+	"getaddresstxids":       handleGetAddressTxIDs,
 	"getbestblock":          handleGetBestBlock,
 	"getbestblockhash":      handleGetBestBlockHash,
 	"getblock":              handleGetBlock,
@@ -195,12 +233,14 @@ var rpcHandlersBeforeInit = map[types.Method]commandHandler{
 	"getblockcount":         handleGetBlockCount,
 	"getblockhash":          handleGetBlockHash,
 	"getblockheader":        handleGetBlockHeader,
+	"getblockstats":         handleGetBlockStats,
 	"getblocksubsidy":       handleGetBlockSubsidy,
 	"getcfilterv2":          handleGetCFilterV2,
 	"getchaintips":          handleGetChainTips,
 	"getcoinsupply":         handleGetCoinSupply,
 	"getconnectioncount":    handleGetConnectionCount,
 	"getcurrentnet":         handleGetCurrentNet,
+	"getdescriptorinfo":     handleGetDescriptorInfo,
 	"getdifficulty":         handleGetDifficulty,
 	"getgenerate":           handleGetGenerate,
 	"gethashespersec":       handleGetHashesPerSec,
@@ -211,6 +251,7 @@ var rpcHandlersBeforeInit = map[types.Method]commandHandler{
 	"getnettotals":          handleGetNetTotals,
 	"getnetworkhashps":      handleGetNetworkHashPS,
 	"getnetworkinfo":        handleGetNetworkInfo,
+	"getnodeaddresses":      handleGetNodeAddresses,
 	"getpeerinfo":           handleGetPeerInfo,
 	"getrawmempool":         handleGetRawMempool,
 	"getrawtransaction":     handleGetRawTransaction,
@@ -223,21 +264,28 @@ var rpcHandlersBeforeInit = map[types.Method]commandHandler{
 	"getvoteinfo":           handleGetVoteInfo,
 	"gettxout":              handleGetTxOut,
 	"gettxoutsetinfo":       handleGetTxOutSetInfo,
+	"gettxspendingprevout":  handleGetTxSpendingPrevOut,
 	"getwork":               handleGetWork,
 	"help":                  handleHelp,
 	"invalidateblock":       handleInvalidateBlock,
+	"listbanned":            handleListBanned,
 	"livetickets":           handleLiveTickets,
+	"loadmempool":           handleLoadMempool,
 	"node":                  handleNode,
 	"ping":                  handlePing,
 	"reconsiderblock":       handleReconsiderBlock,
 	"regentemplate":         handleRegenTemplate,
+	"savemempool":           handleSaveMempool,
+	"searchrawtransactions": handleSearchRawTransactions,
 	"sendrawtransaction":    handleSendRawTransaction,
+	"setban":                handleSetBan,
 	"setgenerate":           handleSetGenerate,
 	"stop":                  handleStop,
 	"submitblock":           handleSubmitBlock,
 	"ticketfeeinfo":         handleTicketFeeInfo,
 	"ticketsforaddress":     handleTicketsForAddress,
 	"ticketvwap":            handleTicketVWAP,
+	"tracetxinput":          handleTraceTxInput,
 	"txfeeinfo":             handleTxFeeInfo,
 	"validateaddress":       handleValidateAddress,
 	"verifychain":           handleVerifyChain,
@@ -334,71 +382,120 @@ var rpcUnimplemented = map[string]struct{}{
 	"estimatepriority": {},
 }
 
-// Commands that are available to a limited user
-var rpcLimited = map[string]struct{}{
-	// Websockets commands
-	"notifyblocks":          {},
-	"notifynewtransactions": {},
-	"rescan":                {},
-	"session":               {},
-	"rebroadcastwinners":    {},
-
-	// Websockets AND HTTP/S commands
-	"help": {},
-
-	// HTTP/S-only commands
-	"createrawsstx":        {},
-	"createrawssrtx":       {},
-	"createrawtransaction": {},
+// Commands that are available when running in light mode.  The blocks are not
+// connected in light mode, so only the commands that rely on the block headers,
+// the committed filters, or the network state make sense.
+var rpcLightMode = map[string]struct{}{
+	"addnode":              {},
+	"combinepsdt":          {},
+	"debuglevel":           {},
+	"decodepsdt":           {},
 	"decoderawtransaction": {},
 	"decodescript":         {},
-	"estimatefee":          {},
-	"estimatesmartfee":     {},
-	"estimatestakediff":    {},
-	"existsaddress":        {},
-	"existsaddresses":      {},
-	"existsliveticket":     {},
-	"existslivetickets":    {},
-	"existsmempooltxs":     {},
+	"deriveaddresses":      {},
+	"finalizepsdt":         {},
+	"getaddednodeinfo":     {},
 	"getbestblock":         {},
 	"getbestblockhash":     {},
-	"getblock":             {},
 	"getblockchaininfo":    {},
 	"getblockcount":        {},
-	"getblockhash":         {},
 	"getblockheader":       {},
-	"getblocksubsidy":      {},
 	"getcfilterv2":         {},
 	"getchaintips":         {},
-	"getcoinsupply":        {},
+	"getconnectioncount":   {},
 	"getcurrentnet":        {},
-	"getdifficulty":        {},
-	"getheaders":           {},
-	"getinfo":              {},
+	"getdescriptorinfo":    {},
 	"getnettotals":         {},
-	"getnetworkhashps":     {},
 	"getnetworkinfo":       {},
-	"getrawmempool":        {},
+	"getnodeaddresses":     {},
+	"getpeerinfo":          {},
 	"getstakedifficulty":   {},
-	"getstakeversioninfo":  {},
-	"getstakeversions":     {},
-	"getrawtransaction":    {},
-	"gettreasurybalance":   {},
-	"gettxout":             {},
-	"getvoteinfo":          {},
-	"livetickets":          {},
-	"regentemplate":        {},
-	"sendrawtransaction":   {},
-	"submitblock":          {},
-	"ticketfeeinfo":        {},
-	"ticketsforaddress":    {},
-	"ticketvwap":           {},
-	"txfeeinfo":            {},
+	"help":                 {},
+	"node":                 {},
+	"ping":                 {},
+	"session":              {},
+	"stop":                 {},
+	"uptime":               {},
 	"validateaddress":      {},
 	"verifymessage":        {},
 	"version":              {},
 }
 
+// Commands that are available to a limited user
+var rpcLimited = map[string]struct{}{
+	// Websockets commands
+	"notifyblocks":          {},
+	"notifynewtransactions": {},
+	"rescan":                {},
+	"session":               {},
+	"rebroadcastwinners":    {},
+
+	// Websockets AND HTTP/S commands
+	"help": {},
+
+	// HTTP/S-only commands
+	"combinepsdt":           {},
+	"createrawsstx":         {},
+	"createrawssrtx":        {},
+	"createrawtransaction":  {},
+	"decodepsdt":            {},
+	"decoderawtransaction":  {},
+	"decodescript":          {},
+	"deriveaddresses":       {},
+	"estimatefee":           {},
+	"estimatesmartfee":      {},
+	"estimatestakediff":     {},
+	"existsaddress":         {},
+	"existsaddresses":       {},
+	"existsliveticket":      {},
+	"existslivetickets":     {},
+	"existsmempooltxs":      {},
+	"finalizepsdt":          {},
+	"getaddresstxids":       {},
+	"getbestblock":          {},
+	"getbestblockhash":      {},
+	"getblock":              {},
+	"getblockchaininfo":     {},
+	"getblockcount":         {},
+	"getblockhash":          {},
+	"getblockheader":        {},
+	"getblockstats":         {},
+	"getblocksubsidy":       {},
+	"getcfilterv2":          {},
+	"getchaintips":          {},
+	"getcoinsupply":         {},
+	"getcurrentnet":         {},
+	"getdescriptorinfo":     {},
+	"getdifficulty":         {},
+	"getheaders":            {},
+	"getinfo":               {},
+	"getnettotals":          {},
+	"getnetworkhashps":      {},
+	"getnetworkinfo":        {},
+	"getrawmempool":         {},
+	"getstakedifficulty":    {},
+	"getstakeversioninfo":   {},
+	"getstakeversions":      {},
+	"getrawtransaction":     {},
+	"gettreasurybalance":    {},
+	"gettxout":              {},
+	"gettxspendingprevout":  {},
+	"getvoteinfo":           {},
+	"livetickets":           {},
+	"regentemplate":         {},
+	"searchrawtransactions": {},
+	"sendrawtransaction":    {},
+	"submitblock":           {},
+	"ticketfeeinfo":         {},
+	"ticketsforaddress":     {},
+	"ticketvwap":            {},
+	"tracetxinput":          {},
+	"txfeeinfo":             {},
+	"validateaddress":       {},
+	"verifymessage":         {},
+	"version":               {},
+}
+
 // rpcInternalErr is a convenience function to convert an internal error to an
 // RPC error with the appropriate code set.  It also logs the error to the RPC
 // server subsystem since internal errors really should not occur.  The context
@@ -478,6 +575,24 @@ func rpcBlockNotFoundError(blockHash chainhash.Hash) *dcrjson.RPCError {
 			blockHash))
 }
 
+// rpcBlockPrunedError is a convenience function for returning a nicely
+// formatted RPC error which indicates that the data for a requested block is
+// no longer available because it has been pruned.
+func rpcBlockPrunedError() *dcrjson.RPCError {
+	return rpcMiscError("Block not available (pruned data)")
+}
+
+// isBlockDataPruned returns whether or not the data for the main chain block
+// with the provided hash has been pruned.
+func isBlockDataPruned(chain Chain, blockHash *chainhash.Hash) bool {
+	pruneHeight := chain.PruneHeight()
+	if pruneHeight == 0 {
+		return false
+	}
+	height, err := chain.BlockHeightByHash(blockHash)
+	return err == nil && height > 0 && height < pruneHeight
+}
+
 // rpcConnectionClosedError is a convenience function for returning an RPC error
 // which indicates the associated connection has been closed, most likely due to
 // context cancellation such as when the server is being shutdown.
@@ -973,6 +1088,42 @@ func handleCreateRawSStx(_ context.Context, s *Server, cmd interface{}) (interfa
 	return mtxHex, nil
 }
 
+// handleClearBanned implements the clearbanned command.
+func handleClearBanned(_ context.Context, s *Server, _ interface{}) (interface{}, error) {
+	if err := s.cfg.ConnMgr.ClearBanned(); err != nil {
+		return nil, rpcInternalErr(err, "Unable to clear ban list")
+	}
+	return nil, nil
+}
+
+// handleCombinePSDT handles combinepsdt commands.
+func handleCombinePSDT(_ context.Context, _ *Server, cmd interface{}) (interface{}, error) {
+	c := cmd.(*types.CombinePSDTCmd)
+	if len(c.PSDTs) == 0 {
+		return nil, rpcInvalidError("No partially signed transactions to " +
+			"combine")
+	}
+
+	packets := make([]*psdt.Packet, 0, len(c.PSDTs))
+	for _, encoded := range c.PSDTs {
+		p, err := psdt.ParseBase64(encoded)
+		if err != nil {
+			return nil, rpcDeserializationError("Could not decode PSDT: %v",
+				err)
+		}
+		packets = append(packets, p)
+	}
+	combined, err := psdt.Combine(packets...)
+	if err != nil {
+		return nil, rpcInvalidError("Could not combine PSDTs: %v", err)
+	}
+	encoded, err := combined.Base64()
+	if err != nil {
+		return nil, rpcInternalErr(err, "Could not encode PSDT")
+	}
+	return encoded, nil
+}
+
 // handleCreateRawSSRtx handles createrawssrtx commands.
 func handleCreateRawSSRtx(_ context.Context, s *Server, cmd interface{}) (interface{}, error) {
 	c := cmd.(*types.CreateRawSSRtxCmd)
@@ -1327,6 +1478,191 @@ func (s *Server) createTxRawResult(chainParams *chaincfg.Params,
 	return txReply, nil
 }
 
+// sigHashTypeStrings is a map of signature hash types to their human-readable
+// names.
+var sigHashTypeStrings = map[txscript.SigHashType]string{
+	txscript.SigHashAll:                                   "ALL",
+	txscript.SigHashNone:                                  "NONE",
+	txscript.SigHashSingle:                                "SINGLE",
+	txscript.SigHashAll | txscript.SigHashAnyOneCanPay:    "ALL|ANYONECANPAY",
+	txscript.SigHashNone | txscript.SigHashAnyOneCanPay:   "NONE|ANYONECANPAY",
+	txscript.SigHashSingle | txscript.SigHashAnyOneCanPay: "SINGLE|ANYONECANPAY",
+}
+
+// scriptPubKeyResult returns the JSON representation of the provided public
+// key script.
+func (s *Server) scriptPubKeyResult(version uint16, pkScript []byte) types.ScriptPubKeyResult {
+	// The disassembled string will contain [error] inline if the script
+	// doesn't fully parse, so ignore the error here.
+	disbuf, _ := txscript.DisasmString(pkScript)
+	scriptType, addrs := stdscript.ExtractAddrs(version, pkScript,
+		s.cfg.ChainParams)
+	addresses := make([]string, len(addrs))
+	for i, addr := range addrs {
+		addresses[i] = addr.String()
+	}
+	reqSigs := stdscript.DetermineRequiredSigs(version, pkScript)
+	return types.ScriptPubKeyResult{
+		Asm:       disbuf,
+		Hex:       hex.EncodeToString(pkScript),
+		ReqSigs:   int32(reqSigs),
+		Type:      scriptType.String(),
+		Addresses: addresses,
+		Version:   version,
+	}
+}
+
+// psdtScriptResult returns the decodepsdt result for the provided version 0
+// script of a partially signed transaction.
+func psdtScriptResult(script []byte) *types.PSDTScript {
+	// The disassembled string will contain [error] inline if the script
+	// doesn't fully parse, so ignore the error here.
+	disbuf, _ := txscript.DisasmString(script)
+	return &types.PSDTScript{
+		Asm:  disbuf,
+		Hex:  hex.EncodeToString(script),
+		Type: stdscript.DetermineScriptType(0, script).String(),
+	}
+}
+
+// psdtDerivationResults returns the decodepsdt results for the provided BIP32
+// derivations of a partially signed transaction.
+func psdtDerivationResults(derivations []*psdt.Bip32Derivation) []types.PSDTBip32Derivation {
+	// hardenedKeyStart is the index of the first hardened child key.  It is
+	// the same as hdkeychain.HardenedKeyStart.
+	const hardenedKeyStart = 0x80000000
+
+	results := make([]types.PSDTBip32Derivation, 0, len(derivations))
+	for _, d := range derivations {
+		var path strings.Builder
+		path.WriteString("m")
+		for _, index := range d.Path {
+			if index >= hardenedKeyStart {
+				fmt.Fprintf(&path, "/%d'", index-hardenedKeyStart)
+				continue
+			}
+			fmt.Fprintf(&path, "/%d", index)
+		}
+		var fingerprint [4]byte
+		binary.BigEndian.PutUint32(fingerprint[:], d.MasterKeyFingerprint)
+		results = append(results, types.PSDTBip32Derivation{
+			PubKey:            hex.EncodeToString(d.PubKey),
+			MasterFingerprint: hex.EncodeToString(fingerprint[:]),
+			Path:              path.String(),
+		})
+	}
+	return results
+}
+
+// psdtUnknownResults returns the decodepsdt results for the provided unknown
+// key-value pairs of a partially signed transaction.
+func psdtUnknownResults(unknowns []*psdt.Unknown) map[string]string {
+	if len(unknowns) == 0 {
+		return nil
+	}
+	results := make(map[string]string, len(unknowns))
+	for _, u := range unknowns {
+		results[hex.EncodeToString(u.Key)] = hex.EncodeToString(u.Value)
+	}
+	return results
+}
+
+// handleDecodePSDT handles decodepsdt commands.
+func handleDecodePSDT(_ context.Context, s *Server, cmd interface{}) (interface{}, error) {
+	c := cmd.(*types.DecodePSDTCmd)
+
+	p, err := psdt.ParseBase64(c.PSDT)
+	if err != nil {
+		return nil, rpcDeserializationError("Could not decode PSDT: %v", err)
+	}
+
+	// Determine if the treasury rules are active as of the current best tip.
+	prevBlkHash := s.cfg.Chain.BestSnapshot().Hash
+	isTreasuryEnabled, err := s.isTreasuryAgendaActive(&prevBlkHash)
+	if err != nil {
+		return nil, err
+	}
+
+	mtx := p.UnsignedTx
+	result := types.DecodePSDTResult{
+		Tx: types.TxRawDecodeResult{
+			Txid:     mtx.TxHash().String(),
+			Version:  int32(mtx.Version),
+			Locktime: mtx.LockTime,
+			Expiry:   mtx.Expiry,
+			Vin:      createVinList(mtx, isTreasuryEnabled),
+			Vout:     createVoutList(mtx, s.cfg.ChainParams, nil),
+		},
+		TxType:  p.TxType.String(),
+		Unknown: psdtUnknownResults(p.Unknowns),
+		Inputs:  make([]types.DecodePSDTInput, 0, len(p.Inputs)),
+		Outputs: make([]types.DecodePSDTOutput, 0, len(p.Outputs)),
+	}
+	for i := range p.Inputs {
+		in := &p.Inputs[i]
+		input := types.DecodePSDTInput{
+			Unknown: psdtUnknownResults(in.Unknowns),
+		}
+		if in.PrevOut != nil {
+			amount := dcrutil.Amount(in.PrevOut.Value)
+			input.PrevOut = &types.PSDTPrevOut{
+				Amount: amount.ToCoin(),
+				ScriptPubKey: s.scriptPubKeyResult(in.PrevOut.Version,
+					in.PrevOut.PkScript),
+			}
+		}
+		if len(in.PartialSigs) > 0 {
+			input.PartialSignatures = make(map[string]string,
+				len(in.PartialSigs))
+			for _, ps := range in.PartialSigs {
+				pubKey := hex.EncodeToString(ps.PubKey)
+				input.PartialSignatures[pubKey] = hex.EncodeToString(ps.Signature)
+			}
+		}
+		if in.SigHashType != 0 {
+			hashType, ok := sigHashTypeStrings[in.SigHashType]
+			if !ok {
+				hashType = fmt.Sprintf("%#x", uint32(in.SigHashType))
+			}
+			input.SigHashType = hashType
+		}
+		if in.RedeemScript != nil {
+			input.RedeemScript = psdtScriptResult(in.RedeemScript)
+		}
+		if len(in.Bip32Derivations) > 0 {
+			input.Bip32Derivations = psdtDerivationResults(in.Bip32Derivations)
+		}
+		if in.FinalScriptSig != nil {
+			// The disassembled string will contain [error] inline if the
+			// script doesn't fully parse, so ignore the error here.
+			disbuf, _ := txscript.DisasmString(in.FinalScriptSig)
+			input.FinalScriptSig = &types.ScriptSig{
+				Asm: disbuf,
+				Hex: hex.EncodeToString(in.FinalScriptSig),
+			}
+		}
+		result.Inputs = append(result.Inputs, input)
+	}
+	for i := range p.Outputs {
+		out := &p.Outputs[i]
+		output := types.DecodePSDTOutput{
+			Unknown: psdtUnknownResults(out.Unknowns),
+		}
+		if out.RedeemScript != nil {
+			output.RedeemScript = psdtScriptResult(out.RedeemScript)
+		}
+		if len(out.Bip32Derivations) > 0 {
+			output.Bip32Derivations = psdtDerivationResults(out.Bip32Derivations)
+		}
+		result.Outputs = append(result.Outputs, output)
+	}
+	if fee, ok := p.Fee(); ok {
+		feeCoin := dcrutil.Amount(fee).ToCoin()
+		result.Fee = &feeCoin
+	}
+	return result, nil
+}
+
 // handleDecodeRawTransaction handles decoderawtransaction commands.
 func handleDecodeRawTransaction(_ context.Context, s *Server, cmd interface{}) (interface{}, error) {
 	c := cmd.(*types.DecodeRawTransactionCmd)
@@ -1425,6 +1761,74 @@ func handleDecodeScript(_ context.Context, s *Server, cmd interface{}) (interfac
 	return reply, nil
 }
 
+// handleDeriveAddresses implements the deriveaddresses command.
+func handleDeriveAddresses(_ context.Context, s *Server, cmd interface{}) (interface{}, error) {
+	c := cmd.(*types.DeriveAddressesCmd)
+
+	// Require a checksum since the derived addresses are typically used to
+	// receive funds and a typo in the descriptor would result in addresses
+	// that do not belong to the caller.
+	if !strings.Contains(c.Descriptor, "#") {
+		return nil, rpcInvalidError("Missing descriptor checksum")
+	}
+	desc, err := descriptor.Parse(c.Descriptor, s.cfg.ChainParams)
+	if err != nil {
+		return nil, rpcInvalidError("Invalid descriptor: %v", err)
+	}
+
+	// Determine the range of child indices to derive.  Ranged descriptors
+	// require a range that is either the end or the beginning and end of the
+	// range while other descriptors must not specify one.
+	var begin, end uint32
+	switch {
+	case desc.IsRange() && c.Range == nil:
+		return nil, rpcInvalidError("Range must be specified for a ranged " +
+			"descriptor")
+
+	case !desc.IsRange() && c.Range != nil:
+		return nil, rpcInvalidError("Range must not be specified for a " +
+			"descriptor that is not ranged")
+
+	case c.Range != nil:
+		switch derivRange := *c.Range; len(derivRange) {
+		case 1:
+			end = derivRange[0]
+		case 2:
+			begin, end = derivRange[0], derivRange[1]
+		default:
+			return nil, rpcInvalidError("Range must either be the end or " +
+				"the beginning and end of the range")
+		}
+		if begin > end {
+			return nil, rpcInvalidError("Range beginning %d is after the "+
+				"end %d", begin, end)
+		}
+		if end >= hdkeychain.HardenedKeyStart {
+			return nil, rpcInvalidError("Range end %d must be less than %d",
+				end, uint32(hdkeychain.HardenedKeyStart))
+		}
+		if end-begin >= maxDeriveAddresses {
+			return nil, rpcInvalidError("Range is limited to %d addresses",
+				maxDeriveAddresses)
+		}
+	}
+
+	addrs := make([]string, 0, end-begin+1)
+	for i := begin; i <= end; i++ {
+		output, err := desc.Expand(i, s.cfg.ChainParams)
+		if err != nil {
+			return nil, rpcInvalidError("Unable to derive address at index "+
+				"%d: %v", i, err)
+		}
+		if output.Address == nil {
+			return nil, rpcInvalidError("Descriptor does not have a " +
+				"corresponding address")
+		}
+		addrs = append(addrs, output.Address.String())
+	}
+	return addrs, nil
+}
+
 // handleEstimateFee implements the estimatefee command.
 // TODO this is a very basic implementation.  It should be
 // modified to match the bitcoin-core one.
@@ -1748,6 +2152,43 @@ func handleExistsMempoolTxs(_ context.Context, s *Server, cmd interface{}) (inte
 	return hex.EncodeToString([]byte(set)), nil
 }
 
+// handleFinalizePSDT handles finalizepsdt commands.
+func handleFinalizePSDT(_ context.Context, _ *Server, cmd interface{}) (interface{}, error) {
+	c := cmd.(*types.FinalizePSDTCmd)
+
+	p, err := psdt.ParseBase64(c.PSDT)
+	if err != nil {
+		return nil, rpcDeserializationError("Could not decode PSDT: %v", err)
+	}
+
+	// Finalize as many inputs as possible.  Inputs that can't be finalized
+	// yet, such as those that do not have enough signatures, are left as is
+	// and are reflected by the packet not being complete.
+	for idx := range p.Inputs {
+		_ = p.Finalize(idx)
+	}
+
+	result := types.FinalizePSDTResult{Complete: p.IsComplete()}
+	if result.Complete && (c.Extract == nil || *c.Extract) {
+		tx, err := p.Extract()
+		if err != nil {
+			return nil, rpcInternalErr(err, "Could not extract transaction")
+		}
+		txBytes, err := tx.Bytes()
+		if err != nil {
+			return nil, rpcInternalErr(err, "Could not serialize transaction")
+		}
+		result.Hex = hex.EncodeToString(txBytes)
+		return result, nil
+	}
+	encoded, err := p.Base64()
+	if err != nil {
+		return nil, rpcInternalErr(err, "Could not encode PSDT")
+	}
+	result.PSDT = encoded
+	return result, nil
+}
+
 // handleGenerate handles generate commands.
 func handleGenerate(ctx context.Context, s *Server, cmd interface{}) (interface{}, error) {
 	// Respond with an error if there are no addresses to pay the
@@ -1870,22 +2311,195 @@ func handleGetAddedNodeInfo(_ context.Context, s *Server, cmd interface{}) (inte
 	return results, nil
 }
 
+// addrTxn houses a transaction that involves an address along with the hash
+// of the block that contains it and its index within that block.  The block
+// hash is nil for unconfirmed transactions.
+type addrTxn struct {
+	tx       *wire.MsgTx
+	blkHash  *chainhash.Hash
+	blkIndex uint32
+}
+
+// fetchAddrTxns returns the transactions that involve the provided address
+// from both the address index and the memory pool after skipping the provided
+// number of transactions.  Confirmed transactions are ordered by their
+// position in the main chain and are followed by unconfirmed transactions in
+// the order they were added to the memory pool.  The entire order is reversed
+// when the reverse flag is set so that the most recent transactions come
+// first.
+func (s *Server) fetchAddrTxns(addrStr string, skip, count *int, reverse bool) ([]addrTxn, error) {
+	addrIndex := s.cfg.AddrIndexer
+	if addrIndex == nil {
+		err := errors.New("the address index must be enabled to query " +
+			"transactions by address (specify --addrindex)")
+		return nil, rpcInternalErr(err, "Configuration")
+	}
+
+	// Decode the provided address.  This also ensures the network encoded with
+	// the address matches the network the server is currently on.
+	addr, err := stdaddr.DecodeAddress(addrStr, s.cfg.ChainParams)
+	if err != nil {
+		return nil, rpcAddressKeyError("Could not decode address: %v", err)
+	}
+
+	// Validate the paging parameters.
+	var numToSkip uint32
+	if skip != nil {
+		if *skip < 0 {
+			return nil, rpcInvalidError("Skip must not be negative: %d",
+				*skip)
+		}
+		numToSkip = uint32(*skip)
+	}
+	numRequested := uint32(100)
+	if count != nil {
+		if *count < 0 || *count > maxAddrTxnsPerRequest {
+			return nil, rpcInvalidError("Count must be between 0 and %d: %d",
+				maxAddrTxnsPerRequest, *count)
+		}
+		numRequested = uint32(*count)
+	}
+	if numRequested == 0 {
+		return []addrTxn{}, nil
+	}
+
+	// Ensure the address index is synced.
+	tHeight, tHash, err := addrIndex.Tip()
+	if err != nil {
+		return nil, rpcInternalErr(err, "Address index tip")
+	}
+
+	chain := s.cfg.Chain
+
+	// Return an out-of-sync error if index is lagging a
+	// maximum reorg depth (6) blocks or more from the chain tip.
+	if chain.BestSnapshot().Height > (tHeight + 5) {
+		err := fmt.Errorf("%s: index not synced", addrIndex.Name())
+		return nil, rpcInternalErr(err, "Sync")
+	}
+
+sync:
+	for !chain.BestSnapshot().Hash.IsEqual(tHash) {
+		select {
+		case <-time.After(syncWait):
+			err := fmt.Errorf("%s: index not synced", addrIndex.Name())
+			return nil, rpcInternalErr(err, "Sync")
+		case <-addrIndex.WaitForSync():
+			break sync
+		}
+	}
+
+	// addUnconfirmed adds the provided unconfirmed transactions to the
+	// results while accounting for the number to skip and the number
+	// requested.
+	results := make([]addrTxn, 0, numRequested)
+	addUnconfirmed := func(txns []*dcrutil.Tx) {
+		for _, tx := range txns {
+			if uint32(len(results)) == numRequested {
+				return
+			}
+			if numToSkip > 0 {
+				numToSkip--
+				continue
+			}
+			results = append(results, addrTxn{tx: tx.MsgTx()})
+		}
+	}
+
+	// addConfirmed loads the transactions for the provided address index
+	// entries from the database and adds them to the results.
+	addConfirmed := func(entries []indexers.TxIndexEntry) error {
+		return s.cfg.DB.View(func(dbTx database.Tx) error {
+			for i := range entries {
+				entry := &entries[i]
+				txBytes, err := dbTx.FetchBlockRegion(&entry.BlockRegion)
+				if err != nil {
+					return err
+				}
+				var msgTx wire.MsgTx
+				err = msgTx.Deserialize(bytes.NewReader(txBytes))
+				if err != nil {
+					return err
+				}
+				results = append(results, addrTxn{
+					tx:       &msgTx,
+					blkHash:  entry.BlockRegion.Hash,
+					blkIndex: entry.BlockIndex,
+				})
+			}
+			return nil
+		})
+	}
+
+	// Unconfirmed transactions are the most recent ones, so they come first
+	// in reverse order and last otherwise.
+	unconfirmed := addrIndex.UnconfirmedTxnsForAddress(addr)
+	if reverse {
+		for i, j := 0, len(unconfirmed)-1; i < j; i, j = i+1, j-1 {
+			unconfirmed[i], unconfirmed[j] = unconfirmed[j], unconfirmed[i]
+		}
+		addUnconfirmed(unconfirmed)
+		numRemaining := numRequested - uint32(len(results))
+		if numRemaining == 0 {
+			return results, nil
+		}
+		entries, _, err := addrIndex.EntriesForAddress(addr, numToSkip,
+			numRemaining, true)
+		if err != nil {
+			return nil, rpcInternalErr(err, "Failed to fetch address entries")
+		}
+		if err := addConfirmed(entries); err != nil {
+			return nil, rpcInternalErr(err, "Failed to load transaction")
+		}
+		return results, nil
+	}
+
+	entries, numSkipped, err := addrIndex.EntriesForAddress(addr, numToSkip,
+		numRequested, false)
+	if err != nil {
+		return nil, rpcInternalErr(err, "Failed to fetch address entries")
+	}
+	if err := addConfirmed(entries); err != nil {
+		return nil, rpcInternalErr(err, "Failed to load transaction")
+	}
+	numToSkip -= numSkipped
+	addUnconfirmed(unconfirmed)
+	return results, nil
+}
+
+// handleGetAddressTxIDs implements the getaddresstxids command.
+func handleGetAddressTxIDs(_ context.Context, s *Server, cmd interface{}) (interface{}, error) {
+	c := cmd.(*types.GetAddressTxIDsCmd)
+
+	reverse := c.Reverse != nil && *c.Reverse
+	txns, err := s.fetchAddrTxns(c.Address, c.Skip, c.Count, reverse)
+	if err != nil {
+		return nil, err
+	}
+
+	txIDs := make([]string, 0, len(txns))
+	for i := range txns {
+		txIDs = append(txIDs, txns[i].tx.TxHash().String())
+	}
+	return txIDs, nil
+}
+
 // handleGetBestBlock implements the getbestblock command.
 func handleGetBestBlock(_ context.Context, s *Server, _ interface{}) (interface{}, error) {
 	// All other "get block" commands give either the height, the hash, or
 	// both but require the block SHA.  This gets both for the best block.
-	best := s.cfg.Chain.BestSnapshot()
+	hash, height := s.bestBlock()
 	result := &types.GetBestBlockResult{
-		Hash:   best.Hash.String(),
-		Height: best.Height,
+		Hash:   hash.String(),
+		Height: height,
 	}
 	return result, nil
 }
 
 // handleGetBestBlockHash implements the getbestblockhash command.
 func handleGetBestBlockHash(_ context.Context, s *Server, _ interface{}) (interface{}, error) {
-	best := s.cfg.Chain.BestSnapshot()
-	return best.Hash.String(), nil
+	hash, _ := s.bestBlock()
+	return hash.String(), nil
 }
 
 // getDifficultyRatio returns the proof-of-work difficulty as a multiple of the
@@ -1921,6 +2535,9 @@ func handleGetBlock(_ context.Context, s *Server, cmd interface{}) (interface{},
 	chain := s.cfg.Chain
 	blk, err := chain.BlockByHash(hash)
 	if err != nil {
+		if errors.Is(err, blockchain.ErrBlockPruned) {
+			return nil, rpcBlockPrunedError()
+		}
 		return nil, &dcrjson.RPCError{
 			Code:    dcrjson.ErrRPCBlockNotFound,
 			Message: fmt.Sprintf("Block not found: %v", hash),
@@ -2168,6 +2785,8 @@ func handleGetBlockchainInfo(_ context.Context, s *Server, _ interface{}) (inter
 		Difficulty:           best.Bits,
 		DifficultyRatio:      getDifficultyRatio(best.Bits, params),
 		MaxBlockSize:         maxBlockSize,
+		Pruned:               chain.IsPruned(),
+		PruneHeight:          chain.PruneHeight(),
 		Deployments:          dInfo,
 	}
 
@@ -2176,8 +2795,8 @@ func handleGetBlockchainInfo(_ context.Context, s *Server, _ interface{}) (inter
 
 // handleGetBlockCount implements the getblockcount command.
 func handleGetBlockCount(_ context.Context, s *Server, _ interface{}) (interface{}, error) {
-	best := s.cfg.Chain.BestSnapshot()
-	return best.Height, nil
+	_, height := s.bestBlock()
+	return height, nil
 }
 
 // handleGetBlockHash implements the getblockhash command.
@@ -2295,6 +2914,252 @@ func handleGetBlockHeader(_ context.Context, s *Server, cmd interface{}) (interf
 	return blockHeaderReply, nil
 }
 
+// feeRatePercentiles houses the percentiles, in ascending order, reported for
+// the fee rates of the transactions in a block by the getblockstats command.
+var feeRatePercentiles = []float64{0.10, 0.25, 0.50, 0.75, 0.90}
+
+// calcMedian returns the median of the provided values.  The average of the
+// two middle values, truncated to an integer, is returned for an even number
+// of values.  The provided slice is sorted in place.
+func calcMedian(values []int64) int64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	mid := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[mid-1] + values[mid]) / 2
+	}
+	return values[mid]
+}
+
+// calcFeeRatePercentiles returns the fee rates at each of the percentiles
+// defined by feeRatePercentiles for the provided fee rates weighted by the
+// provided transaction sizes.  The provided slices must be the same length.
+func calcFeeRatePercentiles(feeRates, sizes []int64) []int64 {
+	results := make([]int64, len(feeRatePercentiles))
+	if len(feeRates) == 0 {
+		return results
+	}
+
+	// Sort the fee rates along with their associated sizes in ascending order
+	// by fee rate.
+	indices := make([]int, len(feeRates))
+	var totalSize int64
+	for i := range indices {
+		indices[i] = i
+		totalSize += sizes[i]
+	}
+	sort.Slice(indices, func(i, j int) bool {
+		return feeRates[indices[i]] < feeRates[indices[j]]
+	})
+
+	// Walk the sorted fee rates while accumulating the sizes and record the
+	// fee rate that causes each percentile threshold to be reached.
+	var cumulativeSize int64
+	var p int
+	for _, idx := range indices {
+		cumulativeSize += sizes[idx]
+		for p < len(feeRatePercentiles) &&
+			float64(cumulativeSize) >= float64(totalSize)*feeRatePercentiles[p] {
+
+			results[p] = feeRates[idx]
+			p++
+		}
+	}
+	for ; p < len(feeRatePercentiles); p++ {
+		results[p] = feeRates[indices[len(indices)-1]]
+	}
+	return results
+}
+
+// handleGetBlockStats implements the getblockstats command.
+func handleGetBlockStats(_ context.Context, s *Server, cmd interface{}) (interface{}, error) {
+	c := cmd.(*types.GetBlockStatsCmd)
+
+	// Determine the hash of the requested block which may be identified by
+	// either its height in the main chain or its hash.  Only blocks in the
+	// main chain are supported since the stats rely on the spend journal.
+	chain := s.cfg.Chain
+	var blkHash *chainhash.Hash
+	hashOrHeight := string(c.HashOrHeight)
+	if height, err := strconv.ParseInt(hashOrHeight, 10, 64); err == nil {
+		blkHash, err = chain.BlockHashByHeight(height)
+		if err != nil {
+			return nil, &dcrjson.RPCError{
+				Code: dcrjson.ErrRPCOutOfRange,
+				Message: fmt.Sprintf("Block number out of range: %v",
+					height),
+			}
+		}
+	} else {
+		blkHash, err = chainhash.NewHashFromStr(hashOrHeight)
+		if err != nil {
+			return nil, rpcDecodeHexError(hashOrHeight)
+		}
+		if !chain.MainChainHasBlock(blkHash) {
+			return nil, rpcBlockNotFoundError(*blkHash)
+		}
+	}
+
+	block, err := chain.BlockByHash(blkHash)
+	if err != nil {
+		if errors.Is(err, blockchain.ErrBlockPruned) {
+			return nil, rpcBlockPrunedError()
+		}
+		return nil, rpcBlockNotFoundError(*blkHash)
+	}
+	spent, err := chain.FetchSpentTxOuts(blkHash)
+	if err != nil {
+		context := fmt.Sprintf("Failed to load spent outputs for block %s",
+			blkHash)
+		return nil, rpcInternalErr(err, context)
+	}
+
+	msgBlock := block.MsgBlock()
+	header := &msgBlock.Header
+	result := types.GetBlockStatsResult{
+		Hash:   blkHash.String(),
+		Height: int64(header.Height),
+		Time:   header.Timestamp.Unix(),
+		Txs:    int64(len(msgBlock.Transactions) + len(msgBlock.STransactions)),
+	}
+
+	// Calculate the stats for all transactions in the block.  The stake tree
+	// is processed first since that is the order the spend journal records the
+	// spent outputs in.
+	var fees, feeRates, sizes []int64
+	var spentIdx int
+	processTx := func(tx *wire.MsgTx, txType stake.TxType, isCoinBase bool) error {
+		switch txType {
+		case stake.TxTypeSStx:
+			result.Tickets++
+		case stake.TxTypeSSGen:
+			result.Votes++
+		case stake.TxTypeSSRtx:
+			result.Revocations++
+		case stake.TxTypeTSpend:
+			result.TSpends++
+		case stake.TxTypeTAdd:
+			result.TAdds++
+		}
+
+		// Account for the outputs that are added to the utxo set.
+		result.Outs += int64(len(tx.TxOut))
+		var totalOut int64
+		for _, txOut := range tx.TxOut {
+			totalOut += txOut.Value
+			if txscript.IsUnspendable(txOut.Value, txOut.PkScript) {
+				continue
+			}
+			result.UtxoIncrease++
+			result.UtxoSizeInc += int64(txOut.SerializeSize())
+		}
+
+		// The coinbase and treasurybase do not spend any outputs or pay any
+		// fees, so they are not included in the remaining stats.
+		if isCoinBase || txType == stake.TxTypeTreasuryBase {
+			return nil
+		}
+
+		// Account for the outputs that are removed from the utxo set and
+		// determine the total input amount.  Note that stakebase inputs and
+		// treasury spend inputs do not spend a previous output.
+		result.Ins += int64(len(tx.TxIn))
+		var totalIn int64
+		for txInIdx, txIn := range tx.TxIn {
+			if (txInIdx == 0 && txType == stake.TxTypeSSGen) ||
+				txType == stake.TxTypeTSpend {
+
+				totalIn += txIn.ValueIn
+				continue
+			}
+			if spentIdx >= len(spent) {
+				str := fmt.Sprintf("missing spent output for input %d of "+
+					"transaction %s", txInIdx, tx.TxHash())
+				return errors.New(str)
+			}
+			stxo := &spent[spentIdx]
+			spentIdx++
+			totalIn += stxo.Amount
+			result.UtxoIncrease--
+			result.UtxoSizeInc -= int64((&wire.TxOut{
+				Value:    stxo.Amount,
+				Version:  stxo.ScriptVersion,
+				PkScript: stxo.PkScript,
+			}).SerializeSize())
+		}
+
+		size := int64(tx.SerializeSize())
+		fee := totalIn - totalOut
+		result.TotalSize += size
+		result.TotalOut += totalOut
+		result.TotalFee += fee
+		fees = append(fees, fee)
+		feeRates = append(feeRates, fee*1000/size)
+		sizes = append(sizes, size)
+		return nil
+	}
+	for _, stx := range msgBlock.STransactions {
+		if err := processTx(stx, stake.DetermineTxType(stx), false); err != nil {
+			return nil, rpcInternalErr(err, "Failed to calculate block stats")
+		}
+	}
+	for i, tx := range msgBlock.Transactions {
+		isCoinBase := i == 0
+		err := processTx(tx, stake.TxTypeRegular, isCoinBase)
+		if err != nil {
+			return nil, rpcInternalErr(err, "Failed to calculate block stats")
+		}
+	}
+
+	// Calculate the aggregate fee and size stats.
+	if len(fees) > 0 {
+		numTxns := int64(len(fees))
+		result.AvgFee = result.TotalFee / numTxns
+		result.AvgFeeRate = result.TotalFee * 1000 / result.TotalSize
+		result.AvgTxSize = result.TotalSize / numTxns
+		result.MinFee, result.MaxFee = fees[0], fees[0]
+		result.MinFeeRate, result.MaxFeeRate = feeRates[0], feeRates[0]
+		result.MinTxSize, result.MaxTxSize = sizes[0], sizes[0]
+		for i := range fees {
+			if fees[i] < result.MinFee {
+				result.MinFee = fees[i]
+			}
+			if fees[i] > result.MaxFee {
+				result.MaxFee = fees[i]
+			}
+			if feeRates[i] < result.MinFeeRate {
+				result.MinFeeRate = feeRates[i]
+			}
+			if feeRates[i] > result.MaxFeeRate {
+				result.MaxFeeRate = feeRates[i]
+			}
+			if sizes[i] < result.MinTxSize {
+				result.MinTxSize = sizes[i]
+			}
+			if sizes[i] > result.MaxTxSize {
+				result.MaxTxSize = sizes[i]
+			}
+		}
+	}
+	result.FeeRatePercentiles = calcFeeRatePercentiles(feeRates, sizes)
+	result.MedianFee = calcMedian(fees)
+	result.MedianTxSize = calcMedian(sizes)
+
+	// Calculate the subsidy split for the block.
+	dev, pos, pow, err := s.calcSubsidySplit(&header.PrevBlock,
+		int64(header.Height), header.Voters)
+	if err != nil {
+		return nil, err
+	}
+	result.PoWSubsidy = pow
+	result.PoSSubsidy = pos
+	result.TreasurySubsidy = dev
+
+	return result, nil
+}
+
 // handleGetBlockSubsidy implements the getblocksubsidy command.
 func handleGetBlockSubsidy(_ context.Context, s *Server, cmd interface{}) (interface{}, error) {
 	c := cmd.(*types.GetBlockSubsidyCmd)
@@ -2316,34 +3181,10 @@ func handleGetBlockSubsidy(_ context.Context, s *Server, cmd interface{}) (inter
 		}
 		prevBlkHash = header.PrevBlock
 	}
-	isTreasuryEnabled, err := s.isTreasuryAgendaActive(&prevBlkHash)
-	if err != nil {
-		return nil, err
-	}
-	isSubsidyEnabled, err := s.isSubsidySplitAgendaActive(&prevBlkHash)
-	if err != nil {
-		return nil, err
-	}
-	isSubsidyR2Enabled, err := s.isSubsidySplitR2AgendaActive(&prevBlkHash)
+	dev, pos, pow, err := s.calcSubsidySplit(&prevBlkHash, height, voters)
 	if err != nil {
 		return nil, err
 	}
-
-	// Determine which subsidy split variant to use depending on the active
-	// agendas.
-	subsidySplitVariant := standalone.SSVOriginal
-	switch {
-	case isSubsidyR2Enabled:
-		subsidySplitVariant = standalone.SSVDCP0012
-	case isSubsidyEnabled:
-		subsidySplitVariant = standalone.SSVDCP0010
-	}
-
-	subsidyCache := s.cfg.SubsidyCache
-	dev := subsidyCache.CalcTreasurySubsidy(height, voters, isTreasuryEnabled)
-	pos := subsidyCache.CalcStakeVoteSubsidyV3(height-1, subsidySplitVariant) *
-		int64(voters)
-	pow := subsidyCache.CalcWorkSubsidyV3(height, voters, subsidySplitVariant)
 	total := dev + pos + pow
 
 	rep := types.GetBlockSubsidyResult{
@@ -2386,6 +3227,34 @@ func handleGetCurrentNet(_ context.Context, s *Server, cmd interface{}) (interfa
 	return s.cfg.ChainParams.Net, nil
 }
 
+// handleGetDescriptorInfo implements the getdescriptorinfo command.
+func handleGetDescriptorInfo(_ context.Context, s *Server, cmd interface{}) (interface{}, error) {
+	c := cmd.(*types.GetDescriptorInfoCmd)
+
+	desc, err := descriptor.Parse(c.Descriptor, s.cfg.ChainParams)
+	if err != nil {
+		return nil, rpcInvalidError("Invalid descriptor: %v", err)
+	}
+
+	// The reported checksum is for the descriptor as provided as opposed to
+	// its canonical form.
+	provided := c.Descriptor
+	if i := strings.LastIndexByte(provided, '#'); i != -1 {
+		provided = provided[:i]
+	}
+	checksum, err := descriptor.Checksum(provided)
+	if err != nil {
+		return nil, rpcInternalErr(err, "Unable to calculate checksum")
+	}
+
+	return &types.GetDescriptorInfoResult{
+		Descriptor:     desc.String(),
+		Checksum:       checksum,
+		IsRange:        desc.IsRange(),
+		HasPrivateKeys: desc.HasPrivateKeys(),
+	}, nil
+}
+
 // handleGetDifficulty implements the getdifficulty command.
 func handleGetDifficulty(_ context.Context, s *Server, _ interface{}) (interface{}, error) {
 	best := s.cfg.Chain.BestSnapshot()
@@ -2469,7 +3338,7 @@ func handleGetCFilterV2(_ context.Context, s *Server, cmd interface{}) (interfac
 
 	var proofHashes []string
 	if len(proof.ProofHashes) > 0 {
-		proofHashes = make([]string, 0, len(proof.ProofHashes))
+		proofHashes = make([]string, len(proof.ProofHashes))
 		for i := range proof.ProofHashes {
 			proofHashes[i] = proof.ProofHashes[i].String()
 		}
@@ -2515,8 +3384,11 @@ func handleGetMempoolInfo(_ context.Context, s *Server, _ interface{}) (interfac
 	}
 
 	ret := &types.GetMempoolInfoResult{
-		Size:  int64(len(mempoolTxns)),
-		Bytes: numBytes,
+		Size:          int64(len(mempoolTxns)),
+		Bytes:         numBytes,
+		MaxMempool:    s.cfg.TxMempooler.MaxSize(),
+		MempoolMinFee: s.cfg.TxMempooler.MinRelayFee().ToCoin(),
+		Evicted:       s.cfg.TxMempooler.NumEvicted(),
 	}
 
 	return ret, nil
@@ -2678,6 +3550,41 @@ func handleGetNetworkInfo(_ context.Context, s *Server, _ interface{}) (interfac
 	return info, nil
 }
 
+// handleGetNodeAddresses implements the getnodeaddresses command.
+func handleGetNodeAddresses(_ context.Context, s *Server, cmd interface{}) (interface{}, error) {
+	c := cmd.(*types.GetNodeAddressesCmd)
+
+	count := *c.Count
+	if count < 0 {
+		return nil, rpcInvalidError("Address count out of range: %d", count)
+	}
+
+	// Return all of the available addresses when the count is zero.
+	addrMgr := s.cfg.AddrManager
+	addrs := addrMgr.AddressCache()
+	if count != 0 && count < len(addrs) {
+		addrs = addrs[:count]
+	}
+
+	result := make([]types.GetNodeAddressesResult, 0, len(addrs))
+	for _, addr := range addrs {
+		host, _, err := net.SplitHostPort(addr.Key())
+		if err != nil {
+			return nil, rpcInternalErr(err, "Unable to parse address")
+		}
+		result = append(result, types.GetNodeAddressesResult{
+			Time:     addr.Timestamp.Unix(),
+			Services: fmt.Sprintf("%08d", uint64(addr.Services)),
+			Address:  host,
+			Port:     addr.Port,
+			Network:  addr.Type.String(),
+			Group:    addrMgr.GroupKey(addr),
+			ASN:      addrMgr.ASN(addr),
+		})
+	}
+	return result, nil
+}
+
 // handleGetPeerInfo implements the getpeerinfo command.
 func handleGetPeerInfo(_ context.Context, s *Server, _ interface{}) (interface{}, error) {
 	peers := s.cfg.ConnMgr.ConnectedPeers()
@@ -2705,6 +3612,7 @@ func handleGetPeerInfo(_ context.Context, s *Server, _ interface{}) (interface{}
 			Version:        statsSnap.Version,
 			SubVer:         statsSnap.UserAgent,
 			Inbound:        statsSnap.Inbound,
+			ConnType:       p.ConnType(),
 			StartingHeight: statsSnap.StartingHeight,
 			CurrentHeight:  statsSnap.LastBlock,
 			BanScore:       int32(p.BanScore()),
@@ -2880,6 +3788,9 @@ func handleGetRawTransaction(_ context.Context, s *Server, cmd interface{}) (int
 			return err
 		})
 		if err != nil {
+			if isBlockDataPruned(chain, blockRegion.Hash) {
+				return nil, rpcBlockPrunedError()
+			}
 			return nil, rpcNoTxInfoError(txHash)
 		}
 
@@ -2969,6 +3880,34 @@ func handleGetRawTransaction(_ context.Context, s *Server, cmd interface{}) (int
 // handleGetStakeDifficulty implements the getstakedifficulty command.
 func handleGetStakeDifficulty(_ context.Context, s *Server, _ interface{}) (interface{}, error) {
 	chain := s.cfg.Chain
+
+	// Calculate the stake difficulty based on the best known header when
+	// running in light mode since the blocks are not connected in that case.
+	if s.cfg.LightMode {
+		hash, _ := chain.BestHeader()
+		blockHeader, err := chain.HeaderByHash(&hash)
+		if err != nil {
+			log.Errorf("Error getting block header: %v", err)
+			return nil, &dcrjson.RPCError{
+				Code:    dcrjson.ErrRPCDifficulty,
+				Message: "Error getting stake difficulty: " + err.Error(),
+			}
+		}
+		nextStakeDiff, err := chain.CalcNextRequiredStakeDifficultyV2(&hash)
+		if err != nil {
+			log.Errorf("Error calculating next stake difficulty: %v", err)
+			return nil, &dcrjson.RPCError{
+				Code:    dcrjson.ErrRPCDifficulty,
+				Message: "Error getting stake difficulty: " + err.Error(),
+			}
+		}
+		result := types.GetStakeDifficultyResult{
+			CurrentStakeDifficulty: dcrutil.Amount(blockHeader.SBits).ToCoin(),
+			NextStakeDifficulty:    dcrutil.Amount(nextStakeDiff).ToCoin(),
+		}
+		return result, nil
+	}
+
 	best := chain.BestSnapshot()
 	blockHeader, err := chain.HeaderByHeight(best.Height)
 	if err != nil {
@@ -3258,6 +4197,9 @@ func handleGetTreasurySpendVotes(_ context.Context, s *Server, cmd interface{})
 			// first one and extract the tspend.
 			fullBlock, err := chain.BlockByHash(&blocks[0])
 			if err != nil {
+				if errors.Is(err, blockchain.ErrBlockPruned) {
+					return nil, rpcBlockPrunedError()
+				}
 				// Shouldn't happen unless tspend db is hosed.
 				const context = "Block containing mined treasury spend not found"
 				return nil, rpcInternalErr(err, context)
@@ -3650,26 +4592,133 @@ func handleGetTxOut(_ context.Context, s *Server, cmd interface{}) (interface{},
 		},
 		Coinbase: isCoinbase,
 	}
-	return txOutReply, nil
-}
+	return txOutReply, nil
+}
+
+// handleGetTxOutSetInfo returns statistics on the current unspent transaction output set.
+func handleGetTxOutSetInfo(_ context.Context, s *Server, _ interface{}) (interface{}, error) {
+	best := s.cfg.Chain.BestSnapshot()
+	stats, err := s.cfg.Chain.FetchUtxoStats()
+	if err != nil {
+		return nil, err
+	}
+
+	return types.GetTxOutSetInfoResult{
+		Height:         best.Height,
+		BestBlock:      best.Hash.String(),
+		Transactions:   stats.Transactions,
+		TxOuts:         stats.Utxos,
+		DiskSize:       stats.Size,
+		TotalAmount:    stats.Total,
+		SerializedHash: stats.SerializedHash.String(),
+	}, nil
+}
+
+// handleGetTxSpendingPrevOut implements the gettxspendingprevout command.
+func handleGetTxSpendingPrevOut(_ context.Context, s *Server, cmd interface{}) (interface{}, error) {
+	c := cmd.(*types.GetTxSpendingPrevOutCmd)
+
+	if len(c.Outputs) == 0 {
+		return nil, rpcInvalidError("No outputs specified")
+	}
+
+	// Convert the provided outputs to outpoints after performing some
+	// validity checks.
+	outpoints := make([]wire.OutPoint, 0, len(c.Outputs))
+	for _, output := range c.Outputs {
+		txHash, err := chainhash.NewHashFromStr(output.Txid)
+		if err != nil {
+			return nil, rpcDecodeHexError(output.Txid)
+		}
+
+		if !(output.Tree == wire.TxTreeRegular ||
+			output.Tree == wire.TxTreeStake) {
+			return nil, rpcInvalidError("Tx tree must be regular " +
+				"or stake")
+		}
+
+		outpoints = append(outpoints, wire.OutPoint{
+			Hash:  *txHash,
+			Index: output.Vout,
+			Tree:  output.Tree,
+		})
+	}
+
+	// Ensure the spend index is synced when it is enabled.  Only the mempool
+	// is checked otherwise.
+	chain := s.cfg.Chain
+	spendIndex := s.cfg.SpendIndexer
+	if spendIndex != nil {
+		tHeight, tHash, err := spendIndex.Tip()
+		if err != nil {
+			return nil, rpcInternalErr(err, "Spend index tip")
+		}
+
+		// Return an out-of-sync error if index is lagging a
+		// maximum reorg depth (6) blocks or more from the chain tip.
+		if chain.BestSnapshot().Height > (tHeight + 5) {
+			err := fmt.Errorf("%s: index not synced", spendIndex.Name())
+			return nil, rpcInternalErr(err, "Sync")
+		}
+
+	sync:
+		for !chain.BestSnapshot().Hash.IsEqual(tHash) {
+			select {
+			case <-time.After(syncWait):
+				err := fmt.Errorf("%s: index not synced", spendIndex.Name())
+				return nil, rpcInternalErr(err, "Sync")
+			case <-spendIndex.WaitForSync():
+				break sync
+			}
+		}
+	}
 
-// handleGetTxOutSetInfo returns statistics on the current unspent transaction output set.
-func handleGetTxOutSetInfo(_ context.Context, s *Server, _ interface{}) (interface{}, error) {
-	best := s.cfg.Chain.BestSnapshot()
-	stats, err := s.cfg.Chain.FetchUtxoStats()
-	if err != nil {
-		return nil, err
-	}
+	results := make([]types.GetTxSpendingPrevOutResult, 0, len(outpoints))
+	for i := range outpoints {
+		outpoint := &outpoints[i]
+		result := types.GetTxSpendingPrevOutResult{
+			Txid: outpoint.Hash.String(),
+			Vout: outpoint.Index,
+			Tree: outpoint.Tree,
+		}
 
-	return types.GetTxOutSetInfoResult{
-		Height:         best.Height,
-		BestBlock:      best.Hash.String(),
-		Transactions:   stats.Transactions,
-		TxOuts:         stats.Utxos,
-		DiskSize:       stats.Size,
-		TotalAmount:    stats.Total,
-		SerializedHash: stats.SerializedHash.String(),
-	}, nil
+		// Prefer the mempool since the spending transaction in the main
+		// chain, if any, would necessarily conflict with it.
+		if tx := s.cfg.TxMempooler.FetchSpendingTx(outpoint); tx != nil {
+			for txInIdx, txIn := range tx.MsgTx().TxIn {
+				if txIn.PreviousOutPoint == *outpoint {
+					vin := uint32(txInIdx)
+					result.SpendingVin = &vin
+					break
+				}
+			}
+			result.SpendingTxid = tx.Hash().String()
+			results = append(results, result)
+			continue
+		}
+
+		if spendIndex != nil {
+			entry, err := spendIndex.Entry(outpoint)
+			if err != nil {
+				const context = "Failed to retrieve spending transaction"
+				return nil, rpcInternalErr(err, context)
+			}
+			if entry != nil {
+				blkHeight, err := chain.BlockHeightByHash(&entry.BlockHash)
+				if err != nil {
+					const context = "Failed to retrieve block height"
+					return nil, rpcInternalErr(err, context)
+				}
+				vin := entry.InputIndex
+				result.SpendingTxid = entry.TxHash.String()
+				result.SpendingVin = &vin
+				result.BlockHash = entry.BlockHash.String()
+				result.BlockHeight = blkHeight
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
 }
 
 // pruneOldBlockTemplates prunes all old block templates from the templatePool
@@ -4133,6 +5182,20 @@ func handleInvalidateBlock(_ context.Context, s *Server, cmd interface{}) (inter
 	return nil, nil
 }
 
+// handleListBanned implements the listbanned command.
+func handleListBanned(_ context.Context, s *Server, _ interface{}) (interface{}, error) {
+	entries := s.cfg.ConnMgr.BannedSubnets()
+	result := make([]types.ListBannedResult, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, types.ListBannedResult{
+			Address:     entry.Subnet.String(),
+			BanCreated:  entry.Created.Unix(),
+			BannedUntil: entry.Expires.Unix(),
+		})
+	}
+	return result, nil
+}
+
 // handleLiveTickets implements the livetickets command.
 func handleLiveTickets(_ context.Context, s *Server, _ interface{}) (interface{}, error) {
 	lt, err := s.cfg.Chain.LiveTickets()
@@ -4148,6 +5211,19 @@ func handleLiveTickets(_ context.Context, s *Server, _ interface{}) (interface{}
 	return types.LiveTicketsResult{Tickets: ltString}, nil
 }
 
+// handleLoadMempool implements the loadmempool command.
+func handleLoadMempool(_ context.Context, s *Server, _ interface{}) (interface{}, error) {
+	path, accepted, rejected, err := s.cfg.MempoolPersister.LoadMempool()
+	if err != nil {
+		return nil, rpcInternalErr(err, "Unable to load mempool")
+	}
+	return &types.LoadMempoolResult{
+		Filename: path,
+		Accepted: int64(accepted),
+		Rejected: int64(rejected),
+	}, nil
+}
+
 // handlePing implements the ping command.
 func handlePing(_ context.Context, s *Server, _ interface{}) (interface{}, error) {
 	// Ask server to ping \o_
@@ -4224,6 +5300,99 @@ func handleRegenTemplate(_ context.Context, s *Server, _ interface{}) (interface
 	return nil, nil
 }
 
+// handleSaveMempool implements the savemempool command.
+func handleSaveMempool(_ context.Context, s *Server, _ interface{}) (interface{}, error) {
+	path, numTxns, err := s.cfg.MempoolPersister.SaveMempool()
+	if err != nil {
+		return nil, rpcInternalErr(err, "Unable to save mempool")
+	}
+	return &types.SaveMempoolResult{
+		Filename: path,
+		Count:    int64(numTxns),
+	}, nil
+}
+
+// handleSearchRawTransactions implements the searchrawtransactions command.
+func handleSearchRawTransactions(_ context.Context, s *Server, cmd interface{}) (interface{}, error) {
+	c := cmd.(*types.SearchRawTransactionsCmd)
+
+	verbose := true
+	if c.Verbose != nil {
+		verbose = *c.Verbose != 0
+	}
+
+	reverse := c.Reverse != nil && *c.Reverse
+	txns, err := s.fetchAddrTxns(c.Address, c.Skip, c.Count, reverse)
+	if err != nil {
+		return nil, err
+	}
+
+	// When the verbose flag isn't set, simply return the serialized
+	// transactions as hex-encoded strings.
+	if !verbose {
+		hexTxns := make([]string, 0, len(txns))
+		for i := range txns {
+			mtxHex, err := s.messageToHex(txns[i].tx)
+			if err != nil {
+				return nil, err
+			}
+			hexTxns = append(hexTxns, mtxHex)
+		}
+		return hexTxns, nil
+	}
+
+	// The verbose flag is set, so generate the JSON objects.
+	chain := s.cfg.Chain
+	best := chain.BestSnapshot()
+	rawTxns := make([]types.TxRawResult, 0, len(txns))
+	for i := range txns {
+		txn := &txns[i]
+
+		var (
+			blkHeader     *wire.BlockHeader
+			prevBlkHash   chainhash.Hash
+			blkHashStr    string
+			blkHeight     int64
+			confirmations int64
+		)
+		if txn.blkHash != nil {
+			header, err := chain.HeaderByHash(txn.blkHash)
+			if err != nil {
+				return nil, rpcInternalErr(err, "Failed to fetch block header")
+			}
+
+			blkHeader = &header
+			prevBlkHash = header.PrevBlock
+			blkHashStr = txn.blkHash.String()
+			blkHeight = int64(header.Height)
+			confirmations = 1 + best.Height - blkHeight
+		} else {
+			// The transaction is in the mempool when there is no block hash
+			// set, so the previous block hash is the current best chain tip
+			// in that case.
+			prevBlkHash = best.Hash
+		}
+
+		// Determine if the treasury rules are active as of either the block
+		// the contains the transaction or the current best tip when it is in
+		// the mempool.
+		isTreasuryEnabled, err := s.isTreasuryAgendaActive(&prevBlkHash)
+		if err != nil {
+			return nil, rpcInternalErr(err, "Treasury Status")
+		}
+
+		txHash := txn.tx.TxHash()
+		rawTxn, err := s.createTxRawResult(s.cfg.ChainParams, txn.tx,
+			txHash.String(), txn.blkIndex, blkHeader, blkHashStr, blkHeight,
+			confirmations, isTreasuryEnabled)
+		if err != nil {
+			return nil, err
+		}
+		rawTxns = append(rawTxns, *rawTxn)
+	}
+	return rawTxns, nil
+}
+
 // handleSendRawTransaction implements the sendrawtransaction command.
 func handleSendRawTransaction(_ context.Context, s *Server, cmd interface{}) (interface{}, error) {
 	c := cmd.(*types.SendRawTransactionCmd)
@@ -4307,6 +5476,65 @@ func handleSendRawTransaction(_ context.Context, s *Server, cmd interface{}) (in
 	return tx.Hash().String(), nil
 }
 
+// handleSetBan implements the setban command.
+func handleSetBan(_ context.Context, s *Server, cmd interface{}) (interface{}, error) {
+	c := cmd.(*types.SetBanCmd)
+
+	subnet, err := banmanager.ParseSubnet(c.Subnet)
+	if err != nil {
+		return nil, rpcInvalidError("%v: invalid IP address or subnet %q",
+			c.SubCmd, c.Subnet)
+	}
+
+	switch c.SubCmd {
+	case types.SBAdd:
+		now := s.cfg.Clock.Now()
+		banTime := *c.BanTime
+		if banTime < 0 {
+			return nil, rpcInvalidError("%v: ban time must not be negative",
+				c.SubCmd)
+		}
+
+		// Use the default ban duration when no ban time is specified.
+		// Otherwise, the ban time is either the number of seconds to ban for
+		// or, when the absolute flag is set, the unix time the ban expires.
+		var expires time.Time
+		switch {
+		case banTime == 0:
+			expires = now.Add(s.cfg.BanDuration)
+		case *c.Absolute:
+			expires = time.Unix(banTime, 0)
+			if !expires.After(now) {
+				return nil, rpcInvalidError("%v: absolute ban time %d is "+
+					"not in the future", c.SubCmd, banTime)
+			}
+		default:
+			expires = now.Add(time.Duration(banTime) * time.Second)
+		}
+
+		err := s.cfg.ConnMgr.BanSubnet(subnet, now, expires)
+		if err != nil {
+			return nil, rpcInternalErr(err, "Unable to save ban list")
+		}
+
+	case types.SBRemove:
+		err := s.cfg.ConnMgr.UnbanSubnet(subnet)
+		if errors.Is(err, banmanager.ErrNotBanned) {
+			return nil, rpcInvalidError("%v: subnet %s is not banned",
+				c.SubCmd, subnet)
+		}
+		if err != nil {
+			return nil, rpcInternalErr(err, "Unable to save ban list")
+		}
+
+	default:
+		return nil, rpcInvalidError("%v: invalid subcommand for setban",
+			c.SubCmd)
+	}
+
+	return nil, nil
+}
+
 // handleSetGenerate implements the setgenerate command.
 func handleSetGenerate(_ context.Context, s *Server, cmd interface{}) (interface{}, error) {
 	c := cmd.(*types.SetGenerateCmd)
@@ -4494,6 +5722,15 @@ func calcFeePerKb(tx *dcrutil.Tx) dcrutil.Amount {
 	return ((in - out) * 1000) / dcrutil.Amount(tx.MsgTx().SerializeSize())
 }
 
+// ticketFeeInfoError returns the RPC error to use for the provided error that
+// resulted from failing to obtain ticket fee information.
+func ticketFeeInfoError(err error) *dcrjson.RPCError {
+	if errors.Is(err, blockchain.ErrBlockPruned) {
+		return rpcBlockPrunedError()
+	}
+	return rpcInternalErr(err, "Could not obtain ticket fee info")
+}
+
 // ticketFeeInfoForBlock fetches the ticket fee information for a given tx type
 // in a block.
 func ticketFeeInfoForBlock(s *Server, height int64, txType stake.TxType) (*types.FeeInfoBlock, error) {
@@ -4616,8 +5853,7 @@ func handleTicketFeeInfo(_ context.Context, s *Server, cmd interface{}) (interfa
 		for i := start; i > end; i-- {
 			feeInfo, err := ticketFeeInfoForBlock(s, i, stake.TxTypeSStx)
 			if err != nil {
-				const context = "Could not obtain ticket fee info"
-				return nil, rpcInternalErr(err, context)
+				return nil, ticketFeeInfoError(err)
 			}
 			feeInfoBlocks = append(feeInfoBlocks, *feeInfo)
 		}
@@ -4638,7 +5874,7 @@ func handleTicketFeeInfo(_ context.Context, s *Server, cmd interface{}) (interfa
 		feeInfo, err := ticketFeeInfoForRange(s, lastChange, bestHeight+1,
 			stake.TxTypeSStx)
 		if err != nil {
-			return nil, rpcInternalErr(err, "Could not obtain ticket fee info")
+			return nil, ticketFeeInfoError(err)
 		}
 		feeInfoWindows = append(feeInfoWindows, *feeInfo)
 
@@ -4659,8 +5895,7 @@ func handleTicketFeeInfo(_ context.Context, s *Server, cmd interface{}) (interfa
 				feeInfo, err := ticketFeeInfoForRange(s, i-winLen, i,
 					stake.TxTypeSStx)
 				if err != nil {
-					const context = "Could not obtain ticket fee info"
-					return nil, rpcInternalErr(err, context)
+					return nil, ticketFeeInfoError(err)
 				}
 				feeInfoWindows = append(feeInfoWindows, *feeInfo)
 			}
@@ -4767,6 +6002,221 @@ func handleTicketVWAP(_ context.Context, s *Server, cmd interface{}) (interface{
 	return dcrutil.Amount(vwap).ToCoin(), nil
 }
 
+// fetchTx returns the transaction with the provided hash from the mempool when
+// it is available there and from the transaction index otherwise.
+func (s *Server) fetchTx(txHash *chainhash.Hash) (*wire.MsgTx, error) {
+	tx, err := s.cfg.TxMempooler.FetchTransaction(txHash)
+	if err == nil {
+		return tx.MsgTx(), nil
+	}
+
+	txIndex := s.cfg.TxIndexer
+	if txIndex == nil {
+		err := errors.New("the transaction index must be enabled to " +
+			"query the blockchain (specify --txindex)")
+		return nil, rpcInternalErr(err, "Configuration")
+	}
+
+	// Ensure the tx index is synced.
+	tHeight, tHash, err := txIndex.Tip()
+	if err != nil {
+		return nil, rpcInternalErr(err, "Tip")
+	}
+
+	// Return an out-of-sync error if index is lagging a maximum reorg depth
+	// (6) blocks or more from the chain tip.
+	chain := s.cfg.Chain
+	if chain.BestSnapshot().Height > (tHeight + 5) {
+		err := fmt.Errorf("%s: index not synced", txIndex.Name())
+		return nil, rpcInternalErr(err, "Sync")
+	}
+
+sync:
+	for !chain.BestSnapshot().Hash.IsEqual(tHash) {
+		select {
+		case <-time.After(syncWait):
+			err := fmt.Errorf("%s: index not synced", txIndex.Name())
+			return nil, rpcInternalErr(err, "Sync")
+		case <-txIndex.WaitForSync():
+			break sync
+		}
+	}
+
+	// Look up the location of the transaction.
+	idxEntry, err := txIndex.Entry(txHash)
+	if err != nil {
+		const context = "Failed to retrieve transaction location"
+		return nil, rpcInternalErr(err, context)
+	}
+	if idxEntry == nil {
+		return nil, rpcNoTxInfoError(txHash)
+	}
+
+	// Load the raw transaction bytes from the database.
+	var txBytes []byte
+	err = s.cfg.DB.View(func(dbTx database.Tx) error {
+		var err error
+		txBytes, err = dbTx.FetchBlockRegion(&idxEntry.BlockRegion)
+		return err
+	})
+	if err != nil {
+		if isBlockDataPruned(s.cfg.Chain, idxEntry.BlockRegion.Hash) {
+			return nil, rpcBlockPrunedError()
+		}
+		return nil, rpcNoTxInfoError(txHash)
+	}
+
+	var msgTx wire.MsgTx
+	err = msgTx.Deserialize(bytes.NewReader(txBytes))
+	if err != nil {
+		return nil, rpcInternalErr(err, "Failed to deserialize transaction")
+	}
+	return &msgTx, nil
+}
+
+// hexStack returns the provided stack items hex encoded.
+func hexStack(stack [][]byte) []string {
+	if stack == nil {
+		return nil
+	}
+	items := make([]string, 0, len(stack))
+	for _, item := range stack {
+		items = append(items, hex.EncodeToString(item))
+	}
+	return items
+}
+
+// traceStepSize returns the approximate serialized size of the provided
+// tracetxinput step.
+func traceStepSize(step *types.ScriptTraceStep) int {
+	size := traceStepOverhead + len(step.Opcode) + len(step.Error) +
+		len(step.CondStack) + len(step.CondStackAfter)
+	for _, stack := range [][]string{step.Stack, step.AltStack,
+		step.StackAfter, step.AltStackAfter} {
+
+		for _, item := range stack {
+			size += len(item) + traceItemOverhead
+		}
+	}
+	return size
+}
+
+// handleTraceTxInput implements the tracetxinput command.
+func handleTraceTxInput(_ context.Context, s *Server, cmd interface{}) (interface{}, error) {
+	c := cmd.(*types.TraceTxInputCmd)
+
+	// Convert the provided transaction hash hex to a Hash.
+	txHash, err := chainhash.NewHashFromStr(c.Txid)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.Txid)
+	}
+
+	mtx, err := s.fetchTx(txHash)
+	if err != nil {
+		return nil, err
+	}
+	if c.Vin >= uint32(len(mtx.TxIn)) {
+		return nil, rpcInvalidError("Input index %d does not exist for "+
+			"transaction %v", c.Vin, txHash)
+	}
+
+	// Stakebase, treasurybase, and treasury spend inputs do not spend a
+	// previous output and therefore have no scripts to execute.
+	txIn := mtx.TxIn[c.Vin]
+	prevOut := &txIn.PreviousOutPoint
+	if prevOut.Hash == (chainhash.Hash{}) {
+		return nil, rpcInvalidError("Input %d of transaction %v does not "+
+			"spend a previous output", c.Vin, txHash)
+	}
+
+	// Look up the previous output being spent in the set of unspent outputs
+	// first since it will be available there when the transaction is in the
+	// mempool and otherwise fall back to the transaction that created it.
+	var prevTxOut *wire.TxOut
+	entry, err := s.cfg.Chain.FetchUtxoEntry(*prevOut)
+	if err != nil {
+		return nil, rpcInternalErr(err, "Failed to retrieve utxo entry")
+	}
+	if entry != nil && !entry.IsSpent() {
+		prevTxOut = &wire.TxOut{
+			Value:    entry.Amount(),
+			Version:  entry.ScriptVersion(),
+			PkScript: entry.PkScript(),
+		}
+	} else {
+		prevTx, err := s.fetchTx(&prevOut.Hash)
+		if err != nil {
+			return nil, err
+		}
+		if prevOut.Index >= uint32(len(prevTx.TxOut)) {
+			return nil, rpcInvalidError("Output %v spent by input %d of "+
+				"transaction %v does not exist", prevOut, c.Vin, txHash)
+		}
+		prevTxOut = prevTx.TxOut[prevOut.Index]
+	}
+
+	scriptFlags, err := s.cfg.StandardVerifyFlags()
+	if err != nil {
+		return nil, rpcInternalErr(err, "Could not obtain script flags")
+	}
+
+	// Execute the input scripts with a tracer that records each executed
+	// opcode.  Note that failing to create the engine and failing to execute
+	// the scripts are reported in the result as opposed to returning an error
+	// since reporting why an input is invalid is the purpose of the command.
+	sigScriptAsm, _ := txscript.DisasmString(txIn.SignatureScript)
+	result := types.TraceTxInputResult{
+		Txid:   txHash.String(),
+		Vin:    c.Vin,
+		Amount: dcrutil.Amount(prevTxOut.Value).ToCoin(),
+		ScriptSig: types.ScriptSig{
+			Asm: sigScriptAsm,
+			Hex: hex.EncodeToString(txIn.SignatureScript),
+		},
+		ScriptPubKey: s.scriptPubKeyResult(prevTxOut.Version,
+			prevTxOut.PkScript),
+		Steps: make([]types.ScriptTraceStep, 0),
+	}
+	vm, err := txscript.NewEngine(prevTxOut.PkScript, mtx, int(c.Vin),
+		scriptFlags, prevTxOut.Version, nil)
+	if err == nil {
+		var traceSize int
+		vm.SetTracer(func(step *txscript.TraceStep) {
+			traceStep := types.ScriptTraceStep{
+				ScriptIdx:      step.ScriptIdx,
+				OpcodeIdx:      step.OpcodeIdx,
+				Opcode:         step.Disasm,
+				Stack:          hexStack(step.Stack),
+				AltStack:       hexStack(step.AltStack),
+				CondStack:      step.CondStack,
+				StackAfter:     hexStack(step.StackAfter),
+				AltStackAfter:  hexStack(step.AltStackAfter),
+				CondStackAfter: step.CondStackAfter,
+			}
+			if step.Err != nil {
+				traceStep.Error = step.Err.Error()
+			}
+
+			// Stop tracing and mark the trace as truncated once it reaches
+			// the maximum allowed size.  The scripts are still executed to
+			// completion so the result reports whether or not they are valid.
+			traceSize += traceStepSize(&traceStep)
+			if traceSize > maxTraceTxInputSize {
+				result.Truncated = true
+				vm.SetTracer(nil)
+				return
+			}
+			result.Steps = append(result.Steps, traceStep)
+		})
+		err = vm.Execute()
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	result.Valid = err == nil
+	return result, nil
+}
+
 // handleTxFeeInfo implements the txfeeinfo command.
 func handleTxFeeInfo(_ context.Context, s *Server, cmd interface{}) (interface{}, error) {
 	c := cmd.(*types.TxFeeInfoCmd)
@@ -4790,8 +6240,7 @@ func handleTxFeeInfo(_ context.Context, s *Server, cmd interface{}) (interface{}
 			feeInfo, err := ticketFeeInfoForBlock(s, i,
 				stake.TxTypeRegular)
 			if err != nil {
-				const context = "Could not obtain ticket fee info"
-				return nil, rpcInternalErr(err, context)
+				return nil, ticketFeeInfoError(err)
 			}
 			feeInfoBlocks = append(feeInfoBlocks, *feeInfo)
 		}
@@ -4832,7 +6281,7 @@ func handleTxFeeInfo(_ context.Context, s *Server, cmd interface{}) (interface{}
 	feeInfo, err := ticketFeeInfoForRange(s, int64(start), int64(end+1),
 		stake.TxTypeRegular)
 	if err != nil {
-		return nil, rpcInternalErr(err, "Could not obtain ticket fee info")
+		return nil, ticketFeeInfoError(err)
 	}
 
 	feeInfoRange = types.FeeInfoRange{
@@ -5085,6 +6534,42 @@ func (s *Server) isSubsidySplitR2AgendaActive(prevBlkHash *chainhash.Hash) (bool
 	return isActive, nil
 }
 
+// calcSubsidySplit returns the treasury, stake vote, and proof-of-work subsidy
+// portions, in that order, for a block at the provided height with the provided
+// number of voters based on the agendas that are active for the block AFTER the
+// provided block hash.
+func (s *Server) calcSubsidySplit(prevBlkHash *chainhash.Hash, height int64, voters uint16) (int64, int64, int64, error) {
+	isTreasuryEnabled, err := s.isTreasuryAgendaActive(prevBlkHash)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	isSubsidyEnabled, err := s.isSubsidySplitAgendaActive(prevBlkHash)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	isSubsidyR2Enabled, err := s.isSubsidySplitR2AgendaActive(prevBlkHash)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	// Determine which subsidy split variant to use depending on the active
+	// agendas.
+	subsidySplitVariant := standalone.SSVOriginal
+	switch {
+	case isSubsidyR2Enabled:
+		subsidySplitVariant = standalone.SSVDCP0012
+	case isSubsidyEnabled:
+		subsidySplitVariant = standalone.SSVDCP0010
+	}
+
+	subsidyCache := s.cfg.SubsidyCache
+	dev := subsidyCache.CalcTreasurySubsidy(height, voters, isTreasuryEnabled)
+	pos := subsidyCache.CalcStakeVoteSubsidyV3(height-1, subsidySplitVariant) *
+		int64(voters)
+	pow := subsidyCache.CalcWorkSubsidyV3(height, voters, subsidySplitVariant)
+	return dev, pos, pow, nil
+}
+
 // httpStatusLine returns a response Status-Line (RFC 2616 Section 6.1) for the
 // given request and response status code.  This function was lifted and
 // adapted from the standard library HTTP server code since it's not exported.
@@ -5328,8 +6813,40 @@ func (s *Server) standardCmdResult(ctx context.Context, cmd *parsedRPCCmd) (inte
 	if !ok {
 		return nil, dcrjson.ErrRPCMethodNotFound
 	}
+	if err := s.checkLightMode(cmd.method); err != nil {
+		return nil, err
+	}
+
+	if s.cfg.RequestObserver == nil {
+		return handler(ctx, s, cmd.params)
+	}
+	start := time.Now()
+	result, err := handler(ctx, s, cmd.params)
+	s.cfg.RequestObserver.ObserveRequest(cmd.method, time.Since(start))
+	return result, err
+}
+
+// checkLightMode returns ErrRPCLightMode when the server is running in light
+// mode and the provided command is not available in that case.
+func (s *Server) checkLightMode(method types.Method) error {
+	if !s.cfg.LightMode {
+		return nil
+	}
+	if _, ok := rpcLightMode[string(method)]; !ok {
+		return ErrRPCLightMode
+	}
+	return nil
+}
 
-	return handler(ctx, s, cmd.params)
+// bestBlock returns the hash and height of the best block.  This is the best
+// known header when running in light mode since the blocks are not connected in
+// that case.
+func (s *Server) bestBlock() (chainhash.Hash, int64) {
+	if s.cfg.LightMode {
+		return s.cfg.Chain.BestHeader()
+	}
+	best := s.cfg.Chain.BestSnapshot()
+	return best.Hash, best.Height
 }
 
 // parseCmd parses a JSON-RPC request object into known concrete command.  The
@@ -5826,6 +7343,20 @@ func (s *Server) Run(ctx context.Context) {
 		}(listener)
 	}
 
+	// Serve the REST interface on its own listeners when enabled.
+	if len(s.cfg.RESTListeners) > 0 {
+		restServer := s.restRoute(ctx)
+		for _, listener := range s.cfg.RESTListeners {
+			wg.Add(1)
+			go func(listener net.Listener) {
+				log.Infof("REST server listening on %s", listener.Addr())
+				restServer.Serve(listener)
+				log.Tracef("REST listener done for %s", listener.Addr())
+				wg.Done()
+			}(listener)
+		}
+	}
+
 	// Subscribe for async work notifications when background template
 	// generation is enabled.
 	if len(s.cfg.MiningAddrs) > 0 && s.cfg.BlockTemplater != nil {
@@ -5853,7 +7384,11 @@ func (s *Server) Run(ctx context.Context) {
 	// Close all listeners and wait for all goroutines to terminate.
 	log.Warnf("RPC server shutting down")
 	var hasCloseErr bool
-	for _, listener := range s.cfg.Listeners {
+	listeners := make([]net.Listener, 0, len(s.cfg.Listeners)+
+		len(s.cfg.RESTListeners))
+	listeners = append(listeners, s.cfg.Listeners...)
+	listeners = append(listeners, s.cfg.RESTListeners...)
+	for _, listener := range listeners {
 		err := listener.Close()
 		if err != nil {
 			log.Errorf("Failed to close listener %s: %v", listener.Addr(), err)
@@ -5874,6 +7409,12 @@ type Config struct {
 	// is stopped.
 	Listeners []net.Listener
 
+	// RESTListeners defines a slice of listeners for which the RPC server will
+	// take ownership of and serve the unauthenticated read-only REST interface
+	// on.  The REST interface is disabled when there are none.  They will be
+	// closed when the RPC server is stopped.
+	RESTListeners []net.Listener
+
 	// StartupTime is the unix timestamp for when the server that is hosting
 	// the RPC server started.
 	StartupTime int64
@@ -5915,6 +7456,10 @@ type Config struct {
 	// TxMempooler defines the transaction memory pool to interact with.
 	TxMempooler TxMempooler
 
+	// MempoolPersister defines the interface to use for saving and loading
+	// the contents of the transaction memory pool.
+	MempoolPersister MempoolPersister
+
 	// These fields allow the RPC server to interface with mining.
 	//
 	// BlockTemplater generates block templates, CPUMiner solves
@@ -5927,9 +7472,21 @@ type Config struct {
 	// use.
 	TxIndexer TxIndexer
 
+	// AddrIndexer defines the optional address indexer for the RPC server to
+	// use.
+	AddrIndexer AddrIndexer
+
+	// SpendIndexer defines the optional spend indexer for the RPC server to
+	// use.
+	SpendIndexer SpendIndexer
+
 	// NetInfo defines a slice of the available networks.
 	NetInfo []types.NetworksResult
 
+	// BanDuration is the default duration subnets are banned for when a ban
+	// time is not specified.
+	BanDuration time.Duration
+
 	// MinRelayTxFee defines the minimum transaction fee in Atoms/1000 bytes to be
 	// considered a non-zero fee.
 	MinRelayTxFee dcrutil.Amount
@@ -5977,6 +7534,19 @@ type Config struct {
 
 	// FiltererV2 defines the V2 filterer for the RPC server to use.
 	FiltererV2 FiltererV2
+
+	// RequestObserver defines an optional observer of the requests handled by
+	// the RPC server.
+	RequestObserver RequestObserver
+
+	// LightMode indicates whether or not the server is running in light mode
+	// which only syncs the block headers and committed filters.  Only a subset
+	// of the commands are available in that case.
+	LightMode bool
+
+	// StandardVerifyFlags defines the function to retrieve the flags to use
+	// for verifying scripts for the block after the current best block.
+	StandardVerifyFlags func() (txscript.ScriptFlags, error)
 }
 
 // New returns a new instance of the Server struct.
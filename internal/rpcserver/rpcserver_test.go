@@ -7,8 +7,10 @@
 package rpcserver
 
 import (
+	"context"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/decred/dcrd/rpc/jsonrpc/types/v4"
 )
@@ -151,3 +153,38 @@ func TestLimitedMethodsExist(t *testing.T) {
 		}
 	}
 }
+
+// testRequestObserver provides a mock request observer that records the
+// observed methods.
+type testRequestObserver struct {
+	methods []types.Method
+}
+
+// ObserveRequest records the provided method.
+func (o *testRequestObserver) ObserveRequest(method types.Method, _ time.Duration) {
+	o.methods = append(o.methods, method)
+}
+
+// TestRequestObserver ensures the configured request observer is notified of
+// handled standard RPC commands.
+func TestRequestObserver(t *testing.T) {
+	observer := new(testRequestObserver)
+	cfg := defaultMockConfig(defaultChainParams)
+	cfg.RequestObserver = observer
+	s := &Server{cfg: *cfg}
+
+	cmd := &parsedRPCCmd{method: "getbestblockhash"}
+	if _, err := s.standardCmdResult(context.Background(), cmd); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Ensure unknown methods are not observed.
+	cmd = &parsedRPCCmd{method: "unknownmethod"}
+	if _, err := s.standardCmdResult(context.Background(), cmd); err == nil {
+		t.Fatal("did not receive expected error for unknown method")
+	}
+
+	if len(observer.methods) != 1 || observer.methods[0] != "getbestblockhash" {
+		t.Fatalf("unexpected observed methods: %v", observer.methods)
+	}
+}
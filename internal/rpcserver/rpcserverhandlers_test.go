@@ -1,4 +1,4 @@
-// Copyright (c) 2020-2023 The Decred developers
+// Copyright (c) 2020-2024 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
@@ -31,19 +31,24 @@ import (
 	"github.com/decred/dcrd/chaincfg/chainhash"
 	"github.com/decred/dcrd/chaincfg/v3"
 	"github.com/decred/dcrd/database/v3"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
 	"github.com/decred/dcrd/dcrjson/v4"
 	"github.com/decred/dcrd/dcrutil/v4"
 	"github.com/decred/dcrd/gcs/v4"
 	"github.com/decred/dcrd/gcs/v4/blockcf2"
+	"github.com/decred/dcrd/hdkeychain/v3"
+	"github.com/decred/dcrd/hdkeychain/v3/descriptor"
 	"github.com/decred/dcrd/internal/blockchain"
 	"github.com/decred/dcrd/internal/blockchain/indexers"
 	"github.com/decred/dcrd/internal/mempool"
 	"github.com/decred/dcrd/internal/mining"
+	"github.com/decred/dcrd/internal/staging/banmanager"
 	"github.com/decred/dcrd/internal/version"
 	"github.com/decred/dcrd/math/uint256"
 	"github.com/decred/dcrd/peer/v3"
 	"github.com/decred/dcrd/rpc/jsonrpc/types/v4"
 	"github.com/decred/dcrd/txscript/v4"
+	"github.com/decred/dcrd/txscript/v4/psdt"
 	"github.com/decred/dcrd/txscript/v4/stdaddr"
 	"github.com/decred/dcrd/txscript/v4/stdscript"
 	"github.com/decred/dcrd/wire"
@@ -143,6 +148,8 @@ type testRPCChain struct {
 	blockHashByHeightErr          error
 	blockHeightByHash             int64
 	blockHeightByHashErr          error
+	calcNextReqStakeDiffV2        int64
+	calcNextReqStakeDiffV2Err     error
 	calcWantHeight                int64
 	chainTips                     []blockchain.ChainTipInfo
 	chainWork                     uint256.Uint256
@@ -152,6 +159,8 @@ type testRPCChain struct {
 	countVoteVersion              uint32
 	countVoteVersionErr           error
 	estimateNextStakeDifficultyFn func(hash *chainhash.Hash, newTickets int64, useMaxTickets bool) (diff int64, err error)
+	fetchSpentTxOuts              []blockchain.SpentTxOut
+	fetchSpentTxOutsErr           error
 	fetchUtxoEntry                UtxoEntry
 	fetchUtxoEntryErr             error
 	fetchUtxoStats                *blockchain.UtxoStats
@@ -168,6 +177,7 @@ type testRPCChain struct {
 	heightRangeFn                 func(startHeight, endHeight int64) ([]chainhash.Hash, error)
 	invalidateBlockErr            error
 	isCurrent                     bool
+	isPruned                      bool
 	liveTickets                   []chainhash.Hash
 	liveTicketsErr                error
 	locateHeaders                 []wire.BlockHeader
@@ -182,6 +192,7 @@ type testRPCChain struct {
 	missedTicketsErr              error
 	nextThresholdState            blockchain.ThresholdStateTuple
 	nextThresholdStateErr         error
+	pruneHeight                   int64
 	reconsiderBlockErr            error
 	stateLastChangedHeight        int64
 	stateLastChangedHeightErr     error
@@ -233,6 +244,12 @@ func (c *testRPCChain) BlockHeightByHash(hash *chainhash.Hash) (int64, error) {
 	return c.blockHeightByHash, c.blockHeightByHashErr
 }
 
+// CalcNextRequiredStakeDifficultyV2 returns a mocked required stake difficulty
+// for the block after the given block.
+func (c *testRPCChain) CalcNextRequiredStakeDifficultyV2(hash *chainhash.Hash) (int64, error) {
+	return c.calcNextReqStakeDiffV2, c.calcNextReqStakeDiffV2Err
+}
+
 // CalcWantHeight returns a mocked height of the final block of the previous
 // interval given a block height.
 func (c *testRPCChain) CalcWantHeight(interval, height int64) int64 {
@@ -273,6 +290,11 @@ func (c *testRPCChain) EstimateNextStakeDifficulty(hash *chainhash.Hash, newTick
 	return c.estimateNextStakeDifficultyFn(hash, newTickets, useMaxTickets)
 }
 
+// FetchSpentTxOuts returns mocked spent transaction outputs.
+func (c *testRPCChain) FetchSpentTxOuts(hash *chainhash.Hash) ([]blockchain.SpentTxOut, error) {
+	return c.fetchSpentTxOuts, c.fetchSpentTxOutsErr
+}
+
 // FetchUtxoEntry returns a mocked UtxoEntry.
 func (c *testRPCChain) FetchUtxoEntry(outpoint wire.OutPoint) (UtxoEntry, error) {
 	return c.fetchUtxoEntry, c.fetchUtxoEntryErr
@@ -329,6 +351,18 @@ func (c *testRPCChain) IsCurrent() bool {
 	return c.isCurrent
 }
 
+// IsPruned returns a mocked bool representing whether or not the chain has
+// pruned block data.
+func (c *testRPCChain) IsPruned() bool {
+	return c.isPruned
+}
+
+// PruneHeight returns a mocked height of the oldest block in the main chain
+// with data available.
+func (c *testRPCChain) PruneHeight() int64 {
+	return c.pruneHeight
+}
+
 // LiveTickets returns a mocked slice of all currently live tickets.
 func (c *testRPCChain) LiveTickets() ([]chainhash.Hash, error) {
 	return c.liveTickets, c.liveTicketsErr
@@ -458,6 +492,7 @@ type testPeer struct {
 	connected         bool
 	id                int32
 	inbound           bool
+	connType          string
 	localAddr         net.Addr
 	lastPingNonce     uint64
 	isTxRelayDisabled bool
@@ -486,6 +521,12 @@ func (p *testPeer) Inbound() bool {
 	return p.inbound
 }
 
+// ConnType returns a mocked description of the type of connection with the
+// peer.
+func (p *testPeer) ConnType() string {
+	return p.connType
+}
+
 // StatsSnapshot returns a mocked snapshot of the current peer flags and
 // statistics.
 func (p *testPeer) StatsSnapshot() *peer.StatsSnap {
@@ -518,6 +559,8 @@ func (p *testPeer) BanScore() uint32 {
 // AddrManager interface.
 type testAddrManager struct {
 	localAddresses []addrmgr.LocalAddr
+	addressCache   []*addrmgr.NetAddress
+	asns           map[string]uint32
 }
 
 // LocalAddresses returns a mocked summary of local addresses information
@@ -526,6 +569,25 @@ func (c *testAddrManager) LocalAddresses() []addrmgr.LocalAddr {
 	return c.localAddresses
 }
 
+// AddressCache returns a mocked subset of known good addresses.
+func (c *testAddrManager) AddressCache() []*addrmgr.NetAddress {
+	return c.addressCache
+}
+
+// GroupKey returns a mocked network group for the provided address which is
+// the autonomous system when it is mocked and the network prefix otherwise.
+func (c *testAddrManager) GroupKey(na *addrmgr.NetAddress) string {
+	if asn := c.ASN(na); asn != 0 {
+		return fmt.Sprintf("as:%d", asn)
+	}
+	return na.GroupKey()
+}
+
+// ASN returns a mocked autonomous system number for the provided address.
+func (c *testAddrManager) ASN(na *addrmgr.NetAddress) uint32 {
+	return c.asns[na.Key()]
+}
+
 // testSyncManager provides a mock sync manager by implementing the
 // SyncManager interface.
 type testSyncManager struct {
@@ -652,6 +714,102 @@ func (t *testTxIndexer) Entry(hash *chainhash.Hash) (*indexers.TxIndexEntry, err
 	return t.entry(hash)
 }
 
+// testAddrIndexer provides a mock address indexer by implementing the
+// AddrIndexer interface.
+type testAddrIndexer struct {
+	entries      []indexers.TxIndexEntry
+	entriesErr   error
+	unconfirmed  []*dcrutil.Tx
+	tipHeight    int64
+	tipHash      *chainhash.Hash
+	tipErr       error
+	signalOnWait bool
+}
+
+// Name returns the human-readable name of the index.
+func (t *testAddrIndexer) Name() string {
+	return "testAddrIndexer"
+}
+
+// Tip returns the current index tip.
+func (t *testAddrIndexer) Tip() (int64, *chainhash.Hash, error) {
+	return t.tipHeight, t.tipHash, t.tipErr
+}
+
+// WaitForSync subscribes clients for the next index sync update.
+func (t *testAddrIndexer) WaitForSync() chan bool {
+	c := make(chan bool)
+	if t.signalOnWait {
+		close(c)
+	}
+	return c
+}
+
+// EntriesForAddress returns the mocked index entries after applying the
+// provided paging parameters.
+func (t *testAddrIndexer) EntriesForAddress(addr stdaddr.Address, numToSkip, numRequested uint32, reverse bool) ([]indexers.TxIndexEntry, uint32, error) {
+	if t.entriesErr != nil {
+		return nil, 0, t.entriesErr
+	}
+	entries := make([]indexers.TxIndexEntry, len(t.entries))
+	copy(entries, t.entries)
+	if reverse {
+		for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+			entries[i], entries[j] = entries[j], entries[i]
+		}
+	}
+	if numToSkip > uint32(len(entries)) {
+		numToSkip = uint32(len(entries))
+	}
+	entries = entries[numToSkip:]
+	if uint32(len(entries)) > numRequested {
+		entries = entries[:numRequested]
+	}
+	return entries, numToSkip, nil
+}
+
+// UnconfirmedTxnsForAddress returns the mocked unconfirmed transactions.
+func (t *testAddrIndexer) UnconfirmedTxnsForAddress(addr stdaddr.Address) []*dcrutil.Tx {
+	txns := make([]*dcrutil.Tx, len(t.unconfirmed))
+	copy(txns, t.unconfirmed)
+	return txns
+}
+
+// testSpendIndexer provides a mock spend indexer by implementing the
+// SpendIndexer interface.
+type testSpendIndexer struct {
+	entry        *indexers.SpendIndexEntry
+	entryErr     error
+	tipHeight    int64
+	tipHash      *chainhash.Hash
+	tipErr       error
+	signalOnWait bool
+}
+
+// Name returns the human-readable name of the index.
+func (t *testSpendIndexer) Name() string {
+	return "testSpendIndexer"
+}
+
+// Tip returns the current index tip.
+func (t *testSpendIndexer) Tip() (int64, *chainhash.Hash, error) {
+	return t.tipHeight, t.tipHash, t.tipErr
+}
+
+// WaitForSync subscribes clients for the next index sync update.
+func (t *testSpendIndexer) WaitForSync() chan bool {
+	c := make(chan bool)
+	if t.signalOnWait {
+		close(c)
+	}
+	return c
+}
+
+// Entry returns the mocked spend index entry.
+func (t *testSpendIndexer) Entry(outpoint *wire.OutPoint) (*indexers.SpendIndexEntry, error) {
+	return t.entry, t.entryErr
+}
+
 // testDB provides a mock database by implementing the database.DB interface.
 type testDB struct {
 	dbType   string
@@ -700,6 +858,7 @@ func (d *testDB) Flush() error {
 type testDatabaseTx struct {
 	metadata             database.Bucket
 	storeBlockErr        error
+	deleteBlockErr       error
 	hasBlock             bool
 	hasBlockErr          error
 	hasBlocks            []bool
@@ -729,6 +888,12 @@ func (t *testDatabaseTx) StoreBlock(block database.BlockSerializer) error {
 	return t.storeBlockErr
 }
 
+// DeleteBlock provides a mock implementation for removing the provided block
+// from the database.
+func (t *testDatabaseTx) DeleteBlock(hash *chainhash.Hash) error {
+	return t.deleteBlockErr
+}
+
 // HasBlock returns a mocked bool representing whether or not a block with the
 // given hash exists in the database.
 func (t *testDatabaseTx) HasBlock(hash *chainhash.Hash) (bool, error) {
@@ -804,6 +969,10 @@ type testConnManager struct {
 	persistentPeers     []Peer
 	addedNodeInfo       []Peer
 	lookup              func(host string) ([]net.IP, error)
+	banSubnet           func(subnet *net.IPNet, created, expires time.Time) error
+	unbanSubnetErr      error
+	bannedSubnets       []banmanager.BanEntry
+	clearBannedErr      error
 }
 
 // Connect provides a mock implementation for adding the provided address as a
@@ -880,6 +1049,29 @@ func (c *testConnManager) Lookup(host string) ([]net.IP, error) {
 	return c.lookup(host)
 }
 
+// BanSubnet provides a mock implementation for adding the provided subnet to
+// the ban list.
+func (c *testConnManager) BanSubnet(subnet *net.IPNet, created, expires time.Time) error {
+	return c.banSubnet(subnet, created, expires)
+}
+
+// UnbanSubnet provides a mock implementation for removing the provided subnet
+// from the ban list.
+func (c *testConnManager) UnbanSubnet(subnet *net.IPNet) error {
+	return c.unbanSubnetErr
+}
+
+// BannedSubnets returns a mocked list of entries in the ban list.
+func (c *testConnManager) BannedSubnets() []banmanager.BanEntry {
+	return c.bannedSubnets
+}
+
+// ClearBanned provides a mock implementation for removing all entries from the
+// ban list.
+func (c *testConnManager) ClearBanned() error {
+	return c.clearBannedErr
+}
+
 // testCPUMiner provides a mock CPU miner by implementing the CPUMiner
 // interface.
 type testCPUMiner struct {
@@ -990,6 +1182,29 @@ func (s *testSanityChecker) CheckBlockSanity(block *dcrutil.Block) error {
 	return s.checkBlockSanityErr
 }
 
+// testMempoolPersister provides a mock mempool persister by implementing the
+// MempoolPersister interface.
+type testMempoolPersister struct {
+	filename string
+	saved    int
+	saveErr  error
+	accepted int
+	rejected int
+	loadErr  error
+}
+
+// SaveMempool returns the mocked path of the mempool file and number of saved
+// transactions.
+func (p *testMempoolPersister) SaveMempool() (string, int, error) {
+	return p.filename, p.saved, p.saveErr
+}
+
+// LoadMempool returns the mocked path of the mempool file and number of loaded
+// transactions.
+func (p *testMempoolPersister) LoadMempool() (string, int, int, error) {
+	return p.filename, p.accepted, p.rejected, p.loadErr
+}
+
 // testFiltererV2 provides a mock V2 filterer by implementing the FiltererV2
 // interface.
 type testFiltererV2 struct {
@@ -1092,7 +1307,11 @@ type testTxMempooler struct {
 	count               int
 	fetchTransaction    *dcrutil.Tx
 	fetchTransactionErr error
+	fetchSpendingTx     *dcrutil.Tx
 	tspendHashes        []chainhash.Hash
+	minRelayFee         dcrutil.Amount
+	maxSize             int64
+	numEvicted          uint64
 }
 
 // HaveTransactions returns a mocked bool slice representing whether or not the
@@ -1124,12 +1343,33 @@ func (mp *testTxMempooler) FetchTransaction(txHash *chainhash.Hash) (*dcrutil.Tx
 	return mp.fetchTransaction, mp.fetchTransactionErr
 }
 
+// FetchSpendingTx returns the mocked transaction that spends the provided
+// outpoint.
+func (mp *testTxMempooler) FetchSpendingTx(outpoint *wire.OutPoint) *dcrutil.Tx {
+	return mp.fetchSpendingTx
+}
+
 // TSpendHashes returns the mocked list of mempool treasury spend transaction
 // hashes.
 func (mp *testTxMempooler) TSpendHashes() []chainhash.Hash {
 	return mp.tspendHashes
 }
 
+// MinRelayFee returns the mocked minimum relay fee of the pool.
+func (mp *testTxMempooler) MinRelayFee() dcrutil.Amount {
+	return mp.minRelayFee
+}
+
+// MaxSize returns the mocked maximum size of the pool.
+func (mp *testTxMempooler) MaxSize() int64 {
+	return mp.maxSize
+}
+
+// NumEvicted returns the mocked number of transactions evicted from the pool.
+func (mp *testTxMempooler) NumEvicted() uint64 {
+	return mp.numEvicted
+}
+
 // testNtfnManager provides a mock notification manager by implementing the
 // NtfnManager interface.
 type testNtfnManager struct {
@@ -1312,6 +1552,82 @@ func hexToMsgTx(s string) *wire.MsgTx {
 	return &msgTx
 }
 
+// testPSDTs houses partially signed transactions for a transaction that spends
+// a 2-of-2 multisig pay-to-script-hash output for use in the tests.
+type testPSDTs struct {
+	unsigned     *psdt.Packet
+	signed1      *psdt.Packet
+	signed2      *psdt.Packet
+	combined     *psdt.Packet
+	redeemScript []byte
+	p2shAddr     string
+}
+
+// makeTestPSDTs returns partially signed transactions for use in the tests and
+// will panic if there is an error.
+func makeTestPSDTs() *testPSDTs {
+	key1 := secp256k1.PrivKeyFromBytes(hexToBytes("01"))
+	key2 := secp256k1.PrivKeyFromBytes(hexToBytes("02"))
+	redeemScript, err := stdscript.MultiSigScriptV0(2,
+		key1.PubKey().SerializeCompressed(),
+		key2.PubKey().SerializeCompressed())
+	if err != nil {
+		panic(err)
+	}
+	addr, err := stdaddr.NewAddressScriptHashV0(redeemScript,
+		defaultChainParams)
+	if err != nil {
+		panic(err)
+	}
+	_, pkScript := addr.PaymentScript()
+
+	tx := hexToMsgTx("01000000010d33d3840e9074183dc9a8d82a5031075a98135bfe1" +
+		"82840ddaf575aa2032fe00000000000feffffff0100e1f50500000000000017a91" +
+		"4f59833f104faa3c7fd0c7dc1e3967fe77a9c15238701000000010000000100e1f" +
+		"5050000000000000000ffffffff00")
+	unsigned, err := psdt.New(tx, psdt.TxTypeRegular)
+	if err != nil {
+		panic(err)
+	}
+	err = unsigned.AddInPrevOut(0, wire.NewTxOut(150000000, pkScript))
+	if err != nil {
+		panic(err)
+	}
+	if err := unsigned.AddInRedeemScript(0, redeemScript); err != nil {
+		panic(err)
+	}
+
+	signed1, signed2 := unsigned.Copy(), unsigned.Copy()
+	if err := signed1.Sign(0, key1); err != nil {
+		panic(err)
+	}
+	if err := signed2.Sign(0, key2); err != nil {
+		panic(err)
+	}
+	combined, err := psdt.Combine(signed1, signed2)
+	if err != nil {
+		panic(err)
+	}
+	return &testPSDTs{
+		unsigned:     unsigned,
+		signed1:      signed1,
+		signed2:      signed2,
+		combined:     combined,
+		redeemScript: redeemScript,
+		p2shAddr:     addr.String(),
+	}
+}
+
+// mustBase64 returns the base64 encoding of the provided partially signed
+// transaction and will panic if there is an error.
+func mustBase64(p *psdt.Packet) string {
+	encoded, err := p.Base64()
+	if err != nil {
+		panic(err)
+	}
+	return encoded
+}
+
 // cloneParams returns a deep copy of the provided parameters so the caller is
 // free to modify them without worrying about interfering with other tests.
 func cloneParams(params *chaincfg.Params) *chaincfg.Params {
@@ -1374,13 +1690,19 @@ type rpcTest struct {
 	setExistsAddresserNil bool
 	mockTxIndexer         *testTxIndexer
 	setTxIndexerNil       bool
+	mockAddrIndexer       *testAddrIndexer
+	setAddrIndexerNil     bool
+	mockSpendIndexer      *testSpendIndexer
+	setSpendIndexerNil    bool
 	mockDB                *testDB
 	mockConnManager       *testConnManager
 	mockClock             *testClock
 	mockLogManager        *testLogManager
 	mockFiltererV2        *testFiltererV2
 	mockTxMempooler       *testTxMempooler
+	mockMempoolPersister  *testMempoolPersister
 	mockHelpCacher        *testHelpCacher
+	lightMode             bool
 	result                interface{}
 	wantErr               bool
 	errCode               dcrjson.RPCErrorCode
@@ -1627,6 +1949,36 @@ func defaultMockTxIndexer() *testTxIndexer {
 	}
 }
 
+// defaultMockAddrIndexer provides a default mock address indexer to be used
+// throughout the tests. Tests can override these defaults by calling
+// defaultMockAddrIndexer, updating fields as necessary on the returned
+// *testAddrIndexer, and then setting rpcTest.mockAddrIndexer as that
+// *testAddrIndexer.
+func defaultMockAddrIndexer() *testAddrIndexer {
+	bestHeight := int64(block432100.Header.Height)
+	bestHash := block432100.Header.BlockHash()
+	return &testAddrIndexer{
+		tipHeight:    bestHeight,
+		tipHash:      &bestHash,
+		signalOnWait: true,
+	}
+}
+
+// defaultMockSpendIndexer provides a default mock spend indexer to be used
+// throughout the tests. Tests can override these defaults by calling
+// defaultMockSpendIndexer, updating fields as necessary on the returned
+// *testSpendIndexer, and then setting rpcTest.mockSpendIndexer as that
+// *testSpendIndexer.
+func defaultMockSpendIndexer() *testSpendIndexer {
+	bestHeight := int64(block432100.Header.Height)
+	bestHash := block432100.Header.BlockHash()
+	return &testSpendIndexer{
+		tipHeight:    bestHeight,
+		tipHash:      &bestHash,
+		signalOnWait: true,
+	}
+}
+
 // defaultMockDB provides a default mock database to be used throughout the
 // tests. Tests can override these defaults by calling defaultMockDB, updating
 // fields as necessary on the returned *testDB, and then setting rpcTest.mockDB
@@ -1716,6 +2068,17 @@ func defaultMockConnManager() *testConnManager {
 			}
 			return nil, errors.New("host not found")
 		},
+		banSubnet: func(subnet *net.IPNet, created, expires time.Time) error {
+			return nil
+		},
+		bannedSubnets: []banmanager.BanEntry{{
+			Subnet: &net.IPNet{
+				IP:   net.IPv4(10, 0, 0, 0).To4(),
+				Mask: net.CIDRMask(24, 32),
+			},
+			Created: time.Unix(1592931302, 0),
+			Expires: time.Unix(1593017702, 0),
+		}},
 	}
 }
 
@@ -1739,6 +2102,17 @@ func defaultMockLogManager() *testLogManager {
 	}
 }
 
+// defaultMockMempoolPersister provides a default mock mempool persister to be
+// used throughout the tests.  Tests can override these defaults by calling
+// defaultMockMempoolPersister, updating fields as necessary on the returned
+// *testMempoolPersister, and then setting rpcTest.mockMempoolPersister as that
+// *testMempoolPersister.
+func defaultMockMempoolPersister() *testMempoolPersister {
+	return &testMempoolPersister{
+		filename: "/home/user/.dcrd/data/mainnet/mempool.dat",
+	}
+}
+
 // defaultMockFiltererV2 provides a default mock V2 filterer to be used
 // throughout the tests. Tests can override these defaults by calling
 // defaultMockFiltererV2, updating fields as necessary on the returned
@@ -1780,6 +2154,7 @@ func defaultMockCPUMiner() *testCPUMiner {
 func defaultMockTxMempooler() *testTxMempooler {
 	return &testTxMempooler{
 		fetchTransactionErr: errors.New("transaction is not in the pool"),
+		minRelayFee:         mempool.DefaultMinRelayTxFee,
 	}
 }
 
@@ -1787,25 +2162,33 @@ func defaultMockTxMempooler() *testTxMempooler {
 // the tests.  Defaults can be overridden by tests through the rpcTest struct.
 func defaultMockConfig(chainParams *chaincfg.Params) *Config {
 	return &Config{
-		ChainParams:     chainParams,
-		Chain:           defaultMockRPCChain(),
-		SanityChecker:   defaultMockSanityChecker(),
-		BlockTemplater:  defaultMockBlockTemplater(),
-		AddrManager:     defaultMockAddrManager(),
-		FeeEstimator:    defaultMockFeeEstimator(),
-		SyncMgr:         defaultMockSyncManager(),
-		ExistsAddresser: defaultMockExistsAddresser(),
-		TxIndexer:       defaultMockTxIndexer(),
-		DB:              defaultMockDB(),
-		ConnMgr:         defaultMockConnManager(),
-		CPUMiner:        defaultMockCPUMiner(),
-		TxMempooler:     defaultMockTxMempooler(),
-		Clock:           &testClock{},
-		LogManager:      defaultMockLogManager(),
-		FiltererV2:      defaultMockFiltererV2(),
-		TimeSource:      blockchain.NewMedianTime(),
-		Services:        wire.SFNodeNetwork | wire.SFNodeCF,
-		SubsidyCache:    standalone.NewSubsidyCache(chainParams),
+		ChainParams:      chainParams,
+		Chain:            defaultMockRPCChain(),
+		SanityChecker:    defaultMockSanityChecker(),
+		BlockTemplater:   defaultMockBlockTemplater(),
+		AddrManager:      defaultMockAddrManager(),
+		FeeEstimator:     defaultMockFeeEstimator(),
+		SyncMgr:          defaultMockSyncManager(),
+		ExistsAddresser:  defaultMockExistsAddresser(),
+		TxIndexer:        defaultMockTxIndexer(),
+		AddrIndexer:      defaultMockAddrIndexer(),
+		SpendIndexer:     defaultMockSpendIndexer(),
+		DB:               defaultMockDB(),
+		ConnMgr:          defaultMockConnManager(),
+		CPUMiner:         defaultMockCPUMiner(),
+		TxMempooler:      defaultMockTxMempooler(),
+		MempoolPersister: defaultMockMempoolPersister(),
+		Clock:            &testClock{},
+		LogManager:       defaultMockLogManager(),
+		FiltererV2:       defaultMockFiltererV2(),
+		StandardVerifyFlags: func() (txscript.ScriptFlags, error) {
+			return mempool.BaseStandardVerifyFlags |
+				txscript.ScriptVerifySHA256 |
+				txscript.ScriptVerifyTreasury, nil
+		},
+		TimeSource:   blockchain.NewMedianTime(),
+		Services:     wire.SFNodeNetwork | wire.SFNodeCF,
+		SubsidyCache: standalone.NewSubsidyCache(chainParams),
 		NetInfo: []types.NetworksResult{{
 			Name:                      "IPV4",
 			Limited:                   false,
@@ -1825,6 +2208,7 @@ func defaultMockConfig(chainParams *chaincfg.Params) *Config {
 			Proxy:                     "",
 			ProxyRandomizeCredentials: false,
 		}},
+		BanDuration:        time.Hour * 24,
 		MinRelayTxFee:      dcrutil.Amount(10000),
 		MaxProtocolVersion: wire.CFilterV2Version,
 		UserAgentVersion: fmt.Sprintf("%d.%d.%d", version.Major, version.Minor,
@@ -1897,6 +2281,46 @@ func TestHandleAddNode(t *testing.T) {
 	}})
 }
 
+func TestHandleCombinePSDT(t *testing.T) {
+	t.Parallel()
+
+	psdts := makeTestPSDTs()
+	mismatched := psdts.unsigned.Copy()
+	mismatched.UnsignedTx.Expiry++
+	testRPCServerHandler(t, []rpcTest{{
+		name:    "handleCombinePSDT: ok",
+		handler: handleCombinePSDT,
+		cmd: &types.CombinePSDTCmd{
+			PSDTs: []string{mustBase64(psdts.signed1),
+				mustBase64(psdts.signed2)},
+		},
+		result: mustBase64(psdts.combined),
+	}, {
+		name:    "handleCombinePSDT: no psdts",
+		handler: handleCombinePSDT,
+		cmd:     &types.CombinePSDTCmd{},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInvalidParameter,
+	}, {
+		name:    "handleCombinePSDT: invalid psdt",
+		handler: handleCombinePSDT,
+		cmd: &types.CombinePSDTCmd{
+			PSDTs: []string{mustBase64(psdts.signed1), "cHNidP8="},
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCDeserialization,
+	}, {
+		name:    "handleCombinePSDT: different transactions",
+		handler: handleCombinePSDT,
+		cmd: &types.CombinePSDTCmd{
+			PSDTs: []string{mustBase64(psdts.signed1),
+				mustBase64(mismatched)},
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInvalidParameter,
+	}})
+}
+
 func TestHandleCreateRawSStx(t *testing.T) {
 	t.Parallel()
 
@@ -2192,6 +2616,28 @@ func TestHandleCreateRawSStx(t *testing.T) {
 	}})
 }
 
+func TestHandleClearBanned(t *testing.T) {
+	t.Parallel()
+
+	testRPCServerHandler(t, []rpcTest{{
+		name:    "handleClearBanned: ok",
+		handler: handleClearBanned,
+		cmd:     &types.ClearBannedCmd{},
+		result:  nil,
+	}, {
+		name:    "handleClearBanned: unable to save ban list",
+		handler: handleClearBanned,
+		cmd:     &types.ClearBannedCmd{},
+		mockConnManager: func() *testConnManager {
+			connManager := defaultMockConnManager()
+			connManager.clearBannedErr = errors.New("unable to save")
+			return connManager
+		}(),
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInternal.Code,
+	}})
+}
+
 func TestHandleCreateRawSSRtx(t *testing.T) {
 	t.Parallel()
 
@@ -2651,6 +3097,111 @@ func TestHandleDebugLevel(t *testing.T) {
 	}})
 }
 
+func TestHandleDecodePSDT(t *testing.T) {
+	t.Parallel()
+
+	psdts := makeTestPSDTs()
+	withDerivations := psdts.unsigned.Copy()
+	err := withDerivations.AddInSigHashType(0, txscript.SigHashAll)
+	if err != nil {
+		t.Fatalf("unexpected error adding sighash type: %v", err)
+	}
+	err = withDerivations.AddOutBip32Derivation(0, &psdt.Bip32Derivation{
+		PubKey:               psdts.signed1.Inputs[0].PartialSigs[0].PubKey,
+		MasterKeyFingerprint: 0x01020304,
+		Path:                 []uint32{0x80000000 + 44, 0x80000000 + 42, 1, 7},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error adding derivation: %v", err)
+	}
+	withDerivations.Unknowns = []*psdt.Unknown{{Key: []byte{0x70}, Value: []byte{1}}}
+	redeemScriptAsm, _ := txscript.DisasmString(psdts.redeemScript)
+	fee := 0.5
+	testRPCServerHandler(t, []rpcTest{{
+		name:    "handleDecodePSDT: ok",
+		handler: handleDecodePSDT,
+		cmd: &types.DecodePSDTCmd{
+			PSDT: mustBase64(withDerivations),
+		},
+		result: types.DecodePSDTResult{
+			Tx: types.TxRawDecodeResult{
+				Txid:     "f8e1d2fea09a3ff89c54ddbf4c0f333503afb470fc6bfaa981b8cf5a98165749",
+				Version:  1,
+				Locktime: 1,
+				Expiry:   1,
+				Vin: []types.Vin{{
+					Txid:        "e02f03a25a57afdd402818fe5b13985a0731502ad8a8c93d1874900e84d3330d",
+					Vout:        0,
+					Tree:        0,
+					Sequence:    4294967294,
+					AmountIn:    1,
+					BlockHeight: 0,
+					BlockIndex:  4294967295,
+					ScriptSig: &types.ScriptSig{
+						Asm: "",
+						Hex: "",
+					},
+				}},
+				Vout: []types.Vout{{
+					Value:   1,
+					N:       0,
+					Version: 0,
+					ScriptPubKey: types.ScriptPubKeyResult{
+						Asm:     "OP_HASH160 f59833f104faa3c7fd0c7dc1e3967fe77a9c1523 OP_EQUAL",
+						Hex:     "a914f59833f104faa3c7fd0c7dc1e3967fe77a9c152387",
+						ReqSigs: 1,
+						Type:    "scripthash",
+						Addresses: []string{
+							"DcurAwesomeAddressmqDctW5wJCW1Cn2MF",
+						},
+					},
+				}},
+			},
+			TxType:  "regular",
+			Unknown: map[string]string{"70": "01"},
+			Inputs: []types.DecodePSDTInput{{
+				PrevOut: &types.PSDTPrevOut{
+					Amount: 1.5,
+					ScriptPubKey: types.ScriptPubKeyResult{
+						Asm: "OP_HASH160 " +
+							hex.EncodeToString(stdaddr.Hash160(psdts.redeemScript)) +
+							" OP_EQUAL",
+						Hex: "a914" +
+							hex.EncodeToString(stdaddr.Hash160(psdts.redeemScript)) +
+							"87",
+						ReqSigs:   1,
+						Type:      "scripthash",
+						Addresses: []string{psdts.p2shAddr},
+					},
+				},
+				SigHashType: "ALL",
+				RedeemScript: &types.PSDTScript{
+					Asm:  redeemScriptAsm,
+					Hex:  hex.EncodeToString(psdts.redeemScript),
+					Type: "multisig",
+				},
+			}},
+			Outputs: []types.DecodePSDTOutput{{
+				Bip32Derivations: []types.PSDTBip32Derivation{{
+					PubKey: hex.EncodeToString(
+						psdts.signed1.Inputs[0].PartialSigs[0].PubKey),
+					MasterFingerprint: "01020304",
+					Path:              "m/44'/42'/1/7",
+				}},
+			}},
+			Fee: &fee,
+		},
+	}, {
+		name:    "handleDecodePSDT: invalid base64",
+		handler: handleDecodePSDT,
+		cmd: &types.DecodePSDTCmd{
+			PSDT: "!",
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCDeserialization,
+	}})
+}
+
 func TestHandleDecodeRawTransaction(t *testing.T) {
 	t.Parallel()
 
@@ -2861,45 +3412,192 @@ func TestHandleDecodeScript(t *testing.T) {
 	}})
 }
 
-func TestHandleEstimateFee(t *testing.T) {
-	t.Parallel()
+// testDescriptorKeys houses an extended key and the addresses of its children
+// for use in the descriptor related tests.
+type testDescriptorKeys struct {
+	dprv       string
+	dpub       string
+	childAddrs []string
+}
 
-	testRPCServerHandler(t, []rpcTest{{
-		name:    "handleEstimateFee: ok",
-		handler: handleEstimateFee,
-		cmd:     &types.EstimateFeeCmd{},
-		result:  float64(0.0001),
-	}})
+// makeTestDescriptorKeys returns an extended key along with the pay-to-pubkey-
+// hash addresses of its first few children.
+func makeTestDescriptorKeys(t *testing.T) *testDescriptorKeys {
+	t.Helper()
+
+	seed := bytes.Repeat([]byte{0x01}, hdkeychain.RecommendedSeedLen)
+	master, err := hdkeychain.NewMaster(seed, defaultChainParams)
+	if err != nil {
+		t.Fatalf("unexpected error creating master key: %v", err)
+	}
+	keys := &testDescriptorKeys{
+		dprv: master.String(),
+		dpub: master.Neuter().String(),
+	}
+	for i := uint32(0); i < 3; i++ {
+		child, err := master.ChildBIP32Std(i)
+		if err != nil {
+			t.Fatalf("unexpected error deriving child %d: %v", i, err)
+		}
+		addr, err := stdaddr.NewAddressPubKeyEcdsaSecp256k1V0Raw(
+			child.SerializedPubKey(), defaultChainParams)
+		if err != nil {
+			t.Fatalf("unexpected error creating address: %v", err)
+		}
+		keys.childAddrs = append(keys.childAddrs,
+			addr.AddressPubKeyHash().String())
+	}
+	return keys
 }
 
-func TestHandleEstimateSmartFee(t *testing.T) {
+// mustDescriptorChecksum returns the passed descriptor followed by its checksum
+// and will panic if there is an error.
+func mustDescriptorChecksum(desc string) string {
+	checksum, err := descriptor.Checksum(desc)
+	if err != nil {
+		panic(err)
+	}
+	return desc + "#" + checksum
+}
+
+func TestHandleDeriveAddresses(t *testing.T) {
 	t.Parallel()
 
-	conservative := types.EstimateSmartFeeConservative
-	economical := types.EstimateSmartFeeEconomical
-	validFeeEstimator := defaultMockFeeEstimator()
-	validFeeEstimator.estimateFeeAmt = 123456789
-	result := &types.EstimateSmartFeeResult{
-		FeeRate: float64(1.23456789),
-	}
+	keys := makeTestDescriptorKeys(t)
+	ranged := mustDescriptorChecksum("pkh(" + keys.dpub + "/*)")
 	testRPCServerHandler(t, []rpcTest{{
-		name:    "handleEstimateSmartFee: ok with mode",
-		handler: handleEstimateSmartFee,
-		cmd: &types.EstimateSmartFeeCmd{
-			Confirmations: 0,
-			Mode:          &conservative,
+		name:    "handleDeriveAddresses: ok not ranged",
+		handler: handleDeriveAddresses,
+		cmd: &types.DeriveAddressesCmd{
+			Descriptor: mustDescriptorChecksum("pkh(" + keys.dpub + "/1)"),
 		},
-		mockFeeEstimator: validFeeEstimator,
-		result:           result,
+		result: []string{keys.childAddrs[1]},
 	}, {
-		name:             "handleEstimateSmartFee: ok no mode",
-		handler:          handleEstimateSmartFee,
-		cmd:              &types.EstimateSmartFeeCmd{},
-		mockFeeEstimator: validFeeEstimator,
-		result:           result,
+		name:    "handleDeriveAddresses: ok range end",
+		handler: handleDeriveAddresses,
+		cmd: &types.DeriveAddressesCmd{
+			Descriptor: ranged,
+			Range:      &[]uint32{2},
+		},
+		result: keys.childAddrs,
 	}, {
-		name:    "handleEstimateSmartFee: not conservative mode",
-		handler: handleEstimateSmartFee,
+		name:    "handleDeriveAddresses: ok range beginning and end",
+		handler: handleDeriveAddresses,
+		cmd: &types.DeriveAddressesCmd{
+			Descriptor: ranged,
+			Range:      &[]uint32{1, 2},
+		},
+		result: keys.childAddrs[1:],
+	}, {
+		name:    "handleDeriveAddresses: missing checksum",
+		handler: handleDeriveAddresses,
+		cmd: &types.DeriveAddressesCmd{
+			Descriptor: "pkh(" + keys.dpub + ")",
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInvalidParameter,
+	}, {
+		name:    "handleDeriveAddresses: invalid checksum",
+		handler: handleDeriveAddresses,
+		cmd: &types.DeriveAddressesCmd{
+			Descriptor: "pkh(" + keys.dpub + ")#qqqqqqqq",
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInvalidParameter,
+	}, {
+		name:    "handleDeriveAddresses: ranged without range",
+		handler: handleDeriveAddresses,
+		cmd: &types.DeriveAddressesCmd{
+			Descriptor: ranged,
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInvalidParameter,
+	}, {
+		name:    "handleDeriveAddresses: range for descriptor not ranged",
+		handler: handleDeriveAddresses,
+		cmd: &types.DeriveAddressesCmd{
+			Descriptor: mustDescriptorChecksum("pkh(" + keys.dpub + ")"),
+			Range:      &[]uint32{1},
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInvalidParameter,
+	}, {
+		name:    "handleDeriveAddresses: range beginning after end",
+		handler: handleDeriveAddresses,
+		cmd: &types.DeriveAddressesCmd{
+			Descriptor: ranged,
+			Range:      &[]uint32{2, 1},
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInvalidParameter,
+	}, {
+		name:    "handleDeriveAddresses: range too large",
+		handler: handleDeriveAddresses,
+		cmd: &types.DeriveAddressesCmd{
+			Descriptor: ranged,
+			Range:      &[]uint32{0, maxDeriveAddresses},
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInvalidParameter,
+	}, {
+		name:    "handleDeriveAddresses: range end hardened",
+		handler: handleDeriveAddresses,
+		cmd: &types.DeriveAddressesCmd{
+			Descriptor: ranged,
+			Range:      &[]uint32{hdkeychain.HardenedKeyStart},
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInvalidParameter,
+	}, {
+		name:    "handleDeriveAddresses: no address",
+		handler: handleDeriveAddresses,
+		cmd: &types.DeriveAddressesCmd{
+			Descriptor: mustDescriptorChecksum("raw(deadbeef)"),
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInvalidParameter,
+	}})
+}
+
+func TestHandleEstimateFee(t *testing.T) {
+	t.Parallel()
+
+	testRPCServerHandler(t, []rpcTest{{
+		name:    "handleEstimateFee: ok",
+		handler: handleEstimateFee,
+		cmd:     &types.EstimateFeeCmd{},
+		result:  float64(0.0001),
+	}})
+}
+
+func TestHandleEstimateSmartFee(t *testing.T) {
+	t.Parallel()
+
+	conservative := types.EstimateSmartFeeConservative
+	economical := types.EstimateSmartFeeEconomical
+	validFeeEstimator := defaultMockFeeEstimator()
+	validFeeEstimator.estimateFeeAmt = 123456789
+	result := &types.EstimateSmartFeeResult{
+		FeeRate: float64(1.23456789),
+	}
+	testRPCServerHandler(t, []rpcTest{{
+		name:    "handleEstimateSmartFee: ok with mode",
+		handler: handleEstimateSmartFee,
+		cmd: &types.EstimateSmartFeeCmd{
+			Confirmations: 0,
+			Mode:          &conservative,
+		},
+		mockFeeEstimator: validFeeEstimator,
+		result:           result,
+	}, {
+		name:             "handleEstimateSmartFee: ok no mode",
+		handler:          handleEstimateSmartFee,
+		cmd:              &types.EstimateSmartFeeCmd{},
+		mockFeeEstimator: validFeeEstimator,
+		result:           result,
+	}, {
+		name:    "handleEstimateSmartFee: not conservative mode",
+		handler: handleEstimateSmartFee,
 		cmd: &types.EstimateSmartFeeCmd{
 			Mode: &economical,
 		},
@@ -3412,6 +4110,67 @@ func TestHandleExistsMempoolTxs(t *testing.T) {
 	}})
 }
 
+func TestHandleFinalizePSDT(t *testing.T) {
+	t.Parallel()
+
+	psdts := makeTestPSDTs()
+	finalized := psdts.combined.Copy()
+	if err := finalized.FinalizeAll(); err != nil {
+		t.Fatalf("unexpected error finalizing: %v", err)
+	}
+	signedTx, err := finalized.Extract()
+	if err != nil {
+		t.Fatalf("unexpected error extracting: %v", err)
+	}
+	signedTxBytes, err := signedTx.Bytes()
+	if err != nil {
+		t.Fatalf("unexpected error serializing: %v", err)
+	}
+	testRPCServerHandler(t, []rpcTest{{
+		name:    "handleFinalizePSDT: ok",
+		handler: handleFinalizePSDT,
+		cmd: &types.FinalizePSDTCmd{
+			PSDT:    mustBase64(psdts.combined),
+			Extract: dcrjson.Bool(true),
+		},
+		result: types.FinalizePSDTResult{
+			Hex:      hex.EncodeToString(signedTxBytes),
+			Complete: true,
+		},
+	}, {
+		name:    "handleFinalizePSDT: ok without extract",
+		handler: handleFinalizePSDT,
+		cmd: &types.FinalizePSDTCmd{
+			PSDT:    mustBase64(psdts.combined),
+			Extract: dcrjson.Bool(false),
+		},
+		result: types.FinalizePSDTResult{
+			PSDT:     mustBase64(finalized),
+			Complete: true,
+		},
+	}, {
+		name:    "handleFinalizePSDT: not enough signatures",
+		handler: handleFinalizePSDT,
+		cmd: &types.FinalizePSDTCmd{
+			PSDT:    mustBase64(psdts.signed1),
+			Extract: dcrjson.Bool(true),
+		},
+		result: types.FinalizePSDTResult{
+			PSDT:     mustBase64(psdts.signed1),
+			Complete: false,
+		},
+	}, {
+		name:    "handleFinalizePSDT: invalid psdt",
+		handler: handleFinalizePSDT,
+		cmd: &types.FinalizePSDTCmd{
+			PSDT:    "cHNidP8=",
+			Extract: dcrjson.Bool(true),
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCDeserialization,
+	}})
+}
+
 func TestHandleGenerate(t *testing.T) {
 	t.Parallel()
 
@@ -3561,6 +4320,169 @@ func TestHandleGetAddedNodeInfo(t *testing.T) {
 	}})
 }
 
+func TestHandleGetAddressTxIDs(t *testing.T) {
+	t.Parallel()
+
+	// Create a mock address index with a confirmed and an unconfirmed
+	// transaction along with a database that contains the confirmed one.
+	var confirmedTx, unconfirmedTx wire.MsgTx
+	if err := confirmedTx.FromBytes(hexToBytes(hexFromFile("tx432098-11.hex"))); err != nil {
+		t.Fatalf("unable to create tx from bytes: %v", err)
+	}
+	if err := unconfirmedTx.FromBytes(hexToBytes(hexFromFile("tx432100-1.hex"))); err != nil {
+		t.Fatalf("unable to create tx from bytes: %v", err)
+	}
+	confirmedHash := confirmedTx.TxHash().String()
+	unconfirmedHash := unconfirmedTx.TxHash().String()
+	addrIndex := func() *testAddrIndexer {
+		idx := defaultMockAddrIndexer()
+		idx.entries = []indexers.TxIndexEntry{{
+			BlockRegion: database.BlockRegion{
+				Hash: mustParseHash("00000000000000001fc4c4c7a3f2ec6d552dda16a3a928f27bd6" +
+					"bd16d8f1e9b3"),
+				Offset: 52508,
+				Len:    453,
+			},
+			BlockIndex: 11,
+		}}
+		idx.unconfirmed = []*dcrutil.Tx{dcrutil.NewTx(&unconfirmedTx)}
+		return idx
+	}()
+	db := func() *testDB {
+		db := defaultMockDB()
+		db.viewTx = &testDatabaseTx{
+			fetchBlockRegion: func(region *database.BlockRegion) ([]byte, error) {
+				return hexToBytes(hexFromFile("tx432098-11.hex")), nil
+			},
+		}
+		return db
+	}()
+	const addr = "DsbjabD32RuS1deAj2uTjKfFZ6nSza5qVf3"
+
+	testRPCServerHandler(t, []rpcTest{{
+		name:    "handleGetAddressTxIDs: ok",
+		handler: handleGetAddressTxIDs,
+		cmd: &types.GetAddressTxIDsCmd{
+			Address: addr,
+		},
+		mockAddrIndexer: addrIndex,
+		mockDB:          db,
+		result:          []string{confirmedHash, unconfirmedHash},
+	}, {
+		name:    "handleGetAddressTxIDs: ok reverse",
+		handler: handleGetAddressTxIDs,
+		cmd: &types.GetAddressTxIDsCmd{
+			Address: addr,
+			Reverse: dcrjson.Bool(true),
+		},
+		mockAddrIndexer: addrIndex,
+		mockDB:          db,
+		result:          []string{unconfirmedHash, confirmedHash},
+	}, {
+		name:    "handleGetAddressTxIDs: ok skip confirmed",
+		handler: handleGetAddressTxIDs,
+		cmd: &types.GetAddressTxIDsCmd{
+			Address: addr,
+			Skip:    dcrjson.Int(1),
+		},
+		mockAddrIndexer: addrIndex,
+		mockDB:          db,
+		result:          []string{unconfirmedHash},
+	}, {
+		name:    "handleGetAddressTxIDs: ok reverse skip unconfirmed",
+		handler: handleGetAddressTxIDs,
+		cmd: &types.GetAddressTxIDsCmd{
+			Address: addr,
+			Skip:    dcrjson.Int(1),
+			Reverse: dcrjson.Bool(true),
+		},
+		mockAddrIndexer: addrIndex,
+		mockDB:          db,
+		result:          []string{confirmedHash},
+	}, {
+		name:    "handleGetAddressTxIDs: ok count limits results",
+		handler: handleGetAddressTxIDs,
+		cmd: &types.GetAddressTxIDsCmd{
+			Address: addr,
+			Count:   dcrjson.Int(1),
+			Reverse: dcrjson.Bool(true),
+		},
+		mockAddrIndexer: addrIndex,
+		mockDB:          db,
+		result:          []string{unconfirmedHash},
+	}, {
+		name:    "handleGetAddressTxIDs: address index not enabled",
+		handler: handleGetAddressTxIDs,
+		cmd: &types.GetAddressTxIDsCmd{
+			Address: addr,
+		},
+		setAddrIndexerNil: true,
+		wantErr:           true,
+		errCode:           dcrjson.ErrRPCInternal.Code,
+	}, {
+		name:    "handleGetAddressTxIDs: invalid address",
+		handler: handleGetAddressTxIDs,
+		cmd: &types.GetAddressTxIDsCmd{
+			Address: "invalid",
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInvalidAddressOrKey,
+	}, {
+		name:    "handleGetAddressTxIDs: negative skip",
+		handler: handleGetAddressTxIDs,
+		cmd: &types.GetAddressTxIDsCmd{
+			Address: addr,
+			Skip:    dcrjson.Int(-1),
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInvalidParameter,
+	}, {
+		name:    "handleGetAddressTxIDs: count too large",
+		handler: handleGetAddressTxIDs,
+		cmd: &types.GetAddressTxIDsCmd{
+			Address: addr,
+			Count:   dcrjson.Int(maxAddrTxnsPerRequest + 1),
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInvalidParameter,
+	}, {
+		name:    "handleGetAddressTxIDs: index not synced",
+		handler: handleGetAddressTxIDs,
+		cmd: &types.GetAddressTxIDsCmd{
+			Address: addr,
+		},
+		mockAddrIndexer: func() *testAddrIndexer {
+			idx := defaultMockAddrIndexer()
+			idx.tipHeight -= 6
+			return idx
+		}(),
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInternal.Code,
+	}, {
+		name:    "handleGetAddressTxIDs: unable to fetch entries",
+		handler: handleGetAddressTxIDs,
+		cmd: &types.GetAddressTxIDsCmd{
+			Address: addr,
+		},
+		mockAddrIndexer: func() *testAddrIndexer {
+			idx := defaultMockAddrIndexer()
+			idx.entriesErr = errors.New("unable to fetch entries")
+			return idx
+		}(),
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInternal.Code,
+	}, {
+		name:    "handleGetAddressTxIDs: unable to fetch block region",
+		handler: handleGetAddressTxIDs,
+		cmd: &types.GetAddressTxIDsCmd{
+			Address: addr,
+		},
+		mockAddrIndexer: addrIndex,
+		wantErr:         true,
+		errCode:         dcrjson.ErrRPCInternal.Code,
+	}})
+}
+
 func TestHandleGetBestBlock(t *testing.T) {
 	t.Parallel()
 
@@ -3572,6 +4494,21 @@ func TestHandleGetBestBlock(t *testing.T) {
 			Hash:   block432100.BlockHash().String(),
 			Height: int64(block432100.Header.Height),
 		},
+	}, {
+		name:    "handleGetBestBlock: light mode uses best header",
+		handler: handleGetBestBlock,
+		mockChain: func() *testRPCChain {
+			chain := defaultMockRPCChain()
+			chain.bestHeaderHash = chainhash.Hash{0x01}
+			chain.bestHeaderHeight = 432101
+			return chain
+		}(),
+		lightMode: true,
+		cmd:       &types.GetBestBlockCmd{},
+		result: &types.GetBestBlockResult{
+			Hash:   chainhash.Hash{0x01}.String(),
+			Height: 432101,
+		},
 	}})
 }
 
@@ -3634,6 +4571,51 @@ func TestHandleGetBlockchainInfo(t *testing.T) {
 				},
 			},
 		},
+	}, {
+		name:    "handleGetBlockchainInfo: ok with pruned blockchain",
+		handler: handleGetBlockchainInfo,
+		cmd:     &types.GetBlockChainInfoCmd{},
+		mockChain: func() *testRPCChain {
+			chain := defaultMockRPCChain()
+			chain.bestSnapshot = &blockchain.BestState{
+				Height:   463073,
+				Bits:     404696953,
+				Hash:     *hash,
+				PrevHash: *prevHash,
+			}
+			chain.bestHeaderHash = *hash
+			chain.bestHeaderHeight = 463073
+			chain.chainWork = hexToUint256("115d2833849090b0026506")
+			chain.isCurrent = false
+			chain.isPruned = true
+			chain.pruneHeight = 400000
+			chain.maxBlockSize = 393216
+			chain.stateLastChangedHeight = int64(149248)
+			return chain
+		}(),
+		result: types.GetBlockChainInfoResult{
+			Chain:                "mainnet",
+			Blocks:               int64(463073),
+			Headers:              int64(463073),
+			SyncHeight:           int64(463074),
+			ChainWork:            "000000000000000000000000000000000000000000115d2833849090b0026506",
+			InitialBlockDownload: true,
+			VerificationProgress: float64(1),
+			BestBlockHash:        "00000000000000001e6ec1501c858506de1de4703d1be8bab4061126e8f61480",
+			Difficulty:           uint32(404696953),
+			DifficultyRatio:      float64(35256672611.3862),
+			MaxBlockSize:         int64(393216),
+			Pruned:               true,
+			PruneHeight:          int64(400000),
+			Deployments: map[string]types.AgendaInfo{
+				"headercommitments": {
+					Status:     "started",
+					Since:      int64(149248),
+					StartTime:  uint64(1567641600),
+					ExpireTime: uint64(1599264000),
+				},
+			},
+		},
 	}, {
 		name:    "handleGetBlockchainInfo: ok with empty blockchain",
 		handler: handleGetBlockchainInfo,
@@ -3937,6 +4919,21 @@ func TestHandleGetBlock(t *testing.T) {
 		}(),
 		wantErr: true,
 		errCode: dcrjson.ErrRPCBlockNotFound,
+	}, {
+		name:    "handleGetBlock: block data pruned",
+		handler: handleGetBlock,
+		cmd: &types.GetBlockCmd{
+			Hash:      blkHashString,
+			Verbose:   dcrjson.Bool(false),
+			VerboseTx: dcrjson.Bool(false),
+		},
+		mockChain: func() *testRPCChain {
+			chain := defaultMockRPCChain()
+			chain.blockByHashErr = blockchain.ErrBlockPruned
+			return chain
+		}(),
+		wantErr: true,
+		errCode: dcrjson.ErrRPCMisc,
 	}, {
 		name:    "handleGetBlock: could not fetch chain work",
 		handler: handleGetBlock,
@@ -4166,6 +5163,166 @@ func TestHandleGetBlockHeader(t *testing.T) {
 	}})
 }
 
+func TestHandleGetBlockStats(t *testing.T) {
+	t.Parallel()
+
+	// Create mock spent outputs for the inputs of the block that spend
+	// previous outputs in the order they are recorded in the spend journal.
+	p2pkhScript := hexToBytes("76a914f127302adf84741d28fa705a995dc827030077e588ac")
+	var spent []blockchain.SpentTxOut
+	addSpent := func(txIn *wire.TxIn) {
+		spent = append(spent, blockchain.SpentTxOut{
+			Amount:   txIn.ValueIn,
+			PkScript: p2pkhScript,
+		})
+	}
+	for _, stx := range block432100.STransactions {
+		isVote := stake.IsSSGen(stx)
+		if stake.IsTreasuryBase(stx) || stake.IsTSpend(stx) {
+			continue
+		}
+		for txInIdx, txIn := range stx.TxIn {
+			if txInIdx == 0 && isVote {
+				continue
+			}
+			addSpent(txIn)
+		}
+	}
+	for _, tx := range block432100.Transactions[1:] {
+		for _, txIn := range tx.TxIn {
+			addSpent(txIn)
+		}
+	}
+	chainWithSpent := func() *testRPCChain {
+		chain := defaultMockRPCChain()
+		chain.fetchSpentTxOuts = spent
+		return chain
+	}()
+
+	blkHash := block432100.BlockHash()
+	result := types.GetBlockStatsResult{
+		Hash:               blkHash.String(),
+		Height:             int64(block432100.Header.Height),
+		Time:               block432100.Header.Timestamp.Unix(),
+		Txs:                7,
+		Ins:                11,
+		Outs:               25,
+		TotalSize:          2424,
+		TotalOut:           91110717604,
+		TotalFee:           7534,
+		AvgFee:             1255,
+		MinFee:             1,
+		MaxFee:             4550,
+		MedianFee:          1,
+		AvgFeeRate:         3108,
+		MinFeeRate:         2,
+		MaxFeeRate:         10088,
+		FeeRatePercentiles: []int64{2, 2, 2, 10033, 10088},
+		AvgTxSize:          404,
+		MinTxSize:          297,
+		MaxTxSize:          451,
+		MedianTxSize:       419,
+		UtxoIncrease:       7,
+		UtxoSizeInc:        259,
+		Votes:              4,
+		Tickets:            1,
+		PoWSubsidy:         746176492,
+		PoSSubsidy:         373088244,
+		TreasurySubsidy:    155453436,
+	}
+
+	testRPCServerHandler(t, []rpcTest{{
+		name:    "handleGetBlockStats: ok by height",
+		handler: handleGetBlockStats,
+		cmd: &types.GetBlockStatsCmd{
+			HashOrHeight: "432100",
+		},
+		mockChain: chainWithSpent,
+		result:    result,
+	}, {
+		name:    "handleGetBlockStats: ok by hash",
+		handler: handleGetBlockStats,
+		cmd: &types.GetBlockStatsCmd{
+			HashOrHeight: types.HashOrHeight(blkHash.String()),
+		},
+		mockChain: chainWithSpent,
+		result:    result,
+	}, {
+		name:    "handleGetBlockStats: height out of range",
+		handler: handleGetBlockStats,
+		cmd: &types.GetBlockStatsCmd{
+			HashOrHeight: "999999999",
+		},
+		mockChain: func() *testRPCChain {
+			chain := defaultMockRPCChain()
+			chain.blockHashByHeightErr = errors.New("no block at height")
+			return chain
+		}(),
+		wantErr: true,
+		errCode: dcrjson.ErrRPCOutOfRange,
+	}, {
+		name:    "handleGetBlockStats: invalid hash",
+		handler: handleGetBlockStats,
+		cmd: &types.GetBlockStatsCmd{
+			HashOrHeight: "invalid",
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCDecodeHexString,
+	}, {
+		name:    "handleGetBlockStats: block not in main chain",
+		handler: handleGetBlockStats,
+		cmd: &types.GetBlockStatsCmd{
+			HashOrHeight: types.HashOrHeight(blkHash.String()),
+		},
+		mockChain: func() *testRPCChain {
+			chain := defaultMockRPCChain()
+			chain.mainChainHasBlock = false
+			return chain
+		}(),
+		wantErr: true,
+		errCode: dcrjson.ErrRPCBlockNotFound,
+	}, {
+		name:    "handleGetBlockStats: block data pruned",
+		handler: handleGetBlockStats,
+		cmd: &types.GetBlockStatsCmd{
+			HashOrHeight: "432100",
+		},
+		mockChain: func() *testRPCChain {
+			chain := defaultMockRPCChain()
+			chain.blockByHashErr = blockchain.ErrBlockPruned
+			return chain
+		}(),
+		wantErr: true,
+		errCode: dcrjson.ErrRPCMisc,
+	}, {
+		name:    "handleGetBlockStats: unable to fetch spent outputs",
+		handler: handleGetBlockStats,
+		cmd: &types.GetBlockStatsCmd{
+			HashOrHeight: "432100",
+		},
+		mockChain: func() *testRPCChain {
+			chain := defaultMockRPCChain()
+			chain.fetchSpentTxOutsErr = errors.New("unable to fetch spent outputs")
+			return chain
+		}(),
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInternal.Code,
+	}, {
+		name:    "handleGetBlockStats: missing spent outputs",
+		handler: handleGetBlockStats,
+		cmd: &types.GetBlockStatsCmd{
+			HashOrHeight: "432100",
+		},
+		mockChain: func() *testRPCChain {
+			chain := defaultMockRPCChain()
+			chain.fetchSpentTxOuts = spent[:len(spent)-1]
+			return chain
+		}(),
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInternal.Code,
+	}})
+}
+
 func TestHandleGetBlockSubsidy(t *testing.T) {
 	t.Parallel()
 
@@ -4352,6 +5509,53 @@ func TestHandleGetCurrentNet(t *testing.T) {
 	}})
 }
 
+func TestHandleGetDescriptorInfo(t *testing.T) {
+	t.Parallel()
+
+	keys := makeTestDescriptorKeys(t)
+	pubDesc := "pkh(" + keys.dpub + "/1)"
+	pubChecksum, err := descriptor.Checksum(pubDesc)
+	if err != nil {
+		t.Fatalf("unexpected error calculating checksum: %v", err)
+	}
+	privDesc := "pkh(" + keys.dprv + "/0h/*)"
+	privChecksum, err := descriptor.Checksum(privDesc)
+	if err != nil {
+		t.Fatalf("unexpected error calculating checksum: %v", err)
+	}
+	testRPCServerHandler(t, []rpcTest{{
+		name:    "handleGetDescriptorInfo: ok",
+		handler: handleGetDescriptorInfo,
+		cmd: &types.GetDescriptorInfoCmd{
+			Descriptor: pubDesc,
+		},
+		result: &types.GetDescriptorInfoResult{
+			Descriptor: pubDesc + "#" + pubChecksum,
+			Checksum:   pubChecksum,
+		},
+	}, {
+		name:    "handleGetDescriptorInfo: ok private ranged",
+		handler: handleGetDescriptorInfo,
+		cmd: &types.GetDescriptorInfoCmd{
+			Descriptor: privDesc + "#" + privChecksum,
+		},
+		result: &types.GetDescriptorInfoResult{
+			Descriptor:     mustDescriptorChecksum("pkh(" + keys.dpub + "/0'/*)"),
+			Checksum:       privChecksum,
+			IsRange:        true,
+			HasPrivateKeys: true,
+		},
+	}, {
+		name:    "handleGetDescriptorInfo: invalid descriptor",
+		handler: handleGetDescriptorInfo,
+		cmd: &types.GetDescriptorInfoCmd{
+			Descriptor: "pkh(" + keys.dpub + "/0h)",
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInvalidParameter,
+	}})
+}
+
 func TestHandleGetDifficulty(t *testing.T) {
 	t.Parallel()
 
@@ -4494,8 +5698,28 @@ func TestHandleGetMempoolInfo(t *testing.T) {
 		}(),
 		cmd: &types.GetMempoolInfoCmd{},
 		result: &types.GetMempoolInfoResult{
-			Size:  2,
-			Bytes: 627,
+			Size:          2,
+			Bytes:         627,
+			MempoolMinFee: 0.0001,
+		},
+	}, {
+		name:    "handleGetMempoolInfo: ok with evictions",
+		handler: handleGetMempoolInfo,
+		mockTxMempooler: func() *testTxMempooler {
+			mp := defaultMockTxMempooler()
+			mp.txDescs = []*mempool.TxDesc{txDescOne, txDescTwo}
+			mp.minRelayFee = 25000
+			mp.maxSize = 300 * 1024 * 1024
+			mp.numEvicted = 12
+			return mp
+		}(),
+		cmd: &types.GetMempoolInfoCmd{},
+		result: &types.GetMempoolInfoResult{
+			Size:          2,
+			Bytes:         627,
+			MaxMempool:    300 * 1024 * 1024,
+			MempoolMinFee: 0.00025,
+			Evicted:       12,
 		},
 	}})
 }
@@ -4626,6 +5850,67 @@ func TestHandleGetNetworkHashPS(t *testing.T) {
 	}})
 }
 
+func TestHandleGetNodeAddresses(t *testing.T) {
+	t.Parallel()
+
+	addr1 := addrmgr.NewNetAddressIPPort(net.ParseIP("1.2.3.4"), 9108,
+		wire.SFNodeNetwork)
+	addr1.Timestamp = time.Unix(1592918788, 0)
+	addr2 := addrmgr.NewNetAddressIPPort(net.ParseIP("2001:470::1"), 19108,
+		wire.SFNodeNetwork|wire.SFNodeCF)
+	addr2.Timestamp = time.Unix(1592918790, 0)
+	addrManager := func() *testAddrManager {
+		addrManager := defaultMockAddrManager()
+		addrManager.addressCache = []*addrmgr.NetAddress{addr1, addr2}
+		addrManager.asns = map[string]uint32{addr1.Key(): 13335}
+		return addrManager
+	}()
+	result1 := types.GetNodeAddressesResult{
+		Time:     1592918788,
+		Services: "00000001",
+		Address:  "1.2.3.4",
+		Port:     9108,
+		Network:  "ipv4",
+		Group:    "as:13335",
+		ASN:      13335,
+	}
+	result2 := types.GetNodeAddressesResult{
+		Time:     1592918790,
+		Services: "00000005",
+		Address:  "2001:470::1",
+		Port:     19108,
+		Network:  "ipv6",
+		Group:    "2001:470::",
+	}
+
+	testRPCServerHandler(t, []rpcTest{{
+		name:            "handleGetNodeAddresses: default count",
+		handler:         handleGetNodeAddresses,
+		cmd:             &types.GetNodeAddressesCmd{Count: dcrjson.Int(1)},
+		mockAddrManager: addrManager,
+		result:          []types.GetNodeAddressesResult{result1},
+	}, {
+		name:            "handleGetNodeAddresses: all addresses",
+		handler:         handleGetNodeAddresses,
+		cmd:             &types.GetNodeAddressesCmd{Count: dcrjson.Int(0)},
+		mockAddrManager: addrManager,
+		result:          []types.GetNodeAddressesResult{result1, result2},
+	}, {
+		name:            "handleGetNodeAddresses: count exceeds available",
+		handler:         handleGetNodeAddresses,
+		cmd:             &types.GetNodeAddressesCmd{Count: dcrjson.Int(10)},
+		mockAddrManager: addrManager,
+		result:          []types.GetNodeAddressesResult{result1, result2},
+	}, {
+		name:            "handleGetNodeAddresses: negative count",
+		handler:         handleGetNodeAddresses,
+		cmd:             &types.GetNodeAddressesCmd{Count: dcrjson.Int(-1)},
+		mockAddrManager: addrManager,
+		wantErr:         true,
+		errCode:         dcrjson.ErrRPCInvalidParameter,
+	}})
+}
+
 func TestHandleGetNetworkInfo(t *testing.T) {
 	t.Parallel()
 
@@ -4690,6 +5975,7 @@ func TestHandleGetPeerInfo(t *testing.T) {
 					banScore:          uint32(0),
 					id:                int32(5),
 					addr:              "106.14.238.184:19108",
+					connType:          "block-relay-only",
 					lastPingNonce:     uint64(10),
 					statsSnapshot: &peer.StatsSnap{
 						ID:             int32(5),
@@ -4734,6 +6020,7 @@ func TestHandleGetPeerInfo(t *testing.T) {
 			Version:        uint32(6),
 			SubVer:         "/dcrwire:0.3.0/dcrd:1.5.0(pre)/",
 			Inbound:        false,
+			ConnType:       "block-relay-only",
 			StartingHeight: int64(323327),
 			CurrentHeight:  int64(323327),
 			BanScore:       int32(0),
@@ -5237,6 +6524,136 @@ func TestHandleGetTxOutSetInfo(t *testing.T) {
 	}})
 }
 
+func TestHandleGetTxSpendingPrevOut(t *testing.T) {
+	t.Parallel()
+
+	// Create a transaction that spends an output along with the results that
+	// are expected when it is found in the mempool or the spend index.
+	var spendTx wire.MsgTx
+	if err := spendTx.FromBytes(hexToBytes(hexFromFile("tx432100-1.hex"))); err != nil {
+		t.Fatalf("unable to create tx from bytes: %v", err)
+	}
+	const spendVin = 0
+	prevOut := spendTx.TxIn[spendVin].PreviousOutPoint
+	output := types.TxSpendingPrevOut{
+		Txid: prevOut.Hash.String(),
+		Vout: prevOut.Index,
+		Tree: prevOut.Tree,
+	}
+	unspentResult := types.GetTxSpendingPrevOutResult{
+		Txid: output.Txid,
+		Vout: output.Vout,
+		Tree: output.Tree,
+	}
+	vin := uint32(spendVin)
+	mempoolResult := unspentResult
+	mempoolResult.SpendingTxid = spendTx.TxHash().String()
+	mempoolResult.SpendingVin = &vin
+	blkHash := block432100.BlockHash()
+	chainResult := mempoolResult
+	chainResult.BlockHash = blkHash.String()
+	chainResult.BlockHeight = int64(block432100.Header.Height)
+	spendIndex := func() *testSpendIndexer {
+		idx := defaultMockSpendIndexer()
+		idx.entry = &indexers.SpendIndexEntry{
+			BlockHash:  blkHash,
+			TxHash:     spendTx.TxHash(),
+			InputIndex: spendVin,
+		}
+		return idx
+	}()
+
+	testRPCServerHandler(t, []rpcTest{{
+		name:    "handleGetTxSpendingPrevOut: ok unspent",
+		handler: handleGetTxSpendingPrevOut,
+		cmd: &types.GetTxSpendingPrevOutCmd{
+			Outputs: []types.TxSpendingPrevOut{output},
+		},
+		result: []types.GetTxSpendingPrevOutResult{unspentResult},
+	}, {
+		name:    "handleGetTxSpendingPrevOut: ok spent in mempool",
+		handler: handleGetTxSpendingPrevOut,
+		cmd: &types.GetTxSpendingPrevOutCmd{
+			Outputs: []types.TxSpendingPrevOut{output},
+		},
+		mockTxMempooler: func() *testTxMempooler {
+			mp := defaultMockTxMempooler()
+			mp.fetchSpendingTx = dcrutil.NewTx(&spendTx)
+			return mp
+		}(),
+		setSpendIndexerNil: true,
+		result:             []types.GetTxSpendingPrevOutResult{mempoolResult},
+	}, {
+		name:    "handleGetTxSpendingPrevOut: ok spent in chain",
+		handler: handleGetTxSpendingPrevOut,
+		cmd: &types.GetTxSpendingPrevOutCmd{
+			Outputs: []types.TxSpendingPrevOut{output},
+		},
+		mockSpendIndexer: spendIndex,
+		result:           []types.GetTxSpendingPrevOutResult{chainResult},
+	}, {
+		name:    "handleGetTxSpendingPrevOut: ok spend index not enabled",
+		handler: handleGetTxSpendingPrevOut,
+		cmd: &types.GetTxSpendingPrevOutCmd{
+			Outputs: []types.TxSpendingPrevOut{output},
+		},
+		setSpendIndexerNil: true,
+		result:             []types.GetTxSpendingPrevOutResult{unspentResult},
+	}, {
+		name:    "handleGetTxSpendingPrevOut: no outputs",
+		handler: handleGetTxSpendingPrevOut,
+		cmd:     &types.GetTxSpendingPrevOutCmd{},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInvalidParameter,
+	}, {
+		name:    "handleGetTxSpendingPrevOut: invalid txid",
+		handler: handleGetTxSpendingPrevOut,
+		cmd: &types.GetTxSpendingPrevOutCmd{
+			Outputs: []types.TxSpendingPrevOut{{Txid: "invalid"}},
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCDecodeHexString,
+	}, {
+		name:    "handleGetTxSpendingPrevOut: invalid tree",
+		handler: handleGetTxSpendingPrevOut,
+		cmd: &types.GetTxSpendingPrevOutCmd{
+			Outputs: []types.TxSpendingPrevOut{{
+				Txid: output.Txid,
+				Vout: output.Vout,
+				Tree: 2,
+			}},
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInvalidParameter,
+	}, {
+		name:    "handleGetTxSpendingPrevOut: index not synced",
+		handler: handleGetTxSpendingPrevOut,
+		cmd: &types.GetTxSpendingPrevOutCmd{
+			Outputs: []types.TxSpendingPrevOut{output},
+		},
+		mockSpendIndexer: func() *testSpendIndexer {
+			idx := defaultMockSpendIndexer()
+			idx.tipHeight -= 6
+			return idx
+		}(),
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInternal.Code,
+	}, {
+		name:    "handleGetTxSpendingPrevOut: unable to fetch entry",
+		handler: handleGetTxSpendingPrevOut,
+		cmd: &types.GetTxSpendingPrevOutCmd{
+			Outputs: []types.TxSpendingPrevOut{output},
+		},
+		mockSpendIndexer: func() *testSpendIndexer {
+			idx := defaultMockSpendIndexer()
+			idx.entryErr = errors.New("unable to fetch entry")
+			return idx
+		}(),
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInternal.Code,
+	}})
+}
+
 func TestHandleInvalidateBlock(t *testing.T) {
 	t.Parallel()
 
@@ -5284,6 +6701,31 @@ func TestHandleInvalidateBlock(t *testing.T) {
 	}})
 }
 
+func TestHandleListBanned(t *testing.T) {
+	t.Parallel()
+
+	testRPCServerHandler(t, []rpcTest{{
+		name:    "handleListBanned: ok",
+		handler: handleListBanned,
+		cmd:     &types.ListBannedCmd{},
+		result: []types.ListBannedResult{{
+			Address:     "10.0.0.0/24",
+			BanCreated:  1592931302,
+			BannedUntil: 1593017702,
+		}},
+	}, {
+		name:    "handleListBanned: no bans",
+		handler: handleListBanned,
+		cmd:     &types.ListBannedCmd{},
+		mockConnManager: func() *testConnManager {
+			connManager := defaultMockConnManager()
+			connManager.bannedSubnets = nil
+			return connManager
+		}(),
+		result: []types.ListBannedResult{},
+	}})
+}
+
 func TestHandleLiveTickets(t *testing.T) {
 	t.Parallel()
 
@@ -5323,6 +6765,38 @@ func TestHandleLiveTickets(t *testing.T) {
 	}})
 }
 
+func TestHandleLoadMempool(t *testing.T) {
+	t.Parallel()
+
+	testRPCServerHandler(t, []rpcTest{{
+		name:    "handleLoadMempool: ok",
+		handler: handleLoadMempool,
+		cmd:     &types.LoadMempoolCmd{},
+		mockMempoolPersister: func() *testMempoolPersister {
+			p := defaultMockMempoolPersister()
+			p.accepted = 10
+			p.rejected = 2
+			return p
+		}(),
+		result: &types.LoadMempoolResult{
+			Filename: "/home/user/.dcrd/data/mainnet/mempool.dat",
+			Accepted: 10,
+			Rejected: 2,
+		},
+	}, {
+		name:    "handleLoadMempool: unable to load mempool",
+		handler: handleLoadMempool,
+		cmd:     &types.LoadMempoolCmd{},
+		mockMempoolPersister: func() *testMempoolPersister {
+			p := defaultMockMempoolPersister()
+			p.loadErr = errors.New("unsupported mempool serialization version")
+			return p
+		}(),
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInternal.Code,
+	}})
+}
+
 func TestHandleNode(t *testing.T) {
 	t.Parallel()
 
@@ -6025,6 +7499,157 @@ func TestHandleGetWork(t *testing.T) {
 	}})
 }
 
+func TestHandleSetBan(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1592931302, 0)
+	mockClock := &testClock{now: now}
+
+	// expectBan returns a mock connection manager that returns an error when
+	// the subnet and expiration time of a ban do not match the provided ones.
+	expectBan := func(wantSubnet string, wantExpires time.Time) *testConnManager {
+		connManager := defaultMockConnManager()
+		connManager.banSubnet = func(subnet *net.IPNet, created, expires time.Time) error {
+			if subnet.String() != wantSubnet || !created.Equal(now) ||
+				!expires.Equal(wantExpires) {
+
+				return fmt.Errorf("unexpected ban of %s from %v until %v",
+					subnet, created, expires)
+			}
+			return nil
+		}
+		return connManager
+	}
+
+	testRPCServerHandler(t, []rpcTest{{
+		name:    "handleSetBan: add subnet with default ban time",
+		handler: handleSetBan,
+		cmd: &types.SetBanCmd{
+			Subnet:   "10.0.0.1/24",
+			SubCmd:   types.SBAdd,
+			BanTime:  dcrjson.Int64(0),
+			Absolute: dcrjson.Bool(false),
+		},
+		mockClock:       mockClock,
+		mockConnManager: expectBan("10.0.0.0/24", now.Add(time.Hour*24)),
+		result:          nil,
+	}, {
+		name:    "handleSetBan: add address with relative ban time",
+		handler: handleSetBan,
+		cmd: &types.SetBanCmd{
+			Subnet:   "2001:db8::1",
+			SubCmd:   types.SBAdd,
+			BanTime:  dcrjson.Int64(3600),
+			Absolute: dcrjson.Bool(false),
+		},
+		mockClock:       mockClock,
+		mockConnManager: expectBan("2001:db8::1/128", now.Add(time.Hour)),
+		result:          nil,
+	}, {
+		name:    "handleSetBan: add address with absolute ban time",
+		handler: handleSetBan,
+		cmd: &types.SetBanCmd{
+			Subnet:   "10.0.0.1",
+			SubCmd:   types.SBAdd,
+			BanTime:  dcrjson.Int64(1600000000),
+			Absolute: dcrjson.Bool(true),
+		},
+		mockClock:       mockClock,
+		mockConnManager: expectBan("10.0.0.1/32", time.Unix(1600000000, 0)),
+		result:          nil,
+	}, {
+		name:    "handleSetBan: absolute ban time in the past",
+		handler: handleSetBan,
+		cmd: &types.SetBanCmd{
+			Subnet:   "10.0.0.1",
+			SubCmd:   types.SBAdd,
+			BanTime:  dcrjson.Int64(1500000000),
+			Absolute: dcrjson.Bool(true),
+		},
+		mockClock: mockClock,
+		wantErr:   true,
+		errCode:   dcrjson.ErrRPCInvalidParameter,
+	}, {
+		name:    "handleSetBan: negative ban time",
+		handler: handleSetBan,
+		cmd: &types.SetBanCmd{
+			Subnet:   "10.0.0.1",
+			SubCmd:   types.SBAdd,
+			BanTime:  dcrjson.Int64(-1),
+			Absolute: dcrjson.Bool(false),
+		},
+		mockClock: mockClock,
+		wantErr:   true,
+		errCode:   dcrjson.ErrRPCInvalidParameter,
+	}, {
+		name:    "handleSetBan: invalid subnet",
+		handler: handleSetBan,
+		cmd: &types.SetBanCmd{
+			Subnet:   "invalid_address",
+			SubCmd:   types.SBAdd,
+			BanTime:  dcrjson.Int64(0),
+			Absolute: dcrjson.Bool(false),
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInvalidParameter,
+	}, {
+		name:    "handleSetBan: unable to save ban list",
+		handler: handleSetBan,
+		cmd: &types.SetBanCmd{
+			Subnet:   "10.0.0.1",
+			SubCmd:   types.SBAdd,
+			BanTime:  dcrjson.Int64(0),
+			Absolute: dcrjson.Bool(false),
+		},
+		mockConnManager: func() *testConnManager {
+			connManager := defaultMockConnManager()
+			connManager.banSubnet = func(*net.IPNet, time.Time, time.Time) error {
+				return errors.New("unable to save")
+			}
+			return connManager
+		}(),
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInternal.Code,
+	}, {
+		name:    "handleSetBan: remove subnet",
+		handler: handleSetBan,
+		cmd: &types.SetBanCmd{
+			Subnet:   "10.0.0.0/24",
+			SubCmd:   types.SBRemove,
+			BanTime:  dcrjson.Int64(0),
+			Absolute: dcrjson.Bool(false),
+		},
+		result: nil,
+	}, {
+		name:    "handleSetBan: remove subnet that is not banned",
+		handler: handleSetBan,
+		cmd: &types.SetBanCmd{
+			Subnet:   "10.0.1.0/24",
+			SubCmd:   types.SBRemove,
+			BanTime:  dcrjson.Int64(0),
+			Absolute: dcrjson.Bool(false),
+		},
+		mockConnManager: func() *testConnManager {
+			connManager := defaultMockConnManager()
+			connManager.unbanSubnetErr = banmanager.ErrNotBanned
+			return connManager
+		}(),
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInvalidParameter,
+	}, {
+		name:    "handleSetBan: invalid subcommand",
+		handler: handleSetBan,
+		cmd: &types.SetBanCmd{
+			Subnet:   "10.0.0.1",
+			SubCmd:   "invalid",
+			BanTime:  dcrjson.Int64(0),
+			Absolute: dcrjson.Bool(false),
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInvalidParameter,
+	}})
+}
+
 func TestHandleSetGenerate(t *testing.T) {
 	t.Parallel()
 
@@ -6143,6 +7768,36 @@ func TestHandleRegenTemplate(t *testing.T) {
 	}})
 }
 
+func TestHandleSaveMempool(t *testing.T) {
+	t.Parallel()
+
+	testRPCServerHandler(t, []rpcTest{{
+		name:    "handleSaveMempool: ok",
+		handler: handleSaveMempool,
+		cmd:     &types.SaveMempoolCmd{},
+		mockMempoolPersister: func() *testMempoolPersister {
+			p := defaultMockMempoolPersister()
+			p.saved = 12
+			return p
+		}(),
+		result: &types.SaveMempoolResult{
+			Filename: "/home/user/.dcrd/data/mainnet/mempool.dat",
+			Count:    12,
+		},
+	}, {
+		name:    "handleSaveMempool: unable to save mempool",
+		handler: handleSaveMempool,
+		cmd:     &types.SaveMempoolCmd{},
+		mockMempoolPersister: func() *testMempoolPersister {
+			p := defaultMockMempoolPersister()
+			p.saveErr = errors.New("permission denied")
+			return p
+		}(),
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInternal.Code,
+	}})
+}
+
 func TestHandleTSpendVotes(t *testing.T) {
 	t.Parallel()
 
@@ -6466,6 +8121,351 @@ func TestHandleTicketVWAP(t *testing.T) {
 	}})
 }
 
+func TestHandleTraceTxInput(t *testing.T) {
+	t.Parallel()
+
+	// Create a transaction that spends a pay-to-script-hash output with a
+	// redeem script of OP_TRUE along with an otherwise identical transaction
+	// that provides the wrong redeem script.  Note that the output of the
+	// transaction pays to the same script so it may also serve as the
+	// transaction that contains the previous output.
+	redeemScript := []byte{txscript.OP_TRUE}
+	p2shAddr, err := stdaddr.NewAddressScriptHashV0(redeemScript,
+		defaultChainParams)
+	if err != nil {
+		t.Fatalf("unexpected error creating address: %v", err)
+	}
+	_, pkScript := p2shAddr.PaymentScript()
+	scriptHash := hex.EncodeToString(stdaddr.Hash160(redeemScript))
+	prevOut := wire.NewOutPoint(mustParseHash("e02f03a25a57afdd402818fe5b13"+
+		"985a0731502ad8a8c93d1874900e84d3330d"), 0, wire.TxTreeRegular)
+	tx := wire.NewMsgTx()
+	tx.AddTxIn(wire.NewTxIn(prevOut, 100000000, []byte{txscript.OP_DATA_1,
+		txscript.OP_TRUE}))
+	tx.AddTxOut(wire.NewTxOut(100000000, pkScript))
+	txid := tx.TxHash().String()
+	badTx := tx.Copy()
+	badTx.TxIn[0].SignatureScript = []byte{txscript.OP_DATA_1, txscript.OP_2}
+	coinbaseTx := tx.Copy()
+	coinbaseTx.TxIn[0].PreviousOutPoint = *wire.NewOutPoint(&chainhash.Hash{},
+		wire.MaxPrevOutIndex, wire.TxTreeRegular)
+
+	mempoolWithTx := func(tx *wire.MsgTx) *testTxMempooler {
+		mp := defaultMockTxMempooler()
+		mp.fetchTransaction = dcrutil.NewTx(tx)
+		mp.fetchTransactionErr = nil
+		return mp
+	}
+	chainWithPrevOut := func() *testRPCChain {
+		chain := defaultMockRPCChain()
+		chain.fetchUtxoEntry = &testRPCUtxoEntry{
+			amount:   100000000,
+			pkScript: pkScript,
+		}
+		return chain
+	}()
+	chainWithoutPrevOut := func() *testRPCChain {
+		chain := defaultMockRPCChain()
+		chain.fetchUtxoEntry = nil
+		return chain
+	}()
+
+	scriptPubKey := types.ScriptPubKeyResult{
+		Asm:       "OP_HASH160 " + scriptHash + " OP_EQUAL",
+		Hex:       hex.EncodeToString(pkScript),
+		ReqSigs:   1,
+		Type:      "scripthash",
+		Addresses: []string{p2shAddr.String()},
+	}
+	validSteps := []types.ScriptTraceStep{{
+		ScriptIdx:      0,
+		OpcodeIdx:      0,
+		Opcode:         "OP_DATA_1 0x51",
+		Stack:          []string{},
+		AltStack:       []string{},
+		CondStack:      []bool{},
+		StackAfter:     []string{"51"},
+		AltStackAfter:  []string{},
+		CondStackAfter: []bool{},
+	}, {
+		ScriptIdx:      1,
+		OpcodeIdx:      0,
+		Opcode:         "OP_HASH160",
+		Stack:          []string{"51"},
+		AltStack:       []string{},
+		CondStack:      []bool{},
+		StackAfter:     []string{scriptHash},
+		AltStackAfter:  []string{},
+		CondStackAfter: []bool{},
+	}, {
+		ScriptIdx:      1,
+		OpcodeIdx:      1,
+		Opcode:         "OP_DATA_20 0x" + scriptHash,
+		Stack:          []string{scriptHash},
+		AltStack:       []string{},
+		CondStack:      []bool{},
+		StackAfter:     []string{scriptHash, scriptHash},
+		AltStackAfter:  []string{},
+		CondStackAfter: []bool{},
+	}, {
+		ScriptIdx:      1,
+		OpcodeIdx:      2,
+		Opcode:         "OP_EQUAL",
+		Stack:          []string{scriptHash, scriptHash},
+		AltStack:       []string{},
+		CondStack:      []bool{},
+		StackAfter:     []string{"01"},
+		AltStackAfter:  []string{},
+		CondStackAfter: []bool{},
+	}, {
+		ScriptIdx:      2,
+		OpcodeIdx:      0,
+		Opcode:         "OP_1",
+		Stack:          []string{},
+		AltStack:       []string{},
+		CondStack:      []bool{},
+		StackAfter:     []string{"01"},
+		AltStackAfter:  []string{},
+		CondStackAfter: []bool{},
+	}}
+	validResult := types.TraceTxInputResult{
+		Txid:   txid,
+		Vin:    0,
+		Amount: 1,
+		ScriptSig: types.ScriptSig{
+			Asm: "51",
+			Hex: "0151",
+		},
+		ScriptPubKey: scriptPubKey,
+		Steps:        validSteps,
+		Valid:        true,
+	}
+
+	badHash := hex.EncodeToString(stdaddr.Hash160([]byte{txscript.OP_2}))
+	badSteps := []types.ScriptTraceStep{{
+		ScriptIdx:      0,
+		OpcodeIdx:      0,
+		Opcode:         "OP_DATA_1 0x52",
+		Stack:          []string{},
+		AltStack:       []string{},
+		CondStack:      []bool{},
+		StackAfter:     []string{"52"},
+		AltStackAfter:  []string{},
+		CondStackAfter: []bool{},
+	}, {
+		ScriptIdx:      1,
+		OpcodeIdx:      0,
+		Opcode:         "OP_HASH160",
+		Stack:          []string{"52"},
+		AltStack:       []string{},
+		CondStack:      []bool{},
+		StackAfter:     []string{badHash},
+		AltStackAfter:  []string{},
+		CondStackAfter: []bool{},
+	}, {
+		ScriptIdx:      1,
+		OpcodeIdx:      1,
+		Opcode:         "OP_DATA_20 0x" + scriptHash,
+		Stack:          []string{badHash},
+		AltStack:       []string{},
+		CondStack:      []bool{},
+		StackAfter:     []string{badHash, scriptHash},
+		AltStackAfter:  []string{},
+		CondStackAfter: []bool{},
+	}, {
+		ScriptIdx:      1,
+		OpcodeIdx:      2,
+		Opcode:         "OP_EQUAL",
+		Stack:          []string{badHash, scriptHash},
+		AltStack:       []string{},
+		CondStack:      []bool{},
+		StackAfter:     []string{""},
+		AltStackAfter:  []string{},
+		CondStackAfter: []bool{},
+	}}
+
+	testRPCServerHandler(t, []rpcTest{{
+		name:    "handleTraceTxInput: ok",
+		handler: handleTraceTxInput,
+		cmd: &types.TraceTxInputCmd{
+			Txid: txid,
+			Vin:  0,
+		},
+		mockChain:       chainWithPrevOut,
+		mockTxMempooler: mempoolWithTx(tx),
+		result:          validResult,
+	}, {
+		name:    "handleTraceTxInput: ok with previous output from transaction",
+		handler: handleTraceTxInput,
+		cmd: &types.TraceTxInputCmd{
+			Txid: txid,
+			Vin:  0,
+		},
+		mockChain:       chainWithoutPrevOut,
+		mockTxMempooler: mempoolWithTx(tx),
+		result:          validResult,
+	}, {
+		name:    "handleTraceTxInput: ok with failed execution",
+		handler: handleTraceTxInput,
+		cmd: &types.TraceTxInputCmd{
+			Txid: txid,
+			Vin:  0,
+		},
+		mockChain:       chainWithPrevOut,
+		mockTxMempooler: mempoolWithTx(badTx),
+		result: types.TraceTxInputResult{
+			Txid:   txid,
+			Vin:    0,
+			Amount: 1,
+			ScriptSig: types.ScriptSig{
+				Asm: "52",
+				Hex: "0152",
+			},
+			ScriptPubKey: scriptPubKey,
+			Steps:        badSteps,
+			Valid:        false,
+			Error:        "false stack entry at end of script execution",
+		},
+	}, {
+		name:    "handleTraceTxInput: invalid txid",
+		handler: handleTraceTxInput,
+		cmd: &types.TraceTxInputCmd{
+			Txid: "invalid",
+			Vin:  0,
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCDecodeHexString,
+	}, {
+		name:    "handleTraceTxInput: tx index not enabled",
+		handler: handleTraceTxInput,
+		cmd: &types.TraceTxInputCmd{
+			Txid: txid,
+			Vin:  0,
+		},
+		setTxIndexerNil: true,
+		mockTxMempooler: func() *testTxMempooler {
+			mp := defaultMockTxMempooler()
+			mp.fetchTransactionErr = errors.New("unable to fetch tx from mempool")
+			return mp
+		}(),
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInternal.Code,
+	}, {
+		name:    "handleTraceTxInput: input index out of range",
+		handler: handleTraceTxInput,
+		cmd: &types.TraceTxInputCmd{
+			Txid: txid,
+			Vin:  1,
+		},
+		mockTxMempooler: mempoolWithTx(tx),
+		wantErr:         true,
+		errCode:         dcrjson.ErrRPCInvalidParameter,
+	}, {
+		name:    "handleTraceTxInput: input does not spend previous output",
+		handler: handleTraceTxInput,
+		cmd: &types.TraceTxInputCmd{
+			Txid: txid,
+			Vin:  0,
+		},
+		mockTxMempooler: mempoolWithTx(coinbaseTx),
+		wantErr:         true,
+		errCode:         dcrjson.ErrRPCInvalidParameter,
+	}, {
+		name:    "handleTraceTxInput: unable to fetch utxo entry",
+		handler: handleTraceTxInput,
+		cmd: &types.TraceTxInputCmd{
+			Txid: txid,
+			Vin:  0,
+		},
+		mockChain: func() *testRPCChain {
+			chain := defaultMockRPCChain()
+			chain.fetchUtxoEntryErr = errors.New("unable to fetch utxo entry")
+			return chain
+		}(),
+		mockTxMempooler: mempoolWithTx(tx),
+		wantErr:         true,
+		errCode:         dcrjson.ErrRPCInternal.Code,
+	}})
+}
+
+// TestHandleTraceTxInputTruncated ensures tracetxinput results for scripts that
+// produce traces that exceed the maximum allowed size are truncated while still
+// reporting the result of executing the scripts in full.
+func TestHandleTraceTxInputTruncated(t *testing.T) {
+	t.Parallel()
+
+	// Create a transaction that spends an output with a script that repeatedly
+	// duplicates a couple of max size stack items provided by the signature
+	// script and then drops all of them before finally pushing true.  This
+	// results in each step of the trace containing several megabytes of stack
+	// data in aggregate.
+	const numDups = 100
+	item := bytes.Repeat([]byte{0x01}, txscript.MaxScriptElementSize)
+	sigScript, err := txscript.NewScriptBuilder().AddData(item).AddData(item).
+		Script()
+	if err != nil {
+		t.Fatalf("unexpected error creating signature script: %v", err)
+	}
+	var pkScript []byte
+	pkScript = append(pkScript, bytes.Repeat([]byte{txscript.OP_2DUP},
+		numDups)...)
+	pkScript = append(pkScript, bytes.Repeat([]byte{txscript.OP_2DROP},
+		numDups+1)...)
+	pkScript = append(pkScript, txscript.OP_TRUE)
+	prevOut := wire.NewOutPoint(mustParseHash("e02f03a25a57afdd402818fe5b13"+
+		"985a0731502ad8a8c93d1874900e84d3330d"), 0, wire.TxTreeRegular)
+	tx := wire.NewMsgTx()
+	tx.AddTxIn(wire.NewTxIn(prevOut, 100000000, sigScript))
+	tx.AddTxOut(wire.NewTxOut(100000000, []byte{txscript.OP_TRUE}))
+
+	cfg := defaultMockConfig(defaultChainParams)
+	chain := defaultMockRPCChain()
+	chain.fetchUtxoEntry = &testRPCUtxoEntry{
+		amount:   100000000,
+		pkScript: pkScript,
+	}
+	cfg.Chain = chain
+	mp := defaultMockTxMempooler()
+	mp.fetchTransaction = dcrutil.NewTx(tx)
+	mp.fetchTransactionErr = nil
+	cfg.TxMempooler = mp
+	testServer := &Server{
+		cfg:        *cfg,
+		ntfnMgr:    new(testNtfnManager),
+		workState:  newWorkState(),
+		helpCacher: &testHelpCacher{},
+	}
+
+	cmd := &types.TraceTxInputCmd{Txid: tx.TxHash().String(), Vin: 0}
+	res, err := handleTraceTxInput(context.Background(), testServer, cmd)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result := res.(types.TraceTxInputResult)
+	if !result.Valid {
+		t.Fatalf("unexpected invalid result: %v", result.Error)
+	}
+	if !result.Truncated {
+		t.Fatal("trace was not marked as truncated")
+	}
+
+	// Ensure the trace contains some, but not all, of the steps and that the
+	// included steps do not exceed the maximum allowed size.
+	const numOpcodes = 2 + numDups + numDups + 1 + 1
+	if len(result.Steps) == 0 || len(result.Steps) >= numOpcodes {
+		t.Fatalf("unexpected number of steps -- got %d, want between 1 and %d",
+			len(result.Steps), numOpcodes-1)
+	}
+	var traceSize int
+	for i := range result.Steps {
+		traceSize += traceStepSize(&result.Steps[i])
+	}
+	if traceSize > maxTraceTxInputSize {
+		t.Fatalf("trace size %d exceeds max allowed size %d", traceSize,
+			maxTraceTxInputSize)
+	}
+}
+
 func TestHandleTxFeeInfo(t *testing.T) {
 	t.Parallel()
 
@@ -6675,6 +8675,19 @@ func TestHandleTicketFeeInfo(t *testing.T) {
 		}(),
 		wantErr: true,
 		errCode: dcrjson.ErrRPCInternal.Code,
+	}, {
+		name:    "handleTicketFeeInfo: block data pruned",
+		handler: handleTicketFeeInfo,
+		cmd: &types.TicketFeeInfoCmd{
+			Blocks: &blocks,
+		},
+		mockChain: func() *testRPCChain {
+			chain := defaultMockRPCChain()
+			chain.blockByHeightErr = blockchain.ErrBlockPruned
+			return chain
+		}(),
+		wantErr: true,
+		errCode: dcrjson.ErrRPCMisc,
 	}, {
 		name:    "handleTicketFeeInfo: unable to fetch ticket fee info for range",
 		handler: handleTicketFeeInfo,
@@ -6855,6 +8868,117 @@ func TestHandleVerifyMessage(t *testing.T) {
 	}})
 }
 
+func TestHandleSearchRawTransactions(t *testing.T) {
+	t.Parallel()
+
+	// Create a mock address index with a confirmed and an unconfirmed
+	// transaction along with a database that contains the confirmed one.
+	confirmedHex := hexFromFile("tx432098-11.hex")
+	unconfirmedHex := hexFromFile("tx432100-1.hex")
+	var unconfirmedTx wire.MsgTx
+	if err := unconfirmedTx.FromBytes(hexToBytes(unconfirmedHex)); err != nil {
+		t.Fatalf("unable to create tx from bytes: %v", err)
+	}
+	addrIndex := func() *testAddrIndexer {
+		idx := defaultMockAddrIndexer()
+		idx.entries = []indexers.TxIndexEntry{{
+			BlockRegion: database.BlockRegion{
+				Hash: mustParseHash("00000000000000001fc4c4c7a3f2ec6d552dda16a3a928f27bd6" +
+					"bd16d8f1e9b3"),
+				Offset: 52508,
+				Len:    453,
+			},
+			BlockIndex: 11,
+		}}
+		idx.unconfirmed = []*dcrutil.Tx{dcrutil.NewTx(&unconfirmedTx)}
+		return idx
+	}()
+	db := func() *testDB {
+		db := defaultMockDB()
+		db.viewTx = &testDatabaseTx{
+			fetchBlockRegion: func(region *database.BlockRegion) ([]byte, error) {
+				return hexToBytes(confirmedHex), nil
+			},
+		}
+		return db
+	}()
+	const addr = "DsbjabD32RuS1deAj2uTjKfFZ6nSza5qVf3"
+
+	testRPCServerHandler(t, []rpcTest{{
+		name:    "handleSearchRawTransactions: ok not verbose",
+		handler: handleSearchRawTransactions,
+		cmd: &types.SearchRawTransactionsCmd{
+			Address: addr,
+			Verbose: dcrjson.Int(0),
+		},
+		mockAddrIndexer: addrIndex,
+		mockDB:          db,
+		result:          []string{confirmedHex, unconfirmedHex},
+	}, {
+		name:    "handleSearchRawTransactions: ok not verbose reverse",
+		handler: handleSearchRawTransactions,
+		cmd: &types.SearchRawTransactionsCmd{
+			Address: addr,
+			Verbose: dcrjson.Int(0),
+			Reverse: dcrjson.Bool(true),
+		},
+		mockAddrIndexer: addrIndex,
+		mockDB:          db,
+		result:          []string{unconfirmedHex, confirmedHex},
+	}, {
+		name:    "handleSearchRawTransactions: ok count zero",
+		handler: handleSearchRawTransactions,
+		cmd: &types.SearchRawTransactionsCmd{
+			Address: addr,
+			Count:   dcrjson.Int(0),
+		},
+		mockAddrIndexer: addrIndex,
+		mockDB:          db,
+		result:          []types.TxRawResult{},
+	}, {
+		name:    "handleSearchRawTransactions: address index not enabled",
+		handler: handleSearchRawTransactions,
+		cmd: &types.SearchRawTransactionsCmd{
+			Address: addr,
+		},
+		setAddrIndexerNil: true,
+		wantErr:           true,
+		errCode:           dcrjson.ErrRPCInternal.Code,
+	}, {
+		name:    "handleSearchRawTransactions: unable to fetch header by hash",
+		handler: handleSearchRawTransactions,
+		cmd: &types.SearchRawTransactionsCmd{
+			Address: addr,
+		},
+		mockAddrIndexer: addrIndex,
+		mockDB:          db,
+		mockChain: func() *testRPCChain {
+			chain := defaultMockRPCChain()
+			chain.headerByHashErr = errors.New("unable to fetch header by hash")
+			return chain
+		}(),
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInternal.Code,
+	}, {
+		name:    "handleSearchRawTransactions: unable to fetch treasury agenda status",
+		handler: handleSearchRawTransactions,
+		cmd: &types.SearchRawTransactionsCmd{
+			Address: addr,
+		},
+		mockAddrIndexer: addrIndex,
+		mockDB:          db,
+		mockChain: func() *testRPCChain {
+			chain := defaultMockRPCChain()
+			chain.treasuryActive = false
+			chain.treasuryActiveErr =
+				errors.New("unable to fetch treasury agenda status")
+			return chain
+		}(),
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInternal.Code,
+	}})
+}
+
 func TestHandleSendRawTransaction(t *testing.T) {
 	t.Parallel()
 
@@ -7625,6 +9749,22 @@ func TestHandleGetRawTransaction(t *testing.T) {
 		mockTxIndexer:   txIndex,
 		wantErr:         true,
 		errCode:         dcrjson.ErrRPCNoTxInfo,
+	}, {
+		name:    "handleGetRawTransaction: block data pruned",
+		handler: handleGetRawTransaction,
+		cmd: &types.GetRawTransactionCmd{
+			Txid:    txid,
+			Verbose: &nonVerboseTx,
+		},
+		mockChain: func() *testRPCChain {
+			chain := defaultMockRPCChain()
+			chain.pruneHeight = chain.blockHeightByHash + 1
+			return chain
+		}(),
+		mockTxMempooler: txPool,
+		mockTxIndexer:   txIndex,
+		wantErr:         true,
+		errCode:         dcrjson.ErrRPCMisc,
 	}, {
 		name:    "handleGetRawTransaction: ok, not verbose",
 		handler: handleGetRawTransaction,
@@ -7902,6 +10042,33 @@ func TestHandleGetStakeDifficulty(t *testing.T) {
 			CurrentStakeDifficulty: 144.2816259,
 			NextStakeDifficulty:    144.2816259,
 		},
+	}, {
+		name:    "handleGetStakeDifficulty: light mode unable to calc next",
+		handler: handleGetStakeDifficulty,
+		mockChain: func() *testRPCChain {
+			chain := defaultMockRPCChain()
+			chain.calcNextReqStakeDiffV2Err = errors.New("unable to calc " +
+				"next stake difficulty")
+			return chain
+		}(),
+		lightMode: true,
+		cmd:       &types.GetStakeDifficultyCmd{},
+		wantErr:   true,
+		errCode:   dcrjson.ErrRPCDifficulty,
+	}, {
+		name:    "handleGetStakeDifficulty: light mode ok",
+		handler: handleGetStakeDifficulty,
+		mockChain: func() *testRPCChain {
+			chain := defaultMockRPCChain()
+			chain.calcNextReqStakeDiffV2 = 15000000000
+			return chain
+		}(),
+		lightMode: true,
+		cmd:       &types.GetStakeDifficultyCmd{},
+		result: types.GetStakeDifficultyResult{
+			CurrentStakeDifficulty: 144.2816259,
+			NextStakeDifficulty:    150,
+		},
 	}})
 }
 
@@ -8023,6 +10190,18 @@ func testRPCServerHandler(t *testing.T, tests []rpcTest) {
 			if test.setTxIndexerNil {
 				rpcserverConfig.TxIndexer = nil
 			}
+			if test.mockAddrIndexer != nil {
+				rpcserverConfig.AddrIndexer = test.mockAddrIndexer
+			}
+			if test.setAddrIndexerNil {
+				rpcserverConfig.AddrIndexer = nil
+			}
+			if test.mockSpendIndexer != nil {
+				rpcserverConfig.SpendIndexer = test.mockSpendIndexer
+			}
+			if test.setSpendIndexerNil {
+				rpcserverConfig.SpendIndexer = nil
+			}
 			if test.mockDB != nil {
 				rpcserverConfig.DB = test.mockDB
 			}
@@ -8064,9 +10243,13 @@ func testRPCServerHandler(t *testing.T, tests []rpcTest) {
 			if test.mockTxMempooler != nil {
 				rpcserverConfig.TxMempooler = test.mockTxMempooler
 			}
+			if test.mockMempoolPersister != nil {
+				rpcserverConfig.MempoolPersister = test.mockMempoolPersister
+			}
 			if test.mockHelpCacher != nil {
 				helpCacher = test.mockHelpCacher
 			}
+			rpcserverConfig.LightMode = test.lightMode
 
 			ctx := context.Background()
 			testServer := &Server{
@@ -1,5 +1,5 @@
 // Copyright (c) 2015 The btcsuite developers
-// Copyright (c) 2015-2023 The Decred developers
+// Copyright (c) 2015-2024 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
@@ -41,6 +41,22 @@ var helpDescsEnUS = map[string]string{
 	"node-target":        "Either the IP address and port of the peer to operate on, or a valid peer ID.",
 	"node-connectsubcmd": "'perm' to make the connected peer a permanent one, 'temp' to try a single connect to a peer",
 
+	// SetBanCmd help.
+	"setban--synopsis": "Attempts to add or remove an IP address or subnet from the ban list.  Adding a ban also disconnects all connected peers with an address in the subnet.",
+	"setban-subnet":    "The IP address or subnet in CIDR notation (e.g. 192.168.0.0/24) to operate on",
+	"setban-subcmd":    "'add' to add the IP address or subnet to the ban list or 'remove' to remove it from the ban list",
+	"setban-bantime":   "The number of seconds the IP address or subnet is banned for or, when absolute is true, the unix time the ban expires (0 for the default ban duration)",
+	"setban-absolute":  "Whether or not the ban time is an absolute unix time",
+
+	// ListBannedCmd help.
+	"listbanned--synopsis":         "Returns the IP addresses and subnets in the ban list.",
+	"listbannedresult-address":     "The banned IP address or subnet in CIDR notation",
+	"listbannedresult-bancreated":  "The unix time the ban was created",
+	"listbannedresult-banneduntil": "The unix time the ban expires",
+
+	// ClearBannedCmd help.
+	"clearbanned--synopsis": "Removes all IP addresses and subnets from the ban list.",
+
 	// TransactionInput help.
 	"transactioninput-amount": "The previous output amount in coins",
 	"transactioninput-txid":   "The hash of the input transaction",
@@ -67,6 +83,12 @@ var helpDescsEnUS = map[string]string{
 	"sstxcommitout-changeamt":     "Amount for change in atoms",
 	"sstxcommitout-changeaddr":    "Address for change",
 
+	// CombinePSDTCmd help.
+	"combinepsdt--synopsis": "Combines multiple partially signed transactions for the same transaction into a single partially signed transaction.\n" +
+		"This is typically used to merge the signatures provided by independent signers.",
+	"combinepsdt-psdts":    "The base64-encoded partially signed transactions to combine",
+	"combinepsdt--result0": "The base64-encoded combined partially signed transaction",
+
 	// CreateRawSSRTxCmd help.
 	"createrawssrtx--synopsis": "Returns a new transaction spending the provided inputs and sending to the provided addresses.\n" +
 		"The transaction inputs are not signed in the created transaction.\n" +
@@ -148,6 +170,58 @@ var helpDescsEnUS = map[string]string{
 	"txrawdecoderesult-vout":     "The transaction outputs as JSON objects",
 	"txrawdecoderesult-expiry":   "The transaction expiry",
 
+	// PSDTScript help.
+	"psdtscript-asm":  "Disassembly of the script",
+	"psdtscript-hex":  "Hex-encoded bytes of the script",
+	"psdtscript-type": "The type of the script (e.g. 'multisig')",
+
+	// PSDTBip32Derivation help.
+	"psdtbip32derivation-pubkey":            "The hex-encoded compressed public key",
+	"psdtbip32derivation-masterfingerprint": "The hex-encoded fingerprint of the master extended key",
+	"psdtbip32derivation-path":              "The derivation path of the public key from the master extended key",
+
+	// PSDTPrevOut help.
+	"psdtprevout-amount":       "The amount of the previous output in DCR",
+	"psdtprevout-scriptPubKey": "The public key script of the previous output as a JSON object",
+
+	// DecodePSDTInput help.
+	"decodepsdtinput-prevout":                  "The previous output spent by the input (only present when known)",
+	"decodepsdtinput-partialsignatures":        "The partial signatures of the input",
+	"decodepsdtinput-partialsignatures--key":   "pubkey",
+	"decodepsdtinput-partialsignatures--value": "signature",
+	"decodepsdtinput-partialsignatures--desc":  "The hex-encoded public key as the key and the hex-encoded signature as the value",
+	"decodepsdtinput-sighashtype":              "The signature hash type signers must use",
+	"decodepsdtinput-redeemscript":             "The redeem script of the previous output",
+	"decodepsdtinput-bip32derivs":              "The BIP32 derivations of the public keys involved in signing the input",
+	"decodepsdtinput-finalscriptsig":           "The final signature script of the input (only present when finalized)",
+	"decodepsdtinput-unknown":                  "The unknown key-value pairs of the input",
+	"decodepsdtinput-unknown--key":             "key",
+	"decodepsdtinput-unknown--value":           "value",
+	"decodepsdtinput-unknown--desc":            "The hex-encoded key as the key and the hex-encoded value as the value",
+
+	// DecodePSDTOutput help.
+	"decodepsdtoutput-redeemscript":   "The redeem script of the output",
+	"decodepsdtoutput-bip32derivs":    "The BIP32 derivations of the public keys involved in the output",
+	"decodepsdtoutput-unknown":        "The unknown key-value pairs of the output",
+	"decodepsdtoutput-unknown--key":   "key",
+	"decodepsdtoutput-unknown--value": "value",
+	"decodepsdtoutput-unknown--desc":  "The hex-encoded key as the key and the hex-encoded value as the value",
+
+	// DecodePSDTResult help.
+	"decodepsdtresult-tx":             "The decoded unsigned transaction",
+	"decodepsdtresult-txtype":         "The stake transaction type hint (e.g. 'regular' or 'ticket')",
+	"decodepsdtresult-unknown":        "The unknown global key-value pairs",
+	"decodepsdtresult-unknown--key":   "key",
+	"decodepsdtresult-unknown--value": "value",
+	"decodepsdtresult-unknown--desc":  "The hex-encoded key as the key and the hex-encoded value as the value",
+	"decodepsdtresult-inputs":         "The metadata of the inputs as JSON objects",
+	"decodepsdtresult-outputs":        "The metadata of the outputs as JSON objects",
+	"decodepsdtresult-fee":            "The transaction fee in DCR (only present when the previous outputs of all inputs are known)",
+
+	// DecodePSDTCmd help.
+	"decodepsdt--synopsis": "Returns a JSON object representing the provided base64-encoded partially signed transaction.",
+	"decodepsdt-psdt":      "The base64-encoded partially signed transaction",
+
 	// DecodeRawTransactionCmd help.
 	"decoderawtransaction--synopsis": "Returns a JSON object representing the provided serialized, hex-encoded transaction.",
 	"decoderawtransaction-hextx":     "Serialized, hex-encoded transaction",
@@ -164,6 +238,22 @@ var helpDescsEnUS = map[string]string{
 	"decodescript-hexscript": "Hex-encoded script",
 	"decodescript-version":   "The script version, defaults to version 0 if not set.",
 
+	// DeriveAddressesCmd help.
+	"deriveaddresses--synopsis":  "Derives the addresses described by the provided output descriptor.",
+	"deriveaddresses-descriptor": "The output descriptor which must include its checksum",
+	"deriveaddresses-range":      "The end or the beginning and end of the range of child indices to derive addresses for (only allowed and required for ranged descriptors)",
+	"deriveaddresses--result0":   "The derived addresses",
+
+	// FinalizePSDTResult help.
+	"finalizepsdtresult-psdt":     "The base64-encoded partially signed transaction (only present when not extracted)",
+	"finalizepsdtresult-hex":      "The hex-encoded signed transaction (only present when complete and extracted)",
+	"finalizepsdtresult-complete": "Whether or not all inputs are finalized",
+
+	// FinalizePSDTCmd help.
+	"finalizepsdt--synopsis": "Finalizes the inputs of the provided partially signed transaction that have enough signatures and extracts the signed transaction once all inputs are finalized.",
+	"finalizepsdt-psdt":      "The base64-encoded partially signed transaction",
+	"finalizepsdt-extract":   "Return the signed transaction instead of the partially signed transaction when it is complete",
+
 	// ExistsAddressCmd help.
 	"existsaddress--synopsis": "Test for the existence of the provided address",
 	"existsaddress-address":   "The address to check",
@@ -212,6 +302,16 @@ var helpDescsEnUS = map[string]string{
 	"getaddednodeinfo--condition1": "dns=true",
 	"getaddednodeinfo--result0":    "List of added peers",
 
+	// GetAddressTxIDsCmd help.
+	"getaddresstxids--synopsis": "Returns the hashes of the transactions that involve the given address, including unconfirmed transactions in the mempool.\n" +
+		"Confirmed transactions are returned in the order they appear in the main chain followed by unconfirmed transactions in the order they were added to the mempool.\n" +
+		"NOTE: This requires the address index to be enabled (--addrindex).",
+	"getaddresstxids-address":  "The address to look up transactions for",
+	"getaddresstxids-skip":     "The number of leading transactions to skip",
+	"getaddresstxids-count":    "The maximum number of transactions to return (max: 10000)",
+	"getaddresstxids-reverse":  "Specifies the transactions are returned in reverse order so the most recent transactions are first",
+	"getaddresstxids--result0": "The hashes of the transactions",
+
 	// GetBestBlockResult help.
 	"getbestblockresult-hash":   "Hex-encoded bytes of the best block hash",
 	"getbestblockresult-height": "Height of the best block",
@@ -248,6 +348,8 @@ var helpDescsEnUS = map[string]string{
 	"getblockchaininforesult-chainwork":            "Hex encoded total work done for the chain.",
 	"getblockchaininforesult-initialblockdownload": "Best guess of whether this node is in the initial block download mode used to catch up the chain when it is far behind",
 	"getblockchaininforesult-maxblocksize":         "The maximum allowed block size.",
+	"getblockchaininforesult-pruned":               "Whether or not old block data is pruned.",
+	"getblockchaininforesult-pruneheight":          "The height of the oldest block with data available (only when block data has been pruned).",
 	"getblockchaininforesult-deployments":          "Network consensus deployments.",
 	"getblockchaininforesult-deployments--desc":    "Consensus deployment agendas.",
 	"getblockchaininforesult-deployments--key":     "The consensus deployment agenda id.",
@@ -349,6 +451,45 @@ var helpDescsEnUS = map[string]string{
 	"getblockheaderverboseresult-extradata":         "Extra data field for the requested block",
 	"getblockheaderverboseresult-stakeversion":      "The stake version of the block",
 
+	// GetBlockStatsCmd help.
+	"getblockstats--synopsis": "Returns statistics about the transactions, fees, and subsidy of a block in the main chain.\n" +
+		"All amounts are in atoms and all fee rates are in atoms per kilobyte.\n" +
+		"The fee, fee rate, and transaction size statistics exclude the coinbase and treasurybase.",
+	"getblockstats-hashorheight": "The hash or height of the block (string or numeric)",
+
+	// GetBlockStatsResult help.
+	"getblockstatsresult-hash":               "The hash of the block",
+	"getblockstatsresult-height":             "The height of the block",
+	"getblockstatsresult-time":               "The block time in seconds since 1 Jan 1970 GMT",
+	"getblockstatsresult-txs":                "The total number of transactions in both trees",
+	"getblockstatsresult-ins":                "The total number of inputs",
+	"getblockstatsresult-outs":               "The total number of outputs",
+	"getblockstatsresult-totalsize":          "The total size of the transactions",
+	"getblockstatsresult-totalout":           "The total amount of the outputs",
+	"getblockstatsresult-totalfee":           "The total fee paid by the transactions",
+	"getblockstatsresult-avgfee":             "The average fee paid by the transactions",
+	"getblockstatsresult-minfee":             "The minimum fee paid by a transaction",
+	"getblockstatsresult-maxfee":             "The maximum fee paid by a transaction",
+	"getblockstatsresult-medianfee":          "The median fee paid by the transactions",
+	"getblockstatsresult-avgfeerate":         "The average fee rate of the transactions",
+	"getblockstatsresult-minfeerate":         "The minimum fee rate of a transaction",
+	"getblockstatsresult-maxfeerate":         "The maximum fee rate of a transaction",
+	"getblockstatsresult-feeratepercentiles": "The fee rates at the 10th, 25th, 50th, 75th, and 90th percentiles weighted by transaction size",
+	"getblockstatsresult-avgtxsize":          "The average size of the transactions",
+	"getblockstatsresult-mintxsize":          "The minimum size of a transaction",
+	"getblockstatsresult-maxtxsize":          "The maximum size of a transaction",
+	"getblockstatsresult-mediantxsize":       "The median size of the transactions",
+	"getblockstatsresult-utxoincrease":       "The net change in the number of unspent transaction outputs",
+	"getblockstatsresult-utxosizeinc":        "The net change in the serialized size of the unspent transaction outputs",
+	"getblockstatsresult-votes":              "The number of votes",
+	"getblockstatsresult-tickets":            "The number of ticket purchases",
+	"getblockstatsresult-revocations":        "The number of ticket revocations",
+	"getblockstatsresult-tspends":            "The number of treasury spends",
+	"getblockstatsresult-tadds":              "The number of treasury adds",
+	"getblockstatsresult-powsubsidy":         "The Proof-of-Work subsidy",
+	"getblockstatsresult-possubsidy":         "The Proof-of-Stake subsidy paid to the votes",
+	"getblockstatsresult-treasurysubsidy":    "The treasury subsidy",
+
 	// GetBlockSubsidyCmd help.
 	"getblocksubsidy--synopsis": "Returns information regarding subsidy amounts.",
 	"getblocksubsidy-height":    "The block height",
@@ -394,6 +535,16 @@ var helpDescsEnUS = map[string]string{
 	"getcurrentnet--synopsis": "Get Decred network the server is running on.",
 	"getcurrentnet--result0":  "The network identifier",
 
+	// GetDescriptorInfoCmd help.
+	"getdescriptorinfo--synopsis":  "Returns information about the provided output descriptor.",
+	"getdescriptorinfo-descriptor": "The output descriptor, optionally followed by its checksum",
+
+	// GetDescriptorInfoResult help.
+	"getdescriptorinforesult-descriptor":     "The canonical form of the descriptor along with its checksum with any extended private keys replaced by the associated extended public keys",
+	"getdescriptorinforesult-checksum":       "The checksum of the provided descriptor",
+	"getdescriptorinforesult-isrange":        "Whether or not the descriptor is ranged",
+	"getdescriptorinforesult-hasprivatekeys": "Whether or not the provided descriptor contains extended private keys",
+
 	// GetDifficultyCmd help.
 	"getdifficulty--synopsis": "Returns the proof-of-work difficulty as a multiple of the minimum difficulty.",
 	"getdifficulty--result0":  "The difficulty",
@@ -490,8 +641,11 @@ var helpDescsEnUS = map[string]string{
 	"getmempoolinfo--synopsis": "Returns memory pool information",
 
 	// GetMempoolInfoResult help.
-	"getmempoolinforesult-bytes": "Size in bytes of the mempool",
-	"getmempoolinforesult-size":  "Number of transactions in the mempool",
+	"getmempoolinforesult-bytes":         "Size in bytes of the mempool",
+	"getmempoolinforesult-size":          "Number of transactions in the mempool",
+	"getmempoolinforesult-maxmempool":    "Maximum size in bytes of the mempool (0 when unlimited)",
+	"getmempoolinforesult-mempoolminfee": "Minimum fee in DCR/kB for regular transactions and ticket purchases to be accepted, which is raised while the mempool is full",
+	"getmempoolinforesult-evicted":       "Total number of transactions evicted from the mempool due to its maximum size",
 
 	// GetMiningInfoResult help.
 	"getmininginforesult-blocks":           "Height of the latest best block",
@@ -516,6 +670,19 @@ var helpDescsEnUS = map[string]string{
 	"getnetworkhashps-height":    "Perform estimate ending with this height or -1 for current best chain block height",
 	"getnetworkhashps--result0":  "Estimated hashes per second",
 
+	// GetNodeAddressesCmd help.
+	"getnodeaddresses--synopsis": "Returns known good addresses of potential peers along with the network groups they are considered part of for the purposes of peer diversity.",
+	"getnodeaddresses-count":     "The maximum number of addresses to return or 0 for all available addresses",
+
+	// GetNodeAddressesResult help.
+	"getnodeaddressesresult-time":     "Time the address was last seen in seconds since 1 Jan 1970 GMT",
+	"getnodeaddressesresult-services": "Services bitmask which represents the services supported by the address",
+	"getnodeaddressesresult-address":  "The host of the address",
+	"getnodeaddressesresult-port":     "The port of the address",
+	"getnodeaddressesresult-network":  "The network of the address (ipv4, ipv6, torv2, torv3, i2p, or cjdns)",
+	"getnodeaddressesresult-group":    "The network group of the address which is the autonomous system in the form as:number when known and the network prefix otherwise",
+	"getnodeaddressesresult-asn":      "The autonomous system number of the address when an ASN map is loaded and contains it",
+
 	// GetNetworkInfoCmd help.
 	"getnetworkinfo--synopsis": "Returns a JSON object containing network-related information.",
 
@@ -567,6 +734,7 @@ var helpDescsEnUS = map[string]string{
 	"getpeerinforesult-version":        "The protocol version of the peer",
 	"getpeerinforesult-subver":         "The user agent of the peer",
 	"getpeerinforesult-inbound":        "Whether or not the peer is an inbound connection",
+	"getpeerinforesult-conntype":       "The type of the connection (inbound, manual, outbound-full-relay, or block-relay-only)",
 	"getpeerinforesult-startingheight": "The latest block height the peer knew about when the connection was established",
 	"getpeerinforesult-currentheight":  "The current height of the peer",
 	"getpeerinforesult-banscore":       "The ban score",
@@ -663,6 +831,25 @@ var helpDescsEnUS = map[string]string{
 	"gettxoutsetinforesult-disksize":       "The size of the utxo set on disk, in bytes.",
 	"gettxoutsetinforesult-totalamount":    "The total value of the utxo set.",
 
+	// GetTxSpendingPrevOutCmd help.
+	"gettxspendingprevout--synopsis": "Returns the transactions that spend the given outputs.\n" +
+		"The mempool is always checked while the main chain is only checked when the spend index is enabled (--spendindex).",
+	"gettxspendingprevout-outputs": "The outputs to look up the spending transactions of",
+
+	// TxSpendingPrevOut help.
+	"txspendingprevout-txid": "The hash of the transaction that contains the output",
+	"txspendingprevout-vout": "The index of the output",
+	"txspendingprevout-tree": "The tree of the transaction that contains the output",
+
+	// GetTxSpendingPrevOutResult help.
+	"gettxspendingprevoutresult-txid":         "The hash of the transaction that contains the output",
+	"gettxspendingprevoutresult-vout":         "The index of the output",
+	"gettxspendingprevoutresult-tree":         "The tree of the transaction that contains the output",
+	"gettxspendingprevoutresult-spendingtxid": "The hash of the transaction that spends the output (only when spent)",
+	"gettxspendingprevoutresult-spendingvin":  "The index of the input of the spending transaction that spends the output (only when spent)",
+	"gettxspendingprevoutresult-blockhash":    "The hash of the block that contains the spending transaction (only when confirmed)",
+	"gettxspendingprevoutresult-blockheight":  "The height of the block that contains the spending transaction (only when confirmed)",
+
 	// GetWorkResult help.
 	"getworkresult-data":     "Hex-encoded block data",
 	"getworkresult-hash1":    "(DEPRECATED) Hex-encoded formatted hash buffer",
@@ -701,6 +888,24 @@ var helpDescsEnUS = map[string]string{
 		"Any descendants that are neither themselves marked as having failed validation, nor descendants of another such block, are also made eligibile for best chain selection.",
 	"reconsiderblock-blockhash": "The hash of the block to reconsider",
 
+	// SaveMempoolCmd help.
+	"savemempool--synopsis":      "Saves the transactions in the mempool to the mempool file in the data directory so they can be loaded again with loadmempool or on startup.",
+	"savemempoolresult-filename": "The path of the mempool file",
+	"savemempoolresult-count":    "The number of transactions saved",
+
+	// SearchRawTransactionsCmd help.
+	"searchrawtransactions--synopsis": "Returns the transactions that involve the given address, including unconfirmed transactions in the mempool.\n" +
+		"Confirmed transactions are returned in the order they appear in the main chain followed by unconfirmed transactions in the order they were added to the mempool.\n" +
+		"NOTE: This requires the address index to be enabled (--addrindex).",
+	"searchrawtransactions-address":     "The address to look up transactions for",
+	"searchrawtransactions-verbose":     "Specifies the transactions are returned as JSON objects instead of hex-encoded strings",
+	"searchrawtransactions-skip":        "The number of leading transactions to skip",
+	"searchrawtransactions-count":       "The maximum number of transactions to return (max: 10000)",
+	"searchrawtransactions-reverse":     "Specifies the transactions are returned in reverse order so the most recent transactions are first",
+	"searchrawtransactions--condition0": "verbose=0",
+	"searchrawtransactions--condition1": "verbose=1",
+	"searchrawtransactions--result0":    "Hex-encoded bytes of the serialized transactions",
+
 	// SendRawTransactionCmd help.
 	"sendrawtransaction--synopsis":     "Submits the serialized, hex-encoded transaction to the local peer and relays it to the network.",
 	"sendrawtransaction-hextx":         "Serialized, hex-encoded signed transaction",
@@ -836,6 +1041,12 @@ var helpDescsEnUS = map[string]string{
 	"livetickets--synopsis":     "Returns live ticket hashes from the ticket database",
 	"liveticketsresult-tickets": "List of live tickets",
 
+	// LoadMempoolCmd help.
+	"loadmempool--synopsis":      "Loads the transactions from the mempool file in the data directory, which is written by savemempool and on shutdown, into the mempool.  The transactions are fully revalidated and ones that are no longer valid are rejected.",
+	"loadmempoolresult-filename": "The path of the mempool file",
+	"loadmempoolresult-accepted": "The number of transactions accepted into the mempool",
+	"loadmempoolresult-rejected": "The number of transactions that were rejected",
+
 	// TicketBuckets help.
 	"ticketbuckets--synopsis": "Request for the number of tickets currently in each bucket of the ticket database.",
 	"ticketbucket-tickets":    "Number of tickets in bucket.",
@@ -891,6 +1102,33 @@ var helpDescsEnUS = map[string]string{
 	"ticketvwap-end":       "The end height to begin calculating the VWAP from",
 	"ticketvwap--result0":  "The volume weighted average price",
 
+	// TraceTxInput help.
+	"tracetxinput--synopsis": "Executes the scripts of an input of a mempool or chain transaction with the standard script verification flags and returns a trace of every executed opcode.\n" +
+		"Transactions that are not in the mempool require the transaction index (--txindex).",
+	"tracetxinput-txid":               "The hash of the transaction",
+	"tracetxinput-vin":                "The index of the input to trace",
+	"tracetxinputresult-txid":         "The hash of the transaction",
+	"tracetxinputresult-vin":          "The index of the traced input",
+	"tracetxinputresult-amount":       "The amount of the previous output spent by the input",
+	"tracetxinputresult-scriptSig":    "The signature script of the input",
+	"tracetxinputresult-scriptPubKey": "The public key script of the previous output spent by the input",
+	"tracetxinputresult-steps":        "The executed opcodes in execution order",
+	"tracetxinputresult-truncated":    "Whether or not the steps were truncated due to the trace exceeding the maximum allowed size (only present when truncated)",
+	"tracetxinputresult-valid":        "Whether or not the scripts executed successfully",
+	"tracetxinputresult-error":        "The reason the scripts failed to execute (only present when not valid)",
+
+	// ScriptTraceStep help.
+	"scripttracestep-scriptidx":      "The index of the script that contains the opcode (0 = signature script, 1 = public key script, 2 = pay-to-script-hash redeem script)",
+	"scripttracestep-opcodeidx":      "The index of the opcode within the script",
+	"scripttracestep-opcode":         "Disassembly of the opcode",
+	"scripttracestep-stack":          "The hex-encoded data stack prior to executing the opcode with the last item being the top of the stack",
+	"scripttracestep-altstack":       "The hex-encoded alternate data stack prior to executing the opcode with the last item being the top of the stack",
+	"scripttracestep-condstack":      "Whether or not each conditional branch the opcode is nested within is executing starting with the outermost branch",
+	"scripttracestep-stackafter":     "The hex-encoded data stack after executing the opcode (null when the opcode failed)",
+	"scripttracestep-altstackafter":  "The hex-encoded alternate data stack after executing the opcode (null when the opcode failed)",
+	"scripttracestep-condstackafter": "The conditional branch execution state after executing the opcode (null when the opcode failed)",
+	"scripttracestep-error":          "The reason the opcode failed to execute (only present on failure)",
+
 	// TxFeeInfo help.
 	"txfeeinfo--synopsis":            "Get various information about regular transaction fees from the mempool, blocks, and difficulty windows",
 	"txfeeinfo-blocks":               "The number of blocks to calculate transaction fees for, starting from the end of the tip moving backwards",
@@ -922,12 +1160,16 @@ var helpDescsEnUS = map[string]string{
 // pointer to the type (or nil to indicate no return value).
 var rpcResultTypes = map[types.Method][]interface{}{
 	"addnode":               nil,
+	"clearbanned":           nil,
 	"createrawsstx":         {(*string)(nil)},
 	"createrawssrtx":        {(*string)(nil)},
+	"combinepsdt":           {(*string)(nil)},
 	"createrawtransaction":  {(*string)(nil)},
 	"debuglevel":            {(*string)(nil), (*string)(nil)},
+	"decodepsdt":            {(*types.DecodePSDTResult)(nil)},
 	"decoderawtransaction":  {(*types.TxRawDecodeResult)(nil)},
 	"decodescript":          {(*types.DecodeScriptResult)(nil)},
+	"deriveaddresses":       {(*[]string)(nil)},
 	"estimatefee":           {(*float64)(nil)},
 	"estimatesmartfee":      {(*types.EstimateSmartFeeResult)(nil)},
 	"estimatestakediff":     {(*types.EstimateStakeDiffResult)(nil)},
@@ -937,7 +1179,9 @@ var rpcResultTypes = map[types.Method][]interface{}{
 	"existslivetickets":     {(*string)(nil)},
 	"existsmempooltxs":      {(*string)(nil)},
 	"getaddednodeinfo":      {(*[]string)(nil), (*[]types.GetAddedNodeInfoResult)(nil)},
+	"getaddresstxids":       {(*[]string)(nil)},
 	"getbestblock":          {(*types.GetBestBlockResult)(nil)},
+	"finalizepsdt":          {(*types.FinalizePSDTResult)(nil)},
 	"generate":              {(*[]string)(nil)},
 	"getbestblockhash":      {(*string)(nil)},
 	"getblock":              {(*string)(nil), (*types.GetBlockVerboseResult)(nil)},
@@ -945,11 +1189,13 @@ var rpcResultTypes = map[types.Method][]interface{}{
 	"getblockcount":         {(*int64)(nil)},
 	"getblockhash":          {(*string)(nil)},
 	"getblockheader":        {(*string)(nil), (*types.GetBlockHeaderVerboseResult)(nil)},
+	"getblockstats":         {(*types.GetBlockStatsResult)(nil)},
 	"getblocksubsidy":       {(*types.GetBlockSubsidyResult)(nil)},
 	"getcfilterv2":          {(*types.GetCFilterV2Result)(nil)},
 	"getchaintips":          {(*[]types.GetChainTipsResult)(nil)},
 	"getconnectioncount":    {(*int32)(nil)},
 	"getcurrentnet":         {(*uint32)(nil)},
+	"getdescriptorinfo":     {(*types.GetDescriptorInfoResult)(nil)},
 	"getdifficulty":         {(*float64)(nil)},
 	"getstakedifficulty":    {(*types.GetStakeDifficultyResult)(nil)},
 	"getstakeversioninfo":   {(*types.GetStakeVersionInfoResult)(nil)},
@@ -963,6 +1209,7 @@ var rpcResultTypes = map[types.Method][]interface{}{
 	"getnettotals":          {(*types.GetNetTotalsResult)(nil)},
 	"getnetworkhashps":      {(*int64)(nil)},
 	"getnetworkinfo":        {(*[]types.GetNetworkInfoResult)(nil)},
+	"getnodeaddresses":      {(*[]types.GetNodeAddressesResult)(nil)},
 	"getpeerinfo":           {(*[]types.GetPeerInfoResult)(nil)},
 	"getrawmempool":         {(*[]string)(nil), (*types.GetRawMempoolVerboseResult)(nil)},
 	"getrawtransaction":     {(*string)(nil), (*types.TxRawResult)(nil)},
@@ -971,23 +1218,30 @@ var rpcResultTypes = map[types.Method][]interface{}{
 	"gettreasuryspendvotes": {(*types.GetTreasurySpendVotesResult)(nil)},
 	"gettxout":              {(*types.GetTxOutResult)(nil)},
 	"gettxoutsetinfo":       {(*types.GetTxOutSetInfoResult)(nil)},
+	"gettxspendingprevout":  {(*[]types.GetTxSpendingPrevOutResult)(nil)},
 	"getvoteinfo":           {(*types.GetVoteInfoResult)(nil)},
 	"getwork":               {(*types.GetWorkResult)(nil), (*bool)(nil)},
 	"getcoinsupply":         {(*int64)(nil)},
 	"help":                  {(*string)(nil), (*string)(nil)},
 	"invalidateblock":       nil,
+	"listbanned":            {(*[]types.ListBannedResult)(nil)},
 	"livetickets":           {(*types.LiveTicketsResult)(nil)},
+	"loadmempool":           {(*types.LoadMempoolResult)(nil)},
 	"node":                  nil,
 	"ping":                  nil,
 	"reconsiderblock":       nil,
 	"regentemplate":         nil,
+	"savemempool":           {(*types.SaveMempoolResult)(nil)},
+	"searchrawtransactions": {(*[]string)(nil), (*[]types.TxRawResult)(nil)},
 	"sendrawtransaction":    {(*string)(nil)},
+	"setban":                nil,
 	"setgenerate":           nil,
 	"stop":                  {(*string)(nil)},
 	"submitblock":           {nil, (*string)(nil)},
 	"ticketfeeinfo":         {(*types.TicketFeeInfoResult)(nil)},
 	"ticketsforaddress":     {(*types.TicketsForAddressResult)(nil)},
 	"ticketvwap":            {(*float64)(nil)},
+	"tracetxinput":          {(*types.TraceTxInputResult)(nil)},
 	"txfeeinfo":             {(*types.TxFeeInfoResult)(nil)},
 	"validateaddress":       {(*types.ValidateAddressChainResult)(nil)},
 	"verifychain":           {(*bool)(nil)},
@@ -1,5 +1,5 @@
 // Copyright (c) 2013-2016 The btcsuite developers
-// Copyright (c) 2015-2023 The Decred developers
+// Copyright (c) 2015-2024 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
@@ -1667,7 +1667,10 @@ out:
 						var resp interface{}
 						wsHandler, ok := wsHandlers[cmd.method]
 						if ok {
-							resp, err = wsHandler(ctx, c, cmd.params)
+							err = c.rpcServer.checkLightMode(cmd.method)
+							if err == nil {
+								resp, err = wsHandler(ctx, c, cmd.params)
+							}
 						} else {
 							resp, err = c.rpcServer.standardCmdResult(ctx,
 								cmd)
@@ -1738,7 +1741,10 @@ func (c *wsClient) serviceRequest(ctx context.Context, r *parsedRPCCmd) {
 	// exist fallback to handling the command as a standard command.
 	wsHandler, ok := wsHandlers[r.method]
 	if ok {
-		result, err = wsHandler(ctx, c, r.params)
+		err = c.rpcServer.checkLightMode(r.method)
+		if err == nil {
+			result, err = wsHandler(ctx, c, r.params)
+		}
 	} else {
 		result, err = c.rpcServer.standardCmdResult(ctx, r)
 	}
@@ -2290,6 +2296,9 @@ func handleRescan(_ context.Context, wsc *wsClient, icmd interface{}) (interface
 	for i := range blockHashes {
 		block, err := bc.BlockByHash(&blockHashes[i])
 		if err != nil {
+			if errors.Is(err, blockchain.ErrBlockPruned) {
+				return nil, rpcBlockPrunedError()
+			}
 			return nil, &dcrjson.RPCError{
 				Code:    dcrjson.ErrRPCBlockNotFound,
 				Message: "Failed to fetch block: " + err.Error(),
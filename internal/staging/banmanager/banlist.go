@@ -0,0 +1,272 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package banmanager
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// banListVersion is the current version of the serialization format used to
+// persist the ban list.
+const banListVersion = 1
+
+// ErrNotBanned indicates an attempt to remove a subnet from the ban list that
+// is not banned.
+var ErrNotBanned = errors.New("subnet is not banned")
+
+// BanEntry describes a banned subnet along with the time it was banned and the
+// time the ban expires.
+type BanEntry struct {
+	Subnet  *net.IPNet
+	Created time.Time
+	Expires time.Time
+}
+
+// serializedBanEntry is the JSON representation of a ban list entry.
+type serializedBanEntry struct {
+	Subnet  string `json:"subnet"`
+	Created int64  `json:"created"`
+	Expires int64  `json:"expires"`
+}
+
+// serializedBanList is the JSON representation of the ban list.
+type serializedBanList struct {
+	Version int                  `json:"version"`
+	Bans    []serializedBanEntry `json:"bans"`
+}
+
+// BanList houses a set of banned IP subnets along with the times their bans
+// expire.  It is optionally backed by a file on disk so that bans persist
+// across restarts.
+//
+// All methods are safe for concurrent access.
+type BanList struct {
+	mtx     sync.Mutex
+	path    string
+	entries map[string]*BanEntry
+}
+
+// NewBanList returns a new empty ban list that is persisted to the file at the
+// provided path whenever it is modified.  An empty path disables persistence.
+// Load must be called to read any previously persisted bans.
+func NewBanList(path string) *BanList {
+	return &BanList{
+		path:    path,
+		entries: make(map[string]*BanEntry),
+	}
+}
+
+// ParseSubnet parses the provided string as either a single IP address or a
+// subnet in CIDR notation.  Single IP addresses are converted to a subnet that
+// only contains that address.
+func ParseSubnet(s string) (*net.IPNet, error) {
+	if strings.Contains(s, "/") {
+		_, subnet, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, err
+		}
+		return subnet, nil
+	}
+
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP address or subnet %q", s)
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+}
+
+// pruneExpired removes all entries with bans that have expired as of the
+// provided time and returns whether or not any were removed.
+//
+// This function MUST be called with the ban list mutex held.
+func (bl *BanList) pruneExpired(now time.Time) bool {
+	var pruned bool
+	for key, entry := range bl.entries {
+		if !now.Before(entry.Expires) {
+			log.Infof("Ban for %s expired", key)
+			delete(bl.entries, key)
+			pruned = true
+		}
+	}
+	return pruned
+}
+
+// save writes the ban list to its backing file when one is configured.  The
+// file is written atomically by first writing to a temporary file and then
+// moving it into place.
+//
+// This function MUST be called with the ban list mutex held.
+func (bl *BanList) save() error {
+	if bl.path == "" {
+		return nil
+	}
+
+	sbl := serializedBanList{
+		Version: banListVersion,
+		Bans:    make([]serializedBanEntry, 0, len(bl.entries)),
+	}
+	for key, entry := range bl.entries {
+		sbl.Bans = append(sbl.Bans, serializedBanEntry{
+			Subnet:  key,
+			Created: entry.Created.Unix(),
+			Expires: entry.Expires.Unix(),
+		})
+	}
+	sort.Slice(sbl.Bans, func(i, j int) bool {
+		return sbl.Bans[i].Subnet < sbl.Bans[j].Subnet
+	})
+	serialized, err := json.Marshal(&sbl)
+	if err != nil {
+		return err
+	}
+
+	tmpPath := bl.path + ".new"
+	if err := os.WriteFile(tmpPath, serialized, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, bl.path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// Load replaces the contents of the ban list with the bans persisted in its
+// backing file.  A missing file is not treated as an error and results in an
+// empty ban list.  Bans that have already expired are discarded.
+func (bl *BanList) Load() error {
+	if bl.path == "" {
+		return nil
+	}
+
+	serialized, err := os.ReadFile(bl.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var sbl serializedBanList
+	if err := json.Unmarshal(serialized, &sbl); err != nil {
+		return fmt.Errorf("unable to decode %s: %w", bl.path, err)
+	}
+	if sbl.Version != banListVersion {
+		return fmt.Errorf("unsupported ban list version %d in %s",
+			sbl.Version, bl.path)
+	}
+
+	entries := make(map[string]*BanEntry, len(sbl.Bans))
+	for _, sbe := range sbl.Bans {
+		subnet, err := ParseSubnet(sbe.Subnet)
+		if err != nil {
+			return fmt.Errorf("invalid ban list entry in %s: %w", bl.path,
+				err)
+		}
+		entries[subnet.String()] = &BanEntry{
+			Subnet:  subnet,
+			Created: time.Unix(sbe.Created, 0),
+			Expires: time.Unix(sbe.Expires, 0),
+		}
+	}
+
+	bl.mtx.Lock()
+	bl.entries = entries
+	bl.pruneExpired(time.Now())
+	numBans := len(bl.entries)
+	bl.mtx.Unlock()
+
+	log.Infof("Loaded %d bans from %s", numBans, bl.path)
+	return nil
+}
+
+// Ban adds the provided subnet to the ban list until the provided expiration
+// time.  Banning a subnet that is already banned replaces the existing ban.
+func (bl *BanList) Ban(subnet *net.IPNet, created, expires time.Time) error {
+	key := subnet.String()
+
+	bl.mtx.Lock()
+	defer bl.mtx.Unlock()
+	bl.pruneExpired(created)
+	bl.entries[key] = &BanEntry{
+		Subnet:  subnet,
+		Created: created,
+		Expires: expires,
+	}
+	return bl.save()
+}
+
+// Unban removes the provided subnet from the ban list.  ErrNotBanned is
+// returned when the subnet is not banned.
+func (bl *BanList) Unban(subnet *net.IPNet) error {
+	key := subnet.String()
+
+	bl.mtx.Lock()
+	defer bl.mtx.Unlock()
+	if _, ok := bl.entries[key]; !ok {
+		return ErrNotBanned
+	}
+	delete(bl.entries, key)
+	return bl.save()
+}
+
+// Clear removes all entries from the ban list.
+func (bl *BanList) Clear() error {
+	bl.mtx.Lock()
+	defer bl.mtx.Unlock()
+	bl.entries = make(map[string]*BanEntry)
+	return bl.save()
+}
+
+// IsBanned returns whether or not the provided IP address is contained in any
+// of the subnets in the ban list with a ban that has not expired as of the
+// provided time along with the latest time the applicable bans expire.
+func (bl *BanList) IsBanned(ip net.IP, now time.Time) (time.Time, bool) {
+	if ip == nil {
+		return time.Time{}, false
+	}
+
+	var banEnd time.Time
+	bl.mtx.Lock()
+	for _, entry := range bl.entries {
+		if now.Before(entry.Expires) && entry.Subnet.Contains(ip) &&
+			entry.Expires.After(banEnd) {
+
+			banEnd = entry.Expires
+		}
+	}
+	bl.mtx.Unlock()
+
+	return banEnd, !banEnd.IsZero()
+}
+
+// Entries returns the entries in the ban list with bans that have not expired
+// as of the provided time sorted by subnet.
+func (bl *BanList) Entries(now time.Time) []BanEntry {
+	bl.mtx.Lock()
+	entries := make([]BanEntry, 0, len(bl.entries))
+	for _, entry := range bl.entries {
+		if now.Before(entry.Expires) {
+			entries = append(entries, *entry)
+		}
+	}
+	bl.mtx.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Subnet.String() < entries[j].Subnet.String()
+	})
+	return entries
+}
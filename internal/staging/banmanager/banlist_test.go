@@ -0,0 +1,177 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package banmanager
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestParseSubnet ensures single IP addresses and subnets in CIDR notation are
+// parsed as expected and that invalid input is rejected.
+func TestParseSubnet(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{{
+		name:  "single IPv4 address",
+		input: "10.0.0.1",
+		want:  "10.0.0.1/32",
+	}, {
+		name:  "single IPv6 address",
+		input: "2001:db8::1",
+		want:  "2001:db8::1/128",
+	}, {
+		name:  "IPv4 subnet with host bits set",
+		input: "10.0.0.1/24",
+		want:  "10.0.0.0/24",
+	}, {
+		name:  "IPv6 subnet",
+		input: "2001:db8::/32",
+		want:  "2001:db8::/32",
+	}, {
+		name:    "hostname",
+		input:   "example.com",
+		wantErr: true,
+	}, {
+		name:    "invalid prefix length",
+		input:   "10.0.0.0/33",
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		subnet, err := ParseSubnet(test.input)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%q: did not receive expected error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", test.name, err)
+			continue
+		}
+		if subnet.String() != test.want {
+			t.Errorf("%q: unexpected subnet: got %s, want %s", test.name,
+				subnet, test.want)
+		}
+	}
+}
+
+// TestBanList ensures banning, unbanning, expiration, and persistence of the
+// ban list work as expected.
+func TestBanList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "banlist.json")
+	bl := NewBanList(path)
+
+	// Ensure loading a missing file results in an empty ban list.
+	if err := bl.Load(); err != nil {
+		t.Fatalf("Load: unexpected error for missing file: %v", err)
+	}
+	now := time.Unix(time.Now().Unix(), 0)
+	if entries := bl.Entries(now); len(entries) != 0 {
+		t.Fatalf("unexpected number of entries: got %d, want 0", len(entries))
+	}
+
+	// Ban a subnet and a single address and ensure addresses are reported as
+	// banned accordingly.
+	subnet, _ := ParseSubnet("10.0.0.0/24")
+	single, _ := ParseSubnet("192.168.1.1")
+	if err := bl.Ban(subnet, now, now.Add(time.Hour)); err != nil {
+		t.Fatalf("Ban: unexpected error: %v", err)
+	}
+	if err := bl.Ban(single, now, now.Add(2*time.Hour)); err != nil {
+		t.Fatalf("Ban: unexpected error: %v", err)
+	}
+	checks := []struct {
+		ip        string
+		at        time.Time
+		wantBan   bool
+		wantUntil time.Time
+	}{
+		{"10.0.0.1", now, true, now.Add(time.Hour)},
+		{"10.0.0.255", now, true, now.Add(time.Hour)},
+		{"10.0.1.1", now, false, time.Time{}},
+		{"192.168.1.1", now, true, now.Add(2 * time.Hour)},
+		{"192.168.1.2", now, false, time.Time{}},
+		{"10.0.0.1", now.Add(time.Hour), false, time.Time{}},
+		{"192.168.1.1", now.Add(time.Hour), true, now.Add(2 * time.Hour)},
+	}
+	for _, check := range checks {
+		until, banned := bl.IsBanned(net.ParseIP(check.ip), check.at)
+		if banned != check.wantBan || !until.Equal(check.wantUntil) {
+			t.Fatalf("IsBanned(%s, %v): got (%v, %v), want (%v, %v)",
+				check.ip, check.at, until, banned, check.wantUntil,
+				check.wantBan)
+		}
+	}
+	entries := bl.Entries(now.Add(time.Hour))
+	if len(entries) != 1 || entries[0].Subnet.String() != "192.168.1.1/32" {
+		t.Fatalf("unexpected entries after expiration: %v", entries)
+	}
+
+	// Ensure the bans are persisted.
+	bl2 := NewBanList(path)
+	if err := bl2.Load(); err != nil {
+		t.Fatalf("Load: unexpected error: %v", err)
+	}
+	entries = bl2.Entries(now)
+	if len(entries) != 2 {
+		t.Fatalf("unexpected number of loaded entries: got %d, want 2",
+			len(entries))
+	}
+	if entries[0].Subnet.String() != "10.0.0.0/24" ||
+		!entries[0].Created.Equal(now) ||
+		!entries[0].Expires.Equal(now.Add(time.Hour)) {
+
+		t.Fatalf("unexpected loaded entry: %+v", entries[0])
+	}
+
+	// Ensure unbanning works and attempting to unban a subnet that is not
+	// banned returns the expected error.
+	if err := bl2.Unban(subnet); err != nil {
+		t.Fatalf("Unban: unexpected error: %v", err)
+	}
+	if _, banned := bl2.IsBanned(net.ParseIP("10.0.0.1"), now); banned {
+		t.Fatal("IsBanned: address still banned after unban")
+	}
+	if err := bl2.Unban(subnet); !errors.Is(err, ErrNotBanned) {
+		t.Fatalf("Unban: unexpected error: got %v, want %v", err,
+			ErrNotBanned)
+	}
+
+	// Ensure clearing the ban list removes all entries and is persisted.
+	if err := bl2.Clear(); err != nil {
+		t.Fatalf("Clear: unexpected error: %v", err)
+	}
+	bl3 := NewBanList(path)
+	if err := bl3.Load(); err != nil {
+		t.Fatalf("Load: unexpected error: %v", err)
+	}
+	if entries := bl3.Entries(now); len(entries) != 0 {
+		t.Fatalf("unexpected number of entries after clear: got %d, want 0",
+			len(entries))
+	}
+
+	// Ensure invalid files are rejected.
+	if err := os.WriteFile(path, []byte(`{"version":2}`), 0600); err != nil {
+		t.Fatalf("unable to write file: %v", err)
+	}
+	if err := NewBanList(path).Load(); err == nil {
+		t.Fatal("Load: did not error on unsupported version")
+	}
+	if err := os.WriteFile(path, []byte("{"), 0600); err != nil {
+		t.Fatalf("unable to write file: %v", err)
+	}
+	if err := NewBanList(path).Load(); err == nil {
+		t.Fatal("Load: did not error on malformed file")
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/decred/dcrd/internal/mining/cpuminer"
 	"github.com/decred/dcrd/internal/netsync"
 	"github.com/decred/dcrd/internal/rpcserver"
+	"github.com/decred/dcrd/internal/staging/banmanager"
 	"github.com/decred/dcrd/peer/v3"
 	"github.com/decred/dcrd/txscript/v4"
 	"github.com/decred/slog"
@@ -81,6 +82,7 @@ var (
 // Initialize package-global logger variables.
 func init() {
 	addrmgr.UseLogger(amgrLog)
+	banmanager.UseLogger(srvrLog)
 	blockchain.UseLogger(chanLog)
 	blockchain.UseTreasuryLogger(trsyLog)
 	connmgr.UseLogger(cmgrLog)
@@ -0,0 +1,317 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/decred/dcrd/internal/blockchain"
+	"github.com/decred/dcrd/internal/metrics"
+	"github.com/decred/dcrd/internal/rpcserver"
+	"github.com/decred/dcrd/rpc/jsonrpc/types/v4"
+)
+
+const (
+	// metricsPath is the HTTP path the metrics are served on.
+	metricsPath = "/metrics"
+
+	// metricsReadTimeout is the maximum amount of time to wait for clients of
+	// the metrics server to send their request.
+	metricsReadTimeout = time.Second * 10
+)
+
+var (
+	// durationBuckets are the upper bounds, in seconds, of the buckets used by
+	// the histograms that track durations.
+	durationBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1,
+		0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+	// feeRateBuckets are the upper bounds, in atoms/kB, of the buckets used by
+	// the histogram that tracks the fee rates of the transactions in the
+	// mempool.
+	feeRateBuckets = []float64{1e4, 2e4, 5e4, 1e5, 2e5, 5e5, 1e6, 1e7}
+)
+
+// serverMetrics houses the metrics of the server along with the registry that
+// they are registered with.
+type serverMetrics struct {
+	registry *metrics.Registry
+
+	// The following fields are the metrics that are updated as the
+	// associated events take place.  All other metrics are obtained from the
+	// various subsystems each time the metrics are requested.
+	blockValidationTime *metrics.Histogram
+	blockConnectionTime *metrics.Histogram
+	utxoCacheFlushTime  *metrics.Histogram
+	rpcRequestTime      *metrics.HistogramVec
+}
+
+// Ensure serverMetrics implements the rpcserver.RequestObserver interface.
+var _ rpcserver.RequestObserver = (*serverMetrics)(nil)
+
+// newServerMetrics returns a new instance of the server metrics with the
+// metrics that are updated as the associated events take place registered.
+// The remaining metrics are registered via registerServer once the server has
+// been created.
+func newServerMetrics() *serverMetrics {
+	r := metrics.NewRegistry()
+	return &serverMetrics{
+		registry: r,
+		blockValidationTime: r.NewHistogram(
+			"dcrd_block_validation_duration_seconds",
+			"Time spent fully validating blocks connected to the main chain.",
+			durationBuckets),
+		blockConnectionTime: r.NewHistogram(
+			"dcrd_block_connect_duration_seconds",
+			"Time spent connecting blocks to the main chain.",
+			durationBuckets),
+		utxoCacheFlushTime: r.NewHistogram(
+			"dcrd_utxocache_flush_duration_seconds",
+			"Time spent flushing the UTXO cache to the database.",
+			durationBuckets),
+		rpcRequestTime: r.NewHistogramVec(
+			"dcrd_rpc_request_duration_seconds",
+			"Time spent handling RPC requests by method.", "method",
+			durationBuckets),
+	}
+}
+
+// onBlockConnectTimings records the provided block validation and connection
+// times.  Blocks that were not validated while being connected do not count
+// towards the validation time.
+func (m *serverMetrics) onBlockConnectTimings(validation, connection time.Duration) {
+	if validation > 0 {
+		m.blockValidationTime.ObserveDuration(validation)
+	}
+	m.blockConnectionTime.ObserveDuration(connection)
+}
+
+// onUtxoCacheFlush records the provided UTXO cache flush time.
+func (m *serverMetrics) onUtxoCacheFlush(duration time.Duration) {
+	m.utxoCacheFlushTime.ObserveDuration(duration)
+}
+
+// ObserveRequest records the provided time spent handling an RPC request for
+// the provided method.
+//
+// This function is safe for concurrent access and is part of the
+// rpcserver.RequestObserver interface implementation.
+func (m *serverMetrics) ObserveRequest(method types.Method, duration time.Duration) {
+	m.rpcRequestTime.With(string(method)).ObserveDuration(duration)
+}
+
+// formatFeeRateLabel returns the provided fee rate formatted for use as a
+// label value.
+func formatFeeRateLabel(feeRate float64) string {
+	if math.IsInf(feeRate, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(feeRate, 'f', -1, 64)
+}
+
+// registerServer registers the metrics that are obtained from the various
+// subsystems of the provided server each time the metrics are requested.
+//
+// The provided context is used to stop querying the server for its connected
+// peers once it is shutting down.
+func (m *serverMetrics) registerServer(ctx context.Context, s *server, utxoCache *blockchain.UtxoCache) {
+	r := m.registry
+
+	// Peer metrics.
+	r.NewGaugeVecFunc("dcrd_peers", "Number of connected peers by direction.",
+		[]string{"direction"}, func() []metrics.LabeledValue {
+			replyChan := make(chan []*serverPeer, 1)
+			select {
+			case s.query <- getPeersMsg{reply: replyChan}:
+			case <-ctx.Done():
+				return nil
+			}
+			var inbound, outbound float64
+			for _, sp := range <-replyChan {
+				if sp.Inbound() {
+					inbound++
+				} else {
+					outbound++
+				}
+			}
+			return []metrics.LabeledValue{
+				{LabelValues: []string{"inbound"}, Value: inbound},
+				{LabelValues: []string{"outbound"}, Value: outbound},
+			}
+		})
+	r.NewCounterFunc("dcrd_peer_received_bytes_total",
+		"Total number of bytes received from all peers.", func() float64 {
+			received, _ := s.NetTotals()
+			return float64(received)
+		})
+	r.NewCounterFunc("dcrd_peer_sent_bytes_total",
+		"Total number of bytes sent to all peers.", func() float64 {
+			_, sent := s.NetTotals()
+			return float64(sent)
+		})
+
+	// Chain and sync metrics.
+	r.NewGaugeFunc("dcrd_chain_height",
+		"Height of the current best chain tip.", func() float64 {
+			return float64(s.chain.BestSnapshot().Height)
+		})
+	r.NewGaugeFunc("dcrd_chain_header_height",
+		"Height of the best known block header.", func() float64 {
+			_, height := s.chain.BestHeader()
+			return float64(height)
+		})
+	r.NewGaugeFunc("dcrd_sync_current",
+		"Whether the chain is believed to be synced with the network.",
+		func() float64 {
+			if s.syncManager.IsCurrent() {
+				return 1
+			}
+			return 0
+		})
+
+	// Mempool metrics.
+	r.NewGaugeFunc("dcrd_mempool_txs",
+		"Number of transactions in the mempool.", func() float64 {
+			return float64(s.txMemPool.Count())
+		})
+	r.NewHistogramFunc("dcrd_mempool_fee_rate_atoms_per_kb",
+		"Fee rates of the transactions in the mempool in atoms/kB.",
+		func() *metrics.HistogramSnapshot {
+			snap := metrics.NewHistogramSnapshot(feeRateBuckets)
+			for _, txDesc := range s.txMemPool.TxDescs() {
+				if txDesc.TxSize <= 0 {
+					continue
+				}
+				snap.Observe(float64(txDesc.Fee) * 1000 /
+					float64(txDesc.TxSize))
+			}
+			return snap
+		})
+
+	// UTXO cache metrics.
+	r.NewCounterFunc("dcrd_utxocache_hits_total",
+		"Total number of UTXO cache lookups that resulted in a hit.",
+		func() float64 {
+			return float64(utxoCache.Stats().Hits)
+		})
+	r.NewCounterFunc("dcrd_utxocache_misses_total",
+		"Total number of UTXO cache lookups that resulted in a miss.",
+		func() float64 {
+			return float64(utxoCache.Stats().Misses)
+		})
+	r.NewGaugeFunc("dcrd_utxocache_hit_ratio",
+		"Ratio of UTXO cache lookups that resulted in a hit.",
+		func() float64 {
+			stats := utxoCache.Stats()
+			lookups := stats.Hits + stats.Misses
+			if lookups == 0 {
+				return 1
+			}
+			return float64(stats.Hits) / float64(lookups)
+		})
+	r.NewGaugeFunc("dcrd_utxocache_entries",
+		"Number of entries in the UTXO cache.", func() float64 {
+			return float64(utxoCache.Stats().Entries)
+		})
+	r.NewGaugeFunc("dcrd_utxocache_size_bytes",
+		"Total size of the UTXO cache in bytes.", func() float64 {
+			return float64(utxoCache.Stats().Size)
+		})
+	r.NewGaugeFunc("dcrd_utxocache_max_size_bytes",
+		"Maximum allowed size of the UTXO cache in bytes.", func() float64 {
+			return float64(utxoCache.Stats().MaxSize)
+		})
+
+	// Fee estimator metrics.
+	r.NewGaugeVecFunc("dcrd_fee_estimator_confirmed_txs",
+		"Exponentially decayed number of mined transactions tracked by the "+
+			"fee estimator by fee rate bucket upper bound in atoms/kB.",
+		[]string{"feerate"}, func() []metrics.LabeledValue {
+			buckets := s.feeEstimator.BucketStats()
+			values := make([]metrics.LabeledValue, 0, len(buckets))
+			for _, bucket := range buckets {
+				values = append(values, metrics.LabeledValue{
+					LabelValues: []string{formatFeeRateLabel(bucket.FeeRate)},
+					Value:       bucket.ConfirmedTxs,
+				})
+			}
+			return values
+		})
+	r.NewGaugeVecFunc("dcrd_fee_estimator_mempool_txs",
+		"Number of mempool transactions tracked by the fee estimator by fee "+
+			"rate bucket upper bound in atoms/kB.",
+		[]string{"feerate"}, func() []metrics.LabeledValue {
+			buckets := s.feeEstimator.BucketStats()
+			values := make([]metrics.LabeledValue, 0, len(buckets))
+			for _, bucket := range buckets {
+				values = append(values, metrics.LabeledValue{
+					LabelValues: []string{formatFeeRateLabel(bucket.FeeRate)},
+					Value:       bucket.MemPoolTxs,
+				})
+			}
+			return values
+		})
+}
+
+// setupMetricsListeners returns a slice of listeners that are configured for
+// use with the metrics server depending on the configuration settings for
+// listen addresses.
+func setupMetricsListeners() ([]net.Listener, error) {
+	netAddrs, err := parseListeners(cfg.MetricsListeners)
+	if err != nil {
+		return nil, err
+	}
+
+	listeners := make([]net.Listener, 0, len(netAddrs))
+	for _, addr := range netAddrs {
+		listener, err := net.Listen(addr.Network(), addr.String())
+		if err != nil {
+			srvrLog.Warnf("Can't listen on %s: %v", addr, err)
+			continue
+		}
+		listeners = append(listeners, listener)
+	}
+
+	return listeners, nil
+}
+
+// runMetricsServer serves the metrics on the provided listeners until the
+// provided context is cancelled at which point the listeners are closed.
+func runMetricsServer(ctx context.Context, m *serverMetrics, listeners []net.Listener) {
+	mux := http.NewServeMux()
+	mux.Handle(metricsPath, m.registry)
+	httpServer := &http.Server{
+		Handler:     mux,
+		ReadTimeout: metricsReadTimeout,
+	}
+
+	var wg sync.WaitGroup
+	for _, listener := range listeners {
+		wg.Add(1)
+		go func(listener net.Listener) {
+			srvrLog.Infof("Metrics server listening on %s", listener.Addr())
+			err := httpServer.Serve(listener)
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				srvrLog.Errorf("Metrics server on %s failed: %v",
+					listener.Addr(), err)
+			}
+			srvrLog.Tracef("Metrics listener done for %s", listener.Addr())
+			wg.Done()
+		}(listener)
+	}
+
+	<-ctx.Done()
+	if err := httpServer.Close(); err != nil {
+		srvrLog.Errorf("Unable to close metrics server: %v", err)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,401 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package peer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/wire"
+)
+
+const (
+	// captureFormatVersion is the current version of the capture file
+	// format.  See the Message Capture section of the package documentation
+	// for details regarding the format.
+	captureFormatVersion = 1
+
+	// captureFileExt is the extension of capture files.
+	captureFileExt = ".dcap"
+
+	// DefaultCaptureMaxFileSize is the default maximum size of a capture file
+	// before it is rotated.
+	DefaultCaptureMaxFileSize = 10 * 1024 * 1024 // 10 MiB
+
+	// DefaultCaptureMaxFiles is the default maximum number of capture files
+	// that are retained for each peer.
+	DefaultCaptureMaxFiles = 5
+)
+
+// captureMagic identifies capture files.
+var captureMagic = [4]byte{'d', 'c', 'a', 'p'}
+
+// CaptureDirection identifies whether a captured message was sent to or
+// received from the remote peer.
+type CaptureDirection uint8
+
+// These constants define the directions of captured messages.
+const (
+	// CaptureReceived indicates the message was received from the remote
+	// peer.
+	CaptureReceived CaptureDirection = 0
+
+	// CaptureSent indicates the message was sent to the remote peer.
+	CaptureSent CaptureDirection = 1
+)
+
+// Map of CaptureDirection values back to their names for pretty printing.
+var captureDirectionStrings = map[CaptureDirection]string{
+	CaptureReceived: "received",
+	CaptureSent:     "sent",
+}
+
+// String returns the CaptureDirection in human-readable form.
+func (d CaptureDirection) String() string {
+	if s, ok := captureDirectionStrings[d]; ok {
+		return s
+	}
+	return fmt.Sprintf("Unknown CaptureDirection (%d)", uint8(d))
+}
+
+// captureFile writes the messages sent to and received from a remote peer to
+// capture files that are rotated once they reach a maximum size.  Only the
+// most recent files, up to a maximum number of them, are retained.
+//
+// It is safe for concurrent access.
+type captureFile struct {
+	mtx         sync.Mutex
+	dir         string
+	baseName    string
+	net         wire.CurrencyNet
+	inbound     bool
+	addr        string
+	maxFileSize int64
+	maxFiles    int
+	file        *os.File
+	fileSize    int64
+	seq         int
+	closed      bool
+}
+
+// captureFileBaseName returns the base name for the capture files of a peer
+// with the provided address, direction, and connection time.  Characters that
+// are not suitable for file names are replaced.
+func captureFileBaseName(addr string, inbound bool, connTime time.Time) string {
+	sanitized := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9',
+			r == '.', r == '-':
+			return r
+		}
+		return '_'
+	}, addr)
+	return fmt.Sprintf("%s_%s_%d", sanitized, directionString(inbound),
+		connTime.UnixNano())
+}
+
+// newCaptureFile creates the first capture file for a peer with the provided
+// address in the provided directory and returns a captureFile that writes to
+// it.  The directory is created if needed.
+func newCaptureFile(dir, addr string, inbound bool, net wire.CurrencyNet, maxFileSize int64, maxFiles int) (*captureFile, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	c := &captureFile{
+		dir:         dir,
+		baseName:    captureFileBaseName(addr, inbound, time.Now()),
+		net:         net,
+		inbound:     inbound,
+		addr:        addr,
+		maxFileSize: maxFileSize,
+		maxFiles:    maxFiles,
+	}
+	if err := c.openFile(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// filePath returns the path of the capture file with the provided sequence
+// number.
+func (c *captureFile) filePath(seq int) string {
+	name := fmt.Sprintf("%s.%03d%s", c.baseName, seq, captureFileExt)
+	return filepath.Join(c.dir, name)
+}
+
+// openFile removes the oldest capture file when the max number of files would
+// otherwise be exceeded, creates the capture file for the current sequence
+// number, and writes the header to it.
+//
+// This function MUST be called with the capture mutex held (for writes) or
+// prior to the capture file being used concurrently.
+func (c *captureFile) openFile() error {
+	if c.maxFiles > 0 && c.seq >= c.maxFiles {
+		err := os.Remove(c.filePath(c.seq - c.maxFiles))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(c.filePath(c.seq), os.O_CREATE|os.O_WRONLY|
+		os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	var hdr bytes.Buffer
+	hdr.Write(captureMagic[:])
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], captureFormatVersion)
+	hdr.Write(buf[:])
+	binary.LittleEndian.PutUint32(buf[:], uint32(c.net))
+	hdr.Write(buf[:])
+	var inbound uint8
+	if c.inbound {
+		inbound = 1
+	}
+	hdr.WriteByte(inbound)
+	if err := wire.WriteVarString(&hdr, 0, c.addr); err != nil {
+		f.Close()
+		return err
+	}
+	n, err := f.Write(hdr.Bytes())
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	c.file = f
+	c.fileSize = int64(n)
+	return nil
+}
+
+// writeRecord writes a record for the message with the provided command and
+// payload to the capture file and rotates it when it exceeds the max size.
+// Records written after the capture file is closed are ignored.
+//
+// This function is safe for concurrent access.
+func (c *captureFile) writeRecord(direction CaptureDirection, pver uint32, command string, payload []byte) error {
+	var rec bytes.Buffer
+	rec.Grow(8 + 1 + 4 + wire.MessageHeaderSize + len(payload))
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(time.Now().UnixNano()))
+	rec.Write(buf[:])
+	rec.WriteByte(uint8(direction))
+	binary.LittleEndian.PutUint32(buf[:4], pver)
+	rec.Write(buf[:4])
+
+	// Encode the message with the common message framing.
+	var cmd [wire.CommandSize]byte
+	copy(cmd[:], command)
+	binary.LittleEndian.PutUint32(buf[:4], uint32(c.net))
+	rec.Write(buf[:4])
+	rec.Write(cmd[:])
+	binary.LittleEndian.PutUint32(buf[:4], uint32(len(payload)))
+	rec.Write(buf[:4])
+	rec.Write(chainhash.HashB(payload)[:4])
+	rec.Write(payload)
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.closed {
+		return nil
+	}
+
+	n, err := c.file.Write(rec.Bytes())
+	c.fileSize += int64(n)
+	if err != nil {
+		return err
+	}
+
+	// Rotate the capture file once it reaches the max size.
+	if c.maxFileSize > 0 && c.fileSize >= c.maxFileSize {
+		if err := c.file.Close(); err != nil {
+			return err
+		}
+		c.seq++
+		if err := c.openFile(); err != nil {
+			c.closed = true
+			return err
+		}
+	}
+	return nil
+}
+
+// close closes the current capture file.  Any further records are ignored.
+//
+// This function is safe for concurrent access.
+func (c *captureFile) close() error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	return c.file.Close()
+}
+
+// CaptureHeader describes the connection the messages in a capture file were
+// captured from.
+type CaptureHeader struct {
+	// Net is the network the captured messages belong to.
+	Net wire.CurrencyNet
+
+	// Inbound is whether or not the remote peer initiated the connection.
+	Inbound bool
+
+	// Addr is the address of the remote peer.
+	Addr string
+}
+
+// CaptureRecord is a message that was sent to or received from a remote peer
+// as read from a capture file.
+type CaptureRecord struct {
+	// Timestamp is the time the message was captured.
+	Timestamp time.Time
+
+	// Direction is whether the message was sent to or received from the
+	// remote peer.
+	Direction CaptureDirection
+
+	// ProtocolVersion is the protocol version the message was encoded with.
+	ProtocolVersion uint32
+
+	// Command is the command of the message.
+	Command string
+
+	// RawMessage is the message encoded with the common message framing
+	// including the header.
+	RawMessage []byte
+}
+
+// CaptureReader reads the records of a capture file.
+type CaptureReader struct {
+	r io.Reader
+
+	// Header is the header of the capture file.
+	Header CaptureHeader
+}
+
+// NewCaptureReader returns a CaptureReader that reads from r after reading and
+// validating the header of the capture file.
+func NewCaptureReader(r io.Reader) (*CaptureReader, error) {
+	var fixed [13]byte
+	if _, err := io.ReadFull(r, fixed[:]); err != nil {
+		return nil, fmt.Errorf("unable to read capture header: %w", err)
+	}
+	if !bytes.Equal(fixed[:4], captureMagic[:]) {
+		return nil, errors.New("not a capture file")
+	}
+	version := binary.LittleEndian.Uint32(fixed[4:8])
+	if version != captureFormatVersion {
+		return nil, fmt.Errorf("unsupported capture format version %d",
+			version)
+	}
+	addr, err := wire.ReadVarString(r, 0)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read capture header: %w", err)
+	}
+
+	return &CaptureReader{
+		r: r,
+		Header: CaptureHeader{
+			Net:     wire.CurrencyNet(binary.LittleEndian.Uint32(fixed[8:12])),
+			Inbound: fixed[12] == 1,
+			Addr:    addr,
+		},
+	}, nil
+}
+
+// Next reads the next record from the capture file.  It returns io.EOF when
+// there are no more records.
+func (cr *CaptureReader) Next() (*CaptureRecord, error) {
+	var fixed [13 + wire.MessageHeaderSize]byte
+	n, err := io.ReadFull(cr.r, fixed[:])
+	if err != nil {
+		if errors.Is(err, io.EOF) && n == 0 {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("unable to read capture record: %w", err)
+	}
+
+	msgHeader := fixed[13:]
+	payloadLen := binary.LittleEndian.Uint32(msgHeader[16:20])
+	if payloadLen > wire.MaxMessagePayload {
+		return nil, fmt.Errorf("captured message payload of %d bytes "+
+			"exceeds the max allowed %d bytes", payloadLen,
+			wire.MaxMessagePayload)
+	}
+	rawMsg := make([]byte, wire.MessageHeaderSize+int(payloadLen))
+	copy(rawMsg, msgHeader)
+	if _, err := io.ReadFull(cr.r, rawMsg[wire.MessageHeaderSize:]); err != nil {
+		return nil, fmt.Errorf("unable to read capture record: %w", err)
+	}
+
+	cmd := msgHeader[4 : 4+wire.CommandSize]
+	return &CaptureRecord{
+		Timestamp: time.Unix(0, int64(binary.LittleEndian.Uint64(
+			fixed[:8]))),
+		Direction:       CaptureDirection(fixed[8]),
+		ProtocolVersion: binary.LittleEndian.Uint32(fixed[9:13]),
+		Command:         string(bytes.TrimRight(cmd, "\x00")),
+		RawMessage:      rawMsg,
+	}, nil
+}
+
+// Decode decodes the captured message for the provided network.
+func (rec *CaptureRecord) Decode(net wire.CurrencyNet) (wire.Message, error) {
+	msg, _, err := wire.ReadMessage(bytes.NewReader(rec.RawMessage),
+		rec.ProtocolVersion, net)
+	return msg, err
+}
+
+// encodePayload returns the payload of the provided message encoded for the
+// provided protocol version.
+func encodePayload(msg wire.Message, pver uint32) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := msg.BtcEncode(&buf, pver); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// captureMessage writes the provided message, which was sent to or received
+// from the remote peer per the provided direction, to the capture files of the
+// peer when capturing is enabled.  The payload is the encoded message when it
+// is already available and it will be encoded otherwise.
+//
+// Capturing is disabled for the peer when any errors occur.
+func (p *Peer) captureMessage(direction CaptureDirection, msg wire.Message, payload []byte) {
+	if p.capture == nil {
+		return
+	}
+
+	pver := p.ProtocolVersion()
+	if payload == nil {
+		var err error
+		payload, err = encodePayload(msg, pver)
+		if err != nil {
+			log.Errorf("Unable to encode %s message to capture for %s: %v",
+				msg.Command(), p, err)
+			return
+		}
+	}
+
+	err := p.capture.writeRecord(direction, pver, msg.Command(), payload)
+	if err != nil {
+		log.Errorf("Unable to capture messages for %s: %v", p, err)
+		p.capture.close()
+	}
+}
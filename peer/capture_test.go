@@ -0,0 +1,296 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package peer
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/decred/dcrd/wire"
+)
+
+// readCaptureFile reads the header and all records of the provided capture
+// file.
+func readCaptureFile(t *testing.T, path string) (*CaptureHeader, []*CaptureRecord) {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("unable to open capture file: %v", err)
+	}
+	defer f.Close()
+
+	cr, err := NewCaptureReader(f)
+	if err != nil {
+		t.Fatalf("unable to read capture header: %v", err)
+	}
+	var records []*CaptureRecord
+	for {
+		rec, err := cr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unable to read capture record: %v", err)
+		}
+		records = append(records, rec)
+	}
+	return &cr.Header, records
+}
+
+// captureFiles returns the paths of the capture files in the provided
+// directory sorted by name.
+func captureFiles(t *testing.T, dir string) []string {
+	t.Helper()
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+captureFileExt))
+	if err != nil {
+		t.Fatalf("unable to list capture files: %v", err)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// TestCaptureFile ensures messages written to capture files are read back as
+// expected and that capture files are rotated per the configured limits.
+func TestCaptureFile(t *testing.T) {
+	const pver = MaxProtocolVersion
+	const net = wire.SimNet
+	const addr = "[::1]:18555"
+
+	dir := t.TempDir()
+	capture, err := newCaptureFile(dir, addr, true, net, 0, 0)
+	if err != nil {
+		t.Fatalf("unable to create capture file: %v", err)
+	}
+	msgs := []wire.Message{
+		wire.NewMsgPing(1),
+		wire.NewMsgPong(1),
+		wire.NewMsgGetAddr(),
+	}
+	start := time.Now()
+	for i, msg := range msgs {
+		direction := CaptureReceived
+		if i%2 == 1 {
+			direction = CaptureSent
+		}
+		payload, err := encodePayload(msg, pver)
+		if err != nil {
+			t.Fatalf("unable to encode message: %v", err)
+		}
+		err = capture.writeRecord(direction, pver, msg.Command(), payload)
+		if err != nil {
+			t.Fatalf("unable to write capture record: %v", err)
+		}
+	}
+	if err := capture.close(); err != nil {
+		t.Fatalf("unable to close capture file: %v", err)
+	}
+
+	// Ensure records written after the capture is closed are ignored.
+	err = capture.writeRecord(CaptureSent, pver, wire.CmdVerAck, nil)
+	if err != nil {
+		t.Fatalf("unexpected error writing to closed capture: %v", err)
+	}
+
+	paths := captureFiles(t, dir)
+	if len(paths) != 1 {
+		t.Fatalf("unexpected number of capture files - got %d, want 1",
+			len(paths))
+	}
+	hdr, records := readCaptureFile(t, paths[0])
+	wantHdr := CaptureHeader{Net: net, Inbound: true, Addr: addr}
+	if !reflect.DeepEqual(*hdr, wantHdr) {
+		t.Fatalf("unexpected capture header - got %v, want %v", *hdr, wantHdr)
+	}
+	if len(records) != len(msgs) {
+		t.Fatalf("unexpected number of records - got %d, want %d",
+			len(records), len(msgs))
+	}
+	for i, rec := range records {
+		wantDirection := CaptureReceived
+		if i%2 == 1 {
+			wantDirection = CaptureSent
+		}
+		if rec.Direction != wantDirection {
+			t.Fatalf("#%d: unexpected direction - got %v, want %v", i,
+				rec.Direction, wantDirection)
+		}
+		if rec.ProtocolVersion != pver {
+			t.Fatalf("#%d: unexpected protocol version - got %d, want %d", i,
+				rec.ProtocolVersion, pver)
+		}
+		if rec.Command != msgs[i].Command() {
+			t.Fatalf("#%d: unexpected command - got %q, want %q", i,
+				rec.Command, msgs[i].Command())
+		}
+		if rec.Timestamp.Before(start.Add(-time.Second)) {
+			t.Fatalf("#%d: unexpected timestamp %v", i, rec.Timestamp)
+		}
+		msg, err := rec.Decode(net)
+		if err != nil {
+			t.Fatalf("#%d: unable to decode message: %v", i, err)
+		}
+		if !reflect.DeepEqual(msg, msgs[i]) {
+			t.Fatalf("#%d: mismatched message\ngot: %v\nwant: %v", i,
+				spew.Sdump(msg), spew.Sdump(msgs[i]))
+		}
+	}
+
+	// Ensure capture files are rotated once they reach the max size and only
+	// the most recent files are retained.
+	dir = t.TempDir()
+	capture, err = newCaptureFile(dir, addr, false, net, 1, 2)
+	if err != nil {
+		t.Fatalf("unable to create capture file: %v", err)
+	}
+	const numRecords = 5
+	for i := 0; i < numRecords; i++ {
+		payload, err := encodePayload(wire.NewMsgPing(uint64(i)), pver)
+		if err != nil {
+			t.Fatalf("unable to encode message: %v", err)
+		}
+		err = capture.writeRecord(CaptureSent, pver, wire.CmdPing, payload)
+		if err != nil {
+			t.Fatalf("unable to write capture record: %v", err)
+		}
+	}
+	if err := capture.close(); err != nil {
+		t.Fatalf("unable to close capture file: %v", err)
+	}
+	paths = captureFiles(t, dir)
+	if len(paths) != 2 {
+		t.Fatalf("unexpected number of capture files - got %d, want 2",
+			len(paths))
+	}
+
+	// The last file is created empty after the final rotation, so the
+	// retained record is the final ping.
+	_, records = readCaptureFile(t, paths[0])
+	if len(records) != 1 {
+		t.Fatalf("unexpected number of records - got %d, want 1",
+			len(records))
+	}
+	msg, err := records[0].Decode(net)
+	if err != nil {
+		t.Fatalf("unable to decode message: %v", err)
+	}
+	if ping, ok := msg.(*wire.MsgPing); !ok || ping.Nonce != numRecords-1 {
+		t.Fatalf("unexpected message %v", spew.Sdump(msg))
+	}
+	_, records = readCaptureFile(t, paths[1])
+	if len(records) != 0 {
+		t.Fatalf("unexpected number of records - got %d, want 0",
+			len(records))
+	}
+}
+
+// TestPeerCapture ensures peers capture the messages they exchange when
+// capturing is enabled.
+func TestPeerCapture(t *testing.T) {
+	verack := make(chan struct{}, 4)
+	newCfg := func(captureDir string) *Config {
+		return &Config{
+			Listeners: MessageListeners{
+				OnVerAck: func(p *Peer, msg *wire.MsgVerAck) {
+					verack <- struct{}{}
+				},
+				OnWrite: func(p *Peer, bytesWritten int, msg wire.Message,
+					err error) {
+					if _, ok := msg.(*wire.MsgVerAck); ok {
+						verack <- struct{}{}
+					}
+				},
+			},
+			UserAgentName:    "peer",
+			UserAgentVersion: "1.0",
+			Net:              wire.SimNet,
+			Encryption:       EncryptionPreferred,
+			CaptureDir:       captureDir,
+		}
+	}
+
+	inDir, outDir := t.TempDir(), t.TempDir()
+	inConn, outConn := pipe(
+		&conn{raddr: "10.0.0.1:18555"},
+		&conn{raddr: "10.0.0.2:18555"},
+	)
+	inPeer := NewInboundPeer(newCfg(inDir))
+	inPeer.AssociateConnection(inConn)
+	outPeer, err := NewOutboundPeer(newCfg(outDir), "10.0.0.2:18555")
+	if err != nil {
+		t.Fatalf("unexpected error creating peer: %v", err)
+	}
+	outPeer.AssociateConnection(outConn)
+	for i := 0; i < 4; i++ {
+		select {
+		case <-verack:
+		case <-time.After(time.Second):
+			t.Fatal("verack timeout")
+		}
+	}
+	inPeer.Disconnect()
+	outPeer.Disconnect()
+	inPeer.WaitForDisconnect()
+	outPeer.WaitForDisconnect()
+
+	// Ensure both peers captured the version and verack messages in the
+	// expected directions.  Note that the verack sent by each peer is not
+	// necessarily captured prior to the one it received.
+	tests := []struct {
+		name    string
+		dir     string
+		inbound bool
+		first   CaptureDirection
+	}{
+		{name: "inbound", dir: inDir, inbound: true, first: CaptureReceived},
+		{name: "outbound", dir: outDir, inbound: false, first: CaptureSent},
+	}
+	for _, test := range tests {
+		paths := captureFiles(t, test.dir)
+		if len(paths) != 1 {
+			t.Fatalf("%s: unexpected number of capture files - got %d, "+
+				"want 1", test.name, len(paths))
+		}
+		hdr, records := readCaptureFile(t, paths[0])
+		if hdr.Inbound != test.inbound || hdr.Net != wire.SimNet {
+			t.Fatalf("%s: unexpected capture header %v", test.name, hdr)
+		}
+		if len(records) < 4 {
+			t.Fatalf("%s: unexpected number of records - got %d, want at "+
+				"least 4", test.name, len(records))
+		}
+		second := CaptureSent
+		if test.first == CaptureSent {
+			second = CaptureReceived
+		}
+		for i, want := range []CaptureDirection{test.first, second} {
+			if records[i].Command != wire.CmdVersion ||
+				records[i].Direction != want {
+
+				t.Fatalf("%s: unexpected record #%d - got %s %v, want "+
+					"version %v", test.name, i, records[i].Command,
+					records[i].Direction, want)
+			}
+		}
+		var numVerAcks int
+		for _, rec := range records[2:] {
+			if rec.Command == wire.CmdVerAck {
+				numVerAcks++
+			}
+		}
+		if numVerAcks != 2 {
+			t.Fatalf("%s: unexpected number of verack records - got %d, "+
+				"want 2", test.name, numVerAcks)
+		}
+	}
+}
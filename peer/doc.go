@@ -1,5 +1,5 @@
 // Copyright (c) 2015-2016 The btcsuite developers
-// Copyright (c) 2016-2022 The Decred developers
+// Copyright (c) 2016-2024 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
@@ -68,6 +68,66 @@ Disconnect to disconnect from the peer and clean up all resources.
 WaitForDisconnect can be used to block until peer disconnection and resource
 cleanup has completed.
 
+# Encrypted Transport
+
+Connections may optionally be upgraded to an encrypted and authenticated
+transport when both peers support it as advertised by the
+wire.SFNodeEncryptedTransport service flag in their version messages.  The
+Encryption field of the Config struct selects the policy for doing so.  When the
+upgrade takes place, the peers exchange ephemeral secp256k1 public keys, derive
+keys from their ECDH shared secret and the version messages they exchanged, and
+send all further messages sealed with an AEAD.  Messages retain the common
+message framing except the header checksum is omitted since the AEAD already
+authenticates them.  Callers may determine whether or not a connection was
+upgraded with the Encrypted function.
+
+Since the keys are bound to the version messages, any modification of them in
+transit causes the connection to fail once the first encrypted message is
+received.
+
+However, the ephemeral keys are not authenticated since peers do not have
+long-term identities.  Consequently, the encrypted transport only protects
+against passive observers.  It does NOT protect against active attackers that
+are able to intercept the connection.  Such an attacker is able to complete a
+separate handshake with each peer and relay messages between them, or strip the
+wire.SFNodeEncryptedTransport service flag from the version messages so the
+connection is never upgraded.  The latter downgrade is detected when the
+EncryptionRequired policy is used, but the former is not detectable.
+
+# Message Capture
+
+Every message sent to and received from the remote peer may optionally be
+written to capture files in the directory specified by the CaptureDir field of
+the Config struct.  This is primarily intended to aid in debugging interop
+issues.  The capture files for each peer are rotated once they reach the size
+specified by the CaptureMaxFileSize field and only the number of most recent
+files specified by the CaptureMaxFiles field are retained.  The
+NewCaptureReader function may be used to read the captured messages.
+
+All integers in capture files are encoded in little endian.  Each file begins
+with a header:
+
+	Field            Type       Description
+	magic            [4]byte    "dcap"
+	format version   uint32     1
+	network          uint32     wire.CurrencyNet of the captured messages
+	inbound          uint8      1 when the remote peer initiated the connection
+	address          varstring  address of the remote peer
+
+The header is followed by zero or more records until the end of the file:
+
+	Field            Type       Description
+	timestamp        int64      time the message was captured in Unix nanoseconds
+	direction        uint8      0 when received and 1 when sent
+	protocol version uint32     protocol version the message was encoded with
+	message          []byte     message encoded with the common message framing
+
+The message of each record always includes the full 24 byte header of the
+common message framing, including the checksum, regardless of whether or not
+the connection was upgraded to the encrypted transport, so it may be read with
+wire.ReadMessage.  Since every file includes the header, the files of a capture
+that has been rotated may be decoded independently.
+
 # Callbacks
 
 In order to do anything useful with a peer, it is necessary to react to decred
@@ -114,6 +114,9 @@ func messageSummary(msg wire.Message) string {
 	case *wire.MsgAddr:
 		return fmt.Sprintf("%d addr", len(msg.AddrList))
 
+	case *wire.MsgAddrV2:
+		return fmt.Sprintf("%d addr", len(msg.AddrList))
+
 	case *wire.MsgPing:
 		// No summary - perhaps add nonce.
 
@@ -163,6 +166,42 @@ func messageSummary(msg wire.Message) string {
 	case *wire.MsgInitState:
 		return fmt.Sprintf("blocks %d, votes %d, treasury spends %d",
 			len(msg.BlockHashes), len(msg.VoteHashes), len(msg.TSpendHashes))
+
+	case *wire.MsgSendCmpct:
+		return fmt.Sprintf("announce %v, version %d", msg.Announce,
+			msg.Version)
+
+	case *wire.MsgCmpctBlock:
+		return fmt.Sprintf("hash %s, %d tx (%d prefilled), %d stx (%d "+
+			"prefilled)", msg.Header.BlockHash(), msg.TxCount(),
+			len(msg.PrefilledTxns), msg.STxCount(),
+			len(msg.StakePrefilledTxns))
+
+	case *wire.MsgGetBlockTxn:
+		return fmt.Sprintf("hash %s, %d tx, %d stx", msg.BlockHash,
+			len(msg.Indexes), len(msg.StakeIndexes))
+
+	case *wire.MsgBlockTxn:
+		return fmt.Sprintf("hash %s, %d tx, %d stx", msg.BlockHash,
+			len(msg.Transactions), len(msg.STransactions))
+
+	case *wire.MsgFilterLoad:
+		return fmt.Sprintf("%d bytes, %d hash funcs, flags %d",
+			len(msg.Filter), msg.HashFuncs, msg.Flags)
+
+	case *wire.MsgFilterAdd:
+		return fmt.Sprintf("%d bytes", len(msg.Data))
+
+	case *wire.MsgMerkleBlock:
+		return fmt.Sprintf("hash %s, %d tx, %d stx, %d proofs",
+			msg.Header.BlockHash(), msg.Transactions, msg.STransactions,
+			len(msg.Proofs))
+
+	case *wire.MsgGetCFHeadersV2:
+		return locatorSummary(msg.BlockLocatorHashes, &msg.HashStop)
+
+	case *wire.MsgCFHeadersV2:
+		return fmt.Sprintf("num %d", len(msg.Headers))
 	}
 
 	// No summary for other messages.
@@ -1,5 +1,5 @@
 // Copyright (c) 2013-2016 The btcsuite developers
-// Copyright (c) 2016-2021 The Decred developers
+// Copyright (c) 2016-2024 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
@@ -28,7 +28,7 @@ import (
 
 const (
 	// MaxProtocolVersion is the max protocol version the peer supports.
-	MaxProtocolVersion = wire.RemoveRejectVersion
+	MaxProtocolVersion = wire.CFHeadersV2Version
 
 	// outputBufferSize is the number of elements the output channels use.
 	outputBufferSize = 5000
@@ -100,6 +100,9 @@ type MessageListeners struct {
 	// OnAddr is invoked when a peer receives an addr wire message.
 	OnAddr func(p *Peer, msg *wire.MsgAddr)
 
+	// OnAddrV2 is invoked when a peer receives an addrv2 wire message.
+	OnAddrV2 func(p *Peer, msg *wire.MsgAddrV2)
+
 	// OnPing is invoked when a peer receives a ping wire message.
 	OnPing func(p *Peer, msg *wire.MsgPing)
 
@@ -197,6 +200,43 @@ type MessageListeners struct {
 	// OnInitState is invoked when a peer receives an initstate message.
 	OnInitState func(p *Peer, msg *wire.MsgInitState)
 
+	// OnSendCmpct is invoked when a peer receives a sendcmpct wire message.
+	OnSendCmpct func(p *Peer, msg *wire.MsgSendCmpct)
+
+	// OnCmpctBlock is invoked when a peer receives a cmpctblock wire
+	// message.
+	OnCmpctBlock func(p *Peer, msg *wire.MsgCmpctBlock)
+
+	// OnGetBlockTxn is invoked when a peer receives a getblocktxn wire
+	// message.
+	OnGetBlockTxn func(p *Peer, msg *wire.MsgGetBlockTxn)
+
+	// OnBlockTxn is invoked when a peer receives a blocktxn wire message.
+	OnBlockTxn func(p *Peer, msg *wire.MsgBlockTxn)
+
+	// OnFilterLoad is invoked when a peer receives a filterload wire
+	// message.
+	OnFilterLoad func(p *Peer, msg *wire.MsgFilterLoad)
+
+	// OnFilterAdd is invoked when a peer receives a filteradd wire message.
+	OnFilterAdd func(p *Peer, msg *wire.MsgFilterAdd)
+
+	// OnFilterClear is invoked when a peer receives a filterclear wire
+	// message.
+	OnFilterClear func(p *Peer, msg *wire.MsgFilterClear)
+
+	// OnMerkleBlock is invoked when a peer receives a merkleblock wire
+	// message.
+	OnMerkleBlock func(p *Peer, msg *wire.MsgMerkleBlock)
+
+	// OnGetCFHeadersV2 is invoked when a peer receives a getcfhdrsv2 wire
+	// message.
+	OnGetCFHeadersV2 func(p *Peer, msg *wire.MsgGetCFHeadersV2)
+
+	// OnCFHeadersV2 is invoked when a peer receives a cfheadersv2 wire
+	// message.
+	OnCFHeadersV2 func(p *Peer, msg *wire.MsgCFHeadersV2)
+
 	// OnRead is invoked when a peer receives a wire message.  It consists
 	// of the number of bytes read, the message, and whether or not an error
 	// in the read occurred.  Typically, callers will opt to use the
@@ -265,6 +305,19 @@ type Config struct {
 	// not send inv messages for transactions.
 	DisableRelayTx bool
 
+	// DisableRelayAddr specifies if addresses should not be relayed to or
+	// accepted from the remote peer.  When set, no addresses are sent via
+	// PushAddrMsg and PushAddrV2Msg and any getaddr, addr, and addrv2
+	// messages received from the remote peer are ignored.
+	DisableRelayAddr bool
+
+	// Encryption specifies the policy for upgrading the connection with the
+	// remote peer to the encrypted transport.  Support for the encrypted
+	// transport is advertised via the wire.SFNodeEncryptedTransport service
+	// flag unless the policy is EncryptionDisabled.  This field can be
+	// omitted in which case it will be EncryptionDisabled.
+	Encryption EncryptionPolicy
+
 	// Listeners houses callback functions to be invoked on receiving peer
 	// messages.
 	Listeners MessageListeners
@@ -272,6 +325,24 @@ type Config struct {
 	// IdleTimeout is the duration of inactivity before a peer is timed
 	// out in seconds.
 	IdleTimeout time.Duration
+
+	// CaptureDir specifies a directory to write capture files that contain
+	// every message sent to and received from the remote peer to.  This is
+	// primarily useful for debugging.  See the package documentation for
+	// details regarding the capture file format.  This field can be omitted
+	// in which case messages are not captured.
+	CaptureDir string
+
+	// CaptureMaxFileSize specifies the size in bytes a capture file may
+	// reach before it is rotated.  This field can be omitted in which case
+	// DefaultCaptureMaxFileSize will be used.
+	CaptureMaxFileSize int64
+
+	// CaptureMaxFiles specifies the max number of capture files to retain
+	// for the peer.  The oldest file is removed when the limit is exceeded.
+	// This field can be omitted in which case DefaultCaptureMaxFiles will be
+	// used.
+	CaptureMaxFiles int
 }
 
 // minUint32 is a helper function to return the minimum of two uint32s.
@@ -425,6 +496,25 @@ type Peer struct {
 
 	conn net.Conn
 
+	// transport is the encrypted transport used to send and receive messages
+	// when the connection has been upgraded to it.  It is set during protocol
+	// negotiation prior to starting the input and output handlers and never
+	// modified afterwards.
+	transport *encryptedTransport
+
+	// localVersionPayload and remoteVersionPayload are the serialized payloads
+	// of the version messages sent to and received from the remote peer,
+	// respectively.  They are set during protocol negotiation in order to bind
+	// the keys of the encrypted transport to the version messages and cleared
+	// once the negotiation is complete.
+	localVersionPayload  []byte
+	remoteVersionPayload []byte
+
+	// capture writes the messages sent to and received from the remote peer
+	// to capture files when capturing is enabled.  It is set when the
+	// connection is associated with the peer and never modified afterwards.
+	capture *captureFile
+
 	// These fields are set at creation time and never modified, so they are
 	// safe to read from concurrently without a mutex.
 	addr    string
@@ -440,8 +530,10 @@ type Peer struct {
 	advertisedProtoVer   uint32 // protocol version advertised by remote
 	protocolVersion      uint32 // negotiated protocol version
 	sendHeadersPreferred bool   // peer sent a sendheaders message
+	sendCmpctPreferred   bool   // peer sent a sendcmpct message to announce
 	versionSent          bool
 	verAckReceived       bool
+	encrypted            bool // connection uses the encrypted transport
 
 	knownInventory     lru.Cache
 	prevGetBlocksMtx   sync.Mutex
@@ -672,6 +764,18 @@ func (p *Peer) VerAckReceived() bool {
 	return verAckReceived
 }
 
+// Encrypted returns whether or not the connection with the remote peer has been
+// upgraded to the encrypted transport.
+//
+// This function is safe for concurrent access.
+func (p *Peer) Encrypted() bool {
+	p.flagsMtx.Lock()
+	encrypted := p.encrypted
+	p.flagsMtx.Unlock()
+
+	return encrypted
+}
+
 // ProtocolVersion returns the negotiated peer protocol version.
 //
 // This function is safe for concurrent access.
@@ -782,6 +886,19 @@ func (p *Peer) WantsHeaders() bool {
 	return sendHeadersPreferred
 }
 
+// WantsCmpctBlocks returns if the peer wants new blocks to be announced by
+// directly sending cmpctblock messages instead of headers or inventory
+// vectors.
+//
+// This function is safe for concurrent access.
+func (p *Peer) WantsCmpctBlocks() bool {
+	p.flagsMtx.Lock()
+	sendCmpctPreferred := p.sendCmpctPreferred
+	p.flagsMtx.Unlock()
+
+	return sendCmpctPreferred
+}
+
 // PushAddrMsg sends an addr message to the connected peer using the provided
 // addresses.  This function is useful over manually sending the message via
 // QueueMessage since it automatically limits the addresses to the maximum
@@ -792,7 +909,7 @@ func (p *Peer) WantsHeaders() bool {
 // This function is safe for concurrent access.
 func (p *Peer) PushAddrMsg(addresses []*wire.NetAddress) ([]*wire.NetAddress, error) {
 	// Nothing to send.
-	if len(addresses) == 0 {
+	if len(addresses) == 0 || p.cfg.DisableRelayAddr {
 		return nil, nil
 	}
 
@@ -816,6 +933,37 @@ func (p *Peer) PushAddrMsg(addresses []*wire.NetAddress) ([]*wire.NetAddress, er
 	return msg.AddrList, nil
 }
 
+// PushAddrV2Msg sends an addrv2 message to the connected peer using the
+// provided addresses.  It is the same as PushAddrMsg except it sends an addrv2
+// message which the peer must support as indicated by its protocol version.
+//
+// This function is safe for concurrent access.
+func (p *Peer) PushAddrV2Msg(addresses []*wire.NetAddressV2) ([]*wire.NetAddressV2, error) {
+	// Nothing to send.
+	if len(addresses) == 0 || p.cfg.DisableRelayAddr {
+		return nil, nil
+	}
+
+	msg := wire.NewMsgAddrV2()
+	msg.AddrList = make([]*wire.NetAddressV2, len(addresses))
+	copy(msg.AddrList, addresses)
+
+	// Randomize the addresses sent if there are more than the maximum allowed.
+	if len(msg.AddrList) > wire.MaxAddrPerMsg {
+		// Shuffle the address list.
+		for i := range msg.AddrList {
+			j := rand.Intn(i + 1)
+			msg.AddrList[i], msg.AddrList[j] = msg.AddrList[j], msg.AddrList[i]
+		}
+
+		// Truncate it to the maximum size.
+		msg.AddrList = msg.AddrList[:wire.MaxAddrPerMsg]
+	}
+
+	p.QueueMessage(msg, nil)
+	return msg.AddrList, nil
+}
+
 // PushGetBlocksMsg sends a getblocks message for the provided block locator
 // and stop hash.  It will ignore back-to-back duplicate requests.
 //
@@ -941,8 +1089,16 @@ func (p *Peer) readMessage() (wire.Message, []byte, error) {
 	if err != nil {
 		return nil, nil, err
 	}
-	n, msg, buf, err := wire.ReadMessageN(p.conn, p.ProtocolVersion(),
-		p.cfg.Net)
+	var n int
+	var msg wire.Message
+	var buf []byte
+	if p.transport != nil {
+		n, msg, buf, err = p.transport.readMessage(p.conn,
+			p.ProtocolVersion(), p.cfg.Net)
+	} else {
+		n, msg, buf, err = wire.ReadMessageN(p.conn, p.ProtocolVersion(),
+			p.cfg.Net)
+	}
 	atomic.AddUint64(&p.bytesReceived, uint64(n))
 	if p.cfg.Listeners.OnRead != nil {
 		p.cfg.Listeners.OnRead(p, n, msg, err)
@@ -950,6 +1106,7 @@ func (p *Peer) readMessage() (wire.Message, []byte, error) {
 	if err != nil {
 		return nil, nil, err
 	}
+	p.captureMessage(CaptureReceived, msg, buf)
 
 	// Only construct expensive log strings when the logging level requires it.
 	if log.Level() <= slog.LevelDebug {
@@ -995,8 +1152,19 @@ func (p *Peer) writeMessage(msg wire.Message) error {
 	}
 
 	// Write the message to the peer.
-	n, err := wire.WriteMessageN(p.conn, msg, p.ProtocolVersion(), p.cfg.Net)
+	var n int
+	var err error
+	if p.transport != nil {
+		n, err = p.transport.writeMessage(p.conn, msg, p.ProtocolVersion(),
+			p.cfg.Net)
+	} else {
+		n, err = wire.WriteMessageN(p.conn, msg, p.ProtocolVersion(),
+			p.cfg.Net)
+	}
 	atomic.AddUint64(&p.bytesSent, uint64(n))
+	if err == nil {
+		p.captureMessage(CaptureSent, msg, nil)
+	}
 	if p.cfg.Listeners.OnWrite != nil {
 		p.cfg.Listeners.OnWrite(p, n, msg, err)
 	}
@@ -1073,6 +1241,12 @@ func (p *Peer) maybeAddDeadline(pendingResponses map[string]time.Time, msgCmd st
 	case wire.CmdGetInitState:
 		pendingResponses[wire.CmdInitState] = deadline
 		addedDeadline = true
+
+	case wire.CmdGetBlockTxn:
+		// Expects a blocktxn or notfound message.
+		pendingResponses[wire.CmdBlockTxn] = deadline
+		pendingResponses[wire.CmdNotFound] = deadline
+		addedDeadline = true
 	}
 
 	if addedDeadline {
@@ -1129,6 +1303,11 @@ out:
 				case wire.CmdNotFound:
 					delete(pendingResponses, wire.CmdBlock)
 					delete(pendingResponses, wire.CmdTx)
+					delete(pendingResponses, wire.CmdBlockTxn)
+					delete(pendingResponses, wire.CmdNotFound)
+
+				case wire.CmdBlockTxn:
+					delete(pendingResponses, wire.CmdBlockTxn)
 					delete(pendingResponses, wire.CmdNotFound)
 
 				default:
@@ -1292,15 +1471,20 @@ out:
 			}
 
 		case *wire.MsgGetAddr:
-			if p.cfg.Listeners.OnGetAddr != nil {
+			if p.cfg.Listeners.OnGetAddr != nil && !p.cfg.DisableRelayAddr {
 				p.cfg.Listeners.OnGetAddr(p, msg)
 			}
 
 		case *wire.MsgAddr:
-			if p.cfg.Listeners.OnAddr != nil {
+			if p.cfg.Listeners.OnAddr != nil && !p.cfg.DisableRelayAddr {
 				p.cfg.Listeners.OnAddr(p, msg)
 			}
 
+		case *wire.MsgAddrV2:
+			if p.cfg.Listeners.OnAddrV2 != nil && !p.cfg.DisableRelayAddr {
+				p.cfg.Listeners.OnAddrV2(p, msg)
+			}
+
 		case *wire.MsgPing:
 			p.handlePingMsg(msg)
 			if p.cfg.Listeners.OnPing != nil {
@@ -1437,6 +1621,63 @@ out:
 				p.cfg.Listeners.OnInitState(p, msg)
 			}
 
+		case *wire.MsgSendCmpct:
+			// Only announce blocks via compact blocks when the peer
+			// requested it using a supported compact block version.
+			p.flagsMtx.Lock()
+			p.sendCmpctPreferred = msg.Announce &&
+				msg.Version == wire.CmpctBlockVersion
+			p.flagsMtx.Unlock()
+
+			if p.cfg.Listeners.OnSendCmpct != nil {
+				p.cfg.Listeners.OnSendCmpct(p, msg)
+			}
+
+		case *wire.MsgCmpctBlock:
+			if p.cfg.Listeners.OnCmpctBlock != nil {
+				p.cfg.Listeners.OnCmpctBlock(p, msg)
+			}
+
+		case *wire.MsgGetBlockTxn:
+			if p.cfg.Listeners.OnGetBlockTxn != nil {
+				p.cfg.Listeners.OnGetBlockTxn(p, msg)
+			}
+
+		case *wire.MsgBlockTxn:
+			if p.cfg.Listeners.OnBlockTxn != nil {
+				p.cfg.Listeners.OnBlockTxn(p, msg)
+			}
+
+		case *wire.MsgFilterLoad:
+			if p.cfg.Listeners.OnFilterLoad != nil {
+				p.cfg.Listeners.OnFilterLoad(p, msg)
+			}
+
+		case *wire.MsgFilterAdd:
+			if p.cfg.Listeners.OnFilterAdd != nil {
+				p.cfg.Listeners.OnFilterAdd(p, msg)
+			}
+
+		case *wire.MsgFilterClear:
+			if p.cfg.Listeners.OnFilterClear != nil {
+				p.cfg.Listeners.OnFilterClear(p, msg)
+			}
+
+		case *wire.MsgMerkleBlock:
+			if p.cfg.Listeners.OnMerkleBlock != nil {
+				p.cfg.Listeners.OnMerkleBlock(p, msg)
+			}
+
+		case *wire.MsgGetCFHeadersV2:
+			if p.cfg.Listeners.OnGetCFHeadersV2 != nil {
+				p.cfg.Listeners.OnGetCFHeadersV2(p, msg)
+			}
+
+		case *wire.MsgCFHeadersV2:
+			if p.cfg.Listeners.OnCFHeadersV2 != nil {
+				p.cfg.Listeners.OnCFHeadersV2(p, msg)
+			}
+
 		default:
 			log.Debugf("Received unhandled message of type %v "+
 				"from %v", rmsg.Command(), p)
@@ -1788,6 +2029,9 @@ func (p *Peer) Disconnect() {
 	log.Tracef("Disconnecting %s", p)
 	if atomic.LoadInt32(&p.connected) != 0 {
 		p.conn.Close()
+		if p.capture != nil {
+			p.capture.close()
+		}
 	}
 	close(p.quit)
 }
@@ -1797,7 +2041,7 @@ func (p *Peer) Disconnect() {
 // acceptable then return an error.
 func (p *Peer) readRemoteVersionMsg() error {
 	// Read their version message.
-	remoteMsg, _, err := p.readMessage()
+	remoteMsg, payload, err := p.readMessage()
 	if err != nil {
 		return err
 	}
@@ -1807,6 +2051,7 @@ func (p *Peer) readRemoteVersionMsg() error {
 	if !ok {
 		return errors.New("a version message must precede all others")
 	}
+	p.remoteVersionPayload = payload
 
 	// Detect self connections.
 	if !allowSelfConns && sentNonces.Contains(msg.Nonce) {
@@ -1907,8 +2152,12 @@ func (p *Peer) localVersionMsg() (*wire.MsgVersion, error) {
 	msg.AddUserAgent(p.cfg.UserAgentName, p.cfg.UserAgentVersion,
 		p.cfg.UserAgentComments...)
 
-	// Advertise local services.
+	// Advertise local services along with support for the encrypted
+	// transport per the encryption policy.
 	msg.Services = p.cfg.Services
+	if p.cfg.Encryption != EncryptionDisabled {
+		msg.Services |= wire.SFNodeEncryptedTransport
+	}
 
 	// Advertise our max supported protocol version.
 	msg.ProtocolVersion = int32(p.ProtocolVersion())
@@ -1926,6 +2175,14 @@ func (p *Peer) writeLocalVersionMsg() error {
 		return err
 	}
 
+	// Keep the serialized payload of the version message so the keys of the
+	// encrypted transport can be bound to it.
+	var payload bytes.Buffer
+	if err := localVerMsg.BtcEncode(&payload, p.ProtocolVersion()); err != nil {
+		return err
+	}
+	p.localVersionPayload = payload.Bytes()
+
 	if err := p.writeMessage(localVerMsg); err != nil {
 		return err
 	}
@@ -1937,25 +2194,35 @@ func (p *Peer) writeLocalVersionMsg() error {
 }
 
 // negotiateInboundProtocol waits to receive a version message from the peer
-// then sends our version message. If the events do not occur in that order then
-// it returns an error.
+// then sends our version message and upgrades the connection to the encrypted
+// transport when supported. If the events do not occur in that order then it
+// returns an error.
 func (p *Peer) negotiateInboundProtocol() error {
 	if err := p.readRemoteVersionMsg(); err != nil {
 		return err
 	}
 
-	return p.writeLocalVersionMsg()
+	if err := p.writeLocalVersionMsg(); err != nil {
+		return err
+	}
+
+	return p.negotiateEncryption()
 }
 
 // negotiateOutboundProtocol sends our version message then waits to receive a
-// version message from the peer.  If the events do not occur in that order then
-// it returns an error.
+// version message from the peer and upgrades the connection to the encrypted
+// transport when supported.  If the events do not occur in that order then it
+// returns an error.
 func (p *Peer) negotiateOutboundProtocol() error {
 	if err := p.writeLocalVersionMsg(); err != nil {
 		return err
 	}
 
-	return p.readRemoteVersionMsg()
+	if err := p.readRemoteVersionMsg(); err != nil {
+		return err
+	}
+
+	return p.negotiateEncryption()
 }
 
 // start begins processing input and output messages.
@@ -2023,6 +2290,16 @@ func (p *Peer) AssociateConnection(conn net.Conn) {
 		p.na = na
 	}
 
+	if p.cfg.CaptureDir != "" {
+		capture, err := newCaptureFile(p.cfg.CaptureDir, p.addr, p.inbound,
+			p.cfg.Net, p.cfg.CaptureMaxFileSize, p.cfg.CaptureMaxFiles)
+		if err != nil {
+			log.Errorf("Unable to capture messages for %s: %v", p, err)
+		} else {
+			p.capture = capture
+		}
+	}
+
 	go func(peer *Peer) {
 		if err := peer.start(); err != nil {
 			log.Debugf("Cannot start peer %v: %v", peer, err)
@@ -2064,6 +2341,14 @@ func newPeerBase(cfgOrig *Config, inbound bool) *Peer {
 		cfg.IdleTimeout = defaultIdleTimeout
 	}
 
+	// Set default capture file limits if the caller did not specify them.
+	if cfg.CaptureMaxFileSize == 0 {
+		cfg.CaptureMaxFileSize = DefaultCaptureMaxFileSize
+	}
+	if cfg.CaptureMaxFiles == 0 {
+		cfg.CaptureMaxFiles = DefaultCaptureMaxFiles
+	}
+
 	p := Peer{
 		inbound:         inbound,
 		knownInventory:  lru.NewCache(maxKnownInventory),
@@ -332,6 +332,9 @@ func TestPeerListeners(t *testing.T) {
 			OnAddr: func(p *Peer, msg *wire.MsgAddr) {
 				ok <- msg
 			},
+			OnAddrV2: func(p *Peer, msg *wire.MsgAddrV2) {
+				ok <- msg
+			},
 			OnPing: func(p *Peer, msg *wire.MsgPing) {
 				ok <- msg
 			},
@@ -410,6 +413,36 @@ func TestPeerListeners(t *testing.T) {
 			OnInitState: func(p *Peer, msg *wire.MsgInitState) {
 				ok <- msg
 			},
+			OnSendCmpct: func(p *Peer, msg *wire.MsgSendCmpct) {
+				ok <- msg
+			},
+			OnCmpctBlock: func(p *Peer, msg *wire.MsgCmpctBlock) {
+				ok <- msg
+			},
+			OnGetBlockTxn: func(p *Peer, msg *wire.MsgGetBlockTxn) {
+				ok <- msg
+			},
+			OnBlockTxn: func(p *Peer, msg *wire.MsgBlockTxn) {
+				ok <- msg
+			},
+			OnFilterLoad: func(p *Peer, msg *wire.MsgFilterLoad) {
+				ok <- msg
+			},
+			OnFilterAdd: func(p *Peer, msg *wire.MsgFilterAdd) {
+				ok <- msg
+			},
+			OnFilterClear: func(p *Peer, msg *wire.MsgFilterClear) {
+				ok <- msg
+			},
+			OnMerkleBlock: func(p *Peer, msg *wire.MsgMerkleBlock) {
+				ok <- msg
+			},
+			OnGetCFHeadersV2: func(p *Peer, msg *wire.MsgGetCFHeadersV2) {
+				ok <- msg
+			},
+			OnCFHeadersV2: func(p *Peer, msg *wire.MsgCFHeadersV2) {
+				ok <- msg
+			},
 		},
 		UserAgentName:    "peer",
 		UserAgentVersion: "1.0",
@@ -463,6 +496,10 @@ func TestPeerListeners(t *testing.T) {
 			"OnAddr",
 			wire.NewMsgAddr(),
 		},
+		{
+			"OnAddrV2",
+			wire.NewMsgAddrV2(),
+		},
 		{
 			"OnPing",
 			wire.NewMsgPing(42),
@@ -564,6 +601,47 @@ func TestPeerListeners(t *testing.T) {
 			"OnInitState",
 			wire.NewMsgInitState(),
 		},
+		{
+			"OnSendCmpct",
+			wire.NewMsgSendCmpct(true, wire.CmpctBlockVersion),
+		},
+		{
+			"OnCmpctBlock",
+			wire.NewMsgCmpctBlock(wire.NewMsgBlock(&wire.BlockHeader{}), 0,
+				nil),
+		},
+		{
+			"OnGetBlockTxn",
+			wire.NewMsgGetBlockTxn(&chainhash.Hash{}, nil, nil),
+		},
+		{
+			"OnBlockTxn",
+			wire.NewMsgBlockTxn(&chainhash.Hash{}),
+		},
+		{
+			"OnFilterLoad",
+			wire.NewMsgFilterLoad([]byte{0x01}, 10, 0, wire.BloomUpdateNone),
+		},
+		{
+			"OnFilterAdd",
+			wire.NewMsgFilterAdd([]byte{0x01}),
+		},
+		{
+			"OnFilterClear",
+			wire.NewMsgFilterClear(),
+		},
+		{
+			"OnMerkleBlock",
+			wire.NewMsgMerkleBlock(&wire.BlockHeader{}, 0, 0),
+		},
+		{
+			"OnGetCFHeadersV2",
+			wire.NewMsgGetCFHeadersV2(),
+		},
+		{
+			"OnCFHeadersV2",
+			wire.NewMsgCFHeadersV2(),
+		},
 	}
 	t.Logf("Running %d tests", len(tests))
 	for _, test := range tests {
@@ -756,6 +834,15 @@ func TestOutboundPeer(t *testing.T) {
 		t.Errorf("PushAddrMsg: unexpected err %v\n", err)
 		return
 	}
+	var addrsV2 []*wire.NetAddressV2
+	for i := 0; i < 5; i++ {
+		na := wire.NetAddressV2{NetID: wire.NetIDIPv4, Addr: make([]byte, 4)}
+		addrsV2 = append(addrsV2, &na)
+	}
+	if _, err := p2.PushAddrV2Msg(addrsV2); err != nil {
+		t.Errorf("PushAddrV2Msg: unexpected err %v\n", err)
+		return
+	}
 	if err := p2.PushGetBlocksMsg(nil, &chainhash.Hash{}); err != nil {
 		t.Errorf("PushGetBlocksMsg: unexpected err %v\n", err)
 		return
@@ -774,6 +861,30 @@ func TestOutboundPeer(t *testing.T) {
 	p2.QueueMessage(wire.NewMsgFeeFilter(20000), nil)
 
 	p2.Disconnect()
+
+	// Ensure no addresses are sent when address relay is disabled.
+	peerCfg.DisableRelayAddr = true
+	r3, w3 := io.Pipe()
+	c3 := &conn{raddr: "10.0.0.1:8333", Writer: w3, Reader: r3}
+	p3, err := NewOutboundPeer(peerCfg, "10.0.0.1:8333")
+	if err != nil {
+		t.Errorf("NewOutboundPeer: unexpected err - %v\n", err)
+		return
+	}
+	p3.AssociateConnection(c3)
+	sent, err := p3.PushAddrMsg(addrs)
+	if err != nil || len(sent) != 0 {
+		t.Errorf("PushAddrMsg: unexpected result with address relay "+
+			"disabled - sent %d, err %v", len(sent), err)
+		return
+	}
+	sentV2, err := p3.PushAddrV2Msg(addrsV2)
+	if err != nil || len(sentV2) != 0 {
+		t.Errorf("PushAddrV2Msg: unexpected result with address relay "+
+			"disabled - sent %d, err %v", len(sentV2), err)
+		return
+	}
+	p3.Disconnect()
 }
 
 // TestDuplicateVersionMsg ensures that receiving a version message after one
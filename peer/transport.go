@@ -0,0 +1,345 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package peer
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/wire"
+)
+
+// EncryptionPolicy defines the policy for upgrading connections with remote
+// peers to the encrypted transport.
+type EncryptionPolicy uint8
+
+// These constants define the available encryption policies.
+const (
+	// EncryptionDisabled neither advertises support for the encrypted
+	// transport nor upgrades any connections to it.
+	EncryptionDisabled EncryptionPolicy = iota
+
+	// EncryptionPreferred advertises support for the encrypted transport and
+	// upgrades connections with remote peers that also support it while
+	// falling back to the plaintext transport for remote peers that do not.
+	EncryptionPreferred
+
+	// EncryptionRequired advertises support for the encrypted transport and
+	// upgrades connections with remote peers that also support it while
+	// disconnecting remote peers that do not.
+	EncryptionRequired
+)
+
+// Map of EncryptionPolicy values back to their constant names for pretty
+// printing.
+var encryptionPolicyStrings = map[EncryptionPolicy]string{
+	EncryptionDisabled:  "EncryptionDisabled",
+	EncryptionPreferred: "EncryptionPreferred",
+	EncryptionRequired:  "EncryptionRequired",
+}
+
+// String returns the EncryptionPolicy in human-readable form.
+func (policy EncryptionPolicy) String() string {
+	if s, ok := encryptionPolicyStrings[policy]; ok {
+		return s
+	}
+	return fmt.Sprintf("Unknown EncryptionPolicy (%d)", uint8(policy))
+}
+
+const (
+	// handshakeKeySize is the size of the serialized ephemeral public keys
+	// that are exchanged during the encryption handshake.
+	handshakeKeySize = secp256k1.PubKeyBytesLenCompressed
+
+	// aeadNonceSize and aeadTagSize are the sizes of the nonces and
+	// authentication tags, respectively, of the AEAD used to seal frames.
+	aeadNonceSize = 12
+	aeadTagSize   = 16
+
+	// sealedLenSize is the size of the sealed length prefix of each frame.
+	sealedLenSize = 4 + aeadTagSize
+
+	// maxFrameSize is the maximum size of the plaintext of a frame which is
+	// a message with an authenticated header and the max allowed payload.
+	maxFrameSize = wire.AuthenticatedMessageHeaderSize + wire.MaxMessagePayload
+)
+
+var (
+	// initiatorKeyTag and responderKeyTag are the tags used to derive the
+	// keys for the frames sent by the initiator and responder, respectively.
+	initiatorKeyTag = []byte("dcrd encrypted transport initiator")
+	responderKeyTag = []byte("dcrd encrypted transport responder")
+)
+
+// deriveTransportKey derives a key for one direction of the encrypted transport
+// from the provided tag, ECDH shared secret, serialized ephemeral public keys of
+// the initiator and responder, and the serialized payloads of the version
+// messages sent by the initiator and responder.
+//
+// Committing to the version messages binds the keys to the entire handshake
+// transcript, so the peers end up with different keys, and therefore fail to
+// open any frames, when the version messages they observed differ.
+func deriveTransportKey(tag, secret, initiatorPubKey, responderPubKey, initiatorVersion, responderVersion []byte) []byte {
+	var lenBytes [4]byte
+	h := sha256.New()
+	h.Write(tag)
+	h.Write(secret)
+	h.Write(initiatorPubKey)
+	h.Write(responderPubKey)
+	for _, version := range [][]byte{initiatorVersion, responderVersion} {
+		binary.LittleEndian.PutUint32(lenBytes[:], uint32(len(version)))
+		h.Write(lenBytes[:])
+		h.Write(version)
+	}
+	return h.Sum(nil)
+}
+
+// newTransportAEAD returns an AES-256-GCM AEAD for the provided key.
+func newTransportAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptedTransport houses the state needed to send and receive messages via
+// the encrypted transport.
+//
+// Each message is sent as a frame that consists of the sealed length of the
+// message followed by the sealed message itself.  The message is serialized
+// with the common message framing except the checksum is omitted from the
+// header since the AEAD already authenticates the message.  Every seal uses a
+// distinct nonce that is derived from a counter which is incremented for each
+// one, so any frames that are dropped, replayed, or reordered fail to open.
+//
+// Sending and receiving make use of independent keys and nonces, so it is safe
+// for a single reader and a single writer to access the transport
+// concurrently.
+type encryptedTransport struct {
+	sendAEAD  cipher.AEAD
+	sendNonce uint64
+	recvAEAD  cipher.AEAD
+	recvNonce uint64
+}
+
+// newEncryptedTransport returns an encrypted transport that uses keys derived
+// from an ECDH shared secret between the provided local ephemeral private key
+// and remote ephemeral public key along with the serialized payloads of the
+// version messages sent by the local and remote peer.  The initiator flag
+// specifies whether or not the local peer initiated the connection.
+func newEncryptedTransport(privKey *secp256k1.PrivateKey, remotePubKey *secp256k1.PublicKey, localVersion, remoteVersion []byte, initiator bool) (*encryptedTransport, error) {
+	secret := secp256k1.GenerateSharedSecret(privKey, remotePubKey)
+	initiatorPubKey := privKey.PubKey().SerializeCompressed()
+	responderPubKey := remotePubKey.SerializeCompressed()
+	initiatorVersion, responderVersion := localVersion, remoteVersion
+	if !initiator {
+		initiatorPubKey, responderPubKey = responderPubKey, initiatorPubKey
+		initiatorVersion, responderVersion = responderVersion, initiatorVersion
+	}
+	initiatorKey := deriveTransportKey(initiatorKeyTag, secret,
+		initiatorPubKey, responderPubKey, initiatorVersion, responderVersion)
+	responderKey := deriveTransportKey(responderKeyTag, secret,
+		initiatorPubKey, responderPubKey, initiatorVersion, responderVersion)
+
+	sendKey, recvKey := initiatorKey, responderKey
+	if !initiator {
+		sendKey, recvKey = responderKey, initiatorKey
+	}
+	sendAEAD, err := newTransportAEAD(sendKey)
+	if err != nil {
+		return nil, err
+	}
+	recvAEAD, err := newTransportAEAD(recvKey)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptedTransport{sendAEAD: sendAEAD, recvAEAD: recvAEAD}, nil
+}
+
+// transportNonce returns the AEAD nonce for the provided counter.
+func transportNonce(counter uint64) []byte {
+	var nonce [aeadNonceSize]byte
+	binary.LittleEndian.PutUint64(nonce[:], counter)
+	return nonce[:]
+}
+
+// seal appends the sealed plaintext to dst using the next send nonce and
+// returns the resulting slice.
+func (t *encryptedTransport) seal(dst, plaintext []byte) []byte {
+	nonce := transportNonce(t.sendNonce)
+	t.sendNonce++
+	return t.sendAEAD.Seal(dst, nonce, plaintext, nil)
+}
+
+// open authenticates and decrypts the provided ciphertext using the next
+// receive nonce.  The plaintext is decrypted in place.
+func (t *encryptedTransport) open(ciphertext []byte) ([]byte, error) {
+	nonce := transportNonce(t.recvNonce)
+	t.recvNonce++
+	plaintext, err := t.recvAEAD.Open(ciphertext[:0], nonce, ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("failed to authenticate encrypted frame")
+	}
+	return plaintext, nil
+}
+
+// writeMessage writes the provided message to w as a frame of the encrypted
+// transport and returns the number of bytes written.
+func (t *encryptedTransport) writeMessage(w io.Writer, msg wire.Message, pver uint32, net wire.CurrencyNet) (int, error) {
+	var buf bytes.Buffer
+	_, err := wire.WriteAuthenticatedMessageN(&buf, msg, pver, net)
+	if err != nil {
+		return 0, err
+	}
+	plaintext := buf.Bytes()
+
+	var lenBytes [4]byte
+	binary.LittleEndian.PutUint32(lenBytes[:], uint32(len(plaintext)))
+	frame := make([]byte, 0, sealedLenSize+len(plaintext)+aeadTagSize)
+	frame = t.seal(frame, lenBytes[:])
+	frame = t.seal(frame, plaintext)
+	return w.Write(frame)
+}
+
+// readMessage reads the next frame of the encrypted transport from r and
+// returns the number of bytes read in addition to the parsed message and raw
+// bytes which comprise the message payload.
+func (t *encryptedTransport) readMessage(r io.Reader, pver uint32, net wire.CurrencyNet) (int, wire.Message, []byte, error) {
+	var sealedLen [sealedLenSize]byte
+	totalBytes, err := io.ReadFull(r, sealedLen[:])
+	if err != nil {
+		return totalBytes, nil, nil, err
+	}
+	lenBytes, err := t.open(sealedLen[:])
+	if err != nil {
+		return totalBytes, nil, nil, err
+	}
+
+	// Enforce the maximum frame size prior to allocating the frame to
+	// prevent memory exhaustion.
+	frameLen := binary.LittleEndian.Uint32(lenBytes)
+	if frameLen < wire.AuthenticatedMessageHeaderSize || frameLen > maxFrameSize {
+		return totalBytes, nil, nil, fmt.Errorf("invalid encrypted frame "+
+			"size %d", frameLen)
+	}
+
+	frame := make([]byte, frameLen+aeadTagSize)
+	n, err := io.ReadFull(r, frame)
+	totalBytes += n
+	if err != nil {
+		return totalBytes, nil, nil, err
+	}
+	plaintext, err := t.open(frame)
+	if err != nil {
+		return totalBytes, nil, nil, err
+	}
+
+	pr := bytes.NewReader(plaintext)
+	_, msg, payload, err := wire.ReadAuthenticatedMessageN(pr, pver, net)
+	if err != nil {
+		return totalBytes, nil, nil, err
+	}
+	if pr.Len() != 0 {
+		return totalBytes, nil, nil, fmt.Errorf("encrypted frame contains "+
+			"%d trailing bytes", pr.Len())
+	}
+	return totalBytes, msg, payload, nil
+}
+
+// negotiateEncryption upgrades the connection with the remote peer to the
+// encrypted transport when both the local and remote peer support it per the
+// configured encryption policy.  It must only be called after the version
+// messages have been exchanged and prior to starting the input and output
+// handlers.
+//
+// The upgrade consists of both peers exchanging ephemeral public keys in
+// plaintext, with the outbound peer sending first, and deriving the keys for
+// the encrypted transport from their ECDH shared secret and the version
+// messages that were exchanged.  Binding the keys to the version messages
+// ensures any tampering with them, such as altering the advertised services,
+// protocol version, or best height, results in the connection failing as soon
+// as the first encrypted message is received.
+//
+// Note that the keys are not authenticated, so this only protects against
+// passive observers and attackers that tamper with the handshake without
+// fully intercepting the connection.  An active attacker that intercepts the
+// connection is able to complete separate handshakes with both peers or strip
+// the service flag from the version messages to prevent the upgrade entirely.
+func (p *Peer) negotiateEncryption() error {
+	policy := p.cfg.Encryption
+	if policy == EncryptionDisabled {
+		return nil
+	}
+
+	if p.Services()&wire.SFNodeEncryptedTransport == 0 {
+		if policy == EncryptionRequired {
+			return errors.New("peer does not support the encrypted transport")
+		}
+		return nil
+	}
+
+	privKey, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		return err
+	}
+	pubKey := privKey.PubKey().SerializeCompressed()
+
+	// The outbound peer sends its key first to match the order the version
+	// messages are exchanged in.
+	var remotePubKeyBytes [handshakeKeySize]byte
+	writeKey := func() error {
+		n, err := p.conn.Write(pubKey)
+		atomic.AddUint64(&p.bytesSent, uint64(n))
+		return err
+	}
+	readKey := func() error {
+		n, err := io.ReadFull(p.conn, remotePubKeyBytes[:])
+		atomic.AddUint64(&p.bytesReceived, uint64(n))
+		return err
+	}
+	if p.inbound {
+		if err := readKey(); err != nil {
+			return err
+		}
+		if err := writeKey(); err != nil {
+			return err
+		}
+	} else {
+		if err := writeKey(); err != nil {
+			return err
+		}
+		if err := readKey(); err != nil {
+			return err
+		}
+	}
+
+	remotePubKey, err := secp256k1.ParsePubKey(remotePubKeyBytes[:])
+	if err != nil {
+		return err
+	}
+	transport, err := newEncryptedTransport(privKey, remotePubKey,
+		p.localVersionPayload, p.remoteVersionPayload, !p.inbound)
+	if err != nil {
+		return err
+	}
+	p.localVersionPayload = nil
+	p.remoteVersionPayload = nil
+
+	p.transport = transport
+	p.flagsMtx.Lock()
+	p.encrypted = true
+	p.flagsMtx.Unlock()
+	log.Debugf("Upgraded connection to %s to the encrypted transport", p)
+	return nil
+}
@@ -0,0 +1,327 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package peer
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/wire"
+)
+
+// Mock serialized version message payloads used to derive the keys of the
+// test transports.
+var (
+	testInitiatorVersion = []byte("initiator version")
+	testResponderVersion = []byte("responder version")
+)
+
+// newTestTransports returns a pair of encrypted transports for an initiator
+// and responder that share keys derived from newly generated ephemeral keys and
+// the same version message payloads.
+func newTestTransports(t *testing.T) (*encryptedTransport, *encryptedTransport) {
+	t.Helper()
+	return newTestTransportsWithVersions(t, testResponderVersion,
+		testResponderVersion)
+}
+
+// newTestTransportsWithVersions returns a pair of encrypted transports for an
+// initiator and responder that use keys derived from newly generated ephemeral
+// keys where the initiator and responder observed the provided version message
+// payloads from the responder, respectively.
+func newTestTransportsWithVersions(t *testing.T, initiatorSawVersion, responderVersion []byte) (*encryptedTransport, *encryptedTransport) {
+	t.Helper()
+
+	initiatorKey, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("unexpected error generating key: %v", err)
+	}
+	responderKey, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("unexpected error generating key: %v", err)
+	}
+	initiator, err := newEncryptedTransport(initiatorKey,
+		responderKey.PubKey(), testInitiatorVersion, initiatorSawVersion, true)
+	if err != nil {
+		t.Fatalf("unexpected error creating transport: %v", err)
+	}
+	responder, err := newEncryptedTransport(responderKey,
+		initiatorKey.PubKey(), responderVersion, testInitiatorVersion, false)
+	if err != nil {
+		t.Fatalf("unexpected error creating transport: %v", err)
+	}
+	return initiator, responder
+}
+
+// TestEncryptedTransport ensures messages written by one side of the encrypted
+// transport are read back by the other side in both directions and that frames
+// which have been tampered with or replayed are rejected.
+func TestEncryptedTransport(t *testing.T) {
+	const pver = MaxProtocolVersion
+	const net = wire.MainNet
+	initiator, responder := newTestTransports(t)
+
+	msgs := []wire.Message{
+		wire.NewMsgVerAck(),
+		wire.NewMsgPing(0x0123456789abcdef),
+		wire.NewMsgGetAddr(),
+		wire.NewMsgPong(0xfedcba9876543210),
+	}
+	for i, msg := range msgs {
+		// Alternate the direction of each message.
+		sender, receiver := initiator, responder
+		if i%2 == 1 {
+			sender, receiver = responder, initiator
+		}
+
+		var buf bytes.Buffer
+		nw, err := sender.writeMessage(&buf, msg, pver, net)
+		if err != nil {
+			t.Fatalf("#%d: unexpected write error: %v", i, err)
+		}
+		if nw != buf.Len() {
+			t.Fatalf("#%d: unexpected num bytes written - got %d, want %d",
+				i, nw, buf.Len())
+		}
+
+		// Ensure the command is not visible in the frame.
+		if bytes.Contains(buf.Bytes(), []byte(msg.Command())) {
+			t.Fatalf("#%d: frame contains plaintext command %q", i,
+				msg.Command())
+		}
+
+		nr, gotMsg, _, err := receiver.readMessage(&buf, pver, net)
+		if err != nil {
+			t.Fatalf("#%d: unexpected read error: %v", i, err)
+		}
+		if nr != nw {
+			t.Fatalf("#%d: unexpected num bytes read - got %d, want %d", i,
+				nr, nw)
+		}
+		if !reflect.DeepEqual(gotMsg, msg) {
+			t.Fatalf("#%d: mismatched message\ngot: %v\nwant: %v", i,
+				spew.Sdump(gotMsg), spew.Sdump(msg))
+		}
+	}
+
+	// Ensure a frame with a modified byte fails to authenticate.
+	var buf bytes.Buffer
+	_, err := initiator.writeMessage(&buf, wire.NewMsgVerAck(), pver, net)
+	if err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+	tampered := buf.Bytes()
+	tampered[len(tampered)-1] ^= 0x01
+	_, _, _, err = responder.readMessage(bytes.NewReader(tampered), pver, net)
+	if err == nil {
+		t.Fatal("did not receive expected error for tampered frame")
+	}
+
+	// Ensure a replayed frame fails to authenticate since the nonces no longer
+	// match.
+	initiator, responder = newTestTransports(t)
+	buf.Reset()
+	_, err = initiator.writeMessage(&buf, wire.NewMsgVerAck(), pver, net)
+	if err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+	frame := append([]byte(nil), buf.Bytes()...)
+	_, _, _, err = responder.readMessage(&buf, pver, net)
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	_, _, _, err = responder.readMessage(bytes.NewReader(frame), pver, net)
+	if err == nil {
+		t.Fatal("did not receive expected error for replayed frame")
+	}
+
+	// Ensure a frame sealed with different keys fails to authenticate.
+	other, _ := newTestTransports(t)
+	buf.Reset()
+	_, err = other.writeMessage(&buf, wire.NewMsgVerAck(), pver, net)
+	if err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+	_, responder = newTestTransports(t)
+	_, _, _, err = responder.readMessage(&buf, pver, net)
+	if err == nil {
+		t.Fatal("did not receive expected error for frame with wrong keys")
+	}
+
+	// Ensure frames fail to authenticate in both directions when the version
+	// message observed by the initiator differs from the one the responder
+	// sent such as when it is modified in transit.
+	initiator, responder = newTestTransportsWithVersions(t,
+		[]byte("modified version"), testResponderVersion)
+	buf.Reset()
+	_, err = initiator.writeMessage(&buf, wire.NewMsgVerAck(), pver, net)
+	if err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+	_, _, _, err = responder.readMessage(&buf, pver, net)
+	if err == nil {
+		t.Fatal("did not receive expected error for frame with modified " +
+			"version")
+	}
+	buf.Reset()
+	_, err = responder.writeMessage(&buf, wire.NewMsgVerAck(), pver, net)
+	if err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+	_, _, _, err = initiator.readMessage(&buf, pver, net)
+	if err == nil {
+		t.Fatal("did not receive expected error for frame with modified " +
+			"version")
+	}
+}
+
+// TestPeerEncryption ensures connections between peers are upgraded to the
+// encrypted transport per their encryption policies.
+func TestPeerEncryption(t *testing.T) {
+	tests := []struct {
+		name          string
+		inPolicy      EncryptionPolicy
+		outPolicy     EncryptionPolicy
+		wantEncrypted bool
+		wantConnected bool
+	}{{
+		name:          "both preferred",
+		inPolicy:      EncryptionPreferred,
+		outPolicy:     EncryptionPreferred,
+		wantEncrypted: true,
+		wantConnected: true,
+	}, {
+		name:          "required and preferred",
+		inPolicy:      EncryptionPreferred,
+		outPolicy:     EncryptionRequired,
+		wantEncrypted: true,
+		wantConnected: true,
+	}, {
+		name:          "preferred and disabled",
+		inPolicy:      EncryptionDisabled,
+		outPolicy:     EncryptionPreferred,
+		wantEncrypted: false,
+		wantConnected: true,
+	}, {
+		name:          "both disabled",
+		inPolicy:      EncryptionDisabled,
+		outPolicy:     EncryptionDisabled,
+		wantEncrypted: false,
+		wantConnected: true,
+	}, {
+		name:          "required and disabled",
+		inPolicy:      EncryptionDisabled,
+		outPolicy:     EncryptionRequired,
+		wantEncrypted: false,
+		wantConnected: false,
+	}}
+
+	for _, test := range tests {
+		verack := make(chan struct{}, 2)
+		pong := make(chan struct{}, 1)
+		newCfg := func(policy EncryptionPolicy) *Config {
+			return &Config{
+				Listeners: MessageListeners{
+					OnVerAck: func(p *Peer, msg *wire.MsgVerAck) {
+						verack <- struct{}{}
+					},
+					OnPong: func(p *Peer, msg *wire.MsgPong) {
+						pong <- struct{}{}
+					},
+				},
+				UserAgentName:    "peer",
+				UserAgentVersion: "1.0",
+				Net:              wire.MainNet,
+				Encryption:       policy,
+			}
+		}
+
+		inConn, outConn := pipe(
+			&conn{raddr: "10.0.0.1:8333"},
+			&conn{raddr: "10.0.0.2:8333"},
+		)
+		inPeer := NewInboundPeer(newCfg(test.inPolicy))
+		inPeer.AssociateConnection(inConn)
+		outPeer, err := NewOutboundPeer(newCfg(test.outPolicy), "10.0.0.2:8333")
+		if err != nil {
+			t.Fatalf("%q: unexpected error creating peer: %v", test.name, err)
+		}
+		outPeer.AssociateConnection(outConn)
+
+		if !test.wantConnected {
+			select {
+			case <-disconnected(outPeer):
+			case <-time.After(time.Second):
+				t.Fatalf("%q: peer did not disconnect", test.name)
+			}
+			inPeer.Disconnect()
+			continue
+		}
+
+		for i := 0; i < 2; i++ {
+			select {
+			case <-verack:
+			case <-time.After(time.Second):
+				t.Fatalf("%q: verack timeout", test.name)
+			}
+		}
+		if inPeer.Encrypted() != test.wantEncrypted {
+			t.Fatalf("%q: unexpected inbound encryption - got %v, want %v",
+				test.name, inPeer.Encrypted(), test.wantEncrypted)
+		}
+		if outPeer.Encrypted() != test.wantEncrypted {
+			t.Fatalf("%q: unexpected outbound encryption - got %v, want %v",
+				test.name, outPeer.Encrypted(), test.wantEncrypted)
+		}
+
+		// Ensure messages continue to flow after the handshake.
+		outPeer.QueueMessage(wire.NewMsgPing(1), nil)
+		select {
+		case <-pong:
+		case <-time.After(time.Second):
+			t.Fatalf("%q: pong timeout", test.name)
+		}
+
+		inPeer.Disconnect()
+		outPeer.Disconnect()
+		inPeer.WaitForDisconnect()
+		outPeer.WaitForDisconnect()
+	}
+}
+
+// disconnected returns a channel that is closed once the provided peer has
+// disconnected.
+func disconnected(p *Peer) <-chan struct{} {
+	c := make(chan struct{})
+	go func() {
+		p.WaitForDisconnect()
+		close(c)
+	}()
+	return c
+}
+
+// TestEncryptionPolicyStringer tests the stringized output for the encryption
+// policy type.
+func TestEncryptionPolicyStringer(t *testing.T) {
+	tests := []struct {
+		in   EncryptionPolicy
+		want string
+	}{
+		{EncryptionDisabled, "EncryptionDisabled"},
+		{EncryptionPreferred, "EncryptionPreferred"},
+		{EncryptionRequired, "EncryptionRequired"},
+		{0xff, "Unknown EncryptionPolicy (255)"},
+	}
+	for i, test := range tests {
+		result := test.in.String()
+		if result != test.want {
+			t.Errorf("String #%d\n got: %s want: %s", i, result, test.want)
+		}
+	}
+}
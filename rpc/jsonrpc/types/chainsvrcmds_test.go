@@ -1,5 +1,5 @@
 // Copyright (c) 2014 The btcsuite developers
-// Copyright (c) 2016-2022 The Decred developers
+// Copyright (c) 2016-2024 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
@@ -41,6 +41,28 @@ func TestChainSvrCmds(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"addnode","params":["127.0.0.1","remove"],"id":1}`,
 			unmarshalled: &AddNodeCmd{Addr: "127.0.0.1", SubCmd: ANRemove},
 		},
+		{
+			name: "clearbanned",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("clearbanned"))
+			},
+			staticCmd: func() interface{} {
+				return NewClearBannedCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"clearbanned","params":[],"id":1}`,
+			unmarshalled: &ClearBannedCmd{},
+		},
+		{
+			name: "combinepsdt",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("combinepsdt"), []string{"a", "b"})
+			},
+			staticCmd: func() interface{} {
+				return NewCombinePSDTCmd([]string{"a", "b"})
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"combinepsdt","params":[["a","b"]],"id":1}`,
+			unmarshalled: &CombinePSDTCmd{PSDTs: []string{"a", "b"}},
+		},
 		{
 			name: "createrawtransaction",
 			newCmd: func() (interface{}, error) {
@@ -94,6 +116,17 @@ func TestChainSvrCmds(t *testing.T) {
 				LevelSpec: "trace",
 			},
 		},
+		{
+			name: "decodepsdt",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("decodepsdt"), "cHNkdP8=")
+			},
+			staticCmd: func() interface{} {
+				return NewDecodePSDTCmd("cHNkdP8=")
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"decodepsdt","params":["cHNkdP8="],"id":1}`,
+			unmarshalled: &DecodePSDTCmd{PSDT: "cHNkdP8="},
+		},
 		{
 			name: "decoderawtransaction",
 			newCmd: func() (interface{}, error) {
@@ -129,6 +162,31 @@ func TestChainSvrCmds(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"decodescript","params":["00",1],"id":1}`,
 			unmarshalled: &DecodeScriptCmd{HexScript: "00", Version: dcrjson.Uint16(1)},
 		},
+		{
+			name: "deriveaddresses",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("deriveaddresses"), "raw(00)#abcdefgh")
+			},
+			staticCmd: func() interface{} {
+				return NewDeriveAddressesCmd("raw(00)#abcdefgh", nil)
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"deriveaddresses","params":["raw(00)#abcdefgh"],"id":1}`,
+			unmarshalled: &DeriveAddressesCmd{Descriptor: "raw(00)#abcdefgh"},
+		},
+		{
+			name: "deriveaddresses optional",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("deriveaddresses"), "raw(00)#abcdefgh", []uint32{1, 5})
+			},
+			staticCmd: func() interface{} {
+				return NewDeriveAddressesCmd("raw(00)#abcdefgh", &[]uint32{1, 5})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"deriveaddresses","params":["raw(00)#abcdefgh",[1,5]],"id":1}`,
+			unmarshalled: &DeriveAddressesCmd{
+				Descriptor: "raw(00)#abcdefgh",
+				Range:      &[]uint32{1, 5},
+			},
+		},
 		{
 			name: "estimatefee",
 			newCmd: func() (interface{}, error) {
@@ -171,6 +229,34 @@ func TestChainSvrCmds(t *testing.T) {
 				Mode:          EstimateSmartFeeModeAddr(EstimateSmartFeeConservative),
 			},
 		},
+		{
+			name: "finalizepsdt",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("finalizepsdt"), "cHNkdP8=")
+			},
+			staticCmd: func() interface{} {
+				return NewFinalizePSDTCmd("cHNkdP8=", nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"finalizepsdt","params":["cHNkdP8="],"id":1}`,
+			unmarshalled: &FinalizePSDTCmd{
+				PSDT:    "cHNkdP8=",
+				Extract: dcrjson.Bool(true),
+			},
+		},
+		{
+			name: "finalizepsdt optional",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("finalizepsdt"), "cHNkdP8=", false)
+			},
+			staticCmd: func() interface{} {
+				return NewFinalizePSDTCmd("cHNkdP8=", dcrjson.Bool(false))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"finalizepsdt","params":["cHNkdP8=",false],"id":1}`,
+			unmarshalled: &FinalizePSDTCmd{
+				PSDT:    "cHNkdP8=",
+				Extract: dcrjson.Bool(false),
+			},
+		},
 		{
 			name: "generate",
 			newCmd: func() (interface{}, error) {
@@ -209,6 +295,39 @@ func TestChainSvrCmds(t *testing.T) {
 				Node: dcrjson.String("127.0.0.1"),
 			},
 		},
+		{
+			name: "getaddresstxids",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("getaddresstxids"), "1Address")
+			},
+			staticCmd: func() interface{} {
+				return NewGetAddressTxIDsCmd("1Address", nil, nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getaddresstxids","params":["1Address"],"id":1}`,
+			unmarshalled: &GetAddressTxIDsCmd{
+				Address: "1Address",
+				Skip:    dcrjson.Int(0),
+				Count:   dcrjson.Int(100),
+				Reverse: dcrjson.Bool(false),
+			},
+		},
+		{
+			name: "getaddresstxids optional",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("getaddresstxids"), "1Address", 5, 10, true)
+			},
+			staticCmd: func() interface{} {
+				return NewGetAddressTxIDsCmd("1Address", dcrjson.Int(5),
+					dcrjson.Int(10), dcrjson.Bool(true))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getaddresstxids","params":["1Address",5,10,true],"id":1}`,
+			unmarshalled: &GetAddressTxIDsCmd{
+				Address: "1Address",
+				Skip:    dcrjson.Int(5),
+				Count:   dcrjson.Int(10),
+				Reverse: dcrjson.Bool(true),
+			},
+		},
 		{
 			name: "getbestblock",
 			newCmd: func() (interface{}, error) {
@@ -327,6 +446,19 @@ func TestChainSvrCmds(t *testing.T) {
 				Verbose: dcrjson.Bool(true),
 			},
 		},
+		{
+			name: "getblockstats",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("getblockstats"), "123")
+			},
+			staticCmd: func() interface{} {
+				return NewGetBlockStatsCmd("123")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getblockstats","params":["123"],"id":1}`,
+			unmarshalled: &GetBlockStatsCmd{
+				HashOrHeight: "123",
+			},
+		},
 		{
 			name: "getblocksubsidy",
 			newCmd: func() (interface{}, error) {
@@ -387,6 +519,17 @@ func TestChainSvrCmds(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"getcurrentnet","params":[],"id":1}`,
 			unmarshalled: &GetCurrentNetCmd{},
 		},
+		{
+			name: "getdescriptorinfo",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("getdescriptorinfo"), "raw(00)")
+			},
+			staticCmd: func() interface{} {
+				return NewGetDescriptorInfoCmd("raw(00)")
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"getdescriptorinfo","params":["raw(00)"],"id":1}`,
+			unmarshalled: &GetDescriptorInfoCmd{Descriptor: "raw(00)"},
+		},
 		{
 			name: "getdifficulty",
 			newCmd: func() (interface{}, error) {
@@ -517,6 +660,32 @@ func TestChainSvrCmds(t *testing.T) {
 				Height: dcrjson.Int(123),
 			},
 		},
+		{
+			name: "getnodeaddresses",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("getnodeaddresses"))
+			},
+			staticCmd: func() interface{} {
+				return NewGetNodeAddressesCmd(nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getnodeaddresses","params":[],"id":1}`,
+			unmarshalled: &GetNodeAddressesCmd{
+				Count: dcrjson.Int(1),
+			},
+		},
+		{
+			name: "getnodeaddresses optional",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("getnodeaddresses"), 10)
+			},
+			staticCmd: func() interface{} {
+				return NewGetNodeAddressesCmd(dcrjson.Int(10))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getnodeaddresses","params":[10],"id":1}`,
+			unmarshalled: &GetNodeAddressesCmd{
+				Count: dcrjson.Int(10),
+			},
+		},
 		{
 			name: "getpeerinfo",
 			newCmd: func() (interface{}, error) {
@@ -653,6 +822,21 @@ func TestChainSvrCmds(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"gettxoutsetinfo","params":[],"id":1}`,
 			unmarshalled: &GetTxOutSetInfoCmd{},
 		},
+		{
+			name: "gettxspendingprevout",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("gettxspendingprevout"),
+					`[{"txid":"123","vout":1,"tree":0}]`)
+			},
+			staticCmd: func() interface{} {
+				outputs := []TxSpendingPrevOut{{Txid: "123", Vout: 1, Tree: 0}}
+				return NewGetTxSpendingPrevOutCmd(outputs)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"gettxspendingprevout","params":[[{"txid":"123","vout":1,"tree":0}]],"id":1}`,
+			unmarshalled: &GetTxSpendingPrevOutCmd{
+				Outputs: []TxSpendingPrevOut{{Txid: "123", Vout: 1, Tree: 0}},
+			},
+		},
 		{
 			name: "getvoteinfo",
 			newCmd: func() (interface{}, error) {
@@ -760,6 +944,28 @@ func TestChainSvrCmds(t *testing.T) {
 				Command: dcrjson.String("getblock"),
 			},
 		},
+		{
+			name: "listbanned",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("listbanned"))
+			},
+			staticCmd: func() interface{} {
+				return NewListBannedCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"listbanned","params":[],"id":1}`,
+			unmarshalled: &ListBannedCmd{},
+		},
+		{
+			name: "loadmempool",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("loadmempool"))
+			},
+			staticCmd: func() interface{} {
+				return NewLoadMempoolCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"loadmempool","params":[],"id":1}`,
+			unmarshalled: &LoadMempoolCmd{},
+		},
 		{
 			name: "node option remove",
 			newCmd: func() (interface{}, error) {
@@ -814,6 +1020,52 @@ func TestChainSvrCmds(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"ping","params":[],"id":1}`,
 			unmarshalled: &PingCmd{},
 		},
+		{
+			name: "savemempool",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("savemempool"))
+			},
+			staticCmd: func() interface{} {
+				return NewSaveMempoolCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"savemempool","params":[],"id":1}`,
+			unmarshalled: &SaveMempoolCmd{},
+		},
+		{
+			name: "searchrawtransactions",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("searchrawtransactions"), "1Address")
+			},
+			staticCmd: func() interface{} {
+				return NewSearchRawTransactionsCmd("1Address", nil, nil, nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"searchrawtransactions","params":["1Address"],"id":1}`,
+			unmarshalled: &SearchRawTransactionsCmd{
+				Address: "1Address",
+				Verbose: dcrjson.Int(1),
+				Skip:    dcrjson.Int(0),
+				Count:   dcrjson.Int(100),
+				Reverse: dcrjson.Bool(false),
+			},
+		},
+		{
+			name: "searchrawtransactions optional",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("searchrawtransactions"), "1Address", 0, 5, 10, true)
+			},
+			staticCmd: func() interface{} {
+				return NewSearchRawTransactionsCmd("1Address", dcrjson.Int(0),
+					dcrjson.Int(5), dcrjson.Int(10), dcrjson.Bool(true))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"searchrawtransactions","params":["1Address",0,5,10,true],"id":1}`,
+			unmarshalled: &SearchRawTransactionsCmd{
+				Address: "1Address",
+				Verbose: dcrjson.Int(0),
+				Skip:    dcrjson.Int(5),
+				Count:   dcrjson.Int(10),
+				Reverse: dcrjson.Bool(true),
+			},
+		},
 		{
 			name: "sendrawtransaction",
 			newCmd: func() (interface{}, error) {
@@ -842,6 +1094,40 @@ func TestChainSvrCmds(t *testing.T) {
 				AllowHighFees: dcrjson.Bool(false),
 			},
 		},
+		{
+			name: "setban",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("setban"), "10.0.0.0/24", SBAdd)
+			},
+			staticCmd: func() interface{} {
+				return NewSetBanCmd("10.0.0.0/24", SBAdd, nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"setban","params":["10.0.0.0/24","add"],"id":1}`,
+			unmarshalled: &SetBanCmd{
+				Subnet:   "10.0.0.0/24",
+				SubCmd:   SBAdd,
+				BanTime:  dcrjson.Int64(0),
+				Absolute: dcrjson.Bool(false),
+			},
+		},
+		{
+			name: "setban optional",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("setban"), "10.0.0.1", SBAdd,
+					int64(1700000000), true)
+			},
+			staticCmd: func() interface{} {
+				return NewSetBanCmd("10.0.0.1", SBAdd,
+					dcrjson.Int64(1700000000), dcrjson.Bool(true))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"setban","params":["10.0.0.1","add",1700000000,true],"id":1}`,
+			unmarshalled: &SetBanCmd{
+				Subnet:   "10.0.0.1",
+				SubCmd:   SBAdd,
+				BanTime:  dcrjson.Int64(1700000000),
+				Absolute: dcrjson.Bool(true),
+			},
+		},
 		{
 			name: "setgenerate",
 			newCmd: func() (interface{}, error) {
@@ -914,6 +1200,20 @@ func TestChainSvrCmds(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "tracetxinput",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("tracetxinput"), "123", 1)
+			},
+			staticCmd: func() interface{} {
+				return NewTraceTxInputCmd("123", 1)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"tracetxinput","params":["123",1],"id":1}`,
+			unmarshalled: &TraceTxInputCmd{
+				Txid: "123",
+				Vin:  1,
+			},
+		},
 		{
 			name: "validateaddress",
 			newCmd: func() (interface{}, error) {
@@ -1053,3 +1353,57 @@ func TestChainSvrCmds(t *testing.T) {
 		}
 	}
 }
+
+// TestHashOrHeightParams ensures the parameters of commands that identify a
+// block by either its hash or its height accept both JSON strings and JSON
+// numbers and reject other types.
+func TestHashOrHeightParams(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		param   string
+		want    HashOrHeight
+		wantErr bool
+	}{{
+		name:  "hash",
+		param: `"00000000000000001fc4c4c7a3f2ec6d552dda16a3a928f27bd6bd16d8f1e9b3"`,
+		want:  "00000000000000001fc4c4c7a3f2ec6d552dda16a3a928f27bd6bd16d8f1e9b3",
+	}, {
+		name:  "height as string",
+		param: `"432100"`,
+		want:  "432100",
+	}, {
+		name:  "height as number",
+		param: `432100`,
+		want:  "432100",
+	}, {
+		name:    "fractional number",
+		param:   `1.5`,
+		wantErr: true,
+	}, {
+		name:    "bool",
+		param:   `true`,
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		params := []json.RawMessage{json.RawMessage(test.param)}
+		cmd, err := dcrjson.ParseParams(Method("getblockstats"), params)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%s: did not receive expected error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		got := cmd.(*GetBlockStatsCmd).HashOrHeight
+		if got != test.want {
+			t.Errorf("%s: unexpected value -- got %q, want %q", test.name,
+				got, test.want)
+		}
+	}
+}
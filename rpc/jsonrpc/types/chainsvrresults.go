@@ -1,5 +1,5 @@
 // Copyright (c) 2014 The btcsuite developers
-// Copyright (c) 2015-2023 The Decred developers
+// Copyright (c) 2015-2024 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
@@ -26,6 +26,65 @@ type DecodeScriptResult struct {
 	P2sh      string   `json:"p2sh,omitempty"`
 }
 
+// PSDTScript models a redeem script of a partially signed transaction.
+type PSDTScript struct {
+	Asm  string `json:"asm"`
+	Hex  string `json:"hex"`
+	Type string `json:"type"`
+}
+
+// PSDTBip32Derivation models the BIP32 derivation of a public key of a
+// partially signed transaction.
+type PSDTBip32Derivation struct {
+	PubKey            string `json:"pubkey"`
+	MasterFingerprint string `json:"masterfingerprint"`
+	Path              string `json:"path"`
+}
+
+// PSDTPrevOut models the previous output spent by an input of a partially
+// signed transaction.
+type PSDTPrevOut struct {
+	Amount       float64            `json:"amount"`
+	ScriptPubKey ScriptPubKeyResult `json:"scriptPubKey"`
+}
+
+// DecodePSDTInput models the metadata of an input of a partially signed
+// transaction returned from the decodepsdt command.
+type DecodePSDTInput struct {
+	PrevOut           *PSDTPrevOut          `json:"prevout,omitempty"`
+	PartialSignatures map[string]string     `json:"partialsignatures,omitempty"`
+	SigHashType       string                `json:"sighashtype,omitempty"`
+	RedeemScript      *PSDTScript           `json:"redeemscript,omitempty"`
+	Bip32Derivations  []PSDTBip32Derivation `json:"bip32derivs,omitempty"`
+	FinalScriptSig    *ScriptSig            `json:"finalscriptsig,omitempty"`
+	Unknown           map[string]string     `json:"unknown,omitempty"`
+}
+
+// DecodePSDTOutput models the metadata of an output of a partially signed
+// transaction returned from the decodepsdt command.
+type DecodePSDTOutput struct {
+	RedeemScript     *PSDTScript           `json:"redeemscript,omitempty"`
+	Bip32Derivations []PSDTBip32Derivation `json:"bip32derivs,omitempty"`
+	Unknown          map[string]string     `json:"unknown,omitempty"`
+}
+
+// DecodePSDTResult models the data returned from the decodepsdt command.
+type DecodePSDTResult struct {
+	Tx      TxRawDecodeResult  `json:"tx"`
+	TxType  string             `json:"txtype"`
+	Unknown map[string]string  `json:"unknown,omitempty"`
+	Inputs  []DecodePSDTInput  `json:"inputs"`
+	Outputs []DecodePSDTOutput `json:"outputs"`
+	Fee     *float64           `json:"fee,omitempty"`
+}
+
+// FinalizePSDTResult models the data returned from the finalizepsdt command.
+type FinalizePSDTResult struct {
+	PSDT     string `json:"psdt,omitempty"`
+	Hex      string `json:"hex,omitempty"`
+	Complete bool   `json:"complete"`
+}
+
 // EstimateSmartFeeResult models the data returned from the estimatesmartfee
 // command.
 type EstimateSmartFeeResult struct {
@@ -130,6 +189,8 @@ type GetBlockChainInfoResult struct {
 	ChainWork            string                `json:"chainwork"`
 	InitialBlockDownload bool                  `json:"initialblockdownload"`
 	MaxBlockSize         int64                 `json:"maxblocksize"`
+	Pruned               bool                  `json:"pruned"`
+	PruneHeight          int64                 `json:"pruneheight,omitempty"`
 	Deployments          map[string]AgendaInfo `json:"deployments"`
 }
 
@@ -164,6 +225,42 @@ type GetBlockHeaderVerboseResult struct {
 	NextHash      string  `json:"nextblockhash,omitempty"`
 }
 
+// GetBlockStatsResult models the data returned from the getblockstats command.
+// All amounts are in atoms and all fee rates are in atoms per kilobyte.
+type GetBlockStatsResult struct {
+	Hash               string  `json:"hash"`
+	Height             int64   `json:"height"`
+	Time               int64   `json:"time"`
+	Txs                int64   `json:"txs"`
+	Ins                int64   `json:"ins"`
+	Outs               int64   `json:"outs"`
+	TotalSize          int64   `json:"totalsize"`
+	TotalOut           int64   `json:"totalout"`
+	TotalFee           int64   `json:"totalfee"`
+	AvgFee             int64   `json:"avgfee"`
+	MinFee             int64   `json:"minfee"`
+	MaxFee             int64   `json:"maxfee"`
+	MedianFee          int64   `json:"medianfee"`
+	AvgFeeRate         int64   `json:"avgfeerate"`
+	MinFeeRate         int64   `json:"minfeerate"`
+	MaxFeeRate         int64   `json:"maxfeerate"`
+	FeeRatePercentiles []int64 `json:"feeratepercentiles"`
+	AvgTxSize          int64   `json:"avgtxsize"`
+	MinTxSize          int64   `json:"mintxsize"`
+	MaxTxSize          int64   `json:"maxtxsize"`
+	MedianTxSize       int64   `json:"mediantxsize"`
+	UtxoIncrease       int64   `json:"utxoincrease"`
+	UtxoSizeInc        int64   `json:"utxosizeinc"`
+	Votes              int64   `json:"votes"`
+	Tickets            int64   `json:"tickets"`
+	Revocations        int64   `json:"revocations"`
+	TSpends            int64   `json:"tspends"`
+	TAdds              int64   `json:"tadds"`
+	PoWSubsidy         int64   `json:"powsubsidy"`
+	PoSSubsidy         int64   `json:"possubsidy"`
+	TreasurySubsidy    int64   `json:"treasurysubsidy"`
+}
+
 // GetBlockSubsidyResult models the data returned from the getblocksubsidy
 // command.
 type GetBlockSubsidyResult struct {
@@ -189,6 +286,15 @@ type GetCFilterV2Result struct {
 	ProofHashes []string `json:"proofhashes"`
 }
 
+// GetDescriptorInfoResult models the data returned from the getdescriptorinfo
+// command.
+type GetDescriptorInfoResult struct {
+	Descriptor     string `json:"descriptor"`
+	Checksum       string `json:"checksum"`
+	IsRange        bool   `json:"isrange"`
+	HasPrivateKeys bool   `json:"hasprivatekeys"`
+}
+
 // GetHeadersResult models the data returned by the chain server getheaders
 // command.
 type GetHeadersResult struct {
@@ -213,8 +319,11 @@ type InfoChainResult struct {
 // GetMempoolInfoResult models the data returned from the getmempoolinfo
 // command.
 type GetMempoolInfoResult struct {
-	Size  int64 `json:"size"`
-	Bytes int64 `json:"bytes"`
+	Size          int64   `json:"size"`
+	Bytes         int64   `json:"bytes"`
+	MaxMempool    int64   `json:"maxmempool"`
+	MempoolMinFee float64 `json:"mempoolminfee"`
+	Evicted       uint64  `json:"evicted"`
 }
 
 // GetMiningInfoResult models the data from the getmininginfo command.
@@ -272,6 +381,21 @@ type GetNetTotalsResult struct {
 	TimeMillis     int64  `json:"timemillis"`
 }
 
+// GetNodeAddressesResult models the data returned from the getnodeaddresses
+// command.  The group is the network group the address is considered part of
+// for the purposes of peer diversity.  It is the autonomous system number (ASN)
+// of the address, which is also provided separately, when an ASN map is loaded
+// and contains the address and the network prefix of the address otherwise.
+type GetNodeAddressesResult struct {
+	Time     int64  `json:"time"`
+	Services string `json:"services"`
+	Address  string `json:"address"`
+	Port     uint16 `json:"port"`
+	Network  string `json:"network"`
+	Group    string `json:"group"`
+	ASN      uint32 `json:"asn,omitempty"`
+}
+
 // GetPeerInfoResult models the data returned from the getpeerinfo command.
 type GetPeerInfoResult struct {
 	ID             int32   `json:"id"`
@@ -290,6 +414,7 @@ type GetPeerInfoResult struct {
 	Version        uint32  `json:"version"`
 	SubVer         string  `json:"subver"`
 	Inbound        bool    `json:"inbound"`
+	ConnType       string  `json:"conntype"`
 	StartingHeight int64   `json:"startingheight"`
 	CurrentHeight  int64   `json:"currentheight,omitempty"`
 	BanScore       int32   `json:"banscore"`
@@ -398,6 +523,20 @@ type GetTxOutSetInfoResult struct {
 	TotalAmount    int64  `json:"totalamount"`
 }
 
+// GetTxSpendingPrevOutResult models the data returned from the
+// gettxspendingprevout command for each requested output.  The spending
+// fields are only set when the output is spent and the block fields are only
+// set when the spending transaction is confirmed.
+type GetTxSpendingPrevOutResult struct {
+	Txid         string  `json:"txid"`
+	Vout         uint32  `json:"vout"`
+	Tree         int8    `json:"tree"`
+	SpendingTxid string  `json:"spendingtxid,omitempty"`
+	SpendingVin  *uint32 `json:"spendingvin,omitempty"`
+	BlockHash    string  `json:"blockhash,omitempty"`
+	BlockHeight  int64   `json:"blockheight,omitempty"`
+}
+
 // Choice models an individual choice inside an Agenda.
 type Choice struct {
 	ID          string  `json:"id"`
@@ -479,6 +618,27 @@ type LiveTicketsResult struct {
 	Tickets []string `json:"tickets"`
 }
 
+// ListBannedResult models the data returned for each entry from the listbanned
+// command.
+type ListBannedResult struct {
+	Address     string `json:"address"`
+	BanCreated  int64  `json:"bancreated"`
+	BannedUntil int64  `json:"banneduntil"`
+}
+
+// LoadMempoolResult models the data returned from the loadmempool command.
+type LoadMempoolResult struct {
+	Filename string `json:"filename"`
+	Accepted int64  `json:"accepted"`
+	Rejected int64  `json:"rejected"`
+}
+
+// SaveMempoolResult models the data returned from the savemempool command.
+type SaveMempoolResult struct {
+	Filename string `json:"filename"`
+	Count    int64  `json:"count"`
+}
+
 // FeeInfoBlock is ticket fee information about a block.
 type FeeInfoBlock struct {
 	Height uint32  `json:"height"`
@@ -529,6 +689,35 @@ type TicketFeeInfoResult struct {
 	FeeInfoWindows []FeeInfoWindow `json:"feeinfowindows"`
 }
 
+// ScriptTraceStep models a single opcode executed by the script engine as
+// returned from the tracetxinput command.  The stacks are hex-encoded with the
+// last item being the top of the stack.
+type ScriptTraceStep struct {
+	ScriptIdx      int      `json:"scriptidx"`
+	OpcodeIdx      int      `json:"opcodeidx"`
+	Opcode         string   `json:"opcode"`
+	Stack          []string `json:"stack"`
+	AltStack       []string `json:"altstack"`
+	CondStack      []bool   `json:"condstack"`
+	StackAfter     []string `json:"stackafter"`
+	AltStackAfter  []string `json:"altstackafter"`
+	CondStackAfter []bool   `json:"condstackafter"`
+	Error          string   `json:"error,omitempty"`
+}
+
+// TraceTxInputResult models the data returned from the tracetxinput command.
+type TraceTxInputResult struct {
+	Txid         string             `json:"txid"`
+	Vin          uint32             `json:"vin"`
+	Amount       float64            `json:"amount"`
+	ScriptSig    ScriptSig          `json:"scriptSig"`
+	ScriptPubKey ScriptPubKeyResult `json:"scriptPubKey"`
+	Steps        []ScriptTraceStep  `json:"steps"`
+	Truncated    bool               `json:"truncated,omitempty"`
+	Valid        bool               `json:"valid"`
+	Error        string             `json:"error,omitempty"`
+}
+
 // TxFeeInfoResult models the data returned from the ticketfeeinfo command.
 type TxFeeInfoResult struct {
 	FeeInfoMempool FeeInfoMempool `json:"feeinfomempool"`
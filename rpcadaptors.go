@@ -1,5 +1,5 @@
 // Copyright (c) 2017 The btcsuite developers
-// Copyright (c) 2015-2022 The Decred developers
+// Copyright (c) 2015-2024 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
@@ -20,6 +20,7 @@ import (
 	"github.com/decred/dcrd/internal/mining/cpuminer"
 	"github.com/decred/dcrd/internal/netsync"
 	"github.com/decred/dcrd/internal/rpcserver"
+	"github.com/decred/dcrd/internal/staging/banmanager"
 	"github.com/decred/dcrd/peer/v3"
 	"github.com/decred/dcrd/wire"
 )
@@ -63,6 +64,23 @@ func (p *rpcPeer) Inbound() bool {
 	return (*serverPeer)(p).Peer.Inbound()
 }
 
+// ConnType returns a description of the type of connection with the peer such
+// as whether it is inbound, manual, or an outbound connection that relays
+// everything or only blocks.
+//
+// This function is safe for concurrent access and is part of the rpcserver.Peer
+// interface implementation.
+func (p *rpcPeer) ConnType() string {
+	sp := (*serverPeer)(p)
+	switch {
+	case sp.Inbound():
+		return "inbound"
+	case sp.persistent:
+		return "manual"
+	}
+	return sp.connType.String()
+}
+
 // StatsSnapshot returns a snapshot of the current peer flags and statistics.
 //
 // This function is safe for concurrent access and is part of the rpcserver.Peer
@@ -306,6 +324,60 @@ func (*rpcConnManager) Lookup(host string) ([]net.IP, error) {
 	return dcrdLookup(host)
 }
 
+// BanSubnet adds the provided subnet to the ban list until the provided
+// expiration time and disconnects all connected peers with an address in the
+// subnet.
+//
+// This function is safe for concurrent access and is part of the
+// rpcserver.ConnManager interface implementation.
+func (cm *rpcConnManager) BanSubnet(subnet *net.IPNet, created, expires time.Time) error {
+	if err := cm.server.banList.Ban(subnet, created, expires); err != nil {
+		return err
+	}
+
+	// Each disconnect request disconnects at most one inbound peer, so keep
+	// issuing them until no more matching peers are found.
+	replyChan := make(chan error)
+	for {
+		cm.server.query <- disconnectNodeMsg{
+			cmp: func(sp *serverPeer) bool {
+				return subnet.Contains(sp.NA().IP)
+			},
+			reply: replyChan,
+		}
+		if err := <-replyChan; err != nil {
+			break
+		}
+	}
+	return nil
+}
+
+// UnbanSubnet removes the provided subnet from the ban list.  An error is
+// returned when the subnet is not banned.
+//
+// This function is safe for concurrent access and is part of the
+// rpcserver.ConnManager interface implementation.
+func (cm *rpcConnManager) UnbanSubnet(subnet *net.IPNet) error {
+	return cm.server.banList.Unban(subnet)
+}
+
+// BannedSubnets returns the entries in the ban list with bans that have not
+// expired.
+//
+// This function is safe for concurrent access and is part of the
+// rpcserver.ConnManager interface implementation.
+func (cm *rpcConnManager) BannedSubnets() []banmanager.BanEntry {
+	return cm.server.banList.Entries(time.Now())
+}
+
+// ClearBanned removes all entries from the ban list.
+//
+// This function is safe for concurrent access and is part of the
+// rpcserver.ConnManager interface implementation.
+func (cm *rpcConnManager) ClearBanned() error {
+	return cm.server.banList.Clear()
+}
+
 // rpcSyncMgr provides an adaptor for use with the RPC server and implements the
 // rpcserver.SyncManager interface.
 type rpcSyncMgr struct {
@@ -364,6 +436,40 @@ func (b *rpcSyncMgr) RecentlyConfirmedTxn(hash *chainhash.Hash) bool {
 	return b.server.recentlyConfirmedTxns.Contains(hash[:])
 }
 
+// rpcMempoolPersister provides an adaptor for use with the RPC server and
+// implements the rpcserver.MempoolPersister interface.
+type rpcMempoolPersister struct {
+	server *server
+}
+
+// Ensure rpcMempoolPersister implements the rpcserver.MempoolPersister
+// interface.
+var _ rpcserver.MempoolPersister = (*rpcMempoolPersister)(nil)
+
+// SaveMempool writes the contents of the mempool to the mempool file in the
+// data directory and returns the path of the file along with the number of
+// transactions that were written.
+//
+// This function is safe for concurrent access and is part of the
+// rpcserver.MempoolPersister interface implementation.
+func (p *rpcMempoolPersister) SaveMempool() (string, int, error) {
+	path := p.server.mempoolFilePath()
+	numTxns, err := p.server.txMemPool.DumpFile(path)
+	return path, numTxns, err
+}
+
+// LoadMempool loads the transactions in the mempool file in the data directory
+// into the mempool and returns the path of the file along with the number of
+// transactions that were accepted and rejected.
+//
+// This function is safe for concurrent access and is part of the
+// rpcserver.MempoolPersister interface implementation.
+func (p *rpcMempoolPersister) LoadMempool() (string, int, int, error) {
+	path := p.server.mempoolFilePath()
+	accepted, rejected, err := p.server.txMemPool.LoadFile(path)
+	return path, accepted, rejected, err
+}
+
 // rpcUtxoEntry represents a utxo entry for use with the RPC server and
 // implements the rpcserver.UtxoEntry interface.
 type rpcUtxoEntry struct {
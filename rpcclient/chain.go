@@ -10,6 +10,7 @@ import (
 	"context"
 	"encoding/hex"
 	"encoding/json"
+	"strconv"
 
 	"github.com/decred/dcrd/chaincfg/chainhash"
 	"github.com/decred/dcrd/dcrjson/v4"
@@ -433,6 +434,69 @@ func (c *Client) GetBlockHeaderVerbose(ctx context.Context, hash *chainhash.Hash
 	return c.GetBlockHeaderVerboseAsync(ctx, hash).Receive()
 }
 
+// FutureGetBlockStatsResult is a future promise to deliver the result of a
+// GetBlockStatsAsync RPC invocation (or an applicable error).
+type FutureGetBlockStatsResult cmdRes
+
+// Receive waits for the response promised by the future and returns the
+// statistics of the requested block.
+func (r *FutureGetBlockStatsResult) Receive() (*chainjson.GetBlockStatsResult, error) {
+	res, err := receiveFuture(r.ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal the result
+	var stats chainjson.GetBlockStatsResult
+	err = json.Unmarshal(res, &stats)
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// GetBlockStatsAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See GetBlockStats for the blocking version and more details.
+func (c *Client) GetBlockStatsAsync(ctx context.Context, hash *chainhash.Hash) *FutureGetBlockStatsResult {
+	hashOrHeight := ""
+	if hash != nil {
+		hashOrHeight = hash.String()
+	}
+
+	cmd := chainjson.NewGetBlockStatsCmd(chainjson.HashOrHeight(hashOrHeight))
+	return (*FutureGetBlockStatsResult)(c.sendCmd(ctx, cmd))
+}
+
+// GetBlockStats returns statistics about the transactions, fees, and subsidy of
+// the main chain block with the given hash.
+//
+// See GetBlockStatsByHeight to identify the block by its height instead.
+func (c *Client) GetBlockStats(ctx context.Context, hash *chainhash.Hash) (*chainjson.GetBlockStatsResult, error) {
+	return c.GetBlockStatsAsync(ctx, hash).Receive()
+}
+
+// GetBlockStatsByHeightAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See GetBlockStatsByHeight for the blocking version and more details.
+func (c *Client) GetBlockStatsByHeightAsync(ctx context.Context, height int64) *FutureGetBlockStatsResult {
+	hashOrHeight := chainjson.HashOrHeight(strconv.FormatInt(height, 10))
+	cmd := chainjson.NewGetBlockStatsCmd(hashOrHeight)
+	return (*FutureGetBlockStatsResult)(c.sendCmd(ctx, cmd))
+}
+
+// GetBlockStatsByHeight returns statistics about the transactions, fees, and
+// subsidy of the block at the given height in the main chain.
+//
+// See GetBlockStats to identify the block by its hash instead.
+func (c *Client) GetBlockStatsByHeight(ctx context.Context, height int64) (*chainjson.GetBlockStatsResult, error) {
+	return c.GetBlockStatsByHeightAsync(ctx, height).Receive()
+}
+
 // FutureGetBlockSubsidyResult is a future promise to deliver the result of a
 // GetBlockSubsidyAsync RPC invocation (or an applicable error).
 type FutureGetBlockSubsidyResult cmdRes
@@ -855,6 +919,54 @@ func (c *Client) GetTxOut(ctx context.Context, txHash *chainhash.Hash, index uin
 	return c.GetTxOutAsync(ctx, txHash, index, tree, mempool).Receive()
 }
 
+// FutureGetTxSpendingPrevOutResult is a future promise to deliver the result of
+// a GetTxSpendingPrevOutAsync RPC invocation (or an applicable error).
+type FutureGetTxSpendingPrevOutResult cmdRes
+
+// Receive waits for the response promised by the future and returns the
+// transactions that spend the requested outputs.
+func (r *FutureGetTxSpendingPrevOutResult) Receive() ([]chainjson.GetTxSpendingPrevOutResult, error) {
+	res, err := receiveFuture(r.ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as an array of gettxspendingprevout result objects.
+	var results []chainjson.GetTxSpendingPrevOutResult
+	err = json.Unmarshal(res, &results)
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// GetTxSpendingPrevOutAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See GetTxSpendingPrevOut for the blocking version and more details.
+func (c *Client) GetTxSpendingPrevOutAsync(ctx context.Context, outpoints []wire.OutPoint) *FutureGetTxSpendingPrevOutResult {
+	outputs := make([]chainjson.TxSpendingPrevOut, 0, len(outpoints))
+	for i := range outpoints {
+		outputs = append(outputs, chainjson.TxSpendingPrevOut{
+			Txid: outpoints[i].Hash.String(),
+			Vout: outpoints[i].Index,
+			Tree: outpoints[i].Tree,
+		})
+	}
+
+	cmd := chainjson.NewGetTxSpendingPrevOutCmd(outputs)
+	return (*FutureGetTxSpendingPrevOutResult)(c.sendCmd(ctx, cmd))
+}
+
+// GetTxSpendingPrevOut returns the transactions that spend the provided
+// outpoints.  The mempool is always checked, while the main chain is only
+// checked when the server has the spend index enabled.
+func (c *Client) GetTxSpendingPrevOut(ctx context.Context, outpoints []wire.OutPoint) ([]chainjson.GetTxSpendingPrevOutResult, error) {
+	return c.GetTxSpendingPrevOutAsync(ctx, outpoints).Receive()
+}
+
 // FutureRescanResult is a future promise to deliver the result of a
 // RescanAsynnc RPC invocation (or an applicable error).
 type FutureRescanResult cmdRes
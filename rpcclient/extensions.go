@@ -1,5 +1,5 @@
 // Copyright (c) 2014-2015 The btcsuite developers
-// Copyright (c) 2015-2022 The Decred developers
+// Copyright (c) 2015-2024 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
@@ -70,6 +70,51 @@ func (c *Client) DebugLevel(ctx context.Context, levelSpec string) (string, erro
 	return c.DebugLevelAsync(ctx, levelSpec).Receive()
 }
 
+// FutureDeriveAddressesResult is a future promise to deliver the result of a
+// DeriveAddressesAsync RPC invocation (or an applicable error).
+type FutureDeriveAddressesResult cmdRes
+
+// Receive waits for the response promised by the future and returns the
+// addresses derived from the descriptor.
+func (r *FutureDeriveAddressesResult) Receive() ([]string, error) {
+	res, err := receiveFuture(r.ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal the result as an array of strings.
+	var addrs []string
+	err = json.Unmarshal(res, &addrs)
+	if err != nil {
+		return nil, err
+	}
+	return addrs, nil
+}
+
+// DeriveAddressesAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See DeriveAddresses for the blocking version and more details.
+//
+// NOTE: This is a dcrd extension.
+func (c *Client) DeriveAddressesAsync(ctx context.Context, descriptor string, derivRange *[]uint32) *FutureDeriveAddressesResult {
+	cmd := chainjson.NewDeriveAddressesCmd(descriptor, derivRange)
+	return (*FutureDeriveAddressesResult)(c.sendCmd(ctx, cmd))
+}
+
+// DeriveAddresses returns the addresses described by the provided output
+// descriptor, which must include its checksum.
+//
+// The range must be nil for descriptors that are not ranged and either the end
+// or the beginning and end of the child indices to derive for ranged
+// descriptors.
+//
+// NOTE: This is a dcrd extension.
+func (c *Client) DeriveAddresses(ctx context.Context, descriptor string, derivRange *[]uint32) ([]string, error) {
+	return c.DeriveAddressesAsync(ctx, descriptor, derivRange).Receive()
+}
+
 // FutureEstimateStakeDiffResult is a future promise to deliver the result of a
 // EstimateStakeDiffAsync RPC invocation (or an applicable error).
 type FutureEstimateStakeDiffResult cmdRes
@@ -403,6 +448,49 @@ func (c *Client) GetCurrentNet(ctx context.Context) (wire.CurrencyNet, error) {
 	return c.GetCurrentNetAsync(ctx).Receive()
 }
 
+// FutureGetDescriptorInfoResult is a future promise to deliver the result of a
+// GetDescriptorInfoAsync RPC invocation (or an applicable error).
+type FutureGetDescriptorInfoResult cmdRes
+
+// Receive waits for the response promised by the future and returns
+// information about the descriptor.
+func (r *FutureGetDescriptorInfoResult) Receive() (*chainjson.GetDescriptorInfoResult, error) {
+	res, err := receiveFuture(r.ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a getdescriptorinfo result object.
+	var infoResult chainjson.GetDescriptorInfoResult
+	err = json.Unmarshal(res, &infoResult)
+	if err != nil {
+		return nil, err
+	}
+
+	return &infoResult, nil
+}
+
+// GetDescriptorInfoAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See GetDescriptorInfo for the blocking version and more details.
+//
+// NOTE: This is a dcrd extension.
+func (c *Client) GetDescriptorInfoAsync(ctx context.Context, descriptor string) *FutureGetDescriptorInfoResult {
+	cmd := chainjson.NewGetDescriptorInfoCmd(descriptor)
+	return (*FutureGetDescriptorInfoResult)(c.sendCmd(ctx, cmd))
+}
+
+// GetDescriptorInfo returns the canonical form of the provided output
+// descriptor along with its checksum and whether it is ranged and contains
+// private keys.
+//
+// NOTE: This is a dcrd extension.
+func (c *Client) GetDescriptorInfo(ctx context.Context, descriptor string) (*chainjson.GetDescriptorInfoResult, error) {
+	return c.GetDescriptorInfoAsync(ctx, descriptor).Receive()
+}
+
 // FutureGetHeadersResult is a future promise to deliver the result of a
 // getheaders RPC invocation (or an applicable error).
 type FutureGetHeadersResult cmdRes
@@ -7,8 +7,8 @@ require (
 	github.com/decred/dcrd/dcrjson/v4 v4.0.1
 	github.com/decred/dcrd/dcrutil/v4 v4.0.1
 	github.com/decred/dcrd/gcs/v4 v4.0.0
-	github.com/decred/dcrd/rpc/jsonrpc/types/v4 v4.0.0
-	github.com/decred/dcrd/txscript/v4 v4.1.0
+	github.com/decred/dcrd/rpc/jsonrpc/types/v4 v4.1.0
+	github.com/decred/dcrd/txscript/v4 v4.2.0
 	github.com/decred/dcrd/wire v1.6.0
 	github.com/decred/go-socks v1.1.0
 	github.com/decred/slog v1.2.0
@@ -30,3 +30,8 @@ require (
 	github.com/klauspost/cpuid/v2 v2.0.9 // indirect
 	lukechampine.com/blake3 v1.2.1 // indirect
 )
+
+replace (
+	github.com/decred/dcrd/rpc/jsonrpc/types/v4 => ../rpc/jsonrpc/types
+	github.com/decred/dcrd/txscript/v4 => ../txscript
+)
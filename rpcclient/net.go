@@ -1,5 +1,5 @@
 // Copyright (c) 2014-2015 The btcsuite developers
-// Copyright (c) 2015-2022 The Decred developers
+// Copyright (c) 2015-2024 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
@@ -249,6 +249,46 @@ func (c *Client) GetPeerInfo(ctx context.Context) ([]chainjson.GetPeerInfoResult
 	return c.GetPeerInfoAsync(ctx).Receive()
 }
 
+// FutureGetNodeAddressesResult is a future promise to deliver the result of a
+// GetNodeAddressesAsync RPC invocation (or an applicable error).
+type FutureGetNodeAddressesResult cmdRes
+
+// Receive waits for the response promised by the future and returns known
+// good addresses of potential peers along with their network groups.
+func (r *FutureGetNodeAddressesResult) Receive() ([]chainjson.GetNodeAddressesResult, error) {
+	res, err := receiveFuture(r.ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as an array of getnodeaddresses result objects.
+	var addrs []chainjson.GetNodeAddressesResult
+	err = json.Unmarshal(res, &addrs)
+	if err != nil {
+		return nil, err
+	}
+
+	return addrs, nil
+}
+
+// GetNodeAddressesAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See GetNodeAddresses for the blocking version and more details.
+func (c *Client) GetNodeAddressesAsync(ctx context.Context, count int) *FutureGetNodeAddressesResult {
+	cmd := chainjson.NewGetNodeAddressesCmd(&count)
+	return (*FutureGetNodeAddressesResult)(c.sendCmd(ctx, cmd))
+}
+
+// GetNodeAddresses returns up to the provided number of known good addresses
+// of potential peers along with the network groups they are considered part of
+// for the purposes of peer diversity.  A count of 0 returns all available
+// addresses.
+func (c *Client) GetNodeAddresses(ctx context.Context, count int) ([]chainjson.GetNodeAddressesResult, error) {
+	return c.GetNodeAddressesAsync(ctx, count).Receive()
+}
+
 // FutureGetNetTotalsResult is a future promise to deliver the result of a
 // GetNetTotalsAsync RPC invocation (or an applicable error).
 type FutureGetNetTotalsResult cmdRes
@@ -322,3 +362,121 @@ func (c *Client) GetNetworkInfoAsync(ctx context.Context) *FutureGetNetworkInfoR
 func (c *Client) GetNetworkInfo(ctx context.Context) (*chainjson.GetNetworkInfoResult, error) {
 	return c.GetNetworkInfoAsync(ctx).Receive()
 }
+
+// SetBanCommand enumerates the available commands that the SetBan function
+// accepts.
+type SetBanCommand string
+
+// Constants used to indicate the command for the SetBan function.
+const (
+	// SBAdd indicates the specified IP address or subnet should be added to
+	// the ban list.
+	SBAdd SetBanCommand = "add"
+
+	// SBRemove indicates the specified IP address or subnet should be
+	// removed from the ban list.
+	SBRemove SetBanCommand = "remove"
+)
+
+// String returns the SetBanCommand in human-readable form.
+func (cmd SetBanCommand) String() string {
+	return string(cmd)
+}
+
+// FutureSetBanResult is a future promise to deliver the result of a
+// SetBanAsync RPC invocation (or an applicable error).
+type FutureSetBanResult cmdRes
+
+// Receive waits for the response promised by the future and returns an error if
+// any occurred when performing the specified command.
+func (r *FutureSetBanResult) Receive() error {
+	_, err := receiveFuture(r.ctx, r.c)
+	return err
+}
+
+// SetBanAsync returns an instance of a type that can be used to get the result
+// of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See SetBan for the blocking version and more details.
+func (c *Client) SetBanAsync(ctx context.Context, subnet string, command SetBanCommand, banTime int64, absolute bool) *FutureSetBanResult {
+	cmd := chainjson.NewSetBanCmd(subnet, chainjson.SetBanSubCmd(command),
+		&banTime, &absolute)
+	return (*FutureSetBanResult)(c.sendCmd(ctx, cmd))
+}
+
+// SetBan attempts to perform the passed command on the passed IP address or
+// subnet in CIDR notation.  For example, it can be used to add a subnet to or
+// remove a subnet from the ban list of the server.
+//
+// When adding a ban, the ban time is the number of seconds to ban for or, when
+// absolute is true, the unix time the ban expires.  A ban time of 0 uses the
+// default ban duration of the server.
+func (c *Client) SetBan(ctx context.Context, subnet string, command SetBanCommand, banTime int64, absolute bool) error {
+	return c.SetBanAsync(ctx, subnet, command, banTime, absolute).Receive()
+}
+
+// FutureListBannedResult is a future promise to deliver the result of a
+// ListBannedAsync RPC invocation (or an applicable error).
+type FutureListBannedResult cmdRes
+
+// Receive waits for the response promised by the future and returns the
+// entries in the ban list.
+func (r *FutureListBannedResult) Receive() ([]chainjson.ListBannedResult, error) {
+	res, err := receiveFuture(r.ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal as an array of listbanned result objects.
+	var banned []chainjson.ListBannedResult
+	err = json.Unmarshal(res, &banned)
+	if err != nil {
+		return nil, err
+	}
+
+	return banned, nil
+}
+
+// ListBannedAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See ListBanned for the blocking version and more details.
+func (c *Client) ListBannedAsync(ctx context.Context) *FutureListBannedResult {
+	cmd := chainjson.NewListBannedCmd()
+	return (*FutureListBannedResult)(c.sendCmd(ctx, cmd))
+}
+
+// ListBanned returns the IP addresses and subnets in the ban list of the
+// server along with the times the bans were created and expire.
+func (c *Client) ListBanned(ctx context.Context) ([]chainjson.ListBannedResult, error) {
+	return c.ListBannedAsync(ctx).Receive()
+}
+
+// FutureClearBannedResult is a future promise to deliver the result of a
+// ClearBannedAsync RPC invocation (or an applicable error).
+type FutureClearBannedResult cmdRes
+
+// Receive waits for the response promised by the future and returns an error if
+// any occurred when clearing the ban list.
+func (r *FutureClearBannedResult) Receive() error {
+	_, err := receiveFuture(r.ctx, r.c)
+	return err
+}
+
+// ClearBannedAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See ClearBanned for the blocking version and more details.
+func (c *Client) ClearBannedAsync(ctx context.Context) *FutureClearBannedResult {
+	cmd := chainjson.NewClearBannedCmd()
+	return (*FutureClearBannedResult)(c.sendCmd(ctx, cmd))
+}
+
+// ClearBanned removes all IP addresses and subnets from the ban list of the
+// server.
+func (c *Client) ClearBanned(ctx context.Context) error {
+	return c.ClearBannedAsync(ctx).Receive()
+}
@@ -1,5 +1,5 @@
 // Copyright (c) 2014-2016 The btcsuite developers
-// Copyright (c) 2015-2023 The Decred developers
+// Copyright (c) 2015-2024 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
@@ -15,6 +15,7 @@ import (
 	"github.com/decred/dcrd/dcrjson/v4"
 	"github.com/decred/dcrd/dcrutil/v4"
 	chainjson "github.com/decred/dcrd/rpc/jsonrpc/types/v4"
+	"github.com/decred/dcrd/txscript/v4/psdt"
 	"github.com/decred/dcrd/txscript/v4/stdaddr"
 	"github.com/decred/dcrd/wire"
 )
@@ -160,6 +161,138 @@ func (c *Client) DecodeRawTransaction(ctx context.Context, serializedTx []byte)
 	return c.DecodeRawTransactionAsync(ctx, serializedTx).Receive()
 }
 
+// FutureCombinePSDTResult is a future promise to deliver the result of a
+// CombinePSDTAsync RPC invocation (or an applicable error).
+type FutureCombinePSDTResult cmdRes
+
+// Receive waits for the response promised by the future and returns the
+// combined partially signed transaction.
+func (r *FutureCombinePSDTResult) Receive() (*psdt.Packet, error) {
+	res, err := receiveFuture(r.ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a string.
+	var psdtB64 string
+	err = json.Unmarshal(res, &psdtB64)
+	if err != nil {
+		return nil, err
+	}
+
+	return psdt.ParseBase64(psdtB64)
+}
+
+// CombinePSDTAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See CombinePSDT for the blocking version and more details.
+func (c *Client) CombinePSDTAsync(ctx context.Context, packets []*psdt.Packet) *FutureCombinePSDTResult {
+	psdts := make([]string, 0, len(packets))
+	for _, p := range packets {
+		psdtB64, err := p.Base64()
+		if err != nil {
+			return (*FutureCombinePSDTResult)(newFutureError(ctx, err))
+		}
+		psdts = append(psdts, psdtB64)
+	}
+	cmd := chainjson.NewCombinePSDTCmd(psdts)
+	return (*FutureCombinePSDTResult)(c.sendCmd(ctx, cmd))
+}
+
+// CombinePSDT combines multiple partially signed transactions for the same
+// transaction into a single partially signed transaction.
+func (c *Client) CombinePSDT(ctx context.Context, packets []*psdt.Packet) (*psdt.Packet, error) {
+	return c.CombinePSDTAsync(ctx, packets).Receive()
+}
+
+// FutureDecodePSDTResult is a future promise to deliver the result of a
+// DecodePSDTAsync RPC invocation (or an applicable error).
+type FutureDecodePSDTResult cmdRes
+
+// Receive waits for the response promised by the future and returns information
+// about a partially signed transaction.
+func (r *FutureDecodePSDTResult) Receive() (*chainjson.DecodePSDTResult, error) {
+	res, err := receiveFuture(r.ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a decodepsdt result object.
+	var decodeResult chainjson.DecodePSDTResult
+	err = json.Unmarshal(res, &decodeResult)
+	if err != nil {
+		return nil, err
+	}
+
+	return &decodeResult, nil
+}
+
+// DecodePSDTAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See DecodePSDT for the blocking version and more details.
+func (c *Client) DecodePSDTAsync(ctx context.Context, packet *psdt.Packet) *FutureDecodePSDTResult {
+	psdtB64, err := packet.Base64()
+	if err != nil {
+		return (*FutureDecodePSDTResult)(newFutureError(ctx, err))
+	}
+	cmd := chainjson.NewDecodePSDTCmd(psdtB64)
+	return (*FutureDecodePSDTResult)(c.sendCmd(ctx, cmd))
+}
+
+// DecodePSDT returns information about a partially signed transaction.
+func (c *Client) DecodePSDT(ctx context.Context, packet *psdt.Packet) (*chainjson.DecodePSDTResult, error) {
+	return c.DecodePSDTAsync(ctx, packet).Receive()
+}
+
+// FutureFinalizePSDTResult is a future promise to deliver the result of a
+// FinalizePSDTAsync RPC invocation (or an applicable error).
+type FutureFinalizePSDTResult cmdRes
+
+// Receive waits for the response promised by the future and returns the
+// finalized partially signed transaction or the signed transaction when it is
+// complete and extraction was requested.
+func (r *FutureFinalizePSDTResult) Receive() (*chainjson.FinalizePSDTResult, error) {
+	res, err := receiveFuture(r.ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a finalizepsdt result object.
+	var finalizeResult chainjson.FinalizePSDTResult
+	err = json.Unmarshal(res, &finalizeResult)
+	if err != nil {
+		return nil, err
+	}
+
+	return &finalizeResult, nil
+}
+
+// FinalizePSDTAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See FinalizePSDT for the blocking version and more details.
+func (c *Client) FinalizePSDTAsync(ctx context.Context, packet *psdt.Packet, extract bool) *FutureFinalizePSDTResult {
+	psdtB64, err := packet.Base64()
+	if err != nil {
+		return (*FutureFinalizePSDTResult)(newFutureError(ctx, err))
+	}
+	cmd := chainjson.NewFinalizePSDTCmd(psdtB64, &extract)
+	return (*FutureFinalizePSDTResult)(c.sendCmd(ctx, cmd))
+}
+
+// FinalizePSDT finalizes the inputs of the provided partially signed
+// transaction that have enough signatures.  The signed transaction is returned
+// in place of the partially signed transaction when all inputs are finalized
+// and extract is true.
+func (c *Client) FinalizePSDT(ctx context.Context, packet *psdt.Packet, extract bool) (*chainjson.FinalizePSDTResult, error) {
+	return c.FinalizePSDTAsync(ctx, packet, extract).Receive()
+}
+
 // FutureCreateRawTransactionResult is a future promise to deliver the result
 // of a CreateRawTransactionAsync RPC invocation (or an applicable error).
 type FutureCreateRawTransactionResult cmdRes
@@ -394,3 +527,214 @@ func (c *Client) SendRawTransactionAsync(ctx context.Context, tx *wire.MsgTx, al
 func (c *Client) SendRawTransaction(ctx context.Context, tx *wire.MsgTx, allowHighFees bool) (*chainhash.Hash, error) {
 	return c.SendRawTransactionAsync(ctx, tx, allowHighFees).Receive()
 }
+
+// FutureSearchRawTransactionsResult is a future promise to deliver the result
+// of a SearchRawTransactionsAsync RPC invocation (or an applicable error).
+type FutureSearchRawTransactionsResult cmdRes
+
+// Receive waits for the response promised by the future and returns the
+// found raw transactions.
+func (r *FutureSearchRawTransactionsResult) Receive() ([]*wire.MsgTx, error) {
+	res, err := receiveFuture(r.ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal as an array of strings.
+	var searchRawTxnsResult []string
+	err = json.Unmarshal(res, &searchRawTxnsResult)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decode and deserialize each transaction.
+	msgTxns := make([]*wire.MsgTx, 0, len(searchRawTxnsResult))
+	for _, hexTx := range searchRawTxnsResult {
+		// Decode the serialized transaction hex to raw bytes.
+		serializedTx, err := hex.DecodeString(hexTx)
+		if err != nil {
+			return nil, err
+		}
+
+		// Deserialize the transaction and add it to the result slice.
+		var msgTx wire.MsgTx
+		err = msgTx.Deserialize(bytes.NewReader(serializedTx))
+		if err != nil {
+			return nil, err
+		}
+		msgTxns = append(msgTxns, &msgTx)
+	}
+
+	return msgTxns, nil
+}
+
+// SearchRawTransactionsAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See SearchRawTransactions for the blocking version and more details.
+func (c *Client) SearchRawTransactionsAsync(ctx context.Context, address stdaddr.Address, skip, count int, reverse bool) *FutureSearchRawTransactionsResult {
+	addr := address.String()
+	verbose := dcrjson.Int(0)
+	cmd := chainjson.NewSearchRawTransactionsCmd(addr, verbose, &skip, &count,
+		&reverse)
+	return (*FutureSearchRawTransactionsResult)(c.sendCmd(ctx, cmd))
+}
+
+// SearchRawTransactions returns transactions that involve the passed address.
+// Confirmed transactions are returned in the order they appear in the main
+// chain followed by unconfirmed transactions in the mempool, or the reverse of
+// that order when the reverse flag is set.
+//
+// NOTE: Chain servers do not typically provide this capability unless it has
+// specifically been enabled.
+//
+// See SearchRawTransactionsVerbose to retrieve a list of data structures with
+// information about the transactions instead of the transactions themselves.
+func (c *Client) SearchRawTransactions(ctx context.Context, address stdaddr.Address, skip, count int, reverse bool) ([]*wire.MsgTx, error) {
+	return c.SearchRawTransactionsAsync(ctx, address, skip, count,
+		reverse).Receive()
+}
+
+// FutureSearchRawTransactionsVerboseResult is a future promise to deliver the
+// result of the SearchRawTransactionsVerboseAsync RPC invocation (or an
+// applicable error).
+type FutureSearchRawTransactionsVerboseResult cmdRes
+
+// Receive waits for the response promised by the future and returns the
+// found raw transactions.
+func (r *FutureSearchRawTransactionsVerboseResult) Receive() ([]*chainjson.TxRawResult, error) {
+	res, err := receiveFuture(r.ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal as an array of raw transaction results.
+	var result []*chainjson.TxRawResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// SearchRawTransactionsVerboseAsync returns an instance of a type that can be
+// used to get the result of the RPC at some future time by invoking the
+// Receive function on the returned instance.
+//
+// See SearchRawTransactionsVerbose for the blocking version and more details.
+func (c *Client) SearchRawTransactionsVerboseAsync(ctx context.Context, address stdaddr.Address, skip, count int, reverse bool) *FutureSearchRawTransactionsVerboseResult {
+	addr := address.String()
+	verbose := dcrjson.Int(1)
+	cmd := chainjson.NewSearchRawTransactionsCmd(addr, verbose, &skip, &count,
+		&reverse)
+	return (*FutureSearchRawTransactionsVerboseResult)(c.sendCmd(ctx, cmd))
+}
+
+// SearchRawTransactionsVerbose returns a list of data structures that
+// describe transactions which involve the passed address.
+//
+// NOTE: Chain servers do not typically provide this capability unless it has
+// specifically been enabled.
+//
+// See SearchRawTransactions to retrieve a list of raw transactions instead.
+func (c *Client) SearchRawTransactionsVerbose(ctx context.Context, address stdaddr.Address, skip, count int, reverse bool) ([]*chainjson.TxRawResult, error) {
+	return c.SearchRawTransactionsVerboseAsync(ctx, address, skip, count,
+		reverse).Receive()
+}
+
+// FutureGetAddressTxIDsResult is a future promise to deliver the result of a
+// GetAddressTxIDsAsync RPC invocation (or an applicable error).
+type FutureGetAddressTxIDsResult cmdRes
+
+// Receive waits for the response promised by the future and returns the
+// hashes of the found transactions.
+func (r *FutureGetAddressTxIDsResult) Receive() ([]*chainhash.Hash, error) {
+	res, err := receiveFuture(r.ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal as an array of strings.
+	var txHashStrs []string
+	err = json.Unmarshal(res, &txHashStrs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decode each transaction hash.
+	txHashes := make([]*chainhash.Hash, 0, len(txHashStrs))
+	for _, txHashStr := range txHashStrs {
+		txHash, err := chainhash.NewHashFromStr(txHashStr)
+		if err != nil {
+			return nil, err
+		}
+		txHashes = append(txHashes, txHash)
+	}
+
+	return txHashes, nil
+}
+
+// GetAddressTxIDsAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See GetAddressTxIDs for the blocking version and more details.
+func (c *Client) GetAddressTxIDsAsync(ctx context.Context, address stdaddr.Address, skip, count int, reverse bool) *FutureGetAddressTxIDsResult {
+	cmd := chainjson.NewGetAddressTxIDsCmd(address.String(), &skip, &count,
+		&reverse)
+	return (*FutureGetAddressTxIDsResult)(c.sendCmd(ctx, cmd))
+}
+
+// GetAddressTxIDs returns the hashes of the transactions that involve the
+// passed address.
+//
+// NOTE: Chain servers do not typically provide this capability unless it has
+// specifically been enabled.
+//
+// See SearchRawTransactions to retrieve the transactions themselves.
+func (c *Client) GetAddressTxIDs(ctx context.Context, address stdaddr.Address, skip, count int, reverse bool) ([]*chainhash.Hash, error) {
+	return c.GetAddressTxIDsAsync(ctx, address, skip, count, reverse).Receive()
+}
+
+// FutureTraceTxInputResult is a future promise to deliver the result of a
+// TraceTxInputAsync RPC invocation (or an applicable error).
+type FutureTraceTxInputResult cmdRes
+
+// Receive waits for the response promised by the future and returns the trace
+// of the execution of the scripts of the transaction input.
+func (r *FutureTraceTxInputResult) Receive() (*chainjson.TraceTxInputResult, error) {
+	res, err := receiveFuture(r.ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a tracetxinput result object.
+	var traceResult chainjson.TraceTxInputResult
+	err = json.Unmarshal(res, &traceResult)
+	if err != nil {
+		return nil, err
+	}
+
+	return &traceResult, nil
+}
+
+// TraceTxInputAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See TraceTxInput for the blocking version and more details.
+func (c *Client) TraceTxInputAsync(ctx context.Context, txHash *chainhash.Hash, vin uint32) *FutureTraceTxInputResult {
+	cmd := chainjson.NewTraceTxInputCmd(txHash.String(), vin)
+	return (*FutureTraceTxInputResult)(c.sendCmd(ctx, cmd))
+}
+
+// TraceTxInput executes the scripts of the provided input of a mempool or
+// chain transaction and returns a trace of every executed opcode.
+//
+// NOTE: Transactions that are not in the mempool require the server to have
+// the transaction index enabled.
+func (c *Client) TraceTxInput(ctx context.Context, txHash *chainhash.Hash, vin uint32) (*chainjson.TraceTxInputResult, error) {
+	return c.TraceTxInputAsync(ctx, txHash, vin).Receive()
+}
@@ -18,6 +18,7 @@ import (
 	"net"
 	"os"
 	"path"
+	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
@@ -37,12 +38,14 @@ import (
 	"github.com/decred/dcrd/dcrutil/v4"
 	"github.com/decred/dcrd/internal/blockchain"
 	"github.com/decred/dcrd/internal/blockchain/indexers"
+	"github.com/decred/dcrd/internal/bloom"
 	"github.com/decred/dcrd/internal/fees"
 	"github.com/decred/dcrd/internal/mempool"
 	"github.com/decred/dcrd/internal/mining"
 	"github.com/decred/dcrd/internal/mining/cpuminer"
 	"github.com/decred/dcrd/internal/netsync"
 	"github.com/decred/dcrd/internal/rpcserver"
+	"github.com/decred/dcrd/internal/staging/banmanager"
 	"github.com/decred/dcrd/internal/version"
 	"github.com/decred/dcrd/math/uint256"
 	"github.com/decred/dcrd/peer/v3"
@@ -74,7 +77,7 @@ const (
 	connectionRetryInterval = time.Second * 5
 
 	// maxProtocolVersion is the max protocol version the server supports.
-	maxProtocolVersion = wire.RemoveRejectVersion
+	maxProtocolVersion = wire.CFHeadersV2Version
 
 	// These fields are used to track known addresses on a per-peer basis.
 	//
@@ -94,6 +97,20 @@ const (
 	// submissions cached.
 	maxCachedNaSubmissions = 20
 
+	// mempoolFilename is the name of the file in the data directory the
+	// contents of the mempool are saved to on shutdown and loaded from on
+	// startup.
+	mempoolFilename = "mempool.dat"
+
+	// banListFilename is the name of the file in the data directory the ban
+	// list is persisted to.
+	banListFilename = "banlist.json"
+
+	// feeFilterUpdateInterval is the interval at which the minimum relay fee
+	// of the mempool is checked for changes in order to notify peers via
+	// feefilter messages.
+	feeFilterUpdateInterval = time.Minute
+
 	// These constants control the maximum number of simultaneous pending
 	// getdata messages and the individual data item requests they make without
 	// being disconnected.
@@ -326,12 +343,11 @@ func (sc *naSubmissionCache) bestSubmission(net addrmgr.NetAddressType) *naSubmi
 }
 
 // peerState maintains state of inbound, persistent, outbound peers as well
-// as banned peers and outbound groups.
+// as outbound groups.
 type peerState struct {
 	inboundPeers    map[int32]*serverPeer
 	outboundPeers   map[int32]*serverPeer
 	persistentPeers map[int32]*serverPeer
-	banned          map[string]time.Time
 	outboundGroups  map[string]int
 	subCache        *naSubmissionCache
 }
@@ -502,6 +518,7 @@ type server struct {
 
 	chainParams          *chaincfg.Params
 	addrManager          *addrmgr.AddrManager
+	banList              *banmanager.BanList
 	connManager          *connmgr.ConnManager
 	sigCache             *txscript.SigCache
 	subsidyCache         *standalone.SubsidyCache
@@ -531,8 +548,16 @@ type server struct {
 	// do not need to be protected for concurrent access.
 	indexSubscriber *indexers.IndexSubscriber
 	txIndex         *indexers.TxIndex
+	addrIndex       *indexers.AddrIndex
+	spendIndex      *indexers.SpendIndex
 	existsAddrIndex *indexers.ExistsAddrIndex
 
+	// The following fields are used for the optional metrics server.  They
+	// will be nil if the metrics server is not enabled.  These fields are set
+	// during initial creation of the server and never changed afterwards.
+	metrics          *serverMetrics
+	metricsListeners []net.Listener
+
 	// These following fields are used to filter duplicate block lottery data
 	// anouncements.
 	lotteryDataBroadcastMtx sync.RWMutex
@@ -541,6 +566,12 @@ type server struct {
 	// recentlyConfirmedTxns tracks transactions that have been confirmed in the
 	// most recent blocks.
 	recentlyConfirmedTxns *apbf.Filter
+
+	// mempoolLoaded tracks whether or not the persisted mempool contents have
+	// been loaded on startup.  The mempool is only saved on shutdown once it
+	// has been loaded to avoid overwriting the persisted contents with a
+	// partially loaded mempool.
+	mempoolLoaded atomic.Bool
 }
 
 // serverPeer extends the peer to maintain state shared by the server.
@@ -548,6 +579,7 @@ type serverPeer struct {
 	*peer.Peer
 
 	connReq        *connmgr.ConnReq
+	connType       connmgr.ConnType
 	server         *server
 	persistent     bool
 	continueHash   atomic.Pointer[chainhash.Hash]
@@ -555,6 +587,7 @@ type serverPeer struct {
 	disableRelayTx bool
 	isWhitelisted  bool
 	knownAddresses *apbf.Filter
+	filter         *bloom.Filter
 	banScore       connmgr.DynamicBanScore
 	quit           chan struct{}
 
@@ -582,6 +615,10 @@ type serverPeer struct {
 	// used to filter duplicates.
 	announcedBlock *chainhash.Hash
 
+	// sentFeeFilter tracks the most recent minimum relay fee announced to the
+	// peer via a feefilter message.
+	sentFeeFilter atomic.Int64
+
 	// The following fields are used to serve getdata requests asynchronously as
 	// opposed to directly in the peer input handler.
 	//
@@ -594,6 +631,12 @@ type serverPeer struct {
 	numPendingGetDataItemReqs atomic.Uint32
 }
 
+// isBlockRelayOnly returns whether or not the peer is an outbound connection
+// that only relays blocks and headers and never transactions or addresses.
+func (sp *serverPeer) isBlockRelayOnly() bool {
+	return sp.connType == connmgr.ConnTypeBlockRelay
+}
+
 // newServerPeer returns a new serverPeer instance. The peer needs to be set by
 // the caller.
 func newServerPeer(s *server, isPersistent bool) *serverPeer {
@@ -601,6 +644,7 @@ func newServerPeer(s *server, isPersistent bool) *serverPeer {
 		server:         s,
 		persistent:     isPersistent,
 		knownAddresses: apbf.NewFilter(maxKnownAddrsPerPeer, knownAddrsFPRate),
+		filter:         bloom.LoadFilter(nil),
 		quit:           make(chan struct{}),
 		txProcessed:    make(chan struct{}, 1),
 		blockProcessed: make(chan struct{}, 1),
@@ -623,6 +667,7 @@ func (sp *serverPeer) handleServeGetData(invVects []*wire.InvVect,
 	for _, iv := range invVects {
 		var sendInv bool
 		var dataMsg wire.Message
+		var matchedTxns []*dcrutil.Tx
 		switch iv.Type {
 		case wire.InvTypeTx:
 			// Attempt to fetch the requested transaction from the pool.  A call
@@ -668,6 +713,25 @@ func (sp *serverPeer) handleServeGetData(invVects []*wire.InvVect,
 			continueHash := sp.continueHash.Load()
 			sendInv = continueHash != nil && *continueHash == *blockHash
 
+		case wire.InvTypeFilteredBlock:
+			// Filtered blocks are only served to peers that have loaded a
+			// bloom filter.
+			if !sp.filter.IsLoaded() {
+				break
+			}
+
+			blockHash := &iv.Hash
+			block, err := sp.server.chain.BlockByHash(blockHash)
+			if err != nil {
+				peerLog.Tracef("Unable to fetch requested block hash %v: %v",
+					blockHash, err)
+				break
+			}
+
+			// The transactions that match the filter are sent immediately
+			// after the merkleblock message as required by the protocol.
+			dataMsg, matchedTxns = bloom.NewMerkleBlock(block, sp.filter)
+
 		default:
 			peerLog.Warnf("Unknown type '%d' in inventory request from %s",
 				iv.Type, sp)
@@ -718,6 +782,9 @@ func (sp *serverPeer) handleServeGetData(invVects []*wire.InvVect,
 		// data to be sent to the peer.
 		sp.numPendingGetDataItemReqs.Add(^uint32(0))
 		sp.QueueMessage(dataMsg, sendDoneChan)
+		for _, tx := range matchedTxns {
+			sp.QueueMessage(tx.MsgTx(), nil)
+		}
 
 		// Send a new inventory message to trigger the peer to issue another
 		// getblocks message for the next batch of inventory if needed.
@@ -868,13 +935,141 @@ func addrmgrToWireNetAddress(netAddr *addrmgr.NetAddress) *wire.NetAddress {
 		netAddr.IP, netAddr.Port)
 }
 
+// wireV2ToAddrmgrNetAddress converts a wire NetAddressV2 to an address manager
+// NetAddress.  False is returned when the address belongs to a network that is
+// not supported by the address manager or is otherwise invalid.
+func wireV2ToAddrmgrNetAddress(netAddr *wire.NetAddressV2) (*addrmgr.NetAddress, bool) {
+	var addrType addrmgr.NetAddressType
+	switch netAddr.NetID {
+	case wire.NetIDIPv4, wire.NetIDIPv6:
+		ip := net.IP(netAddr.Addr).To16()
+		if ip == nil {
+			return nil, false
+		}
+		newNetAddr := addrmgr.NewNetAddressIPPort(ip, netAddr.Port,
+			netAddr.Services)
+		newNetAddr.Timestamp = netAddr.Timestamp
+		return newNetAddr, true
+
+	case wire.NetIDTorV2:
+		// TORv2 addresses are represented by the address manager in the
+		// OnionCat IPv6 range.
+		ip := make(net.IP, 0, net.IPv6len)
+		ip = append(ip, 0xfd, 0x87, 0xd8, 0x7e, 0xeb, 0x43)
+		ip = append(ip, netAddr.Addr...)
+		if len(ip) != net.IPv6len {
+			return nil, false
+		}
+		newNetAddr := addrmgr.NewNetAddressIPPort(ip, netAddr.Port,
+			netAddr.Services)
+		newNetAddr.Timestamp = netAddr.Timestamp
+		return newNetAddr, true
+
+	case wire.NetIDTorV3:
+		addrType = addrmgr.TORv3Address
+	case wire.NetIDI2P:
+		addrType = addrmgr.I2PAddress
+	case wire.NetIDCJDNS:
+		addrType = addrmgr.CJDNSAddress
+	default:
+		return nil, false
+	}
+
+	newNetAddr, err := addrmgr.NewNetAddressFromParams(addrType, netAddr.Addr,
+		netAddr.Port, netAddr.Timestamp, netAddr.Services)
+	if err != nil {
+		return nil, false
+	}
+	return newNetAddr, true
+}
+
+// wireV2ToAddrmgrNetAddresses converts a collection of wire v2 net addresses to
+// a collection of address manager net addresses.  Addresses that belong to
+// networks that are not supported by the address manager are skipped.
+func wireV2ToAddrmgrNetAddresses(netAddrs []*wire.NetAddressV2) []*addrmgr.NetAddress {
+	addrs := make([]*addrmgr.NetAddress, 0, len(netAddrs))
+	for _, wireAddr := range netAddrs {
+		if addr, ok := wireV2ToAddrmgrNetAddress(wireAddr); ok {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
+
+// addrmgrToWireNetAddressV2 converts an address manager net address to a wire
+// v2 net address.
+func addrmgrToWireNetAddressV2(netAddr *addrmgr.NetAddress) *wire.NetAddressV2 {
+	var netID wire.NetworkID
+	addr := netAddr.IP
+	switch netAddr.Type {
+	case addrmgr.TORv2Address:
+		// Strip the OnionCat prefix.
+		netID = wire.NetIDTorV2
+		addr = addr[6:]
+	case addrmgr.TORv3Address:
+		netID = wire.NetIDTorV3
+	case addrmgr.I2PAddress:
+		netID = wire.NetIDI2P
+	case addrmgr.CJDNSAddress:
+		netID = wire.NetIDCJDNS
+	default:
+		ip := net.IP(netAddr.IP)
+		if ip4 := ip.To4(); ip4 != nil {
+			netID = wire.NetIDIPv4
+			addr = ip4
+		} else {
+			netID = wire.NetIDIPv6
+			addr = ip.To16()
+		}
+	}
+	return wire.NewNetAddressV2(netAddr.Timestamp, netAddr.Services, netID,
+		addr, netAddr.Port)
+}
+
+// isAddrV1Compatible returns whether or not the provided address can be
+// represented in an addr message as opposed to only an addrv2 message.
+func isAddrV1Compatible(netAddr *addrmgr.NetAddress) bool {
+	switch netAddr.Type {
+	case addrmgr.TORv3Address, addrmgr.I2PAddress, addrmgr.CJDNSAddress:
+		return false
+	}
+	return true
+}
+
+// pushAddrV2Msg sends an addrv2 message to the connected peer using the
+// provided addresses.
+func (sp *serverPeer) pushAddrV2Msg(addresses []*addrmgr.NetAddress) {
+	// Filter addresses already known to the peer.
+	addrs := make([]*wire.NetAddressV2, 0, len(addresses))
+	for _, addr := range addresses {
+		if !sp.addressKnown(addr) {
+			addrs = append(addrs, addrmgrToWireNetAddressV2(addr))
+		}
+	}
+	known, err := sp.PushAddrV2Msg(addrs)
+	if err != nil {
+		peerLog.Errorf("Can't push address message to %s: %v", sp, err)
+		sp.Disconnect()
+		return
+	}
+
+	knownNetAddrs := wireV2ToAddrmgrNetAddresses(known)
+	sp.addKnownAddresses(knownNetAddrs)
+}
+
 // pushAddrMsg sends an addr message to the connected peer using the provided
-// addresses.
+// addresses.  An addrv2 message is sent instead when the peer supports it.
 func (sp *serverPeer) pushAddrMsg(addresses []*addrmgr.NetAddress) {
-	// Filter addresses already known to the peer.
+	if sp.ProtocolVersion() >= wire.AddrV2Version {
+		sp.pushAddrV2Msg(addresses)
+		return
+	}
+
+	// Filter addresses already known to the peer along with those that can
+	// only be represented by addrv2 messages.
 	addrs := make([]*wire.NetAddress, 0, len(addresses))
 	for _, addr := range addresses {
-		if !sp.addressKnown(addr) {
+		if !sp.addressKnown(addr) && isAddrV1Compatible(addr) {
 			wireNetAddr := addrmgrToWireNetAddress(addr)
 			addrs = append(addrs, wireNetAddr)
 		}
@@ -1010,8 +1205,8 @@ func (sp *serverPeer) OnVersion(_ *peer.Peer, msg *wire.MsgVersion) {
 		}
 
 		// Request known addresses if the server address manager needs
-		// more.
-		if addrManager.NeedMoreAddresses() {
+		// more.  Block relay only peers are never asked for addresses.
+		if addrManager.NeedMoreAddresses() && !sp.isBlockRelayOnly() {
 			sp.QueueMessage(wire.NewMsgGetAddr(), nil)
 		}
 
@@ -1026,8 +1221,9 @@ func (sp *serverPeer) OnVersion(_ *peer.Peer, msg *wire.MsgVersion) {
 	sp.peerNa = &msg.AddrYou
 	sp.peerNaMtx.Unlock()
 
-	// Choose whether or not to relay transactions.
-	sp.setDisableRelayTx(msg.DisableRelayTx)
+	// Choose whether or not to relay transactions.  Transactions are never
+	// relayed to block relay only peers regardless of their preference.
+	sp.setDisableRelayTx(msg.DisableRelayTx || sp.isBlockRelayOnly())
 
 	// Add the remote peer time as a sample for creating an offset against
 	// the local clock to keep the network time in sync.
@@ -1039,9 +1235,36 @@ func (sp *serverPeer) OnVersion(_ *peer.Peer, msg *wire.MsgVersion) {
 
 // OnVerAck is invoked when a peer receives a verack wire message.  It creates
 // and sends a sendheaders message to request all block annoucements are made
-// via full headers instead of the inv message.
+// via full headers instead of the inv message.  It also sends a sendcmpct
+// message to peers that support compact blocks unless transaction relay is
+// disabled since compact blocks rely on the transactions being in the mempool.
+// Only outbound peers are asked to announce new blocks via compact blocks.
 func (sp *serverPeer) OnVerAck(_ *peer.Peer, msg *wire.MsgVerAck) {
 	sp.QueueMessage(wire.NewMsgSendHeaders(), nil)
+	if !cfg.BlocksOnly && sp.ProtocolVersion() >= wire.CompactBlocksVersion {
+		sp.QueueMessage(wire.NewMsgSendCmpct(!sp.Inbound(),
+			wire.CmpctBlockVersion), nil)
+	}
+	sp.maybePushFeeFilter(sp.server.txMemPool.MinRelayFee())
+}
+
+// maybePushFeeFilter sends a feefilter message with the provided minimum relay
+// fee to the peer when it differs from the one most recently sent and the peer
+// supports the message.  Nothing is sent when transaction relay is disabled via
+// the blocksonly option or the peer is a block relay only connection since the
+// peer is not expected to relay transactions in that case.
+//
+// This function is safe for concurrent access.
+func (sp *serverPeer) maybePushFeeFilter(minFee dcrutil.Amount) {
+	if cfg.BlocksOnly || sp.isBlockRelayOnly() ||
+		sp.ProtocolVersion() < wire.FeeFilterVersion {
+
+		return
+	}
+	if sp.sentFeeFilter.Swap(int64(minFee)) == int64(minFee) {
+		return
+	}
+	sp.QueueMessage(&wire.MsgFeeFilter{MinFee: int64(minFee)}, nil)
 }
 
 // OnMemPool is invoked when a peer receives a mempool wire message.  It creates
@@ -1064,8 +1287,14 @@ func (sp *serverPeer) OnMemPool(_ *peer.Peer, msg *wire.MsgMemPool) {
 	txMemPool := sp.server.txMemPool
 	txDescs := txMemPool.TxDescs()
 
-	// Send the inventory message if there is anything to send.
+	// Send the inventory message if there is anything to send while only
+	// including the transactions that match the bloom filter loaded by the
+	// peer, if any.
+	filterLoaded := sp.filter.IsLoaded()
 	for _, txDesc := range txDescs {
+		if filterLoaded && !sp.filter.MatchTxAndUpdate(txDesc.Tx) {
+			continue
+		}
 		iv := wire.NewInvVect(wire.InvTypeTx, txDesc.Tx.Hash())
 		sp.QueueInventory(iv)
 	}
@@ -1287,6 +1516,11 @@ func (sp *serverPeer) OnTx(_ *peer.Peer, msg *wire.MsgTx) {
 			msg.TxHash(), sp)
 		return
 	}
+	if sp.isBlockRelayOnly() {
+		peerLog.Tracef("Ignoring tx %v from block relay only peer %v",
+			msg.TxHash(), sp)
+		return
+	}
 
 	// Add the transaction to the known inventory for the peer.
 	// Convert the raw MsgTx to a dcrutil.Tx which provides some convenience
@@ -1327,6 +1561,71 @@ func (sp *serverPeer) OnBlock(_ *peer.Peer, msg *wire.MsgBlock, buf []byte) {
 	<-sp.blockProcessed
 }
 
+// OnCmpctBlock is invoked when a peer receives a cmpctblock wire message.  It
+// blocks until the compact block has been processed which includes processing
+// the reconstructed block when all of its transactions are available.
+func (sp *serverPeer) OnCmpctBlock(_ *peer.Peer, msg *wire.MsgCmpctBlock) {
+	// Add the block to the known inventory for the peer.
+	blockHash := msg.Header.BlockHash()
+	iv := wire.NewInvVect(wire.InvTypeBlock, &blockHash)
+	sp.AddKnownInventory(iv)
+
+	// Queue the compact block up to be handled by the net sync manager and
+	// intentionally block further receives until it is processed for the same
+	// reasons as full blocks.
+	sp.server.syncManager.QueueCmpctBlock(msg, sp.syncMgrPeer,
+		sp.blockProcessed)
+	<-sp.blockProcessed
+}
+
+// OnGetBlockTxn is invoked when a peer receives a getblocktxn wire message.  It
+// responds with a blocktxn message that contains the requested transactions of
+// the block or a notfound message when the block is not available.
+func (sp *serverPeer) OnGetBlockTxn(_ *peer.Peer, msg *wire.MsgGetBlockTxn) {
+	block, err := sp.server.chain.BlockByHash(&msg.BlockHash)
+	if err != nil {
+		notFound := wire.NewMsgNotFound()
+		iv := wire.NewInvVect(wire.InvTypeBlock, &msg.BlockHash)
+		notFound.AddInvVect(iv)
+		sp.QueueMessage(notFound, nil)
+		return
+	}
+
+	msgBlock := block.MsgBlock()
+	reply := wire.NewMsgBlockTxn(&msg.BlockHash)
+	for _, index := range msg.Indexes {
+		if index >= uint32(len(msgBlock.Transactions)) {
+			peerLog.Debugf("Peer %s requested out of range regular "+
+				"transaction %d of block %s -- disconnecting", sp, index,
+				msg.BlockHash)
+			sp.Disconnect()
+			return
+		}
+		reply.Transactions = append(reply.Transactions,
+			msgBlock.Transactions[index])
+	}
+	for _, index := range msg.StakeIndexes {
+		if index >= uint32(len(msgBlock.STransactions)) {
+			peerLog.Debugf("Peer %s requested out of range stake "+
+				"transaction %d of block %s -- disconnecting", sp, index,
+				msg.BlockHash)
+			sp.Disconnect()
+			return
+		}
+		reply.STransactions = append(reply.STransactions,
+			msgBlock.STransactions[index])
+	}
+	sp.QueueMessage(reply, nil)
+}
+
+// OnBlockTxn is invoked when a peer receives a blocktxn wire message.  It
+// blocks until the block the transactions complete has been processed.
+func (sp *serverPeer) OnBlockTxn(_ *peer.Peer, msg *wire.MsgBlockTxn) {
+	sp.server.syncManager.QueueBlockTxn(msg, sp.syncMgrPeer,
+		sp.blockProcessed)
+	<-sp.blockProcessed
+}
+
 // OnInv is invoked when a peer receives an inv wire message and is used to
 // examine the inventory being advertised by the remote peer and react
 // accordingly.  We pass the message down to the net sync manager which will
@@ -1338,7 +1637,9 @@ func (sp *serverPeer) OnInv(_ *peer.Peer, msg *wire.MsgInv) {
 		return
 	}
 
-	if !cfg.BlocksOnly {
+	// Transaction announcements are only expected when transaction relay is
+	// enabled for the peer.
+	if !cfg.BlocksOnly && !sp.isBlockRelayOnly() {
 		sp.server.syncManager.QueueInv(msg, sp.syncMgrPeer)
 		return
 	}
@@ -1545,6 +1846,48 @@ func (sp *serverPeer) OnGetCFilterV2(_ *peer.Peer, msg *wire.MsgGetCFilterV2) {
 	sp.QueueMessage(filterMsg, nil)
 }
 
+// OnCFilterV2 is invoked when a peer receives a cfilterv2 wire message.  The
+// filters are only requested when running in light mode, so they are ignored
+// otherwise.
+func (sp *serverPeer) OnCFilterV2(_ *peer.Peer, msg *wire.MsgCFilterV2) {
+	if !cfg.LightMode {
+		return
+	}
+	sp.server.syncManager.QueueCFilterV2(msg, sp.syncMgrPeer)
+}
+
+// OnGetCFHeadersV2 is invoked when a peer receives a getcfhdrsv2 wire message.
+func (sp *serverPeer) OnGetCFHeadersV2(_ *peer.Peer, msg *wire.MsgGetCFHeadersV2) {
+	// Find the most recent known block in the best chain based on the block
+	// locator and fetch the hashes of all of the blocks after it until either
+	// wire.MaxCFHeadersV2PerMsg have been fetched or the provided stop hash is
+	// encountered.
+	chain := sp.server.chain
+	hashes := chain.LocateBlocks(msg.BlockLocatorHashes, &msg.HashStop,
+		wire.MaxCFHeadersV2PerMsg)
+
+	// Attempt to obtain the filter and header commitment proof for each block.
+	//
+	// Stop at the first missing filter so the response always consists of
+	// the filter hashes for a contiguous range of blocks.
+	headersMsg := &wire.MsgCFHeadersV2{
+		Headers: make([]*wire.CFHeaderV2, 0, len(hashes)),
+	}
+	for i := range hashes {
+		blockHash := &hashes[i]
+		filter, proof, err := chain.FilterByBlockHash(blockHash)
+		if err != nil {
+			break
+		}
+
+		filterHash := filter.Hash()
+		header := wire.NewCFHeaderV2(blockHash, &filterHash, proof.ProofIndex,
+			proof.ProofHashes)
+		headersMsg.Headers = append(headersMsg.Headers, header)
+	}
+	sp.QueueMessage(headersMsg, nil)
+}
+
 // OnGetCFHeaders is invoked when a peer receives a getcfheader wire message.
 func (sp *serverPeer) OnGetCFHeaders(_ *peer.Peer, msg *wire.MsgGetCFHeaders) {
 	// Disconnect and/or ban depending on the node cf services flag and
@@ -1559,6 +1902,120 @@ func (sp *serverPeer) OnGetCFTypes(_ *peer.Peer, msg *wire.MsgGetCFTypes) {
 	sp.enforceNodeCFFlag(msg.Command())
 }
 
+// enforceNodeBloomFlag disconnects the peer if the server does not advertise
+// support for bloom filters.  The peer is also banned when banning is enabled
+// since the bloom filter messages are only supported by protocol versions that
+// are high enough to observe the service bit and therefore the peer is
+// knowingly violating the protocol.
+//
+// It returns whether or not the peer is allowed to use bloom filters.
+func (sp *serverPeer) enforceNodeBloomFlag(cmd string) bool {
+	if hasServices(sp.server.services, wire.SFNodeBloom) {
+		return true
+	}
+
+	// NOTE: Even though the addBanScore function already examines whether
+	// or not banning is enabled, it is checked here as well to ensure the
+	// violation is logged and the peer is disconnected regardless.
+	if !cfg.DisableBanning {
+		// Disconnect the peer regardless of whether it was banned.
+		sp.addBanScore(100, 0, cmd)
+		sp.Disconnect()
+		return false
+	}
+
+	peerLog.Debugf("%s sent an unsupported %s request -- disconnecting", sp,
+		cmd)
+	sp.Disconnect()
+	return false
+}
+
+// ignoreBloomFilterMsg returns whether or not the bloom filter message with the
+// provided command should be ignored because the peer is a block relay only
+// peer.  Transactions are never relayed to block relay only peers, so they are
+// not allowed to load bloom filters since loading one would otherwise enable
+// transaction relay.
+func (sp *serverPeer) ignoreBloomFilterMsg(cmd string) bool {
+	if !sp.isBlockRelayOnly() {
+		return false
+	}
+
+	peerLog.Debugf("Ignoring %s request from block relay only peer %s", cmd,
+		sp)
+	return true
+}
+
+// OnFilterLoad is invoked when a peer receives a filterload wire message and is
+// used to load the bloom filter that is used to filter the transactions relayed
+// to the peer and included in the merkleblock messages sent to it.  Loading a
+// filter also enables transaction relay for peers that disabled it in their
+// version message unless the local node is in blocks only mode.
+func (sp *serverPeer) OnFilterLoad(_ *peer.Peer, msg *wire.MsgFilterLoad) {
+	// Disconnect and/or ban depending on the node bloom services flag.
+	if !sp.enforceNodeBloomFlag(msg.Command()) {
+		return
+	}
+
+	// Ignore bloom filters from block relay only peers since transactions are
+	// never relayed to them.
+	if sp.ignoreBloomFilterMsg(msg.Command()) {
+		return
+	}
+
+	// Transaction relay is never enabled by a filter when running in blocks
+	// only mode.
+	if !cfg.BlocksOnly {
+		sp.setDisableRelayTx(false)
+	}
+	sp.filter.Reload(msg)
+}
+
+// OnFilterAdd is invoked when a peer receives a filteradd wire message and is
+// used to add data to the bloom filter loaded by the peer.
+func (sp *serverPeer) OnFilterAdd(_ *peer.Peer, msg *wire.MsgFilterAdd) {
+	// Disconnect and/or ban depending on the node bloom services flag.
+	if !sp.enforceNodeBloomFlag(msg.Command()) {
+		return
+	}
+
+	// Ignore bloom filters from block relay only peers.
+	if sp.ignoreBloomFilterMsg(msg.Command()) {
+		return
+	}
+
+	if !sp.filter.IsLoaded() {
+		peerLog.Debugf("%s sent a filteradd request with no filter loaded "+
+			"-- disconnecting", sp)
+		sp.Disconnect()
+		return
+	}
+
+	sp.filter.Add(msg.Data)
+}
+
+// OnFilterClear is invoked when a peer receives a filterclear wire message and
+// is used to unload the bloom filter loaded by the peer.
+func (sp *serverPeer) OnFilterClear(_ *peer.Peer, msg *wire.MsgFilterClear) {
+	// Disconnect and/or ban depending on the node bloom services flag.
+	if !sp.enforceNodeBloomFlag(msg.Command()) {
+		return
+	}
+
+	// Ignore bloom filters from block relay only peers.
+	if sp.ignoreBloomFilterMsg(msg.Command()) {
+		return
+	}
+
+	if !sp.filter.IsLoaded() {
+		peerLog.Debugf("%s sent a filterclear request with no filter loaded "+
+			"-- disconnecting", sp)
+		sp.Disconnect()
+		return
+	}
+
+	sp.filter.Unload()
+}
+
 // OnGetAddr is invoked when a peer receives a getaddr wire message and is used
 // to provide the peer with known addresses from the address manager.
 func (sp *serverPeer) OnGetAddr(_ *peer.Peer, msg *wire.MsgGetAddr) {
@@ -1594,6 +2051,22 @@ func (sp *serverPeer) OnGetAddr(_ *peer.Peer, msg *wire.MsgGetAddr) {
 // OnAddr is invoked when a peer receives an addr wire message and is used to
 // notify the server about advertised addresses.
 func (sp *serverPeer) OnAddr(_ *peer.Peer, msg *wire.MsgAddr) {
+	sp.handleAddrs(msg.Command(), len(msg.AddrList),
+		wireToAddrmgrNetAddresses(msg.AddrList))
+}
+
+// OnAddrV2 is invoked when a peer receives an addrv2 wire message and is used
+// to notify the server about advertised addresses.  Addresses for networks
+// that are not supported are ignored.
+func (sp *serverPeer) OnAddrV2(_ *peer.Peer, msg *wire.MsgAddrV2) {
+	sp.handleAddrs(msg.Command(), len(msg.AddrList),
+		wireV2ToAddrmgrNetAddresses(msg.AddrList))
+}
+
+// handleAddrs notifies the server about the provided addresses advertised by
+// the peer via the provided command which contained the provided number of
+// addresses prior to filtering any unsupported addresses.
+func (sp *serverPeer) handleAddrs(cmd string, numAddrs int, addrList []*addrmgr.NetAddress) {
 	// Ignore addresses when running on the simulation and regression test
 	// networks.  This helps prevent the networks from becoming another public
 	// test network since they will not be able to learn about other peers that
@@ -1603,9 +2076,9 @@ func (sp *serverPeer) OnAddr(_ *peer.Peer, msg *wire.MsgAddr) {
 	}
 
 	// A message that has no addresses is invalid.
-	if len(msg.AddrList) == 0 {
+	if numAddrs == 0 {
 		peerLog.Errorf("Command [%s] from %s does not contain any addresses",
-			msg.Command(), sp)
+			cmd, sp)
 
 		// Ban peers sending empty address requests.
 		sp.server.BanPeer(sp)
@@ -1613,7 +2086,6 @@ func (sp *serverPeer) OnAddr(_ *peer.Peer, msg *wire.MsgAddr) {
 	}
 
 	now := time.Now()
-	addrList := wireToAddrmgrNetAddresses(msg.AddrList)
 	for _, na := range addrList {
 		// Don't add more address if we're disconnecting.
 		if !sp.Connected() {
@@ -1715,6 +2187,14 @@ func randomUint16Number(max uint16) uint16 {
 // attemptDcrdDial is a wrapper function around dcrdDial which adds and marks
 // the remote peer as attempted in the address manager.
 func (s *server) attemptDcrdDial(ctx context.Context, network, addr string) (net.Conn, error) {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		ip := net.ParseIP(host)
+		if banEnd, ok := s.banList.IsBanned(ip, time.Now()); ok {
+			return nil, fmt.Errorf("address %s is banned for another %v",
+				addr, time.Until(banEnd))
+		}
+	}
+
 	if !cfg.SimNet && !cfg.RegNet {
 		host, portStr, err := net.SplitHostPort(addr)
 		if err != nil {
@@ -1826,16 +2306,11 @@ func (s *server) handleAddPeerMsg(state *peerState, sp *serverPeer) bool {
 		sp.Disconnect()
 		return false
 	}
-	if banEnd, ok := state.banned[host]; ok {
-		if time.Now().Before(banEnd) {
-			srvrLog.Debugf("Peer %s is banned for another %v - disconnecting",
-				host, time.Until(banEnd))
-			sp.Disconnect()
-			return false
-		}
-
-		srvrLog.Infof("Peer %s is no longer banned", host)
-		delete(state.banned, host)
+	if banEnd, ok := s.banList.IsBanned(net.ParseIP(host), time.Now()); ok {
+		srvrLog.Debugf("Peer %s is banned for another %v - disconnecting",
+			host, time.Until(banEnd))
+		sp.Disconnect()
+		return false
 	}
 
 	// Limit max number of connections from a single IP.  However, allow
@@ -1884,7 +2359,7 @@ func (s *server) handleAddPeerMsg(state *peerState, sp *serverPeer) bool {
 		}
 	} else {
 		remoteAddr := wireToAddrmgrNetAddress(sp.NA())
-		state.outboundGroups[remoteAddr.GroupKey()]++
+		state.outboundGroups[s.addrManager.GroupKey(remoteAddr)]++
 		if sp.persistent {
 			state.persistentPeers[sp.ID()] = sp
 		} else {
@@ -1971,7 +2446,7 @@ func (s *server) handleDonePeerMsg(state *peerState, sp *serverPeer) {
 	if _, ok := list[sp.ID()]; ok {
 		if !sp.Inbound() && sp.VersionKnown() {
 			remoteAddr := wireToAddrmgrNetAddress(sp.NA())
-			state.outboundGroups[remoteAddr.GroupKey()]--
+			state.outboundGroups[s.addrManager.GroupKey(remoteAddr)]--
 		}
 		if !sp.Inbound() && sp.connReq != nil {
 			s.connManager.Disconnect(sp.connReq.ID())
@@ -2007,15 +2482,43 @@ func (s *server) handleBanPeerMsg(state *peerState, sp *serverPeer) {
 		srvrLog.Debugf("can't split ban peer %s %v", sp.Addr(), err)
 		return
 	}
+	subnet, err := banmanager.ParseSubnet(host)
+	if err != nil {
+		srvrLog.Debugf("can't ban peer %s: %v", sp.Addr(), err)
+		return
+	}
 	direction := directionString(sp.Inbound())
 	srvrLog.Infof("Banned peer %s (%s) for %v", host, direction,
 		cfg.BanDuration)
-	state.banned[host] = time.Now().Add(cfg.BanDuration)
+	now := time.Now()
+	if err := s.banList.Ban(subnet, now, now.Add(cfg.BanDuration)); err != nil {
+		srvrLog.Errorf("Unable to save ban list: %v", err)
+	}
+}
+
+// newCmpctBlock returns a compact block for the provided block with a random
+// nonce.  The coinbase and treasurybase, when present, are prefilled since they
+// are never in the mempool.
+func newCmpctBlock(block *dcrutil.Block) *wire.MsgCmpctBlock {
+	nonce, err := wire.RandomUint64()
+	if err != nil {
+		srvrLog.Warnf("Unable to generate compact block nonce: %v", err)
+	}
+	return wire.NewMsgCmpctBlock(block.MsgBlock(), nonce,
+		func(tx *wire.MsgTx, tree int8, index int) bool {
+			if tree == wire.TxTreeRegular {
+				return index == 0
+			}
+			return standalone.IsTreasuryBase(tx)
+		})
 }
 
 // handleRelayInvMsg deals with relaying inventory to peers that are not already
 // known to have it.  It is invoked from the peerHandler goroutine.
 func (s *server) handleRelayInvMsg(state *peerState, msg relayMsg) {
+	// The compact block for block announcements is only created once it is
+	// needed and then shared by all peers that prefer compact blocks.
+	var cmpctBlock *wire.MsgCmpctBlock
 	state.forAllPeers(func(sp *serverPeer) {
 		if !sp.Connected() {
 			return
@@ -2037,17 +2540,35 @@ func (s *server) handleRelayInvMsg(state *peerState, msg relayMsg) {
 			sp.announcedBlock = &iv.Hash
 		}
 
+		// Generate and send a compact block message instead of an inventory
+		// message for block announcements when the peer prefers compact
+		// blocks.
+		if isBlockAnnouncement && sp.WantsCmpctBlocks() {
+			block, ok := msg.data.(*dcrutil.Block)
+			if !ok {
+				peerLog.Warnf("Underlying data for compact block" +
+					" is not a block")
+				return
+			}
+			if cmpctBlock == nil {
+				cmpctBlock = newCmpctBlock(block)
+			}
+			sp.QueueMessage(cmpctBlock, nil)
+			return
+		}
+
 		// Generate and send a headers message instead of an inventory message
 		// for block announcements when the peer prefers headers.
 		if isBlockAnnouncement && sp.WantsHeaders() {
-			blockHeader, ok := msg.data.(wire.BlockHeader)
+			block, ok := msg.data.(*dcrutil.Block)
 			if !ok {
 				peerLog.Warnf("Underlying data for headers" +
-					" is not a block header")
+					" is not a block")
 				return
 			}
 			msgHeaders := wire.NewMsgHeaders()
-			if err := msgHeaders.AddBlockHeader(&blockHeader); err != nil {
+			err := msgHeaders.AddBlockHeader(&block.MsgBlock().Header)
+			if err != nil {
 				peerLog.Errorf("Failed to add block"+
 					" header: %v", err)
 				return
@@ -2056,12 +2577,8 @@ func (s *server) handleRelayInvMsg(state *peerState, msg relayMsg) {
 			return
 		}
 
-		if iv.Type == wire.InvTypeTx {
-			// Don't relay the transaction to the peer when it has
-			// transaction relaying disabled.
-			if sp.relayTxDisabled() {
-				return
-			}
+		if iv.Type == wire.InvTypeTx && !sp.shouldRelayTxInv(msg.data) {
+			return
 		}
 
 		// Either queue the inventory to be relayed immediately or with
@@ -2077,6 +2594,71 @@ func (s *server) handleRelayInvMsg(state *peerState, msg relayMsg) {
 	})
 }
 
+// shouldRelayTxInv returns whether or not the inventory for the transaction
+// housed by the provided relay data should be relayed to the peer.
+func (sp *serverPeer) shouldRelayTxInv(data interface{}) bool {
+	// Never relay transactions to block relay only peers or peers that have
+	// transaction relaying disabled.
+	if sp.isBlockRelayOnly() || sp.relayTxDisabled() {
+		return false
+	}
+
+	// Don't relay the transaction if it does not match the bloom filter loaded
+	// by the peer.
+	if sp.filter.IsLoaded() {
+		tx, ok := data.(*dcrutil.Tx)
+		if !ok {
+			peerLog.Warnf("Underlying data for tx inv relay is not a "+
+				"transaction: %T", data)
+			return false
+		}
+		if !sp.filter.MatchTxAndUpdate(tx) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// handleFeeFilterUpdate notifies all connected peers of changes to the minimum
+// relay fee of the mempool, which rises when transactions are evicted due to
+// the maximum mempool size and decays over time thereafter.  It is invoked from
+// the peerHandler goroutine.
+func (s *server) handleFeeFilterUpdate(state *peerState) {
+	minFee := s.txMemPool.MinRelayFee()
+	state.forAllPeers(func(sp *serverPeer) {
+		if !sp.Connected() {
+			return
+		}
+		sp.maybePushFeeFilter(minFee)
+	})
+}
+
+// handleSaveAnchors saves the addresses of the connected block relay only
+// outbound peers as anchors so they are reconnected to first on the next start.
+// Block relay only peers are long-lived since they are never rotated and are
+// much harder for an attacker to observe, which makes them ideal anchors.  It
+// is invoked from the peerHandler goroutine on shutdown.
+func (s *server) handleSaveAnchors(state *peerState) {
+	var anchors []*addrmgr.NetAddress
+	for _, sp := range state.outboundPeers {
+		if !sp.isBlockRelayOnly() || sp.persistent || !sp.Connected() ||
+			!sp.VersionKnown() || sp.NA() == nil {
+
+			continue
+		}
+		anchors = append(anchors, wireToAddrmgrNetAddress(sp.NA()))
+	}
+	if len(anchors) == 0 {
+		return
+	}
+	if err := s.addrManager.SaveAnchors(anchors); err != nil {
+		srvrLog.Warnf("Unable to save anchors: %v", err)
+		return
+	}
+	srvrLog.Debugf("Saved %d anchor connections", len(anchors))
+}
+
 // handleBroadcastMsg deals with broadcasting messages to peers.  It is invoked
 // from the peerHandler goroutine.
 func (s *server) handleBroadcastMsg(state *peerState, bmsg *broadcastMsg) {
@@ -2203,7 +2785,7 @@ func (s *server) handleQuery(ctx context.Context, state *peerState, querymsg int
 			// Keep group counts ok since we remove from
 			// the list now.
 			remoteAddr := wireToAddrmgrNetAddress(sp.NA())
-			state.outboundGroups[remoteAddr.GroupKey()]--
+			state.outboundGroups[s.addrManager.GroupKey(remoteAddr)]--
 
 			peerLog.Debugf("Removing persistent peer %s (reqid %d)", remoteAddr,
 				sp.connReq.ID())
@@ -2259,7 +2841,7 @@ func (s *server) handleQuery(ctx context.Context, state *peerState, querymsg int
 			// Keep group counts ok since we remove from
 			// the list now.
 			remoteAddr := wireToAddrmgrNetAddress(sp.NA())
-			state.outboundGroups[remoteAddr.GroupKey()]--
+			state.outboundGroups[s.addrManager.GroupKey(remoteAddr)]--
 		})
 		if found {
 			// If there are multiple outbound connections to the same
@@ -2268,7 +2850,7 @@ func (s *server) handleQuery(ctx context.Context, state *peerState, querymsg int
 			for found {
 				found = disconnectPeer(state.outboundPeers, msg.cmp, func(sp *serverPeer) {
 					remoteAddr := wireToAddrmgrNetAddress(sp.NA())
-					state.outboundGroups[remoteAddr.GroupKey()]--
+					state.outboundGroups[s.addrManager.GroupKey(remoteAddr)]--
 				})
 			}
 			msg.reply <- nil
@@ -2323,15 +2905,24 @@ func newPeerConfig(sp *serverPeer) *peer.Config {
 			OnBlock:          sp.OnBlock,
 			OnInv:            sp.OnInv,
 			OnHeaders:        sp.OnHeaders,
+			OnCmpctBlock:     sp.OnCmpctBlock,
+			OnGetBlockTxn:    sp.OnGetBlockTxn,
+			OnBlockTxn:       sp.OnBlockTxn,
 			OnGetData:        sp.OnGetData,
 			OnGetBlocks:      sp.OnGetBlocks,
 			OnGetHeaders:     sp.OnGetHeaders,
 			OnGetCFilter:     sp.OnGetCFilter,
 			OnGetCFilterV2:   sp.OnGetCFilterV2,
+			OnCFilterV2:      sp.OnCFilterV2,
+			OnGetCFHeadersV2: sp.OnGetCFHeadersV2,
 			OnGetCFHeaders:   sp.OnGetCFHeaders,
 			OnGetCFTypes:     sp.OnGetCFTypes,
+			OnFilterLoad:     sp.OnFilterLoad,
+			OnFilterAdd:      sp.OnFilterAdd,
+			OnFilterClear:    sp.OnFilterClear,
 			OnGetAddr:        sp.OnGetAddr,
 			OnAddr:           sp.OnAddr,
+			OnAddrV2:         sp.OnAddrV2,
 			OnRead:           sp.OnRead,
 			OnWrite:          sp.OnWrite,
 			OnNotFound:       sp.OnNotFound,
@@ -2350,17 +2941,50 @@ func newPeerConfig(sp *serverPeer) *peer.Config {
 		UserAgentComments: userAgentComments,
 		Net:               sp.server.chainParams.Net,
 		Services:          sp.server.services,
-		DisableRelayTx:    cfg.BlocksOnly,
+		DisableRelayTx:    cfg.BlocksOnly || sp.isBlockRelayOnly(),
+		DisableRelayAddr:  sp.isBlockRelayOnly(),
+		Encryption:        p2pEncryptionPolicy(),
 		ProtocolVersion:   maxProtocolVersion,
 		IdleTimeout:       cfg.PeerIdleTimeout,
+		CaptureDir:        cfg.CaptureDir,
 	}
 }
 
+// p2pEncryptionPolicy returns the policy for upgrading connections with peers
+// to the encrypted transport per the configuration options.
+func p2pEncryptionPolicy() peer.EncryptionPolicy {
+	switch {
+	case cfg.NoP2PEncrypt:
+		return peer.EncryptionDisabled
+	case cfg.RequireP2PEncrypt:
+		return peer.EncryptionRequired
+	}
+	return peer.EncryptionPreferred
+}
+
+// isAddrBanned returns whether or not the IP address of the provided network
+// address is banned along with the time the ban expires.
+func (s *server) isAddrBanned(addr net.Addr) (time.Time, bool) {
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return time.Time{}, false
+	}
+	return s.banList.IsBanned(net.ParseIP(host), time.Now())
+}
+
 // inboundPeerConnected is invoked by the connection manager when a new inbound
 // connection is established.  It initializes a new inbound server peer
 // instance, associates it with the connection, and starts all additional server
 // peer processing goroutines.
 func (s *server) inboundPeerConnected(conn net.Conn) {
+	// Disconnect banned peers before performing any additional processing.
+	if banEnd, ok := s.isAddrBanned(conn.RemoteAddr()); ok {
+		srvrLog.Debugf("Inbound connection from banned address %s rejected "+
+			"for another %v", conn.RemoteAddr(), time.Until(banEnd))
+		conn.Close()
+		return
+	}
+
 	sp := newServerPeer(s, false)
 	sp.isWhitelisted = isWhitelisted(conn.RemoteAddr())
 	sp.Peer = peer.NewInboundPeer(newPeerConfig(sp))
@@ -2376,6 +3000,7 @@ func (s *server) inboundPeerConnected(conn net.Conn) {
 // peer processing goroutines.
 func (s *server) outboundPeerConnected(c *connmgr.ConnReq, conn net.Conn) {
 	sp := newServerPeer(s, c.Permanent)
+	sp.connType = c.Type
 	p, err := peer.NewOutboundPeer(newPeerConfig(sp), c.Addr.String())
 	if err != nil {
 		srvrLog.Debugf("Cannot create outbound peer %s: %v", c.Addr, err)
@@ -2406,7 +3031,6 @@ func (s *server) peerHandler(ctx context.Context) {
 		inboundPeers:    make(map[int32]*serverPeer),
 		persistentPeers: make(map[int32]*serverPeer),
 		outboundPeers:   make(map[int32]*serverPeer),
-		banned:          make(map[string]time.Time),
 		outboundGroups:  make(map[string]int),
 		subCache: &naSubmissionCache{
 			cache: make(map[string]*naSubmission, maxCachedNaSubmissions),
@@ -2414,6 +3038,9 @@ func (s *server) peerHandler(ctx context.Context) {
 		},
 	}
 
+	feeFilterTicker := time.NewTicker(feeFilterUpdateInterval)
+	defer feeFilterTicker.Stop()
+
 out:
 	for {
 		select {
@@ -2447,9 +3074,17 @@ out:
 		case qmsg := <-s.query:
 			s.handleQuery(ctx, state, qmsg)
 
+		// Notify peers of changes to the minimum relay fee.
+		case <-feeFilterTicker.C:
+			s.handleFeeFilterUpdate(state)
+
 		case <-ctx.Done():
 			close(s.quit)
 
+			// Save the block relay only peers prior to disconnecting them so
+			// they are reconnected to first on the next start.
+			s.handleSaveAnchors(state)
+
 			// Disconnect all peers on server shutdown.
 			state.forAllPeers(func(sp *serverPeer) {
 				srvrLog.Tracef("Shutdown peer %s", sp)
@@ -2511,7 +3146,7 @@ func (s *server) RelayBlockAnnouncement(block *dcrutil.Block, reqServices wire.S
 	case <-s.quit:
 	case s.relayInv <- relayMsg{
 		invVect:     invVect,
-		data:        block.MsgBlock().Header,
+		data:        block,
 		immediate:   true,
 		reqServices: reqServices,
 	}:
@@ -3182,6 +3817,15 @@ func (s *server) Run(ctx context.Context) {
 		}()
 	}
 
+	// Start the metrics server when it is enabled.
+	if s.metrics != nil {
+		wg.Add(1)
+		go func() {
+			runMetricsServer(ctx, s.metrics, s.metricsListeners)
+			wg.Done()
+		}()
+	}
+
 	// Start the background block template generator and CPU miner if the config
 	// provides a mining address.
 	if len(cfg.miningAddrs) > 0 {
@@ -3209,6 +3853,19 @@ func (s *server) Run(ctx context.Context) {
 		wg.Done()
 	}()
 
+	// Load the mempool contents persisted during the previous shutdown.
+	if !cfg.NoPersistMempool {
+		wg.Add(1)
+		go func() {
+			_, _, err := s.txMemPool.LoadFile(s.mempoolFilePath())
+			if err != nil {
+				srvrLog.Errorf("Unable to load mempool: %v", err)
+			}
+			s.mempoolLoaded.Store(true)
+			wg.Done()
+		}()
+	}
+
 	// Shutdown the server when the context is cancelled.
 	<-ctx.Done()
 	s.shutdown.Store(true)
@@ -3217,9 +3874,22 @@ func (s *server) Run(ctx context.Context) {
 	s.feeEstimator.Close()
 	s.chain.ShutdownUtxoCache()
 	wg.Wait()
+
+	// Save the mempool contents so they can be loaded on the next startup.
+	if !cfg.NoPersistMempool && s.mempoolLoaded.Load() {
+		if _, err := s.txMemPool.DumpFile(s.mempoolFilePath()); err != nil {
+			srvrLog.Errorf("Unable to save mempool: %v", err)
+		}
+	}
 	srvrLog.Trace("Server stopped")
 }
 
+// mempoolFilePath returns the path of the file in the data directory the
+// contents of the mempool are persisted to.
+func (s *server) mempoolFilePath() string {
+	return filepath.Join(cfg.DataDir, mempoolFilename)
+}
+
 // parseListeners determines whether each listen address is IPv4 and IPv6 and
 // returns a slice of appropriate net.Addrs to listen on with TCP. It also
 // properly detects addresses which apply to "all interfaces" and adds the
@@ -3566,10 +4236,13 @@ func makeReloadableTLSConfig(certPath, keyPath, clientCAsPath string) (*tls.Conf
 	}, nil
 }
 
-// setupRPCListeners returns a slice of listeners that are configured for use
-// with the RPC server depending on the configuration settings for listen
-// addresses and TLS.
-func setupRPCListeners() ([]net.Listener, error) {
+// setupRPCListeners returns slices of listeners that are configured for use
+// with the RPC server and its REST interface, respectively, depending on the
+// configuration settings for listen addresses and TLS.
+//
+// The REST listeners never require client certificates since the REST
+// interface is unauthenticated.
+func setupRPCListeners() ([]net.Listener, []net.Listener, error) {
 	var notifyAddrServer boundAddrEventServer
 	if cfg.BoundAddrEvents {
 		notifyAddrServer = newBoundAddrEventServer(outgoingPipeMessages)
@@ -3577,6 +4250,7 @@ func setupRPCListeners() ([]net.Listener, error) {
 
 	// Setup TLS if not disabled.
 	listenFunc := net.Listen
+	restListenFunc := net.Listen
 	if !cfg.DisableRPC && !cfg.DisableTLS {
 		// Generate the TLS cert and key file if both don't already exist.
 		keyFileExists := fileExists(cfg.RPCKey)
@@ -3591,11 +4265,11 @@ func setupRPCListeners() ([]net.Listener, error) {
 		if !keyFileExists && !certFileExists {
 			curve, err := tlsCurve(cfg.TLSCurve)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 			err = genCertPair(cfg.RPCCert, cfg.RPCKey, cfg.AltDNSNames, curve)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 		}
 		var clientCACerts string
@@ -3605,32 +4279,58 @@ func setupRPCListeners() ([]net.Listener, error) {
 		tlsConfig, err := makeReloadableTLSConfig(cfg.RPCCert, cfg.RPCKey,
 			clientCACerts)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		// Change the standard net.Listen function to the tls one.
 		listenFunc = func(net string, laddr string) (net.Listener, error) {
 			return tls.Listen(net, laddr, tlsConfig)
 		}
-	}
 
-	netAddrs, err := parseListeners(cfg.RPCListeners)
-	if err != nil {
-		return nil, err
+		if len(cfg.RESTListeners) > 0 {
+			restTLSConfig, err := makeReloadableTLSConfig(cfg.RPCCert,
+				cfg.RPCKey, "")
+			if err != nil {
+				return nil, nil, err
+			}
+			restListenFunc = func(net string, laddr string) (net.Listener, error) {
+				return tls.Listen(net, laddr, restTLSConfig)
+			}
+		}
 	}
 
-	listeners := make([]net.Listener, 0, len(netAddrs))
-	for _, addr := range netAddrs {
-		listener, err := listenFunc(addr.Network(), addr.String())
+	listen := func(addrs []string, listenFunc func(string, string) (net.Listener, error)) ([]net.Listener, error) {
+		netAddrs, err := parseListeners(addrs)
 		if err != nil {
-			rpcsLog.Warnf("Can't listen on %s: %v", addr, err)
-			continue
+			return nil, err
 		}
-		listeners = append(listeners, listener)
-		notifyAddrServer.notifyRPCAddress(listener.Addr().String())
+
+		listeners := make([]net.Listener, 0, len(netAddrs))
+		for _, addr := range netAddrs {
+			listener, err := listenFunc(addr.Network(), addr.String())
+			if err != nil {
+				rpcsLog.Warnf("Can't listen on %s: %v", addr, err)
+				continue
+			}
+			listeners = append(listeners, listener)
+			notifyAddrServer.notifyRPCAddress(listener.Addr().String())
+		}
+		return listeners, nil
+	}
+
+	listeners, err := listen(cfg.RPCListeners, listenFunc)
+	if err != nil {
+		return nil, nil, err
+	}
+	restListeners, err := listen(cfg.RESTListeners, restListenFunc)
+	if err != nil {
+		for _, listener := range listeners {
+			listener.Close()
+		}
+		return nil, nil, err
 	}
 
-	return listeners, nil
+	return listeners, restListeners, nil
 }
 
 // newServer returns a new dcrd server configured to listen on addr for the
@@ -3641,7 +4341,36 @@ func newServer(ctx context.Context, listenAddrs []string, db database.DB,
 	dataDir string) (*server, error) {
 
 	amgr := addrmgr.New(cfg.DataDir, dcrdLookup)
+	if cfg.ASMap != "" {
+		data, err := os.ReadFile(cfg.ASMap)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read ASN map: %w", err)
+		}
+		asmap, err := addrmgr.ParseASMap(data)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load ASN map %s: %w",
+				cfg.ASMap, err)
+		}
+		amgr.SetASMap(asmap)
+		srvrLog.Infof("Using ASN map %s (hash %v)", cfg.ASMap, asmap.Hash())
+	}
+	banList := banmanager.NewBanList(filepath.Join(cfg.DataDir,
+		banListFilename))
+	if err := banList.Load(); err != nil {
+		srvrLog.Warnf("Unable to load ban list: %v", err)
+	}
 	services := defaultServices
+	if cfg.Prune != 0 || cfg.LightMode {
+		// Pruned and light mode nodes are unable to serve the full block
+		// chain.
+		services &^= wire.SFNodeNetwork
+	}
+	if p2pEncryptionPolicy() != peer.EncryptionDisabled {
+		services |= wire.SFNodeEncryptedTransport
+	}
+	if cfg.PeerBloomFilters {
+		services |= wire.SFNodeBloom
+	}
 
 	var listeners []net.Listener
 	var nat *upnpNAT
@@ -3666,6 +4395,7 @@ func newServer(ctx context.Context, listenAddrs []string, db database.DB,
 	s := server{
 		chainParams:          chainParams,
 		addrManager:          amgr,
+		banList:              banList,
 		newPeers:             make(chan *serverPeer, cfg.MaxPeers),
 		donePeers:            make(chan *serverPeer, cfg.MaxPeers),
 		banPeers:             make(chan *serverPeer, cfg.MaxPeers),
@@ -3739,38 +4469,83 @@ func newServer(ctx context.Context, listenAddrs []string, db database.DB,
 		srvrLog.Info("Assume valid is disabled")
 	}
 
+	// Setup the metrics and listeners for the metrics server when it is
+	// enabled.  The metrics that are updated as events take place are hooked
+	// into the relevant subsystems as they are created.
+	var onUtxoCacheFlush func(time.Duration)
+	var onBlockConnectTimings func(validation, connection time.Duration)
+	if len(cfg.MetricsListeners) > 0 {
+		s.metricsListeners, err = setupMetricsListeners()
+		if err != nil {
+			return nil, err
+		}
+		if len(s.metricsListeners) == 0 {
+			return nil, errors.New("no usable metrics listen addresses")
+		}
+		s.metrics = newServerMetrics()
+		onUtxoCacheFlush = s.metrics.onUtxoCacheFlush
+		onBlockConnectTimings = s.metrics.onBlockConnectTimings
+	}
+
 	// Create a new block chain instance with the appropriate configuration.
 	utxoBackend := blockchain.NewLevelDbUtxoBackend(utxoDb)
 	utxoCache := blockchain.NewUtxoCache(&blockchain.UtxoCacheConfig{
 		Backend:      utxoBackend,
 		FlushBlockDB: s.db.Flush,
 		MaxSize:      uint64(cfg.UtxoCacheMaxSize) * 1024 * 1024,
+		OnFlush:      onUtxoCacheFlush,
 	})
 	s.chain, err = blockchain.New(ctx,
 		&blockchain.Config{
-			DB:              s.db,
-			UtxoBackend:     utxoBackend,
-			ChainParams:     s.chainParams,
-			AssumeValid:     assumeValid,
-			TimeSource:      s.timeSource,
-			Notifications:   s.handleBlockchainNotification,
-			SigCache:        s.sigCache,
-			SubsidyCache:    s.subsidyCache,
-			IndexSubscriber: s.indexSubscriber,
-			UtxoCache:       utxoCache,
+			DB:                    s.db,
+			UtxoBackend:           utxoBackend,
+			ChainParams:           s.chainParams,
+			AssumeValid:           assumeValid,
+			TimeSource:            s.timeSource,
+			Notifications:         s.handleBlockchainNotification,
+			OnBlockConnectTimings: onBlockConnectTimings,
+			SigCache:              s.sigCache,
+			SubsidyCache:          s.subsidyCache,
+			IndexSubscriber:       s.indexSubscriber,
+			UtxoCache:             utxoCache,
+			PruneTarget:           uint64(cfg.Prune) * 1024 * 1024,
 		})
 	if err != nil {
 		return nil, err
 	}
 
 	queryer := &blockchain.ChainQueryerAdapter{BlockChain: s.chain}
-	if cfg.TxIndex {
-		indxLog.Info("Transaction index is enabled")
+	if cfg.TxIndex || cfg.AddrIndex {
+		// Enable the transaction index if the address index is enabled since
+		// it requires it.
+		if !cfg.TxIndex {
+			indxLog.Infof("Transaction index enabled because it is " +
+				"required by the address index")
+			cfg.TxIndex = true
+		} else {
+			indxLog.Info("Transaction index is enabled")
+		}
 		s.txIndex, err = indexers.NewTxIndex(s.indexSubscriber, db, queryer)
 		if err != nil {
 			return nil, err
 		}
 	}
+	if cfg.AddrIndex {
+		indxLog.Info("Address index is enabled")
+		s.addrIndex, err = indexers.NewAddrIndex(s.indexSubscriber, db,
+			queryer)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if cfg.SpendIndex {
+		indxLog.Info("Spend index is enabled")
+		s.spendIndex, err = indexers.NewSpendIndex(s.indexSubscriber, db,
+			queryer)
+		if err != nil {
+			return nil, err
+		}
+	}
 	if !cfg.NoExistsAddrIndex {
 		indxLog.Info("Exists address index is enabled")
 		s.existsAddrIndex, err = indexers.NewExistsAddrIndex(s.indexSubscriber,
@@ -3792,6 +4567,7 @@ func newServer(ctx context.Context, listenAddrs []string, db database.DB,
 			MaxOrphanTxSize:        mempool.MaxStandardTxSize,
 			MaxSigOpsPerTx:         blockchain.MaxSigOpsPerBlock / 5,
 			MinRelayTxFee:          cfg.minRelayTxFee,
+			MaxPoolSize:            int64(cfg.MaxMempool) * 1024 * 1024,
 			AllowOldVotes:          cfg.AllowOldVotes,
 			MaxVoteAge: func() uint16 {
 				switch chainParams.Net {
@@ -3825,6 +4601,7 @@ func newServer(ctx context.Context, listenAddrs []string, db database.DB,
 			return s.chain.BestSnapshot().MedianTime
 		},
 		ExistsAddrIndex:           s.existsAddrIndex,
+		AddrIndex:                 s.addrIndex,
 		AddTxToFeeEstimation:      s.feeEstimator.AddMemPoolTransaction,
 		RemoveTxFromFeeEstimation: s.feeEstimator.RemoveMemPoolTransaction,
 		OnVoteReceived: func(voteTx *dcrutil.Tx) {
@@ -3870,6 +4647,8 @@ func newServer(ctx context.Context, listenAddrs []string, db database.DB,
 		MaxPeers:              cfg.MaxPeers,
 		MaxOrphanTxs:          cfg.MaxOrphanTxs,
 		RecentlyConfirmedTxns: s.recentlyConfirmedTxns,
+		LightMode:             cfg.LightMode,
+		WatchScripts:          cfg.watchScripts,
 	})
 
 	// Dump the blockchain and quit if requested.
@@ -3989,7 +4768,13 @@ func newServer(ctx context.Context, listenAddrs []string, db database.DB,
 				// to the same network segment at the expense of
 				// others.
 				netAddr := addr.NetAddress()
-				if s.OutboundGroupCount(netAddr.GroupKey()) != 0 {
+				if s.OutboundGroupCount(s.addrManager.GroupKey(netAddr)) != 0 {
+					continue
+				}
+
+				// Skip banned addresses.
+				_, banned := s.banList.IsBanned(netAddr.IP, time.Now())
+				if banned {
 					continue
 				}
 
@@ -4005,6 +4790,20 @@ func newServer(ctx context.Context, listenAddrs []string, db database.DB,
 					continue
 				}
 
+				// Addresses on overlay networks are dialed by their host
+				// names since they can't be resolved to IP addresses.  Only
+				// Tor is currently supported for outbound connections.
+				switch netAddr.Type {
+				case addrmgr.TORv3Address:
+					if cfg.NoOnion {
+						continue
+					}
+					return simpleAddr{net: "tcp", addr: netAddr.Key()}, nil
+
+				case addrmgr.I2PAddress, addrmgr.CJDNSAddress:
+					continue
+				}
+
 				return addrStringToNetAddr(netAddr.Key())
 			}
 
@@ -4012,20 +4811,66 @@ func newServer(ctx context.Context, listenAddrs []string, db database.DB,
 		}
 	}
 
+	// Load the anchors saved on the previous clean shutdown.  The anchors are
+	// always loaded, even when they are not used, since doing so removes the
+	// anchors file and thereby ensures they are never reused on a later start.
+	anchors, err := amgr.LoadAnchors()
+	if err != nil {
+		srvrLog.Warnf("Unable to load anchors: %v", err)
+	}
+	var anchorAddrs []net.Addr
+	for _, na := range anchors {
+		if newAddressFunc == nil {
+			break
+		}
+		if _, banned := s.banList.IsBanned(na.IP, time.Now()); banned {
+			continue
+		}
+
+		var addr net.Addr
+		switch na.Type {
+		case addrmgr.TORv3Address:
+			if cfg.NoOnion {
+				continue
+			}
+			addr = simpleAddr{net: "tcp", addr: na.Key()}
+
+		case addrmgr.I2PAddress, addrmgr.CJDNSAddress:
+			continue
+
+		default:
+			addr, err = addrStringToNetAddr(na.Key())
+			if err != nil {
+				continue
+			}
+		}
+		anchorAddrs = append(anchorAddrs, addr)
+	}
+	if len(anchorAddrs) > 0 {
+		srvrLog.Infof("Reconnecting to %d anchor connections",
+			len(anchorAddrs))
+	}
+
 	// Create a connection manager.
 	targetOutbound := defaultTargetOutbound
 	if cfg.MaxPeers < targetOutbound {
 		targetOutbound = cfg.MaxPeers
 	}
+	targetBlockRelay := cfg.BlockRelayConns
+	if cfg.MaxPeers-targetOutbound < targetBlockRelay {
+		targetBlockRelay = cfg.MaxPeers - targetOutbound
+	}
 	cmgr, err := connmgr.New(&connmgr.Config{
-		Listeners:      listeners,
-		OnAccept:       s.inboundPeerConnected,
-		RetryDuration:  connectionRetryInterval,
-		TargetOutbound: uint32(targetOutbound),
-		Dial:           s.attemptDcrdDial,
-		Timeout:        cfg.DialTimeout,
-		OnConnection:   s.outboundPeerConnected,
-		GetNewAddress:  newAddressFunc,
+		Listeners:                listeners,
+		OnAccept:                 s.inboundPeerConnected,
+		RetryDuration:            connectionRetryInterval,
+		TargetOutbound:           uint32(targetOutbound),
+		TargetBlockRelayOutbound: uint32(targetBlockRelay),
+		Anchors:                  anchorAddrs,
+		Dial:                     s.attemptDcrdDial,
+		Timeout:                  cfg.DialTimeout,
+		OnConnection:             s.outboundPeerConnected,
+		GetNewAddress:            newAddressFunc,
 	})
 	if err != nil {
 		return nil, err
@@ -4053,7 +4898,7 @@ func newServer(ctx context.Context, listenAddrs []string, db database.DB,
 	if !cfg.DisableRPC {
 		// Setup listeners for the configured RPC listen addresses and
 		// TLS settings.
-		rpcListeners, err := setupRPCListeners()
+		rpcListeners, restListeners, err := setupRPCListeners()
 		if err != nil {
 			return nil, err
 		}
@@ -4063,17 +4908,19 @@ func newServer(ctx context.Context, listenAddrs []string, db database.DB,
 		}
 
 		rpcsConfig := rpcserver.Config{
-			Listeners:    rpcListeners,
-			ConnMgr:      &rpcConnManager{&s},
-			SyncMgr:      &rpcSyncMgr{server: &s, syncMgr: s.syncManager},
-			FeeEstimator: s.feeEstimator,
-			TimeSource:   s.timeSource,
-			Services:     s.services,
-			AddrManager:  s.addrManager,
-			Clock:        &rpcClock{},
-			SubsidyCache: s.subsidyCache,
-			Chain:        &rpcChain{s.chain},
-			ChainParams:  chainParams,
+			Listeners:        rpcListeners,
+			RESTListeners:    restListeners,
+			ConnMgr:          &rpcConnManager{&s},
+			SyncMgr:          &rpcSyncMgr{server: &s, syncMgr: s.syncManager},
+			MempoolPersister: &rpcMempoolPersister{&s},
+			FeeEstimator:     s.feeEstimator,
+			TimeSource:       s.timeSource,
+			Services:         s.services,
+			AddrManager:      s.addrManager,
+			Clock:            &rpcClock{},
+			SubsidyCache:     s.subsidyCache,
+			Chain:            &rpcChain{s.chain},
+			ChainParams:      chainParams,
 			SanityChecker: &rpcSanityChecker{
 				chain:       s.chain,
 				timeSource:  s.timeSource,
@@ -4083,6 +4930,7 @@ func newServer(ctx context.Context, listenAddrs []string, db database.DB,
 			TxMempooler:          s.txMemPool,
 			CPUMiner:             &rpcCPUMiner{s.cpuMiner},
 			NetInfo:              cfg.generateNetworkInfo(),
+			BanDuration:          cfg.BanDuration,
 			MinRelayTxFee:        cfg.minRelayTxFee,
 			Proxy:                cfg.Proxy,
 			RPCUser:              cfg.RPCUser,
@@ -4099,6 +4947,15 @@ func newServer(ctx context.Context, listenAddrs []string, db database.DB,
 			UserAgentVersion:     userAgentVersion,
 			LogManager:           &rpcLogManager{},
 			FiltererV2:           s.chain,
+			LightMode:            cfg.LightMode,
+			StandardVerifyFlags: func() (txscript.ScriptFlags, error) {
+				return standardScriptVerifyFlags(s.chain)
+			},
+		}
+		if cfg.LightMode {
+			// The committed filters are only available from the sync manager
+			// in light mode since the full blocks are not available.
+			rpcsConfig.FiltererV2 = s.syncManager
 		}
 		if s.existsAddrIndex != nil {
 			rpcsConfig.ExistsAddresser = s.existsAddrIndex
@@ -4109,6 +4966,15 @@ func newServer(ctx context.Context, listenAddrs []string, db database.DB,
 		if s.txIndex != nil {
 			rpcsConfig.TxIndexer = s.txIndex
 		}
+		if s.addrIndex != nil {
+			rpcsConfig.AddrIndexer = s.addrIndex
+		}
+		if s.spendIndex != nil {
+			rpcsConfig.SpendIndexer = s.spendIndex
+		}
+		if s.metrics != nil {
+			rpcsConfig.RequestObserver = s.metrics
+		}
 
 		s.rpcServer, err = rpcserver.New(&rpcsConfig)
 		if err != nil {
@@ -4122,6 +4988,12 @@ func newServer(ctx context.Context, listenAddrs []string, db database.DB,
 		}()
 	}
 
+	// Register the metrics that are obtained from the various subsystems now
+	// that they have all been created.
+	if s.metrics != nil {
+		s.metrics.registerServer(ctx, &s, utxoCache)
+	}
+
 	return &s, nil
 }
 
@@ -0,0 +1,126 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/decred/dcrd/connmgr/v3"
+	"github.com/decred/dcrd/dcrutil/v4"
+	"github.com/decred/dcrd/wire"
+)
+
+// TestFilterLoadBlocksOnly ensures loading a bloom filter only enables
+// transaction relay for peers that disabled it in their version message when
+// the local node is not in blocks only mode.
+func TestFilterLoadBlocksOnly(t *testing.T) {
+	defer func(origCfg *config) {
+		cfg = origCfg
+	}(cfg)
+
+	tests := []struct {
+		name         string
+		blocksOnly   bool
+		wantDisabled bool
+	}{{
+		name:         "not blocks only",
+		blocksOnly:   false,
+		wantDisabled: false,
+	}, {
+		name:         "blocks only",
+		blocksOnly:   true,
+		wantDisabled: true,
+	}}
+
+	for _, test := range tests {
+		cfg = &config{BlocksOnly: test.blocksOnly}
+		s := &server{services: wire.SFNodeNetwork | wire.SFNodeBloom}
+		sp := newServerPeer(s, false)
+		sp.setDisableRelayTx(true)
+
+		msg := wire.NewMsgFilterLoad([]byte{0xff}, 1, 0, wire.BloomUpdateNone)
+		sp.OnFilterLoad(nil, msg)
+		if !sp.filter.IsLoaded() {
+			t.Errorf("%s: filter not loaded", test.name)
+			continue
+		}
+		if got := sp.relayTxDisabled(); got != test.wantDisabled {
+			t.Errorf("%s: mismatched relay tx disabled -- got %v, want %v",
+				test.name, got, test.wantDisabled)
+		}
+	}
+}
+
+// TestBlockRelayOnlyFilterLoad ensures block relay only peers are not able to
+// enable transaction relay by loading a bloom filter and therefore never have
+// transaction inventory relayed to them while other peers that load a bloom
+// filter do.
+func TestBlockRelayOnlyFilterLoad(t *testing.T) {
+	defer func(origCfg *config) {
+		cfg = origCfg
+	}(cfg)
+	cfg = &config{}
+
+	// Create a filter that matches everything along with a transaction to
+	// relay.
+	filterLoad := wire.NewMsgFilterLoad([]byte{0xff}, 1, 0, wire.BloomUpdateNone)
+	filterAdd := wire.NewMsgFilterAdd([]byte{0x01})
+	filterClear := wire.NewMsgFilterClear()
+	msgTx := wire.NewMsgTx()
+	msgTx.AddTxOut(wire.NewTxOut(0, []byte{0x51}))
+	tx := dcrutil.NewTx(msgTx)
+
+	tests := []struct {
+		name      string
+		connType  connmgr.ConnType
+		wantRelay bool
+	}{{
+		name:      "outbound full relay",
+		connType:  connmgr.ConnTypeFullRelay,
+		wantRelay: true,
+	}, {
+		name:      "outbound block relay only",
+		connType:  connmgr.ConnTypeBlockRelay,
+		wantRelay: false,
+	}}
+
+	for _, test := range tests {
+		s := &server{services: wire.SFNodeNetwork | wire.SFNodeBloom}
+		sp := newServerPeer(s, false)
+		sp.connType = test.connType
+
+		// Disable transaction relay as the peer would in its version message
+		// and load a filter that matches everything.
+		sp.setDisableRelayTx(true)
+		sp.OnFilterLoad(nil, filterLoad)
+		if got := sp.filter.IsLoaded(); got != test.wantRelay {
+			t.Errorf("%s: mismatched filter loaded -- got %v, want %v",
+				test.name, got, test.wantRelay)
+			continue
+		}
+		if got := sp.shouldRelayTxInv(tx); got != test.wantRelay {
+			t.Errorf("%s: mismatched tx relay after filterload -- got %v, "+
+				"want %v", test.name, got, test.wantRelay)
+			continue
+		}
+
+		// Ensure adding to the filter does not change anything.
+		sp.OnFilterAdd(nil, filterAdd)
+		if got := sp.shouldRelayTxInv(tx); got != test.wantRelay {
+			t.Errorf("%s: mismatched tx relay after filteradd -- got %v, "+
+				"want %v", test.name, got, test.wantRelay)
+			continue
+		}
+
+		// Ensure clearing the filter does not enable relay for block relay
+		// only peers either.
+		sp.OnFilterClear(nil, filterClear)
+		if got := sp.shouldRelayTxInv(tx); got != test.wantRelay {
+			t.Errorf("%s: mismatched tx relay after filterclear -- got %v, "+
+				"want %v", test.name, got, test.wantRelay)
+			continue
+		}
+	}
+}
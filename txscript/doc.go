@@ -27,6 +27,15 @@ is used to prove the spender is authorized to perform the transaction.
 One benefit of using a scripting language is added flexibility in specifying
 what conditions must be met in order to spend decred.
 
+# Tracing
+
+The execution of scripts may be traced for debugging purposes by setting a
+Tracer on an Engine via SetTracer prior to calling Execute.  The tracer is
+invoked with a TraceStep that contains the program counter, the executed
+opcode, and the state of the stacks before and after executing it.  Tracing is
+not enabled via NewEngine or script flags so that the signature of NewEngine
+remains unchanged and script flags remain solely for script validation rules.
+
 # Errors
 
 The errors returned by this package are of type txscript.ErrorKind wrapped by
@@ -1,5 +1,5 @@
 // Copyright (c) 2013-2017 The btcsuite developers
-// Copyright (c) 2015-2023 The Decred developers
+// Copyright (c) 2015-2024 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
@@ -9,6 +9,8 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/schnorr"
 	"github.com/decred/dcrd/wire"
 	"github.com/decred/slog"
 )
@@ -194,6 +196,14 @@ type Engine struct {
 	// nolint: dupword
 	condNestDepth    int32
 	condDisableDepth int32
+
+	// tracer is an optional callback that is invoked with the details of each
+	// executed opcode.
+	tracer Tracer
+
+	// schnorrBatch is an optional batch that secp256k1 Schnorr signature
+	// checks are deferred to instead of verifying them immediately.
+	schnorrBatch SchnorrBatch
 }
 
 // hasFlag returns whether the script engine instance has the passed flag set.
@@ -501,8 +511,11 @@ func (vm *Engine) Step() (done bool, err error) {
 	// Execute the opcode while taking into account several things such as
 	// disabled opcodes, illegal opcodes, maximum allowed operations per script,
 	// maximum script element sizes, and conditionals.
-	err = vm.executeOpcode(vm.tokenizer.op, vm.tokenizer.Data())
+	op, data := vm.tokenizer.op, vm.tokenizer.Data()
+	traceStep := vm.beginTraceStep(op, data)
+	err = vm.executeOpcode(op, data)
 	if err != nil {
+		vm.endTraceStep(traceStep, err)
 		return true, err
 	}
 
@@ -512,8 +525,11 @@ func (vm *Engine) Step() (done bool, err error) {
 	if combinedStackSize > MaxStackSize {
 		str := fmt.Sprintf("combined stack size %d > max allowed %d",
 			combinedStackSize, MaxStackSize)
-		return false, scriptError(ErrStackOverflow, str)
+		err := scriptError(ErrStackOverflow, str)
+		vm.endTraceStep(traceStep, err)
+		return false, err
 	}
+	vm.endTraceStep(traceStep, nil)
 
 	// Prepare for next instruction.
 	vm.opcodeIdx++
@@ -691,6 +707,30 @@ func (vm *Engine) SetAltStack(data [][]byte) {
 	setStack(&vm.astack, data)
 }
 
+// SchnorrBatch defines an interface for a batch that secp256k1 Schnorr
+// signature checks may be deferred to so they are verified together once the
+// scripts have been executed.  It is implemented by schnorr.BatchVerifier.
+//
+// Add must add the signature for the provided hash and public key to the batch
+// or return an error when the signature is known to be invalid.
+type SchnorrBatch interface {
+	Add(sig *schnorr.Signature, hash []byte, pubKey *secp256k1.PublicKey) error
+}
+
+// SetSchnorrBatch sets a batch that secp256k1 Schnorr signature checks
+// performed by OP_CHECKSIGALT and OP_CHECKSIGALTVERIFY are deferred to.
+// Passing nil restores immediate verification.
+//
+// Deferred signatures are assumed to be valid during execution, so a
+// successful execution only implies the scripts are valid when the batch
+// verifies.  Callers must execute the scripts again without a batch to
+// determine the correct result when the batch fails to verify or when the
+// execution fails after signatures were added to the batch, since the result
+// of a signature check may alter the execution path.
+func (vm *Engine) SetSchnorrBatch(batch SchnorrBatch) {
+	vm.schnorrBatch = batch
+}
+
 // NewEngine returns a new script engine for the provided public key script,
 // transaction, and input index.  The flags modify the behavior of the script
 // engine according to the description provided by each flag.
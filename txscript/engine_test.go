@@ -1,5 +1,5 @@
 // Copyright (c) 2013-2017 The btcsuite developers
-// Copyright (c) 2015-2023 The Decred developers
+// Copyright (c) 2015-2024 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
@@ -7,9 +7,13 @@ package txscript
 
 import (
 	"errors"
+	"reflect"
 	"testing"
 
 	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrec"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/schnorr"
 	"github.com/decred/dcrd/wire"
 )
 
@@ -150,3 +154,342 @@ func TestCheckErrorCondition(t *testing.T) {
 		t.Errorf("unexpected error %v on final check", err)
 	}
 }
+
+// TestTracer ensures the tracer set on an engine is invoked with the expected
+// details of each executed opcode.
+func TestTracer(t *testing.T) {
+	t.Parallel()
+
+	// tx with a single input and empty scripts.
+	tx := &wire.MsgTx{
+		SerType: wire.TxSerializeFull,
+		Version: 1,
+		TxIn: []*wire.TxIn{{
+			PreviousOutPoint: wire.OutPoint{Index: 0},
+			Sequence:         4294967295,
+		}},
+		TxOut: []*wire.TxOut{{Value: 1000000000}},
+	}
+
+	// Define some stack items for convenience.
+	one, two, three := []byte{0x01}, []byte{0x02}, []byte{0x03}
+	empty := [][]byte{}
+	noConds := []bool{}
+
+	tests := []struct {
+		name     string      // test description
+		script   string      // short form public key script to execute
+		expected []TraceStep // expected trace steps
+		err      error       // expected error from execute
+		errStep  int         // index of step expected to contain an error
+	}{{
+		name:   "conditional execution",
+		script: "1 0 IF 2 ELSE 3 ENDIF",
+		expected: []TraceStep{{
+			ScriptIdx:      1,
+			OpcodeIdx:      0,
+			Opcode:         OP_1,
+			Disasm:         "OP_1",
+			Stack:          empty,
+			AltStack:       empty,
+			CondStack:      noConds,
+			StackAfter:     [][]byte{one},
+			AltStackAfter:  empty,
+			CondStackAfter: noConds,
+		}, {
+			ScriptIdx:      1,
+			OpcodeIdx:      1,
+			Opcode:         OP_0,
+			Disasm:         "OP_0",
+			Stack:          [][]byte{one},
+			AltStack:       empty,
+			CondStack:      noConds,
+			StackAfter:     [][]byte{one, nil},
+			AltStackAfter:  empty,
+			CondStackAfter: noConds,
+		}, {
+			ScriptIdx:      1,
+			OpcodeIdx:      2,
+			Opcode:         OP_IF,
+			Disasm:         "OP_IF",
+			Stack:          [][]byte{one, nil},
+			AltStack:       empty,
+			CondStack:      noConds,
+			StackAfter:     [][]byte{one},
+			AltStackAfter:  empty,
+			CondStackAfter: []bool{false},
+		}, {
+			ScriptIdx:      1,
+			OpcodeIdx:      3,
+			Opcode:         OP_2,
+			Disasm:         "OP_2",
+			Stack:          [][]byte{one},
+			AltStack:       empty,
+			CondStack:      []bool{false},
+			StackAfter:     [][]byte{one},
+			AltStackAfter:  empty,
+			CondStackAfter: []bool{false},
+		}, {
+			ScriptIdx:      1,
+			OpcodeIdx:      4,
+			Opcode:         OP_ELSE,
+			Disasm:         "OP_ELSE",
+			Stack:          [][]byte{one},
+			AltStack:       empty,
+			CondStack:      []bool{false},
+			StackAfter:     [][]byte{one},
+			AltStackAfter:  empty,
+			CondStackAfter: []bool{true},
+		}, {
+			ScriptIdx:      1,
+			OpcodeIdx:      5,
+			Opcode:         OP_3,
+			Disasm:         "OP_3",
+			Stack:          [][]byte{one},
+			AltStack:       empty,
+			CondStack:      []bool{true},
+			StackAfter:     [][]byte{one, three},
+			AltStackAfter:  empty,
+			CondStackAfter: []bool{true},
+		}, {
+			ScriptIdx:      1,
+			OpcodeIdx:      6,
+			Opcode:         OP_ENDIF,
+			Disasm:         "OP_ENDIF",
+			Stack:          [][]byte{one, three},
+			AltStack:       empty,
+			CondStack:      []bool{true},
+			StackAfter:     [][]byte{one, three},
+			AltStackAfter:  empty,
+			CondStackAfter: noConds,
+		}},
+	}, {
+		name:   "failed opcode",
+		script: "2 TOALTSTACK VERIFY",
+		expected: []TraceStep{{
+			ScriptIdx:      1,
+			OpcodeIdx:      0,
+			Opcode:         OP_2,
+			Disasm:         "OP_2",
+			Stack:          empty,
+			AltStack:       empty,
+			CondStack:      noConds,
+			StackAfter:     [][]byte{two},
+			AltStackAfter:  empty,
+			CondStackAfter: noConds,
+		}, {
+			ScriptIdx:      1,
+			OpcodeIdx:      1,
+			Opcode:         OP_TOALTSTACK,
+			Disasm:         "OP_TOALTSTACK",
+			Stack:          [][]byte{two},
+			AltStack:       empty,
+			CondStack:      noConds,
+			StackAfter:     empty,
+			AltStackAfter:  [][]byte{two},
+			CondStackAfter: noConds,
+		}, {
+			ScriptIdx: 1,
+			OpcodeIdx: 2,
+			Opcode:    OP_VERIFY,
+			Disasm:    "OP_VERIFY",
+			Stack:     empty,
+			AltStack:  [][]byte{two},
+			CondStack: noConds,
+		}},
+		err:     ErrInvalidStackOperation,
+		errStep: 2,
+	}}
+
+	for _, test := range tests {
+		pkScript := mustParseShortFormV0(test.script)
+		vm, err := NewEngine(pkScript, tx, 0, 0, 0, nil)
+		if err != nil {
+			t.Errorf("%q: failed to create engine: %v", test.name, err)
+			continue
+		}
+		var steps []TraceStep
+		vm.SetTracer(func(step *TraceStep) {
+			steps = append(steps, *step)
+		})
+
+		err = vm.Execute()
+		if !errors.Is(err, test.err) {
+			t.Errorf("%q: unexpected error -- got %v, want %v", test.name,
+				err, test.err)
+			continue
+		}
+		if len(steps) != len(test.expected) {
+			t.Errorf("%q: unexpected number of steps -- got %d, want %d",
+				test.name, len(steps), len(test.expected))
+			continue
+		}
+		for i := range steps {
+			// Ensure the error is set for the expected step and then clear it
+			// to allow comparing the remaining fields.
+			step := &steps[i]
+			if test.err != nil && i == test.errStep {
+				if !errors.Is(step.Err, test.err) {
+					t.Errorf("%q: unexpected error in step %d -- got %v, "+
+						"want %v", test.name, i, step.Err, test.err)
+				}
+				step.Err = nil
+			}
+
+			if !reflect.DeepEqual(*step, test.expected[i]) {
+				t.Errorf("%q: mismatched step %d -- got %+v, want %+v",
+					test.name, i, *step, test.expected[i])
+			}
+		}
+	}
+}
+
+// testSchnorrBatch is a SchnorrBatch that records the signatures added to it
+// so they can be verified individually.
+type testSchnorrBatch struct {
+	sigs    []*schnorr.Signature
+	hashes  [][]byte
+	pubKeys []*secp256k1.PublicKey
+	err     error
+}
+
+// Add records the provided signature, hash, and public key unless the batch is
+// configured to return an error.
+//
+// This is part of the SchnorrBatch interface.
+func (b *testSchnorrBatch) Add(sig *schnorr.Signature, hash []byte, pubKey *secp256k1.PublicKey) error {
+	if b.err != nil {
+		return b.err
+	}
+	b.sigs = append(b.sigs, sig)
+	b.hashes = append(b.hashes, hash)
+	b.pubKeys = append(b.pubKeys, pubKey)
+	return nil
+}
+
+// verify returns whether or not all of the recorded signatures are valid.
+func (b *testSchnorrBatch) verify() bool {
+	for i, sig := range b.sigs {
+		if !sig.Verify(b.hashes[i], b.pubKeys[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// TestSchnorrBatch ensures that secp256k1 Schnorr signature checks are deferred
+// to the batch set on an engine and assumed to be valid during execution.
+func TestSchnorrBatch(t *testing.T) {
+	t.Parallel()
+
+	// tx with a single input that is signed below.
+	tx := &wire.MsgTx{
+		SerType: wire.TxSerializeFull,
+		Version: 1,
+		TxIn: []*wire.TxIn{{
+			PreviousOutPoint: wire.OutPoint{Index: 0},
+			Sequence:         4294967295,
+		}},
+		TxOut: []*wire.TxOut{{Value: 1000000000}},
+	}
+
+	// Create a pay-to-alt-pubkey script for a secp256k1 Schnorr key along
+	// with valid and invalid signatures for spending it.
+	privKey := secp256k1.PrivKeyFromBytes(hexToBytes("22a47fa09a223f2aa079" +
+		"edf85a7c2d4f8720ee63e502ee2869afab7de234b80c"))
+	pkScript, err := NewScriptBuilder().
+		AddData(privKey.PubKey().SerializeCompressed()).
+		AddInt64(int64(dcrec.STSchnorrSecp256k1)).
+		AddOp(OP_CHECKSIGALT).Script()
+	if err != nil {
+		t.Fatalf("unexpected error building script: %v", err)
+	}
+	hash, err := CalcSignatureHash(pkScript, SigHashAll, tx, 0, nil)
+	if err != nil {
+		t.Fatalf("unexpected error calculating signature hash: %v", err)
+	}
+	sig, err := schnorr.Sign(privKey, hash)
+	if err != nil {
+		t.Fatalf("unexpected error signing: %v", err)
+	}
+	validSig := append(sig.Serialize(), byte(SigHashAll))
+	invalidSig := append(sig.Serialize(), byte(SigHashAll))
+	invalidSig[40] ^= 0x01
+
+	tests := []struct {
+		name      string // test description
+		sig       []byte // signature to spend the script with
+		useBatch  bool   // whether or not to set a batch on the engine
+		batchErr  error  // error for the batch to return when adding
+		err       error  // expected error from execute
+		numSigs   int    // expected number of signatures added to the batch
+		batchGood bool   // expected result of verifying the batch
+	}{{
+		name: "valid signature without batch",
+		sig:  validSig,
+		err:  nil,
+	}, {
+		name: "invalid signature without batch",
+		sig:  invalidSig,
+		err:  ErrEvalFalse,
+	}, {
+		name:      "valid signature with batch",
+		sig:       validSig,
+		useBatch:  true,
+		err:       nil,
+		numSigs:   1,
+		batchGood: true,
+	}, {
+		name:      "invalid signature with batch is assumed valid",
+		sig:       invalidSig,
+		useBatch:  true,
+		err:       nil,
+		numSigs:   1,
+		batchGood: false,
+	}, {
+		name:      "signature rejected by batch",
+		sig:       validSig,
+		useBatch:  true,
+		batchErr:  errors.New("rejected"),
+		err:       ErrEvalFalse,
+		numSigs:   0,
+		batchGood: true,
+	}}
+
+	for _, test := range tests {
+		sigScript, err := NewScriptBuilder().AddData(test.sig).Script()
+		if err != nil {
+			t.Fatalf("%q: unexpected error building script: %v", test.name,
+				err)
+		}
+		tx.TxIn[0].SignatureScript = sigScript
+		vm, err := NewEngine(pkScript, tx, 0, 0, 0, nil)
+		if err != nil {
+			t.Errorf("%q: failed to create script: %v", test.name, err)
+			continue
+		}
+		batch := &testSchnorrBatch{err: test.batchErr}
+		if test.useBatch {
+			vm.SetSchnorrBatch(batch)
+		}
+
+		err = vm.Execute()
+		if !errors.Is(err, test.err) {
+			t.Errorf("%q: unexpected error -- got %v, want %v", test.name,
+				err, test.err)
+			continue
+		}
+		if !test.useBatch {
+			continue
+		}
+		if len(batch.sigs) != test.numSigs {
+			t.Errorf("%q: unexpected number of batched signatures -- got %d, "+
+				"want %d", test.name, len(batch.sigs), test.numSigs)
+			continue
+		}
+		if batch.verify() != test.batchGood {
+			t.Errorf("%q: unexpected batch result -- got %v, want %v",
+				test.name, !test.batchGood, test.batchGood)
+		}
+	}
+}
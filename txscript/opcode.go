@@ -1,5 +1,5 @@
 // Copyright (c) 2013-2016 The btcsuite developers
-// Copyright (c) 2015-2023 The Decred developers
+// Copyright (c) 2015-2024 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
@@ -2897,6 +2897,15 @@ func opcodeCheckSigAlt(op *opcode, data []byte, vm *Engine) error {
 			vm.dstack.PushBool(false)
 			return nil // nolint:nilerr
 		}
+
+		// Defer verification to the batch when one is set and assume the
+		// signature is valid.  Signatures that are known to be invalid
+		// without verifying them are rejected immediately.
+		if vm.schnorrBatch != nil {
+			err := vm.schnorrBatch.Add(sigSec, hash, pubKeySec)
+			vm.dstack.PushBool(err == nil)
+			return nil
+		}
 		ok := sigSec.Verify(hash, pubKeySec)
 		vm.dstack.PushBool(ok)
 		return nil
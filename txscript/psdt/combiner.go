@@ -0,0 +1,169 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package psdt
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// This file implements the combiner role which merges the information of
+// multiple packets for the same transaction, such as the partial signatures
+// produced by independent signers.
+
+// mergeBytes merges the provided field from two packets.  It returns an error
+// when both packets contain different values.
+func mergeBytes(field string, dst, src []byte) ([]byte, error) {
+	switch {
+	case src == nil:
+		return dst, nil
+	case dst == nil:
+		return copyBytes(src), nil
+	case !bytes.Equal(dst, src):
+		str := fmt.Sprintf("packets contain conflicting %s", field)
+		return nil, makeError(ErrConflictingData, str)
+	}
+	return dst, nil
+}
+
+// mergeUnknowns merges the provided unknown pairs from two packets while
+// keeping the first value for duplicate keys.
+func mergeUnknowns(dst, src []*Unknown) []*Unknown {
+	for _, u := range src {
+		var found bool
+		for _, existing := range dst {
+			if bytes.Equal(existing.Key, u.Key) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst = append(dst, &Unknown{Key: copyBytes(u.Key),
+				Value: copyBytes(u.Value)})
+		}
+	}
+	return dst
+}
+
+// mergeDerivations merges the provided derivations from two packets while
+// keeping the first derivation for duplicate public keys.
+func mergeDerivations(dst, src []*Bip32Derivation) []*Bip32Derivation {
+	for _, d := range src {
+		var found bool
+		for _, existing := range dst {
+			if bytes.Equal(existing.PubKey, d.PubKey) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst = append(dst, copyDerivations([]*Bip32Derivation{d})...)
+		}
+	}
+	return dst
+}
+
+// mergeInput merges the information of the src input into the dst input.
+func mergeInput(idx int, dst, src *PInput) error {
+	// Inputs that are finalized in either packet are finalized in the
+	// combined packet.
+	if dst.FinalScriptSig != nil {
+		return nil
+	}
+	if src.FinalScriptSig != nil {
+		prevOut := dst.PrevOut
+		*dst = *src
+		if dst.PrevOut == nil {
+			dst.PrevOut = prevOut
+		}
+		return nil
+	}
+
+	switch {
+	case dst.PrevOut == nil:
+		dst.PrevOut = src.PrevOut
+	case src.PrevOut != nil && (dst.PrevOut.Value != src.PrevOut.Value ||
+		dst.PrevOut.Version != src.PrevOut.Version ||
+		!bytes.Equal(dst.PrevOut.PkScript, src.PrevOut.PkScript)):
+
+		str := fmt.Sprintf("packets contain conflicting previous outputs "+
+			"for input %d", idx)
+		return makeError(ErrConflictingData, str)
+	}
+	switch {
+	case dst.SigHashType == 0:
+		dst.SigHashType = src.SigHashType
+	case src.SigHashType != 0 && dst.SigHashType != src.SigHashType:
+		str := fmt.Sprintf("packets contain conflicting signature hash "+
+			"types for input %d", idx)
+		return makeError(ErrConflictingData, str)
+	}
+	field := fmt.Sprintf("redeem scripts for input %d", idx)
+	redeemScript, err := mergeBytes(field, dst.RedeemScript, src.RedeemScript)
+	if err != nil {
+		return err
+	}
+	dst.RedeemScript = redeemScript
+	for _, ps := range src.PartialSigs {
+		if dst.partialSig(ps.PubKey) == nil {
+			dst.PartialSigs = append(dst.PartialSigs, &PartialSig{
+				PubKey:    copyBytes(ps.PubKey),
+				Signature: copyBytes(ps.Signature),
+			})
+		}
+	}
+	dst.Bip32Derivations = mergeDerivations(dst.Bip32Derivations,
+		src.Bip32Derivations)
+	dst.Unknowns = mergeUnknowns(dst.Unknowns, src.Unknowns)
+	return nil
+}
+
+// Combine merges the information of the provided packets, which must all be
+// for the same unsigned transaction, into a new packet.  This is the combiner
+// role.
+func Combine(packets ...*Packet) (*Packet, error) {
+	if len(packets) == 0 {
+		return nil, makeError(ErrMismatchedPackets, "no packets to combine")
+	}
+
+	combined := packets[0].Copy()
+	txHash := combined.UnsignedTx.TxHash()
+	for _, p := range packets[1:] {
+		if p.UnsignedTx.TxHash() != txHash {
+			str := fmt.Sprintf("packet for transaction %v can not be "+
+				"combined with packet for transaction %v",
+				p.UnsignedTx.TxHash(), txHash)
+			return nil, makeError(ErrMismatchedPackets, str)
+		}
+		if p.TxType != combined.TxType {
+			str := fmt.Sprintf("packets contain conflicting transaction "+
+				"types %v and %v", combined.TxType, p.TxType)
+			return nil, makeError(ErrConflictingData, str)
+		}
+
+		p = p.Copy()
+		for idx := range combined.Inputs {
+			err := mergeInput(idx, &combined.Inputs[idx], &p.Inputs[idx])
+			if err != nil {
+				return nil, err
+			}
+		}
+		for idx := range combined.Outputs {
+			dst, src := &combined.Outputs[idx], &p.Outputs[idx]
+			field := fmt.Sprintf("redeem scripts for output %d", idx)
+			redeemScript, err := mergeBytes(field, dst.RedeemScript,
+				src.RedeemScript)
+			if err != nil {
+				return nil, err
+			}
+			dst.RedeemScript = redeemScript
+			dst.Bip32Derivations = mergeDerivations(dst.Bip32Derivations,
+				src.Bip32Derivations)
+			dst.Unknowns = mergeUnknowns(dst.Unknowns, src.Unknowns)
+		}
+		combined.Unknowns = mergeUnknowns(combined.Unknowns, p.Unknowns)
+	}
+	return combined, nil
+}
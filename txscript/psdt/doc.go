@@ -0,0 +1,56 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package psdt implements partially signed Decred transactions (PSDT).
+
+A partially signed transaction is an interchange format that carries an unsigned
+transaction along with all of the per-input and per-output metadata the parties
+involved in creating and signing it need.  This allows transactions to be
+constructed and signed by independent parties, such as the participants of
+multi-party custody arrangements and hardware signing devices, that do not have
+access to each other's keys or the blockchain.
+
+The format is modeled after the partially signed Bitcoin transaction format
+described by BIP174 and adapted to Decred transactions.
+
+# Roles
+
+The package provides the following roles which are typically carried out by
+different parties:
+
+  - Creator: New creates a packet for an unsigned transaction along with a hint
+    for its stake transaction type
+  - Updater: the AddIn* and AddOut* methods add the information needed to sign
+    the inputs and verify the outputs, such as the previous outputs, redeem
+    scripts, signature hash types and BIP32 derivation paths of the keys
+  - Signer: Sign and AddPartialSig add partial signatures for inputs
+  - Combiner: Combine merges multiple packets for the same transaction, such
+    as the packets returned by independent signers
+  - Finalizer: Finalize and FinalizeAll turn the partial signatures of the
+    inputs into complete signature scripts
+  - Extractor: Extract returns the final signed transaction
+
+# Serialization
+
+A serialized packet consists of the magic bytes "psdt" followed by 0xff, a
+global map, one map for each input and one map for each output of the unsigned
+transaction.  Each map is a sequence of key-value pairs that are serialized as
+variable length byte arrays and is terminated by a key of zero length.  The
+first byte of every key identifies its type and the remaining bytes are key
+data, such as a public key.
+
+Key-value pairs with unknown types are retained so they survive being processed
+by software that does not recognize them.  Packets are typically exchanged as
+the base64 encoding of their serialization.
+
+# Errors
+
+Errors returned by this package are of type psdt.Error and fully support the
+standard library errors.Is and errors.As functions.  This allows the caller to
+programmatically determine the specific error by examining the ErrorKind field
+of the type asserted psdt.Error while still providing rich error messages with
+contextual information.
+*/
+package psdt
@@ -0,0 +1,112 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package psdt
+
+// ErrorKind identifies a kind of error.
+type ErrorKind string
+
+// These constants are used to identify a specific ErrorKind.
+const (
+	// ErrInvalidMagic indicates a serialized packet does not begin with the
+	// expected magic bytes.
+	ErrInvalidMagic = ErrorKind("ErrInvalidMagic")
+
+	// ErrMalformedPacket indicates a serialized packet could not be decoded
+	// because it does not adhere to the format.
+	ErrMalformedPacket = ErrorKind("ErrMalformedPacket")
+
+	// ErrUnsupportedVersion indicates a packet has a version that is not
+	// supported.
+	ErrUnsupportedVersion = ErrorKind("ErrUnsupportedVersion")
+
+	// ErrDuplicateKey indicates a map in a serialized packet contains the
+	// same key more than once.
+	ErrDuplicateKey = ErrorKind("ErrDuplicateKey")
+
+	// ErrInvalidUnsignedTx indicates the unsigned transaction of a packet is
+	// either missing or contains signature scripts.
+	ErrInvalidUnsignedTx = ErrorKind("ErrInvalidUnsignedTx")
+
+	// ErrInvalidIndex indicates an out-of-bounds input or output index was
+	// provided.
+	ErrInvalidIndex = ErrorKind("ErrInvalidIndex")
+
+	// ErrInvalidPubKey indicates a public key is not a valid compressed
+	// secp256k1 public key.
+	ErrInvalidPubKey = ErrorKind("ErrInvalidPubKey")
+
+	// ErrInvalidSignature indicates a signature is either malformed or does
+	// not commit to the input it is provided for.
+	ErrInvalidSignature = ErrorKind("ErrInvalidSignature")
+
+	// ErrMissingPrevOut indicates an operation requires the previous output
+	// spent by an input, but it has not been provided.
+	ErrMissingPrevOut = ErrorKind("ErrMissingPrevOut")
+
+	// ErrMissingRedeemScript indicates an input spends a pay-to-script-hash
+	// output, but the redeem script has not been provided.
+	ErrMissingRedeemScript = ErrorKind("ErrMissingRedeemScript")
+
+	// ErrInvalidRedeemScript indicates a redeem script does not hash to the
+	// script hash of the output it is provided for.
+	ErrInvalidRedeemScript = ErrorKind("ErrInvalidRedeemScript")
+
+	// ErrUnsupportedScript indicates an operation is not supported for the
+	// type or version of the script.
+	ErrUnsupportedScript = ErrorKind("ErrUnsupportedScript")
+
+	// ErrKeyNotRelevant indicates a public key is not able to sign for an
+	// input.
+	ErrKeyNotRelevant = ErrorKind("ErrKeyNotRelevant")
+
+	// ErrInputFinalized indicates an attempt to modify the signing data of an
+	// input that has already been finalized.
+	ErrInputFinalized = ErrorKind("ErrInputFinalized")
+
+	// ErrNotEnoughSignatures indicates an input can not be finalized because
+	// it does not have enough partial signatures.
+	ErrNotEnoughSignatures = ErrorKind("ErrNotEnoughSignatures")
+
+	// ErrNotFinalized indicates a transaction can not be extracted because
+	// not all of its inputs have been finalized.
+	ErrNotFinalized = ErrorKind("ErrNotFinalized")
+
+	// ErrMismatchedPackets indicates an attempt to combine packets for
+	// different transactions.
+	ErrMismatchedPackets = ErrorKind("ErrMismatchedPackets")
+
+	// ErrConflictingData indicates an attempt to combine packets that
+	// contain different values for the same field.
+	ErrConflictingData = ErrorKind("ErrConflictingData")
+)
+
+// Error satisfies the error interface and prints human-readable errors.
+func (e ErrorKind) Error() string {
+	return string(e)
+}
+
+// Error identifies a partially signed transaction related error.
+//
+// It has full support for errors.Is and errors.As, so the caller can ascertain
+// the specific reason for the error by checking the underlying error.
+type Error struct {
+	Err         error
+	Description string
+}
+
+// Error satisfies the error interface and prints human-readable errors.
+func (e Error) Error() string {
+	return e.Description
+}
+
+// Unwrap returns the underlying wrapped error.
+func (e Error) Unwrap() error {
+	return e.Err
+}
+
+// makeError creates an Error given a set of arguments.
+func makeError(kind ErrorKind, desc string) Error {
+	return Error{Err: kind, Description: desc}
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package psdt
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+// TestErrorKindStringer tests the stringized output for the ErrorKind type.
+func TestErrorKindStringer(t *testing.T) {
+	tests := []struct {
+		in   ErrorKind
+		want string
+	}{
+		{ErrInvalidMagic, "ErrInvalidMagic"},
+		{ErrMalformedPacket, "ErrMalformedPacket"},
+		{ErrUnsupportedVersion, "ErrUnsupportedVersion"},
+		{ErrDuplicateKey, "ErrDuplicateKey"},
+		{ErrInvalidUnsignedTx, "ErrInvalidUnsignedTx"},
+		{ErrInvalidIndex, "ErrInvalidIndex"},
+		{ErrInvalidPubKey, "ErrInvalidPubKey"},
+		{ErrInvalidSignature, "ErrInvalidSignature"},
+		{ErrMissingPrevOut, "ErrMissingPrevOut"},
+		{ErrMissingRedeemScript, "ErrMissingRedeemScript"},
+		{ErrInvalidRedeemScript, "ErrInvalidRedeemScript"},
+		{ErrUnsupportedScript, "ErrUnsupportedScript"},
+		{ErrKeyNotRelevant, "ErrKeyNotRelevant"},
+		{ErrInputFinalized, "ErrInputFinalized"},
+		{ErrNotEnoughSignatures, "ErrNotEnoughSignatures"},
+		{ErrNotFinalized, "ErrNotFinalized"},
+		{ErrMismatchedPackets, "ErrMismatchedPackets"},
+		{ErrConflictingData, "ErrConflictingData"},
+	}
+
+	for i, test := range tests {
+		result := test.in.Error()
+		if result != test.want {
+			t.Errorf("#%d: got: %s want: %s", i, result, test.want)
+			continue
+		}
+	}
+}
+
+// TestError tests the error output for the Error type.
+func TestError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   Error
+		want string
+	}{{
+		Error{Description: "some error"},
+		"some error",
+	}, {
+		Error{Description: "human-readable error"},
+		"human-readable error",
+	}}
+
+	for i, test := range tests {
+		result := test.in.Error()
+		if result != test.want {
+			t.Errorf("#%d: got: %s want: %s", i, result, test.want)
+			continue
+		}
+	}
+}
+
+// TestErrorKindIsAs ensures both ErrorKind and Error can be identified as being
+// a specific error kind via errors.Is and unwrapped via errors.As.
+func TestErrorKindIsAs(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		target    error
+		wantMatch bool
+		wantAs    ErrorKind
+	}{{
+		name:      "ErrInvalidMagic == ErrInvalidMagic",
+		err:       ErrInvalidMagic,
+		target:    ErrInvalidMagic,
+		wantMatch: true,
+		wantAs:    ErrInvalidMagic,
+	}, {
+		name:      "Error.ErrInvalidMagic == ErrInvalidMagic",
+		err:       makeError(ErrInvalidMagic, ""),
+		target:    ErrInvalidMagic,
+		wantMatch: true,
+		wantAs:    ErrInvalidMagic,
+	}, {
+		name:      "ErrInvalidMagic != ErrMalformedPacket",
+		err:       ErrInvalidMagic,
+		target:    ErrMalformedPacket,
+		wantMatch: false,
+		wantAs:    ErrInvalidMagic,
+	}, {
+		name:      "Error.ErrInvalidMagic != ErrMalformedPacket",
+		err:       makeError(ErrInvalidMagic, ""),
+		target:    ErrMalformedPacket,
+		wantMatch: false,
+		wantAs:    ErrInvalidMagic,
+	}, {
+		name:      "ErrInvalidMagic != Error.ErrMalformedPacket",
+		err:       ErrInvalidMagic,
+		target:    makeError(ErrMalformedPacket, ""),
+		wantMatch: false,
+		wantAs:    ErrInvalidMagic,
+	}, {
+		name:      "Error.ErrInvalidMagic != Error.ErrMalformedPacket",
+		err:       makeError(ErrInvalidMagic, ""),
+		target:    makeError(ErrMalformedPacket, ""),
+		wantMatch: false,
+		wantAs:    ErrInvalidMagic,
+	}, {
+		name:      "Error.ErrInvalidMagic != io.EOF",
+		err:       makeError(ErrInvalidMagic, ""),
+		target:    io.EOF,
+		wantMatch: false,
+		wantAs:    ErrInvalidMagic,
+	}}
+
+	for _, test := range tests {
+		// Ensure the error matches or not depending on the expected result.
+		result := errors.Is(test.err, test.target)
+		if result != test.wantMatch {
+			t.Errorf("%s: incorrect error identification -- got %v, want %v",
+				test.name, result, test.wantMatch)
+			continue
+		}
+
+		// Ensure the underlying error kind can be unwrapped and is the
+		// expected kind.
+		var kind ErrorKind
+		if !errors.As(test.err, &kind) {
+			t.Errorf("%s: unable to unwrap to error kind", test.name)
+			continue
+		}
+		if kind != test.wantAs {
+			t.Errorf("%s: unexpected unwrapped error kind -- got %v, want %v",
+				test.name, kind, test.wantAs)
+			continue
+		}
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package psdt
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/decred/dcrd/txscript/v4"
+	"github.com/decred/dcrd/txscript/v4/stdaddr"
+	"github.com/decred/dcrd/txscript/v4/stdscript"
+	"github.com/decred/dcrd/wire"
+)
+
+// This file implements the finalizer role which turns the partial signatures
+// of inputs into complete signature scripts and the extractor role which
+// produces the final signed transaction.
+
+// partialSig returns the partial signature of the input by the provided public
+// key or nil when there is none.
+func (in *PInput) partialSig(pubKey []byte) []byte {
+	for _, ps := range in.PartialSigs {
+		if bytes.Equal(ps.PubKey, pubKey) {
+			return ps.Signature
+		}
+	}
+	return nil
+}
+
+// Finalize builds the final signature script for the input at the provided
+// index from its partial signatures.  Finalizing an input that is already
+// finalized has no effect.
+//
+// Inputs that spend pay-to-pubkey-hash, pay-to-pubkey and standard multisig
+// scripts are supported along with their pay-to-script-hash and stake-tagged
+// variants.  The signing data of the input, aside from the previous output, is
+// removed once it is finalized since it is no longer needed.
+func (p *Packet) Finalize(idx int) error {
+	if err := p.checkInputIndex(idx); err != nil {
+		return err
+	}
+	in := &p.Inputs[idx]
+	if in.FinalScriptSig != nil {
+		return nil
+	}
+	script, err := p.signingScript(idx)
+	if err != nil {
+		return err
+	}
+
+	builder := txscript.NewScriptBuilder()
+	switch {
+	case extractPubKeyHash(script) != nil:
+		pkHash := extractPubKeyHash(script)
+		var found bool
+		for _, ps := range in.PartialSigs {
+			if bytes.Equal(stdaddr.Hash160(ps.PubKey), pkHash) {
+				builder.AddData(ps.Signature).AddData(ps.PubKey)
+				found = true
+				break
+			}
+		}
+		if !found {
+			str := fmt.Sprintf("input %d has no signature", idx)
+			return makeError(ErrNotEnoughSignatures, str)
+		}
+
+	case stdscript.ExtractCompressedPubKeyV0(script) != nil:
+		pubKey := stdscript.ExtractCompressedPubKeyV0(script)
+		sig := in.partialSig(pubKey)
+		if sig == nil {
+			str := fmt.Sprintf("input %d has no signature", idx)
+			return makeError(ErrNotEnoughSignatures, str)
+		}
+		builder.AddData(sig)
+
+	case stdscript.IsMultiSigScriptV0(script):
+		// The signatures must be in the same order as the public keys in the
+		// multisig script.  No dummy element is needed in Decred.
+		details := stdscript.ExtractMultiSigScriptDetailsV0(script, true)
+		var numSigs uint16
+		for _, pubKey := range details.PubKeys {
+			if numSigs == details.RequiredSigs {
+				break
+			}
+			if sig := in.partialSig(pubKey); sig != nil {
+				builder.AddData(sig)
+				numSigs++
+			}
+		}
+		if numSigs < details.RequiredSigs {
+			str := fmt.Sprintf("input %d has %d of %d required signatures",
+				idx, numSigs, details.RequiredSigs)
+			return makeError(ErrNotEnoughSignatures, str)
+		}
+
+	default:
+		str := fmt.Sprintf("unsupported signing script %x for input %d",
+			script, idx)
+		return makeError(ErrUnsupportedScript, str)
+	}
+	if in.RedeemScript != nil {
+		builder.AddData(in.RedeemScript)
+	}
+	sigScript, err := builder.Script()
+	if err != nil {
+		return err
+	}
+
+	in.FinalScriptSig = sigScript
+	in.PartialSigs = nil
+	in.SigHashType = 0
+	in.RedeemScript = nil
+	in.Bip32Derivations = nil
+	return nil
+}
+
+// FinalizeAll finalizes all inputs of the packet.  It returns the error for the
+// first input that can not be finalized.
+func (p *Packet) FinalizeAll() error {
+	for idx := range p.Inputs {
+		if err := p.Finalize(idx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Extract returns the signed transaction of a packet that has all of its
+// inputs finalized.  This is the extractor role.
+//
+// The input amounts of the transaction are set to the values of the previous
+// outputs when they are known.
+func (p *Packet) Extract() (*wire.MsgTx, error) {
+	tx := p.UnsignedTx.Copy()
+	for idx := range p.Inputs {
+		in := &p.Inputs[idx]
+		if in.FinalScriptSig == nil {
+			str := fmt.Sprintf("input %d is not finalized", idx)
+			return nil, makeError(ErrNotFinalized, str)
+		}
+		tx.TxIn[idx].SignatureScript = copyBytes(in.FinalScriptSig)
+		if in.PrevOut != nil {
+			tx.TxIn[idx].ValueIn = in.PrevOut.Value
+		}
+	}
+	return tx, nil
+}
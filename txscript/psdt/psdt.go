@@ -0,0 +1,844 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package psdt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/txscript/v4"
+	"github.com/decred/dcrd/wire"
+)
+
+const (
+	// Version is the current version of the partially signed transaction
+	// format.
+	Version = 0
+
+	// maxKeySize is the maximum allowed size of a key in a serialized packet.
+	maxKeySize = 1 + secp256k1.PubKeyBytesLenCompressed
+
+	// maxValueSize is the maximum allowed size of a value in a serialized
+	// packet.
+	maxValueSize = wire.MaxBlockPayload
+)
+
+// magic is the sequence of bytes every serialized packet begins with.
+var magic = [5]byte{'p', 's', 'd', 't', 0xff}
+
+// These constants define the types of the keys in the global map of a
+// serialized packet.
+const (
+	globalUnsignedTxType = 0x00
+	globalTxTypeType     = 0x01
+	globalVersionType    = 0xfb
+)
+
+// These constants define the types of the keys in the input maps of a
+// serialized packet.
+const (
+	inputPrevOutType         = 0x00
+	inputPartialSigType      = 0x01
+	inputSigHashType         = 0x02
+	inputRedeemScriptType    = 0x03
+	inputBip32DerivationType = 0x04
+	inputFinalScriptSigType  = 0x05
+)
+
+// These constants define the types of the keys in the output maps of a
+// serialized packet.
+const (
+	outputRedeemScriptType    = 0x00
+	outputBip32DerivationType = 0x01
+)
+
+// TxType is a hint that describes the stake type of the transaction in a
+// packet to signers.  The values are the same as those of the stake package.
+type TxType uint8
+
+// These constants define the supported transaction type hints.
+const (
+	TxTypeRegular TxType = iota
+	TxTypeSStx
+	TxTypeSSGen
+	TxTypeSSRtx
+	TxTypeTAdd
+	TxTypeTSpend
+	TxTypeTreasuryBase
+)
+
+// txTypeStrings is a map of transaction type hints back to their constant
+// names for pretty printing.
+var txTypeStrings = map[TxType]string{
+	TxTypeRegular:      "regular",
+	TxTypeSStx:         "ticket",
+	TxTypeSSGen:        "vote",
+	TxTypeSSRtx:        "revocation",
+	TxTypeTAdd:         "treasuryadd",
+	TxTypeTSpend:       "treasuryspend",
+	TxTypeTreasuryBase: "treasurybase",
+}
+
+// String returns the TxType as a human-readable string.
+func (t TxType) String() string {
+	if s, ok := txTypeStrings[t]; ok {
+		return s
+	}
+	return fmt.Sprintf("unknown(%d)", uint8(t))
+}
+
+// isSpecialInput returns whether or not the input at the provided index of a
+// transaction with the given type is not signed in the usual way because it
+// does not spend a previous output.  The signature script of such an input is
+// provided by the creator via the final signature script of the input.
+func isSpecialInput(txType TxType, idx int) bool {
+	return idx == 0 && (txType == TxTypeSSGen || txType == TxTypeTreasuryBase)
+}
+
+// Unknown is a key-value pair of a packet that is not recognized by this
+// package.  They are retained so they are not lost when a packet is processed
+// by software that does not understand them.
+type Unknown struct {
+	Key   []byte
+	Value []byte
+}
+
+// Bip32Derivation describes the hierarchical deterministic derivation of a
+// public key.
+type Bip32Derivation struct {
+	// PubKey is the serialized compressed public key.
+	PubKey []byte
+
+	// MasterKeyFingerprint is the fingerprint of the master extended key the
+	// public key is derived from.
+	MasterKeyFingerprint uint32
+
+	// Path is the derivation path of the public key from the master extended
+	// key.  Hardened children are indicated by indices that are at least
+	// hdkeychain.HardenedKeyStart.
+	Path []uint32
+}
+
+// PartialSig is a signature for an input along with the public key that
+// produced it.
+type PartialSig struct {
+	// PubKey is the serialized compressed public key.
+	PubKey []byte
+
+	// Signature is the DER-encoded ECDSA signature with the signature hash
+	// type appended.
+	Signature []byte
+}
+
+// PInput houses the metadata needed to sign and finalize an input of a
+// partially signed transaction.
+type PInput struct {
+	// PrevOut is the previous output spent by the input.
+	PrevOut *wire.TxOut
+
+	// PartialSigs are the signatures collected for the input so far.
+	PartialSigs []*PartialSig
+
+	// SigHashType is the signature hash type signers must use.  A value of
+	// zero indicates txscript.SigHashAll.
+	SigHashType txscript.SigHashType
+
+	// RedeemScript is the redeem script of a previous output that pays to a
+	// script hash.
+	RedeemScript []byte
+
+	// Bip32Derivations describe the derivation of the public keys involved
+	// in signing the input.
+	Bip32Derivations []*Bip32Derivation
+
+	// FinalScriptSig is the complete signature script for the input.  Once
+	// it is set, the input is considered finalized.
+	FinalScriptSig []byte
+
+	// Unknowns are the unrecognized key-value pairs of the input.
+	Unknowns []*Unknown
+}
+
+// POutput houses the metadata of an output of a partially signed transaction
+// which allows signers to verify outputs that belong to them.
+type POutput struct {
+	// RedeemScript is the redeem script of an output that pays to a script
+	// hash.
+	RedeemScript []byte
+
+	// Bip32Derivations describe the derivation of the public keys involved
+	// in the output.
+	Bip32Derivations []*Bip32Derivation
+
+	// Unknowns are the unrecognized key-value pairs of the output.
+	Unknowns []*Unknown
+}
+
+// Packet is a partially signed Decred transaction.  It houses an unsigned
+// transaction along with the per-input and per-output metadata needed by the
+// various parties involved in signing it.
+type Packet struct {
+	// UnsignedTx is the transaction being signed.  All of its signature
+	// scripts are empty.
+	UnsignedTx *wire.MsgTx
+
+	// TxType is a hint that describes the stake type of the transaction.
+	TxType TxType
+
+	// Inputs house the metadata for each input of the transaction.
+	Inputs []PInput
+
+	// Outputs house the metadata for each output of the transaction.
+	Outputs []POutput
+
+	// Unknowns are the unrecognized key-value pairs of the global map.
+	Unknowns []*Unknown
+}
+
+// New creates a new packet for the provided unsigned transaction with the
+// given stake type hint.  This is the creator role.
+//
+// All signature scripts of the transaction must be empty with the exception of
+// the stakebase input of votes and treasurybases, whose signature script is
+// moved to the final signature script of the respective input of the packet.
+func New(tx *wire.MsgTx, txType TxType) (*Packet, error) {
+	if tx == nil {
+		return nil, makeError(ErrInvalidUnsignedTx, "no unsigned transaction")
+	}
+	unsignedTx := tx.Copy()
+	p := &Packet{
+		UnsignedTx: unsignedTx,
+		TxType:     txType,
+		Inputs:     make([]PInput, len(tx.TxIn)),
+		Outputs:    make([]POutput, len(tx.TxOut)),
+	}
+	for idx, txIn := range unsignedTx.TxIn {
+		if len(txIn.SignatureScript) == 0 {
+			continue
+		}
+		if !isSpecialInput(txType, idx) {
+			str := fmt.Sprintf("input %d of the unsigned transaction has a "+
+				"signature script", idx)
+			return nil, makeError(ErrInvalidUnsignedTx, str)
+		}
+		p.Inputs[idx].FinalScriptSig = txIn.SignatureScript
+		txIn.SignatureScript = nil
+	}
+	return p, nil
+}
+
+// checkInputIndex returns an error when the provided index does not refer to
+// an input of the packet.
+func (p *Packet) checkInputIndex(idx int) error {
+	if idx < 0 || idx >= len(p.Inputs) {
+		str := fmt.Sprintf("input index %d is out of range [0, %d)", idx,
+			len(p.Inputs))
+		return makeError(ErrInvalidIndex, str)
+	}
+	return nil
+}
+
+// checkOutputIndex returns an error when the provided index does not refer to
+// an output of the packet.
+func (p *Packet) checkOutputIndex(idx int) error {
+	if idx < 0 || idx >= len(p.Outputs) {
+		str := fmt.Sprintf("output index %d is out of range [0, %d)", idx,
+			len(p.Outputs))
+		return makeError(ErrInvalidIndex, str)
+	}
+	return nil
+}
+
+// checkPubKey returns an error when the provided public key is not a valid
+// serialized compressed secp256k1 public key.
+func checkPubKey(pubKey []byte) error {
+	if len(pubKey) != secp256k1.PubKeyBytesLenCompressed {
+		str := fmt.Sprintf("public key %x is not a compressed public key",
+			pubKey)
+		return makeError(ErrInvalidPubKey, str)
+	}
+	if _, err := secp256k1.ParsePubKey(pubKey); err != nil {
+		str := fmt.Sprintf("invalid public key %x: %v", pubKey, err)
+		return makeError(ErrInvalidPubKey, str)
+	}
+	return nil
+}
+
+// IsComplete returns whether or not all inputs of the packet are finalized,
+// which means the signed transaction can be extracted.
+func (p *Packet) IsComplete() bool {
+	for i := range p.Inputs {
+		if p.Inputs[i].FinalScriptSig == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Fee returns the fee paid by the transaction.  It returns false when the
+// previous output of any input is unknown.
+func (p *Packet) Fee() (int64, bool) {
+	var in int64
+	for idx := range p.Inputs {
+		if isSpecialInput(p.TxType, idx) {
+			in += p.UnsignedTx.TxIn[idx].ValueIn
+			continue
+		}
+		prevOut := p.Inputs[idx].PrevOut
+		if prevOut == nil {
+			return 0, false
+		}
+		in += prevOut.Value
+	}
+	var out int64
+	for _, txOut := range p.UnsignedTx.TxOut {
+		out += txOut.Value
+	}
+	return in - out, true
+}
+
+// copyBytes returns a copy of the provided byte slice while retaining nil.
+func copyBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append([]byte(nil), b...)
+}
+
+// copyUnknowns returns a deep copy of the provided unknown pairs.
+func copyUnknowns(unknowns []*Unknown) []*Unknown {
+	if unknowns == nil {
+		return nil
+	}
+	c := make([]*Unknown, 0, len(unknowns))
+	for _, u := range unknowns {
+		c = append(c, &Unknown{Key: copyBytes(u.Key), Value: copyBytes(u.Value)})
+	}
+	return c
+}
+
+// copyDerivations returns a deep copy of the provided derivations.
+func copyDerivations(derivations []*Bip32Derivation) []*Bip32Derivation {
+	if derivations == nil {
+		return nil
+	}
+	c := make([]*Bip32Derivation, 0, len(derivations))
+	for _, d := range derivations {
+		c = append(c, &Bip32Derivation{
+			PubKey:               copyBytes(d.PubKey),
+			MasterKeyFingerprint: d.MasterKeyFingerprint,
+			Path:                 append([]uint32(nil), d.Path...),
+		})
+	}
+	return c
+}
+
+// Copy returns a deep copy of the packet.
+func (p *Packet) Copy() *Packet {
+	c := &Packet{
+		UnsignedTx: p.UnsignedTx.Copy(),
+		TxType:     p.TxType,
+		Inputs:     make([]PInput, len(p.Inputs)),
+		Outputs:    make([]POutput, len(p.Outputs)),
+		Unknowns:   copyUnknowns(p.Unknowns),
+	}
+	for i := range p.Inputs {
+		in, cin := &p.Inputs[i], &c.Inputs[i]
+		if in.PrevOut != nil {
+			cin.PrevOut = wire.NewTxOut(in.PrevOut.Value,
+				copyBytes(in.PrevOut.PkScript))
+			cin.PrevOut.Version = in.PrevOut.Version
+		}
+		for _, ps := range in.PartialSigs {
+			cin.PartialSigs = append(cin.PartialSigs, &PartialSig{
+				PubKey:    copyBytes(ps.PubKey),
+				Signature: copyBytes(ps.Signature),
+			})
+		}
+		cin.SigHashType = in.SigHashType
+		cin.RedeemScript = copyBytes(in.RedeemScript)
+		cin.Bip32Derivations = copyDerivations(in.Bip32Derivations)
+		cin.FinalScriptSig = copyBytes(in.FinalScriptSig)
+		cin.Unknowns = copyUnknowns(in.Unknowns)
+	}
+	for i := range p.Outputs {
+		out, cout := &p.Outputs[i], &c.Outputs[i]
+		cout.RedeemScript = copyBytes(out.RedeemScript)
+		cout.Bip32Derivations = copyDerivations(out.Bip32Derivations)
+		cout.Unknowns = copyUnknowns(out.Unknowns)
+	}
+	return c
+}
+
+// writePair serializes the provided key-value pair to w.
+func writePair(w io.Writer, key, value []byte) error {
+	if err := wire.WriteVarBytes(w, 0, key); err != nil {
+		return err
+	}
+	return wire.WriteVarBytes(w, 0, value)
+}
+
+// writeTypedPair serializes a key-value pair with a key that consists of the
+// provided type followed by the key data to w.
+func writeTypedPair(w io.Writer, keyType byte, keyData, value []byte) error {
+	key := make([]byte, 0, 1+len(keyData))
+	key = append(key, keyType)
+	key = append(key, keyData...)
+	return writePair(w, key, value)
+}
+
+// writeSeparator serializes the separator that terminates a map to w.
+func writeSeparator(w io.Writer) error {
+	_, err := w.Write([]byte{0x00})
+	return err
+}
+
+// serializeDerivation returns the serialized value of the provided derivation.
+func serializeDerivation(d *Bip32Derivation) []byte {
+	value := make([]byte, 4+4*len(d.Path))
+	binary.LittleEndian.PutUint32(value, d.MasterKeyFingerprint)
+	for i, index := range d.Path {
+		binary.LittleEndian.PutUint32(value[4+4*i:], index)
+	}
+	return value
+}
+
+// writeUnknowns serializes the provided unknown pairs to w.
+func writeUnknowns(w io.Writer, unknowns []*Unknown) error {
+	for _, u := range unknowns {
+		if err := writePair(w, u.Key, u.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Serialize writes the binary serialization of the packet to w.
+func (p *Packet) Serialize(w io.Writer) error {
+	if _, err := w.Write(magic[:]); err != nil {
+		return err
+	}
+
+	// Global map.
+	txBytes, err := p.UnsignedTx.Bytes()
+	if err != nil {
+		return err
+	}
+	if err := writeTypedPair(w, globalUnsignedTxType, nil, txBytes); err != nil {
+		return err
+	}
+	txType := []byte{byte(p.TxType)}
+	if err := writeTypedPair(w, globalTxTypeType, nil, txType); err != nil {
+		return err
+	}
+	var version [4]byte
+	binary.LittleEndian.PutUint32(version[:], Version)
+	err = writeTypedPair(w, globalVersionType, nil, version[:])
+	if err != nil {
+		return err
+	}
+	if err := writeUnknowns(w, p.Unknowns); err != nil {
+		return err
+	}
+	if err := writeSeparator(w); err != nil {
+		return err
+	}
+
+	// Input maps.
+	for i := range p.Inputs {
+		in := &p.Inputs[i]
+		if in.PrevOut != nil {
+			var buf bytes.Buffer
+			var amountAndVersion [10]byte
+			binary.LittleEndian.PutUint64(amountAndVersion[:],
+				uint64(in.PrevOut.Value))
+			binary.LittleEndian.PutUint16(amountAndVersion[8:],
+				in.PrevOut.Version)
+			buf.Write(amountAndVersion[:])
+			err := wire.WriteVarBytes(&buf, 0, in.PrevOut.PkScript)
+			if err != nil {
+				return err
+			}
+			err = writeTypedPair(w, inputPrevOutType, nil, buf.Bytes())
+			if err != nil {
+				return err
+			}
+		}
+		for _, ps := range in.PartialSigs {
+			err := writeTypedPair(w, inputPartialSigType, ps.PubKey,
+				ps.Signature)
+			if err != nil {
+				return err
+			}
+		}
+		if in.SigHashType != 0 {
+			var hashType [4]byte
+			binary.LittleEndian.PutUint32(hashType[:], uint32(in.SigHashType))
+			err := writeTypedPair(w, inputSigHashType, nil, hashType[:])
+			if err != nil {
+				return err
+			}
+		}
+		if in.RedeemScript != nil {
+			err := writeTypedPair(w, inputRedeemScriptType, nil,
+				in.RedeemScript)
+			if err != nil {
+				return err
+			}
+		}
+		for _, d := range in.Bip32Derivations {
+			err := writeTypedPair(w, inputBip32DerivationType, d.PubKey,
+				serializeDerivation(d))
+			if err != nil {
+				return err
+			}
+		}
+		if in.FinalScriptSig != nil {
+			err := writeTypedPair(w, inputFinalScriptSigType, nil,
+				in.FinalScriptSig)
+			if err != nil {
+				return err
+			}
+		}
+		if err := writeUnknowns(w, in.Unknowns); err != nil {
+			return err
+		}
+		if err := writeSeparator(w); err != nil {
+			return err
+		}
+	}
+
+	// Output maps.
+	for i := range p.Outputs {
+		out := &p.Outputs[i]
+		if out.RedeemScript != nil {
+			err := writeTypedPair(w, outputRedeemScriptType, nil,
+				out.RedeemScript)
+			if err != nil {
+				return err
+			}
+		}
+		for _, d := range out.Bip32Derivations {
+			err := writeTypedPair(w, outputBip32DerivationType, d.PubKey,
+				serializeDerivation(d))
+			if err != nil {
+				return err
+			}
+		}
+		if err := writeUnknowns(w, out.Unknowns); err != nil {
+			return err
+		}
+		if err := writeSeparator(w); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Bytes returns the binary serialization of the packet.
+func (p *Packet) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := p.Serialize(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Base64 returns the base64 encoding of the binary serialization of the
+// packet, which is the form packets are usually exchanged in.
+func (p *Packet) Base64() (string, error) {
+	b, err := p.Bytes()
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// keyValue is a key-value pair of a serialized map.
+type keyValue struct {
+	key   []byte
+	value []byte
+}
+
+// readMap reads the key-value pairs of a serialized map up to and including
+// the separator that terminates it.
+func readMap(r io.Reader) ([]keyValue, error) {
+	var pairs []keyValue
+	seen := make(map[string]struct{})
+	for {
+		key, err := wire.ReadVarBytes(r, 0, maxKeySize, "key")
+		if err != nil {
+			str := fmt.Sprintf("failed to read key: %v", err)
+			return nil, makeError(ErrMalformedPacket, str)
+		}
+		if len(key) == 0 {
+			return pairs, nil
+		}
+		if _, ok := seen[string(key)]; ok {
+			str := fmt.Sprintf("duplicate key %x", key)
+			return nil, makeError(ErrDuplicateKey, str)
+		}
+		seen[string(key)] = struct{}{}
+
+		value, err := wire.ReadVarBytes(r, 0, maxValueSize, "value")
+		if err != nil {
+			str := fmt.Sprintf("failed to read value for key %x: %v", key,
+				err)
+			return nil, makeError(ErrMalformedPacket, str)
+		}
+		pairs = append(pairs, keyValue{key: key, value: value})
+	}
+}
+
+// malformedPair returns an error for a key-value pair of the given field that
+// does not have the expected form.
+func malformedPair(field string, kv keyValue) error {
+	str := fmt.Sprintf("malformed %s with key %x and value %x", field, kv.key,
+		kv.value)
+	return makeError(ErrMalformedPacket, str)
+}
+
+// parseDerivation parses the provided key-value pair as a BIP32 derivation.
+func parseDerivation(kv keyValue) (*Bip32Derivation, error) {
+	pubKey := kv.key[1:]
+	if err := checkPubKey(pubKey); err != nil {
+		return nil, err
+	}
+	if len(kv.value) < 4 || len(kv.value)%4 != 0 {
+		return nil, malformedPair("bip32 derivation", kv)
+	}
+	d := &Bip32Derivation{
+		PubKey:               pubKey,
+		MasterKeyFingerprint: binary.LittleEndian.Uint32(kv.value),
+		Path:                 make([]uint32, 0, len(kv.value)/4-1),
+	}
+	for i := 4; i < len(kv.value); i += 4 {
+		d.Path = append(d.Path, binary.LittleEndian.Uint32(kv.value[i:]))
+	}
+	return d, nil
+}
+
+// parsePrevOut parses the provided value as a previous output.
+func parsePrevOut(kv keyValue) (*wire.TxOut, error) {
+	if len(kv.key) != 1 || len(kv.value) < 10 {
+		return nil, malformedPair("previous output", kv)
+	}
+	value := int64(binary.LittleEndian.Uint64(kv.value))
+	version := binary.LittleEndian.Uint16(kv.value[8:])
+	r := bytes.NewReader(kv.value[10:])
+	pkScript, err := wire.ReadVarBytes(r, 0, maxValueSize, "pkscript")
+	if err != nil || r.Len() != 0 {
+		return nil, malformedPair("previous output", kv)
+	}
+	txOut := wire.NewTxOut(value, pkScript)
+	txOut.Version = version
+	return txOut, nil
+}
+
+// parseInput parses the provided key-value pairs of an input map.
+func parseInput(pairs []keyValue) (*PInput, error) {
+	var in PInput
+	for _, kv := range pairs {
+		switch kv.key[0] {
+		case inputPrevOutType:
+			prevOut, err := parsePrevOut(kv)
+			if err != nil {
+				return nil, err
+			}
+			in.PrevOut = prevOut
+
+		case inputPartialSigType:
+			pubKey := kv.key[1:]
+			if err := checkPubKey(pubKey); err != nil {
+				return nil, err
+			}
+			if len(kv.value) == 0 {
+				return nil, malformedPair("partial signature", kv)
+			}
+			in.PartialSigs = append(in.PartialSigs, &PartialSig{
+				PubKey:    pubKey,
+				Signature: kv.value,
+			})
+
+		case inputSigHashType:
+			if len(kv.key) != 1 || len(kv.value) != 4 {
+				return nil, malformedPair("signature hash type", kv)
+			}
+			hashType := binary.LittleEndian.Uint32(kv.value)
+			in.SigHashType = txscript.SigHashType(hashType)
+
+		case inputRedeemScriptType:
+			if len(kv.key) != 1 {
+				return nil, malformedPair("redeem script", kv)
+			}
+			in.RedeemScript = kv.value
+
+		case inputBip32DerivationType:
+			d, err := parseDerivation(kv)
+			if err != nil {
+				return nil, err
+			}
+			in.Bip32Derivations = append(in.Bip32Derivations, d)
+
+		case inputFinalScriptSigType:
+			if len(kv.key) != 1 {
+				return nil, malformedPair("final signature script", kv)
+			}
+			in.FinalScriptSig = kv.value
+
+		default:
+			in.Unknowns = append(in.Unknowns, &Unknown{
+				Key:   kv.key,
+				Value: kv.value,
+			})
+		}
+	}
+	return &in, nil
+}
+
+// parseOutput parses the provided key-value pairs of an output map.
+func parseOutput(pairs []keyValue) (*POutput, error) {
+	var out POutput
+	for _, kv := range pairs {
+		switch kv.key[0] {
+		case outputRedeemScriptType:
+			if len(kv.key) != 1 {
+				return nil, malformedPair("redeem script", kv)
+			}
+			out.RedeemScript = kv.value
+
+		case outputBip32DerivationType:
+			d, err := parseDerivation(kv)
+			if err != nil {
+				return nil, err
+			}
+			out.Bip32Derivations = append(out.Bip32Derivations, d)
+
+		default:
+			out.Unknowns = append(out.Unknowns, &Unknown{
+				Key:   kv.key,
+				Value: kv.value,
+			})
+		}
+	}
+	return &out, nil
+}
+
+// Parse decodes a packet from its binary serialization read from r.
+func Parse(r io.Reader) (*Packet, error) {
+	var m [len(magic)]byte
+	if _, err := io.ReadFull(r, m[:]); err != nil || m != magic {
+		return nil, makeError(ErrInvalidMagic, "packet does not begin with "+
+			"the magic bytes")
+	}
+
+	// Global map.
+	pairs, err := readMap(r)
+	if err != nil {
+		return nil, err
+	}
+	var p Packet
+	var version uint32
+	for _, kv := range pairs {
+		switch kv.key[0] {
+		case globalUnsignedTxType:
+			if len(kv.key) != 1 {
+				return nil, malformedPair("unsigned transaction", kv)
+			}
+			var tx wire.MsgTx
+			if err := tx.FromBytes(kv.value); err != nil {
+				str := fmt.Sprintf("failed to decode unsigned transaction: "+
+					"%v", err)
+				return nil, makeError(ErrInvalidUnsignedTx, str)
+			}
+			p.UnsignedTx = &tx
+
+		case globalTxTypeType:
+			if len(kv.key) != 1 || len(kv.value) != 1 {
+				return nil, malformedPair("transaction type", kv)
+			}
+			p.TxType = TxType(kv.value[0])
+
+		case globalVersionType:
+			if len(kv.key) != 1 || len(kv.value) != 4 {
+				return nil, malformedPair("version", kv)
+			}
+			version = binary.LittleEndian.Uint32(kv.value)
+
+		default:
+			p.Unknowns = append(p.Unknowns, &Unknown{
+				Key:   kv.key,
+				Value: kv.value,
+			})
+		}
+	}
+	if version != Version {
+		str := fmt.Sprintf("unsupported packet version %d", version)
+		return nil, makeError(ErrUnsupportedVersion, str)
+	}
+	if p.UnsignedTx == nil {
+		return nil, makeError(ErrInvalidUnsignedTx, "no unsigned transaction")
+	}
+	for idx, txIn := range p.UnsignedTx.TxIn {
+		if len(txIn.SignatureScript) != 0 {
+			str := fmt.Sprintf("input %d of the unsigned transaction has a "+
+				"signature script", idx)
+			return nil, makeError(ErrInvalidUnsignedTx, str)
+		}
+	}
+
+	// Input and output maps.
+	p.Inputs = make([]PInput, 0, len(p.UnsignedTx.TxIn))
+	for range p.UnsignedTx.TxIn {
+		pairs, err := readMap(r)
+		if err != nil {
+			return nil, err
+		}
+		in, err := parseInput(pairs)
+		if err != nil {
+			return nil, err
+		}
+		p.Inputs = append(p.Inputs, *in)
+	}
+	p.Outputs = make([]POutput, 0, len(p.UnsignedTx.TxOut))
+	for range p.UnsignedTx.TxOut {
+		pairs, err := readMap(r)
+		if err != nil {
+			return nil, err
+		}
+		out, err := parseOutput(pairs)
+		if err != nil {
+			return nil, err
+		}
+		p.Outputs = append(p.Outputs, *out)
+	}
+
+	return &p, nil
+}
+
+// ParseBase64 decodes a packet from the base64 encoding of its binary
+// serialization.
+func ParseBase64(s string) (*Packet, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		str := fmt.Sprintf("invalid base64 encoding: %v", err)
+		return nil, makeError(ErrMalformedPacket, str)
+	}
+	r := bytes.NewReader(b)
+	p, err := Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	if r.Len() != 0 {
+		str := fmt.Sprintf("%d unexpected trailing bytes", r.Len())
+		return nil, makeError(ErrMalformedPacket, str)
+	}
+	return p, nil
+}
@@ -0,0 +1,338 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package psdt
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/txscript/v4"
+	"github.com/decred/dcrd/txscript/v4/stdaddr"
+	"github.com/decred/dcrd/txscript/v4/stdscript"
+	"github.com/decred/dcrd/wire"
+)
+
+// testKeys returns the requested number of deterministic private keys for use
+// in the tests.
+func testKeys(n int) []*secp256k1.PrivateKey {
+	keys := make([]*secp256k1.PrivateKey, 0, n)
+	for i := 0; i < n; i++ {
+		seed := chainhash.HashB([]byte{byte(i)})
+		keys = append(keys, secp256k1.PrivKeyFromBytes(seed))
+	}
+	return keys
+}
+
+// testPacket returns a packet that spends a pay-to-pubkey-hash output of the
+// first key, a 2-of-3 multisig pay-to-script-hash output of the second through
+// fourth keys, and a stake-tagged pay-to-pubkey-hash output of the fifth key
+// along with the previous outputs and redeem script.
+func testPacket(t *testing.T) (*Packet, []*secp256k1.PrivateKey) {
+	t.Helper()
+
+	params := chaincfg.RegNetParams()
+	keys := testKeys(5)
+	pubKey := func(i int) []byte {
+		return keys[i].PubKey().SerializeCompressed()
+	}
+	p2pkhAddr, err := stdaddr.NewAddressPubKeyHashEcdsaSecp256k1V0(
+		stdaddr.Hash160(pubKey(0)), params)
+	if err != nil {
+		t.Fatalf("unexpected error creating address: %v", err)
+	}
+	redeemScript, err := stdscript.MultiSigScriptV0(2, pubKey(1), pubKey(2),
+		pubKey(3))
+	if err != nil {
+		t.Fatalf("unexpected error creating multisig script: %v", err)
+	}
+	p2shAddr, err := stdaddr.NewAddressScriptHashV0(redeemScript, params)
+	if err != nil {
+		t.Fatalf("unexpected error creating address: %v", err)
+	}
+	stakeAddr, err := stdaddr.NewAddressPubKeyHashEcdsaSecp256k1V0(
+		stdaddr.Hash160(pubKey(4)), params)
+	if err != nil {
+		t.Fatalf("unexpected error creating address: %v", err)
+	}
+	_, p2pkhScript := p2pkhAddr.PaymentScript()
+	_, p2shScript := p2shAddr.PaymentScript()
+	_, stakeGenScript := stakeAddr.PayVoteCommitmentScript()
+
+	tx := wire.NewMsgTx()
+	prevOuts := []*wire.TxOut{
+		wire.NewTxOut(1e8, p2pkhScript),
+		wire.NewTxOut(2e8, p2shScript),
+		wire.NewTxOut(3e8, stakeGenScript),
+	}
+	for i := range prevOuts {
+		prevHash := chainhash.HashH([]byte{byte(i)})
+		prevOut := wire.NewOutPoint(&prevHash, uint32(i), wire.TxTreeRegular)
+		tx.AddTxIn(wire.NewTxIn(prevOut, wire.NullValueIn, nil))
+	}
+	tx.AddTxOut(wire.NewTxOut(5e8, p2pkhScript))
+
+	p, err := New(tx, TxTypeRegular)
+	if err != nil {
+		t.Fatalf("unexpected error creating packet: %v", err)
+	}
+	for idx, prevOut := range prevOuts {
+		if err := p.AddInPrevOut(idx, prevOut); err != nil {
+			t.Fatalf("unexpected error adding previous output: %v", err)
+		}
+	}
+	if err := p.AddInRedeemScript(1, redeemScript); err != nil {
+		t.Fatalf("unexpected error adding redeem script: %v", err)
+	}
+	return p, keys
+}
+
+// TestRoundTrip ensures packets survive serialization unchanged.
+func TestRoundTrip(t *testing.T) {
+	p, keys := testPacket(t)
+	pubKey := keys[0].PubKey().SerializeCompressed()
+	d := &Bip32Derivation{
+		PubKey:               pubKey,
+		MasterKeyFingerprint: 0x01020304,
+		Path:                 []uint32{44 + 1<<31, 42 + 1<<31, 1 << 31, 0, 7},
+	}
+	if err := p.AddInBip32Derivation(0, d); err != nil {
+		t.Fatalf("unexpected error adding derivation: %v", err)
+	}
+	if err := p.AddOutBip32Derivation(0, d); err != nil {
+		t.Fatalf("unexpected error adding derivation: %v", err)
+	}
+	if err := p.AddInSigHashType(0, txscript.SigHashAll); err != nil {
+		t.Fatalf("unexpected error adding hash type: %v", err)
+	}
+	if err := p.Sign(0, keys[0]); err != nil {
+		t.Fatalf("unexpected error signing: %v", err)
+	}
+	p.Unknowns = []*Unknown{{Key: []byte{0x70, 1}, Value: []byte{2}}}
+	p.Inputs[2].Unknowns = []*Unknown{{Key: []byte{0x70}, Value: nil}}
+
+	encoded, err := p.Base64()
+	if err != nil {
+		t.Fatalf("unexpected error encoding packet: %v", err)
+	}
+	decoded, err := ParseBase64(encoded)
+	if err != nil {
+		t.Fatalf("unexpected error decoding packet: %v", err)
+	}
+
+	// Compare the unsigned transactions separately since their decoded
+	// signature scripts are not nil.
+	if decoded.UnsignedTx.TxHashFull() != p.UnsignedTx.TxHashFull() {
+		t.Fatalf("mismatched unsigned transaction after round trip")
+	}
+	decoded.UnsignedTx = p.UnsignedTx
+
+	// Unknown values are always decoded as non-nil.
+	p.Inputs[2].Unknowns[0].Value = []byte{}
+	if !reflect.DeepEqual(decoded, p) {
+		t.Fatalf("mismatched packet after round trip:\ngot %+v\nwant %+v",
+			decoded, p)
+	}
+}
+
+// TestSignCombineFinalize ensures packets signed by independent signers are
+// combined, finalized and extracted into a valid transaction.
+func TestSignCombineFinalize(t *testing.T) {
+	p, keys := testPacket(t)
+
+	// Ensure keys that are not able to sign for an input are rejected.
+	err := p.Sign(0, keys[1])
+	if !errors.Is(err, ErrKeyNotRelevant) {
+		t.Fatalf("unexpected error signing with irrelevant key -- got %v, "+
+			"want %v", err, ErrKeyNotRelevant)
+	}
+
+	// Sign the inputs with independent copies of the packet.
+	signer1, signer2 := p.Copy(), p.Copy()
+	if err := signer1.Sign(0, keys[0]); err != nil {
+		t.Fatalf("unexpected error signing: %v", err)
+	}
+	if err := signer1.Sign(1, keys[1]); err != nil {
+		t.Fatalf("unexpected error signing: %v", err)
+	}
+	if err := signer2.Sign(1, keys[3]); err != nil {
+		t.Fatalf("unexpected error signing: %v", err)
+	}
+	if err := signer2.Sign(2, keys[4]); err != nil {
+		t.Fatalf("unexpected error signing: %v", err)
+	}
+
+	// Ensure a signature that does not commit to the input is rejected.
+	sig := signer1.Inputs[0].PartialSigs[0].Signature
+	err = p.AddPartialSig(2, keys[4].PubKey().SerializeCompressed(), sig)
+	if !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("unexpected error adding invalid signature -- got %v, "+
+			"want %v", err, ErrInvalidSignature)
+	}
+
+	// Ensure the multisig input can't be finalized with a single signature.
+	err = signer1.Copy().Finalize(1)
+	if !errors.Is(err, ErrNotEnoughSignatures) {
+		t.Fatalf("unexpected error finalizing -- got %v, want %v", err,
+			ErrNotEnoughSignatures)
+	}
+
+	// Ensure the transaction can't be extracted before it is finalized.
+	combined, err := Combine(signer1, signer2)
+	if err != nil {
+		t.Fatalf("unexpected error combining packets: %v", err)
+	}
+	if _, err := combined.Extract(); !errors.Is(err, ErrNotFinalized) {
+		t.Fatalf("unexpected error extracting -- got %v, want %v", err,
+			ErrNotFinalized)
+	}
+
+	if err := combined.FinalizeAll(); err != nil {
+		t.Fatalf("unexpected error finalizing: %v", err)
+	}
+	if !combined.IsComplete() {
+		t.Fatal("packet is not complete after finalizing")
+	}
+	tx, err := combined.Extract()
+	if err != nil {
+		t.Fatalf("unexpected error extracting: %v", err)
+	}
+	if fee, ok := combined.Fee(); !ok || fee != 1e8 {
+		t.Fatalf("unexpected fee -- got %d (%v), want %d", fee, ok, int64(1e8))
+	}
+
+	// Ensure the signature scripts of the extracted transaction are valid.
+	for idx, in := range combined.Inputs {
+		vm, err := txscript.NewEngine(in.PrevOut.PkScript, tx, idx, 0,
+			in.PrevOut.Version, nil)
+		if err != nil {
+			t.Fatalf("failed to create engine for input %d: %v", idx, err)
+		}
+		if err := vm.Execute(); err != nil {
+			t.Fatalf("invalid signature script for input %d: %v", idx, err)
+		}
+		if tx.TxIn[idx].ValueIn != in.PrevOut.Value {
+			t.Fatalf("unexpected input amount for input %d -- got %d, "+
+				"want %d", idx, tx.TxIn[idx].ValueIn, in.PrevOut.Value)
+		}
+	}
+
+	// Ensure packets for different transactions are not combined.
+	other := p.Copy()
+	other.UnsignedTx.TxOut[0].Value--
+	if _, err := Combine(p, other); !errors.Is(err, ErrMismatchedPackets) {
+		t.Fatalf("unexpected error combining packets -- got %v, want %v",
+			err, ErrMismatchedPackets)
+	}
+}
+
+// TestStakebaseInput ensures the signature script of the stakebase input of a
+// vote is retained as its final signature script.
+func TestStakebaseInput(t *testing.T) {
+	stakebaseScript := []byte{0x00, 0x00}
+	tx := wire.NewMsgTx()
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{},
+		wire.MaxPrevOutIndex, wire.TxTreeRegular), 100, stakebaseScript))
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0,
+		wire.TxTreeStake), 200, nil))
+	tx.AddTxOut(wire.NewTxOut(300, nil))
+
+	// Ensure the stakebase signature script is only accepted for votes.
+	if _, err := New(tx, TxTypeRegular); !errors.Is(err, ErrInvalidUnsignedTx) {
+		t.Fatalf("unexpected error creating packet -- got %v, want %v", err,
+			ErrInvalidUnsignedTx)
+	}
+	p, err := New(tx, TxTypeSSGen)
+	if err != nil {
+		t.Fatalf("unexpected error creating packet: %v", err)
+	}
+	if len(p.UnsignedTx.TxIn[0].SignatureScript) != 0 {
+		t.Fatal("unsigned transaction has a signature script")
+	}
+	if !bytes.Equal(p.Inputs[0].FinalScriptSig, stakebaseScript) {
+		t.Fatalf("unexpected final signature script -- got %x, want %x",
+			p.Inputs[0].FinalScriptSig, stakebaseScript)
+	}
+	if p.IsComplete() {
+		t.Fatal("packet is complete without a signature for the ticket")
+	}
+}
+
+// TestParseErrors ensures malformed packets are rejected with the expected
+// errors.
+func TestParseErrors(t *testing.T) {
+	p, _ := testPacket(t)
+	valid, err := p.Bytes()
+	if err != nil {
+		t.Fatalf("unexpected error serializing packet: %v", err)
+	}
+	txBytes, err := p.UnsignedTx.Bytes()
+	if err != nil {
+		t.Fatalf("unexpected error serializing transaction: %v", err)
+	}
+
+	// serializePairs returns a serialized packet with a global map that
+	// consists of the provided pairs.
+	serializePairs := func(pairs ...[]byte) []byte {
+		var buf bytes.Buffer
+		buf.Write(magic[:])
+		for _, pair := range pairs {
+			wire.WriteVarBytes(&buf, 0, pair)
+		}
+		buf.WriteByte(0)
+		return buf.Bytes()
+	}
+
+	// Create a transaction that has a signature script.
+	signedTx := p.UnsignedTx.Copy()
+	signedTx.TxIn[0].SignatureScript = []byte{0x51}
+	signedTxBytes, err := signedTx.Bytes()
+	if err != nil {
+		t.Fatalf("unexpected error serializing transaction: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{{
+		name: "bad magic",
+		data: append([]byte("psbt"), valid[4:]...),
+		err:  ErrInvalidMagic,
+	}, {
+		name: "truncated",
+		data: valid[:len(valid)-1],
+		err:  ErrMalformedPacket,
+	}, {
+		name: "duplicate key",
+		data: serializePairs([]byte{globalUnsignedTxType}, txBytes,
+			[]byte{globalUnsignedTxType}, txBytes),
+		err: ErrDuplicateKey,
+	}, {
+		name: "unsupported version",
+		data: serializePairs([]byte{globalUnsignedTxType}, txBytes,
+			[]byte{globalVersionType}, []byte{1, 0, 0, 0}),
+		err: ErrUnsupportedVersion,
+	}, {
+		name: "no unsigned transaction",
+		data: serializePairs(),
+		err:  ErrInvalidUnsignedTx,
+	}, {
+		name: "signature script",
+		data: serializePairs([]byte{globalUnsignedTxType}, signedTxBytes),
+		err:  ErrInvalidUnsignedTx,
+	}}
+	for _, test := range tests {
+		_, err := Parse(bytes.NewReader(test.data))
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: unexpected error -- got %v, want %v", test.name,
+				err, test.err)
+		}
+	}
+}
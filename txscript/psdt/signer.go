@@ -0,0 +1,204 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package psdt
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/decred/dcrd/dcrec"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"github.com/decred/dcrd/txscript/v4"
+	"github.com/decred/dcrd/txscript/v4/sign"
+	"github.com/decred/dcrd/txscript/v4/stdaddr"
+	"github.com/decred/dcrd/txscript/v4/stdscript"
+	"github.com/decred/dcrd/wire"
+)
+
+// This file implements the signer role which adds partial signatures for the
+// inputs of a packet.
+
+// extractScriptHash returns the script hash of the provided output when it is
+// any of the supported version 0 pay-to-script-hash scripts, including the
+// stake-tagged variants.  It returns nil otherwise.
+func extractScriptHash(prevOut *wire.TxOut) []byte {
+	if prevOut.Version != 0 {
+		return nil
+	}
+	if h := stdscript.ExtractScriptHashV0(prevOut.PkScript); h != nil {
+		return h
+	}
+	return stdscript.ExtractStakeScriptHashV0(prevOut.PkScript)
+}
+
+// extractPubKeyHash returns the public key hash of the provided script when it
+// is any of the supported version 0 ECDSA pay-to-pubkey-hash scripts,
+// including the stake-tagged variants.  It returns nil otherwise.
+func extractPubKeyHash(script []byte) []byte {
+	if h := stdscript.ExtractPubKeyHashV0(script); h != nil {
+		return h
+	}
+	return stdscript.ExtractStakePubKeyHashV0(script)
+}
+
+// hashType returns the signature hash type that signatures for the input must
+// use.
+func (in *PInput) hashType() txscript.SigHashType {
+	if in.SigHashType == 0 {
+		return txscript.SigHashAll
+	}
+	return in.SigHashType
+}
+
+// signingScript returns the script that signatures for the input at the
+// provided index commit to.  This is the redeem script for inputs that spend
+// pay-to-script-hash outputs and the script of the previous output otherwise.
+func (p *Packet) signingScript(idx int) ([]byte, error) {
+	in := &p.Inputs[idx]
+	if in.PrevOut == nil {
+		str := fmt.Sprintf("previous output of input %d is unknown", idx)
+		return nil, makeError(ErrMissingPrevOut, str)
+	}
+	if in.PrevOut.Version != 0 {
+		str := fmt.Sprintf("previous output of input %d has unsupported "+
+			"script version %d", idx, in.PrevOut.Version)
+		return nil, makeError(ErrUnsupportedScript, str)
+	}
+	scriptHash := extractScriptHash(in.PrevOut)
+	if scriptHash == nil {
+		return in.PrevOut.PkScript, nil
+	}
+	if in.RedeemScript == nil {
+		str := fmt.Sprintf("redeem script of input %d is unknown", idx)
+		return nil, makeError(ErrMissingRedeemScript, str)
+	}
+	if !bytes.Equal(stdaddr.Hash160(in.RedeemScript), scriptHash) {
+		str := fmt.Sprintf("redeem script for input %d does not match the "+
+			"script hash of the previous output", idx)
+		return nil, makeError(ErrInvalidRedeemScript, str)
+	}
+	return in.RedeemScript, nil
+}
+
+// checkRelevantPubKey returns an error when the provided public key is not able
+// to sign for the given script.
+func checkRelevantPubKey(script, pubKey []byte) error {
+	relevant := false
+	switch {
+	case extractPubKeyHash(script) != nil:
+		relevant = bytes.Equal(stdaddr.Hash160(pubKey),
+			extractPubKeyHash(script))
+
+	case stdscript.ExtractCompressedPubKeyV0(script) != nil:
+		relevant = bytes.Equal(pubKey,
+			stdscript.ExtractCompressedPubKeyV0(script))
+
+	case stdscript.IsMultiSigScriptV0(script):
+		details := stdscript.ExtractMultiSigScriptDetailsV0(script, true)
+		for _, multiSigPubKey := range details.PubKeys {
+			if bytes.Equal(pubKey, multiSigPubKey) {
+				relevant = true
+				break
+			}
+		}
+
+	default:
+		str := fmt.Sprintf("unsupported signing script %x", script)
+		return makeError(ErrUnsupportedScript, str)
+	}
+	if !relevant {
+		str := fmt.Sprintf("public key %x is not able to sign for script %x",
+			pubKey, script)
+		return makeError(ErrKeyNotRelevant, str)
+	}
+	return nil
+}
+
+// AddPartialSig adds the provided signature by the given compressed public key
+// for the input at the provided index after ensuring it is valid.  Any
+// existing signature by the same public key is replaced.  This allows the
+// signatures produced by external signers, such as hardware wallets, to be
+// added to a packet.
+//
+// The signature must be a DER-encoded ECDSA signature with the signature hash
+// type of the input appended.
+func (p *Packet) AddPartialSig(idx int, pubKey, sig []byte) error {
+	in, err := p.updatableInput(idx)
+	if err != nil {
+		return err
+	}
+	if err := checkPubKey(pubKey); err != nil {
+		return err
+	}
+	script, err := p.signingScript(idx)
+	if err != nil {
+		return err
+	}
+	if err := checkRelevantPubKey(script, pubKey); err != nil {
+		return err
+	}
+
+	// Ensure the signature uses the required hash type and commits to the
+	// input.
+	hashType := in.hashType()
+	if len(sig) == 0 || txscript.SigHashType(sig[len(sig)-1]) != hashType {
+		str := fmt.Sprintf("signature for input %d does not use hash type "+
+			"%v", idx, hashType)
+		return makeError(ErrInvalidSignature, str)
+	}
+	parsedSig, err := ecdsa.ParseDERSignature(sig[:len(sig)-1])
+	if err != nil {
+		str := fmt.Sprintf("malformed signature for input %d: %v", idx, err)
+		return makeError(ErrInvalidSignature, str)
+	}
+	parsedPubKey, err := secp256k1.ParsePubKey(pubKey)
+	if err != nil {
+		str := fmt.Sprintf("invalid public key %x: %v", pubKey, err)
+		return makeError(ErrInvalidPubKey, str)
+	}
+	hash, err := txscript.CalcSignatureHash(script, hashType, p.UnsignedTx,
+		idx, nil)
+	if err != nil {
+		return err
+	}
+	if !parsedSig.Verify(hash, parsedPubKey) {
+		str := fmt.Sprintf("signature for input %d is not valid", idx)
+		return makeError(ErrInvalidSignature, str)
+	}
+
+	partialSig := &PartialSig{PubKey: copyBytes(pubKey), Signature: copyBytes(sig)}
+	for i, existing := range in.PartialSigs {
+		if bytes.Equal(existing.PubKey, pubKey) {
+			in.PartialSigs[i] = partialSig
+			return nil
+		}
+	}
+	in.PartialSigs = append(in.PartialSigs, partialSig)
+	return nil
+}
+
+// Sign adds a partial signature for the input at the provided index using the
+// given private key.
+func (p *Packet) Sign(idx int, privKey *secp256k1.PrivateKey) error {
+	in, err := p.updatableInput(idx)
+	if err != nil {
+		return err
+	}
+	script, err := p.signingScript(idx)
+	if err != nil {
+		return err
+	}
+	pubKey := privKey.PubKey().SerializeCompressed()
+	if err := checkRelevantPubKey(script, pubKey); err != nil {
+		return err
+	}
+	sig, err := sign.RawTxInSignature(p.UnsignedTx, idx, script,
+		in.hashType(), privKey.Serialize(), dcrec.STEcdsaSecp256k1)
+	if err != nil {
+		return err
+	}
+	return p.AddPartialSig(idx, pubKey, sig)
+}
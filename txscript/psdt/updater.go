@@ -0,0 +1,136 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package psdt
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/decred/dcrd/txscript/v4"
+	"github.com/decred/dcrd/txscript/v4/stdaddr"
+	"github.com/decred/dcrd/wire"
+)
+
+// This file implements the updater role which adds the information needed to
+// sign inputs and verify outputs to a packet.
+
+// updatableInput returns the input at the provided index after ensuring it may
+// still be updated.
+func (p *Packet) updatableInput(idx int) (*PInput, error) {
+	if err := p.checkInputIndex(idx); err != nil {
+		return nil, err
+	}
+	in := &p.Inputs[idx]
+	if in.FinalScriptSig != nil {
+		str := fmt.Sprintf("input %d is already finalized", idx)
+		return nil, makeError(ErrInputFinalized, str)
+	}
+	return in, nil
+}
+
+// AddInPrevOut sets the previous output spent by the input at the provided
+// index.
+func (p *Packet) AddInPrevOut(idx int, prevOut *wire.TxOut) error {
+	in, err := p.updatableInput(idx)
+	if err != nil {
+		return err
+	}
+	c := wire.NewTxOut(prevOut.Value, copyBytes(prevOut.PkScript))
+	c.Version = prevOut.Version
+	in.PrevOut = c
+	return nil
+}
+
+// AddInSigHashType sets the signature hash type signers must use for the input
+// at the provided index.
+func (p *Packet) AddInSigHashType(idx int, hashType txscript.SigHashType) error {
+	in, err := p.updatableInput(idx)
+	if err != nil {
+		return err
+	}
+	in.SigHashType = hashType
+	return nil
+}
+
+// AddInRedeemScript sets the redeem script of the pay-to-script-hash output
+// spent by the input at the provided index.  The redeem script must match the
+// script hash of the previous output when it is known.
+func (p *Packet) AddInRedeemScript(idx int, redeemScript []byte) error {
+	in, err := p.updatableInput(idx)
+	if err != nil {
+		return err
+	}
+	if in.PrevOut != nil {
+		scriptHash := extractScriptHash(in.PrevOut)
+		if scriptHash == nil {
+			str := fmt.Sprintf("previous output of input %d does not pay to "+
+				"a script hash", idx)
+			return makeError(ErrInvalidRedeemScript, str)
+		}
+		if !bytes.Equal(stdaddr.Hash160(redeemScript), scriptHash) {
+			str := fmt.Sprintf("redeem script for input %d does not match "+
+				"the script hash of the previous output", idx)
+			return makeError(ErrInvalidRedeemScript, str)
+		}
+	}
+	in.RedeemScript = copyBytes(redeemScript)
+	return nil
+}
+
+// addDerivation adds the provided derivation to the given derivations while
+// replacing any existing derivation for the same public key.
+func addDerivation(derivations []*Bip32Derivation, d *Bip32Derivation) ([]*Bip32Derivation, error) {
+	if err := checkPubKey(d.PubKey); err != nil {
+		return nil, err
+	}
+	d = copyDerivations([]*Bip32Derivation{d})[0]
+	for i, existing := range derivations {
+		if bytes.Equal(existing.PubKey, d.PubKey) {
+			derivations[i] = d
+			return derivations, nil
+		}
+	}
+	return append(derivations, d), nil
+}
+
+// AddInBip32Derivation adds the derivation of a public key involved in signing
+// the input at the provided index.
+func (p *Packet) AddInBip32Derivation(idx int, d *Bip32Derivation) error {
+	in, err := p.updatableInput(idx)
+	if err != nil {
+		return err
+	}
+	derivations, err := addDerivation(in.Bip32Derivations, d)
+	if err != nil {
+		return err
+	}
+	in.Bip32Derivations = derivations
+	return nil
+}
+
+// AddOutRedeemScript sets the redeem script of the output at the provided
+// index.
+func (p *Packet) AddOutRedeemScript(idx int, redeemScript []byte) error {
+	if err := p.checkOutputIndex(idx); err != nil {
+		return err
+	}
+	p.Outputs[idx].RedeemScript = copyBytes(redeemScript)
+	return nil
+}
+
+// AddOutBip32Derivation adds the derivation of a public key involved in the
+// output at the provided index.
+func (p *Packet) AddOutBip32Derivation(idx int, d *Bip32Derivation) error {
+	if err := p.checkOutputIndex(idx); err != nil {
+		return err
+	}
+	out := &p.Outputs[idx]
+	derivations, err := addDerivation(out.Bip32Derivations, d)
+	if err != nil {
+		return err
+	}
+	out.Bip32Derivations = derivations
+	return nil
+}
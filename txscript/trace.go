@@ -0,0 +1,138 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"strings"
+)
+
+// TraceStep houses information about the execution of a single opcode by the
+// script engine.  It is provided to the tracer that is set on an engine via
+// SetTracer.
+//
+// The stacks are provided as arrays where the last item in the array is the
+// top of the stack in the same manner as GetStack and GetAltStack.  The
+// condition stacks contain an entry for each conditional branch the opcode is
+// nested within starting with the outermost one and each entry indicates
+// whether or not that branch is executing.
+type TraceStep struct {
+	// ScriptIdx and OpcodeIdx identify the program counter of the opcode.
+	// ScriptIdx is the index of the script the opcode belongs to, where 0 is
+	// the signature script, 1 is the public key script, and 2 is the redeem
+	// script in the case of pay-to-script-hash.  OpcodeIdx is the number of
+	// the opcode within that script.
+	ScriptIdx int
+	OpcodeIdx int
+
+	// Opcode is the value of the executed opcode and Data is the data it
+	// pushes, if any.
+	Opcode byte
+	Data   []byte
+
+	// Disasm is the disassembly of the opcode.
+	Disasm string
+
+	// Stack, AltStack, and CondStack are the state of the engine prior to
+	// executing the opcode.
+	Stack     [][]byte
+	AltStack  [][]byte
+	CondStack []bool
+
+	// StackAfter, AltStackAfter, and CondStackAfter are the state of the
+	// engine after executing the opcode.  They are not set when executing the
+	// opcode resulted in an error.
+	StackAfter     [][]byte
+	AltStackAfter  [][]byte
+	CondStackAfter []bool
+
+	// Err is the error that resulted from executing the opcode, if any.
+	Err error
+}
+
+// Tracer defines a callback that is invoked by the script engine with the
+// details of each executed opcode.  This is primarily useful for debugging
+// scripts.
+type Tracer func(step *TraceStep)
+
+// SetTracer sets a tracer that is invoked with the details of each opcode the
+// engine executes.  Passing nil disables tracing.  It may be called from within
+// the tracer itself, for example, to stop tracing once a caller-defined limit
+// is reached while still executing the remaining opcodes.
+//
+// Tracing is configured via this method on a constructed engine as opposed to
+// a parameter to NewEngine or a ScriptFlags value since the signature of
+// NewEngine is part of the stable API and script flags are reserved for
+// consensus and policy rules.  This is the same approach taken by
+// SetSchnorrBatch.
+//
+// Note that tracing is significantly slower than normal execution since the
+// full state of the engine is copied for every opcode.  Callers are
+// responsible for bounding the amount of data they retain since each step
+// includes copies of the full stacks.
+func (vm *Engine) SetTracer(tracer Tracer) {
+	vm.tracer = tracer
+}
+
+// traceStack returns a deep copy of the contents of the passed stack as an
+// array where the last item in the array is the top of the stack.
+func traceStack(stack *stack) [][]byte {
+	array := getStack(stack)
+	for i, item := range array {
+		array[i] = append([]byte(nil), item...)
+	}
+	return array
+}
+
+// condStack returns the current conditional execution state of the engine as
+// a stack where each entry indicates whether or not the branch at the
+// associated nesting depth is executing.
+func (vm *Engine) condStack() []bool {
+	conds := make([]bool, vm.condNestDepth)
+	for i := range conds {
+		conds[i] = vm.condDisableDepth == noCondDisableDepth ||
+			int32(i) < vm.condDisableDepth
+	}
+	return conds
+}
+
+// beginTraceStep returns a trace step populated with the current state of the
+// engine for the passed opcode which is about to be executed.  It returns nil
+// when tracing is disabled.
+func (vm *Engine) beginTraceStep(op *opcode, data []byte) *TraceStep {
+	if vm.tracer == nil {
+		return nil
+	}
+
+	var buf strings.Builder
+	disasmOpcode(&buf, op, data, false)
+	return &TraceStep{
+		ScriptIdx: vm.scriptIdx,
+		OpcodeIdx: vm.opcodeIdx,
+		Opcode:    op.value,
+		Data:      append([]byte(nil), data...),
+		Disasm:    buf.String(),
+		Stack:     traceStack(&vm.dstack),
+		AltStack:  traceStack(&vm.astack),
+		CondStack: vm.condStack(),
+	}
+}
+
+// endTraceStep completes the passed trace step with the current state of the
+// engine and the passed execution error and invokes the tracer with it.  It is
+// a noop when the passed step is nil.
+func (vm *Engine) endTraceStep(step *TraceStep, err error) {
+	if step == nil {
+		return
+	}
+
+	if err != nil {
+		step.Err = err
+	} else {
+		step.StackAfter = traceStack(&vm.dstack)
+		step.AltStackAfter = traceStack(&vm.astack)
+		step.CondStackAfter = vm.condStack()
+	}
+	vm.tracer(step)
+}
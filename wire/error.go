@@ -1,5 +1,5 @@
 // Copyright (c) 2013-2015 The btcsuite developers
-// Copyright (c) 2015-2023 The Decred developers
+// Copyright (c) 2015-2024 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
@@ -133,6 +133,14 @@ const (
 	// ErrTooManyTSpends is returned when the number of tspend hashes
 	// exceeds the maximum allowed.
 	ErrTooManyTSpends
+
+	// ErrInvalidNetAddrSize is returned when the size of a network address
+	// does not match the size required by its network.
+	ErrInvalidNetAddrSize
+
+	// ErrTooManyFilterHashFuncs is returned when the number of hash functions
+	// of a bloom filter exceeds the maximum allowed.
+	ErrTooManyFilterHashFuncs
 )
 
 // Map of ErrorCode values back to their constant names for pretty printing.
@@ -168,6 +176,8 @@ var errorCodeStrings = map[ErrorCode]string{
 	ErrTooManyInitStateTypes:         "ErrTooManyInitStateTypes",
 	ErrInitStateTypeTooLong:          "ErrInitStateTypeTooLong",
 	ErrTooManyTSpends:                "ErrTooManyTSpends",
+	ErrInvalidNetAddrSize:            "ErrInvalidNetAddrSize",
+	ErrTooManyFilterHashFuncs:        "ErrTooManyFilterHashFuncs",
 }
 
 // String returns the ErrorCode as a human-readable name.
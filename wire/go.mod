@@ -4,6 +4,7 @@ go 1.17
 
 require (
 	github.com/davecgh/go-spew v1.1.1
+	github.com/dchest/siphash v1.2.3
 	github.com/decred/dcrd/chaincfg/chainhash v1.0.4
 	lukechampine.com/blake3 v1.2.1
 )
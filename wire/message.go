@@ -1,5 +1,5 @@
 // Copyright (c) 2013-2016 The btcsuite developers
-// Copyright (c) 2015-2023 The Decred developers
+// Copyright (c) 2015-2024 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
@@ -18,6 +18,13 @@ import (
 // checksum 4 bytes.
 const MessageHeaderSize = 24
 
+// AuthenticatedMessageHeaderSize is the number of bytes in the header of a
+// Decred message that is sent over a transport which authenticates messages
+// such as the encrypted transport.  It is the same as the common message header
+// except the checksum is omitted since it is redundant.  Decred network (magic)
+// 4 bytes + command 12 bytes + payload length 4 bytes.
+const AuthenticatedMessageHeaderSize = MessageHeaderSize - 4
+
 // CommandSize is the fixed size of all commands in the common Decred message
 // header.  Shorter commands must be zero padded.
 const CommandSize = 12
@@ -52,6 +59,17 @@ const (
 	CmdCFilterV2      = "cfilterv2"
 	CmdGetInitState   = "getinitstate"
 	CmdInitState      = "initstate"
+	CmdSendCmpct      = "sendcmpct"
+	CmdCmpctBlock     = "cmpctblock"
+	CmdGetBlockTxn    = "getblocktxn"
+	CmdBlockTxn       = "blocktxn"
+	CmdAddrV2         = "addrv2"
+	CmdFilterLoad     = "filterload"
+	CmdFilterAdd      = "filteradd"
+	CmdFilterClear    = "filterclear"
+	CmdMerkleBlock    = "merkleblock"
+	CmdGetCFHeadersV2 = "getcfhdrsv2"
+	CmdCFHeadersV2    = "cfheadersv2"
 )
 
 const (
@@ -188,6 +206,39 @@ func makeEmptyMessage(command string) (Message, error) {
 	case CmdInitState:
 		msg = &MsgInitState{}
 
+	case CmdSendCmpct:
+		msg = &MsgSendCmpct{}
+
+	case CmdCmpctBlock:
+		msg = &MsgCmpctBlock{}
+
+	case CmdGetBlockTxn:
+		msg = &MsgGetBlockTxn{}
+
+	case CmdBlockTxn:
+		msg = &MsgBlockTxn{}
+
+	case CmdAddrV2:
+		msg = &MsgAddrV2{}
+
+	case CmdFilterLoad:
+		msg = &MsgFilterLoad{}
+
+	case CmdFilterAdd:
+		msg = &MsgFilterAdd{}
+
+	case CmdFilterClear:
+		msg = &MsgFilterClear{}
+
+	case CmdMerkleBlock:
+		msg = &MsgMerkleBlock{}
+
+	case CmdGetCFHeadersV2:
+		msg = &MsgGetCFHeadersV2{}
+
+	case CmdCFHeadersV2:
+		msg = &MsgCFHeadersV2{}
+
 	default:
 		str := fmt.Sprintf("unhandled command [%s]", command)
 		return nil, messageError(op, ErrUnknownCmd, str)
@@ -203,23 +254,31 @@ type messageHeader struct {
 	checksum [4]byte     // 4 bytes
 }
 
-// readMessageHeader reads a Decred message header from r.
-func readMessageHeader(r io.Reader) (int, *messageHeader, error) {
+// readMessageHeader reads a Decred message header from r.  The checksum is only
+// read when the checksum flag is set.
+func readMessageHeader(r io.Reader, checksum bool) (int, *messageHeader, error) {
 	// Since readElements doesn't return the amount of bytes read, attempt
 	// to read the entire header into a buffer first in case there is a
 	// short read so the proper amount of read bytes are known.  This works
 	// since the header is a fixed size.
 	var headerBytes [MessageHeaderSize]byte
-	n, err := io.ReadFull(r, headerBytes[:])
+	headerSize := MessageHeaderSize
+	if !checksum {
+		headerSize = AuthenticatedMessageHeaderSize
+	}
+	n, err := io.ReadFull(r, headerBytes[:headerSize])
 	if err != nil {
 		return n, nil, err
 	}
-	hr := bytes.NewReader(headerBytes[:])
+	hr := bytes.NewReader(headerBytes[:headerSize])
 
 	// Create and populate a messageHeader struct from the raw header bytes.
 	hdr := messageHeader{}
 	var command [CommandSize]byte
-	readElements(hr, &hdr.magic, &command, &hdr.length, &hdr.checksum)
+	readElements(hr, &hdr.magic, &command, &hdr.length)
+	if checksum {
+		readElement(hr, &hdr.checksum)
+	}
 
 	// Strip trailing zeros from command string.
 	hdr.command = string(bytes.TrimRight(command[:], string(rune(0))))
@@ -247,11 +306,10 @@ func discardInput(r io.Reader, n uint32) {
 	}
 }
 
-// WriteMessageN writes a Decred Message to w including the necessary header
-// information and returns the number of bytes written.    This function is the
-// same as WriteMessage except it also returns the number of bytes written.
-func WriteMessageN(w io.Writer, msg Message, pver uint32, dcrnet CurrencyNet) (int, error) {
-	const op = "WriteMessage"
+// writeMessageN writes a Decred Message to w including the necessary header
+// information and returns the number of bytes written.  The header only
+// includes the checksum of the payload when the checksum flag is set.
+func writeMessageN(op string, w io.Writer, msg Message, pver uint32, dcrnet CurrencyNet, checksum bool) (int, error) {
 	totalBytes := 0
 
 	// Enforce max command size.
@@ -292,10 +350,13 @@ func WriteMessageN(w io.Writer, msg Message, pver uint32, dcrnet CurrencyNet) (i
 	// Encode the header for the message.  This is done to a buffer
 	// rather than directly to the writer since writeElements doesn't
 	// return the number of bytes written.
-	var checksum [4]byte
-	copy(checksum[:], chainhash.HashB(payload)[0:4])
 	hw := bytes.NewBuffer(make([]byte, 0, MessageHeaderSize))
-	writeElements(hw, dcrnet, command, uint32(lenp), checksum)
+	writeElements(hw, dcrnet, command, uint32(lenp))
+	if checksum {
+		var cksum [4]byte
+		copy(cksum[:], chainhash.HashB(payload)[0:4])
+		writeElement(hw, cksum)
+	}
 
 	// Write header.
 	n, err := w.Write(hw.Bytes())
@@ -310,6 +371,27 @@ func WriteMessageN(w io.Writer, msg Message, pver uint32, dcrnet CurrencyNet) (i
 	return totalBytes, err
 }
 
+// WriteMessageN writes a Decred Message to w including the necessary header
+// information and returns the number of bytes written.    This function is the
+// same as WriteMessage except it also returns the number of bytes written.
+func WriteMessageN(w io.Writer, msg Message, pver uint32, dcrnet CurrencyNet) (int, error) {
+	const op = "WriteMessage"
+	return writeMessageN(op, w, msg, pver, dcrnet, true)
+}
+
+// WriteAuthenticatedMessageN writes a Decred Message to w including the
+// necessary header information without the payload checksum and returns the
+// number of bytes written.
+//
+// This function must only be used with transports that authenticate the
+// written bytes, such as those that make use of an AEAD, since the message is
+// otherwise not protected against corruption.  The message must be read with
+// ReadAuthenticatedMessageN.
+func WriteAuthenticatedMessageN(w io.Writer, msg Message, pver uint32, dcrnet CurrencyNet) (int, error) {
+	const op = "WriteAuthenticatedMessage"
+	return writeMessageN(op, w, msg, pver, dcrnet, false)
+}
+
 // WriteMessage writes a Decred Message to w including the necessary header
 // information.  This function is the same as WriteMessageN except it doesn't
 // return the number of bytes written.  This function is mainly provided for
@@ -320,15 +402,14 @@ func WriteMessage(w io.Writer, msg Message, pver uint32, dcrnet CurrencyNet) err
 	return err
 }
 
-// ReadMessageN reads, validates, and parses the next Decred Message from r for
+// readMessageN reads, validates, and parses the next Decred Message from r for
 // the provided protocol version and Decred network.  It returns the number of
 // bytes read in addition to the parsed Message and raw bytes which comprise the
-// message.  This function is the same as ReadMessage except it also returns the
-// number of bytes read.
-func ReadMessageN(r io.Reader, pver uint32, dcrnet CurrencyNet) (int, Message, []byte, error) {
-	const op = "ReadMessage"
+// message.  The header is only expected to include the checksum of the payload,
+// which is then validated, when the checksum flag is set.
+func readMessageN(op string, r io.Reader, pver uint32, dcrnet CurrencyNet, checksum bool) (int, Message, []byte, error) {
 	totalBytes := 0
-	n, hdr, err := readMessageHeader(r)
+	n, hdr, err := readMessageHeader(r, checksum)
 	totalBytes += n
 	if err != nil {
 		return totalBytes, nil, nil, err
@@ -385,11 +466,14 @@ func ReadMessageN(r io.Reader, pver uint32, dcrnet CurrencyNet) (int, Message, [
 	}
 
 	// Test checksum.
-	checksum := chainhash.HashB(payload)[0:4]
-	if !bytes.Equal(checksum, hdr.checksum[:]) {
-		msg := fmt.Sprintf("payload checksum failed - header indicates %v, "+
-			"but actual checksum is %v.", hdr.checksum, checksum)
-		return totalBytes, nil, nil, messageError(op, ErrPayloadChecksum, msg)
+	if checksum {
+		cksum := chainhash.HashB(payload)[0:4]
+		if !bytes.Equal(cksum, hdr.checksum[:]) {
+			msg := fmt.Sprintf("payload checksum failed - header indicates "+
+				"%v, but actual checksum is %v.", hdr.checksum, cksum)
+			return totalBytes, nil, nil, messageError(op, ErrPayloadChecksum,
+				msg)
+		}
 	}
 
 	// Unmarshal message.  NOTE: This must be a *bytes.Buffer since the
@@ -403,6 +487,29 @@ func ReadMessageN(r io.Reader, pver uint32, dcrnet CurrencyNet) (int, Message, [
 	return totalBytes, msg, payload, nil
 }
 
+// ReadMessageN reads, validates, and parses the next Decred Message from r for
+// the provided protocol version and Decred network.  It returns the number of
+// bytes read in addition to the parsed Message and raw bytes which comprise the
+// message.  This function is the same as ReadMessage except it also returns the
+// number of bytes read.
+func ReadMessageN(r io.Reader, pver uint32, dcrnet CurrencyNet) (int, Message, []byte, error) {
+	const op = "ReadMessage"
+	return readMessageN(op, r, pver, dcrnet, true)
+}
+
+// ReadAuthenticatedMessageN reads, validates, and parses the next Decred Message
+// from r for the provided protocol version and Decred network that was written
+// with WriteAuthenticatedMessageN.  It returns the number of bytes read in
+// addition to the parsed Message and raw bytes which comprise the message.
+//
+// The header of the message is expected to omit the payload checksum.  Callers
+// are therefore responsible for ensuring the bytes read from r have already
+// been authenticated.
+func ReadAuthenticatedMessageN(r io.Reader, pver uint32, dcrnet CurrencyNet) (int, Message, []byte, error) {
+	const op = "ReadAuthenticatedMessage"
+	return readMessageN(op, r, pver, dcrnet, false)
+}
+
 // ReadMessage reads, validates, and parses the next Decred Message from r for
 // the provided protocol version and Decred network.  It returns the parsed
 // Message and raw bytes which comprise the message.  This function only differs
@@ -1,5 +1,5 @@
 // Copyright (c) 2013-2016 The btcsuite developers
-// Copyright (c) 2015-2021 The Decred developers
+// Copyright (c) 2015-2024 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
@@ -80,6 +80,27 @@ func TestMessage(t *testing.T) {
 	msgReject := NewMsgReject("block", RejectDuplicate, "duplicate block")
 	msgGetInitState := NewMsgGetInitState()
 	msgInitState := NewMsgInitState()
+	msgSendCmpct := NewMsgSendCmpct(true, CmpctBlockVersion)
+	msgCmpctBlock := &MsgCmpctBlock{
+		Header:             testBlock.Header,
+		ShortIDs:           []uint64{},
+		PrefilledTxns:      []PrefilledTx{},
+		StakeShortIDs:      []uint64{},
+		StakePrefilledTxns: []PrefilledTx{},
+	}
+	msgGetBlockTxn := NewMsgGetBlockTxn(&chainhash.Hash{}, []uint32{0},
+		[]uint32{})
+	msgBlockTxn := &MsgBlockTxn{
+		Transactions:  []*MsgTx{},
+		STransactions: []*MsgTx{},
+	}
+	msgAddrV2 := NewMsgAddrV2()
+	msgFilterLoad := NewMsgFilterLoad([]byte{0x01}, 10, 0, BloomUpdateNone)
+	msgFilterAdd := NewMsgFilterAdd([]byte{0x01})
+	msgFilterClear := NewMsgFilterClear()
+	msgMerkleBlock := NewMsgMerkleBlock(&testBlock.Header, 0, 0)
+	msgGetCFHeadersV2 := NewMsgGetCFHeadersV2()
+	msgCFHeadersV2 := NewMsgCFHeadersV2()
 
 	tests := []struct {
 		in     Message     // Value to encode
@@ -112,6 +133,17 @@ func TestMessage(t *testing.T) {
 		{msgCFTypes, msgCFTypes, pver, MainNet, 26},
 		{msgGetInitState, msgGetInitState, pver, MainNet, 25},
 		{msgInitState, msgInitState, pver, MainNet, 27},
+		{msgSendCmpct, msgSendCmpct, pver, MainNet, 33},
+		{msgCmpctBlock, msgCmpctBlock, pver, MainNet, 216},
+		{msgGetBlockTxn, msgGetBlockTxn, pver, MainNet, 59},
+		{msgBlockTxn, msgBlockTxn, pver, MainNet, 58},
+		{msgAddrV2, msgAddrV2, pver, MainNet, 25},
+		{msgFilterLoad, msgFilterLoad, pver, MainNet, 35},
+		{msgFilterAdd, msgFilterAdd, pver, MainNet, 26},
+		{msgFilterClear, msgFilterClear, pver, MainNet, 24},
+		{msgMerkleBlock, msgMerkleBlock, pver, MainNet, 213},
+		{msgGetCFHeadersV2, msgGetCFHeadersV2, pver, MainNet, 57},
+		{msgCFHeadersV2, msgCFHeadersV2, pver, MainNet, 25},
 	}
 
 	t.Logf("Running %d tests", len(tests))
@@ -177,6 +209,45 @@ func TestMessage(t *testing.T) {
 			continue
 		}
 	}
+
+	// Do the same thing for Read/WriteAuthenticatedMessageN and ensure the
+	// number of bytes excludes the checksum.
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		// Encode to wire format.
+		var buf bytes.Buffer
+		nw, err := WriteAuthenticatedMessageN(&buf, test.in, test.pver,
+			test.dcrnet)
+		if err != nil {
+			t.Errorf("WriteAuthenticatedMessageN #%d error %v", i, err)
+			continue
+		}
+		wantBytes := test.bytes - (MessageHeaderSize -
+			AuthenticatedMessageHeaderSize)
+		if nw != wantBytes {
+			t.Errorf("WriteAuthenticatedMessageN #%d unexpected num bytes "+
+				"written - got %d, want %d", i, nw, wantBytes)
+		}
+
+		// Decode from wire format.
+		rbuf := bytes.NewReader(buf.Bytes())
+		nr, msg, _, err := ReadAuthenticatedMessageN(rbuf, test.pver,
+			test.dcrnet)
+		if err != nil {
+			t.Errorf("ReadAuthenticatedMessageN #%d error %v, msg %v", i,
+				err, spew.Sdump(msg))
+			continue
+		}
+		if !reflect.DeepEqual(msg, test.out) {
+			t.Errorf("ReadAuthenticatedMessageN #%d\n got: %v want: %v", i,
+				spew.Sdump(msg), spew.Sdump(test.out))
+			continue
+		}
+		if nr != wantBytes {
+			t.Errorf("ReadAuthenticatedMessageN #%d unexpected num bytes "+
+				"read - got %d, want %d", i, nr, wantBytes)
+		}
+	}
 }
 
 // TestReadMessageWireErrors performs negative tests against wire decoding into
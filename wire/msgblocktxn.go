@@ -0,0 +1,142 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+// MsgBlockTxn implements the Message interface and represents a blocktxn
+// message.  It is used to deliver the transactions of a block that were
+// requested with a getblocktxn message in the order they were requested.
+//
+// This message was not added until protocol versions starting with
+// CompactBlocksVersion.
+type MsgBlockTxn struct {
+	BlockHash     chainhash.Hash
+	Transactions  []*MsgTx
+	STransactions []*MsgTx
+}
+
+// readTxns reads a variable length list of transactions from r while limiting
+// the number of transactions to the provided maximum.
+func readTxns(op string, r io.Reader, pver uint32, maxTxns uint64) ([]*MsgTx, error) {
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return nil, err
+	}
+	if count > maxTxns {
+		msg := fmt.Sprintf("too many transactions to fit into a block "+
+			"[count %d, max %d]", count, maxTxns)
+		return nil, messageError(op, ErrTooManyTxs, msg)
+	}
+
+	txns := make([]*MsgTx, 0, count)
+	for i := uint64(0); i < count; i++ {
+		var tx MsgTx
+		if err := tx.BtcDecode(r, pver); err != nil {
+			return nil, err
+		}
+		txns = append(txns, &tx)
+	}
+	return txns, nil
+}
+
+// writeTxns writes the provided list of transactions to w after ensuring the
+// number of transactions does not exceed the provided maximum.
+func writeTxns(op string, w io.Writer, pver uint32, maxTxns uint64, txns []*MsgTx) error {
+	count := uint64(len(txns))
+	if count > maxTxns {
+		msg := fmt.Sprintf("too many transactions to fit into a block "+
+			"[count %d, max %d]", count, maxTxns)
+		return messageError(op, ErrTooManyTxs, msg)
+	}
+
+	err := WriteVarInt(w, pver, count)
+	if err != nil {
+		return err
+	}
+	for _, tx := range txns {
+		if err := tx.BtcEncode(w, pver); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BtcDecode decodes r using the protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgBlockTxn) BtcDecode(r io.Reader, pver uint32) error {
+	const op = "MsgBlockTxn.BtcDecode"
+	if pver < CompactBlocksVersion {
+		msg := fmt.Sprintf("%s message invalid for protocol version %d",
+			msg.Command(), pver)
+		return messageError(op, ErrMsgInvalidForPVer, msg)
+	}
+
+	err := readElement(r, &msg.BlockHash)
+	if err != nil {
+		return err
+	}
+
+	maxTxPerTree := MaxTxPerTxTree(pver)
+	msg.Transactions, err = readTxns(op, r, pver, maxTxPerTree)
+	if err != nil {
+		return err
+	}
+	msg.STransactions, err = readTxns(op, r, pver, maxTxPerTree)
+	return err
+}
+
+// BtcEncode encodes the receiver to w using the protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgBlockTxn) BtcEncode(w io.Writer, pver uint32) error {
+	const op = "MsgBlockTxn.BtcEncode"
+	if pver < CompactBlocksVersion {
+		msg := fmt.Sprintf("%s message invalid for protocol version %d",
+			msg.Command(), pver)
+		return messageError(op, ErrMsgInvalidForPVer, msg)
+	}
+
+	err := writeElement(w, &msg.BlockHash)
+	if err != nil {
+		return err
+	}
+
+	maxTxPerTree := MaxTxPerTxTree(pver)
+	err = writeTxns(op, w, pver, maxTxPerTree, msg.Transactions)
+	if err != nil {
+		return err
+	}
+	return writeTxns(op, w, pver, maxTxPerTree, msg.STransactions)
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgBlockTxn) Command() string {
+	return CmdBlockTxn
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgBlockTxn) MaxPayloadLength(pver uint32) uint32 {
+	if pver < CompactBlocksVersion {
+		return 0
+	}
+
+	// Block hash + the transactions which can be no larger than a block.
+	return chainhash.HashSize + MaxBlockPayload
+}
+
+// NewMsgBlockTxn returns a new blocktxn message that conforms to the Message
+// interface for the provided block hash.  See MsgBlockTxn for details.
+func NewMsgBlockTxn(blockHash *chainhash.Hash) *MsgBlockTxn {
+	return &MsgBlockTxn{
+		BlockHash: *blockHash,
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+// TestBlockTxn tests the MsgBlockTxn API.
+func TestBlockTxn(t *testing.T) {
+	pver := ProtocolVersion
+
+	hash := chainhash.Hash{0x01}
+	msg := NewMsgBlockTxn(&hash)
+	if msg.BlockHash != hash {
+		t.Errorf("NewMsgBlockTxn: wrong block hash - got %v, want %v",
+			msg.BlockHash, hash)
+	}
+
+	// Ensure the command is expected value.
+	wantCmd := "blocktxn"
+	if cmd := msg.Command(); cmd != wantCmd {
+		t.Errorf("NewMsgBlockTxn: wrong command - got %v want %v",
+			cmd, wantCmd)
+	}
+
+	// Ensure max payload is expected value for latest protocol version.
+	wantPayload := uint32(32 + MaxBlockPayload)
+	maxPayload := msg.MaxPayloadLength(pver)
+	if maxPayload != wantPayload {
+		t.Errorf("MaxPayloadLength: wrong max payload length for "+
+			"protocol version %d - got %v, want %v", pver,
+			maxPayload, wantPayload)
+	}
+
+	// Ensure max payload length is not more than MaxMessagePayload.
+	if maxPayload > MaxMessagePayload {
+		t.Fatalf("MaxPayloadLength: payload length (%v) for protocol "+
+			"version %d exceeds MaxMessagePayload (%v).", maxPayload, pver,
+			MaxMessagePayload)
+	}
+}
+
+// TestBlockTxnWire tests the MsgBlockTxn wire encode and decode.
+func TestBlockTxnWire(t *testing.T) {
+	pver := ProtocolVersion
+
+	hash := chainhash.Hash{0x01, 0x02}
+	msg := NewMsgBlockTxn(&hash)
+	msg.Transactions = []*MsgTx{multiTx}
+	msg.STransactions = []*MsgTx{}
+	msgEncoded := []byte{
+		0x01, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Block hash
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x01, // Varint for number of transactions
+	}
+	msgEncoded = append(msgEncoded, multiTxEncoded...)
+	msgEncoded = append(msgEncoded, 0x00) // Varint for number of stake txns
+
+	tests := []struct {
+		in   *MsgBlockTxn // Message to encode
+		out  *MsgBlockTxn // Expected decoded message
+		buf  []byte       // Wire encoding
+		pver uint32       // Protocol version for wire encoding
+	}{{
+		in:   msg,
+		out:  msg,
+		buf:  msgEncoded,
+		pver: pver,
+	}, {
+		in:   msg,
+		out:  msg,
+		buf:  msgEncoded,
+		pver: CompactBlocksVersion,
+	}}
+
+	for i, test := range tests {
+		// Encode the message to wire format.
+		var buf bytes.Buffer
+		err := test.in.BtcEncode(&buf, test.pver)
+		if err != nil {
+			t.Errorf("BtcEncode #%d error %v", i, err)
+			continue
+		}
+		if !bytes.Equal(buf.Bytes(), test.buf) {
+			t.Errorf("BtcEncode #%d - got %s, want: %s", i,
+				spew.Sdump(buf.Bytes()), spew.Sdump(test.buf))
+			continue
+		}
+
+		// Decode the message from wire format.
+		var msg MsgBlockTxn
+		rbuf := bytes.NewReader(test.buf)
+		err = msg.BtcDecode(rbuf, test.pver)
+		if err != nil {
+			t.Errorf("BtcDecode #%d error %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(&msg, test.out) {
+			t.Errorf("BtcDecode #%d - got: %s want: %s", i,
+				spew.Sdump(&msg), spew.Sdump(test.out))
+			continue
+		}
+	}
+}
+
+// TestBlockTxnWireErrors performs negative tests against wire encode and
+// decode of MsgBlockTxn to confirm error paths work correctly.
+func TestBlockTxnWireErrors(t *testing.T) {
+	pver := ProtocolVersion
+	pverNoCmpct := CompactBlocksVersion - 1
+
+	hash := chainhash.Hash{0x01, 0x02}
+	baseMsg := NewMsgBlockTxn(&hash)
+	baseMsg.STransactions = []*MsgTx{multiTx}
+	baseMsgEncoded := []byte{
+		0x01, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Block hash
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, // Varint for number of transactions
+		0x01, // Varint for number of stake transactions
+	}
+	baseMsgEncoded = append(baseMsgEncoded, multiTxEncoded...)
+
+	// Message with more transactions than are allowed.
+	maxTxPerTree := MaxTxPerTxTree(pver)
+	tooManyEncoded := make([]byte, 0, 35)
+	tooManyEncoded = append(tooManyEncoded, baseMsgEncoded[:32]...)
+	tooManyEncoded = append(tooManyEncoded, 0xfd, byte(maxTxPerTree+1),
+		byte((maxTxPerTree+1)>>8))
+
+	tests := []struct {
+		in       *MsgBlockTxn // Value to encode
+		buf      []byte       // Wire encoding
+		pver     uint32       // Protocol version for wire encoding
+		max      int          // Max size of fixed buffer to induce errors
+		writeErr error        // Expected write error
+		readErr  error        // Expected read error
+	}{
+		// Force error in block hash.
+		{baseMsg, baseMsgEncoded, pver, 0, io.ErrShortWrite, io.EOF},
+		// Force error in number of transactions.
+		{baseMsg, baseMsgEncoded, pver, 32, io.ErrShortWrite, io.EOF},
+		// Force error in number of stake transactions.
+		{baseMsg, baseMsgEncoded, pver, 33, io.ErrShortWrite, io.EOF},
+		// Force error in stake transactions.
+		{baseMsg, baseMsgEncoded, pver, 34, io.ErrShortWrite, io.EOF},
+		// Force error due to more transactions than are allowed.
+		{baseMsg, tooManyEncoded, pver, 100, io.ErrShortWrite, ErrTooManyTxs},
+		// Force error due to unsupported protocol version.
+		{baseMsg, baseMsgEncoded, pverNoCmpct, 100, ErrMsgInvalidForPVer, ErrMsgInvalidForPVer},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		// Encode to wire format.
+		w := newFixedWriter(test.max)
+		err := test.in.BtcEncode(w, test.pver)
+		if !errors.Is(err, test.writeErr) {
+			t.Errorf("BtcEncode #%d wrong error got: %v, want: %v", i, err,
+				test.writeErr)
+			continue
+		}
+
+		// Decode from wire format.
+		var msg MsgBlockTxn
+		r := newFixedReader(test.max, test.buf)
+		err = msg.BtcDecode(r, test.pver)
+		if !errors.Is(err, test.readErr) {
+			t.Errorf("BtcDecode #%d wrong error got: %v, want: %v", i, err,
+				test.readErr)
+			continue
+		}
+	}
+}
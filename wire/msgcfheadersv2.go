@@ -0,0 +1,223 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+// MaxCFHeadersV2PerMsg is the maximum number of filter hashes that can be in a
+// single cfheadersv2 message.
+const MaxCFHeadersV2PerMsg = 2000
+
+// CFHeaderV2 houses the hash of the version 2 committed gcs filter for a block
+// along with a proof that can be used to prove the filter hash is committed to
+// by the block header.
+type CFHeaderV2 struct {
+	BlockHash   chainhash.Hash
+	FilterHash  chainhash.Hash
+	ProofIndex  uint32
+	ProofHashes []chainhash.Hash
+}
+
+// NewCFHeaderV2 returns a new filter hash entry suitable for use in a
+// cfheadersv2 message using the passed parameters.
+func NewCFHeaderV2(blockHash, filterHash *chainhash.Hash, proofIndex uint32,
+	proofHashes []chainhash.Hash) *CFHeaderV2 {
+
+	return &CFHeaderV2{
+		BlockHash:   *blockHash,
+		FilterHash:  *filterHash,
+		ProofIndex:  proofIndex,
+		ProofHashes: proofHashes,
+	}
+}
+
+// MsgCFHeadersV2 implements the Message interface and represents a cfheadersv2
+// message.  It is used to deliver the hashes of the version 2 committed gcs
+// filters for a range of blocks along with the proofs that can be used to prove
+// each filter hash is committed to by its block header.  The entries are in
+// ascending order of block height.  Note that the proofs are only useful once
+// the vote to enable header commitments is active.
+//
+// It is delivered in response to a getcfhdrsv2 message (MsgGetCFHeadersV2).
+type MsgCFHeadersV2 struct {
+	Headers []*CFHeaderV2
+}
+
+// AddCFHeader adds a new filter hash entry to the message.
+func (msg *MsgCFHeadersV2) AddCFHeader(header *CFHeaderV2) error {
+	const op = "MsgCFHeadersV2.AddCFHeader"
+	if len(msg.Headers)+1 > MaxCFHeadersV2PerMsg {
+		msg := fmt.Sprintf("too many filter headers in message [max %v]",
+			MaxCFHeadersV2PerMsg)
+		return messageError(op, ErrTooManyHeaders, msg)
+	}
+
+	msg.Headers = append(msg.Headers, header)
+	return nil
+}
+
+// readCFHeaderV2 reads a filter hash entry from r.
+func readCFHeaderV2(r io.Reader, pver uint32, header *CFHeaderV2) error {
+	const op = "readCFHeaderV2"
+	err := readElements(r, &header.BlockHash, &header.FilterHash,
+		&header.ProofIndex)
+	if err != nil {
+		return err
+	}
+
+	// Read num proof hashes and limit to max.
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return err
+	}
+	if count > MaxHeaderProofHashes {
+		msg := fmt.Sprintf("too many proof hashes for message "+
+			"[count %v, max %v]", count, MaxHeaderProofHashes)
+		return messageError(op, ErrTooManyProofs, msg)
+	}
+
+	header.ProofHashes = make([]chainhash.Hash, count)
+	for i := uint64(0); i < count; i++ {
+		err := readElement(r, &header.ProofHashes[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeCFHeaderV2 writes a filter hash entry to w.
+func writeCFHeaderV2(w io.Writer, pver uint32, header *CFHeaderV2) error {
+	const op = "writeCFHeaderV2"
+	numHashes := len(header.ProofHashes)
+	if numHashes > MaxHeaderProofHashes {
+		msg := fmt.Sprintf("too many proof hashes for message "+
+			"[count %v, max %v]", numHashes, MaxHeaderProofHashes)
+		return messageError(op, ErrTooManyProofs, msg)
+	}
+
+	err := writeElements(w, &header.BlockHash, &header.FilterHash,
+		header.ProofIndex)
+	if err != nil {
+		return err
+	}
+
+	err = WriteVarInt(w, pver, uint64(numHashes))
+	if err != nil {
+		return err
+	}
+
+	for i := range header.ProofHashes {
+		err := writeElement(w, &header.ProofHashes[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// BtcDecode decodes r using the Decred protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgCFHeadersV2) BtcDecode(r io.Reader, pver uint32) error {
+	const op = "MsgCFHeadersV2.BtcDecode"
+	if pver < CFHeadersV2Version {
+		msg := fmt.Sprintf("%s message invalid for protocol version %d",
+			msg.Command(), pver)
+		return messageError(op, ErrMsgInvalidForPVer, msg)
+	}
+
+	// Read num filter headers and limit to max.
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return err
+	}
+	if count > MaxCFHeadersV2PerMsg {
+		msg := fmt.Sprintf("too many filter headers for message "+
+			"[count %v, max %v]", count, MaxCFHeadersV2PerMsg)
+		return messageError(op, ErrTooManyHeaders, msg)
+	}
+
+	// Create a contiguous slice of headers to deserialize into in order to
+	// reduce the number of allocations.
+	headers := make([]CFHeaderV2, count)
+	msg.Headers = make([]*CFHeaderV2, 0, count)
+	for i := uint64(0); i < count; i++ {
+		header := &headers[i]
+		err := readCFHeaderV2(r, pver, header)
+		if err != nil {
+			return err
+		}
+		msg.Headers = append(msg.Headers, header)
+	}
+
+	return nil
+}
+
+// BtcEncode encodes the receiver to w using the Decred protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgCFHeadersV2) BtcEncode(w io.Writer, pver uint32) error {
+	const op = "MsgCFHeadersV2.BtcEncode"
+	if pver < CFHeadersV2Version {
+		msg := fmt.Sprintf("%s message invalid for protocol version %d",
+			msg.Command(), pver)
+		return messageError(op, ErrMsgInvalidForPVer, msg)
+	}
+
+	// Limit to max filter headers per message.
+	count := len(msg.Headers)
+	if count > MaxCFHeadersV2PerMsg {
+		msg := fmt.Sprintf("too many filter headers for message "+
+			"[count %v, max %v]", count, MaxCFHeadersV2PerMsg)
+		return messageError(op, ErrTooManyHeaders, msg)
+	}
+
+	err := WriteVarInt(w, pver, uint64(count))
+	if err != nil {
+		return err
+	}
+
+	for _, header := range msg.Headers {
+		err := writeCFHeaderV2(w, pver, header)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgCFHeadersV2) Command() string {
+	return CmdCFHeadersV2
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgCFHeadersV2) MaxPayloadLength(pver uint32) uint32 {
+	// Num filter headers (varInt) + max allowed filter headers each of which
+	// consist of a block hash + filter hash + proof index + max num proof
+	// hashes (including varint).
+	maxHeaderSize := chainhash.HashSize*2 + 4 +
+		uint32(VarIntSerializeSize(MaxHeaderProofHashes)) +
+		(MaxHeaderProofHashes * chainhash.HashSize)
+	return uint32(VarIntSerializeSize(MaxCFHeadersV2PerMsg)) +
+		(MaxCFHeadersV2PerMsg * maxHeaderSize)
+}
+
+// NewMsgCFHeadersV2 returns a new cfheadersv2 message that conforms to the
+// Message interface.  See MsgCFHeadersV2 for details.
+func NewMsgCFHeadersV2() *MsgCFHeadersV2 {
+	return &MsgCFHeadersV2{
+		Headers: make([]*CFHeaderV2, 0, MaxCFHeadersV2PerMsg),
+	}
+}
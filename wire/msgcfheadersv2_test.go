@@ -0,0 +1,242 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+// baseMsgCFHeadersV2 returns a MsgCFHeadersV2 populated with mock values that
+// are used throughout the tests along with its expected encoding.
+func baseMsgCFHeadersV2() (*MsgCFHeadersV2, []byte) {
+	msg := NewMsgCFHeadersV2()
+	msg.AddCFHeader(NewCFHeaderV2(&chainhash.Hash{0x01}, &chainhash.Hash{0x02},
+		0, []chainhash.Hash{{0x03}}))
+	msg.AddCFHeader(NewCFHeaderV2(&chainhash.Hash{0x04}, &chainhash.Hash{0x05},
+		1, []chainhash.Hash{}))
+
+	encoded := []byte{
+		0x02, // Varint for number of filter headers
+		0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // First block hash
+		0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // First filter hash
+		0x00, 0x00, 0x00, 0x00, // First proof index
+		0x01, // Varint for num first proof hashes
+		0x03, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // First proof hash
+		0x04, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Second block hash
+		0x05, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Second filter hash
+		0x01, 0x00, 0x00, 0x00, // Second proof index
+		0x00, // Varint for num second proof hashes
+	}
+	return msg, encoded
+}
+
+// TestCFHeadersV2 tests the MsgCFHeadersV2 API.
+func TestCFHeadersV2(t *testing.T) {
+	pver := ProtocolVersion
+
+	// Ensure the command is expected value.
+	wantCmd := "cfheadersv2"
+	msg := NewMsgCFHeadersV2()
+	if cmd := msg.Command(); cmd != wantCmd {
+		t.Errorf("NewMsgCFHeadersV2: wrong command - got %v want %v", cmd,
+			wantCmd)
+	}
+
+	// Ensure max payload is expected value for latest protocol version.
+	// Num filter headers (varInt) 3 bytes + max allowed filter headers each of
+	// which are a block hash + filter hash + proof index 4 bytes + num proof
+	// hashes (varInt) 1 byte + max allowed proof hashes.
+	wantPayload := uint32(3 + MaxCFHeadersV2PerMsg*(chainhash.HashSize*2+4+1+
+		MaxHeaderProofHashes*chainhash.HashSize))
+	maxPayload := msg.MaxPayloadLength(pver)
+	if maxPayload != wantPayload {
+		t.Errorf("MaxPayloadLength: wrong max payload length for protocol "+
+			"version %d - got %v, want %v", pver, maxPayload, wantPayload)
+	}
+
+	// Ensure the max payload does not exceed the max allowed message payload.
+	if maxPayload > MaxMessagePayload {
+		t.Errorf("MaxPayloadLength: max payload length %d exceeds max "+
+			"message payload %d", maxPayload, MaxMessagePayload)
+	}
+
+	// Ensure filter headers are added properly and adding more than the max
+	// allowed fails.
+	header := NewCFHeaderV2(&chainhash.Hash{}, &chainhash.Hash{}, 0, nil)
+	for i := 0; i < MaxCFHeadersV2PerMsg; i++ {
+		if err := msg.AddCFHeader(header); err != nil {
+			t.Fatalf("AddCFHeader: unexpected error: %v", err)
+		}
+	}
+	err := msg.AddCFHeader(header)
+	if !errors.Is(err, ErrTooManyHeaders) {
+		t.Errorf("AddCFHeader: wrong error - got %v, want %v", err,
+			ErrTooManyHeaders)
+	}
+
+	// Ensure encoding and decoding with the protocol version prior to
+	// CFHeadersV2Version fails.
+	pver = CFHeadersV2Version - 1
+	var buf bytes.Buffer
+	err = msg.BtcEncode(&buf, pver)
+	if !errors.Is(err, ErrMsgInvalidForPVer) {
+		t.Errorf("unexpected error when encoding for protocol version %d - "+
+			"got %v, want %v", pver, err, ErrMsgInvalidForPVer)
+	}
+	var readmsg MsgCFHeadersV2
+	err = readmsg.BtcDecode(&buf, pver)
+	if !errors.Is(err, ErrMsgInvalidForPVer) {
+		t.Errorf("unexpected error when decoding for protocol version %d - "+
+			"got %v, want %v", pver, err, ErrMsgInvalidForPVer)
+	}
+}
+
+// TestCFHeadersV2Wire tests the MsgCFHeadersV2 wire encode and decode for
+// various protocol versions.
+func TestCFHeadersV2Wire(t *testing.T) {
+	msgCFHeaders, msgCFHeadersEncoded := baseMsgCFHeadersV2()
+	noHeaders := NewMsgCFHeadersV2()
+	noHeadersEncoded := []byte{
+		0x00, // Varint for number of filter headers
+	}
+
+	tests := []struct {
+		in   *MsgCFHeadersV2 // Message to encode
+		out  *MsgCFHeadersV2 // Expected decoded message
+		buf  []byte          // Wire encoding
+		pver uint32          // Protocol version for wire encoding
+	}{
+		{msgCFHeaders, msgCFHeaders, msgCFHeadersEncoded, ProtocolVersion},
+		{msgCFHeaders, msgCFHeaders, msgCFHeadersEncoded, CFHeadersV2Version},
+		{noHeaders, noHeaders, noHeadersEncoded, ProtocolVersion},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		// Encode the message to wire format.
+		var buf bytes.Buffer
+		err := test.in.BtcEncode(&buf, test.pver)
+		if err != nil {
+			t.Errorf("BtcEncode #%d error %v", i, err)
+			continue
+		}
+		if !bytes.Equal(buf.Bytes(), test.buf) {
+			t.Errorf("BtcEncode #%d\n got: %s want: %s", i,
+				spew.Sdump(buf.Bytes()), spew.Sdump(test.buf))
+			continue
+		}
+
+		// Decode the message from wire format.
+		var msg MsgCFHeadersV2
+		rbuf := bytes.NewReader(test.buf)
+		err = msg.BtcDecode(rbuf, test.pver)
+		if err != nil {
+			t.Errorf("BtcDecode #%d error %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(&msg, test.out) {
+			t.Errorf("BtcDecode #%d\n got: %s want: %s", i,
+				spew.Sdump(&msg), spew.Sdump(test.out))
+			continue
+		}
+	}
+}
+
+// TestCFHeadersV2WireErrors performs negative tests against wire encode and
+// decode of MsgCFHeadersV2 to confirm error paths work correctly.
+func TestCFHeadersV2WireErrors(t *testing.T) {
+	pver := ProtocolVersion
+
+	baseCFHeaders, baseCFHeadersEncoded := baseMsgCFHeadersV2()
+
+	// Message that forces an error by having more than the max allowed filter
+	// headers.
+	maxHeaders, _ := baseMsgCFHeadersV2()
+	for i := 0; i < MaxCFHeadersV2PerMsg-1; i++ {
+		maxHeaders.Headers = append(maxHeaders.Headers, maxHeaders.Headers[1])
+	}
+	maxHeadersEncoded := []byte{
+		0xfd, 0xd1, 0x07, // Varint for number of filter headers (2001)
+	}
+
+	// Message that forces an error by having more than the max allowed proof
+	// hashes.
+	maxProofHashes, maxProofHashesEncoded := baseMsgCFHeadersV2()
+	maxProofHashes.Headers[0].ProofHashes = make([]chainhash.Hash,
+		MaxHeaderProofHashes+1)
+	maxProofHashesEncoded[69] = MaxHeaderProofHashes + 1
+
+	tests := []struct {
+		in       *MsgCFHeadersV2 // Value to encode
+		buf      []byte          // Wire encoding
+		pver     uint32          // Protocol version for wire encoding
+		max      int             // Max size of fixed buffer to induce errors
+		writeErr error           // Expected write error
+		readErr  error           // Expected read error
+	}{
+		// Force error in num filter headers.
+		{baseCFHeaders, baseCFHeadersEncoded, pver, 0, io.ErrShortWrite, io.EOF},
+		// Force error in first block hash.
+		{baseCFHeaders, baseCFHeadersEncoded, pver, 1, io.ErrShortWrite, io.EOF},
+		// Force error in first filter hash.
+		{baseCFHeaders, baseCFHeadersEncoded, pver, 33, io.ErrShortWrite, io.EOF},
+		// Force error in first proof index.
+		{baseCFHeaders, baseCFHeadersEncoded, pver, 65, io.ErrShortWrite, io.EOF},
+		// Force error in num first proof hashes.
+		{baseCFHeaders, baseCFHeadersEncoded, pver, 69, io.ErrShortWrite, io.EOF},
+		// Force error in middle of first proof hash.
+		{baseCFHeaders, baseCFHeadersEncoded, pver, 80, io.ErrShortWrite, io.ErrUnexpectedEOF},
+		// Force error in second block hash.
+		{baseCFHeaders, baseCFHeadersEncoded, pver, 102, io.ErrShortWrite, io.EOF},
+		// Force error with greater than max filter headers.
+		{maxHeaders, maxHeadersEncoded, pver, 3, ErrTooManyHeaders, ErrTooManyHeaders},
+		// Force error with greater than max proof hashes.
+		{maxProofHashes, maxProofHashesEncoded, pver, 70, ErrTooManyProofs, ErrTooManyProofs},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		// Encode to wire format.
+		w := newFixedWriter(test.max)
+		err := test.in.BtcEncode(w, test.pver)
+		if !errors.Is(err, test.writeErr) {
+			t.Errorf("BtcEncode #%d wrong error got: %v, want: %v", i, err,
+				test.writeErr)
+			continue
+		}
+
+		// Decode from wire format.
+		var msg MsgCFHeadersV2
+		r := newFixedReader(test.max, test.buf)
+		err = msg.BtcDecode(r, test.pver)
+		if !errors.Is(err, test.readErr) {
+			t.Errorf("BtcDecode #%d wrong error got: %v, want: %v", i, err,
+				test.readErr)
+			continue
+		}
+	}
+}
@@ -0,0 +1,347 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/dchest/siphash"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+const (
+	// ShortTxIDSize is the number of bytes used to encode a short
+	// transaction ID.
+	ShortTxIDSize = 6
+
+	// shortTxIDMask is the mask applied to the result of the hash function
+	// used to calculate short transaction IDs to limit them to ShortTxIDSize
+	// bytes.
+	shortTxIDMask = (1 << (ShortTxIDSize * 8)) - 1
+)
+
+// PrefilledTx houses a transaction that is included in full in a compact block
+// along with its index within the transaction tree of the block.
+type PrefilledTx struct {
+	Index uint32
+	Tx    *MsgTx
+}
+
+// MsgCmpctBlock implements the Message interface and represents a cmpctblock
+// message.  It is used to relay a block while only sending short IDs for the
+// transactions the receiving peer is expected to already have, such as those
+// in its mempool, and the full transactions for the rest.
+//
+// Each transaction tree is represented independently by a list of short IDs
+// and a list of prefilled transactions that are ordered by their index within
+// the tree.  The short IDs fill the positions within the tree that are not
+// occupied by a prefilled transaction in order.  Notably, this means the votes
+// of a block, which are relayed independently ahead of the block, are
+// represented by short IDs in the stake tree.
+//
+// The short ID of a transaction is calculated by ShortTxID with the keys
+// returned by ShortTxIDKeys.  Any transactions that are missing after
+// reconstructing a block from the short IDs may be requested with a
+// getblocktxn message.
+//
+// This message was not added until protocol versions starting with
+// CompactBlocksVersion.
+type MsgCmpctBlock struct {
+	Header             BlockHeader
+	Nonce              uint64
+	ShortIDs           []uint64
+	PrefilledTxns      []PrefilledTx
+	StakeShortIDs      []uint64
+	StakePrefilledTxns []PrefilledTx
+}
+
+// TxCount returns the total number of transactions in the regular transaction
+// tree of the block the message represents.
+func (msg *MsgCmpctBlock) TxCount() int {
+	return len(msg.ShortIDs) + len(msg.PrefilledTxns)
+}
+
+// STxCount returns the total number of transactions in the stake transaction
+// tree of the block the message represents.
+func (msg *MsgCmpctBlock) STxCount() int {
+	return len(msg.StakeShortIDs) + len(msg.StakePrefilledTxns)
+}
+
+// ShortTxIDKeys returns the keys used to calculate the short transaction IDs
+// of the message.  They are derived from the first 16 bytes of the BLAKE-256
+// hash of the serialized block header followed by the little-endian nonce of
+// the message.
+func (msg *MsgCmpctBlock) ShortTxIDKeys() (uint64, uint64) {
+	// Ignore the errors since writing to a bytes.Buffer can't fail.
+	buf := bytes.NewBuffer(make([]byte, 0, MaxBlockHeaderPayload+8))
+	_ = writeBlockHeader(buf, 0, &msg.Header)
+	_ = writeElement(buf, msg.Nonce)
+	key := chainhash.HashB(buf.Bytes())
+	k0 := binary.LittleEndian.Uint64(key[0:8])
+	k1 := binary.LittleEndian.Uint64(key[8:16])
+	return k0, k1
+}
+
+// ShortTxID returns the short transaction ID for the provided transaction hash
+// using the provided keys.  It is the SipHash-2-4 of the hash truncated to
+// ShortTxIDSize bytes.
+func ShortTxID(k0, k1 uint64, txHash *chainhash.Hash) uint64 {
+	return siphash.Hash(k0, k1, txHash[:]) & shortTxIDMask
+}
+
+// readShortIDs reads a variable length list of short transaction IDs from r
+// while limiting the number of IDs to the provided maximum.
+func readShortIDs(op string, r io.Reader, pver uint32, maxIDs uint64) ([]uint64, error) {
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return nil, err
+	}
+	if count > maxIDs {
+		msg := fmt.Sprintf("too many short transaction ids for message "+
+			"[count %d, max %d]", count, maxIDs)
+		return nil, messageError(op, ErrTooManyTxs, msg)
+	}
+
+	shortIDs := make([]uint64, count)
+	var buf [8]byte
+	for i := uint64(0); i < count; i++ {
+		if _, err := io.ReadFull(r, buf[:ShortTxIDSize]); err != nil {
+			return nil, err
+		}
+		shortIDs[i] = binary.LittleEndian.Uint64(buf[:])
+	}
+	return shortIDs, nil
+}
+
+// writeShortIDs writes the provided list of short transaction IDs to w.
+func writeShortIDs(w io.Writer, pver uint32, shortIDs []uint64) error {
+	err := WriteVarInt(w, pver, uint64(len(shortIDs)))
+	if err != nil {
+		return err
+	}
+
+	var buf [8]byte
+	for _, shortID := range shortIDs {
+		binary.LittleEndian.PutUint64(buf[:], shortID)
+		if _, err := w.Write(buf[:ShortTxIDSize]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readPrefilledTxns reads a variable length list of prefilled transactions
+// from r.  The total number of transactions in the tree is limited to the
+// provided maximum and the indexes of the transactions must be strictly
+// increasing and within the tree given the provided number of short IDs.
+func readPrefilledTxns(op string, r io.Reader, pver uint32, numShortIDs, maxTxns uint64) ([]PrefilledTx, error) {
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return nil, err
+	}
+	if count > maxTxns-numShortIDs {
+		msg := fmt.Sprintf("too many transactions to fit into a block "+
+			"[count %d, max %d]", numShortIDs+count, maxTxns)
+		return nil, messageError(op, ErrTooManyTxs, msg)
+	}
+
+	numTxns := numShortIDs + count
+	txns := make([]PrefilledTx, count)
+	for i := uint64(0); i < count; i++ {
+		index, err := ReadVarInt(r, pver)
+		if err != nil {
+			return nil, err
+		}
+		if index >= numTxns || (i > 0 && index <= uint64(txns[i-1].Index)) {
+			msg := fmt.Sprintf("prefilled transaction index %d is not "+
+				"strictly increasing or exceeds the number of transactions "+
+				"%d", index, numTxns)
+			return nil, messageError(op, ErrInvalidMsg, msg)
+		}
+
+		var tx MsgTx
+		if err := tx.BtcDecode(r, pver); err != nil {
+			return nil, err
+		}
+		txns[i] = PrefilledTx{Index: uint32(index), Tx: &tx}
+	}
+	return txns, nil
+}
+
+// writePrefilledTxns writes the provided list of prefilled transactions to w
+// after ensuring their indexes are strictly increasing and within the tree
+// given the provided number of short IDs.
+func writePrefilledTxns(op string, w io.Writer, pver uint32, numShortIDs int, txns []PrefilledTx) error {
+	numTxns := numShortIDs + len(txns)
+	err := WriteVarInt(w, pver, uint64(len(txns)))
+	if err != nil {
+		return err
+	}
+
+	for i := range txns {
+		index := txns[i].Index
+		if int64(index) >= int64(numTxns) || (i > 0 && index <= txns[i-1].Index) {
+			msg := fmt.Sprintf("prefilled transaction index %d is not "+
+				"strictly increasing or exceeds the number of transactions "+
+				"%d", index, numTxns)
+			return messageError(op, ErrInvalidMsg, msg)
+		}
+		if err := WriteVarInt(w, pver, uint64(index)); err != nil {
+			return err
+		}
+		if err := txns[i].Tx.BtcEncode(w, pver); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BtcDecode decodes r using the protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgCmpctBlock) BtcDecode(r io.Reader, pver uint32) error {
+	const op = "MsgCmpctBlock.BtcDecode"
+	if pver < CompactBlocksVersion {
+		msg := fmt.Sprintf("%s message invalid for protocol version %d",
+			msg.Command(), pver)
+		return messageError(op, ErrMsgInvalidForPVer, msg)
+	}
+
+	err := readBlockHeader(r, pver, &msg.Header)
+	if err != nil {
+		return err
+	}
+	err = readElement(r, &msg.Nonce)
+	if err != nil {
+		return err
+	}
+
+	// Prevent more transactions than could possibly fit into each tree.  It
+	// would be possible to cause memory exhaustion and panics without a sane
+	// upper bound on the counts.
+	maxTxPerTree := MaxTxPerTxTree(pver)
+	msg.ShortIDs, err = readShortIDs(op, r, pver, maxTxPerTree)
+	if err != nil {
+		return err
+	}
+	msg.PrefilledTxns, err = readPrefilledTxns(op, r, pver,
+		uint64(len(msg.ShortIDs)), maxTxPerTree)
+	if err != nil {
+		return err
+	}
+	msg.StakeShortIDs, err = readShortIDs(op, r, pver, maxTxPerTree)
+	if err != nil {
+		return err
+	}
+	msg.StakePrefilledTxns, err = readPrefilledTxns(op, r, pver,
+		uint64(len(msg.StakeShortIDs)), maxTxPerTree)
+	return err
+}
+
+// BtcEncode encodes the receiver to w using the protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgCmpctBlock) BtcEncode(w io.Writer, pver uint32) error {
+	const op = "MsgCmpctBlock.BtcEncode"
+	if pver < CompactBlocksVersion {
+		msg := fmt.Sprintf("%s message invalid for protocol version %d",
+			msg.Command(), pver)
+		return messageError(op, ErrMsgInvalidForPVer, msg)
+	}
+
+	maxTxPerTree := MaxTxPerTxTree(pver)
+	if uint64(msg.TxCount()) > maxTxPerTree {
+		msg := fmt.Sprintf("too many transactions to fit into a block "+
+			"[count %d, max %d]", msg.TxCount(), maxTxPerTree)
+		return messageError(op, ErrTooManyTxs, msg)
+	}
+	if uint64(msg.STxCount()) > maxTxPerTree {
+		msg := fmt.Sprintf("too many stransactions to fit into a block "+
+			"[count %d, max %d]", msg.STxCount(), maxTxPerTree)
+		return messageError(op, ErrTooManyTxs, msg)
+	}
+
+	err := writeBlockHeader(w, pver, &msg.Header)
+	if err != nil {
+		return err
+	}
+	err = writeElement(w, msg.Nonce)
+	if err != nil {
+		return err
+	}
+	err = writeShortIDs(w, pver, msg.ShortIDs)
+	if err != nil {
+		return err
+	}
+	err = writePrefilledTxns(op, w, pver, len(msg.ShortIDs), msg.PrefilledTxns)
+	if err != nil {
+		return err
+	}
+	err = writeShortIDs(w, pver, msg.StakeShortIDs)
+	if err != nil {
+		return err
+	}
+	return writePrefilledTxns(op, w, pver, len(msg.StakeShortIDs),
+		msg.StakePrefilledTxns)
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgCmpctBlock) Command() string {
+	return CmdCmpctBlock
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgCmpctBlock) MaxPayloadLength(pver uint32) uint32 {
+	if pver < CompactBlocksVersion {
+		return 0
+	}
+
+	// A compact block can be no larger than the block it represents with all
+	// of its transactions prefilled plus the nonce, the additional counts,
+	// and the prefilled transaction indexes.
+	maxTxPerTree := uint32(MaxTxPerTxTree(pver))
+	return MaxBlockPayload + 8 + (MaxVarIntPayload * 2) +
+		(maxTxPerTree * 2 * MaxVarIntPayload)
+}
+
+// NewMsgCmpctBlock returns a new cmpctblock message that conforms to the
+// Message interface and represents the provided block using the provided
+// nonce to calculate the short transaction IDs.  See MsgCmpctBlock for
+// details.
+//
+// The provided function is invoked for every transaction in the block to
+// determine whether or not it is included in full.  The tree parameter is
+// either TxTreeRegular or TxTreeStake and the index parameter is the index of
+// the transaction within that tree.
+func NewMsgCmpctBlock(block *MsgBlock, nonce uint64, prefill func(tx *MsgTx, tree int8, index int) bool) *MsgCmpctBlock {
+	msg := &MsgCmpctBlock{
+		Header: block.Header,
+		Nonce:  nonce,
+	}
+	k0, k1 := msg.ShortTxIDKeys()
+	for i, tx := range block.Transactions {
+		if prefill(tx, TxTreeRegular, i) {
+			msg.PrefilledTxns = append(msg.PrefilledTxns,
+				PrefilledTx{Index: uint32(i), Tx: tx})
+			continue
+		}
+		txHash := tx.TxHash()
+		msg.ShortIDs = append(msg.ShortIDs, ShortTxID(k0, k1, &txHash))
+	}
+	for i, tx := range block.STransactions {
+		if prefill(tx, TxTreeStake, i) {
+			msg.StakePrefilledTxns = append(msg.StakePrefilledTxns,
+				PrefilledTx{Index: uint32(i), Tx: tx})
+			continue
+		}
+		txHash := tx.TxHash()
+		msg.StakeShortIDs = append(msg.StakeShortIDs,
+			ShortTxID(k0, k1, &txHash))
+	}
+	return msg
+}
@@ -0,0 +1,324 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+// testCmpctBlockSource returns a block with multiple transactions in each tree
+// that is used as the source of compact blocks in the tests.
+func testCmpctBlockSource() *MsgBlock {
+	return &MsgBlock{
+		Header: testBlock.Header,
+		Transactions: []*MsgTx{
+			testBlock.Transactions[0],
+			multiTx,
+		},
+		STransactions: []*MsgTx{
+			testBlock.STransactions[0],
+			multiTx,
+		},
+	}
+}
+
+// prefillFirst is a prefill function for NewMsgCmpctBlock that prefills the
+// first transaction of each tree.
+func prefillFirst(tx *MsgTx, tree int8, index int) bool {
+	return index == 0
+}
+
+// TestCmpctBlock tests the MsgCmpctBlock API.
+func TestCmpctBlock(t *testing.T) {
+	pver := ProtocolVersion
+
+	block := testCmpctBlockSource()
+	msg := NewMsgCmpctBlock(block, 0x0102030405060708, prefillFirst)
+
+	// Ensure the command is expected value.
+	wantCmd := "cmpctblock"
+	if cmd := msg.Command(); cmd != wantCmd {
+		t.Errorf("NewMsgCmpctBlock: wrong command - got %v want %v",
+			cmd, wantCmd)
+	}
+
+	// Ensure the transaction counts match the source block.
+	if msg.TxCount() != len(block.Transactions) {
+		t.Errorf("TxCount: wrong count - got %d, want %d", msg.TxCount(),
+			len(block.Transactions))
+	}
+	if msg.STxCount() != len(block.STransactions) {
+		t.Errorf("STxCount: wrong count - got %d, want %d", msg.STxCount(),
+			len(block.STransactions))
+	}
+
+	// Ensure the first transaction of each tree is prefilled and the
+	// remaining transactions are represented by their short IDs.
+	wantPrefilled := []PrefilledTx{{Index: 0, Tx: block.Transactions[0]}}
+	if !reflect.DeepEqual(msg.PrefilledTxns, wantPrefilled) {
+		t.Errorf("NewMsgCmpctBlock: wrong prefilled txns - got %v, want %v",
+			spew.Sdump(msg.PrefilledTxns), spew.Sdump(wantPrefilled))
+	}
+	wantPrefilled = []PrefilledTx{{Index: 0, Tx: block.STransactions[0]}}
+	if !reflect.DeepEqual(msg.StakePrefilledTxns, wantPrefilled) {
+		t.Errorf("NewMsgCmpctBlock: wrong prefilled stake txns - got %v, "+
+			"want %v", spew.Sdump(msg.StakePrefilledTxns),
+			spew.Sdump(wantPrefilled))
+	}
+	k0, k1 := msg.ShortTxIDKeys()
+	txHash := multiTx.TxHash()
+	wantShortIDs := []uint64{ShortTxID(k0, k1, &txHash)}
+	if !reflect.DeepEqual(msg.ShortIDs, wantShortIDs) {
+		t.Errorf("NewMsgCmpctBlock: wrong short ids - got %v, want %v",
+			msg.ShortIDs, wantShortIDs)
+	}
+	if !reflect.DeepEqual(msg.StakeShortIDs, wantShortIDs) {
+		t.Errorf("NewMsgCmpctBlock: wrong stake short ids - got %v, want %v",
+			msg.StakeShortIDs, wantShortIDs)
+	}
+
+	// Ensure the short IDs are limited to their size.
+	if wantShortIDs[0] >= 1<<(ShortTxIDSize*8) {
+		t.Errorf("ShortTxID: short id %x exceeds %d bytes", wantShortIDs[0],
+			ShortTxIDSize)
+	}
+
+	// Ensure the keys, and therefore the short IDs, change with the nonce.
+	msg2 := NewMsgCmpctBlock(block, 0x0102030405060709, prefillFirst)
+	if k20, k21 := msg2.ShortTxIDKeys(); k20 == k0 && k21 == k1 {
+		t.Errorf("ShortTxIDKeys: keys did not change with nonce")
+	}
+	if reflect.DeepEqual(msg.ShortIDs, msg2.ShortIDs) {
+		t.Errorf("NewMsgCmpctBlock: short ids did not change with nonce")
+	}
+
+	// Ensure max payload is expected value for latest protocol version.
+	maxTxPerTree := uint32(MaxTxPerTxTree(pver))
+	wantPayload := MaxBlockPayload + 8 + 18 + maxTxPerTree*2*9
+	maxPayload := msg.MaxPayloadLength(pver)
+	if maxPayload != wantPayload {
+		t.Errorf("MaxPayloadLength: wrong max payload length for "+
+			"protocol version %d - got %v, want %v", pver,
+			maxPayload, wantPayload)
+	}
+
+	// Ensure max payload length is not more than MaxMessagePayload.
+	if maxPayload > MaxMessagePayload {
+		t.Fatalf("MaxPayloadLength: payload length (%v) for protocol "+
+			"version %d exceeds MaxMessagePayload (%v).", maxPayload, pver,
+			MaxMessagePayload)
+	}
+}
+
+// TestCmpctBlockWire tests the MsgCmpctBlock wire encode and decode for
+// various protocol versions.
+func TestCmpctBlockWire(t *testing.T) {
+	pver := ProtocolVersion
+
+	// Compact block message with only short IDs.
+	headerBytes, err := testBlock.Header.Bytes()
+	if err != nil {
+		t.Fatalf("unable to serialize header: %v", err)
+	}
+	shortIDsMsg := &MsgCmpctBlock{
+		Header:             testBlock.Header,
+		Nonce:              0x0102030405060708,
+		ShortIDs:           []uint64{0x010203040506, 0x0a0b0c0d0e0f},
+		PrefilledTxns:      []PrefilledTx{},
+		StakeShortIDs:      []uint64{0x111213141516},
+		StakePrefilledTxns: []PrefilledTx{},
+	}
+	shortIDsMsgEncoded := append(headerBytes, []byte{
+		0x08, 0x07, 0x06, 0x05, 0x04, 0x03, 0x02, 0x01, // Nonce
+		0x02,                               // Varint for number of short ids
+		0x06, 0x05, 0x04, 0x03, 0x02, 0x01, // Short id 1
+		0x0f, 0x0e, 0x0d, 0x0c, 0x0b, 0x0a, // Short id 2
+		0x00,                               // Varint for number of prefilled txns
+		0x01,                               // Varint for number of stake short ids
+		0x16, 0x15, 0x14, 0x13, 0x12, 0x11, // Stake short id 1
+		0x00, // Varint for number of prefilled stake txns
+	}...)
+
+	// Compact block message with prefilled transactions.
+	prefilledMsg := NewMsgCmpctBlock(testCmpctBlockSource(), 1, prefillFirst)
+
+	tests := []struct {
+		in   *MsgCmpctBlock // Message to encode
+		out  *MsgCmpctBlock // Expected decoded message
+		buf  []byte         // Wire encoding or nil to skip comparison
+		pver uint32         // Protocol version for wire encoding
+	}{{
+		in:   shortIDsMsg,
+		out:  shortIDsMsg,
+		buf:  shortIDsMsgEncoded,
+		pver: pver,
+	}, {
+		in:   shortIDsMsg,
+		out:  shortIDsMsg,
+		buf:  shortIDsMsgEncoded,
+		pver: CompactBlocksVersion,
+	}, {
+		in:   prefilledMsg,
+		out:  prefilledMsg,
+		pver: pver,
+	}}
+
+	for i, test := range tests {
+		// Encode the message to wire format.
+		var buf bytes.Buffer
+		err := test.in.BtcEncode(&buf, test.pver)
+		if err != nil {
+			t.Errorf("BtcEncode #%d error %v", i, err)
+			continue
+		}
+		if test.buf != nil && !bytes.Equal(buf.Bytes(), test.buf) {
+			t.Errorf("BtcEncode #%d - got %s, want: %s", i,
+				spew.Sdump(buf.Bytes()), spew.Sdump(test.buf))
+			continue
+		}
+
+		// Decode the message from wire format.
+		var msg MsgCmpctBlock
+		err = msg.BtcDecode(&buf, test.pver)
+		if err != nil {
+			t.Errorf("BtcDecode #%d error %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(&msg, test.out) {
+			t.Errorf("BtcDecode #%d - got: %s want: %s", i,
+				spew.Sdump(&msg), spew.Sdump(test.out))
+			continue
+		}
+	}
+}
+
+// TestCmpctBlockWireErrors performs negative tests against wire encode and
+// decode of MsgCmpctBlock to confirm error paths work correctly.
+func TestCmpctBlockWireErrors(t *testing.T) {
+	pver := ProtocolVersion
+	pverNoCmpct := CompactBlocksVersion - 1
+
+	headerBytes, err := testBlock.Header.Bytes()
+	if err != nil {
+		t.Fatalf("unable to serialize header: %v", err)
+	}
+	baseMsg := &MsgCmpctBlock{
+		Header:             testBlock.Header,
+		Nonce:              0x0102030405060708,
+		ShortIDs:           []uint64{0x010203040506},
+		StakePrefilledTxns: []PrefilledTx{{Index: 0, Tx: multiTx}},
+	}
+	baseMsgEncoded := append(headerBytes, []byte{
+		0x08, 0x07, 0x06, 0x05, 0x04, 0x03, 0x02, 0x01, // Nonce
+		0x01,                               // Varint for number of short ids
+		0x06, 0x05, 0x04, 0x03, 0x02, 0x01, // Short id 1
+		0x00, // Varint for number of prefilled txns
+		0x00, // Varint for number of stake short ids
+		0x01, // Varint for number of prefilled stake txns
+		0x00, // Prefilled stake txn index
+	}...)
+	baseMsgEncoded = append(baseMsgEncoded, multiTxEncoded...)
+
+	// Message with a prefilled transaction index that exceeds the number of
+	// transactions in the tree.
+	badIndexMsg := &MsgCmpctBlock{
+		Header:             testBlock.Header,
+		StakePrefilledTxns: []PrefilledTx{{Index: 1, Tx: multiTx}},
+	}
+	badIndexMsgEncoded := make([]byte, len(baseMsgEncoded))
+	copy(badIndexMsgEncoded, baseMsgEncoded)
+	badIndexMsgEncoded[len(headerBytes)+18] = 0x01
+
+	// Message with prefilled transaction indexes that are not strictly
+	// increasing.
+	unorderedMsg := &MsgCmpctBlock{
+		Header:   testBlock.Header,
+		ShortIDs: []uint64{1, 2},
+		PrefilledTxns: []PrefilledTx{
+			{Index: 1, Tx: multiTx},
+			{Index: 1, Tx: multiTx},
+		},
+	}
+
+	// Offsets of the various fields in the encoded base message.
+	nonceOffset := len(headerBytes)
+	shortIDsOffset := nonceOffset + 8
+	encodedLen := len(baseMsgEncoded)
+
+	tests := []struct {
+		in       *MsgCmpctBlock // Value to encode
+		buf      []byte         // Wire encoding
+		pver     uint32         // Protocol version for wire encoding
+		max      int            // Max size of fixed buffer to induce errors
+		writeErr error          // Expected write error
+		readErr  error          // Expected read error
+	}{
+		// Force error in header.
+		{baseMsg, baseMsgEncoded, pver, 0, io.ErrShortWrite, io.EOF},
+		// Force error in nonce.
+		{baseMsg, baseMsgEncoded, pver, nonceOffset, io.ErrShortWrite, io.EOF},
+		// Force error in number of short ids.
+		{baseMsg, baseMsgEncoded, pver, shortIDsOffset, io.ErrShortWrite, io.EOF},
+		// Force error in short ids.
+		{baseMsg, baseMsgEncoded, pver, shortIDsOffset + 1, io.ErrShortWrite, io.EOF},
+		// Force error in number of prefilled txns.
+		{baseMsg, baseMsgEncoded, pver, shortIDsOffset + 7, io.ErrShortWrite, io.EOF},
+		// Force error in number of stake short ids.
+		{baseMsg, baseMsgEncoded, pver, shortIDsOffset + 8, io.ErrShortWrite, io.EOF},
+		// Force error in number of prefilled stake txns.
+		{baseMsg, baseMsgEncoded, pver, shortIDsOffset + 9, io.ErrShortWrite, io.EOF},
+		// Force error in prefilled stake txn index.
+		{baseMsg, baseMsgEncoded, pver, shortIDsOffset + 10, io.ErrShortWrite, io.EOF},
+		// Force error in prefilled stake txn.
+		{baseMsg, baseMsgEncoded, pver, shortIDsOffset + 11, io.ErrShortWrite, io.EOF},
+		// Force error due to a prefilled txn index that is out of range.
+		{badIndexMsg, badIndexMsgEncoded, pver, encodedLen, ErrInvalidMsg, ErrInvalidMsg},
+		// Force error due to prefilled txn indexes that are not strictly
+		// increasing.
+		{unorderedMsg, badIndexMsgEncoded, pver, encodedLen * 2, ErrInvalidMsg, ErrInvalidMsg},
+		// Force error due to unsupported protocol version.
+		{baseMsg, baseMsgEncoded, pverNoCmpct, 1000, ErrMsgInvalidForPVer, ErrMsgInvalidForPVer},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		// Encode to wire format.
+		w := newFixedWriter(test.max)
+		err := test.in.BtcEncode(w, test.pver)
+		if !errors.Is(err, test.writeErr) {
+			t.Errorf("BtcEncode #%d wrong error got: %v, want: %v", i, err,
+				test.writeErr)
+			continue
+		}
+
+		// Decode from wire format.
+		var msg MsgCmpctBlock
+		r := newFixedReader(test.max, test.buf)
+		err = msg.BtcDecode(r, test.pver)
+		if !errors.Is(err, test.readErr) {
+			t.Errorf("BtcDecode #%d wrong error got: %v, want: %v", i, err,
+				test.readErr)
+			continue
+		}
+	}
+
+	// Ensure decoding a message with more short IDs than are allowed fails.
+	maxTxPerTree := MaxTxPerTxTree(pver)
+	tooManyEncoded := append([]byte{}, baseMsgEncoded[:shortIDsOffset]...)
+	tooManyEncoded = append(tooManyEncoded, 0xfd, byte(maxTxPerTree+1),
+		byte((maxTxPerTree+1)>>8))
+	var msg MsgCmpctBlock
+	err = msg.BtcDecode(bytes.NewReader(tooManyEncoded), pver)
+	if !errors.Is(err, ErrTooManyTxs) {
+		t.Errorf("BtcDecode wrong error got: %v, want: %v", err,
+			ErrTooManyTxs)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+)
+
+// MaxFilterAddDataSize is the maximum byte size of a data element to add to a
+// bloom filter.  It is equal to the maximum size of a data push in a script.
+const MaxFilterAddDataSize = 2048
+
+// MsgFilterAdd implements the Message interface and represents a filteradd
+// message.  It is used to add a data element to an existing bloom filter.
+//
+// This message was not added until protocol versions starting with
+// BloomFilterVersion and is only served by peers that advertise the
+// SFNodeBloom service flag.
+type MsgFilterAdd struct {
+	Data []byte
+}
+
+// BtcDecode decodes r using the Decred protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgFilterAdd) BtcDecode(r io.Reader, pver uint32) error {
+	const op = "MsgFilterAdd.BtcDecode"
+	if pver < BloomFilterVersion {
+		msg := fmt.Sprintf("filteradd message invalid for protocol "+
+			"version %d", pver)
+		return messageError(op, ErrMsgInvalidForPVer, msg)
+	}
+
+	var err error
+	msg.Data, err = ReadVarBytes(r, pver, MaxFilterAddDataSize,
+		"filteradd data")
+	return err
+}
+
+// BtcEncode encodes the receiver to w using the Decred protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgFilterAdd) BtcEncode(w io.Writer, pver uint32) error {
+	const op = "MsgFilterAdd.BtcEncode"
+	if pver < BloomFilterVersion {
+		msg := fmt.Sprintf("filteradd message invalid for protocol "+
+			"version %d", pver)
+		return messageError(op, ErrMsgInvalidForPVer, msg)
+	}
+
+	size := len(msg.Data)
+	if size > MaxFilterAddDataSize {
+		msg := fmt.Sprintf("filteradd size too large for message "+
+			"[size %v, max %v]", size, MaxFilterAddDataSize)
+		return messageError(op, ErrVarBytesTooLong, msg)
+	}
+
+	return WriteVarBytes(w, pver, msg.Data)
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgFilterAdd) Command() string {
+	return CmdFilterAdd
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgFilterAdd) MaxPayloadLength(pver uint32) uint32 {
+	if pver < BloomFilterVersion {
+		return 0
+	}
+
+	// Num data bytes (varInt) + data.
+	return uint32(VarIntSerializeSize(MaxFilterAddDataSize)) +
+		MaxFilterAddDataSize
+}
+
+// NewMsgFilterAdd returns a new filteradd message that conforms to the Message
+// interface.  See MsgFilterAdd for details.
+func NewMsgFilterAdd(data []byte) *MsgFilterAdd {
+	return &MsgFilterAdd{
+		Data: data,
+	}
+}
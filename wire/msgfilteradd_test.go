@@ -0,0 +1,148 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+// TestFilterAdd tests the MsgFilterAdd API against the latest protocol
+// version.
+func TestFilterAdd(t *testing.T) {
+	pver := ProtocolVersion
+
+	// Ensure the command is expected value.
+	wantCmd := "filteradd"
+	msg := NewMsgFilterAdd([]byte{0x01, 0x02})
+	if cmd := msg.Command(); cmd != wantCmd {
+		t.Errorf("NewMsgFilterAdd: wrong command - got %v want %v", cmd,
+			wantCmd)
+	}
+
+	// Ensure max payload is expected value for latest protocol version.
+	// Num data bytes (varInt) + data.
+	wantPayload := uint32(2051)
+	maxPayload := msg.MaxPayloadLength(pver)
+	if maxPayload != wantPayload {
+		t.Errorf("MaxPayloadLength: wrong max payload length for protocol "+
+			"version %d - got %v, want %v", pver, maxPayload, wantPayload)
+	}
+
+	// Ensure encoding with the max data size returns no error.
+	msg = NewMsgFilterAdd(make([]byte, MaxFilterAddDataSize))
+	var buf bytes.Buffer
+	if err := msg.BtcEncode(&buf, pver); err != nil {
+		t.Fatal(err)
+	}
+
+	// Ensure encoding and decoding with the protocol version prior to
+	// BloomFilterVersion fails.
+	pver = BloomFilterVersion - 1
+	err := msg.BtcEncode(&buf, pver)
+	if !errors.Is(err, ErrMsgInvalidForPVer) {
+		t.Errorf("unexpected error when encoding for protocol version %d - "+
+			"got %v, want %v", pver, err, ErrMsgInvalidForPVer)
+	}
+	var readmsg MsgFilterAdd
+	err = readmsg.BtcDecode(&buf, pver)
+	if !errors.Is(err, ErrMsgInvalidForPVer) {
+		t.Errorf("unexpected error when decoding for protocol version %d - "+
+			"got %v, want %v", pver, err, ErrMsgInvalidForPVer)
+	}
+}
+
+// TestFilterAddWire tests the MsgFilterAdd wire encode and decode.
+func TestFilterAddWire(t *testing.T) {
+	msgFilterAdd := NewMsgFilterAdd([]byte{0x01, 0x02, 0x03, 0x04})
+	msgFilterAddEncoded := []byte{
+		0x04,                   // Varint for data size
+		0x01, 0x02, 0x03, 0x04, // Data
+	}
+
+	// Encode the message to wire format.
+	var buf bytes.Buffer
+	err := msgFilterAdd.BtcEncode(&buf, ProtocolVersion)
+	if err != nil {
+		t.Fatalf("BtcEncode error %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), msgFilterAddEncoded) {
+		t.Fatalf("BtcEncode\n got: %s want: %s", spew.Sdump(buf.Bytes()),
+			spew.Sdump(msgFilterAddEncoded))
+	}
+
+	// Decode the message from wire format.
+	var msg MsgFilterAdd
+	err = msg.BtcDecode(bytes.NewReader(msgFilterAddEncoded), ProtocolVersion)
+	if err != nil {
+		t.Fatalf("BtcDecode error %v", err)
+	}
+	if !reflect.DeepEqual(&msg, msgFilterAdd) {
+		t.Fatalf("BtcDecode\n got: %s want: %s", spew.Sdump(&msg),
+			spew.Sdump(msgFilterAdd))
+	}
+}
+
+// TestFilterAddWireErrors performs negative tests against wire encode and
+// decode of MsgFilterAdd to confirm error paths work correctly.
+func TestFilterAddWireErrors(t *testing.T) {
+	pver := ProtocolVersion
+
+	baseFilterAdd := NewMsgFilterAdd([]byte{0x01, 0x02, 0x03, 0x04})
+	baseFilterAddEncoded := []byte{
+		0x04,                   // Varint for data size
+		0x01, 0x02, 0x03, 0x04, // Data
+	}
+
+	// Message that forces an error by having data that exceeds the max
+	// allowed size.
+	maxSizeFilterAdd := NewMsgFilterAdd(make([]byte, MaxFilterAddDataSize+1))
+	maxSizeFilterAddEncoded := []byte{
+		0xfd, 0x01, 0x08, // Varint for data size
+	}
+
+	tests := []struct {
+		in       *MsgFilterAdd // Value to encode
+		buf      []byte        // Wire encoding
+		pver     uint32        // Protocol version for wire encoding
+		max      int           // Max size of fixed buffer to induce errors
+		writeErr error         // Expected write error
+		readErr  error         // Expected read error
+	}{
+		// Force error in data size.
+		{baseFilterAdd, baseFilterAddEncoded, pver, 0, io.ErrShortWrite, io.EOF},
+		// Force error in data.
+		{baseFilterAdd, baseFilterAddEncoded, pver, 1, io.ErrShortWrite, io.EOF},
+		// Force error with greater than max data size.
+		{maxSizeFilterAdd, maxSizeFilterAddEncoded, pver, 3, ErrVarBytesTooLong, ErrVarBytesTooLong},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		// Encode to wire format.
+		w := newFixedWriter(test.max)
+		err := test.in.BtcEncode(w, test.pver)
+		if !errors.Is(err, test.writeErr) {
+			t.Errorf("BtcEncode #%d wrong error got: %v, want: %v", i, err,
+				test.writeErr)
+			continue
+		}
+
+		// Decode from wire format.
+		var msg MsgFilterAdd
+		r := newFixedReader(test.max, test.buf)
+		err = msg.BtcDecode(r, test.pver)
+		if !errors.Is(err, test.readErr) {
+			t.Errorf("BtcDecode #%d wrong error got: %v, want: %v", i, err,
+				test.readErr)
+			continue
+		}
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// TestFilterClear tests the MsgFilterClear API.
+func TestFilterClear(t *testing.T) {
+	pver := ProtocolVersion
+
+	// Ensure the command is expected value.
+	wantCmd := "filterclear"
+	msg := NewMsgFilterClear()
+	if cmd := msg.Command(); cmd != wantCmd {
+		t.Errorf("NewMsgFilterClear: wrong command - got %v want %v", cmd,
+			wantCmd)
+	}
+
+	// Ensure max payload is expected value.
+	wantPayload := uint32(0)
+	maxPayload := msg.MaxPayloadLength(pver)
+	if maxPayload != wantPayload {
+		t.Errorf("MaxPayloadLength: wrong max payload length for protocol "+
+			"version %d - got %v, want %v", pver, maxPayload, wantPayload)
+	}
+
+	// Ensure the message encodes to nothing and decodes for the latest
+	// protocol version.
+	var buf bytes.Buffer
+	if err := msg.BtcEncode(&buf, pver); err != nil {
+		t.Fatalf("BtcEncode error %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("BtcEncode: unexpected payload length %d", buf.Len())
+	}
+	var readmsg MsgFilterClear
+	if err := readmsg.BtcDecode(&buf, pver); err != nil {
+		t.Fatalf("BtcDecode error %v", err)
+	}
+
+	// Ensure encoding and decoding with the protocol version prior to
+	// BloomFilterVersion fails.
+	pver = BloomFilterVersion - 1
+	err := msg.BtcEncode(&buf, pver)
+	if !errors.Is(err, ErrMsgInvalidForPVer) {
+		t.Errorf("unexpected error when encoding for protocol version %d - "+
+			"got %v, want %v", pver, err, ErrMsgInvalidForPVer)
+	}
+	err = readmsg.BtcDecode(&buf, pver)
+	if !errors.Is(err, ErrMsgInvalidForPVer) {
+		t.Errorf("unexpected error when decoding for protocol version %d - "+
+			"got %v, want %v", pver, err, ErrMsgInvalidForPVer)
+	}
+}
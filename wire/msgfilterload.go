@@ -0,0 +1,149 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+)
+
+// BloomUpdateType specifies how a bloom filter is updated when a transaction
+// output matches it.
+type BloomUpdateType uint8
+
+const (
+	// BloomUpdateNone indicates the filter is not adjusted when a match is
+	// found.
+	BloomUpdateNone BloomUpdateType = 0
+
+	// BloomUpdateAll indicates the outpoint of any output that matches the
+	// filter is added to the filter so that transactions which spend it also
+	// match.
+	BloomUpdateAll BloomUpdateType = 1
+
+	// BloomUpdateP2PubkeyOnly indicates the outpoint of an output that matches
+	// the filter is only added to the filter when the output is a
+	// pay-to-pubkey or bare multisig script.
+	BloomUpdateP2PubkeyOnly BloomUpdateType = 2
+)
+
+const (
+	// MaxFilterLoadHashFuncs is the maximum number of hash functions to load
+	// into a bloom filter.
+	MaxFilterLoadHashFuncs = 50
+
+	// MaxFilterLoadFilterSize is the maximum size in bytes a bloom filter can
+	// be.
+	MaxFilterLoadFilterSize = 36000
+)
+
+// MsgFilterLoad implements the Message interface and represents a filterload
+// message.  It is used to load a bloom filter that the remote peer uses to
+// filter the transactions it relays and the transactions it includes in
+// merkleblock messages.
+//
+// This message was not added until protocol versions starting with
+// BloomFilterVersion and is only served by peers that advertise the
+// SFNodeBloom service flag.
+type MsgFilterLoad struct {
+	Filter    []byte
+	HashFuncs uint32
+	Tweak     uint32
+	Flags     BloomUpdateType
+}
+
+// BtcDecode decodes r using the Decred protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgFilterLoad) BtcDecode(r io.Reader, pver uint32) error {
+	const op = "MsgFilterLoad.BtcDecode"
+	if pver < BloomFilterVersion {
+		msg := fmt.Sprintf("filterload message invalid for protocol "+
+			"version %d", pver)
+		return messageError(op, ErrMsgInvalidForPVer, msg)
+	}
+
+	var err error
+	msg.Filter, err = ReadVarBytes(r, pver, MaxFilterLoadFilterSize,
+		"filterload filter size")
+	if err != nil {
+		return err
+	}
+
+	var flags uint8
+	err = readElements(r, &msg.HashFuncs, &msg.Tweak, &flags)
+	if err != nil {
+		return err
+	}
+	msg.Flags = BloomUpdateType(flags)
+
+	if msg.HashFuncs > MaxFilterLoadHashFuncs {
+		msg := fmt.Sprintf("too many filter hash functions for message "+
+			"[count %v, max %v]", msg.HashFuncs, MaxFilterLoadHashFuncs)
+		return messageError(op, ErrTooManyFilterHashFuncs, msg)
+	}
+
+	return nil
+}
+
+// BtcEncode encodes the receiver to w using the Decred protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgFilterLoad) BtcEncode(w io.Writer, pver uint32) error {
+	const op = "MsgFilterLoad.BtcEncode"
+	if pver < BloomFilterVersion {
+		msg := fmt.Sprintf("filterload message invalid for protocol "+
+			"version %d", pver)
+		return messageError(op, ErrMsgInvalidForPVer, msg)
+	}
+
+	size := len(msg.Filter)
+	if size > MaxFilterLoadFilterSize {
+		msg := fmt.Sprintf("filterload filter size too large for message "+
+			"[size %v, max %v]", size, MaxFilterLoadFilterSize)
+		return messageError(op, ErrFilterTooLarge, msg)
+	}
+
+	if msg.HashFuncs > MaxFilterLoadHashFuncs {
+		msg := fmt.Sprintf("too many filter hash functions for message "+
+			"[count %v, max %v]", msg.HashFuncs, MaxFilterLoadHashFuncs)
+		return messageError(op, ErrTooManyFilterHashFuncs, msg)
+	}
+
+	err := WriteVarBytes(w, pver, msg.Filter)
+	if err != nil {
+		return err
+	}
+
+	return writeElements(w, msg.HashFuncs, msg.Tweak, uint8(msg.Flags))
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgFilterLoad) Command() string {
+	return CmdFilterLoad
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgFilterLoad) MaxPayloadLength(pver uint32) uint32 {
+	if pver < BloomFilterVersion {
+		return 0
+	}
+
+	// Num filter bytes (varInt) + filter + 4 bytes hash funcs +
+	// 4 bytes tweak + 1 byte flags.
+	return uint32(VarIntSerializeSize(MaxFilterLoadFilterSize)) +
+		MaxFilterLoadFilterSize + 9
+}
+
+// NewMsgFilterLoad returns a new filterload message that conforms to the
+// Message interface.  See MsgFilterLoad for details.
+func NewMsgFilterLoad(filter []byte, hashFuncs uint32, tweak uint32, flags BloomUpdateType) *MsgFilterLoad {
+	return &MsgFilterLoad{
+		Filter:    filter,
+		HashFuncs: hashFuncs,
+		Tweak:     tweak,
+		Flags:     flags,
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+// MsgGetBlockTxn implements the Message interface and represents a getblocktxn
+// message.  It is used to request the transactions of a block that could not
+// be reconstructed from a cmpctblock message by their indexes within the
+// regular and stake transaction trees.  The indexes of each tree must be
+// strictly increasing.
+//
+// The remote peer responds with a blocktxn message that contains the requested
+// transactions in the same order.
+//
+// This message was not added until protocol versions starting with
+// CompactBlocksVersion.
+type MsgGetBlockTxn struct {
+	BlockHash    chainhash.Hash
+	Indexes      []uint32
+	StakeIndexes []uint32
+}
+
+// readTxIndexes reads a variable length list of strictly increasing
+// transaction indexes from r while limiting the number of indexes to the
+// provided maximum.
+func readTxIndexes(op string, r io.Reader, pver uint32, maxIndexes uint64) ([]uint32, error) {
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return nil, err
+	}
+	if count > maxIndexes {
+		msg := fmt.Sprintf("too many transaction indexes for message "+
+			"[count %d, max %d]", count, maxIndexes)
+		return nil, messageError(op, ErrTooManyTxs, msg)
+	}
+
+	indexes := make([]uint32, count)
+	for i := uint64(0); i < count; i++ {
+		index, err := ReadVarInt(r, pver)
+		if err != nil {
+			return nil, err
+		}
+		if index >= maxIndexes || (i > 0 && index <= uint64(indexes[i-1])) {
+			msg := fmt.Sprintf("transaction index %d is not strictly "+
+				"increasing or exceeds the max number of transactions %d",
+				index, maxIndexes)
+			return nil, messageError(op, ErrInvalidMsg, msg)
+		}
+		indexes[i] = uint32(index)
+	}
+	return indexes, nil
+}
+
+// writeTxIndexes writes the provided list of transaction indexes to w after
+// ensuring they are strictly increasing and limited to the provided maximum.
+func writeTxIndexes(op string, w io.Writer, pver uint32, maxIndexes uint64, indexes []uint32) error {
+	count := uint64(len(indexes))
+	if count > maxIndexes {
+		msg := fmt.Sprintf("too many transaction indexes for message "+
+			"[count %d, max %d]", count, maxIndexes)
+		return messageError(op, ErrTooManyTxs, msg)
+	}
+
+	err := WriteVarInt(w, pver, count)
+	if err != nil {
+		return err
+	}
+	for i, index := range indexes {
+		if uint64(index) >= maxIndexes || (i > 0 && index <= indexes[i-1]) {
+			msg := fmt.Sprintf("transaction index %d is not strictly "+
+				"increasing or exceeds the max number of transactions %d",
+				index, maxIndexes)
+			return messageError(op, ErrInvalidMsg, msg)
+		}
+		err := WriteVarInt(w, pver, uint64(index))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BtcDecode decodes r using the protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgGetBlockTxn) BtcDecode(r io.Reader, pver uint32) error {
+	const op = "MsgGetBlockTxn.BtcDecode"
+	if pver < CompactBlocksVersion {
+		msg := fmt.Sprintf("%s message invalid for protocol version %d",
+			msg.Command(), pver)
+		return messageError(op, ErrMsgInvalidForPVer, msg)
+	}
+
+	err := readElement(r, &msg.BlockHash)
+	if err != nil {
+		return err
+	}
+
+	maxTxPerTree := MaxTxPerTxTree(pver)
+	msg.Indexes, err = readTxIndexes(op, r, pver, maxTxPerTree)
+	if err != nil {
+		return err
+	}
+	msg.StakeIndexes, err = readTxIndexes(op, r, pver, maxTxPerTree)
+	return err
+}
+
+// BtcEncode encodes the receiver to w using the protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgGetBlockTxn) BtcEncode(w io.Writer, pver uint32) error {
+	const op = "MsgGetBlockTxn.BtcEncode"
+	if pver < CompactBlocksVersion {
+		msg := fmt.Sprintf("%s message invalid for protocol version %d",
+			msg.Command(), pver)
+		return messageError(op, ErrMsgInvalidForPVer, msg)
+	}
+
+	err := writeElement(w, &msg.BlockHash)
+	if err != nil {
+		return err
+	}
+
+	maxTxPerTree := MaxTxPerTxTree(pver)
+	err = writeTxIndexes(op, w, pver, maxTxPerTree, msg.Indexes)
+	if err != nil {
+		return err
+	}
+	return writeTxIndexes(op, w, pver, maxTxPerTree, msg.StakeIndexes)
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgGetBlockTxn) Command() string {
+	return CmdGetBlockTxn
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgGetBlockTxn) MaxPayloadLength(pver uint32) uint32 {
+	if pver < CompactBlocksVersion {
+		return 0
+	}
+
+	// Block hash + num indexes and the indexes for each tree.
+	maxTxPerTree := uint32(MaxTxPerTxTree(pver))
+	return chainhash.HashSize + 2*(MaxVarIntPayload+
+		maxTxPerTree*MaxVarIntPayload)
+}
+
+// NewMsgGetBlockTxn returns a new getblocktxn message that conforms to the
+// Message interface using the provided block hash and transaction indexes.
+// See MsgGetBlockTxn for details.
+func NewMsgGetBlockTxn(blockHash *chainhash.Hash, indexes, stakeIndexes []uint32) *MsgGetBlockTxn {
+	return &MsgGetBlockTxn{
+		BlockHash:    *blockHash,
+		Indexes:      indexes,
+		StakeIndexes: stakeIndexes,
+	}
+}
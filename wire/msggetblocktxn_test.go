@@ -0,0 +1,205 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+// TestGetBlockTxn tests the MsgGetBlockTxn API.
+func TestGetBlockTxn(t *testing.T) {
+	pver := ProtocolVersion
+
+	hash := chainhash.Hash{0x01}
+	msg := NewMsgGetBlockTxn(&hash, []uint32{1, 2}, []uint32{0})
+	if msg.BlockHash != hash {
+		t.Errorf("NewMsgGetBlockTxn: wrong block hash - got %v, want %v",
+			msg.BlockHash, hash)
+	}
+
+	// Ensure the command is expected value.
+	wantCmd := "getblocktxn"
+	if cmd := msg.Command(); cmd != wantCmd {
+		t.Errorf("NewMsgGetBlockTxn: wrong command - got %v want %v",
+			cmd, wantCmd)
+	}
+
+	// Ensure max payload is expected value for latest protocol version.  A
+	// hash and a varint count plus the max number of varint indexes for each
+	// tree.
+	maxTxPerTree := uint32(MaxTxPerTxTree(pver))
+	wantPayload := 32 + 2*(9+maxTxPerTree*9)
+	maxPayload := msg.MaxPayloadLength(pver)
+	if maxPayload != wantPayload {
+		t.Errorf("MaxPayloadLength: wrong max payload length for "+
+			"protocol version %d - got %v, want %v", pver,
+			maxPayload, wantPayload)
+	}
+
+	// Ensure max payload length is not more than MaxMessagePayload.
+	if maxPayload > MaxMessagePayload {
+		t.Fatalf("MaxPayloadLength: payload length (%v) for protocol "+
+			"version %d exceeds MaxMessagePayload (%v).", maxPayload, pver,
+			MaxMessagePayload)
+	}
+}
+
+// TestGetBlockTxnWire tests the MsgGetBlockTxn wire encode and decode.
+func TestGetBlockTxnWire(t *testing.T) {
+	pver := ProtocolVersion
+
+	hash := chainhash.Hash{0x01, 0x02}
+	msg := NewMsgGetBlockTxn(&hash, []uint32{0, 2, 0x1234}, []uint32{})
+	msgEncoded := []byte{
+		0x01, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Block hash
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x03,       // Varint for number of indexes
+		0x00, 0x02, // Indexes 0 and 2
+		0xfd, 0x34, 0x12, // Index 0x1234
+		0x00, // Varint for number of stake indexes
+	}
+
+	tests := []struct {
+		in   *MsgGetBlockTxn // Message to encode
+		out  *MsgGetBlockTxn // Expected decoded message
+		buf  []byte          // Wire encoding
+		pver uint32          // Protocol version for wire encoding
+	}{{
+		in:   msg,
+		out:  msg,
+		buf:  msgEncoded,
+		pver: pver,
+	}, {
+		in:   msg,
+		out:  msg,
+		buf:  msgEncoded,
+		pver: CompactBlocksVersion,
+	}}
+
+	for i, test := range tests {
+		// Encode the message to wire format.
+		var buf bytes.Buffer
+		err := test.in.BtcEncode(&buf, test.pver)
+		if err != nil {
+			t.Errorf("BtcEncode #%d error %v", i, err)
+			continue
+		}
+		if !bytes.Equal(buf.Bytes(), test.buf) {
+			t.Errorf("BtcEncode #%d - got %s, want: %s", i,
+				spew.Sdump(buf.Bytes()), spew.Sdump(test.buf))
+			continue
+		}
+
+		// Decode the message from wire format.
+		var msg MsgGetBlockTxn
+		rbuf := bytes.NewReader(test.buf)
+		err = msg.BtcDecode(rbuf, test.pver)
+		if err != nil {
+			t.Errorf("BtcDecode #%d error %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(&msg, test.out) {
+			t.Errorf("BtcDecode #%d - got: %s want: %s", i,
+				spew.Sdump(&msg), spew.Sdump(test.out))
+			continue
+		}
+	}
+}
+
+// TestGetBlockTxnWireErrors performs negative tests against wire encode and
+// decode of MsgGetBlockTxn to confirm error paths work correctly.
+func TestGetBlockTxnWireErrors(t *testing.T) {
+	pver := ProtocolVersion
+	pverNoCmpct := CompactBlocksVersion - 1
+
+	hash := chainhash.Hash{0x01, 0x02}
+	baseMsg := NewMsgGetBlockTxn(&hash, []uint32{0, 2}, []uint32{1})
+	baseMsgEncoded := []byte{
+		0x01, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Block hash
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x02,       // Varint for number of indexes
+		0x00, 0x02, // Indexes 0 and 2
+		0x01, // Varint for number of stake indexes
+		0x01, // Index 1
+	}
+
+	// Message with indexes that are not strictly increasing.
+	unorderedMsg := NewMsgGetBlockTxn(&hash, []uint32{2, 2}, nil)
+	unorderedMsgEncoded := make([]byte, len(baseMsgEncoded))
+	copy(unorderedMsgEncoded, baseMsgEncoded)
+	unorderedMsgEncoded[33] = 0x02
+
+	// Message with more indexes than are allowed.
+	maxTxPerTree := MaxTxPerTxTree(pver)
+	tooManyEncoded := make([]byte, 0, 35)
+	tooManyEncoded = append(tooManyEncoded, baseMsgEncoded[:32]...)
+	tooManyEncoded = append(tooManyEncoded, 0xfd, byte(maxTxPerTree+1),
+		byte((maxTxPerTree+1)>>8))
+
+	tests := []struct {
+		in       *MsgGetBlockTxn // Value to encode
+		buf      []byte          // Wire encoding
+		pver     uint32          // Protocol version for wire encoding
+		max      int             // Max size of fixed buffer to induce errors
+		writeErr error           // Expected write error
+		readErr  error           // Expected read error
+	}{
+		// Force error in block hash.
+		{baseMsg, baseMsgEncoded, pver, 0, io.ErrShortWrite, io.EOF},
+		// Force error in number of indexes.
+		{baseMsg, baseMsgEncoded, pver, 32, io.ErrShortWrite, io.EOF},
+		// Force error in indexes.
+		{baseMsg, baseMsgEncoded, pver, 33, io.ErrShortWrite, io.EOF},
+		// Force error in number of stake indexes.
+		{baseMsg, baseMsgEncoded, pver, 35, io.ErrShortWrite, io.EOF},
+		// Force error in stake indexes.
+		{baseMsg, baseMsgEncoded, pver, 36, io.ErrShortWrite, io.EOF},
+		// Force error due to indexes that are not strictly increasing.
+		{unorderedMsg, unorderedMsgEncoded, pver, 100, ErrInvalidMsg, ErrInvalidMsg},
+		// Force error due to unsupported protocol version.
+		{baseMsg, baseMsgEncoded, pverNoCmpct, 100, ErrMsgInvalidForPVer, ErrMsgInvalidForPVer},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		// Encode to wire format.
+		w := newFixedWriter(test.max)
+		err := test.in.BtcEncode(w, test.pver)
+		if !errors.Is(err, test.writeErr) {
+			t.Errorf("BtcEncode #%d wrong error got: %v, want: %v", i, err,
+				test.writeErr)
+			continue
+		}
+
+		// Decode from wire format.
+		var msg MsgGetBlockTxn
+		r := newFixedReader(test.max, test.buf)
+		err = msg.BtcDecode(r, test.pver)
+		if !errors.Is(err, test.readErr) {
+			t.Errorf("BtcDecode #%d wrong error got: %v, want: %v", i, err,
+				test.readErr)
+			continue
+		}
+	}
+
+	// Ensure decoding a message with more indexes than are allowed fails.
+	var msg MsgGetBlockTxn
+	err := msg.BtcDecode(bytes.NewReader(tooManyEncoded), pver)
+	if !errors.Is(err, ErrTooManyTxs) {
+		t.Errorf("BtcDecode wrong error got: %v, want: %v", err,
+			ErrTooManyTxs)
+	}
+}
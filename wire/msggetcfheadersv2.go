@@ -0,0 +1,139 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+// MsgGetCFHeadersV2 implements the Message interface and represents a decred
+// getcfhdrsv2 message.  It is used to request the hashes of the version 2
+// committed gcs filters for a range of blocks along with the proofs that can be
+// used to prove each filter hash is committed to by its block header.  The
+// range starts after the last known hash in the slice of block locator hashes.
+// The filter hashes are returned via a cfheadersv2 message (MsgCFHeadersV2) and
+// are limited by a specific hash to stop at or the maximum number of filter
+// hashes per message, which is currently 2000.
+//
+// Set the HashStop field to the hash at which to stop and use
+// AddBlockLocatorHash to build up the list of block locator hashes.  See
+// MsgGetHeaders for details regarding building the block locator hashes.
+//
+// Note that the command is abbreviated since command strings are limited to
+// CommandSize bytes.
+type MsgGetCFHeadersV2 struct {
+	BlockLocatorHashes []*chainhash.Hash
+	HashStop           chainhash.Hash
+}
+
+// AddBlockLocatorHash adds a new block locator hash to the message.
+func (msg *MsgGetCFHeadersV2) AddBlockLocatorHash(hash *chainhash.Hash) error {
+	const op = "MsgGetCFHeadersV2.AddBlockLocatorHash"
+	if len(msg.BlockLocatorHashes)+1 > MaxBlockLocatorsPerMsg {
+		msg := fmt.Sprintf("too many block locator hashes for message [max %v]",
+			MaxBlockLocatorsPerMsg)
+		return messageError(op, ErrTooManyLocators, msg)
+	}
+
+	msg.BlockLocatorHashes = append(msg.BlockLocatorHashes, hash)
+	return nil
+}
+
+// BtcDecode decodes r using the Decred protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgGetCFHeadersV2) BtcDecode(r io.Reader, pver uint32) error {
+	const op = "MsgGetCFHeadersV2.BtcDecode"
+	if pver < CFHeadersV2Version {
+		msg := fmt.Sprintf("%s message invalid for protocol version %d",
+			msg.Command(), pver)
+		return messageError(op, ErrMsgInvalidForPVer, msg)
+	}
+
+	// Read num block locator hashes and limit to max.
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return err
+	}
+	if count > MaxBlockLocatorsPerMsg {
+		msg := fmt.Sprintf("too many block locator hashes for message "+
+			"[count %v, max %v]", count, MaxBlockLocatorsPerMsg)
+		return messageError(op, ErrTooManyLocators, msg)
+	}
+
+	// Create a contiguous slice of hashes to deserialize into in order to
+	// reduce the number of allocations.
+	locatorHashes := make([]chainhash.Hash, count)
+	msg.BlockLocatorHashes = make([]*chainhash.Hash, 0, count)
+	for i := uint64(0); i < count; i++ {
+		hash := &locatorHashes[i]
+		err := readElement(r, hash)
+		if err != nil {
+			return err
+		}
+		msg.BlockLocatorHashes = append(msg.BlockLocatorHashes, hash)
+	}
+
+	return readElement(r, &msg.HashStop)
+}
+
+// BtcEncode encodes the receiver to w using the Decred protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgGetCFHeadersV2) BtcEncode(w io.Writer, pver uint32) error {
+	const op = "MsgGetCFHeadersV2.BtcEncode"
+	if pver < CFHeadersV2Version {
+		msg := fmt.Sprintf("%s message invalid for protocol version %d",
+			msg.Command(), pver)
+		return messageError(op, ErrMsgInvalidForPVer, msg)
+	}
+
+	// Limit to max block locator hashes per message.
+	count := len(msg.BlockLocatorHashes)
+	if count > MaxBlockLocatorsPerMsg {
+		msg := fmt.Sprintf("too many block locator hashes for message "+
+			"[count %v, max %v]", count, MaxBlockLocatorsPerMsg)
+		return messageError(op, ErrTooManyLocators, msg)
+	}
+
+	err := WriteVarInt(w, pver, uint64(count))
+	if err != nil {
+		return err
+	}
+
+	for _, hash := range msg.BlockLocatorHashes {
+		err := writeElement(w, hash)
+		if err != nil {
+			return err
+		}
+	}
+
+	return writeElement(w, &msg.HashStop)
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgGetCFHeadersV2) Command() string {
+	return CmdGetCFHeadersV2
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgGetCFHeadersV2) MaxPayloadLength(pver uint32) uint32 {
+	// Num block locator hashes (varInt) + max allowed block locators + hash
+	// stop.
+	return uint32(VarIntSerializeSize(MaxBlockLocatorsPerMsg)) +
+		(MaxBlockLocatorsPerMsg * chainhash.HashSize) + chainhash.HashSize
+}
+
+// NewMsgGetCFHeadersV2 returns a new Decred getcfhdrsv2 message that conforms
+// to the Message interface.  See MsgGetCFHeadersV2 for details.
+func NewMsgGetCFHeadersV2() *MsgGetCFHeadersV2 {
+	return &MsgGetCFHeadersV2{
+		BlockLocatorHashes: make([]*chainhash.Hash, 0,
+			MaxBlockLocatorsPerMsg),
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+// baseMsgGetCFHeadersV2 returns a MsgGetCFHeadersV2 populated with mock values
+// that are used throughout the tests along with its expected encoding.
+func baseMsgGetCFHeadersV2() (*MsgGetCFHeadersV2, []byte) {
+	msg := NewMsgGetCFHeadersV2()
+	msg.AddBlockLocatorHash(&chainhash.Hash{0x01})
+	msg.AddBlockLocatorHash(&chainhash.Hash{0x02})
+	msg.HashStop = chainhash.Hash{0x03}
+
+	encoded := []byte{
+		0x02, // Varint for number of block locator hashes
+		0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // First locator hash
+		0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Second locator hash
+		0x03, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Hash stop
+	}
+	return msg, encoded
+}
+
+// TestGetCFHeadersV2 tests the MsgGetCFHeadersV2 API.
+func TestGetCFHeadersV2(t *testing.T) {
+	pver := ProtocolVersion
+
+	// Ensure the command is expected value.
+	wantCmd := "getcfhdrsv2"
+	msg := NewMsgGetCFHeadersV2()
+	if cmd := msg.Command(); cmd != wantCmd {
+		t.Errorf("NewMsgGetCFHeadersV2: wrong command - got %v want %v", cmd,
+			wantCmd)
+	}
+
+	// Ensure max payload is expected value for latest protocol version.
+	// Num locator hashes (varInt) 3 bytes + max allowed locator hashes + hash
+	// stop.
+	wantPayload := uint32(3 + MaxBlockLocatorsPerMsg*chainhash.HashSize +
+		chainhash.HashSize)
+	maxPayload := msg.MaxPayloadLength(pver)
+	if maxPayload != wantPayload {
+		t.Errorf("MaxPayloadLength: wrong max payload length for protocol "+
+			"version %d - got %v, want %v", pver, maxPayload, wantPayload)
+	}
+
+	// Ensure block locator hashes are added properly and adding more than the
+	// max allowed fails.
+	for i := 0; i < MaxBlockLocatorsPerMsg; i++ {
+		if err := msg.AddBlockLocatorHash(&chainhash.Hash{}); err != nil {
+			t.Fatalf("AddBlockLocatorHash: unexpected error: %v", err)
+		}
+	}
+	err := msg.AddBlockLocatorHash(&chainhash.Hash{})
+	if !errors.Is(err, ErrTooManyLocators) {
+		t.Errorf("AddBlockLocatorHash: wrong error - got %v, want %v", err,
+			ErrTooManyLocators)
+	}
+
+	// Ensure encoding and decoding with the protocol version prior to
+	// CFHeadersV2Version fails.
+	pver = CFHeadersV2Version - 1
+	var buf bytes.Buffer
+	err = msg.BtcEncode(&buf, pver)
+	if !errors.Is(err, ErrMsgInvalidForPVer) {
+		t.Errorf("unexpected error when encoding for protocol version %d - "+
+			"got %v, want %v", pver, err, ErrMsgInvalidForPVer)
+	}
+	var readmsg MsgGetCFHeadersV2
+	err = readmsg.BtcDecode(&buf, pver)
+	if !errors.Is(err, ErrMsgInvalidForPVer) {
+		t.Errorf("unexpected error when decoding for protocol version %d - "+
+			"got %v, want %v", pver, err, ErrMsgInvalidForPVer)
+	}
+}
+
+// TestGetCFHeadersV2Wire tests the MsgGetCFHeadersV2 wire encode and decode
+// for various protocol versions.
+func TestGetCFHeadersV2Wire(t *testing.T) {
+	msgGetCFHeaders, msgGetCFHeadersEncoded := baseMsgGetCFHeadersV2()
+	noLocators := NewMsgGetCFHeadersV2()
+	noLocatorsEncoded := append([]byte{0x00}, msgGetCFHeadersEncoded[65:]...)
+	noLocators.HashStop = msgGetCFHeaders.HashStop
+
+	tests := []struct {
+		in   *MsgGetCFHeadersV2 // Message to encode
+		out  *MsgGetCFHeadersV2 // Expected decoded message
+		buf  []byte             // Wire encoding
+		pver uint32             // Protocol version for wire encoding
+	}{
+		{msgGetCFHeaders, msgGetCFHeaders, msgGetCFHeadersEncoded, ProtocolVersion},
+		{msgGetCFHeaders, msgGetCFHeaders, msgGetCFHeadersEncoded, CFHeadersV2Version},
+		{noLocators, noLocators, noLocatorsEncoded, ProtocolVersion},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		// Encode the message to wire format.
+		var buf bytes.Buffer
+		err := test.in.BtcEncode(&buf, test.pver)
+		if err != nil {
+			t.Errorf("BtcEncode #%d error %v", i, err)
+			continue
+		}
+		if !bytes.Equal(buf.Bytes(), test.buf) {
+			t.Errorf("BtcEncode #%d\n got: %s want: %s", i,
+				spew.Sdump(buf.Bytes()), spew.Sdump(test.buf))
+			continue
+		}
+
+		// Decode the message from wire format.
+		var msg MsgGetCFHeadersV2
+		rbuf := bytes.NewReader(test.buf)
+		err = msg.BtcDecode(rbuf, test.pver)
+		if err != nil {
+			t.Errorf("BtcDecode #%d error %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(&msg, test.out) {
+			t.Errorf("BtcDecode #%d\n got: %s want: %s", i,
+				spew.Sdump(&msg), spew.Sdump(test.out))
+			continue
+		}
+	}
+}
+
+// TestGetCFHeadersV2WireErrors performs negative tests against wire encode and
+// decode of MsgGetCFHeadersV2 to confirm error paths work correctly.
+func TestGetCFHeadersV2WireErrors(t *testing.T) {
+	pver := ProtocolVersion
+
+	baseGetCFHeaders, baseGetCFHeadersEncoded := baseMsgGetCFHeadersV2()
+
+	// Message that forces an error by having more than the max allowed block
+	// locator hashes.
+	maxLocators, _ := baseMsgGetCFHeadersV2()
+	for i := 0; i < MaxBlockLocatorsPerMsg-1; i++ {
+		maxLocators.BlockLocatorHashes = append(maxLocators.BlockLocatorHashes,
+			&chainhash.Hash{})
+	}
+	maxLocatorsEncoded := []byte{
+		0xfd, 0xf5, 0x01, // Varint for number of block locator hashes (501)
+	}
+
+	tests := []struct {
+		in       *MsgGetCFHeadersV2 // Value to encode
+		buf      []byte             // Wire encoding
+		pver     uint32             // Protocol version for wire encoding
+		max      int                // Max size of fixed buffer to induce errors
+		writeErr error              // Expected write error
+		readErr  error              // Expected read error
+	}{
+		// Force error in num block locator hashes.
+		{baseGetCFHeaders, baseGetCFHeadersEncoded, pver, 0, io.ErrShortWrite, io.EOF},
+		// Force error in first block locator hash.
+		{baseGetCFHeaders, baseGetCFHeadersEncoded, pver, 1, io.ErrShortWrite, io.EOF},
+		// Force error in middle of second block locator hash.
+		{baseGetCFHeaders, baseGetCFHeadersEncoded, pver, 40, io.ErrShortWrite, io.ErrUnexpectedEOF},
+		// Force error in hash stop.
+		{baseGetCFHeaders, baseGetCFHeadersEncoded, pver, 65, io.ErrShortWrite, io.EOF},
+		// Force error with greater than max block locator hashes.
+		{maxLocators, maxLocatorsEncoded, pver, 3, ErrTooManyLocators, ErrTooManyLocators},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		// Encode to wire format.
+		w := newFixedWriter(test.max)
+		err := test.in.BtcEncode(w, test.pver)
+		if !errors.Is(err, test.writeErr) {
+			t.Errorf("BtcEncode #%d wrong error got: %v, want: %v", i, err,
+				test.writeErr)
+			continue
+		}
+
+		// Decode from wire format.
+		var msg MsgGetCFHeadersV2
+		r := newFixedReader(test.max, test.buf)
+		err = msg.BtcDecode(r, test.pver)
+		if !errors.Is(err, test.readErr) {
+			t.Errorf("BtcDecode #%d wrong error got: %v, want: %v", i, err,
+				test.readErr)
+			continue
+		}
+	}
+}
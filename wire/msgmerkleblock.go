@@ -0,0 +1,268 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+// MaxTxProofHashes is the maximum number of merkle tree inclusion proof hashes
+// that can be in a single transaction inclusion proof.  It is based on the
+// fact that the proofs are logarithmic in nature and hence a value of 32
+// supports proofs for trees of up to 2^32 transactions.
+const MaxTxProofHashes = 32
+
+// TxInclusionProof houses a merkle tree inclusion proof for a transaction in a
+// merkleblock message.
+//
+// The Tree field specifies which transaction tree the transaction belongs to
+// and is either TxTreeRegular or TxTreeStake.  The Hash field is the full hash
+// of the transaction (including witness data), which is the leaf of the
+// transaction tree, and the Index field is its position within the tree.
+type TxInclusionProof struct {
+	Tree        int8
+	Index       uint32
+	Hash        chainhash.Hash
+	ProofHashes []chainhash.Hash
+}
+
+// MsgMerkleBlock implements the Message interface and represents a merkleblock
+// message.  It is used to deliver a block header along with merkle tree
+// inclusion proofs for the transactions in the block that match the bloom
+// filter loaded by the requesting peer.  The matching transactions themselves
+// are sent via separate tx messages which immediately follow it.
+//
+// Each proof may be used to recalculate the root of the respective transaction
+// tree.  The stake tree root is committed to directly by the header, while the
+// regular tree root is committed to either directly or, once the vote to
+// enable header commitments is active, as part of the combined merkle root of
+// both trees.
+//
+// It is delivered in response to a getdata message (MsgGetData) for an
+// inventory vector with the InvTypeFilteredBlock type.  This message was not
+// added until protocol versions starting with BloomFilterVersion.
+type MsgMerkleBlock struct {
+	Header        BlockHeader
+	Transactions  uint32
+	STransactions uint32
+	Proofs        []TxInclusionProof
+}
+
+// AddProof adds a transaction inclusion proof to the message.
+func (msg *MsgMerkleBlock) AddProof(proof TxInclusionProof) {
+	msg.Proofs = append(msg.Proofs, proof)
+}
+
+// checkTxCounts returns an error when the number of transactions in either
+// tree exceeds the maximum possible.
+func (msg *MsgMerkleBlock) checkTxCounts(op string, pver uint32) error {
+	maxTxPerTree := MaxTxPerTxTree(pver)
+	if uint64(msg.Transactions) > maxTxPerTree {
+		msg := fmt.Sprintf("too many transactions for message "+
+			"[count %d, max %d]", msg.Transactions, maxTxPerTree)
+		return messageError(op, ErrTooManyTxs, msg)
+	}
+	if uint64(msg.STransactions) > maxTxPerTree {
+		msg := fmt.Sprintf("too many stake transactions for message "+
+			"[count %d, max %d]", msg.STransactions, maxTxPerTree)
+		return messageError(op, ErrTooManyTxs, msg)
+	}
+	return nil
+}
+
+// checkProof returns an error when the provided proof does not refer to a
+// transaction within the transaction trees described by the message.
+func (msg *MsgMerkleBlock) checkProof(op string, proof *TxInclusionProof) error {
+	var numTxns uint32
+	switch proof.Tree {
+	case TxTreeRegular:
+		numTxns = msg.Transactions
+	case TxTreeStake:
+		numTxns = msg.STransactions
+	default:
+		msg := fmt.Sprintf("invalid transaction tree %d for proof",
+			proof.Tree)
+		return messageError(op, ErrInvalidMsg, msg)
+	}
+	if proof.Index >= numTxns {
+		msg := fmt.Sprintf("proof index %d out of range for tree %d "+
+			"with %d transactions", proof.Index, proof.Tree, numTxns)
+		return messageError(op, ErrInvalidMsg, msg)
+	}
+	return nil
+}
+
+// BtcDecode decodes r using the Decred protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgMerkleBlock) BtcDecode(r io.Reader, pver uint32) error {
+	const op = "MsgMerkleBlock.BtcDecode"
+	if pver < BloomFilterVersion {
+		msg := fmt.Sprintf("merkleblock message invalid for protocol "+
+			"version %d", pver)
+		return messageError(op, ErrMsgInvalidForPVer, msg)
+	}
+
+	err := readBlockHeader(r, pver, &msg.Header)
+	if err != nil {
+		return err
+	}
+
+	err = readElements(r, &msg.Transactions, &msg.STransactions)
+	if err != nil {
+		return err
+	}
+	if err := msg.checkTxCounts(op, pver); err != nil {
+		return err
+	}
+
+	// Read num proofs and limit to the max possible number of transactions.
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return err
+	}
+	maxProofs := uint64(msg.Transactions) + uint64(msg.STransactions)
+	if count > maxProofs {
+		msg := fmt.Sprintf("too many proofs for message [count %v, max %v]",
+			count, maxProofs)
+		return messageError(op, ErrTooManyProofs, msg)
+	}
+
+	msg.Proofs = make([]TxInclusionProof, count)
+	for i := uint64(0); i < count; i++ {
+		proof := &msg.Proofs[i]
+		var tree uint8
+		err := readElements(r, &tree, &proof.Index, &proof.Hash)
+		if err != nil {
+			return err
+		}
+		proof.Tree = int8(tree)
+		if err := msg.checkProof(op, proof); err != nil {
+			return err
+		}
+
+		// Read num proof hashes and limit to max.
+		numHashes, err := ReadVarInt(r, pver)
+		if err != nil {
+			return err
+		}
+		if numHashes > MaxTxProofHashes {
+			msg := fmt.Sprintf("too many proof hashes for message "+
+				"[count %v, max %v]", numHashes, MaxTxProofHashes)
+			return messageError(op, ErrTooManyProofs, msg)
+		}
+		proof.ProofHashes = make([]chainhash.Hash, numHashes)
+		for j := uint64(0); j < numHashes; j++ {
+			err := readElement(r, &proof.ProofHashes[j])
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// BtcEncode encodes the receiver to w using the Decred protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgMerkleBlock) BtcEncode(w io.Writer, pver uint32) error {
+	const op = "MsgMerkleBlock.BtcEncode"
+	if pver < BloomFilterVersion {
+		msg := fmt.Sprintf("merkleblock message invalid for protocol "+
+			"version %d", pver)
+		return messageError(op, ErrMsgInvalidForPVer, msg)
+	}
+
+	if err := msg.checkTxCounts(op, pver); err != nil {
+		return err
+	}
+	numProofs := len(msg.Proofs)
+	maxProofs := uint64(msg.Transactions) + uint64(msg.STransactions)
+	if uint64(numProofs) > maxProofs {
+		msg := fmt.Sprintf("too many proofs for message [count %v, max %v]",
+			numProofs, maxProofs)
+		return messageError(op, ErrTooManyProofs, msg)
+	}
+	for i := range msg.Proofs {
+		proof := &msg.Proofs[i]
+		if err := msg.checkProof(op, proof); err != nil {
+			return err
+		}
+		numHashes := len(proof.ProofHashes)
+		if numHashes > MaxTxProofHashes {
+			msg := fmt.Sprintf("too many proof hashes for message "+
+				"[count %v, max %v]", numHashes, MaxTxProofHashes)
+			return messageError(op, ErrTooManyProofs, msg)
+		}
+	}
+
+	err := writeBlockHeader(w, pver, &msg.Header)
+	if err != nil {
+		return err
+	}
+
+	err = writeElements(w, msg.Transactions, msg.STransactions)
+	if err != nil {
+		return err
+	}
+
+	err = WriteVarInt(w, pver, uint64(numProofs))
+	if err != nil {
+		return err
+	}
+	for i := range msg.Proofs {
+		proof := &msg.Proofs[i]
+		err := writeElements(w, uint8(proof.Tree), proof.Index, &proof.Hash)
+		if err != nil {
+			return err
+		}
+		err = WriteVarInt(w, pver, uint64(len(proof.ProofHashes)))
+		if err != nil {
+			return err
+		}
+		for j := range proof.ProofHashes {
+			err := writeElement(w, &proof.ProofHashes[j])
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgMerkleBlock) Command() string {
+	return CmdMerkleBlock
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgMerkleBlock) MaxPayloadLength(pver uint32) uint32 {
+	if pver < BloomFilterVersion {
+		return 0
+	}
+
+	// The number of proofs is limited by the number of transactions in the
+	// block, however, proofs for every transaction in a maximum size block
+	// would exceed the maximum message payload, so it is limited by that
+	// instead.
+	return MaxMessagePayload
+}
+
+// NewMsgMerkleBlock returns a new merkleblock message that conforms to the
+// Message interface using the provided block header and transaction counts.
+// See MsgMerkleBlock for details.
+func NewMsgMerkleBlock(bh *BlockHeader, numTxns, numSTxns uint32) *MsgMerkleBlock {
+	return &MsgMerkleBlock{
+		Header:        *bh,
+		Transactions:  numTxns,
+		STransactions: numSTxns,
+		Proofs:        make([]TxInclusionProof, 0),
+	}
+}
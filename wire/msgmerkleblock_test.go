@@ -0,0 +1,289 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+// baseMsgMerkleBlock returns a MsgMerkleBlock populated with mock values that
+// are used throughout the tests along with its expected encoding.
+func baseMsgMerkleBlock(t *testing.T) (*MsgMerkleBlock, []byte) {
+	t.Helper()
+
+	headerBytes, err := testBlock.Header.Bytes()
+	if err != nil {
+		t.Fatalf("unable to serialize header: %v", err)
+	}
+	msg := NewMsgMerkleBlock(&testBlock.Header, 2, 1)
+	msg.AddProof(TxInclusionProof{
+		Tree:        TxTreeRegular,
+		Index:       1,
+		Hash:        chainhash.Hash{0x01},
+		ProofHashes: []chainhash.Hash{{0x02}},
+	})
+	msg.AddProof(TxInclusionProof{
+		Tree:        TxTreeStake,
+		Index:       0,
+		Hash:        chainhash.Hash{0x03},
+		ProofHashes: []chainhash.Hash{},
+	})
+
+	encoded := append(headerBytes, []byte{
+		0x02, 0x00, 0x00, 0x00, // Num regular transactions
+		0x01, 0x00, 0x00, 0x00, // Num stake transactions
+		0x02,                   // Varint for num proofs
+		0x00,                   // Tree of first proof
+		0x01, 0x00, 0x00, 0x00, // Index of first proof
+		0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Hash of first proof
+		0x01, // Varint for num proof hashes of first proof
+		0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Proof hash
+		0x01,                   // Tree of second proof
+		0x00, 0x00, 0x00, 0x00, // Index of second proof
+		0x03, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Hash of second proof
+		0x00, // Varint for num proof hashes of second proof
+	}...)
+	return msg, encoded
+}
+
+// TestMerkleBlock tests the MsgMerkleBlock API.
+func TestMerkleBlock(t *testing.T) {
+	pver := ProtocolVersion
+
+	// Ensure the command is expected value.
+	wantCmd := "merkleblock"
+	msg, _ := baseMsgMerkleBlock(t)
+	if cmd := msg.Command(); cmd != wantCmd {
+		t.Errorf("NewMsgMerkleBlock: wrong command - got %v want %v", cmd,
+			wantCmd)
+	}
+
+	// Ensure max payload is expected value for latest protocol version.
+	wantPayload := uint32(MaxMessagePayload)
+	maxPayload := msg.MaxPayloadLength(pver)
+	if maxPayload != wantPayload {
+		t.Errorf("MaxPayloadLength: wrong max payload length for protocol "+
+			"version %d - got %v, want %v", pver, maxPayload, wantPayload)
+	}
+
+	// Ensure encoding with max proof hashes returns no error.
+	msg.Proofs[0].ProofHashes = make([]chainhash.Hash, MaxTxProofHashes)
+	var buf bytes.Buffer
+	if err := msg.BtcEncode(&buf, pver); err != nil {
+		t.Fatal(err)
+	}
+
+	// Ensure encoding and decoding with the protocol version prior to
+	// BloomFilterVersion fails.
+	pver = BloomFilterVersion - 1
+	err := msg.BtcEncode(&buf, pver)
+	if !errors.Is(err, ErrMsgInvalidForPVer) {
+		t.Errorf("unexpected error when encoding for protocol version %d - "+
+			"got %v, want %v", pver, err, ErrMsgInvalidForPVer)
+	}
+	var readmsg MsgMerkleBlock
+	err = readmsg.BtcDecode(&buf, pver)
+	if !errors.Is(err, ErrMsgInvalidForPVer) {
+		t.Errorf("unexpected error when decoding for protocol version %d - "+
+			"got %v, want %v", pver, err, ErrMsgInvalidForPVer)
+	}
+	if maxPayload := msg.MaxPayloadLength(pver); maxPayload != 0 {
+		t.Errorf("MaxPayloadLength: wrong max payload length for protocol "+
+			"version %d - got %v, want 0", pver, maxPayload)
+	}
+}
+
+// TestMerkleBlockWire tests the MsgMerkleBlock wire encode and decode for
+// various protocol versions.
+func TestMerkleBlockWire(t *testing.T) {
+	msgMerkleBlock, msgMerkleBlockEncoded := baseMsgMerkleBlock(t)
+	noProofs := NewMsgMerkleBlock(&testBlock.Header, 0, 0)
+	noProofsEncoded := append(msgMerkleBlockEncoded[:MaxBlockHeaderPayload:MaxBlockHeaderPayload],
+		[]byte{
+			0x00, 0x00, 0x00, 0x00, // Num regular transactions
+			0x00, 0x00, 0x00, 0x00, // Num stake transactions
+			0x00, // Varint for num proofs
+		}...)
+
+	tests := []struct {
+		in   *MsgMerkleBlock // Message to encode
+		out  *MsgMerkleBlock // Expected decoded message
+		buf  []byte          // Wire encoding
+		pver uint32          // Protocol version for wire encoding
+	}{
+		{msgMerkleBlock, msgMerkleBlock, msgMerkleBlockEncoded, ProtocolVersion},
+		{msgMerkleBlock, msgMerkleBlock, msgMerkleBlockEncoded, BloomFilterVersion},
+		{noProofs, noProofs, noProofsEncoded, ProtocolVersion},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		// Encode the message to wire format.
+		var buf bytes.Buffer
+		err := test.in.BtcEncode(&buf, test.pver)
+		if err != nil {
+			t.Errorf("BtcEncode #%d error %v", i, err)
+			continue
+		}
+		if !bytes.Equal(buf.Bytes(), test.buf) {
+			t.Errorf("BtcEncode #%d\n got: %s want: %s", i,
+				spew.Sdump(buf.Bytes()), spew.Sdump(test.buf))
+			continue
+		}
+
+		// Decode the message from wire format.
+		var msg MsgMerkleBlock
+		rbuf := bytes.NewReader(test.buf)
+		err = msg.BtcDecode(rbuf, test.pver)
+		if err != nil {
+			t.Errorf("BtcDecode #%d error %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(&msg, test.out) {
+			t.Errorf("BtcDecode #%d\n got: %s want: %s", i,
+				spew.Sdump(&msg), spew.Sdump(test.out))
+			continue
+		}
+	}
+}
+
+// TestMerkleBlockWireErrors performs negative tests against wire encode and
+// decode of MsgMerkleBlock to confirm error paths work correctly.
+func TestMerkleBlockWireErrors(t *testing.T) {
+	pver := ProtocolVersion
+	const hdrSize = MaxBlockHeaderPayload
+
+	baseMerkleBlock, baseMerkleBlockEncoded := baseMsgMerkleBlock(t)
+
+	// modified returns a copy of the base message modified by the provided
+	// function along with a copy of its encoding modified by the provided
+	// function.
+	modified := func(modifyMsg func(*MsgMerkleBlock), modifyBuf func([]byte)) (*MsgMerkleBlock, []byte) {
+		msg, buf := baseMsgMerkleBlock(t)
+		modifyMsg(msg)
+		modifyBuf(buf)
+		return msg, buf
+	}
+
+	// Message that forces an error by having more transactions than the max
+	// allowed.
+	maxTxPerTree := uint32(MaxTxPerTxTree(pver))
+	maxTxs, maxTxsEncoded := modified(func(msg *MsgMerkleBlock) {
+		msg.Transactions = maxTxPerTree + 1
+	}, func(buf []byte) {
+		buf[hdrSize] = byte(maxTxPerTree + 1)
+		buf[hdrSize+1] = byte((maxTxPerTree + 1) >> 8)
+	})
+
+	// Message that forces an error by having more proofs than transactions.
+	maxProofs, maxProofsEncoded := modified(func(msg *MsgMerkleBlock) {
+		msg.Transactions = 1
+		msg.STransactions = 0
+	}, func(buf []byte) {
+		buf[hdrSize] = 0x01
+		buf[hdrSize+4] = 0x00
+	})
+
+	// Message that forces an error by having a proof with an invalid tree.
+	badTree, badTreeEncoded := modified(func(msg *MsgMerkleBlock) {
+		msg.Proofs[0].Tree = 2
+	}, func(buf []byte) {
+		buf[hdrSize+9] = 0x02
+	})
+
+	// Message that forces an error by having a proof with an out of range
+	// index.
+	badIndex, badIndexEncoded := modified(func(msg *MsgMerkleBlock) {
+		msg.Proofs[0].Index = 2
+	}, func(buf []byte) {
+		buf[hdrSize+10] = 0x02
+	})
+
+	// Message that forces an error by having more than the max allowed proof
+	// hashes.
+	maxHashes, maxHashesEncoded := modified(func(msg *MsgMerkleBlock) {
+		msg.Proofs[0].ProofHashes = make([]chainhash.Hash, MaxTxProofHashes+1)
+	}, func(buf []byte) {
+		buf[hdrSize+46] = MaxTxProofHashes + 1
+	})
+
+	tests := []struct {
+		in       *MsgMerkleBlock // Value to encode
+		buf      []byte          // Wire encoding
+		pver     uint32          // Protocol version for wire encoding
+		max      int             // Max size of fixed buffer to induce errors
+		writeErr error           // Expected write error
+		readErr  error           // Expected read error
+	}{
+		// Force error in header.
+		{baseMerkleBlock, baseMerkleBlockEncoded, pver, 0, io.ErrShortWrite, io.EOF},
+		// Force error in num regular transactions.
+		{baseMerkleBlock, baseMerkleBlockEncoded, pver, hdrSize, io.ErrShortWrite, io.EOF},
+		// Force error in num stake transactions.
+		{baseMerkleBlock, baseMerkleBlockEncoded, pver, hdrSize + 4, io.ErrShortWrite, io.EOF},
+		// Force error in num proofs.
+		{baseMerkleBlock, baseMerkleBlockEncoded, pver, hdrSize + 8, io.ErrShortWrite, io.EOF},
+		// Force error in tree of first proof.
+		{baseMerkleBlock, baseMerkleBlockEncoded, pver, hdrSize + 9, io.ErrShortWrite, io.EOF},
+		// Force error in index of first proof.
+		{baseMerkleBlock, baseMerkleBlockEncoded, pver, hdrSize + 10, io.ErrShortWrite, io.EOF},
+		// Force error in hash of first proof.
+		{baseMerkleBlock, baseMerkleBlockEncoded, pver, hdrSize + 14, io.ErrShortWrite, io.EOF},
+		// Force error in num proof hashes of first proof.
+		{baseMerkleBlock, baseMerkleBlockEncoded, pver, hdrSize + 46, io.ErrShortWrite, io.EOF},
+		// Force error in middle of first proof hash.
+		{baseMerkleBlock, baseMerkleBlockEncoded, pver, hdrSize + 55, io.ErrShortWrite, io.ErrUnexpectedEOF},
+		// Force error in second proof.
+		{baseMerkleBlock, baseMerkleBlockEncoded, pver, hdrSize + 79, io.ErrShortWrite, io.EOF},
+		// Force error with greater than max transactions.
+		{maxTxs, maxTxsEncoded, pver, len(maxTxsEncoded), ErrTooManyTxs, ErrTooManyTxs},
+		// Force error with more proofs than transactions.
+		{maxProofs, maxProofsEncoded, pver, len(maxProofsEncoded), ErrTooManyProofs, ErrTooManyProofs},
+		// Force error with an invalid tree.
+		{badTree, badTreeEncoded, pver, len(badTreeEncoded), ErrInvalidMsg, ErrInvalidMsg},
+		// Force error with an out of range proof index.
+		{badIndex, badIndexEncoded, pver, len(badIndexEncoded), ErrInvalidMsg, ErrInvalidMsg},
+		// Force error with greater than max proof hashes.
+		{maxHashes, maxHashesEncoded, pver, len(maxHashesEncoded), ErrTooManyProofs, ErrTooManyProofs},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		// Encode to wire format.
+		w := newFixedWriter(test.max)
+		err := test.in.BtcEncode(w, test.pver)
+		if !errors.Is(err, test.writeErr) {
+			t.Errorf("BtcEncode #%d wrong error got: %v, want: %v", i, err,
+				test.writeErr)
+			continue
+		}
+
+		// Decode from wire format.
+		var msg MsgMerkleBlock
+		r := newFixedReader(test.max, test.buf)
+		err = msg.BtcDecode(r, test.pver)
+		if !errors.Is(err, test.readErr) {
+			t.Errorf("BtcDecode #%d wrong error got: %v, want: %v", i, err,
+				test.readErr)
+			continue
+		}
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// MaxNetAddressV2Size is the maximum number of bytes allowed for the address of
+// a NetAddressV2 regardless of its network.
+const MaxNetAddressV2Size = 512
+
+// NetworkID identifies the network a NetAddressV2 belongs to and therefore how
+// its address is to be interpreted.
+type NetworkID uint8
+
+// These constants define the network IDs that are currently known.  Addresses
+// with other network IDs are still decoded so they may be ignored by callers
+// that do not support them.
+const (
+	// NetIDIPv4 identifies an IPv4 address which is 4 bytes.
+	NetIDIPv4 NetworkID = 1
+
+	// NetIDIPv6 identifies an IPv6 address which is 16 bytes.
+	NetIDIPv6 NetworkID = 2
+
+	// NetIDTorV2 identifies a deprecated Tor v2 onion service address which
+	// is 10 bytes.
+	NetIDTorV2 NetworkID = 3
+
+	// NetIDTorV3 identifies a Tor v3 onion service address which is the 32
+	// byte ed25519 public key of the service.
+	NetIDTorV3 NetworkID = 4
+
+	// NetIDI2P identifies an I2P address which is the 32 byte SHA256 hash of
+	// the destination.
+	NetIDI2P NetworkID = 5
+
+	// NetIDCJDNS identifies a CJDNS address which is 16 bytes.
+	NetIDCJDNS NetworkID = 6
+)
+
+// netIDAddrSizes houses the required address size for the known network IDs.
+var netIDAddrSizes = map[NetworkID]int{
+	NetIDIPv4:  4,
+	NetIDIPv6:  16,
+	NetIDTorV2: 10,
+	NetIDTorV3: 32,
+	NetIDI2P:   32,
+	NetIDCJDNS: 16,
+}
+
+// Map of NetworkID values back to their names for pretty printing.
+var netIDStrings = map[NetworkID]string{
+	NetIDIPv4:  "IPv4",
+	NetIDIPv6:  "IPv6",
+	NetIDTorV2: "TorV2",
+	NetIDTorV3: "TorV3",
+	NetIDI2P:   "I2P",
+	NetIDCJDNS: "CJDNS",
+}
+
+// String returns the NetworkID in human-readable form.
+func (id NetworkID) String() string {
+	if s, ok := netIDStrings[id]; ok {
+		return s
+	}
+	return fmt.Sprintf("Unknown NetworkID (%d)", uint8(id))
+}
+
+// AddrSize returns the number of bytes required for addresses of the network
+// and whether or not the network is known.
+func (id NetworkID) AddrSize() (int, bool) {
+	size, ok := netIDAddrSizes[id]
+	return size, ok
+}
+
+// maxNetAddressV2Payload returns the max payload size for a NetAddressV2 based
+// on the protocol version.
+func maxNetAddressV2Payload(pver uint32) uint32 {
+	// Timestamp 4 bytes + services 8 bytes + network id 1 byte + address
+	// size varint + max address size + port 2 bytes.
+	return 4 + 8 + 1 + uint32(VarIntSerializeSize(MaxNetAddressV2Size)) +
+		MaxNetAddressV2Size + 2
+}
+
+// NetAddressV2 defines information about a peer on the network including the
+// time it was last seen, the services it supports, the network it belongs to,
+// its address on that network, and port.
+//
+// Unlike NetAddress, the address is variable length which allows it to
+// represent addresses for networks that are not based on IP such as Tor v3
+// onion services.
+type NetAddressV2 struct {
+	// Last time the address was seen.  This is encoded as a uint32 on the
+	// wire and therefore is limited to 2106.
+	Timestamp time.Time
+
+	// Bitfield which identifies the services supported by the address.
+	Services ServiceFlag
+
+	// NetID identifies the network the address belongs to.
+	NetID NetworkID
+
+	// Addr is the address of the peer on the network identified by NetID.
+	Addr []byte
+
+	// Port the peer is using.  This is encoded in big endian on the wire
+	// which differs from most everything else.
+	Port uint16
+}
+
+// HasService returns whether the specified service is supported by the address.
+func (na *NetAddressV2) HasService(service ServiceFlag) bool {
+	return na.Services&service == service
+}
+
+// AddService adds service as a supported service by the peer generating the
+// message.
+func (na *NetAddressV2) AddService(service ServiceFlag) {
+	na.Services |= service
+}
+
+// NewNetAddressV2 returns a new NetAddressV2 using the provided timestamp,
+// services, network ID, address, and port.  The timestamp is rounded to single
+// second precision.
+func NewNetAddressV2(timestamp time.Time, services ServiceFlag, netID NetworkID,
+	addr []byte, port uint16) *NetAddressV2 {
+
+	// Limit the timestamp to one second precision since the protocol
+	// doesn't support better.
+	return &NetAddressV2{
+		Timestamp: time.Unix(timestamp.Unix(), 0),
+		Services:  services,
+		NetID:     netID,
+		Addr:      addr,
+		Port:      port,
+	}
+}
+
+// checkNetAddressV2Size returns an error when the size of the address of the
+// provided network address does not match the size required by its network.
+func checkNetAddressV2Size(op string, na *NetAddressV2) error {
+	if len(na.Addr) > MaxNetAddressV2Size {
+		msg := fmt.Sprintf("network address is larger than the max allowed "+
+			"size [size %d, max %d]", len(na.Addr), MaxNetAddressV2Size)
+		return messageError(op, ErrVarBytesTooLong, msg)
+	}
+	if size, ok := na.NetID.AddrSize(); ok && len(na.Addr) != size {
+		msg := fmt.Sprintf("%v network address is %d bytes instead of the "+
+			"required %d bytes", na.NetID, len(na.Addr), size)
+		return messageError(op, ErrInvalidNetAddrSize, msg)
+	}
+	return nil
+}
+
+// readNetAddressV2 reads an encoded NetAddressV2 from r depending on the
+// protocol version.
+func readNetAddressV2(r io.Reader, pver uint32, na *NetAddressV2) error {
+	const op = "readNetAddressV2"
+	var netID uint8
+	err := readElements(r, (*uint32Time)(&na.Timestamp), &na.Services, &netID)
+	if err != nil {
+		return err
+	}
+	na.NetID = NetworkID(netID)
+
+	na.Addr, err = ReadVarBytes(r, pver, MaxNetAddressV2Size,
+		"network address")
+	if err != nil {
+		return err
+	}
+	if err := checkNetAddressV2Size(op, na); err != nil {
+		return err
+	}
+
+	na.Port, err = binarySerializer.Uint16(r, bigEndian)
+	return err
+}
+
+// writeNetAddressV2 serializes a NetAddressV2 to w depending on the protocol
+// version.
+func writeNetAddressV2(w io.Writer, pver uint32, na *NetAddressV2) error {
+	const op = "writeNetAddressV2"
+	if err := checkNetAddressV2Size(op, na); err != nil {
+		return err
+	}
+
+	err := writeElements(w, uint32(na.Timestamp.Unix()), na.Services,
+		uint8(na.NetID))
+	if err != nil {
+		return err
+	}
+	if err := WriteVarBytes(w, pver, na.Addr); err != nil {
+		return err
+	}
+	return binary.Write(w, bigEndian, na.Port)
+}
@@ -0,0 +1,277 @@
+// Copyright (c) 2024 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+// TestNetAddressV2 tests the NetAddressV2 API.
+func TestNetAddressV2(t *testing.T) {
+	addr := bytes.Repeat([]byte{0x01}, 32)
+	timestamp := time.Unix(0x495fab29, 500)
+	na := NewNetAddressV2(timestamp, 0, NetIDTorV3, addr, 9108)
+
+	// Ensure we get the same fields back out with the timestamp rounded to
+	// second precision.
+	if !na.Timestamp.Equal(time.Unix(0x495fab29, 0)) {
+		t.Errorf("NewNetAddressV2: wrong timestamp - got %v, want %v",
+			na.Timestamp, time.Unix(0x495fab29, 0))
+	}
+	if na.NetID != NetIDTorV3 {
+		t.Errorf("NewNetAddressV2: wrong network id - got %v, want %v",
+			na.NetID, NetIDTorV3)
+	}
+	if !bytes.Equal(na.Addr, addr) {
+		t.Errorf("NewNetAddressV2: wrong addr - got %x, want %x", na.Addr,
+			addr)
+	}
+	if na.Port != 9108 {
+		t.Errorf("NewNetAddressV2: wrong port - got %v, want %v", na.Port,
+			9108)
+	}
+	if na.HasService(SFNodeNetwork) {
+		t.Errorf("HasService: SFNodeNetwork service is set")
+	}
+
+	// Ensure adding the full service node flag works.
+	na.AddService(SFNodeNetwork)
+	if !na.HasService(SFNodeNetwork) {
+		t.Errorf("HasService: SFNodeNetwork service not set")
+	}
+
+	// Ensure max payload is expected value for latest protocol version.
+	pver := ProtocolVersion
+	wantPayload := uint32(530)
+	maxPayload := maxNetAddressV2Payload(pver)
+	if maxPayload != wantPayload {
+		t.Errorf("maxNetAddressV2Payload: wrong max payload length for "+
+			"protocol version %d - got %v, want %v", pver,
+			maxPayload, wantPayload)
+	}
+
+	// Ensure the network ids have the expected sizes and names.
+	tests := []struct {
+		netID NetworkID
+		size  int
+		known bool
+		str   string
+	}{
+		{NetIDIPv4, 4, true, "IPv4"},
+		{NetIDIPv6, 16, true, "IPv6"},
+		{NetIDTorV2, 10, true, "TorV2"},
+		{NetIDTorV3, 32, true, "TorV3"},
+		{NetIDI2P, 32, true, "I2P"},
+		{NetIDCJDNS, 16, true, "CJDNS"},
+		{0xff, 0, false, "Unknown NetworkID (255)"},
+	}
+	for _, test := range tests {
+		size, known := test.netID.AddrSize()
+		if size != test.size || known != test.known {
+			t.Errorf("AddrSize(%v): got (%d, %v), want (%d, %v)",
+				test.netID, size, known, test.size, test.known)
+		}
+		if str := test.netID.String(); str != test.str {
+			t.Errorf("String: got %q, want %q", str, test.str)
+		}
+	}
+}
+
+// TestNetAddressV2Wire tests the NetAddressV2 wire encode and decode for
+// various network types.
+func TestNetAddressV2Wire(t *testing.T) {
+	torV3Addr := bytes.Repeat([]byte{0xab}, 32)
+	tests := []struct {
+		name string       // test description
+		in   NetAddressV2 // NetAddressV2 to encode
+		buf  []byte       // Wire encoding
+	}{{
+		name: "ipv4",
+		in: NetAddressV2{
+			Timestamp: time.Unix(0x495fab29, 0),
+			Services:  SFNodeNetwork,
+			NetID:     NetIDIPv4,
+			Addr:      []byte{0x7f, 0x00, 0x00, 0x01},
+			Port:      8333,
+		},
+		buf: []byte{
+			0x29, 0xab, 0x5f, 0x49, // Timestamp
+			0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // SFNodeNetwork
+			0x01,                   // Network id
+			0x04,                   // Varint for address size
+			0x7f, 0x00, 0x00, 0x01, // IP 127.0.0.1
+			0x20, 0x8d, // Port 8333 in big-endian
+		},
+	}, {
+		name: "tor v3",
+		in: NetAddressV2{
+			Timestamp: time.Unix(0x495fab29, 0),
+			Services:  SFNodeNetwork,
+			NetID:     NetIDTorV3,
+			Addr:      torV3Addr,
+			Port:      9108,
+		},
+		buf: append(append([]byte{
+			0x29, 0xab, 0x5f, 0x49, // Timestamp
+			0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // SFNodeNetwork
+			0x04, // Network id
+			0x20, // Varint for address size
+		}, torV3Addr...), 0x23, 0x94), // Port 9108 in big-endian
+	}, {
+		name: "unknown network",
+		in: NetAddressV2{
+			Timestamp: time.Unix(0x495fab29, 0),
+			Services:  0,
+			NetID:     0xfe,
+			Addr:      []byte{0x01, 0x02, 0x03},
+			Port:      1,
+		},
+		buf: []byte{
+			0x29, 0xab, 0x5f, 0x49, // Timestamp
+			0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Services
+			0xfe,             // Network id
+			0x03,             // Varint for address size
+			0x01, 0x02, 0x03, // Address
+			0x00, 0x01, // Port 1 in big-endian
+		},
+	}}
+
+	pver := ProtocolVersion
+	var buf bytes.Buffer
+	for _, test := range tests {
+		buf.Reset()
+		// Encode to wire format.
+		err := writeNetAddressV2(&buf, pver, &test.in)
+		if err != nil {
+			t.Errorf("%q: writeNetAddressV2 error %v", test.name, err)
+			continue
+		}
+		if !bytes.Equal(buf.Bytes(), test.buf) {
+			t.Errorf("%q: writeNetAddressV2\n got: %s want: %s", test.name,
+				spew.Sdump(buf.Bytes()), spew.Sdump(test.buf))
+			continue
+		}
+
+		// Decode the message from wire format.
+		var na NetAddressV2
+		rbuf := bytes.NewReader(test.buf)
+		err = readNetAddressV2(rbuf, pver, &na)
+		if err != nil {
+			t.Errorf("%q: readNetAddressV2 error %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(na, test.in) {
+			t.Errorf("%q: readNetAddressV2\n got: %s want: %s", test.name,
+				spew.Sdump(na), spew.Sdump(test.in))
+			continue
+		}
+	}
+}
+
+// TestNetAddressV2WireErrors performs negative tests against wire encode and
+// decode NetAddressV2 to confirm error paths work correctly.
+func TestNetAddressV2WireErrors(t *testing.T) {
+	pver := ProtocolVersion
+
+	baseNetAddr := &NetAddressV2{
+		Timestamp: time.Unix(0x495fab29, 0),
+		Services:  SFNodeNetwork,
+		NetID:     NetIDIPv4,
+		Addr:      []byte{0x7f, 0x00, 0x00, 0x01},
+		Port:      8333,
+	}
+	baseNetAddrEncoded := []byte{
+		0x29, 0xab, 0x5f, 0x49, // Timestamp
+		0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // SFNodeNetwork
+		0x01,                   // Network id
+		0x04,                   // Varint for address size
+		0x7f, 0x00, 0x00, 0x01, // IP 127.0.0.1
+		0x20, 0x8d, // Port 8333 in big-endian
+	}
+
+	// Network address with an address size that does not match its network.
+	badSizeNetAddr := &NetAddressV2{
+		Timestamp: time.Unix(0x495fab29, 0),
+		NetID:     NetIDTorV3,
+		Addr:      []byte{0x01, 0x02},
+	}
+	badSizeNetAddrEncoded := []byte{
+		0x29, 0xab, 0x5f, 0x49, // Timestamp
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Services
+		0x04,       // Network id
+		0x02,       // Varint for address size
+		0x01, 0x02, // Address
+		0x00, 0x00, // Port
+	}
+
+	// Network address with an address that exceeds the max allowed size.
+	tooLongNetAddr := &NetAddressV2{
+		Timestamp: time.Unix(0x495fab29, 0),
+		NetID:     0xfe,
+		Addr:      make([]byte, MaxNetAddressV2Size+1),
+	}
+	tooLongNetAddrEncoded := []byte{
+		0x29, 0xab, 0x5f, 0x49, // Timestamp
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Services
+		0xfe,             // Network id
+		0xfd, 0x01, 0x02, // Varint for address size (513)
+	}
+
+	tests := []struct {
+		in       *NetAddressV2 // Value to encode
+		buf      []byte        // Wire encoding
+		max      int           // Max size of fixed buffer to induce errors
+		writeErr error         // Expected write error
+		readErr  error         // Expected read error
+	}{
+		// Force errors on timestamp.
+		{baseNetAddr, baseNetAddrEncoded, 0, io.ErrShortWrite, io.EOF},
+		// Force errors on services.
+		{baseNetAddr, baseNetAddrEncoded, 4, io.ErrShortWrite, io.EOF},
+		// Force errors on network id.
+		{baseNetAddr, baseNetAddrEncoded, 12, io.ErrShortWrite, io.EOF},
+		// Force errors on address size.
+		{baseNetAddr, baseNetAddrEncoded, 13, io.ErrShortWrite, io.EOF},
+		// Force errors on address.
+		{baseNetAddr, baseNetAddrEncoded, 14, io.ErrShortWrite, io.EOF},
+		// Force errors on port.
+		{baseNetAddr, baseNetAddrEncoded, 18, io.ErrShortWrite, io.EOF},
+		// Force errors on address size that does not match the network.
+		{badSizeNetAddr, badSizeNetAddrEncoded, len(badSizeNetAddrEncoded),
+			ErrInvalidNetAddrSize, ErrInvalidNetAddrSize},
+		// Force errors on address that exceeds the max allowed size.
+		{tooLongNetAddr, tooLongNetAddrEncoded, len(tooLongNetAddrEncoded),
+			ErrVarBytesTooLong, ErrVarBytesTooLong},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		// Encode to wire format.
+		w := newFixedWriter(test.max)
+		err := writeNetAddressV2(w, pver, test.in)
+		if !errors.Is(err, test.writeErr) {
+			t.Errorf("writeNetAddressV2 #%d wrong error got: %v, want: %v",
+				i, err, test.writeErr)
+			continue
+		}
+
+		// Decode from wire format.
+		var na NetAddressV2
+		r := newFixedReader(test.max, test.buf)
+		err = readNetAddressV2(r, pver, &na)
+		if !errors.Is(err, test.readErr) {
+			t.Errorf("readNetAddressV2 #%d wrong error got: %v, want: %v",
+				i, err, test.readErr)
+			continue
+		}
+	}
+}
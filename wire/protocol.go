@@ -1,5 +1,5 @@
 // Copyright (c) 2013-2016 The btcsuite developers
-// Copyright (c) 2015-2021 The Decred developers
+// Copyright (c) 2015-2024 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
@@ -17,10 +17,11 @@ const (
 	InitialProcotolVersion uint32 = 1
 
 	// ProtocolVersion is the latest protocol version this package supports.
-	ProtocolVersion uint32 = 9
+	ProtocolVersion uint32 = 13
 
 	// NodeBloomVersion is the protocol version which added the SFNodeBloom
-	// service flag (unused).
+	// service flag.  Note that the messages needed to make use of it were not
+	// added until BloomFilterVersion.
 	NodeBloomVersion uint32 = 2
 
 	// SendHeadersVersion is the protocol version which added a new
@@ -51,6 +52,24 @@ const (
 	// RemoveRejectVersion is the protocol version which removes support for the
 	// reject message.
 	RemoveRejectVersion uint32 = 9
+
+	// CompactBlocksVersion is the protocol version which adds the sendcmpct,
+	// cmpctblock, getblocktxn, and blocktxn messages.
+	CompactBlocksVersion uint32 = 10
+
+	// AddrV2Version is the protocol version which adds the addrv2 message
+	// which supports variable length network addresses such as Tor v3, I2P,
+	// and CJDNS addresses.
+	AddrV2Version uint32 = 11
+
+	// BloomFilterVersion is the protocol version which adds the filterload,
+	// filteradd, filterclear, and merkleblock messages that are served by
+	// peers which advertise the SFNodeBloom service flag.
+	BloomFilterVersion uint32 = 12
+
+	// CFHeadersV2Version is the protocol version which adds the getcfhdrsv2
+	// and cfheadersv2 messages.
+	CFHeadersV2Version uint32 = 13
 )
 
 // ServiceFlag identifies services supported by a Decred peer.
@@ -67,13 +86,19 @@ const (
 	// SFNodeCF is a flag used to indicate a peer supports v1 gcs filters
 	// (CFs).
 	SFNodeCF
+
+	// SFNodeEncryptedTransport is a flag used to indicate a peer supports
+	// upgrading connections to the encrypted and authenticated transport
+	// after the version messages are exchanged.
+	SFNodeEncryptedTransport
 )
 
 // Map of service flags back to their constant names for pretty printing.
 var sfStrings = map[ServiceFlag]string{
-	SFNodeNetwork: "SFNodeNetwork",
-	SFNodeBloom:   "SFNodeBloom",
-	SFNodeCF:      "SFNodeCF",
+	SFNodeNetwork:            "SFNodeNetwork",
+	SFNodeBloom:              "SFNodeBloom",
+	SFNodeCF:                 "SFNodeCF",
+	SFNodeEncryptedTransport: "SFNodeEncryptedTransport",
 }
 
 // orderedSFStrings is an ordered list of service flags from highest to
@@ -82,6 +107,7 @@ var orderedSFStrings = []ServiceFlag{
 	SFNodeNetwork,
 	SFNodeBloom,
 	SFNodeCF,
+	SFNodeEncryptedTransport,
 }
 
 // String returns the ServiceFlag in human-readable form.